@@ -0,0 +1,133 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// sessionAnnotationsFile is the name of the annotations file in a session's
+// state directory. Like SessionConfig.Metadata, annotations are kept by the
+// SDK next to the session's event log, because the runtime has no place for
+// them. The file maps turn IDs to annotations.
+const sessionAnnotationsFile = "sdk-annotations.json"
+
+// Annotate sets the annotation key of the turn turnID to value, for UI
+// overlays such as "escalated to human" or a ticket link that should stay
+// with the conversation. An empty value removes the annotation. turnID is
+// the ID of the user message that started the turn: [TurnStartInfo.TurnID],
+// or the ID of a user [Message]. Annotations are returned on the turn's
+// user message by [Session.GetMessages] and [Session.Messages], are
+// included in [Session.Export] transcripts, and are deleted with the
+// session. Like [SessionConfig.Metadata], they require the runtime's
+// session store to be on a filesystem this process can reach.
+//
+// It returns [ErrMessageNotFound] if turnID is not a user message of the
+// session.
+//
+// Example:
+//
+//	session.Annotate(ctx, turnID, "escalation", "https://tickets.example.com/T-1234")
+func (s *Session) Annotate(ctx context.Context, turnID, key, value string) error {
+	if key == "" {
+		return errors.New("annotation key must not be empty")
+	}
+	if s.owner == nil {
+		return errors.New("session has no client")
+	}
+	messages, err := s.GetMessages(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(messages, func(m Message) bool { return m.ID == turnID && m.Role == MessageRoleUser }) {
+		return fmt.Errorf("%w: %q", ErrMessageNotFound, turnID)
+	}
+
+	s.annotationsMu.Lock()
+	defer s.annotationsMu.Unlock()
+	annotations, err := s.owner.loadSessionAnnotations(ctx, s.SessionID)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		delete(annotations[turnID], key)
+		if len(annotations[turnID]) == 0 {
+			delete(annotations, turnID)
+		}
+	} else {
+		if annotations == nil {
+			annotations = make(map[string]map[string]string)
+		}
+		if annotations[turnID] == nil {
+			annotations[turnID] = make(map[string]string)
+		}
+		annotations[turnID][key] = value
+	}
+	return s.owner.saveSessionAnnotations(ctx, s.SessionID, annotations)
+}
+
+// Annotations returns the session's turn annotations, keyed by turn ID and
+// then by annotation key. See [Session.Annotate].
+func (s *Session) Annotations(ctx context.Context) (map[string]map[string]string, error) {
+	if s.owner == nil {
+		return nil, nil
+	}
+	s.annotationsMu.Lock()
+	defer s.annotationsMu.Unlock()
+	return s.owner.loadSessionAnnotations(ctx, s.SessionID)
+}
+
+// saveSessionAnnotations writes the session's annotations, replacing any
+// previously saved ones.
+func (c *Client) saveSessionAnnotations(ctx context.Context, sessionID string, annotations map[string]map[string]string) error {
+	eventFile, err := c.sessionEventFilePath(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return fmt.Errorf("failed to encode session annotations: %w", err)
+	}
+	if err := writeSessionFile(filepath.Dir(eventFile), sessionAnnotationsFile, data); err != nil {
+		return fmt.Errorf("failed to write session annotations: %w", err)
+	}
+	return nil
+}
+
+// loadSessionAnnotations reads the session's annotations. It returns nil if
+// none were saved.
+func (c *Client) loadSessionAnnotations(ctx context.Context, sessionID string) (map[string]map[string]string, error) {
+	eventFile, err := c.sessionEventFilePath(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(eventFile), sessionAnnotationsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session annotations: %w", err)
+	}
+	var annotations map[string]map[string]string
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("failed to decode session annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+// annotateMessages sets the annotations of each user message. Messages are
+// left without annotations if they cannot be read, so an unreachable
+// session store does not fail reading the history.
+func (s *Session) annotateMessages(ctx context.Context, messages []Message) {
+	annotations, _ := s.Annotations(ctx)
+	for i := range messages {
+		if messages[i].Role == MessageRoleUser {
+			messages[i].Annotations = annotations[messages[i].ID]
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestSession_Annotate(t *testing.T) {
+	stateDir := t.TempDir()
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(testConversation), nil
+		},
+		"sessions.getEventFilePath": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req rpc.SessionsGetEventFilePathRequest
+			json.Unmarshal(params, &req)
+			result, _ := json.Marshal(rpc.SessionsGetEventFilePathResult{FilePath: filepath.Join(stateDir, req.SessionID, "events.jsonl")})
+			return result, nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	session.owner = client
+	t.Cleanup(func() { close(session.done) })
+
+	t.Run("returns annotations on the turn's user message and in exports", func(t *testing.T) {
+		if err := session.Annotate(t.Context(), "u1", "escalated", "true"); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+		if err := session.Annotate(t.Context(), "u1", "ticket", "OPS-1"); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+		messages, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("GetMessages failed: %v", err)
+		}
+		want := map[string]string{"escalated": "true", "ticket": "OPS-1"}
+		if !maps.Equal(messages[0].Annotations, want) || messages[len(messages)-1].Annotations != nil {
+			t.Errorf("unexpected annotations %v on u1, %v on the last message", messages[0].Annotations, messages[len(messages)-1].Annotations)
+		}
+
+		transcript, err := session.Export(t.Context())
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if !maps.Equal(transcript.Annotations["u1"], want) {
+			t.Errorf("unexpected exported annotations %v", transcript.Annotations)
+		}
+	})
+
+	t.Run("removes an annotation set to the empty value", func(t *testing.T) {
+		if err := session.Annotate(t.Context(), "u2", "ticket", "OPS-2"); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+		if err := session.Annotate(t.Context(), "u2", "ticket", ""); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+		annotations, err := session.Annotations(t.Context())
+		if err != nil {
+			t.Fatalf("Annotations failed: %v", err)
+		}
+		if _, ok := annotations["u2"]; ok {
+			t.Errorf("expected u2 to have no annotations, got %v", annotations["u2"])
+		}
+	})
+
+	t.Run("rejects IDs that are not user messages", func(t *testing.T) {
+		if err := session.Annotate(t.Context(), "a1", "k", "v"); !errors.Is(err, ErrMessageNotFound) {
+			t.Errorf("expected ErrMessageNotFound, got %v", err)
+		}
+	})
+}
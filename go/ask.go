@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AskOption configures [Ask].
+type AskOption func(*askConfig)
+
+type askConfig struct {
+	client        *Client
+	clientOptions *ClientOptions
+	session       SessionConfig
+}
+
+// WithModel sets the model [Ask] uses.
+func WithModel(model string) AskOption {
+	return func(c *askConfig) { c.session.Model = model }
+}
+
+// WithClientOptions sets the options of the client [Ask] starts.
+func WithClientOptions(options *ClientOptions) AskOption {
+	return func(c *askConfig) { c.clientOptions = options }
+}
+
+// WithClient makes [Ask] use a client the caller manages instead of
+// starting and stopping one.
+func WithClient(client *Client) AskOption {
+	return func(c *askConfig) { c.client = client }
+}
+
+// WithSessionConfig sets the configuration of the session [Ask] creates. Ask
+// sets AvailableTools to no tools if the config does not set it.
+func WithSessionConfig(config *SessionConfig) AskOption {
+	return func(c *askConfig) {
+		model := c.session.Model
+		c.session = *config
+		if c.session.Model == "" {
+			c.session.Model = model
+		}
+	}
+}
+
+// Ask sends prompt to a new session with no tools and returns the
+// assistant's answer. It starts a client, creates the session, waits for the
+// answer, then deletes the session and stops the client, which makes it
+// suited to scripts and smoke tests. Use [Client] and [Session] for
+// conversations, tools and streaming.
+//
+// Ask waits as long as ctx allows, or 60 seconds if ctx has no deadline.
+//
+// Example:
+//
+//	answer, err := copilot.Ask(ctx, "What is 2+2?", copilot.WithModel("gpt-5"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(answer)
+func Ask(ctx context.Context, prompt string, options ...AskOption) (_ string, err error) {
+	var config askConfig
+	for _, option := range options {
+		option(&config)
+	}
+	if config.session.AvailableTools == nil {
+		config.session.AvailableTools = []string{}
+	}
+
+	client := config.client
+	if client == nil {
+		clientOptions := config.clientOptions
+		if clientOptions == nil {
+			clientOptions = &ClientOptions{}
+		}
+		client = NewClient(clientOptions)
+		if err := client.Start(ctx); err != nil {
+			return "", fmt.Errorf("failed to start client: %w", err)
+		}
+		defer func() {
+			if stopErr := client.Stop(); stopErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to stop client: %w", stopErr))
+			}
+		}()
+	}
+
+	session, err := client.CreateSession(ctx, &config.session)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	defer func() {
+		// The session is of no use after Ask returns, so its data is
+		// deleted rather than kept for resuming.
+		if disconnectErr := session.Disconnect(); disconnectErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to disconnect session: %w", disconnectErr))
+			return
+		}
+		if deleteErr := client.DeleteSession(context.WithoutCancel(ctx), session.SessionID); deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to delete session: %w", deleteErr))
+		}
+	}()
+
+	response, err := session.SendAndWaitResponse(ctx, MessageOptions{Prompt: prompt})
+	if err != nil {
+		return "", err
+	}
+	if response.Message == nil {
+		return "", errors.New("session became idle without an answer")
+	}
+	return response.Content, nil
+}
@@ -0,0 +1,81 @@
+package copilot
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestAsk(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created map[string]any
+		calls   []string
+		client  *Client
+	)
+	record := func(method string) {
+		mu.Lock()
+		calls = append(calls, method)
+		mu.Unlock()
+	}
+	client = startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			record("session.create")
+			mu.Lock()
+			json.Unmarshal(params, &created)
+			mu.Unlock()
+			result, _ := json.Marshal(map[string]any{"sessionId": created["sessionId"]})
+			return result, nil
+		},
+		"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			record("session.send")
+			var p struct {
+				SessionID string `json:"sessionId"`
+			}
+			json.Unmarshal(params, &p)
+			client.sessionsMux.Lock()
+			session := client.sessions[p.SessionID]
+			client.sessionsMux.Unlock()
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "a1", Content: "4"}})
+			session.dispatchEvent(newTestEvent())
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+		"session.destroy": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			record("session.destroy")
+			return []byte(`{}`), nil
+		},
+		"session.delete": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			record("session.delete")
+			return []byte(`{"success":true}`), nil
+		},
+	})
+
+	answer, err := Ask(t.Context(), "What is 2+2?", WithClient(client), WithModel("gpt-5"))
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if answer != "4" {
+		t.Errorf("expected the answer %q, got %q", "4", answer)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	t.Run("creates a session with no tools", func(t *testing.T) {
+		if tools, ok := created["availableTools"].([]any); !ok || len(tools) != 0 || created["model"] != "gpt-5" {
+			t.Errorf("unexpected session.create params %v", created)
+		}
+	})
+
+	t.Run("disconnects and deletes the session", func(t *testing.T) {
+		want := []string{"session.create", "session.send", "session.destroy", "session.delete"}
+		if !slices.Equal(calls, want) {
+			t.Errorf("expected calls %v, got %v", want, calls)
+		}
+		if len(client.sessions) != 0 {
+			t.Errorf("expected no open sessions, got %d", len(client.sessions))
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package copilot
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// BytesAttachment returns a blob attachment holding data, for content the
+// application has in memory, such as an uploaded file or a generated
+// report, so it need not be written to disk first. When mimeType is empty
+// it is detected from the data with [http.DetectContentType].
+//
+// Example:
+//
+//	_, err := session.Send(ctx, copilot.MessageOptions{
+//		Prompt:      "Summarize this report",
+//		Attachments: []copilot.Attachment{copilot.BytesAttachment("report.csv", report, "text/csv")},
+//	})
+func BytesAttachment(displayName string, data []byte, mimeType string) *AttachmentBlob {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	blob := &AttachmentBlob{Data: &encoded, MIMEType: mimeType}
+	if displayName != "" {
+		blob.DisplayName = &displayName
+	}
+	return blob
+}
+
+// ReaderAttachment reads r to the end and returns a blob attachment holding
+// its content, like [BytesAttachment]. The whole content is sent inline with
+// the message, so r should be of a size the model can take.
+//
+// Example:
+//
+//	file, header, err := r.FormFile("upload")
+//	if err != nil {
+//		return err
+//	}
+//	defer file.Close()
+//	attachment, err := copilot.ReaderAttachment(header.Filename, file, header.Header.Get("Content-Type"))
+//	if err != nil {
+//		return err
+//	}
+func ReaderAttachment(displayName string, r io.Reader, mimeType string) (*AttachmentBlob, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", displayName, err)
+	}
+	return BytesAttachment(displayName, data, mimeType), nil
+}
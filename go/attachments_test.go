@@ -0,0 +1,49 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBytesAttachment(t *testing.T) {
+	t.Run("encodes the data as a blob", func(t *testing.T) {
+		blob := BytesAttachment("report.csv", []byte("a,b\n1,2\n"), "text/csv")
+		encoded, err := json.Marshal(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"type":"blob","data":"YSxiCjEsMgo=","displayName":"report.csv","mimeType":"text/csv"}`
+		if string(encoded) != want {
+			t.Errorf("got  %s\nwant %s", encoded, want)
+		}
+	})
+
+	t.Run("detects the MIME type", func(t *testing.T) {
+		png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		if blob := BytesAttachment("", png, ""); blob.MIMEType != "image/png" || blob.DisplayName != nil {
+			t.Errorf("unexpected blob %+v", blob)
+		}
+	})
+}
+
+func TestReaderAttachment(t *testing.T) {
+	t.Run("reads the content", func(t *testing.T) {
+		blob, err := ReaderAttachment("notes.txt", strings.NewReader("hello"), "text/plain")
+		if err != nil {
+			t.Fatalf("ReaderAttachment failed: %v", err)
+		}
+		if *blob.Data != "aGVsbG8=" || blob.MIMEType != "text/plain" {
+			t.Errorf("unexpected blob %+v", blob)
+		}
+	})
+
+	t.Run("returns read errors", func(t *testing.T) {
+		readErr := errors.New("upload interrupted")
+		if _, err := ReaderAttachment("notes.txt", iotest.ErrReader(readErr), ""); !errors.Is(err, readErr) {
+			t.Errorf("expected the read error, got %v", err)
+		}
+	})
+}
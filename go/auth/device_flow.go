@@ -0,0 +1,266 @@
+// Package auth signs users in to GitHub for Copilot SDK applications.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAccessDenied is returned by [DeviceFlow] when the user declines to
+	// authorize the application.
+	ErrAccessDenied = errors.New("user denied access")
+	// ErrExpiredToken is returned by [DeviceFlow] when the user code expires
+	// before the user enters it.
+	ErrExpiredToken = errors.New("device code expired")
+)
+
+// DeviceCode is what the user needs to authorize the application: a code
+// to enter at a URL.
+type DeviceCode struct {
+	// UserCode is the code the user enters, such as "WDJB-MJHT".
+	UserCode string
+	// VerificationURI is where the user enters the code, such as
+	// "https://github.com/login/device".
+	VerificationURI string
+	// ExpiresAt is when the code expires.
+	ExpiresAt time.Time
+}
+
+// DeviceFlowOptions configures [DeviceFlow].
+type DeviceFlowOptions struct {
+	// Scopes are the OAuth scopes to request. GitHub Apps ignore them: their
+	// tokens have the app's permissions.
+	Scopes []string
+	// Host is the GitHub host to sign in to, such as
+	// "https://github.example.com". Defaults to "https://github.com".
+	Host string
+	// HTTPClient sends the requests. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+	// OnCode is called with the code for the user to enter, to display it.
+	// It returns immediately; DeviceFlow then waits for the user. Defaults
+	// to printing the code and URL to standard error.
+	OnCode func(code DeviceCode)
+}
+
+// Token is a user access token from [DeviceFlow].
+type Token struct {
+	// AccessToken is the token, for the GitHubToken of the client options
+	// or of a session config.
+	AccessToken string
+	TokenType   string
+	// Scope lists the granted scopes, separated by commas.
+	Scope string
+	// ExpiresAt is when AccessToken expires, or the zero time if it does
+	// not. GitHub App user tokens expire unless token expiration is turned
+	// off for the app.
+	ExpiresAt time.Time
+	// RefreshToken gets a new access token after ExpiresAt, and
+	// RefreshTokenExpiresAt is when it expires. Both are empty for tokens
+	// that do not expire.
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// DeviceFlow signs a user in with the OAuth device flow of the GitHub App or
+// OAuth app clientID, for applications without a browser redirect, such as
+// CLIs. It gets a code for the user to enter on GitHub, passes it to
+// [DeviceFlowOptions.OnCode], then polls GitHub until the user authorizes
+// the application, declines, or the code expires. It polls at the interval
+// GitHub asks for, slowing down when asked to, and stops when ctx is done.
+//
+// The device flow must be enabled in the app's settings.
+//
+// Example:
+//
+//	token, err := auth.DeviceFlow(ctx, "Iv1.0123456789abcdef", &auth.DeviceFlowOptions{
+//	    OnCode: func(code auth.DeviceCode) {
+//	        fmt.Printf("Enter %s at %s\n", code.UserCode, code.VerificationURI)
+//	    },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client := copilot.NewClient(&copilot.ClientOptions{GitHubToken: token.AccessToken})
+func DeviceFlow(ctx context.Context, clientID string, opts *DeviceFlowOptions) (*Token, error) {
+	if opts == nil {
+		opts = &DeviceFlowOptions{}
+	}
+	if clientID == "" {
+		return nil, errors.New("clientID is required")
+	}
+	host := strings.TrimSuffix(opts.Host, "/")
+	if host == "" {
+		host = "https://github.com"
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	form := url.Values{"client_id": {clientID}}
+	if len(opts.Scopes) > 0 {
+		form.Set("scope", strings.Join(opts.Scopes, " "))
+	}
+	var code deviceCodeResponse
+	if err := post(ctx, client, host+"/login/device/code", form, &code); err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	if code.Error != "" {
+		return nil, fmt.Errorf("failed to request device code: %s", code.errorMessage())
+	}
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return nil, errors.New("failed to request device code: response has no device_code or user_code")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(code.ExpiresIn) * time.Second)
+	display := opts.OnCode
+	if display == nil {
+		display = func(code DeviceCode) {
+			fmt.Fprintf(os.Stderr, "To sign in, enter the code %s at %s\n", code.UserCode, code.VerificationURI)
+		}
+	}
+	display(DeviceCode{UserCode: code.UserCode, VerificationURI: code.VerificationURI, ExpiresAt: expiresAt})
+
+	// RFC 8628 defaults the interval to 5 seconds and adds 5 seconds on
+	// every slow_down.
+	interval := 5 * time.Second
+	if code.Interval != nil {
+		interval = time.Duration(*code.Interval) * time.Second
+	}
+	form = url.Values{
+		"client_id":   {clientID},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	for {
+		if err := wait(ctx, interval); err != nil {
+			return nil, err
+		}
+		var token accessTokenResponse
+		if err := post(ctx, client, host+"/login/oauth/access_token", form, &token); err != nil {
+			return nil, fmt.Errorf("failed to poll for access token: %w", err)
+		}
+		switch token.Error {
+		case "":
+			if token.AccessToken == "" {
+				return nil, errors.New("failed to poll for access token: response has no access_token")
+			}
+			return token.token(time.Now()), nil
+		case "authorization_pending":
+		case "slow_down":
+			if token.Interval != nil && time.Duration(*token.Interval)*time.Second > interval {
+				interval = time.Duration(*token.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+		case "access_denied":
+			return nil, ErrAccessDenied
+		case "expired_token":
+			return nil, ErrExpiredToken
+		default:
+			return nil, fmt.Errorf("failed to poll for access token: %s", token.errorMessage())
+		}
+	}
+}
+
+// wait sleeps for d or until ctx is done. It is a variable for tests.
+var wait = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// oauthError is the error GitHub's OAuth endpoints return.
+type oauthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e oauthError) errorMessage() string {
+	if e.ErrorDescription != "" {
+		return e.Error + ": " + e.ErrorDescription
+	}
+	return e.Error
+}
+
+type deviceCodeResponse struct {
+	oauthError
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        *int   `json:"interval"`
+}
+
+type accessTokenResponse struct {
+	oauthError
+	AccessToken           string `json:"access_token"`
+	TokenType             string `json:"token_type"`
+	Scope                 string `json:"scope"`
+	ExpiresIn             int    `json:"expires_in"`
+	RefreshToken          string `json:"refresh_token"`
+	RefreshTokenExpiresIn int    `json:"refresh_token_expires_in"`
+	Interval              *int   `json:"interval"`
+}
+
+func (r *accessTokenResponse) token(now time.Time) *Token {
+	token := &Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		Scope:        r.Scope,
+		RefreshToken: r.RefreshToken,
+	}
+	if r.ExpiresIn > 0 {
+		token.ExpiresAt = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	if r.RefreshTokenExpiresIn > 0 {
+		token.RefreshTokenExpiresAt = now.Add(time.Duration(r.RefreshTokenExpiresIn) * time.Second)
+	}
+	return token
+}
+
+// post sends form to endpoint and decodes the JSON response into result.
+func post(ctx context.Context, client *http.Client, endpoint string, form url.Values, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// GitHub reports most OAuth errors with status 200. Other statuses are
+	// errors too, and carry an OAuth error only sometimes.
+	if resp.StatusCode != http.StatusOK {
+		var e oauthError
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
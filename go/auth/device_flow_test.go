@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGitHub serves the device flow endpoints, answering polls with
+// responses in turn.
+func fakeGitHub(t *testing.T, responses ...string) *httptest.Server {
+	t.Helper()
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "Iv1.test" || r.Header.Get("Accept") != "application/json" {
+			t.Errorf("unexpected request %v %v", r.Form, r.Header)
+		}
+		switch r.URL.Path {
+		case "/login/device/code":
+			if r.Form.Get("scope") != "read:user repo" {
+				t.Errorf("unexpected scope %q", r.Form.Get("scope"))
+			}
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"WDJB-MJHT","verification_uri":"https://github.com/login/device","expires_in":900,"interval":5}`)
+		case "/login/oauth/access_token":
+			if r.Form.Get("device_code") != "dc" || r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" {
+				t.Errorf("unexpected poll %v", r.Form)
+			}
+			fmt.Fprint(w, responses[polls])
+			polls++
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recordWaits replaces wait for the test, recording the intervals instead of
+// sleeping.
+func recordWaits(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	original := wait
+	wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { wait = original })
+	return &waits
+}
+
+func TestDeviceFlow(t *testing.T) {
+	t.Run("polls until the user authorizes, slowing down when asked", func(t *testing.T) {
+		waits := recordWaits(t)
+		server := fakeGitHub(t,
+			`{"error":"authorization_pending"}`,
+			`{"error":"slow_down","interval":10}`,
+			`{"error":"slow_down"}`,
+			`{"access_token":"ghu_abc","token_type":"bearer","expires_in":28800,"refresh_token":"ghr_def","refresh_token_expires_in":15897600}`,
+		)
+		var code DeviceCode
+		token, err := DeviceFlow(t.Context(), "Iv1.test", &DeviceFlowOptions{
+			Host:   server.URL,
+			Scopes: []string{"read:user", "repo"},
+			OnCode: func(c DeviceCode) { code = c },
+		})
+		if err != nil {
+			t.Fatalf("DeviceFlow failed: %v", err)
+		}
+		if code.UserCode != "WDJB-MJHT" || code.VerificationURI != "https://github.com/login/device" || time.Until(code.ExpiresAt) < 14*time.Minute {
+			t.Errorf("unexpected code %+v", code)
+		}
+		if token.AccessToken != "ghu_abc" || token.RefreshToken != "ghr_def" || time.Until(token.ExpiresAt) < 7*time.Hour {
+			t.Errorf("unexpected token %+v", token)
+		}
+		if want := []time.Duration{5 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second}; !slices.Equal(*waits, want) {
+			t.Errorf("expected waits %v, got %v", want, *waits)
+		}
+	})
+
+	t.Run("reports denied and expired codes", func(t *testing.T) {
+		recordWaits(t)
+		for response, want := range map[string]error{
+			`{"error":"access_denied"}`: ErrAccessDenied,
+			`{"error":"expired_token"}`: ErrExpiredToken,
+		} {
+			server := fakeGitHub(t, response)
+			_, err := DeviceFlow(t.Context(), "Iv1.test", &DeviceFlowOptions{Host: server.URL, Scopes: []string{"read:user", "repo"}, OnCode: func(DeviceCode) {}})
+			if !errors.Is(err, want) {
+				t.Errorf("expected %v, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("reports other errors with their description", func(t *testing.T) {
+		recordWaits(t)
+		server := fakeGitHub(t, `{"error":"device_flow_disabled","error_description":"Device Flow must be explicitly enabled for this App"}`)
+		_, err := DeviceFlow(t.Context(), "Iv1.test", &DeviceFlowOptions{Host: server.URL, Scopes: []string{"read:user", "repo"}, OnCode: func(DeviceCode) {}})
+		if err == nil || !strings.Contains(err.Error(), "device_flow_disabled: Device Flow must be explicitly enabled") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		server := fakeGitHub(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		_, err := DeviceFlow(ctx, "Iv1.test", &DeviceFlowOptions{Host: server.URL, Scopes: []string{"read:user", "repo"}, OnCode: func(DeviceCode) { cancel() }})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,422 @@
+package copilot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// BedrockProviderOptions configures a provider of Type "bedrock". See
+// [BedrockProvider].
+type BedrockProviderOptions struct {
+	// Region is the AWS region, such as "us-east-1". Defaults to the
+	// AWS_REGION environment variable, then AWS_DEFAULT_REGION.
+	Region string
+	// Credentials returns the credentials requests are signed with.
+	// Defaults to [DefaultAWSCredentials]. It is not used when the provider
+	// has a Bedrock API key in APIKey or BearerToken, or in the
+	// AWS_BEARER_TOKEN_BEDROCK environment variable.
+	Credentials AWSCredentialsProvider
+	// ModelIDs maps the model names the session uses to Bedrock model IDs
+	// or inference profile IDs, such as
+	// "us.anthropic.claude-sonnet-4-5-20250929-v1:0". Names without an
+	// entry are used as the Bedrock model ID.
+	ModelIDs map[string]string
+	// Endpoint overrides the Bedrock runtime endpoint, such as a VPC
+	// endpoint. Defaults to https://bedrock-runtime.<Region>.amazonaws.com.
+	Endpoint string
+}
+
+// BedrockProvider configures Amazon Bedrock as a BYOK provider, for Claude,
+// Llama and other models hosted there. Use [BedrockProvider.Config] to get
+// the [ProviderConfig] for [SessionConfig.Provider].
+//
+// Claude models (Bedrock model IDs containing "anthropic.") use Bedrock's
+// Anthropic Messages API; other models use the Converse API. The API is
+// chosen from the session's model when the session is created, so switching
+// a session between Claude and other models is not supported. Requests reach
+// Bedrock through a proxy the SDK runs on the loopback interface, so the
+// runtime must run on the same machine as the SDK, as it does unless the
+// client connects to a remote CLI server.
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Model: "claude-sonnet-4.5",
+//	    Provider: copilot.BedrockProvider{
+//	        Region: "us-west-2",
+//	        ModelIDs: map[string]string{
+//	            "claude-sonnet-4.5": "us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+//	        },
+//	    }.Config(),
+//	})
+type BedrockProvider struct {
+	// Region, Credentials, ModelIDs and Endpoint are as in
+	// [BedrockProviderOptions].
+	Region      string
+	Credentials AWSCredentialsProvider
+	ModelIDs    map[string]string
+	Endpoint    string
+	// APIKey is a Bedrock API key, used instead of signing requests with
+	// AWS credentials.
+	APIKey string
+	// ModelID, WireModel, MaxPromptTokens and MaxOutputTokens are as in
+	// [ProviderConfig]. WireModel is mapped through ModelIDs.
+	ModelID         string
+	WireModel       string
+	MaxPromptTokens int
+	MaxOutputTokens int
+}
+
+// Config returns the provider as a [ProviderConfig].
+func (p BedrockProvider) Config() *ProviderConfig {
+	return &ProviderConfig{
+		Type:   "bedrock",
+		APIKey: p.APIKey,
+		Bedrock: &BedrockProviderOptions{
+			Region:      p.Region,
+			Credentials: p.Credentials,
+			ModelIDs:    p.ModelIDs,
+			Endpoint:    p.Endpoint,
+		},
+		ModelID:         p.ModelID,
+		WireModel:       p.WireModel,
+		MaxPromptTokens: p.MaxPromptTokens,
+		MaxOutputTokens: p.MaxOutputTokens,
+	}
+}
+
+// Validate reports the first problem with the provider's options.
+func (p BedrockProvider) Validate() error {
+	return p.Config().Validate()
+}
+
+// validateBedrockProvider validates a provider of Type "bedrock".
+func validateBedrockProvider(p *ProviderConfig) error {
+	if p.BaseURL != "" {
+		return errors.New(`BaseURL does not apply to Type "bedrock": set Bedrock.Endpoint to override the endpoint`)
+	}
+	if p.Azure != nil || p.Vertex != nil || p.WireAPI != "" || p.Transport != "" {
+		return errors.New(`Azure, Vertex, WireAPI and Transport do not apply to Type "bedrock"`)
+	}
+	if p.BearerTokenProvider != nil {
+		return errors.New(`BearerTokenProvider is not supported for Type "bedrock": set Bedrock.Credentials instead`)
+	}
+	var options BedrockProviderOptions
+	if p.Bedrock != nil {
+		options = *p.Bedrock
+	}
+	if bedrockRegion(options) == "" {
+		return errors.New("Bedrock.Region is required when AWS_REGION is not set")
+	}
+	if options.Endpoint != "" {
+		if err := validateProvider("", options.Endpoint, "", "", nil); err != nil {
+			return fmt.Errorf("invalid Bedrock.Endpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+func bedrockRegion(options BedrockProviderOptions) string {
+	if options.Region != "" {
+		return options.Region
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// bedrockProxy serves the runtime's model requests for one Bedrock provider.
+// The runtime speaks the Anthropic Messages and OpenAI Chat Completions APIs,
+// but not Bedrock's, which is addressed by model in the URL and needs AWS
+// Signature Version 4 authentication. So the runtime is given an "anthropic"
+// or "openai" provider pointing at the proxy, which maps model names to
+// Bedrock model IDs, signs each request and forwards it: Claude models
+// through Bedrock's native Anthropic Messages API, and other models through
+// the Converse API, translating to and from Chat Completions.
+type bedrockProxy struct {
+	region   string
+	endpoint string
+	modelIDs map[string]string
+	// apiKey is the Bedrock API key, if requests are not signed with
+	// credentials.
+	apiKey      string
+	credentials AWSCredentialsProvider
+	client      *http.Client
+	// token authenticates the runtime, so other local processes cannot
+	// use the proxy to make signed requests.
+	token  string
+	server *http.Server
+	url    string
+}
+
+// startBedrockProxy starts the proxy for provider if it is of Type
+// "bedrock", and returns it with the provider config to send to the runtime
+// in its place. model is the session's model. For other providers it
+// returns nil and provider unchanged. The proxy listens on the loopback
+// interface, so Bedrock is refused for a runtime reached over the network.
+func (c *Client) startBedrockProxy(provider *ProviderConfig, model string) (*bedrockProxy, *ProviderConfig, error) {
+	if provider == nil || provider.Type != "bedrock" {
+		return nil, provider, nil
+	}
+	if c.isExternalServer && c.socketPath == "" {
+		return nil, nil, errors.New(`Type "bedrock" is not supported with a URIConnection: the runtime cannot reach the SDK's Bedrock proxy`)
+	}
+	var options BedrockProviderOptions
+	if provider.Bedrock != nil {
+		options = *provider.Bedrock
+	}
+	client, err := c.httpClient(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	token := make([]byte, 16)
+	rand.Read(token)
+	p := &bedrockProxy{
+		region:      bedrockRegion(options),
+		endpoint:    strings.TrimSuffix(options.Endpoint, "/"),
+		modelIDs:    options.ModelIDs,
+		credentials: options.Credentials,
+		client:      client,
+		token:       hex.EncodeToString(token),
+	}
+	if p.endpoint == "" {
+		p.endpoint = "https://bedrock-runtime." + p.region + ".amazonaws.com"
+	}
+	switch {
+	case provider.BearerToken != "":
+		p.apiKey = provider.BearerToken
+	case provider.APIKey != "":
+		p.apiKey = provider.APIKey
+	default:
+		p.apiKey = os.Getenv("AWS_BEARER_TOKEN_BEDROCK")
+	}
+	if p.credentials == nil {
+		p.credentials = DefaultAWSCredentials
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start Bedrock proxy: %w", err)
+	}
+	p.url = "http://" + listener.Addr().String()
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go p.server.Serve(listener)
+
+	wire := *provider
+	wire.Type = "openai"
+	wire.WireAPI = "completions"
+	model = cmp.Or(provider.WireModel, provider.ModelID, model)
+	if strings.Contains(p.modelID(model), "anthropic.") {
+		wire.Type = "anthropic"
+		wire.WireAPI = ""
+	}
+	wire.BaseURL = p.url
+	wire.APIKey = p.token
+	wire.BearerToken = ""
+	wire.Bedrock = nil
+	return p, &wire, nil
+}
+
+// close stops the proxy.
+func (p *bedrockProxy) close() {
+	if p != nil {
+		p.server.Close()
+	}
+}
+
+// modelID maps model to its Bedrock model ID.
+func (p *bedrockProxy) modelID(model string) string {
+	if id, ok := p.modelIDs[model]; ok {
+		return id
+	}
+	return model
+}
+
+func (p *bedrockProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Api-Key") != p.token && r.Header.Get("Authorization") != "Bearer "+p.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/messages"):
+		p.serveMessages(w, r, body)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		p.serveChatCompletions(w, r, body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// invoke sends body to the Bedrock operation (such as "invoke" or
+// "converse-stream") of modelID.
+func (p *bedrockProxy) invoke(ctx context.Context, modelID, operation string, body []byte) (*http.Response, error) {
+	path := "/model/" + awsURIEncode(modelID) + "/" + operation
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	} else {
+		creds, err := p.credentials(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AWS credentials: %w", err)
+		}
+		signAWSRequest(req, body, creds, p.region, "bedrock", time.Now())
+	}
+	return p.client.Do(req)
+}
+
+// bedrockError reads the error of a failed Bedrock response.
+func bedrockError(resp *http.Response) (errorType, message string) {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(data))
+	}
+	errorType, _, _ = strings.Cut(resp.Header.Get("X-Amzn-Errortype"), ":")
+	if errorType == "" {
+		errorType = "api_error"
+	}
+	return errorType, body.Message
+}
+
+// serveMessages serves an Anthropic Messages request through Bedrock's
+// InvokeModel API, which takes the same request body with the model in the
+// URL.
+func (p *bedrockProxy) serveMessages(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeError := func(status int, errorType, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"type":  "error",
+			"error": map[string]string{"type": errorType, "message": message},
+		})
+	}
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	var model string
+	var stream bool
+	json.Unmarshal(request["model"], &model)
+	json.Unmarshal(request["stream"], &stream)
+	// Bedrock takes the model and streaming mode from the URL, and rejects
+	// fields it does not know.
+	for _, field := range []string{"model", "stream", "metadata", "service_tier"} {
+		delete(request, field)
+	}
+	request["anthropic_version"] = json.RawMessage(`"bedrock-2023-05-31"`)
+	if beta := r.Header.Get("Anthropic-Beta"); beta != "" {
+		var betas []string
+		for _, b := range strings.Split(beta, ",") {
+			betas = append(betas, strings.TrimSpace(b))
+		}
+		request["anthropic_beta"], _ = json.Marshal(betas)
+	}
+	body, _ = json.Marshal(request)
+
+	operation := "invoke"
+	if stream {
+		operation = "invoke-with-response-stream"
+	}
+	resp, err := p.invoke(r.Context(), p.modelID(model), operation, body)
+	if err != nil {
+		writeError(http.StatusBadGateway, "api_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		errorType, message := bedrockError(resp)
+		writeError(resp.StatusCode, errorType, message)
+		return
+	}
+	if !stream {
+		w.Header().Set("Content-Type", "application/json")
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	// Each chunk of the event stream carries one Anthropic streaming event,
+	// which is written as a server-sent event.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	for {
+		headers, payload, err := readEventStreamMessage(resp.Body)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err == nil {
+			err = eventStreamException(headers, payload)
+		}
+		var event []byte
+		if err == nil {
+			if headers[":event-type"] != "chunk" {
+				continue
+			}
+			var chunk struct {
+				Bytes string `json:"bytes"`
+			}
+			json.Unmarshal(payload, &chunk)
+			event, err = base64.StdEncoding.DecodeString(chunk.Bytes)
+		}
+		if err != nil {
+			event, _ = json.Marshal(map[string]any{
+				"type":  "error",
+				"error": map[string]string{"type": "api_error", "message": err.Error()},
+			})
+		}
+		var typed struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(event, &typed)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// eventStreamException returns the error of an exception or error message
+// of an event stream, or nil for other messages.
+func eventStreamException(headers map[string]string, payload []byte) error {
+	switch headers[":message-type"] {
+	case "exception":
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(payload, &body)
+		return fmt.Errorf("%s: %s", headers[":exception-type"], body.Message)
+	case "error":
+		return fmt.Errorf("%s: %s", headers[":error-code"], headers[":error-message"])
+	}
+	return nil
+}
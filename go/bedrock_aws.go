@@ -0,0 +1,297 @@
+package copilot
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the credentials used to sign AWS requests. The SDK signs
+// the requests of the Bedrock provider itself, for the small subset of AWS
+// it needs, so it does not depend on the AWS SDK.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+}
+
+// AWSCredentialsProvider returns the credentials to sign a request with. It
+// is called for every request, so it should cache credentials it fetches.
+// See [BedrockProviderOptions.Credentials].
+type AWSCredentialsProvider func(ctx context.Context) (AWSCredentials, error)
+
+// DefaultAWSCredentials returns credentials from the standard AWS sources, in
+// order: the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables, then the AWS_PROFILE profile ("default" if unset)
+// of the shared credentials file (AWS_SHARED_CREDENTIALS_FILE, or
+// ~/.aws/credentials). For other sources, such as SSO or instance roles, set
+// [BedrockProviderOptions.Credentials] to a function backed by the AWS SDK.
+func DefaultAWSCredentials(ctx context.Context) (AWSCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return AWSCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return AWSCredentials{}, errors.New("no AWS credentials found in the environment")
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	creds, err := readSharedAWSCredentials(path, profile)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("no AWS credentials found in the environment or %s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// readSharedAWSCredentials reads profile from the shared credentials file at
+// path.
+func readSharedAWSCredentials(path, profile string) (AWSCredentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	defer f.Close()
+
+	var creds AWSCredentials
+	inProfile := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inProfile || !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return AWSCredentials{}, err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return AWSCredentials{}, fmt.Errorf("profile %q has no access key", profile)
+	}
+	return creds, nil
+}
+
+// signAWSRequest signs req, whose body is body, with AWS Signature Version
+// 4. It signs the host, content type and X-Amz-* headers.
+func signAWSRequest(req *http.Request, body []byte, creds AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.Host}
+	if req.Host == "" {
+		headers["host"] = req.URL.Host
+	}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Except for S3, each path segment is encoded again on top of the
+	// encoding of the request line.
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	canonicalURI := strings.Join(segments, "/")
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var canonicalQuery []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			canonicalQuery = append(canonicalQuery, awsURIEncode(key)+"="+awsURIEncode(value))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode percent-encodes every byte of s except the RFC 3986
+// unreserved characters, as AWS signing requires.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// maxEventStreamMessage bounds the size of an event stream message.
+const maxEventStreamMessage = 16 << 20
+
+// readEventStreamMessage reads one message of the AWS event stream encoding
+// (application/vnd.amazon.eventstream) from r. It returns the message's
+// string headers and its payload, and io.EOF at the end of the stream.
+func readEventStreamMessage(r io.Reader) (map[string]string, []byte, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil, errors.New("truncated event stream message")
+		}
+		return nil, nil, err
+	}
+	total := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, nil, errors.New("event stream prelude checksum mismatch")
+	}
+	if total < 16 || total > maxEventStreamMessage || headersLen > total-16 {
+		return nil, nil, fmt.Errorf("invalid event stream message length %d", total)
+	}
+	message := make([]byte, total)
+	copy(message, prelude[:])
+	if _, err := io.ReadFull(r, message[12:]); err != nil {
+		return nil, nil, errors.New("truncated event stream message")
+	}
+	if crc32.ChecksumIEEE(message[:total-4]) != binary.BigEndian.Uint32(message[total-4:]) {
+		return nil, nil, errors.New("event stream message checksum mismatch")
+	}
+	headers, err := parseEventStreamHeaders(message[12 : 12+headersLen])
+	if err != nil {
+		return nil, nil, err
+	}
+	return headers, message[12+headersLen : total-4], nil
+}
+
+// parseEventStreamHeaders parses event stream headers, keeping those with
+// string values.
+func parseEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	errTruncated := errors.New("truncated event stream headers")
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+1 {
+			return nil, errTruncated
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+		var size int
+		switch valueType {
+		case 0, 1: // true, false
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // integer
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // UUID
+			size = 16
+		case 6, 7: // byte array, string
+			if len(data) < 2 {
+				return nil, errTruncated
+			}
+			n := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+n {
+				return nil, errTruncated
+			}
+			if valueType == 7 {
+				headers[name] = string(data[2 : 2+n])
+			}
+			data = data[2+n:]
+			continue
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, errTruncated
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
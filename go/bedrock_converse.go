@@ -0,0 +1,499 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// chatRequest is an OpenAI Chat Completions request, which the runtime uses
+// for non-Claude models on Bedrock. The bedrockProxy translates it to a
+// converseRequest, and the Converse response back to Chat Completions.
+type chatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []chatMessage   `json:"messages"`
+	Stream              bool            `json:"stream"`
+	Tools               []chatTool      `json:"tools"`
+	ToolChoice          json.RawMessage `json:"tool_choice"`
+	MaxTokens           *int            `json:"max_tokens"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	Stop                json.RawMessage `json:"stop"`
+}
+
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content,omitempty"`
+	ToolCalls  []chatToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+type chatTool struct {
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type chatToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type converseRequest struct {
+	Messages        []converseMessage        `json:"messages"`
+	System          []converseContent        `json:"system,omitempty"`
+	InferenceConfig *converseInferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig      *converseToolConfig      `json:"toolConfig,omitempty"`
+}
+
+type converseMessage struct {
+	Role    string            `json:"role"`
+	Content []converseContent `json:"content"`
+}
+
+type converseContent struct {
+	Text       string              `json:"text,omitempty"`
+	Image      *converseImage      `json:"image,omitempty"`
+	ToolUse    *converseToolUse    `json:"toolUse,omitempty"`
+	ToolResult *converseToolResult `json:"toolResult,omitempty"`
+}
+
+type converseImage struct {
+	Format string `json:"format"`
+	Source struct {
+		Bytes string `json:"bytes"`
+	} `json:"source"`
+}
+
+type converseToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type converseToolResult struct {
+	ToolUseID string            `json:"toolUseId"`
+	Content   []converseContent `json:"content"`
+}
+
+type converseInferenceConfig struct {
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type converseToolConfig struct {
+	Tools      []converseTool  `json:"tools"`
+	ToolChoice json.RawMessage `json:"toolChoice,omitempty"`
+}
+
+type converseTool struct {
+	ToolSpec struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		InputSchema struct {
+			JSON json.RawMessage `json:"json"`
+		} `json:"inputSchema"`
+	} `json:"toolSpec"`
+}
+
+type converseUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u converseUsage) chat() *chatUsage {
+	return &chatUsage{PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens, TotalTokens: u.TotalTokens}
+}
+
+// converseRequestFor translates a Chat Completions request to Converse.
+func converseRequestFor(chat *chatRequest) (*converseRequest, error) {
+	var req converseRequest
+	for _, m := range chat.Messages {
+		switch m.Role {
+		case "system", "developer":
+			text, err := chatText(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			if text != "" {
+				req.System = append(req.System, converseContent{Text: text})
+			}
+		case "user":
+			content, err := chatUserContent(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			req.Messages = appendConverseMessage(req.Messages, "user", content)
+		case "assistant":
+			text, err := chatText(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			var content []converseContent
+			if text != "" {
+				content = append(content, converseContent{Text: text})
+			}
+			for _, call := range m.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage(`{}`)
+				}
+				content = append(content, converseContent{ToolUse: &converseToolUse{ToolUseID: call.ID, Name: call.Function.Name, Input: input}})
+			}
+			req.Messages = appendConverseMessage(req.Messages, "assistant", content)
+		case "tool":
+			text, err := chatText(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			if text == "" {
+				text = "(no output)" // Converse rejects empty text blocks
+			}
+			result := &converseToolResult{ToolUseID: m.ToolCallID, Content: []converseContent{{Text: text}}}
+			req.Messages = appendConverseMessage(req.Messages, "user", []converseContent{{ToolResult: result}})
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", m.Role)
+		}
+	}
+
+	config := converseInferenceConfig{MaxTokens: chat.MaxCompletionTokens, Temperature: chat.Temperature, TopP: chat.TopP}
+	if config.MaxTokens == nil {
+		config.MaxTokens = chat.MaxTokens
+	}
+	if len(chat.Stop) > 0 {
+		var stop string
+		if json.Unmarshal(chat.Stop, &stop) == nil {
+			config.StopSequences = []string{stop}
+		} else {
+			json.Unmarshal(chat.Stop, &config.StopSequences)
+		}
+	}
+	if config.MaxTokens != nil || config.Temperature != nil || config.TopP != nil || config.StopSequences != nil {
+		req.InferenceConfig = &config
+	}
+
+	if len(chat.Tools) > 0 {
+		req.ToolConfig = &converseToolConfig{}
+		for _, t := range chat.Tools {
+			var tool converseTool
+			tool.ToolSpec.Name = t.Function.Name
+			tool.ToolSpec.Description = t.Function.Description
+			tool.ToolSpec.InputSchema.JSON = t.Function.Parameters
+			if len(tool.ToolSpec.InputSchema.JSON) == 0 {
+				tool.ToolSpec.InputSchema.JSON = json.RawMessage(`{"type":"object"}`)
+			}
+			req.ToolConfig.Tools = append(req.ToolConfig.Tools, tool)
+		}
+		var choice string
+		var named struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		}
+		switch {
+		case json.Unmarshal(chat.ToolChoice, &choice) == nil && choice == "required":
+			req.ToolConfig.ToolChoice = json.RawMessage(`{"any":{}}`)
+		case json.Unmarshal(chat.ToolChoice, &named) == nil && named.Function.Name != "":
+			req.ToolConfig.ToolChoice, _ = json.Marshal(map[string]any{"tool": map[string]string{"name": named.Function.Name}})
+		}
+	}
+	return &req, nil
+}
+
+// appendConverseMessage appends a message, merging it into the last one if
+// it has the same role, since Converse requires roles to alternate.
+func appendConverseMessage(messages []converseMessage, role string, content []converseContent) []converseMessage {
+	if len(content) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, content...)
+		return messages
+	}
+	return append(messages, converseMessage{Role: role, Content: content})
+}
+
+// chatParts decodes message content, which is a string or an array of
+// content parts.
+func chatParts(content json.RawMessage) ([]map[string]json.RawMessage, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return nil, nil
+	}
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		if text == "" {
+			return nil, nil
+		}
+		raw, _ := json.Marshal(text)
+		return []map[string]json.RawMessage{{"type": json.RawMessage(`"text"`), "text": raw}}, nil
+	}
+	var parts []map[string]json.RawMessage
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("invalid message content: %w", err)
+	}
+	return parts, nil
+}
+
+// chatText returns the text of message content.
+func chatText(content json.RawMessage) (string, error) {
+	parts, err := chatParts(content)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, part := range parts {
+		var text string
+		if json.Unmarshal(part["text"], &text) == nil && text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// chatUserContent translates the text and image parts of user message
+// content.
+func chatUserContent(content json.RawMessage) ([]converseContent, error) {
+	parts, err := chatParts(content)
+	if err != nil {
+		return nil, err
+	}
+	var result []converseContent
+	for _, part := range parts {
+		var partType, text string
+		json.Unmarshal(part["type"], &partType)
+		switch partType {
+		case "text":
+			if json.Unmarshal(part["text"], &text) == nil && text != "" {
+				result = append(result, converseContent{Text: text})
+			}
+		case "image_url":
+			var image struct {
+				URL string `json:"url"`
+			}
+			json.Unmarshal(part["image_url"], &image)
+			// Converse only takes inline images.
+			mediaType, data, ok := strings.Cut(strings.TrimPrefix(image.URL, "data:"), ";base64,")
+			if !strings.HasPrefix(image.URL, "data:image/") || !ok {
+				return nil, errors.New("only data: URLs are supported for images")
+			}
+			var img converseImage
+			img.Format = strings.TrimPrefix(mediaType, "image/")
+			if img.Format == "jpg" {
+				img.Format = "jpeg"
+			}
+			img.Source.Bytes = data
+			result = append(result, converseContent{Image: &img})
+		}
+	}
+	return result, nil
+}
+
+// chatFinishReason maps a Converse stop reason to a Chat Completions finish
+// reason.
+func chatFinishReason(stopReason string) string {
+	switch stopReason {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens", "model_context_window_exceeded":
+		return "length"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// serveChatCompletions serves a Chat Completions request through Bedrock's
+// Converse API.
+func (p *bedrockProxy) serveChatCompletions(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeError := func(status int, errorType, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]string{"type": errorType, "message": message},
+		})
+	}
+	var chat chatRequest
+	if err := json.Unmarshal(body, &chat); err != nil {
+		writeError(http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	converse, err := converseRequestFor(&chat)
+	if err != nil {
+		writeError(http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	body, _ = json.Marshal(converse)
+
+	operation := "converse"
+	if chat.Stream {
+		operation = "converse-stream"
+	}
+	resp, err := p.invoke(r.Context(), p.modelID(chat.Model), operation, body)
+	if err != nil {
+		writeError(http.StatusBadGateway, "api_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		errorType, message := bedrockError(resp)
+		writeError(resp.StatusCode, errorType, message)
+		return
+	}
+
+	id := "chatcmpl-" + uuid.NewString()
+	created := time.Now().Unix()
+	if !chat.Stream {
+		var result struct {
+			Output struct {
+				Message converseMessage `json:"message"`
+			} `json:"output"`
+			StopReason string        `json:"stopReason"`
+			Usage      converseUsage `json:"usage"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			writeError(http.StatusBadGateway, "api_error", fmt.Sprintf("invalid Converse response: %v", err))
+			return
+		}
+		message := chatMessage{Role: "assistant"}
+		var text strings.Builder
+		for _, c := range result.Output.Message.Content {
+			text.WriteString(c.Text)
+			if c.ToolUse != nil {
+				var call chatToolCall
+				call.ID = c.ToolUse.ToolUseID
+				call.Type = "function"
+				call.Function.Name = c.ToolUse.Name
+				call.Function.Arguments = string(c.ToolUse.Input)
+				message.ToolCalls = append(message.ToolCalls, call)
+			}
+		}
+		message.Content, _ = json.Marshal(text.String())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   chat.Model,
+			"choices": []map[string]any{{"index": 0, "message": message, "finish_reason": chatFinishReason(result.StopReason)}},
+			"usage":   result.Usage.chat(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	emit := func(delta any, finishReason *string, usage *chatUsage) {
+		chunk := map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   chat.Model,
+			"choices": []map[string]any{},
+		}
+		if delta != nil {
+			chunk["choices"] = []map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}}
+		}
+		if usage != nil {
+			chunk["usage"] = usage
+		}
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// toolCalls maps content block indexes to tool call indexes.
+	toolCalls := make(map[int]int)
+	for {
+		headers, payload, err := readEventStreamMessage(resp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = eventStreamException(headers, payload)
+		}
+		if err != nil {
+			data, _ := json.Marshal(map[string]any{"error": map[string]string{"type": "api_error", "message": err.Error()}})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			return
+		}
+		var event struct {
+			ContentBlockIndex int `json:"contentBlockIndex"`
+			Start             struct {
+				ToolUse *converseToolUse `json:"toolUse"`
+			} `json:"start"`
+			Delta struct {
+				Text    *string `json:"text"`
+				ToolUse *struct {
+					Input string `json:"input"`
+				} `json:"toolUse"`
+			} `json:"delta"`
+			StopReason string        `json:"stopReason"`
+			Usage      converseUsage `json:"usage"`
+		}
+		json.Unmarshal(payload, &event)
+		switch headers[":event-type"] {
+		case "messageStart":
+			emit(map[string]any{"role": "assistant", "content": ""}, nil, nil)
+		case "contentBlockStart":
+			if use := event.Start.ToolUse; use != nil {
+				index := len(toolCalls)
+				toolCalls[event.ContentBlockIndex] = index
+				var call chatToolCall
+				call.Index = &index
+				call.ID = use.ToolUseID
+				call.Type = "function"
+				call.Function.Name = use.Name
+				emit(map[string]any{"tool_calls": []chatToolCall{call}}, nil, nil)
+			}
+		case "contentBlockDelta":
+			// Reasoning deltas have no Chat Completions equivalent and are
+			// dropped.
+			switch {
+			case event.Delta.Text != nil:
+				emit(map[string]any{"content": *event.Delta.Text}, nil, nil)
+			case event.Delta.ToolUse != nil:
+				index := toolCalls[event.ContentBlockIndex]
+				var call chatToolCall
+				call.Index = &index
+				call.Function.Arguments = event.Delta.ToolUse.Input
+				emit(map[string]any{"tool_calls": []chatToolCall{call}}, nil, nil)
+			}
+		case "messageStop":
+			reason := chatFinishReason(event.StopReason)
+			emit(map[string]any{}, &reason, nil)
+		case "metadata":
+			emit(nil, nil, event.Usage.chat())
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,369 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// encodeEventStreamMessage encodes an event stream message with string
+// headers.
+func encodeEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var encodedHeaders bytes.Buffer
+	for name, value := range headers {
+		encodedHeaders.WriteByte(byte(len(name)))
+		encodedHeaders.WriteString(name)
+		encodedHeaders.WriteByte(7)
+		binary.Write(&encodedHeaders, binary.BigEndian, uint16(len(value)))
+		encodedHeaders.WriteString(value)
+	}
+	total := 16 + encodedHeaders.Len() + len(payload)
+	message := binary.BigEndian.AppendUint32(nil, uint32(total))
+	message = binary.BigEndian.AppendUint32(message, uint32(encodedHeaders.Len()))
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, encodedHeaders.Bytes()...)
+	message = append(message, payload...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+func bedrockEvent(eventType string, payload any) []byte {
+	data, _ := json.Marshal(payload)
+	return encodeEventStreamMessage(map[string]string{":message-type": "event", ":event-type": eventType}, data)
+}
+
+// fakeBedrock serves canned Bedrock responses and records the requests.
+type fakeBedrock struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]any
+}
+
+func newFakeBedrock(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) *fakeBedrock {
+	f := &fakeBedrock{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r)
+		f.bodies = append(f.bodies, body)
+		f.mu.Unlock()
+		respond(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func startTestBedrockProxy(t *testing.T, provider BedrockProvider, model string) (*bedrockProxy, *ProviderConfig) {
+	t.Helper()
+	proxy, wire, err := NewClient(&ClientOptions{}).startBedrockProxy(provider.Config(), model)
+	if err != nil {
+		t.Fatalf("startBedrockProxy failed: %v", err)
+	}
+	t.Cleanup(proxy.close)
+	return proxy, wire
+}
+
+func postJSON(t *testing.T, url, token, body string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Api-Key", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("unexpected Authorization header\n got: %s\nwant: %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("unexpected X-Amz-Date %q", got)
+	}
+}
+
+func TestReadEventStreamMessage(t *testing.T) {
+	t.Run("reads messages until EOF", func(t *testing.T) {
+		stream := bytes.NewReader(append(bedrockEvent("chunk", map[string]string{"bytes": "e30="}), bedrockEvent("chunk", nil)...))
+		headers, payload, err := readEventStreamMessage(stream)
+		if err != nil || headers[":event-type"] != "chunk" || string(payload) != `{"bytes":"e30="}` {
+			t.Fatalf("unexpected message %v %q %v", headers, payload, err)
+		}
+		if _, _, err := readEventStreamMessage(stream); err != nil {
+			t.Fatalf("unexpected error reading second message: %v", err)
+		}
+		if _, _, err := readEventStreamMessage(stream); err != io.EOF {
+			t.Errorf("expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("rejects corrupted messages", func(t *testing.T) {
+		message := bedrockEvent("chunk", map[string]string{"bytes": "e30="})
+		message[len(message)-6] ^= 0xff
+		if _, _, err := readEventStreamMessage(bytes.NewReader(message)); err == nil || !strings.Contains(err.Error(), "checksum") {
+			t.Errorf("expected a checksum error, got %v", err)
+		}
+	})
+}
+
+func TestBedrockProvider(t *testing.T) {
+	t.Run("validates", func(t *testing.T) {
+		t.Setenv("AWS_REGION", "")
+		t.Setenv("AWS_DEFAULT_REGION", "")
+		cases := []struct {
+			name     string
+			provider *ProviderConfig
+			want     string
+		}{
+			{name: "region", provider: BedrockProvider{Region: "us-east-1"}.Config()},
+			{name: "missing region", provider: BedrockProvider{}.Config(), want: "Bedrock.Region is required"},
+			{name: "BaseURL", provider: &ProviderConfig{Type: "bedrock", BaseURL: "https://x", Bedrock: &BedrockProviderOptions{Region: "us-east-1"}}, want: "BaseURL does not apply"},
+			{name: "invalid endpoint", provider: BedrockProvider{Region: "us-east-1", Endpoint: "vpce.local"}.Config(), want: "invalid Bedrock.Endpoint"},
+		}
+		for _, tc := range cases {
+			err := tc.provider.Validate()
+			if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+				t.Errorf("%s: expected error %q, got %v", tc.name, tc.want, err)
+			}
+		}
+		named := NamedProviderConfig{Name: "aws", Type: "bedrock"}
+		if err := named.Validate(); err == nil {
+			t.Error("expected named Bedrock providers to be rejected")
+		}
+		remote := NewClient(&ClientOptions{Connection: URIConnection{URL: "runtime.internal:3000"}})
+		if _, _, err := remote.startBedrockProxy(BedrockProvider{Region: "us-east-1"}.Config(), "claude-sonnet-4.5"); err == nil {
+			t.Error("expected Bedrock to be rejected for a runtime reached over the network")
+		}
+	})
+
+	t.Run("forwards Anthropic Messages requests to InvokeModel", func(t *testing.T) {
+		bedrock := newFakeBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+			event, _ := json.Marshal(map[string]any{"type": "message_start", "message": map[string]any{"id": "msg_1"}})
+			w.Write(bedrockEvent("chunk", map[string]string{"bytes": base64.StdEncoding.EncodeToString(event)}))
+			w.Write(bedrockEvent("metrics", map[string]int{"latency": 1}))
+			event, _ = json.Marshal(map[string]any{"type": "message_stop"})
+			w.Write(bedrockEvent("chunk", map[string]string{"bytes": base64.StdEncoding.EncodeToString(event)}))
+		})
+		proxy, wire := startTestBedrockProxy(t, BedrockProvider{
+			Region:   "us-west-2",
+			Endpoint: bedrock.URL,
+			APIKey:   "bedrock-key",
+			ModelIDs: map[string]string{"claude-sonnet-4.5": "us.anthropic.claude-sonnet-4-5-v1:0"},
+		}, "claude-sonnet-4.5")
+		if wire.Type != "anthropic" || wire.BaseURL != proxy.url || wire.APIKey == "bedrock-key" || wire.Bedrock != nil {
+			t.Fatalf("unexpected wire provider %+v", wire)
+		}
+
+		resp, body := postJSON(t, wire.BaseURL+"/v1/messages", wire.APIKey,
+			`{"model":"claude-sonnet-4.5","stream":true,"max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+		}
+		want := "event: message_start\ndata: {\"message\":{\"id\":\"msg_1\"},\"type\":\"message_start\"}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+		if body != want {
+			t.Errorf("unexpected stream\n got: %q\nwant: %q", body, want)
+		}
+
+		r, sent := bedrock.requests[0], bedrock.bodies[0]
+		if r.URL.EscapedPath() != "/model/us.anthropic.claude-sonnet-4-5-v1%3A0/invoke-with-response-stream" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		if r.Header.Get("Authorization") != "Bearer bedrock-key" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		if sent["anthropic_version"] != "bedrock-2023-05-31" || sent["model"] != nil || sent["stream"] != nil || sent["max_tokens"] != float64(10) {
+			t.Errorf("unexpected request body %v", sent)
+		}
+	})
+
+	t.Run("signs requests with AWS credentials", func(t *testing.T) {
+		bedrock := newFakeBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"type":"message"}`))
+		})
+		_, wire := startTestBedrockProxy(t, BedrockProvider{
+			Region:   "eu-central-1",
+			Endpoint: bedrock.URL,
+			Credentials: func(context.Context) (AWSCredentials, error) {
+				return AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, nil
+			},
+		}, "anthropic.claude-3-haiku")
+		t.Setenv("AWS_BEARER_TOKEN_BEDROCK", "")
+
+		if resp, body := postJSON(t, wire.BaseURL+"/v1/messages", wire.APIKey, `{"model":"anthropic.claude-3-haiku"}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+		}
+		r := bedrock.requests[0]
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-central-1/bedrock/aws4_request") {
+			t.Errorf("unexpected Authorization header %q", auth)
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("expected the session token to be sent")
+		}
+	})
+
+	t.Run("rejects requests without the proxy token", func(t *testing.T) {
+		_, wire := startTestBedrockProxy(t, BedrockProvider{Region: "us-east-1", APIKey: "k"}, "meta.llama3-70b")
+		if resp, _ := postJSON(t, wire.BaseURL+"/chat/completions", "wrong", `{}`); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("translates Chat Completions to Converse", func(t *testing.T) {
+		bedrock := newFakeBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write(bedrockEvent("messageStart", map[string]string{"role": "assistant"}))
+			w.Write(bedrockEvent("contentBlockDelta", map[string]any{"contentBlockIndex": 0, "delta": map[string]string{"text": "Let me look."}}))
+			w.Write(bedrockEvent("contentBlockStart", map[string]any{"contentBlockIndex": 1, "start": map[string]any{"toolUse": map[string]string{"toolUseId": "t1", "name": "grep"}}}))
+			w.Write(bedrockEvent("contentBlockDelta", map[string]any{"contentBlockIndex": 1, "delta": map[string]any{"toolUse": map[string]string{"input": `{"q":1}`}}}))
+			w.Write(bedrockEvent("messageStop", map[string]string{"stopReason": "tool_use"}))
+			w.Write(bedrockEvent("metadata", map[string]any{"usage": map[string]int{"inputTokens": 5, "outputTokens": 7, "totalTokens": 12}}))
+		})
+		_, wire := startTestBedrockProxy(t, BedrockProvider{Region: "us-east-1", Endpoint: bedrock.URL, APIKey: "k"}, "meta.llama3-70b")
+		if wire.Type != "openai" || wire.WireAPI != "completions" {
+			t.Fatalf("unexpected wire provider %+v", wire)
+		}
+
+		resp, body := postJSON(t, wire.BaseURL+"/chat/completions", wire.APIKey, `{
+			"model": "meta.llama3-70b",
+			"stream": true,
+			"max_tokens": 100,
+			"messages": [
+				{"role": "system", "content": "Be brief."},
+				{"role": "user", "content": [{"type": "text", "text": "find"}, {"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBO"}}]},
+				{"role": "assistant", "content": null, "tool_calls": [{"id": "t0", "type": "function", "function": {"name": "ls", "arguments": "{}"}}]},
+				{"role": "tool", "tool_call_id": "t0", "content": ""},
+				{"role": "user", "content": "continue"}
+			],
+			"tools": [{"type": "function", "function": {"name": "grep", "parameters": {"type": "object"}}}],
+			"tool_choice": "required"
+		}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+		}
+
+		if path := bedrock.requests[0].URL.Path; path != "/model/meta.llama3-70b/converse-stream" {
+			t.Errorf("unexpected path %s", path)
+		}
+		sent, _ := json.Marshal(bedrock.bodies[0])
+		for _, want := range []string{
+			`"system":[{"text":"Be brief."}]`,
+			`{"image":{"format":"png","source":{"bytes":"iVBO"}}}`,
+			`{"toolUse":{"input":{},"name":"ls","toolUseId":"t0"}}`,
+			// The tool result and the next user message merge into one turn.
+			`{"content":[{"toolResult":{"content":[{"text":"(no output)"}],"toolUseId":"t0"}},{"text":"continue"}],"role":"user"}`,
+			`"inferenceConfig":{"maxTokens":100}`,
+			`"toolChoice":{"any":{}}`,
+		} {
+			if !strings.Contains(string(sent), want) {
+				t.Errorf("expected the Converse request to contain %s, got %s", want, sent)
+			}
+		}
+
+		var deltas []string
+		for _, line := range strings.Split(body, "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok || data == "[DONE]" {
+				continue
+			}
+			var chunk struct {
+				Choices []struct {
+					Delta        json.RawMessage `json:"delta"`
+					FinishReason *string         `json:"finish_reason"`
+				} `json:"choices"`
+				Usage *chatUsage `json:"usage"`
+			}
+			json.Unmarshal([]byte(data), &chunk)
+			switch {
+			case chunk.Usage != nil:
+				deltas = append(deltas, "usage:"+strconv.Itoa(chunk.Usage.TotalTokens))
+			case chunk.Choices[0].FinishReason != nil:
+				deltas = append(deltas, "finish:"+*chunk.Choices[0].FinishReason)
+			default:
+				deltas = append(deltas, string(chunk.Choices[0].Delta))
+			}
+		}
+		want := []string{
+			`{"content":"","role":"assistant"}`,
+			`{"content":"Let me look."}`,
+			`{"tool_calls":[{"index":0,"id":"t1","type":"function","function":{"name":"grep","arguments":""}}]}`,
+			`{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":1}"}}]}`,
+			"finish:tool_calls",
+			"usage:12",
+		}
+		if strings.Join(deltas, "\n") != strings.Join(want, "\n") {
+			t.Errorf("unexpected chunks\n got: %v\nwant: %v", deltas, want)
+		}
+		if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+			t.Errorf("expected the stream to end with [DONE]")
+		}
+	})
+
+	t.Run("CreateSession sends the proxy to the runtime and Disconnect stops it", func(t *testing.T) {
+		var mu sync.Mutex
+		var provider map[string]any
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var p struct {
+					SessionID string         `json:"sessionId"`
+					Provider  map[string]any `json:"provider"`
+				}
+				json.Unmarshal(params, &p)
+				mu.Lock()
+				provider = p.Provider
+				mu.Unlock()
+				result, _ := json.Marshal(map[string]any{"sessionId": p.SessionID})
+				return result, nil
+			},
+			"session.destroy": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{}`), nil
+			},
+		})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			Model: "claude-sonnet-4.5",
+			Provider: BedrockProvider{
+				Region:   "us-east-1",
+				APIKey:   "bedrock-key",
+				ModelIDs: map[string]string{"claude-sonnet-4.5": "us.anthropic.claude-sonnet-4-5-v1:0"},
+			}.Config(),
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if provider["type"] != "anthropic" || provider["baseUrl"] != session.bedrock.url || provider["apiKey"] != session.bedrock.token {
+			t.Errorf("unexpected provider %v", provider)
+		}
+		if err := session.Disconnect(); err != nil {
+			t.Fatalf("Disconnect failed: %v", err)
+		}
+		if _, err := http.Get(session.bedrock.url); err == nil {
+			t.Error("expected the proxy to be stopped")
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets calls through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen short-circuits calls.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets one probe call through to test for recovery.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerConfig configures the per-tool and per-MCP-server circuit
+// breakers of a session. See [SessionConfig.CircuitBreaker].
+//
+// The SDK counts consecutive failures of each SDK-hosted tool and each MCP
+// server from the session's tool.execution_complete events. After too many,
+// the circuit opens: calls are answered with an explanatory failure instead
+// of reaching the dependency, so a dead service does not burn the turn on
+// timeouts. After a cool-down, one call is let through as a probe; its
+// outcome closes the circuit or opens it again.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed calls that opens
+	// a circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenDuration is how long a circuit stays open before a probe call is
+	// let through. Defaults to 30s.
+	OpenDuration time.Duration
+	// OnStateChange, if set, is called when a circuit changes state. target
+	// is the tool name, or "mcp:" followed by the server name. It is called
+	// synchronously from event processing and must not block.
+	OnStateChange func(target string, state CircuitState)
+}
+
+// mcpCircuitPrefix prefixes the circuit targets of MCP servers.
+const mcpCircuitPrefix = "mcp:"
+
+// circuit is the breaker state of one tool or MCP server.
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probe is the tool call ID of the half-open circuit's probe.
+	probe string
+}
+
+// circuitBreaker tracks the circuits of one session.
+type circuitBreaker struct {
+	config  CircuitBreakerConfig
+	session *Session
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	// calls maps running tool calls to their circuit target.
+	calls map[string]string
+}
+
+func newCircuitBreaker(config *CircuitBreakerConfig) *circuitBreaker {
+	if config == nil {
+		return nil
+	}
+	b := &circuitBreaker{config: *config, circuits: make(map[string]*circuit), calls: make(map[string]string)}
+	if b.config.FailureThreshold <= 0 {
+		b.config.FailureThreshold = 5
+	}
+	if b.config.OpenDuration <= 0 {
+		b.config.OpenDuration = 30 * time.Second
+	}
+	return b
+}
+
+// attachCircuitBreaker connects breaker to the session.
+func (s *Session) attachCircuitBreaker(breaker *circuitBreaker) {
+	if breaker == nil {
+		return
+	}
+	breaker.session = s
+	s.breaker = breaker
+}
+
+// admit reports whether a call to target may proceed. If not, it returns
+// the explanation given to the model instead.
+func (b *circuitBreaker) admit(target, toolCallID string) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[target]
+	if c == nil || c.state == CircuitClosed {
+		return true, ""
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.config.OpenDuration {
+		b.transitionLocked(target, c, CircuitHalfOpen)
+	}
+	if c.state == CircuitHalfOpen && c.probe == "" {
+		c.probe = toolCallID
+		return true, ""
+	}
+	until := "a call testing whether it has recovered succeeds"
+	if c.state == CircuitOpen {
+		until = c.openedAt.Add(b.config.OpenDuration).Format(time.RFC3339)
+	}
+	return false, fmt.Sprintf("%s is temporarily unavailable: its last %d calls failed, so it will not be called again until %s. Continue without it or try again later.",
+		describeCircuitTarget(target), c.failures, until)
+}
+
+// observe records the outcome of the session's tool calls. Called only from
+// processEvents.
+func (b *circuitBreaker) observe(event SessionEvent) {
+	switch d := event.Data.(type) {
+	case *ToolExecutionStartData:
+		target := d.ToolName
+		if d.MCPServerName != nil {
+			target = mcpCircuitPrefix + *d.MCPServerName
+		} else if _, ok := b.session.getToolHandler(d.ToolName); !ok {
+			return // built-in tools run inside the runtime
+		}
+		b.mu.Lock()
+		b.calls[d.ToolCallID] = target
+		b.mu.Unlock()
+	case *ToolExecutionCompleteData:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		target, ok := b.calls[d.ToolCallID]
+		if !ok {
+			return
+		}
+		delete(b.calls, d.ToolCallID)
+		b.recordLocked(target, d.ToolCallID, d.Success)
+	}
+}
+
+func (b *circuitBreaker) recordLocked(target, toolCallID string, success bool) {
+	c := b.circuits[target]
+	if c == nil {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[target] = c
+	}
+	switch c.state {
+	case CircuitClosed:
+		if success {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= b.config.FailureThreshold {
+			b.transitionLocked(target, c, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if toolCallID != c.probe {
+			return
+		}
+		c.probe = ""
+		if success {
+			c.failures = 0
+			b.transitionLocked(target, c, CircuitClosed)
+		} else {
+			c.failures++
+			b.transitionLocked(target, c, CircuitOpen)
+		}
+	}
+}
+
+func (b *circuitBreaker) transitionLocked(target string, c *circuit, state CircuitState) {
+	c.state = state
+	if state == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+	if state == CircuitClosed {
+		b.session.logger().Info("circuit closed", "target", target)
+	} else {
+		b.session.logger().Warn("circuit "+string(state), "target", target, "failures", c.failures)
+	}
+	if b.config.OnStateChange != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				b.session.recovered("circuit breaker callback", target, r)
+			}
+		}()
+		b.config.OnStateChange(target, state)
+	}
+}
+
+// enforcePermission rejects the permission request of a call to an MCP
+// server whose circuit is open, reporting whether it did.
+func (b *circuitBreaker) enforcePermission(d *PermissionRequestedData) bool {
+	r, ok := d.PermissionRequest.(*PermissionRequestMCP)
+	if !ok {
+		return false
+	}
+	admitted, reason := b.admit(mcpCircuitPrefix+r.ServerName, derefString(r.ToolCallID))
+	if admitted {
+		return false
+	}
+	b.session.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		RequestID: d.RequestID,
+		Result:    &rpc.PermissionDecisionReject{Feedback: &reason},
+	})
+	return true
+}
+
+// shortCircuitTool answers a call to an SDK-hosted tool whose circuit is
+// open, reporting whether it did.
+func (b *circuitBreaker) shortCircuitTool(d *ExternalToolRequestedData) bool {
+	admitted, reason := b.admit(d.ToolName, d.ToolCallID)
+	if admitted {
+		return false
+	}
+	resultType := "failure"
+	b.session.conn.Load().rpc.Tools.HandlePendingToolCall(context.Background(), &rpc.HandlePendingToolCallRequest{
+		RequestID: d.RequestID,
+		Result: &rpc.ExternalToolTextResultForLlm{
+			TextResultForLlm: reason,
+			ResultType:       &resultType,
+			Error:            &reason,
+		},
+	})
+	return true
+}
+
+func describeCircuitTarget(target string) string {
+	if server, ok := strings.CutPrefix(target, mcpCircuitPrefix); ok {
+		return fmt.Sprintf("MCP server %q", server)
+	}
+	return fmt.Sprintf("Tool %q", target)
+}
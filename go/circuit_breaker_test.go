@@ -0,0 +1,117 @@
+package copilot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	start := func(t *testing.T, handlerCalls chan string) (*Session, chan map[string]any, chan CircuitState) {
+		t.Helper()
+		responses := make(chan map[string]any, 4)
+		respond := func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			responses <- req
+			return []byte(`{"success":true}`), nil
+		}
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.tools.handlePendingToolCall":                respond,
+			"session.permissions.handlePendingPermissionRequest": respond,
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.registerTools([]Tool{{
+			Name: "flaky",
+			Handler: func(inv ToolInvocation) (ToolResult, error) {
+				handlerCalls <- inv.ToolCallID
+				return ToolResult{TextResultForLLM: "ok"}, nil
+			},
+		}})
+		states := make(chan CircuitState, 8)
+		session.attachCircuitBreaker(newCircuitBreaker(&CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenDuration:     50 * time.Millisecond,
+			OnStateChange:    func(target string, state CircuitState) { states <- state },
+		}))
+		return session, responses, states
+	}
+	complete := func(session *Session, id string, server *string, success bool) {
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: id, ToolName: "flaky", MCPServerName: server}})
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionCompleteData{ToolCallID: id, Success: success}})
+	}
+	expectState := func(t *testing.T, states chan CircuitState, want CircuitState) {
+		t.Helper()
+		select {
+		case got := <-states:
+			if got != want {
+				t.Fatalf("expected the circuit to become %s, got %s", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the circuit to become %s", want)
+		}
+	}
+	next := func(t *testing.T, responses chan map[string]any) map[string]any {
+		t.Helper()
+		select {
+		case req := <-responses:
+			return req
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a response to the runtime")
+			return nil
+		}
+	}
+
+	t.Run("short-circuits a failing tool and closes after a successful probe", func(t *testing.T) {
+		handlerCalls := make(chan string, 4)
+		session, responses, states := start(t, handlerCalls)
+		complete(session, "c1", nil, false)
+		complete(session, "c2", nil, true)
+		complete(session, "c3", nil, false)
+		complete(session, "c4", nil, false)
+		expectState(t, states, CircuitOpen)
+
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r5", ToolCallID: "c5", ToolName: "flaky"}})
+		result, _ := next(t, responses)["result"].(map[string]any)
+		if text, _ := result["textResultForLlm"].(string); !strings.Contains(text, `Tool "flaky" is temporarily unavailable: its last 2 calls failed`) {
+			t.Errorf("expected an explanation for the model, got %v", result)
+		}
+		select {
+		case id := <-handlerCalls:
+			t.Errorf("expected the handler not to be called, got %s", id)
+		default:
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r6", ToolCallID: "c6", ToolName: "flaky"}})
+		if id := <-handlerCalls; id != "c6" {
+			t.Errorf("expected the probe c6 to reach the handler, got %s", id)
+		}
+		expectState(t, states, CircuitHalfOpen)
+		next(t, responses)
+		complete(session, "c6", nil, true)
+		expectState(t, states, CircuitClosed)
+	})
+
+	t.Run("rejects permission requests for a failing MCP server", func(t *testing.T) {
+		session, responses, states := start(t, make(chan string, 1))
+		server := "issues"
+		complete(session, "c1", &server, false)
+		complete(session, "c2", &server, false)
+		expectState(t, states, CircuitOpen)
+
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{
+			RequestID:         "p1",
+			PermissionRequest: &PermissionRequestMCP{ServerName: "issues", ToolName: "list_issues", ToolCallID: ptr("c3")},
+		}})
+		req := next(t, responses)
+		result, _ := req["result"].(map[string]any)
+		if req["requestId"] != "p1" || result["kind"] != "reject" || !strings.Contains(result["feedback"].(string), `MCP server "issues"`) {
+			t.Errorf("expected the request to be rejected, got %v", req)
+		}
+	})
+}
@@ -0,0 +1,374 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/internal/embeddedcli"
+)
+
+// ErrCLINotManaged is returned by [Client.UpdateCLI] when the client's CLI
+// was not installed by [EnsureCLI], so the SDK must not replace it.
+var ErrCLINotManaged = errors.New("CLI is not managed by the SDK")
+
+// CLIUpdateOptions configures [Client.CheckForCLIUpdate] and
+// [Client.UpdateCLI]. See [ClientOptions.CLIUpdate].
+type CLIUpdateOptions struct {
+	// CacheDirectory is the directory [EnsureCLI] installs CLIs into. A CLI
+	// under it is managed by the SDK and can be updated. Defaults to the
+	// default of [EnsureOptions.CacheDirectory].
+	CacheDirectory string
+	// RegistryURL overrides the npm registry queried for the latest release.
+	// Defaults to https://registry.npmjs.org.
+	RegistryURL string
+	// HTTPClient is used for registry requests and downloads. Defaults to
+	// [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// CLIUpdateInfo reports the version of the client's CLI and the latest
+// release. See [Client.CheckForCLIUpdate].
+type CLIUpdateInfo struct {
+	CurrentVersion string
+	LatestVersion  string
+	// UpdateAvailable reports whether LatestVersion is newer than
+	// CurrentVersion.
+	UpdateAvailable bool
+	// Managed reports whether the CLI was installed by [EnsureCLI] into the
+	// cache directory, so [Client.UpdateCLI] can replace it.
+	Managed bool
+}
+
+// CLIUpdateStage is a step of [Client.UpdateCLI], as reported to
+// [Client.OnCLIUpdateProgress] handlers.
+type CLIUpdateStage string
+
+const (
+	// CLIUpdateStageChecking means the latest release is being looked up.
+	CLIUpdateStageChecking CLIUpdateStage = "checking"
+	// CLIUpdateStageDownloading means the new release is being downloaded
+	// and checked against the registry's integrity hash.
+	CLIUpdateStageDownloading CLIUpdateStage = "downloading"
+	// CLIUpdateStageVerifying means the installed binary is being run to
+	// confirm its version.
+	CLIUpdateStageVerifying CLIUpdateStage = "verifying"
+	// CLIUpdateStageRestarting means the runtime is being restarted on the
+	// new binary.
+	CLIUpdateStageRestarting CLIUpdateStage = "restarting"
+	// CLIUpdateStageCompleted means the update finished.
+	CLIUpdateStageCompleted CLIUpdateStage = "completed"
+	// CLIUpdateStageFailed means the update failed; the previous binary
+	// stays in use.
+	CLIUpdateStageFailed CLIUpdateStage = "failed"
+)
+
+// CLIUpdateProgress describes the progress of [Client.UpdateCLI].
+type CLIUpdateProgress struct {
+	Stage CLIUpdateStage
+	// Version is the version being installed, once known.
+	Version string
+	// Err is the reason for [CLIUpdateStageFailed].
+	Err error
+}
+
+// CLIUpdateHandler handles CLI update progress.
+type CLIUpdateHandler func(progress CLIUpdateProgress)
+
+// CLIUpdateResult reports the outcome of [Client.UpdateCLI].
+type CLIUpdateResult struct {
+	CLIUpdateInfo
+	// Path is the CLI the client uses after the update.
+	Path string
+	// Updated reports whether a new CLI was installed.
+	Updated bool
+	// Restart is the outcome of restarting the runtime on the new CLI, or
+	// nil if the client was not running.
+	Restart *RestartResult
+}
+
+// OnCLIUpdateProgress subscribes to the progress of [Client.UpdateCLI].
+//
+// Handlers are called synchronously from UpdateCLI. Returns a function that,
+// when called, unsubscribes the handler.
+//
+// Example:
+//
+//	unsubscribe := client.OnCLIUpdateProgress(func(p copilot.CLIUpdateProgress) {
+//	    log.Printf("CLI update %s %s", p.Stage, p.Version)
+//	})
+//	defer unsubscribe()
+func (c *Client) OnCLIUpdateProgress(handler CLIUpdateHandler) func() {
+	c.lifecycleHandlersMux.Lock()
+	if c.cliUpdateHandlers == nil {
+		c.cliUpdateHandlers = make(map[uint64]CLIUpdateHandler)
+	}
+	c.nextLifecycleHandlerID++
+	id := c.nextLifecycleHandlerID
+	c.cliUpdateHandlers[id] = handler
+	c.lifecycleHandlersMux.Unlock()
+
+	return func() {
+		c.lifecycleHandlersMux.Lock()
+		defer c.lifecycleHandlersMux.Unlock()
+		delete(c.cliUpdateHandlers, id)
+	}
+}
+
+func (c *Client) notifyCLIUpdate(progress CLIUpdateProgress) {
+	c.lifecycleHandlersMux.Lock()
+	handlers := make([]CLIUpdateHandler, 0, len(c.cliUpdateHandlers))
+	for _, handler := range c.cliUpdateHandlers {
+		handlers = append(handlers, handler)
+	}
+	c.lifecycleHandlersMux.Unlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() { recover() }() // Ignore handler panics
+			handler(progress)
+		}()
+	}
+}
+
+// CheckForCLIUpdate compares the version of the client's CLI with the
+// latest release on the npm registry.
+//
+// The current version is read from the CLI binary the client spawns, or from
+// the runtime itself for external and in-process runtimes, which must then
+// be connected.
+//
+// Example:
+//
+//	info, err := client.CheckForCLIUpdate(ctx)
+//	if err == nil && info.UpdateAvailable {
+//	    log.Printf("Copilot CLI %s is available (running %s)", info.LatestVersion, info.CurrentVersion)
+//	}
+func (c *Client) CheckForCLIUpdate(ctx context.Context) (*CLIUpdateInfo, error) {
+	info, _, err := c.checkForCLIUpdate(ctx)
+	return info, err
+}
+
+// checkForCLIUpdate also returns the cache directory of a managed CLI.
+func (c *Client) checkForCLIUpdate(ctx context.Context) (*CLIUpdateInfo, string, error) {
+	if err := c.requireOnline("CLI update checks"); err != nil {
+		return nil, "", err
+	}
+	opts, err := c.cliUpdateOptions()
+	if err != nil {
+		return nil, "", err
+	}
+	info := &CLIUpdateInfo{}
+	path := c.spawnedCLIPath()
+	if path != "" {
+		info.CurrentVersion, err = readCLIVersion(ctx, path, c.options.Env)
+	} else {
+		info.CurrentVersion, err = c.runtimeVersion(ctx)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to determine current CLI version: %w", err)
+	}
+
+	if info.LatestVersion, err = latestCLIVersion(ctx, opts); err != nil {
+		return nil, "", err
+	}
+	info.UpdateAvailable = compareCLIVersions(info.LatestVersion, info.CurrentVersion) > 0
+
+	var cacheDir string
+	if path != "" {
+		if cacheDir, err = ensureCacheDirectory(opts.CacheDirectory, c.options.Env); err != nil {
+			return nil, "", err
+		}
+		info.Managed = isWithinDir(path, cacheDir)
+	}
+	return info, cacheDir, nil
+}
+
+// UpdateCLI installs the latest CLI release when the client's CLI is managed
+// by the SDK, then restarts a running client on it (see [Client.Restart]).
+// The download is verified against the registry's integrity hash and
+// installed alongside the current version, so the previous binary keeps
+// working if anything fails. Progress is reported to
+// [Client.OnCLIUpdateProgress] handlers.
+//
+// Returns [ErrCLINotManaged] if the CLI was not installed by [EnsureCLI].
+// When the CLI is already current, nothing is installed and
+// [CLIUpdateResult.Updated] is false.
+//
+// Example:
+//
+//	result, err := client.UpdateCLI(ctx)
+//	if errors.Is(err, copilot.ErrCLINotManaged) {
+//	    return // the CLI is updated by other means
+//	}
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if result.Updated {
+//	    log.Printf("updated Copilot CLI to %s", result.LatestVersion)
+//	}
+func (c *Client) UpdateCLI(ctx context.Context) (*CLIUpdateResult, error) {
+	result, err := c.updateCLI(ctx)
+	if err != nil {
+		progress := CLIUpdateProgress{Stage: CLIUpdateStageFailed, Err: err}
+		if result != nil {
+			progress.Version = result.LatestVersion
+		}
+		c.notifyCLIUpdate(progress)
+		return nil, err
+	}
+	c.notifyCLIUpdate(CLIUpdateProgress{Stage: CLIUpdateStageCompleted, Version: result.LatestVersion})
+	return result, nil
+}
+
+func (c *Client) updateCLI(ctx context.Context) (*CLIUpdateResult, error) {
+	c.notifyCLIUpdate(CLIUpdateProgress{Stage: CLIUpdateStageChecking})
+	info, cacheDir, err := c.checkForCLIUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &CLIUpdateResult{CLIUpdateInfo: *info, Path: c.spawnedCLIPath()}
+	if !info.Managed {
+		return result, ErrCLINotManaged
+	}
+	if !info.UpdateAvailable {
+		return result, nil
+	}
+
+	opts, err := c.cliUpdateOptions()
+	if err != nil {
+		return result, err
+	}
+	version := info.LatestVersion
+	c.notifyCLIUpdate(CLIUpdateProgress{Stage: CLIUpdateStageDownloading, Version: version})
+	path, err := installCLI(ctx, EnsureOptions{RegistryURL: opts.RegistryURL, HTTPClient: opts.HTTPClient}, version, filepath.Join(cacheDir, sanitizeCLIVersion(version)))
+	if err != nil {
+		return result, err
+	}
+	c.notifyCLIUpdate(CLIUpdateProgress{Stage: CLIUpdateStageVerifying, Version: version})
+	installed, err := verifyCLI(ctx, path, CLISourceDownload, c.options.Env)
+	if err != nil {
+		return result, fmt.Errorf("downloaded CLI failed verification: %w", err)
+	}
+
+	c.startStopMux.Lock()
+	previous := c.cliPath
+	c.cliPath = installed.Path
+	running := c.state == stateConnected
+	c.startStopMux.Unlock()
+	result.Path = installed.Path
+	result.Updated = true
+
+	if running {
+		c.notifyCLIUpdate(CLIUpdateProgress{Stage: CLIUpdateStageRestarting, Version: version})
+		if result.Restart, err = c.Restart(ctx); err != nil {
+			// Fall back to the previous CLI so the client keeps working.
+			c.startStopMux.Lock()
+			c.cliPath = previous
+			c.startStopMux.Unlock()
+			_, restoreErr := c.Restart(ctx)
+			return result, errors.Join(fmt.Errorf("failed to restart on CLI %s: %w", version, err), restoreErr)
+		}
+	}
+	return result, nil
+}
+
+// cliUpdateOptions returns [ClientOptions.CLIUpdate] with the HTTP client
+// defaulted from [ClientOptions.Network].
+func (c *Client) cliUpdateOptions() (CLIUpdateOptions, error) {
+	var opts CLIUpdateOptions
+	if c.options.CLIUpdate != nil {
+		opts = *c.options.CLIUpdate
+	}
+	httpClient, err := c.httpClient(opts.HTTPClient)
+	if err != nil {
+		return opts, fmt.Errorf("failed to configure network: %w", err)
+	}
+	opts.HTTPClient = httpClient
+	return opts, nil
+}
+
+// spawnedCLIPath returns the CLI binary the client spawns, or "" for
+// external and in-process runtimes.
+func (c *Client) spawnedCLIPath() string {
+	if c.isExternalServer || c.useInProcess {
+		return ""
+	}
+	c.startStopMux.RLock()
+	path := c.cliPath
+	c.startStopMux.RUnlock()
+	if path == "" {
+		path = embeddedcli.Path()
+	}
+	if path == "" {
+		path, _ = lookPathEnv("copilot", c.options.Env)
+	}
+	if path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	return path
+}
+
+// runtimeVersion asks the connected runtime for its version.
+func (c *Client) runtimeVersion(ctx context.Context) (string, error) {
+	c.startStopMux.RLock()
+	connected := c.client != nil
+	c.startStopMux.RUnlock()
+	if !connected {
+		return "", errors.New("client not connected")
+	}
+	status, err := c.GetStatus(ctx)
+	if err != nil {
+		return "", err
+	}
+	return status.Version, nil
+}
+
+// latestCLIVersion looks up the latest CLI release for this platform.
+func latestCLIVersion(ctx context.Context, opts CLIUpdateOptions) (string, error) {
+	platform, err := cliNPMPlatform()
+	if err != nil {
+		return "", err
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	registry := strings.TrimSuffix(opts.RegistryURL, "/")
+	if registry == "" {
+		registry = defaultNPMRegistryURL
+	}
+
+	pkg := "@github/copilot-" + platform
+	body, err := httpGet(ctx, httpClient, registry+"/"+url.PathEscape(pkg)+"/latest")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch latest %s release: %w", pkg, err)
+	}
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse latest %s release: %w", pkg, err)
+	}
+	if manifest.Version == "" {
+		return "", fmt.Errorf("registry metadata for %s has no version", pkg)
+	}
+	return manifest.Version, nil
+}
+
+// isWithinDir reports whether path is inside dir.
+func isWithinDir(path, dir string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
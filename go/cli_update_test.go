@@ -0,0 +1,127 @@
+package copilot
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newCLIRegistry serves a fake npm registry whose latest CLI release is
+// 1.0.99.
+func newCLIRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	platform, err := cliNPMPlatform()
+	if err != nil {
+		t.Skip(err)
+	}
+	tarball := buildTestTarball(t, "package/copilot", "#!/bin/sh\necho 1.0.99\n")
+	sum := sha512.Sum512(tarball)
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/@github/copilot-" + platform + "/latest":
+			fmt.Fprint(w, `{"version":"1.0.99"}`)
+		case "/@github/copilot-" + platform + "/1.0.99":
+			fmt.Fprintf(w, `{"dist":{"tarball":%q,"integrity":%q}}`, server.URL+"/pkg.tgz", integrity)
+		case "/pkg.tgz":
+			w.Write(tarball)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_CheckForCLIUpdate(t *testing.T) {
+	registry := newCLIRegistry(t)
+	cacheDir := t.TempDir()
+	installDir := filepath.Join(cacheDir, "1.0.80")
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := writeFakeCLI(t, installDir, "GitHub Copilot CLI 1.0.80")
+
+	client := NewClient(&ClientOptions{
+		Connection: StdioConnection{Path: path},
+		CLIUpdate:  &CLIUpdateOptions{CacheDirectory: cacheDir, RegistryURL: registry.URL},
+	})
+	info, err := client.CheckForCLIUpdate(t.Context())
+	if err != nil {
+		t.Fatalf("CheckForCLIUpdate failed: %v", err)
+	}
+	want := CLIUpdateInfo{CurrentVersion: "1.0.80", LatestVersion: "1.0.99", UpdateAvailable: true, Managed: true}
+	if *info != want {
+		t.Errorf("got %+v, want %+v", *info, want)
+	}
+}
+
+func TestClient_UpdateCLI(t *testing.T) {
+	t.Run("installs the latest release and switches to it", func(t *testing.T) {
+		registry := newCLIRegistry(t)
+		cacheDir := t.TempDir()
+		installDir := filepath.Join(cacheDir, "1.0.80")
+		if err := os.MkdirAll(installDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := writeFakeCLI(t, installDir, "1.0.80")
+
+		client := NewClient(&ClientOptions{
+			Connection: StdioConnection{Path: path},
+			CLIUpdate:  &CLIUpdateOptions{CacheDirectory: cacheDir, RegistryURL: registry.URL},
+		})
+		var stages []CLIUpdateStage
+		client.OnCLIUpdateProgress(func(p CLIUpdateProgress) { stages = append(stages, p.Stage) })
+
+		result, err := client.UpdateCLI(t.Context())
+		if err != nil {
+			t.Fatalf("UpdateCLI failed: %v", err)
+		}
+		wantPath := filepath.Join(cacheDir, "1.0.99", "copilot")
+		if !result.Updated || result.Path != wantPath || result.Restart != nil {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if client.cliPath != wantPath {
+			t.Errorf("expected the client to use %s, got %s", wantPath, client.cliPath)
+		}
+		want := []CLIUpdateStage{CLIUpdateStageChecking, CLIUpdateStageDownloading, CLIUpdateStageVerifying, CLIUpdateStageCompleted}
+		if !slices.Equal(stages, want) {
+			t.Errorf("got stages %v, want %v", stages, want)
+		}
+
+		result, err = client.UpdateCLI(t.Context())
+		if err != nil || result.Updated {
+			t.Errorf("expected no update once current, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("refuses to replace a CLI the SDK did not install", func(t *testing.T) {
+		registry := newCLIRegistry(t)
+		path := writeFakeCLI(t, t.TempDir(), "1.0.80")
+		client := NewClient(&ClientOptions{
+			Connection: StdioConnection{Path: path},
+			CLIUpdate:  &CLIUpdateOptions{CacheDirectory: t.TempDir(), RegistryURL: registry.URL},
+		})
+		var last CLIUpdateProgress
+		client.OnCLIUpdateProgress(func(p CLIUpdateProgress) { last = p })
+
+		if _, err := client.UpdateCLI(t.Context()); !errors.Is(err, ErrCLINotManaged) {
+			t.Fatalf("expected ErrCLINotManaged, got %v", err)
+		}
+		if last.Stage != CLIUpdateStageFailed || !errors.Is(last.Err, ErrCLINotManaged) {
+			t.Errorf("expected a failed progress event, got %+v", last)
+		}
+		if client.cliPath != path {
+			t.Errorf("expected the CLI path to be unchanged, got %s", client.cliPath)
+		}
+	})
+}
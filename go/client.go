@@ -31,14 +31,18 @@ package copilot
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"maps"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -49,7 +53,6 @@ import (
 
 	"github.com/github/copilot-sdk/go/internal/embeddedcli"
 	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
-	"github.com/github/copilot-sdk/go/internal/truncbuffer"
 	"github.com/github/copilot-sdk/go/rpc"
 )
 
@@ -93,6 +96,86 @@ func validateSessionFSConfig(config *SessionFSConfig) error {
 	return nil
 }
 
+// validateMCPServers checks MCP server configurations, including those of
+// custom agents, so that mistakes fail session creation instead of silently
+// yielding a server with no tools.
+func validateMCPServers(servers map[string]MCPServerConfig, agents []CustomAgentConfig) error {
+	if err := validateMCPServerMap(servers); err != nil {
+		return err
+	}
+	for _, agent := range agents {
+		if err := validateMCPServerMap(agent.MCPServers); err != nil {
+			return fmt.Errorf("custom agent %q: %w", agent.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateMCPServerMap(servers map[string]MCPServerConfig) error {
+	for name, server := range servers {
+		if err := validateMCPServer(name, server); err != nil {
+			return fmt.Errorf("invalid MCP server %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateMCPServer(name string, server MCPServerConfig) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("server name must not be empty")
+	}
+	// Pointers to the config types also satisfy MCPServerConfig.
+	switch config := server.(type) {
+	case *MCPStdioServerConfig:
+		if config != nil {
+			server = *config
+		}
+	case *MCPHTTPServerConfig:
+		if config != nil {
+			server = *config
+		}
+	case *MCPSSEServerConfig:
+		if config != nil {
+			server = *config
+		}
+	}
+
+	var timeout int
+	var rawURL string
+	switch config := server.(type) {
+	case MCPStdioServerConfig:
+		if strings.TrimSpace(config.Command) == "" {
+			return errors.New("Command is required")
+		}
+		timeout = config.Timeout
+	case MCPHTTPServerConfig:
+		timeout, rawURL = config.Timeout, config.URL
+	case MCPSSEServerConfig:
+		timeout, rawURL = config.Timeout, config.URL
+	case nil, *MCPStdioServerConfig, *MCPHTTPServerConfig, *MCPSSEServerConfig:
+		return errors.New("config must not be nil")
+	default:
+		return fmt.Errorf("unsupported config type %T", server)
+	}
+	if timeout < 0 {
+		return errors.New("Timeout must not be negative")
+	}
+	if _, ok := server.(MCPStdioServerConfig); ok {
+		return nil
+	}
+	if rawURL == "" {
+		return errors.New("URL is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL %q must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
+
 // validateEnvironmentOptions enforces the transport-specific rules for
 // per-client environment, working directory, and telemetry. It panics (fails
 // loud) on a misconfiguration, matching the other SDKs.
@@ -153,9 +236,13 @@ type Client struct {
 	sessions         map[string]*Session
 	sessionsMux      sync.Mutex
 	isExternalServer bool
-	conn             net.Conn // stores net.Conn for external TCP connections
-	useStdio         bool     // resolved value from options
-	useInProcess     bool     // true for InProcessConnection (FFI transport)
+	conn             net.Conn // stores net.Conn for external TCP and unix socket connections
+	socketPath       string   // unix socket path for UnixSocketConnection
+	tlsConfig        *tls.Config
+	upgradeHeaders   http.Header // headers for the HTTP upgrade request, if any
+	upgradePath      string
+	useStdio         bool // resolved value from options
+	useInProcess     bool // true for InProcessConnection (FFI transport)
 	ffiHost          inProcessHost
 	// resolved process options for the spawned runtime (zero values for URIConnection)
 	cliPath            string
@@ -169,6 +256,8 @@ type Client struct {
 	typedLifecycleHandlers    map[SessionLifecycleEventType]map[uint64]SessionLifecycleHandler
 	nextLifecycleHandlerID    uint64
 	lifecycleHandlersMux      sync.Mutex
+	cliUpdateHandlers         map[uint64]CLIUpdateHandler
+	stateHandlers             map[uint64]ClientStateHandler
 	startStopMux              sync.RWMutex // protects process and state during start/[force]stop
 	processDone               chan struct{}
 	processErrorPtr           *error
@@ -178,6 +267,46 @@ type Client struct {
 	// the SDK spawns its own CLI in TCP mode.
 	effectiveConnectionToken string
 	onListModels             func(ctx context.Context) ([]ModelInfo, error)
+	// reconnectCancel is non-nil while a reconnect loop is retrying a dropped
+	// connection. Guarded by startStopMux.
+	reconnectCancel context.CancelFunc
+	// restartCancel is non-nil while the CLI is being relaunched after a
+	// crash, and crashes holds the times of recent crashes. Guarded by
+	// startStopMux.
+	restartCancel context.CancelFunc
+	crashes       []time.Time
+	// heartbeatStop stops the heartbeat loop. Guarded by startStopMux.
+	heartbeatStop chan struct{}
+	healthy       atomic.Bool
+	// lifecycle is the state reported by State, and stateChanges the
+	// transitions not yet delivered to OnStateChange handlers. Guarded by
+	// stateMux.
+	stateMux         sync.Mutex
+	lifecycle        ClientState
+	stateChanges     []ClientStateChange
+	deliveringStates bool
+	// retentionCancel stops the session retention loop. Guarded by
+	// startStopMux.
+	retentionCancel context.CancelFunc
+	// reaperCancel stops the orphaned session reaper loop, and ownerCancel
+	// the loop recording this client as the owner of its sessions. Guarded
+	// by startStopMux.
+	reaperCancel context.CancelFunc
+	ownerCancel  context.CancelFunc
+	// id identifies this client in the owner records of its sessions, and
+	// ownerWake asks the owner heartbeat loop to record them now.
+	id        string
+	ownerWake chan struct{}
+	// providedToken is the latest token from ClientOptions.TokenProvider,
+	// or the token ResolveToken found.
+	providedToken atomic.Pointer[string]
+	// tokenRefreshCancel stops the token refresh loop. Guarded by
+	// startStopMux.
+	tokenRefreshCancel context.CancelFunc
+	// networkClient is built from ClientOptions.Network on first use.
+	networkClientOnce sync.Once
+	networkClient     *http.Client
+	networkClientErr  error
 
 	// RPC provides typed server-scoped RPC methods.
 	// This field is nil until the client is connected via Start().
@@ -216,7 +345,10 @@ func NewClient(options *ClientOptions) *Client {
 	client := &Client{
 		options:          opts,
 		state:            stateDisconnected,
+		lifecycle:        ClientStateStopped,
 		sessions:         make(map[string]*Session),
+		id:               uuid.NewString(),
+		ownerWake:        make(chan struct{}, 1),
 		actualHost:       "localhost",
 		isExternalServer: false,
 		useStdio:         true,
@@ -230,11 +362,7 @@ func NewClient(options *ClientOptions) *Client {
 	// honor the same process/environment override as the other SDKs.
 	connection := opts.Connection
 	if connection == nil {
-		env := opts.Env
-		if env == nil {
-			env = os.Environ()
-		}
-		connection = resolveDefaultConnection(env)
+		connection = resolveDefaultConnection(resolveEnv(opts.Env, opts.InheritEnv))
 	}
 	switch conn := connection.(type) {
 	case StdioConnection:
@@ -261,6 +389,32 @@ func NewClient(options *ClientOptions) *Client {
 		client.isExternalServer = true
 		client.useStdio = false
 		client.tcpConnectionToken = conn.ConnectionToken
+		if conn.TLS != nil {
+			client.tlsConfig = conn.TLS.Clone()
+		} else if strings.HasPrefix(strings.ToLower(conn.URL), "https://") {
+			client.tlsConfig = &tls.Config{}
+		}
+		if len(conn.Headers) > 0 || conn.BearerToken != "" {
+			client.upgradeHeaders = conn.Headers.Clone()
+			if client.upgradeHeaders == nil {
+				client.upgradeHeaders = http.Header{}
+			}
+			if conn.BearerToken != "" {
+				client.upgradeHeaders.Set("Authorization", "Bearer "+conn.BearerToken)
+			}
+			client.upgradePath = "/"
+			if u, err := url.Parse(conn.URL); err == nil && u.Host != "" && u.Path != "" {
+				client.upgradePath = u.Path
+			}
+		}
+	case UnixSocketConnection:
+		if conn.Path == "" {
+			panic("UnixSocketConnection requires a non-empty Path")
+		}
+		client.socketPath = conn.Path
+		client.isExternalServer = true
+		client.useStdio = false
+		client.tcpConnectionToken = conn.ConnectionToken
 	case InProcessConnection:
 		client.useStdio = false
 		client.useInProcess = true
@@ -277,7 +431,17 @@ func NewClient(options *ClientOptions) *Client {
 
 	// Validate auth options when connecting to an external runtime.
 	if client.isExternalServer && (opts.GitHubToken != "" || opts.UseLoggedInUser != nil) {
-		panic("GitHubToken and UseLoggedInUser cannot be used with URIConnection (external runtime manages its own auth)")
+		connectionName := "URIConnection"
+		if client.socketPath != "" {
+			connectionName = "UnixSocketConnection"
+		}
+		panic("GitHubToken and UseLoggedInUser cannot be used with " + connectionName + " (external runtime manages its own auth)")
+	}
+	if client.isExternalServer && opts.TokenProvider != nil {
+		panic("TokenProvider cannot be used with an external runtime (external runtime manages its own auth)")
+	}
+	if opts.GitHubToken != "" && opts.TokenProvider != nil {
+		panic("GitHubToken and TokenProvider cannot both be set")
 	}
 
 	// For child-process transports, a connection-level env takes precedence over
@@ -289,10 +453,9 @@ func NewClient(options *ClientOptions) *Client {
 		}
 	}
 
-	// Default Env to current environment if not set
-	if opts.Env == nil {
-		opts.Env = os.Environ()
-	}
+	// Default Env to current environment if not set, and expand names
+	// passed through from it.
+	opts.Env = resolveEnv(opts.Env, opts.InheritEnv)
 
 	// Check the effective environment for a child-process runtime override.
 	if client.cliPath == "" && !client.useInProcess {
@@ -357,6 +520,28 @@ func getEnvValue(env []string, key string) string {
 	return ""
 }
 
+// resolveEnv returns the runtime environment for the Env option env: the
+// current process environment when env is nil, and otherwise env with names
+// passed through from the current process, on top of the current process
+// environment if inherit is set.
+func resolveEnv(env []string, inherit bool) []string {
+	if env == nil {
+		return os.Environ()
+	}
+	resolved := []string{}
+	if inherit {
+		resolved = os.Environ()
+	}
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			resolved = setEnvValue(resolved, key, value)
+		} else if value, ok := os.LookupEnv(entry); ok {
+			resolved = setEnvValue(resolved, entry, value)
+		}
+	}
+	return resolved
+}
+
 // setEnvValue returns a copy of env with all existing entries for key removed and
 // a single trailing KEY=VALUE entry added so SDK-managed values win deterministically.
 func setEnvValue(env []string, key string, value string) []string {
@@ -378,6 +563,8 @@ func parseCLIURL(url string) (string, int) {
 	// Remove protocol if present
 	cleanURL, _ := strings.CutPrefix(url, "https://")
 	cleanURL, _ = strings.CutPrefix(cleanURL, "http://")
+	// Drop any path; it is only used for the optional upgrade request.
+	cleanURL, _, _ = strings.Cut(cleanURL, "/")
 
 	// Parse host:port or port format
 	var host string
@@ -421,14 +608,42 @@ func parseCLIURL(url string) (string, int) {
 //	}
 //	// Now ready to create sessions
 func (c *Client) Start(ctx context.Context) error {
+	return c.start(ctx, crashRecovery{}, true)
+}
+
+// start implements Start. crash is reported with the change to
+// [ClientStateStopped] if it fails (see [ClientStateChange.Crash]). Unless
+// ready is set, the client is left in [ClientStateStarting] for the caller
+// to re-attach sessions and report [ClientStateReady].
+func (c *Client) start(ctx context.Context, crash crashRecovery, ready bool) (err error) {
 	c.startStopMux.Lock()
 	defer c.startStopMux.Unlock()
 
 	if c.state == stateConnected {
 		return nil
 	}
+	begin := time.Now()
+	defer func() { c.recordOperation(TelemetryEventClientStart, begin, err, &c.options) }()
+
+	if c.options.EnableRemoteSessions {
+		if err := c.requireOnline("remote sessions"); err != nil {
+			return err
+		}
+	}
 
 	c.state = stateConnecting
+	c.setState(ClientStateStarting, nil)
+	defer func() {
+		if err != nil {
+			c.changeState(ClientStateChange{State: ClientStateStopped, Err: err, Crash: crash.count, exitErr: crash.exitErr})
+		}
+	}()
+
+	tokenExpiry, err := c.fetchToken(ctx)
+	if err != nil {
+		c.state = stateError
+		return err
+	}
 
 	// Only start CLI server process if not connecting to external server
 	if !c.isExternalServer {
@@ -453,6 +668,28 @@ func (c *Client) Start(ctx context.Context) error {
 		return errors.Join(err, killErr)
 	}
 
+	if err := c.registerProviders(ctx); err != nil {
+		killErr := c.killProcess()
+		c.state = stateError
+		return errors.Join(err, killErr)
+	}
+
+	c.state = stateConnected
+	c.healthy.Store(true)
+	if ready {
+		c.setState(ClientStateReady, nil)
+	}
+	c.startHeartbeat()
+	c.startRetention()
+	c.startReaper()
+	c.startTokenRefresh(tokenExpiry)
+	c.logger().Debug("copilot client started")
+	return nil
+}
+
+// registerProviders registers the client-side providers configured in the
+// client options with a freshly connected runtime.
+func (c *Client) registerProviders(ctx context.Context) error {
 	// If a session filesystem provider was configured, register it.
 	if c.options.SessionFS != nil {
 		req := &rpc.SessionFSSetProviderRequest{
@@ -466,27 +703,35 @@ func (c *Client) Start(ctx context.Context) error {
 				Sqlite: &sqlite,
 			}
 		}
-		_, err := c.RPC.SessionFS.SetProvider(ctx, req)
-		if err != nil {
-			killErr := c.killProcess()
-			c.state = stateError
-			return errors.Join(err, killErr)
+		if _, err := c.RPC.SessionFS.SetProvider(ctx, req); err != nil {
+			return err
 		}
 	}
 
 	// If a request handler was configured, register as the inference provider.
 	if c.options.RequestHandler != nil {
 		if _, err := c.RPC.LlmInference.SetProvider(ctx); err != nil {
-			killErr := c.killProcess()
-			c.state = stateError
-			return errors.Join(err, killErr)
+			return err
 		}
 	}
-
-	c.state = stateConnected
 	return nil
 }
 
+// stopBackgroundLoops stops reconnection, heartbeat, retention and reaper
+// loops.
+// Must be called with startStopMux held.
+func (c *Client) stopBackgroundLoops() {
+	if c.reconnectCancel != nil {
+		c.reconnectCancel()
+		c.reconnectCancel = nil
+	}
+	c.stopHeartbeat()
+	c.stopRetention()
+	c.stopReaper()
+	c.stopTokenRefresh()
+	c.healthy.Store(false)
+}
+
 // Stop stops the CLI server and closes all active sessions.
 //
 // This method performs graceful cleanup:
@@ -508,6 +753,7 @@ func (c *Client) Start(ctx context.Context) error {
 //	}
 func (c *Client) Stop() error {
 	var errs []error
+	c.cancelRestart()
 
 	// Disconnect all active sessions
 	c.sessionsMux.Lock()
@@ -527,9 +773,23 @@ func (c *Client) Stop() error {
 	c.sessions = make(map[string]*Session)
 	c.sessionsMux.Unlock()
 
+	if err := c.shutdown(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// shutdown stops background loops, shuts down the runtime and closes the
+// connection, leaving sessions untouched.
+func (c *Client) shutdown() error {
+	var errs []error
+
 	c.startStopMux.Lock()
 	defer c.startStopMux.Unlock()
 
+	c.setState(ClientStateStopping, nil, ClientStateStarting, ClientStateReady, ClientStateDegraded)
+	c.stopBackgroundLoops()
+
 	if (c.process != nil || c.ffiHost != nil) && !c.isExternalServer && c.RPC != nil {
 		rpcClient := c.RPC
 		runtimeShutdownStart := time.Now()
@@ -598,14 +858,13 @@ func (c *Client) Stop() error {
 
 	c.RPC = nil
 	c.internalRPC = nil
+	c.setState(ClientStateStopped, nil)
+	c.logger().Debug("copilot client stopped")
 	return errors.Join(errs...)
 }
 
 func (c *Client) logDebugTiming(start time.Time, message string) {
-	switch strings.ToLower(c.options.LogLevel) {
-	case "debug", "all":
-		log.Printf("%s elapsed=%s", message, time.Since(start))
-	}
+	c.logger().Debug(message, "elapsed", time.Since(start))
 }
 
 // ForceStop forcefully stops the CLI server without graceful cleanup.
@@ -642,9 +901,13 @@ func (c *Client) ForceStop() {
 	c.sessions = make(map[string]*Session)
 	c.sessionsMux.Unlock()
 
+	c.cancelRestart()
 	c.startStopMux.Lock()
 	defer c.startStopMux.Unlock()
 
+	c.setState(ClientStateStopping, nil, ClientStateStarting, ClientStateReady, ClientStateDegraded)
+	c.stopBackgroundLoops()
+
 	// Kill CLI process (only if we spawned it)
 	// This is a fallback in case the process wasn't killed above (e.g. if Start hadn't set
 	// osProcess yet), or if the process was restarted and osProcess now points to a new process.
@@ -683,6 +946,7 @@ func (c *Client) ForceStop() {
 
 	c.RPC = nil
 	c.internalRPC = nil
+	c.setState(ClientStateStopped, nil)
 }
 
 func (c *Client) ensureConnected(ctx context.Context) error {
@@ -751,12 +1015,39 @@ func extractTransformCallbacks(config *SystemMessageConfig) (*SystemMessageConfi
 }
 
 func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Session, error) {
+	begin := time.Now()
+	var session *Session
+	err := c.retry(ctx, "create session", func() (err error) {
+		session, err = c.createSession(ctx, config)
+		return err
+	})
+	c.recordOperation(TelemetryEventSessionCreate, begin, err, config)
+	return session, err
+}
+
+func (c *Client) createSession(ctx context.Context, config *SessionConfig) (_ *Session, err error) {
 	if config == nil {
 		config = &SessionConfig{}
 	}
+	if err := validateMCPServers(config.MCPServers, config.CustomAgents); err != nil {
+		return nil, err
+	}
+	c.reportDeprecatedMCPServers(config.MCPServers, config.CustomAgents)
+	if err := validateProviders(config.Provider, config.Providers); err != nil {
+		return nil, err
+	}
+	if err := validateSessionMetadata(config.Metadata); err != nil {
+		return nil, err
+	}
+	if config.Metadata != nil && config.Cloud != nil {
+		return nil, errors.New("Metadata is not supported for cloud sessions")
+	}
+	if err := c.checkOfflineSession(config.Provider != nil || len(config.Providers) > 0, config.Cloud != nil); err != nil {
+		return nil, err
+	}
 
 	if err := c.ensureConnected(ctx); err != nil {
-		return nil, err
+		return nil, jsonrpc2.NotSent(err)
 	}
 
 	c.applyConfigDefaultsForMode(config)
@@ -777,7 +1068,50 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	req.EnableHostGitOperations = config.EnableHostGitOperations
 	req.EnableSessionStore = config.EnableSessionStore
 	req.EnableSkills = config.EnableSkills
-	req.Tools = config.Tools
+	egress, err := newEgressGuard(config.EgressPolicy, c.options.Network)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := newWorkspaceFolders(config.WorkspaceFolders, config.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	workingDirectory := folders.workingDirectory(config.WorkingDirectory)
+	paths, err := newPathGuard(config.AllowedPaths, config.DeniedPaths, workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	rememberedPermissions, err := loadRememberedPermissions(ctx, config.PermissionStore)
+	if err != nil {
+		return nil, err
+	}
+	timeContext, err := newTimeContext(config.TimeContext)
+	if err != nil {
+		return nil, err
+	}
+	segmenter, err := newDeltaSegmenter(config.DeltaSegmentation)
+	if err != nil {
+		return nil, err
+	}
+	output, err := newOutputFormatter(config.OutputProfile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.BusyPolicy.validate(); err != nil {
+		return nil, err
+	}
+	locale, err := newLocaleState(config.Locale)
+	if err != nil {
+		return nil, err
+	}
+	tools, shell := withPTYShell(config.Tools, config.PTYShell, config.ShellEnvPolicy, egress)
+	if err := paths.checkShell(shell); err != nil {
+		return nil, err
+	}
+	if err := config.ShellEnvPolicy.checkShell(shell); err != nil {
+		return nil, err
+	}
+	req.Tools = tools
 	systemMessage := c.systemMessageForMode(config.SystemMessage)
 	wireSystemMessage, transformCallbacks := extractTransformCallbacks(systemMessage)
 	req.SystemMessage = wireSystemMessage
@@ -789,7 +1123,37 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	req.ExcludedTools = excludedTools
 	req.ToolFilterPrecedence = precedence
 	req.ExcludedBuiltInAgents = config.ExcludedBuiltInAgents
-	req.Provider = config.Provider
+	if err := config.Generation.validate(config.Provider); err != nil {
+		return nil, err
+	}
+	bedrock, wireProvider, err := c.startBedrockProxy(config.Provider, config.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			bedrock.close()
+		}
+	}()
+	vertex, wireProvider, err := c.vertexWireProvider(wireProvider)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			vertex.close()
+		}
+	}()
+	seed, wireProvider, err := c.startSeedProxy(config.Generation, wireProvider)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			seed.close()
+		}
+	}()
+	req.Provider = wireProvider
 	req.Capi = config.Capi
 	req.Providers = config.Providers
 	req.Models = config.Models
@@ -801,7 +1165,7 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	req.CoauthorEnabled = config.CoauthorEnabled
 	req.ManageScheduleEnabled = config.ManageScheduleEnabled
 	req.ModelCapabilities = config.ModelCapabilities
-	req.WorkingDirectory = config.WorkingDirectory
+	req.WorkingDirectory = workingDirectory
 	req.MCPServers = config.MCPServers
 	req.MCPOAuthTokenStorage = config.MCPOAuthTokenStorage
 	req.EnvValueMode = "direct"
@@ -870,14 +1234,19 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		config.Hooks.OnUserPromptSubmitted != nil ||
 		config.Hooks.OnSessionStart != nil ||
 		config.Hooks.OnSessionEnd != nil ||
-		config.Hooks.OnErrorOccurred != nil) {
+		config.Hooks.OnErrorOccurred != nil ||
+		config.Hooks.OnPreCompact != nil ||
+		config.Hooks.OnSuspiciousContent != nil) {
+		req.Hooks = Bool(true)
+	}
+	if timeContext != nil || locale != nil || output != nil || folders != nil || config.ScratchDir != nil {
 		req.Hooks = Bool(true)
 	}
 	if config.OnPermissionRequest != nil {
 		req.RequestPermission = Bool(true)
 	}
 
-	traceparent, tracestate := getTraceContext(ctx)
+	traceparent, tracestate := getTraceContext(c.options.TracePropagator, ctx)
 	req.Traceparent = traceparent
 	req.Tracestate = tracestate
 
@@ -906,9 +1275,31 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	// routed to a registered session.
 	initializeSession := func(sessionID string) (*Session, error) {
 		s := newSession(sessionID, c.client, "")
-
-		s.registerTools(config.Tools)
+		s.owner = c
+
+		s.registerTools(tools)
+		s.attachPTYShell(shell)
+		s.attachEgress(egress)
+		s.attachPaths(paths)
+		s.bedrock = bedrock
+		s.vertex = vertex
+		s.seed = seed
+		s.generation = config.Generation
+		s.attachCircuitBreaker(newCircuitBreaker(config.CircuitBreaker))
+		s.permissions.init(config.PermissionStore, rememberedPermissions)
+		if config.URLAttachments != nil {
+			s.urlAttachments = *config.URLAttachments
+		}
+		s.timeContext = timeContext
+		s.segmenter = segmenter
+		s.attachOutputProfile(output)
+		s.workingDirectory = workingDirectory
+		s.folders = folders
+		s.scratch = newScratchDir(config.ScratchDir, s)
+		s.busyPolicy = config.BusyPolicy
+		s.locale = locale
 		s.registerPermissionHandler(config.OnPermissionRequest)
+		s.permissionTimeout = permissionTimeout{config.PermissionTimeout, config.PermissionTimeoutDecision}
 		s.registerMCPAuthHandler(config.OnMCPAuthRequest)
 		if config.OnUserInputRequest != nil {
 			s.registerUserInputHandler(config.OnUserInputRequest)
@@ -937,10 +1328,13 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		if config.CanvasHandler != nil {
 			s.registerCanvasHandler(config.CanvasHandler)
 		}
-		if bearerTokenProviders := collectBearerTokenProviders(config.Provider, config.Providers); bearerTokenProviders != nil {
+		if bearerTokenProviders := collectBearerTokenProviders(wireProvider, config.Providers); bearerTokenProviders != nil {
 			s.registerBearerTokenProviders(bearerTokenProviders)
 		}
 
+		// Kept so the session can be re-attached after a reconnect or
+		// restart, which can happen as soon as it is registered.
+		s.attachRequest, _ = json.Marshal(req)
 		c.sessionsMux.Lock()
 		c.sessions[sessionID] = s
 		c.sessionsMux.Unlock()
@@ -1053,6 +1447,18 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	session.workspacePath = response.WorkspacePath
 	session.setCapabilities(response.Capabilities)
 
+	if config.Metadata != nil {
+		if err := c.saveSessionMetadata(ctx, session.SessionID, config.Metadata); err != nil {
+			// Without its metadata the session would be missing from
+			// filtered listings, so do not leave it behind.
+			c.sessionsMux.Lock()
+			delete(c.sessions, registeredSessionID)
+			c.sessionsMux.Unlock()
+			session.Disconnect()
+			return nil, fmt.Errorf("failed to save metadata for session %s: %w", session.SessionID, err)
+		}
+	}
+
 	if err := c.updateSessionOptionsForMode(ctx, session, optBackInFields{
 		SkipCustomInstructions: config.SkipCustomInstructions,
 		CustomAgentsLocalOnly:  config.CustomAgentsLocalOnly,
@@ -1062,6 +1468,12 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		return nil, err
 	}
 
+	if err := folders.allow(ctx, session); err != nil {
+		return nil, err
+	}
+
+	c.claimSessions()
+	session.logger().Debug("created session")
 	return session, nil
 }
 
@@ -1089,9 +1501,26 @@ func (c *Client) ResumeSession(ctx context.Context, sessionID string, config *Re
 //	    Tools: []copilot.Tool{myNewTool},
 //	})
 func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string, config *ResumeSessionConfig) (*Session, error) {
+	begin := time.Now()
+	session, err := c.resumeSession(ctx, sessionID, config)
+	c.recordOperation(TelemetryEventSessionResume, begin, err, config)
+	return session, err
+}
+
+func (c *Client) resumeSession(ctx context.Context, sessionID string, config *ResumeSessionConfig) (_ *Session, err error) {
 	if config == nil {
 		config = &ResumeSessionConfig{}
 	}
+	if err := validateMCPServers(config.MCPServers, config.CustomAgents); err != nil {
+		return nil, err
+	}
+	c.reportDeprecatedMCPServers(config.MCPServers, config.CustomAgents)
+	if err := validateProviders(config.Provider, config.Providers); err != nil {
+		return nil, err
+	}
+	if err := c.checkOfflineSession(config.Provider != nil || len(config.Providers) > 0, false); err != nil {
+		return nil, err
+	}
 
 	if err := c.ensureConnected(ctx); err != nil {
 		return nil, err
@@ -1109,8 +1538,81 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 	systemMessage := c.systemMessageForMode(config.SystemMessage)
 	wireSystemMessage, transformCallbacks := extractTransformCallbacks(systemMessage)
 	req.SystemMessage = wireSystemMessage
-	req.Tools = config.Tools
-	req.Provider = config.Provider
+	egress, err := newEgressGuard(config.EgressPolicy, c.options.Network)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := newWorkspaceFolders(config.WorkspaceFolders, config.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	workingDirectory := folders.workingDirectory(config.WorkingDirectory)
+	paths, err := newPathGuard(config.AllowedPaths, config.DeniedPaths, workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	rememberedPermissions, err := loadRememberedPermissions(ctx, config.PermissionStore)
+	if err != nil {
+		return nil, err
+	}
+	timeContext, err := newTimeContext(config.TimeContext)
+	if err != nil {
+		return nil, err
+	}
+	segmenter, err := newDeltaSegmenter(config.DeltaSegmentation)
+	if err != nil {
+		return nil, err
+	}
+	output, err := newOutputFormatter(config.OutputProfile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.BusyPolicy.validate(); err != nil {
+		return nil, err
+	}
+	locale, err := newLocaleState(config.Locale)
+	if err != nil {
+		return nil, err
+	}
+	tools, shell := withPTYShell(config.Tools, config.PTYShell, config.ShellEnvPolicy, egress)
+	if err := paths.checkShell(shell); err != nil {
+		return nil, err
+	}
+	if err := config.ShellEnvPolicy.checkShell(shell); err != nil {
+		return nil, err
+	}
+	req.Tools = tools
+	if err := config.Generation.validate(config.Provider); err != nil {
+		return nil, err
+	}
+	bedrock, wireProvider, err := c.startBedrockProxy(config.Provider, config.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			bedrock.close()
+		}
+	}()
+	vertex, wireProvider, err := c.vertexWireProvider(wireProvider)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			vertex.close()
+		}
+	}()
+	seed, wireProvider, err := c.startSeedProxy(config.Generation, wireProvider)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			seed.close()
+		}
+	}()
+	req.Provider = wireProvider
 	req.Capi = config.Capi
 	req.Providers = config.Providers
 	req.Models = config.Models
@@ -1151,10 +1653,15 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		config.Hooks.OnUserPromptSubmitted != nil ||
 		config.Hooks.OnSessionStart != nil ||
 		config.Hooks.OnSessionEnd != nil ||
-		config.Hooks.OnErrorOccurred != nil) {
+		config.Hooks.OnErrorOccurred != nil ||
+		config.Hooks.OnPreCompact != nil ||
+		config.Hooks.OnSuspiciousContent != nil) {
+		req.Hooks = Bool(true)
+	}
+	if timeContext != nil || locale != nil || output != nil || folders != nil || config.ScratchDir != nil {
 		req.Hooks = Bool(true)
 	}
-	req.WorkingDirectory = config.WorkingDirectory
+	req.WorkingDirectory = workingDirectory
 	req.ConfigDir = config.ConfigDirectory
 	req.EnableConfigDiscovery = config.EnableConfigDiscovery
 	req.SkipEmbeddingRetrieval = config.SkipEmbeddingRetrieval
@@ -1219,16 +1726,38 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		req.RequestMCPApps = Bool(true)
 	}
 
-	traceparent, tracestate := getTraceContext(ctx)
+	traceparent, tracestate := getTraceContext(c.options.TracePropagator, ctx)
 	req.Traceparent = traceparent
 	req.Tracestate = tracestate
 
 	// Create and register the session before issuing the RPC so that
 	// events emitted by the CLI (e.g. session.start) are not dropped.
 	session := newSession(sessionID, c.client, "")
-
-	session.registerTools(config.Tools)
+	session.owner = c
+
+	session.registerTools(tools)
+	session.attachPTYShell(shell)
+	session.attachEgress(egress)
+	session.attachPaths(paths)
+	session.bedrock = bedrock
+	session.vertex = vertex
+	session.seed = seed
+	session.generation = config.Generation
+	session.attachCircuitBreaker(newCircuitBreaker(config.CircuitBreaker))
+	session.permissions.init(config.PermissionStore, rememberedPermissions)
+	if config.URLAttachments != nil {
+		session.urlAttachments = *config.URLAttachments
+	}
+	session.timeContext = timeContext
+	session.segmenter = segmenter
+	session.attachOutputProfile(output)
+	session.workingDirectory = workingDirectory
+	session.folders = folders
+	session.scratch = newScratchDir(config.ScratchDir, session)
+	session.busyPolicy = config.BusyPolicy
+	session.locale = locale
 	session.registerPermissionHandler(config.OnPermissionRequest)
+	session.permissionTimeout = permissionTimeout{config.PermissionTimeout, config.PermissionTimeoutDecision}
 	session.registerMCPAuthHandler(config.OnMCPAuthRequest)
 	if config.OnUserInputRequest != nil {
 		session.registerUserInputHandler(config.OnUserInputRequest)
@@ -1257,10 +1786,13 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 	if config.CanvasHandler != nil {
 		session.registerCanvasHandler(config.CanvasHandler)
 	}
-	if bearerTokenProviders := collectBearerTokenProviders(config.Provider, config.Providers); bearerTokenProviders != nil {
+	if bearerTokenProviders := collectBearerTokenProviders(wireProvider, config.Providers); bearerTokenProviders != nil {
 		session.registerBearerTokenProviders(bearerTokenProviders)
 	}
 
+	// Kept so the session can be re-attached after a reconnect or restart,
+	// which can happen as soon as it is registered.
+	session.attachRequest, _ = json.Marshal(req)
 	c.sessionsMux.Lock()
 	c.sessions[sessionID] = session
 	c.sessionsMux.Unlock()
@@ -1325,13 +1857,21 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		return nil, err
 	}
 
+	if err := folders.allow(ctx, session); err != nil {
+		return nil, err
+	}
+
+	c.claimSessions()
+	session.logger().Debug("resumed session")
 	return session, nil
 }
 
 // ListSessions returns metadata about all sessions known to the server.
 //
 // Returns a list of SessionMetadata for all available sessions, including their IDs,
-// timestamps, optional summaries, and context information.
+// timestamps, models, optional summaries, and context information, ordered most
+// recently modified first. Use it to offer a "recent conversations" picker and pass
+// the chosen ID to [Client.ResumeSession].
 //
 // An optional filter can be provided to filter sessions by working directory, git root, repository, or branch.
 //
@@ -1342,7 +1882,7 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 //	    log.Fatal(err)
 //	}
 //	for _, session := range sessions {
-//	    fmt.Printf("Session: %s\n", session.SessionID)
+//	    fmt.Printf("%s  %s  %s\n", session.SessionID, session.ModifiedTime.Format(time.DateTime), session.Model)
 //	}
 //
 // Example with filter:
@@ -1367,6 +1907,15 @@ func (c *Client) ListSessions(ctx context.Context, filter *SessionListFilter) ([
 		return nil, fmt.Errorf("failed to unmarshal sessions response: %w", err)
 	}
 
+	slices.SortStableFunc(response.Sessions, func(a, b SessionMetadata) int {
+		return b.ModifiedTime.Compare(a.ModifiedTime)
+	})
+	c.fillSessionMetadata(ctx, response.Sessions)
+	if filter != nil && len(filter.Metadata) > 0 {
+		response.Sessions = slices.DeleteFunc(response.Sessions, func(session SessionMetadata) bool {
+			return !matchesMetadata(session.Metadata, filter.Metadata)
+		})
+	}
 	return response.Sessions, nil
 }
 
@@ -1399,7 +1948,12 @@ func (c *Client) GetSessionMetadata(ctx context.Context, sessionID string) (*Ses
 		return nil, fmt.Errorf("failed to unmarshal session metadata response: %w", err)
 	}
 
-	return response.Session, nil
+	if response.Session == nil {
+		return nil, nil
+	}
+	sessions := []SessionMetadata{*response.Session}
+	c.fillSessionMetadata(ctx, sessions)
+	return &sessions[0], nil
 }
 
 // DeleteSession permanently deletes a session and all its data from disk,
@@ -1440,8 +1994,12 @@ func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 
 	// Remove from local sessions map if present
 	c.sessionsMux.Lock()
+	session := c.sessions[sessionID]
 	delete(c.sessions, sessionID)
 	c.sessionsMux.Unlock()
+	if session != nil {
+		session.scratch.remove()
+	}
 
 	return nil
 }
@@ -1732,6 +2290,11 @@ func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
 	}
 
 	var models []ModelInfo
+	if c.onListModels == nil {
+		if err := c.requireOnline("ListModels (set ClientOptions.OnListModels)"); err != nil {
+			return nil, err
+		}
+	}
 	if c.onListModels != nil {
 		// Use custom handler instead of CLI RPC
 		var err error
@@ -1819,11 +2382,11 @@ func (c *Client) verifyProtocolVersion(ctx context.Context) error {
 	}
 
 	if serverVersion == nil {
-		return fmt.Errorf("SDK protocol version mismatch: SDK supports versions %d-%d, but server does not report a protocol version. Please update your server to ensure compatibility", minProtocolVersion, maxVersion)
+		return fmt.Errorf("%w: SDK supports versions %d-%d, but server does not report a protocol version. Please update your server to ensure compatibility", ErrProtocolMismatch, minProtocolVersion, maxVersion)
 	}
 
 	if *serverVersion < minProtocolVersion || *serverVersion > maxVersion {
-		return fmt.Errorf("SDK protocol version mismatch: SDK supports versions %d-%d, but server reports version %d. Please update your SDK or server to ensure compatibility", minProtocolVersion, maxVersion, *serverVersion)
+		return fmt.Errorf("%w: SDK supports versions %d-%d, but server reports version %d. Please update your SDK or server to ensure compatibility", ErrProtocolMismatch, minProtocolVersion, maxVersion, *serverVersion)
 	}
 
 	c.negotiatedProtocolVersion = *serverVersion
@@ -1876,17 +2439,18 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 	}
 
 	// Add auth-related flags
-	if c.options.GitHubToken != "" {
+	gitHubToken := c.gitHubToken()
+	if gitHubToken != "" {
 		args = append(args, "--auth-token-env", "COPILOT_SDK_AUTH_TOKEN")
 	}
-	// Default useLoggedInUser to false when GitHubToken is provided
+	// Default useLoggedInUser to false when a token is provided
 	useLoggedInUser := true
 	if c.options.UseLoggedInUser != nil {
 		useLoggedInUser = *c.options.UseLoggedInUser
-	} else if c.options.GitHubToken != "" {
+	} else if c.options.GitHubToken != "" || c.options.TokenProvider != nil {
 		useLoggedInUser = false
 	}
-	if !useLoggedInUser {
+	if !useLoggedInUser || c.options.Offline {
 		args = append(args, "--no-auto-login")
 	}
 
@@ -1917,8 +2481,8 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 	}
 
 	c.process.Env = append([]string{}, c.options.Env...)
-	if c.options.GitHubToken != "" {
-		c.process.Env = setEnvValue(c.process.Env, "COPILOT_SDK_AUTH_TOKEN", c.options.GitHubToken)
+	if gitHubToken != "" {
+		c.process.Env = setEnvValue(c.process.Env, "COPILOT_SDK_AUTH_TOKEN", gitHubToken)
 	}
 
 	if c.effectiveConnectionToken != "" {
@@ -1929,10 +2493,16 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		c.process.Env = setEnvValue(c.process.Env, "COPILOT_HOME", c.options.BaseDirectory)
 	}
 
-	if c.options.Mode == ModeEmpty {
+	if c.options.Mode == ModeEmpty || c.options.TokenProvider != nil {
 		c.process.Env = setEnvValue(c.process.Env, "COPILOT_DISABLE_KEYTAR", "1")
 	}
 
+	if c.options.Network != nil {
+		for key, value := range c.options.Network.environment() {
+			c.process.Env = setEnvValue(c.process.Env, key, value)
+		}
+	}
+
 	if c.options.Telemetry != nil {
 		t := c.options.Telemetry
 		c.process.Env = setEnvValue(c.process.Env, "COPILOT_OTEL_ENABLED", "true")
@@ -1972,10 +2542,10 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 			return fmt.Errorf("failed to create stdout pipe: %w", err)
 		}
 
-		c.process.Stderr = truncbuffer.NewTruncBuffer(stderrBufferSize)
+		c.process.Stderr = newCLIStderr(c.logger())
 
 		if err := c.process.Start(); err != nil {
-			return fmt.Errorf("failed to start CLI server: %w", err)
+			return fmt.Errorf("failed to start CLI server: %w", cliStartError(err))
 		}
 
 		c.monitorProcess()
@@ -1983,13 +2553,23 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		// Create JSON-RPC client immediately
 		c.client = jsonrpc2.NewClient(stdin, stdout)
 		c.client.SetProcessDone(c.processDone, c.processErrorPtr)
+		client := c.client
 		c.client.SetOnClose(func() {
 			// Run in a goroutine to avoid deadlocking with Stop/ForceStop,
 			// which hold startStopMux while waiting for readLoop to finish.
 			go func() {
+				c.startStopMux.RLock()
+				replaced := c.client != client
+				c.startStopMux.RUnlock()
+				if replaced {
+					return // closed by Stop or Restart
+				}
+				c.markUnhealthy(ErrConnectionLost)
 				c.startStopMux.Lock()
 				defer c.startStopMux.Unlock()
-				c.state = stateDisconnected
+				if c.client == client {
+					c.state = stateDisconnected
+				}
 			}()
 		})
 		c.RPC = rpc.NewServerRPC(c.client)
@@ -2005,10 +2585,10 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 			return fmt.Errorf("failed to create stdout pipe: %w", err)
 		}
 
-		c.process.Stderr = truncbuffer.NewTruncBuffer(stderrBufferSize)
+		c.process.Stderr = newCLIStderr(c.logger())
 
 		if err := c.process.Start(); err != nil {
-			return fmt.Errorf("failed to start CLI server: %w", err)
+			return fmt.Errorf("failed to start CLI server: %w", cliStartError(err))
 		}
 
 		c.monitorProcess()
@@ -2025,7 +2605,7 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 			case <-ctx.Done():
 				killErr := c.killProcess()
 				baseErr := fmt.Errorf("failed waiting for CLI server to start: %w", ctx.Err())
-				if buf, ok := proc.Stderr.(*truncbuffer.TruncBuffer); ok {
+				if buf, ok := proc.Stderr.(*cliStderr); ok {
 					if stderr := strings.TrimSpace(buf.String()); stderr != "" {
 						baseErr = fmt.Errorf("%w; stderr: %s", baseErr, stderr)
 					}
@@ -2034,7 +2614,7 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 			case <-c.processDone:
 				killErr := c.killProcess()
 				baseErr := errors.New("CLI server process exited before reporting port")
-				if buf, ok := proc.Stderr.(*truncbuffer.TruncBuffer); ok {
+				if buf, ok := proc.Stderr.(*cliStderr); ok {
 					if stderr := strings.TrimSpace(buf.String()); stderr != "" {
 						baseErr = fmt.Errorf("%w; stderr: %s", baseErr, stderr)
 					}
@@ -2077,7 +2657,7 @@ func (c *Client) startInProcess(ctx context.Context) error {
 		runtimePath = embeddedcli.Path()
 	}
 	if runtimePath == "" {
-		return errors.New("in-process runtime unavailable: set COPILOT_CLI_PATH to a compatible runtime package or build with the bundled embedded runtime")
+		return fmt.Errorf("in-process runtime unavailable: set COPILOT_CLI_PATH to a compatible runtime package or build with the bundled embedded runtime: %w", ErrCLINotFound)
 	}
 
 	config := c.inProcessHostConfig()
@@ -2114,6 +2694,7 @@ func (c *Client) startInProcess(ctx context.Context) error {
 		// Run in a goroutine to avoid deadlocking with Stop/ForceStop, which hold
 		// startStopMux while waiting for readLoop to finish.
 		go func() {
+			c.markUnhealthy(ErrConnectionLost)
 			c.startStopMux.Lock()
 			defer c.startStopMux.Unlock()
 			c.state = stateDisconnected
@@ -2131,16 +2712,17 @@ func (c *Client) inProcessHostConfig() inProcessHostConfig {
 	if c.options.LogLevel != "" {
 		args = append(args, "--log-level", c.options.LogLevel)
 	}
-	if c.options.GitHubToken != "" {
+	gitHubToken := c.gitHubToken()
+	if gitHubToken != "" {
 		args = append(args, "--auth-token-env", "COPILOT_SDK_AUTH_TOKEN")
 	}
 	useLoggedInUser := true
 	if c.options.UseLoggedInUser != nil {
 		useLoggedInUser = *c.options.UseLoggedInUser
-	} else if c.options.GitHubToken != "" {
+	} else if c.options.GitHubToken != "" || c.options.TokenProvider != nil {
 		useLoggedInUser = false
 	}
-	if !useLoggedInUser {
+	if !useLoggedInUser || c.options.Offline {
 		args = append(args, "--no-auto-login")
 	}
 	if c.options.SessionIdleTimeoutSeconds > 0 {
@@ -2151,15 +2733,18 @@ func (c *Client) inProcessHostConfig() inProcessHostConfig {
 	}
 
 	environment := make(map[string]string)
-	if c.options.GitHubToken != "" {
-		environment["COPILOT_SDK_AUTH_TOKEN"] = c.options.GitHubToken
+	if gitHubToken != "" {
+		environment["COPILOT_SDK_AUTH_TOKEN"] = gitHubToken
 	}
 	if c.options.BaseDirectory != "" {
 		environment["COPILOT_HOME"] = c.options.BaseDirectory
 	}
-	if c.options.Mode == ModeEmpty {
+	if c.options.Mode == ModeEmpty || c.options.TokenProvider != nil {
 		environment["COPILOT_DISABLE_KEYTAR"] = "1"
 	}
+	if c.options.Network != nil {
+		maps.Copy(environment, c.options.Network.environment())
+	}
 
 	return inProcessHostConfig{
 		Environment: environment,
@@ -2210,10 +2795,14 @@ func (c *Client) monitorProcess() {
 	c.osProcess.Store(proc.Process)
 	var processError error
 	c.processErrorPtr = &processError
+	logger := c.logger()
+	logger.Debug("started copilot runtime", "path", proc.Path, "pid", proc.Process.Pid)
 	go func() {
 		waitErr := proc.Wait()
+		logger.Debug("copilot runtime exited", "pid", proc.Process.Pid, "error", waitErr)
 		var stderrOutput string
-		if buf, ok := proc.Stderr.(*truncbuffer.TruncBuffer); ok {
+		if buf, ok := proc.Stderr.(*cliStderr); ok {
+			buf.flush()
 			stderrOutput = strings.TrimSpace(buf.String())
 		}
 		if waitErr != nil {
@@ -2230,6 +2819,7 @@ func (c *Client) monitorProcess() {
 			}
 		}
 		close(done)
+		go c.recoverCrash(proc.Process, processError)
 	}()
 }
 
@@ -2240,10 +2830,27 @@ func (c *Client) connectToServer(ctx context.Context) error {
 		return nil
 	}
 
+	if c.socketPath != "" {
+		return c.connectViaUnixSocket(ctx)
+	}
+
 	// Connect via TCP
 	return c.connectViaTCP(ctx)
 }
 
+// connectViaUnixSocket connects to the CLI server via a unix domain socket.
+func (c *Client) connectViaUnixSocket(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, "unix", c.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CLI server at %s: %w", c.socketPath, err)
+	}
+	c.attachConn(conn)
+	return nil
+}
+
 // connectViaTCP connects to the CLI server via TCP socket.
 func (c *Client) connectViaTCP(ctx context.Context) error {
 	if c.actualPort == 0 {
@@ -2261,6 +2868,70 @@ func (c *Client) connectViaTCP(ctx context.Context) error {
 		return fmt.Errorf("failed to connect to CLI server at %s: %w", address, err)
 	}
 
+	if c.tlsConfig != nil {
+		cfg := c.tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = c.actualHost
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(dialCtx); err != nil {
+			conn.Close()
+			return fmt.Errorf("TLS handshake with CLI server at %s failed: %w", address, err)
+		}
+		conn = tlsConn
+	}
+
+	if c.upgradeHeaders != nil {
+		upgraded, err := upgradeConn(dialCtx, conn, address, c.upgradePath, c.upgradeHeaders)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to upgrade connection to CLI server at %s: %w", address, err)
+		}
+		conn = upgraded
+	}
+
+	c.attachConn(conn)
+	return nil
+}
+
+// upgradeJSONRPCProtocol is the protocol name sent in the Upgrade header when
+// connecting through an authenticating gateway.
+const upgradeJSONRPCProtocol = "copilot-jsonrpc"
+
+// upgradeConn sends an HTTP/1.1 upgrade request carrying headers and waits for
+// a 101 Switching Protocols response, after which conn carries raw JSON-RPC.
+func upgradeConn(ctx context.Context, conn net.Conn, host, path string, headers http.Header) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers.Clone()
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", upgradeJSONRPCProtocol)
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	// The reader may have buffered bytes past the response headers.
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// attachConn wires a JSON-RPC client to an established socket connection.
+func (c *Client) attachConn(conn net.Conn) {
 	c.conn = conn
 
 	// Create JSON-RPC client with the connection
@@ -2268,23 +2939,40 @@ func (c *Client) connectViaTCP(ctx context.Context) error {
 	if c.processDone != nil {
 		c.client.SetProcessDone(c.processDone, c.processErrorPtr)
 	}
+	client := c.client
 	c.client.SetOnClose(func() {
 		go func() {
+			c.startStopMux.RLock()
+			replaced := c.client != client
+			c.startStopMux.RUnlock()
+			if replaced {
+				return // closed by Stop or Restart
+			}
+			c.markUnhealthy(ErrConnectionLost)
+			if c.isExternalServer && c.options.Reconnect != nil {
+				c.reconnect(client)
+				return
+			}
 			c.startStopMux.Lock()
 			defer c.startStopMux.Unlock()
-			c.state = stateDisconnected
+			if c.client == client {
+				c.state = stateDisconnected
+			}
 		}()
 	})
 	c.RPC = rpc.NewServerRPC(c.client)
 	c.internalRPC = rpc.NewInternalServerRPC(c.client)
 	c.setupNotificationHandler()
 	c.client.Start()
-
-	return nil
 }
 
 // setupNotificationHandler configures handlers for session events and RPC requests.
 func (c *Client) setupNotificationHandler() {
+	c.client.SetLogger(c.logger())
+	c.client.SetErrorMapper(classifyRuntimeError)
+	c.client.SetOnPanic(func(method string, value any, stack []byte) {
+		c.reportInternalError(&InternalError{Source: "rpc handler", Name: method, Value: value, Stack: stack})
+	})
 	c.client.SetRequestHandler("session.event", jsonrpc2.NotificationHandlerFor(c.handleSessionEvent))
 	c.client.SetRequestHandler("session.lifecycle", jsonrpc2.NotificationHandlerFor(c.handleLifecycleEvent))
 	c.client.SetRequestHandler("userInput.request", jsonrpc2.RequestHandlerFor(c.handleUserInputRequest))
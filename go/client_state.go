@@ -0,0 +1,183 @@
+package copilot
+
+import (
+	"slices"
+)
+
+// ClientState is the lifecycle state of a [Client], as returned by
+// [Client.State] and reported to [Client.OnStateChange] handlers.
+type ClientState string
+
+const (
+	// ClientStateStopped means the client holds no runtime: it has not been
+	// started, was stopped, or failed to start.
+	ClientStateStopped ClientState = "stopped"
+	// ClientStateStarting means [Client.Start] is launching or connecting to
+	// the runtime.
+	ClientStateStarting ClientState = "starting"
+	// ClientStateReady means the client is connected and the runtime is
+	// answering.
+	ClientStateReady ClientState = "ready"
+	// ClientStateDegraded means the client is started but the runtime is not
+	// answering: the connection was lost or heartbeats are failing. Calls are
+	// likely to fail until it is reconnected, relaunched or restarted.
+	// While [ClientOptions.Reconnect] restores the connection, each failed
+	// attempt is reported as a change from this state to itself.
+	ClientStateDegraded ClientState = "degraded"
+	// ClientStateStopping means [Client.Stop], [Client.ForceStop] or
+	// [Client.Restart] is shutting the runtime down.
+	ClientStateStopping ClientState = "stopping"
+)
+
+// ClientStateChange describes a client lifecycle transition.
+type ClientStateChange struct {
+	// Previous is the state the client left.
+	Previous ClientState
+	// State is the state the client entered.
+	State ClientState
+	// Err is the cause of the transition: the error from [Client.Start] when
+	// entering [ClientStateStopped] from [ClientStateStarting]; the liveness
+	// failure (a missed heartbeat, [ErrConnectionLost] or a failed
+	// reconnection attempt) when entering [ClientStateDegraded]; or why the
+	// client gave up reconnecting or relaunching when entering
+	// [ClientStateStopped] from it. When entering [ClientStateReady] after a
+	// reconnection or relaunch, it joins the errors of sessions that could
+	// not be re-attached. Nil otherwise.
+	Err error
+	// Attempt is the number of reconnection attempts made so far, for
+	// changes made while [ClientOptions.Reconnect] restores a dropped
+	// connection.
+	Attempt int
+	// Crash counts the crashes of the CLI process within
+	// [RestartPolicy.Window], including this one, for the change that ends
+	// the recovery from a crash (see [ClientOptions.Restart]).
+	Crash int
+	// Reattached lists the sessions that were and were not re-attached, for
+	// entering [ClientStateReady] after a reconnection or relaunch. Sessions
+	// in [RestartResult.Failed] are no longer usable.
+	Reattached *RestartResult
+	// exitErr is why the CLI process exited, for changes with Crash set; it
+	// is reported to [Client.OnCLIRestart] handlers.
+	exitErr error
+}
+
+// ClientStateHandler handles client state changes.
+type ClientStateHandler func(change ClientStateChange)
+
+// State returns the client's lifecycle state. Use it to gate traffic or
+// report readiness; [Client.OnStateChange] reports changes as they happen.
+func (c *Client) State() ClientState {
+	c.stateMux.Lock()
+	defer c.stateMux.Unlock()
+	if c.lifecycle == "" {
+		return ClientStateStopped
+	}
+	return c.lifecycle
+}
+
+// OnStateChange subscribes to client lifecycle transitions: starting and
+// stopping, losing and regaining the runtime (see [ClientOptions.Heartbeat],
+// [ClientOptions.Reconnect] and [ClientOptions.Restart]), with the progress
+// of reconnection and the outcome of re-attaching sessions.
+//
+// Handlers are called from a background goroutine, one change at a time and
+// in the order the changes happened, so they may call any client method.
+// Returns a function that, when called, unsubscribes the handler.
+//
+// Example:
+//
+//	client.OnStateChange(func(change copilot.ClientStateChange) {
+//	    readiness.Set(change.State == copilot.ClientStateReady)
+//	    if change.Err != nil {
+//	        log.Printf("copilot client %s: %v", change.State, change.Err)
+//	    }
+//	    if change.Reattached != nil && len(change.Reattached.Failed) > 0 {
+//	        log.Printf("copilot sessions lost: %v", change.Reattached.Failed)
+//	    }
+//	})
+func (c *Client) OnStateChange(handler ClientStateHandler) func() {
+	c.lifecycleHandlersMux.Lock()
+	if c.stateHandlers == nil {
+		c.stateHandlers = make(map[uint64]ClientStateHandler)
+	}
+	c.nextLifecycleHandlerID++
+	id := c.nextLifecycleHandlerID
+	c.stateHandlers[id] = handler
+	c.lifecycleHandlersMux.Unlock()
+
+	return func() {
+		c.lifecycleHandlersMux.Lock()
+		defer c.lifecycleHandlersMux.Unlock()
+		delete(c.stateHandlers, id)
+	}
+}
+
+// setState moves the client to state, if it is in one of from (or any
+// state when from is empty), and queues the change for handlers.
+func (c *Client) setState(state ClientState, err error, from ...ClientState) {
+	c.changeState(ClientStateChange{State: state, Err: err}, from...)
+}
+
+// changeState is setState for a change with details. A change to the
+// current state is reported only when it records a reconnection attempt.
+func (c *Client) changeState(change ClientStateChange, from ...ClientState) {
+	c.stateMux.Lock()
+	defer c.stateMux.Unlock()
+	previous := c.lifecycle
+	if previous == "" {
+		previous = ClientStateStopped
+	}
+	if (previous == change.State && change.Attempt == 0) || (len(from) > 0 && !slices.Contains(from, previous)) {
+		return
+	}
+	c.lifecycle = change.State
+	change.Previous = previous
+	logger := c.logger().With("previous", previous)
+	if change.Attempt > 0 {
+		logger = logger.With("attempt", change.Attempt)
+	}
+	if change.Crash > 0 {
+		logger = logger.With("crash", change.Crash)
+	}
+	if change.Err != nil {
+		logger.Warn("copilot client "+string(change.State), "error", change.Err)
+	} else {
+		logger.Debug("copilot client " + string(change.State))
+	}
+
+	c.stateChanges = append(c.stateChanges, change)
+	if !c.deliveringStates {
+		c.deliveringStates = true
+		go c.deliverStateChanges()
+	}
+}
+
+// deliverStateChanges calls handlers for queued state changes until the
+// queue is empty.
+func (c *Client) deliverStateChanges() {
+	for {
+		c.stateMux.Lock()
+		if len(c.stateChanges) == 0 {
+			c.deliveringStates = false
+			c.stateMux.Unlock()
+			return
+		}
+		change := c.stateChanges[0]
+		c.stateChanges = c.stateChanges[1:]
+		c.stateMux.Unlock()
+
+		c.lifecycleHandlersMux.Lock()
+		handlers := make([]ClientStateHandler, 0, len(c.stateHandlers))
+		for _, handler := range c.stateHandlers {
+			handlers = append(handlers, handler)
+		}
+		c.lifecycleHandlersMux.Unlock()
+
+		for _, handler := range handlers {
+			func() {
+				defer func() { recover() }() // Ignore handler panics
+				handler(change)
+			}()
+		}
+	}
+}
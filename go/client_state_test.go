@@ -0,0 +1,98 @@
+package copilot
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClient_State(t *testing.T) {
+	watch := func(client *Client) chan ClientStateChange {
+		changes := make(chan ClientStateChange, 16)
+		client.OnStateChange(func(change ClientStateChange) { changes <- change })
+		return changes
+	}
+	next := func(t *testing.T, changes chan ClientStateChange, n int) []ClientStateChange {
+		t.Helper()
+		var got []ClientStateChange
+		for len(got) < n {
+			select {
+			case change := <-changes:
+				got = append(got, change)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %d state changes, got %v", n, got)
+			}
+		}
+		return got
+	}
+	states := func(changes []ClientStateChange) []ClientState {
+		var states []ClientState
+		for _, change := range changes {
+			states = append(states, change.State)
+		}
+		return states
+	}
+
+	t.Run("reports starting and stopping", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: server.listener.Addr().String()}})
+		changes := watch(client)
+		if client.State() != ClientStateStopped {
+			t.Errorf("expected a new client to be stopped, got %s", client.State())
+		}
+
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		if client.State() != ClientStateReady {
+			t.Errorf("expected ready after Start, got %s", client.State())
+		}
+		client.Stop()
+		client.Stop()
+
+		got := next(t, changes, 4)
+		want := []ClientState{ClientStateStarting, ClientStateReady, ClientStateStopping, ClientStateStopped}
+		if !slices.Equal(states(got), want) || got[0].Previous != ClientStateStopped {
+			t.Errorf("expected %v from stopped, got %v", want, got)
+		}
+		select {
+		case change := <-changes:
+			t.Errorf("expected a second Stop to change nothing, got %v", change)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("reports why a start failed", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Connection: StdioConnection{Path: filepath.Join(t.TempDir(), "copilot")}})
+		changes := watch(client)
+		client.Start(t.Context())
+
+		got := next(t, changes, 2)
+		if !slices.Equal(states(got), []ClientState{ClientStateStarting, ClientStateStopped}) || !errors.Is(got[1].Err, ErrCLINotFound) {
+			t.Errorf("expected starting then stopped with ErrCLINotFound, got %v", got)
+		}
+	})
+
+	t.Run("degrades while reconnecting", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{
+			Connection: UnixSocketConnection{Path: server.listener.Addr().String()},
+			Reconnect:  &ReconnectPolicy{InitialBackoff: 10 * time.Millisecond},
+		})
+		changes := watch(client)
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.Stop()
+		next(t, changes, 2)
+
+		(<-server.conns).Close()
+
+		got := next(t, changes, 2)
+		if !slices.Equal(states(got), []ClientState{ClientStateDegraded, ClientStateReady}) || !errors.Is(got[0].Err, ErrConnectionLost) {
+			t.Errorf("expected degraded with ErrConnectionLost then ready, got %v", got)
+		}
+	})
+}
@@ -1,11 +1,17 @@
 package copilot
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,7 +24,6 @@ import (
 	"time"
 
 	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
-	"github.com/github/copilot-sdk/go/internal/truncbuffer"
 	"github.com/github/copilot-sdk/go/rpc"
 )
 
@@ -486,6 +491,213 @@ func TestClient_SessionFSConfig(t *testing.T) {
 	})
 }
 
+func TestClient_UnixSocketConnection(t *testing.T) {
+	t.Run("treats the socket as an external runtime", func(t *testing.T) {
+		client := NewClient(&ClientOptions{
+			Connection: UnixSocketConnection{Path: "/tmp/copilot.sock", ConnectionToken: "secret"},
+		})
+		if client.socketPath != "/tmp/copilot.sock" {
+			t.Errorf("Expected socket path /tmp/copilot.sock, got %q", client.socketPath)
+		}
+		if !client.isExternalServer || client.useStdio {
+			t.Error("Expected an external, non-stdio connection")
+		}
+		if client.effectiveConnectionToken != "secret" {
+			t.Errorf("Expected connection token 'secret', got %q", client.effectiveConnectionToken)
+		}
+	})
+
+	t.Run("panics without a path", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for empty UnixSocketConnection path")
+			}
+		}()
+		NewClient(&ClientOptions{Connection: UnixSocketConnection{}})
+	})
+
+	t.Run("panics when GitHubToken is set", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if msg, _ := r.(string); !strings.Contains(msg, "cannot be used with UnixSocketConnection") {
+				t.Errorf("Expected panic message about auth options, got: %v", r)
+			}
+		}()
+		NewClient(&ClientOptions{
+			Connection:  UnixSocketConnection{Path: "/tmp/copilot.sock"},
+			GitHubToken: "gho_test_token",
+		})
+	})
+
+	t.Run("connects and performs the handshake over the socket", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "rt.sock")
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Skipf("unix sockets unavailable: %v", err)
+		}
+		defer listener.Close()
+
+		connectParams := make(chan json.RawMessage, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server := jsonrpc2.NewClient(conn, conn)
+			server.SetRequestHandler("connect", func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				connectParams <- append(json.RawMessage(nil), params...)
+				return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+			})
+			server.Start()
+		}()
+
+		client := NewClient(&ClientOptions{
+			Connection: UnixSocketConnection{Path: socketPath, ConnectionToken: "secret"},
+		})
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.ForceStop()
+
+		var params struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(<-connectParams, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.Token != "secret" {
+			t.Errorf("Expected token 'secret' in connect handshake, got %q", params.Token)
+		}
+	})
+}
+
+func TestClient_URIConnectionTLSAndHeaders(t *testing.T) {
+	// Borrow httptest's self-signed certificate for a raw TLS listener.
+	certServer := httptest.NewUnstartedServer(nil)
+	certServer.StartTLS()
+	certs := certServer.TLS.Certificates
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+	certServer.Close()
+
+	// serve accepts one connection, validates the upgrade request, and then
+	// answers the connect handshake over JSON-RPC, reporting its params.
+	serve := func(t *testing.T, listener net.Listener, status int) (<-chan *http.Request, <-chan json.RawMessage) {
+		requests := make(chan *http.Request, 1)
+		connectParams := make(chan json.RawMessage, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			req, err := http.ReadRequest(reader)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			requests <- req
+			fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nConnection: Upgrade\r\nUpgrade: copilot-jsonrpc\r\n\r\n", status, http.StatusText(status))
+			if status != http.StatusSwitchingProtocols {
+				conn.Close()
+				return
+			}
+			server := jsonrpc2.NewClient(conn, conn)
+			server.SetRequestHandler("connect", func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				connectParams <- params
+				return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+			})
+			server.Start()
+		}()
+		return requests, connectParams
+	}
+
+	t.Run("connects over TLS and sends upgrade headers", func(t *testing.T) {
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		requests, connectParams := serve(t, listener, http.StatusSwitchingProtocols)
+
+		client := NewClient(&ClientOptions{
+			Connection: URIConnection{
+				URL:             "https://" + listener.Addr().String() + "/rpc",
+				ConnectionToken: "secret",
+				TLS:             &tls.Config{RootCAs: roots},
+				Headers:         http.Header{"X-Tenant": []string{"acme"}},
+				BearerToken:     "tok",
+			},
+		})
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.ForceStop()
+
+		req := <-requests
+		if req.URL.Path != "/rpc" {
+			t.Errorf("Expected upgrade path /rpc, got %q", req.URL.Path)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("Expected bearer token, got %q", got)
+		}
+		if got := req.Header.Get("X-Tenant"); got != "acme" {
+			t.Errorf("Expected X-Tenant header, got %q", got)
+		}
+		if got := req.Header.Get("Upgrade"); got != "copilot-jsonrpc" {
+			t.Errorf("Expected Upgrade header, got %q", got)
+		}
+		var params struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(<-connectParams, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.Token != "secret" {
+			t.Errorf("Expected token 'secret' in connect handshake, got %q", params.Token)
+		}
+	})
+
+	t.Run("fails when the certificate is not trusted", func(t *testing.T) {
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+
+		client := NewClient(&ClientOptions{
+			Connection: URIConnection{URL: "https://" + listener.Addr().String()},
+		})
+		err = client.Start(t.Context())
+		if err == nil || !strings.Contains(err.Error(), "TLS handshake") {
+			t.Fatalf("Expected TLS handshake error, got %v", err)
+		}
+	})
+
+	t.Run("fails when the gateway rejects the upgrade", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		serve(t, listener, http.StatusUnauthorized)
+
+		client := NewClient(&ClientOptions{
+			Connection: URIConnection{URL: listener.Addr().String(), BearerToken: "bad"},
+		})
+		err = client.Start(t.Context())
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Fatalf("Expected upgrade rejection, got %v", err)
+		}
+	})
+}
+
 func TestClient_AuthOptions(t *testing.T) {
 	t.Run("should accept GitHubToken option", func(t *testing.T) {
 		client := NewClient(&ClientOptions{
@@ -623,6 +835,37 @@ func TestClient_EnvOptions(t *testing.T) {
 			t.Errorf("Expected 0 environment variables, got %d", len(client.options.Env))
 		}
 	})
+
+	t.Run("should pass bare names through from the current process", func(t *testing.T) {
+		t.Setenv("ALLOWED", "yes")
+		t.Setenv("SECRET_TOKEN", "hunter2")
+		client := NewClient(&ClientOptions{
+			Env: []string{"ALLOWED", "UNSET_VARIABLE", "FOO=bar"},
+		})
+
+		if want := []string{"ALLOWED=yes", "FOO=bar"}; !reflect.DeepEqual(client.options.Env, want) {
+			t.Errorf("Expected Env to be %v, got %v", want, client.options.Env)
+		}
+	})
+
+	t.Run("should override the current process environment with InheritEnv", func(t *testing.T) {
+		t.Setenv("KEPT", "host")
+		t.Setenv("FOO", "host")
+		client := NewClient(&ClientOptions{
+			Connection: StdioConnection{Env: []string{"FOO=bar"}},
+			InheritEnv: true,
+		})
+
+		if got := getEnvValue(client.options.Env, "KEPT"); got != "host" {
+			t.Errorf("Expected KEPT to be inherited, got %q", got)
+		}
+		if got := getEnvValue(client.options.Env, "FOO"); got != "bar" {
+			t.Errorf("Expected FOO to be overridden, got %q", got)
+		}
+		if n := strings.Count(strings.Join(client.options.Env, "\n"), "FOO="); n != 1 {
+			t.Errorf("Expected one FOO entry, got %d", n)
+		}
+	})
 }
 
 func TestClient_InProcessConnection(t *testing.T) {
@@ -3116,7 +3359,7 @@ func TestMonitorProcess_StderrCaptured(t *testing.T) {
 	client.process.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
 
 	// Replicate what startCLIServer now does: capture stderr.
-	client.process.Stderr = truncbuffer.NewTruncBuffer(stderrBufferSize)
+	client.process.Stderr = newCLIStderr(client.logger())
 
 	if err := client.process.Start(); err != nil {
 		t.Fatalf("failed to start test process: %v", err)
@@ -3148,7 +3391,7 @@ func TestMonitorProcess_StderrCapturedOnZeroExit(t *testing.T) {
 
 	stderrMsg := "warning: version mismatch, shutting down"
 	client.process = newStderrTestCommand(stderrMsg, 0)
-	client.process.Stderr = truncbuffer.NewTruncBuffer(stderrBufferSize)
+	client.process.Stderr = newCLIStderr(client.logger())
 
 	if err := client.process.Start(); err != nil {
 		t.Fatalf("failed to start test process: %v", err)
@@ -3170,13 +3413,13 @@ func TestMonitorProcess_StderrCapturedOnZeroExit(t *testing.T) {
 }
 
 // TestStartCLIServer_StderrFieldSet verifies that startCLIServer sets
-// exec.Cmd.Stderr to a *truncbuffer.TruncBuffer so CLI diagnostic output is captured.
+// exec.Cmd.Stderr to a *cliStderr so CLI diagnostic output is captured.
 func TestStartCLIServer_StderrFieldSet(t *testing.T) {
 	cmd := exec.Command(os.Args[0])
-	buf := truncbuffer.NewTruncBuffer(stderrBufferSize)
+	buf := newCLIStderr(slog.Default())
 	cmd.Stderr = buf
-	if _, ok := cmd.Stderr.(*truncbuffer.TruncBuffer); !ok {
-		t.Error("expected Stderr to be *truncbuffer.TruncBuffer after assignment")
+	if _, ok := cmd.Stderr.(*cliStderr); !ok {
+		t.Error("expected Stderr to be *cliStderr after assignment")
 	}
 }
 
@@ -3305,3 +3548,83 @@ func TestResumeSessionRequest_ExpAssignments(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateMCPServers(t *testing.T) {
+	cases := []struct {
+		name    string
+		servers map[string]MCPServerConfig
+		agents  []CustomAgentConfig
+		want    string
+	}{
+		{name: "valid", servers: map[string]MCPServerConfig{
+			"local":  MCPStdioServerConfig{Command: "node", Args: []string{"server.js"}},
+			"remote": &MCPHTTPServerConfig{URL: "https://mcp.example.com"},
+			"legacy": MCPSSEServerConfig{URL: "http://localhost:3000/sse"},
+		}},
+		{name: "missing command", servers: map[string]MCPServerConfig{"local": MCPStdioServerConfig{}}, want: `invalid MCP server "local": Command is required`},
+		{name: "relative URL", servers: map[string]MCPServerConfig{"remote": MCPHTTPServerConfig{URL: "mcp.example.com"}}, want: "absolute http or https URL"},
+		{name: "negative timeout", servers: map[string]MCPServerConfig{"s": MCPSSEServerConfig{URL: "https://x", Timeout: -1}}, want: "Timeout must not be negative"},
+		{name: "nil config", servers: map[string]MCPServerConfig{"s": nil}, want: "must not be nil"},
+		{name: "nil pointer config", servers: map[string]MCPServerConfig{"s": (*MCPStdioServerConfig)(nil)}, want: "must not be nil"},
+		{
+			name:   "custom agent server",
+			agents: []CustomAgentConfig{{Name: "reviewer", MCPServers: map[string]MCPServerConfig{"gh": MCPHTTPServerConfig{}}}},
+			want:   `custom agent "reviewer": invalid MCP server "gh": URL is required`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMCPServers(tc.servers, tc.agents)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	t.Run("CreateSession rejects invalid servers before contacting the runtime", func(t *testing.T) {
+		client, requests, cleanup := newInMemoryClient(t)
+		defer cleanup()
+		_, err := client.CreateSession(t.Context(), &SessionConfig{
+			MCPServers: map[string]MCPServerConfig{"local": MCPStdioServerConfig{Args: []string{"server.js"}}},
+		})
+		if err == nil {
+			t.Fatal("expected CreateSession to fail")
+		}
+		if got := requests.snapshot(); len(got) != 0 {
+			t.Errorf("expected no requests, got %v", got)
+		}
+	})
+}
+
+func TestClient_ListSessions(t *testing.T) {
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.list": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(`{"sessions":[
+				{"sessionId":"old","startTime":"2026-01-01T00:00:00Z","modifiedTime":"2026-01-01T01:00:00Z","summary":"Fix the build","isRemote":false},
+				{"sessionId":"new","startTime":"2026-01-02T00:00:00Z","modifiedTime":"2026-01-02T01:00:00Z","summary":"Refactor","isRemote":false},
+				{"sessionId":"bare","startTime":"2026-01-01T12:00:00Z","modifiedTime":"2026-01-01T12:00:00Z","isRemote":false}
+			]}`), nil
+		},
+	})
+
+	sessions, err := client.ListSessions(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	var ids []string
+	for _, session := range sessions {
+		ids = append(ids, session.SessionID)
+	}
+	if want := []string{"new", "bare", "old"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected most recently modified first %v, got %v", want, ids)
+	}
+	if sessions[2].Summary == nil || *sessions[2].Summary != "Fix the build" {
+		t.Errorf("expected the summary, got %+v", sessions[2])
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/github/copilot-sdk/go/cmd/bundler
+
+go 1.24
+
+require github.com/klauspost/compress v1.18.3
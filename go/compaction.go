@@ -0,0 +1,89 @@
+package copilot
+
+import (
+	"context"
+	"slices"
+)
+
+// CompactionInfo is passed to [SessionHooks.OnCompaction].
+type CompactionInfo struct {
+	SessionID string
+	// Summary is the text that replaced the compacted history in the
+	// model's context.
+	Summary string
+	// Compacted are the events the summary replaced: those recorded since
+	// the previous compaction and before this one started. It is nil if
+	// the session history could not be read.
+	Compacted []SessionEvent
+	// Data is the session.compaction_complete event's data, with token
+	// counts and the checkpoint the runtime wrote.
+	Data *SessionCompactionCompleteData
+}
+
+// CompactionHandler is called after the runtime compacts the conversation
+// history.
+type CompactionHandler func(info CompactionInfo)
+
+// observeCompaction invokes [SessionHooks.OnCompaction] for a successful
+// compaction. Reading the compacted events takes a request to the runtime,
+// so the hook runs on its own goroutine rather than holding up event
+// delivery. Called from processEvents.
+func (s *Session) observeCompaction(event SessionEvent) {
+	d, ok := event.Data.(*SessionCompactionCompleteData)
+	if !ok || !d.Success {
+		return
+	}
+	hooks := s.getHooks()
+	if hooks == nil || hooks.OnCompaction == nil {
+		return
+	}
+	go s.reportCompaction(hooks.OnCompaction, event.ID, d)
+}
+
+func (s *Session) reportCompaction(handler CompactionHandler, eventID string, data *SessionCompactionCompleteData) {
+	info := CompactionInfo{SessionID: s.SessionID, Data: data}
+	if data.SummaryContent != nil {
+		info.Summary = *data.SummaryContent
+	}
+	events, err := s.GetEvents(context.Background())
+	if err != nil {
+		s.logger().Warn("failed to read compacted history", "error", err)
+	} else {
+		info.Compacted = compactedEvents(events, eventID)
+	}
+	if s.isClosed() {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger().Error("panic in compaction hook", "error", r)
+			s.recovered("compaction hook", "", r)
+		}
+	}()
+	handler(info)
+}
+
+// compactedEvents returns the events summarized by the compaction that
+// completed with the event completeID: those after the previous
+// session.compaction_complete and before the matching
+// session.compaction_start.
+func compactedEvents(events []SessionEvent, completeID string) []SessionEvent {
+	end := slices.IndexFunc(events, func(e SessionEvent) bool { return e.ID == completeID })
+	if end < 0 {
+		end = len(events)
+	}
+	for i := end - 1; i >= 0; i-- {
+		if _, ok := events[i].Data.(*SessionCompactionStartData); ok {
+			end = i
+			break
+		}
+	}
+	start := 0
+	for i := end - 1; i >= 0; i-- {
+		if _, ok := events[i].Data.(*SessionCompactionCompleteData); ok {
+			start = i + 1
+			break
+		}
+	}
+	return slices.Clone(events[start:end])
+}
@@ -0,0 +1,69 @@
+package copilot
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+const testCompactedHistory = `{"events": [
+	{"id": "u1", "timestamp": "2026-01-01T00:00:00Z", "type": "user.message", "data": {"content": "first"}},
+	{"id": "c1", "timestamp": "2026-01-01T00:00:01Z", "type": "session.compaction_start", "data": {}},
+	{"id": "c2", "timestamp": "2026-01-01T00:00:02Z", "type": "session.compaction_complete", "data": {"success": true, "summaryContent": "earlier"}},
+	{"id": "u2", "timestamp": "2026-01-01T00:00:03Z", "type": "user.message", "data": {"content": "second"}},
+	{"id": "a2", "timestamp": "2026-01-01T00:00:04Z", "type": "assistant.message", "data": {"messageId": "m2", "content": "done"}},
+	{"id": "c3", "timestamp": "2026-01-01T00:00:05Z", "type": "session.compaction_start", "data": {}},
+	{"id": "u3", "timestamp": "2026-01-01T00:00:06Z", "type": "user.message", "data": {"content": "third"}},
+	{"id": "c4", "timestamp": "2026-01-01T00:00:07Z", "type": "session.compaction_complete", "data": {"success": true, "summaryContent": "second turn"}}
+]}`
+
+func TestSession_Compaction(t *testing.T) {
+	t.Run("passes the summary and the events it replaced to OnCompaction", func(t *testing.T) {
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(testCompactedHistory), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		compactions := make(chan CompactionInfo, 1)
+		session.registerHooks(&SessionHooks{OnCompaction: func(info CompactionInfo) { compactions <- info }})
+
+		summary := "second turn"
+		session.dispatchEvent(SessionEvent{ID: "c4", Data: &SessionCompactionCompleteData{Success: true, SummaryContent: &summary}})
+		select {
+		case info := <-compactions:
+			var ids []string
+			for _, event := range info.Compacted {
+				ids = append(ids, event.ID)
+			}
+			if info.Summary != summary || !slices.Equal(ids, []string{"u2", "a2"}) {
+				t.Errorf("unexpected compaction summary %q with events %v", info.Summary, ids)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for OnCompaction")
+		}
+	})
+
+	t.Run("lets OnPreCompact block compaction", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		var trigger string
+		session.registerHooks(&SessionHooks{OnPreCompact: func(input PreCompactHookInput, _ HookInvocation) (*PreCompactHookOutput, error) {
+			trigger = input.Trigger
+			return &PreCompactHookOutput{Decision: "block", Reason: "audit pending"}, nil
+		}})
+
+		raw, _ := json.Marshal(map[string]any{"sessionId": "s1", "timestamp": 0, "cwd": "", "trigger": "auto"})
+		output, err := session.handleHooksInvoke("preCompact", raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out, _ := output.(*PreCompactHookOutput); out == nil || out.Decision != "block" || trigger != "auto" {
+			t.Errorf("unexpected output %+v for trigger %q", output, trigger)
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,7 +16,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/coder/websocket"
 	"github.com/github/copilot-sdk/go/rpc"
 )
 
@@ -103,17 +103,18 @@ func NewTextMessage(text string) CopilotWebSocketMessage {
 // LLM inference requests. HTTP requests are forwarded through Transport (an
 // [http.RoundTripper]); supply a custom RoundTripper to mutate the request,
 // post-process the response, or replace the call entirely. WebSocket requests
-// are serviced by OpenWebSocket; supply one to return a custom handler.
+// are serviced by OpenWebSocket.
 //
-// The default behaviour (both fields nil) transparently forwards HTTP through a
-// shared transport and opens a forwarding WebSocket connection to the runtime's
-// original URL.
+// With Transport nil, HTTP is forwarded transparently through a shared
+// transport. WebSocket requests fail unless OpenWebSocket is set; Open of
+// the github.com/github/copilot-sdk/go/copilotws module forwards them to the
+// runtime's original URL.
 type CopilotRequestHandler struct {
 	// Transport forwards HTTP requests. When nil a shared default transport is
 	// used. RoundTrip is called directly, so redirects are not followed.
 	Transport http.RoundTripper
-	// OpenWebSocket returns a per-connection WebSocket handler. When nil a
-	// transparent [CopilotWebSocketForwarder] to the request URL is opened.
+	// OpenWebSocket returns a per-connection WebSocket handler. When nil,
+	// WebSocket requests fail.
 	OpenWebSocket func(ctx *CopilotRequestContext) (CopilotWebSocketHandler, error)
 }
 
@@ -128,9 +129,9 @@ type WebSocketResponseWriter interface {
 }
 
 // CopilotWebSocketHandler is a per-connection WebSocket handler returned by
-// [CopilotRequestHandler.OpenWebSocket]. The default implementation is
-// [CopilotWebSocketForwarder]; a full transport replacement implements
-// this interface directly.
+// [CopilotRequestHandler.OpenWebSocket]. Forwarder of the
+// github.com/github/copilot-sdk/go/copilotws module forwards to the real
+// upstream; a full transport replacement implements this interface directly.
 type CopilotWebSocketHandler interface {
 	// Open establishes the connection and starts forwarding upstream→runtime
 	// messages into resp. It must not block. ctx is cancelled on teardown.
@@ -255,13 +256,10 @@ func cloneHeader(h http.Header) http.Header {
 }
 
 func (h *CopilotRequestHandler) handleWebSocket(rctx *CopilotRequestContext, sink *responseSink) error {
-	var handler CopilotWebSocketHandler
-	var err error
-	if h.OpenWebSocket != nil {
-		handler, err = h.OpenWebSocket(rctx)
-	} else {
-		handler = NewCopilotWebSocketForwarder(rctx.URL, rctx.Headers)
+	if h.OpenWebSocket == nil {
+		return errors.New("CopilotRequestHandler.OpenWebSocket is required for WebSocket requests; set it to copilotws.Open to forward them")
 	}
+	handler, err := h.OpenWebSocket(rctx)
 	if err != nil {
 		return err
 	}
@@ -370,127 +368,6 @@ func (w *wsResponseWriter) fail(message string, code string) error {
 	return w.sink.sinkError(message, code)
 }
 
-// CopilotWebSocketForwarder is the default [CopilotWebSocketHandler]:
-// it dials the real upstream and runs a receive loop forwarding upstream→runtime
-// messages. Set OnSendRequestMessage / OnSendResponseMessage to observe,
-// transform, or drop messages in either direction.
-type CopilotWebSocketForwarder struct {
-	URL     string
-	Headers http.Header
-	// OnSendRequestMessage observes or transforms each runtime→upstream frame.
-	// The frame type (text vs binary) is available via the message's Binary
-	// field and may be changed in the returned message. Return nil to drop the
-	// frame.
-	OnSendRequestMessage func(msg CopilotWebSocketMessage) *CopilotWebSocketMessage
-	// OnSendResponseMessage observes or transforms each upstream→runtime frame.
-	// The frame type (text vs binary) is available via the message's Binary
-	// field and may be changed in the returned message. Return nil to drop the
-	// frame.
-	OnSendResponseMessage func(msg CopilotWebSocketMessage) *CopilotWebSocketMessage
-
-	conn      *websocket.Conn
-	resp      WebSocketResponseWriter
-	done      chan struct{}
-	err       error
-	closeOnce sync.Once
-}
-
-// NewCopilotWebSocketForwarder creates a forwarding handler targeting
-// url with the given handshake headers.
-func NewCopilotWebSocketForwarder(url string, headers http.Header) *CopilotWebSocketForwarder {
-	return &CopilotWebSocketForwarder{URL: url, Headers: headers, done: make(chan struct{})}
-}
-
-func (f *CopilotWebSocketForwarder) Open(ctx context.Context, resp WebSocketResponseWriter) error {
-	f.resp = resp
-	if f.done == nil {
-		f.done = make(chan struct{})
-	}
-	opts := &websocket.DialOptions{HTTPHeader: f.dialHeaders()}
-	conn, _, err := websocket.Dial(ctx, f.URL, opts)
-	if err != nil {
-		return err
-	}
-	conn.SetReadLimit(-1)
-	f.conn = conn
-	go f.receiveLoop(ctx)
-	return nil
-}
-
-func (f *CopilotWebSocketForwarder) dialHeaders() http.Header {
-	out := http.Header{}
-	for name, values := range f.Headers {
-		if isForbiddenRequestHeader(name) {
-			continue
-		}
-		for _, v := range values {
-			out.Add(name, v)
-		}
-	}
-	return out
-}
-
-func (f *CopilotWebSocketForwarder) receiveLoop(ctx context.Context) {
-	defer close(f.done)
-	for {
-		typ, data, err := f.conn.Read(ctx)
-		if err != nil {
-			if websocket.CloseStatus(err) == websocket.StatusNormalClosure || websocket.CloseStatus(err) == websocket.StatusGoingAway {
-				f.err = nil
-			} else if ctx.Err() != nil {
-				f.err = nil
-			} else {
-				f.err = err
-			}
-			return
-		}
-		out := CopilotWebSocketMessage{Data: data, Binary: typ == websocket.MessageBinary}
-		if f.OnSendResponseMessage != nil {
-			transformed := f.OnSendResponseMessage(out)
-			if transformed == nil {
-				continue
-			}
-			out = *transformed
-		}
-		if out.Binary {
-			_ = f.resp.SendBinary(out.Data)
-		} else {
-			_ = f.resp.SendText(out.Data)
-		}
-	}
-}
-
-func (f *CopilotWebSocketForwarder) SendRequestMessage(ctx context.Context, msg CopilotWebSocketMessage) error {
-	out := msg
-	if f.OnSendRequestMessage != nil {
-		transformed := f.OnSendRequestMessage(msg)
-		if transformed == nil {
-			return nil
-		}
-		out = *transformed
-	}
-	if f.conn == nil {
-		return nil
-	}
-	msgType := websocket.MessageText
-	if out.Binary {
-		msgType = websocket.MessageBinary
-	}
-	return f.conn.Write(ctx, msgType, out.Data)
-}
-
-func (f *CopilotWebSocketForwarder) Done() <-chan struct{} { return f.done }
-func (f *CopilotWebSocketForwarder) Err() error            { return f.err }
-
-func (f *CopilotWebSocketForwarder) Close() error {
-	f.closeOnce.Do(func() {
-		if f.conn != nil {
-			_ = f.conn.Close(websocket.StatusNormalClosure, "")
-		}
-	})
-	return nil
-}
-
 // --- Internal adapter ---
 
 // frameQueue is an unbounded FIFO of body frames, decoupling the RPC dispatch
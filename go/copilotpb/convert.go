@@ -0,0 +1,242 @@
+// Package copilotpb provides protobuf types for data exported from Copilot
+// SDK sessions — session events, tool call records, and transcripts — with
+// converters from the native copilot types.
+//
+// The schema lives in copilot.proto so non-Go pipelines can generate their
+// own bindings. Event payloads are carried as google.protobuf.Struct in the
+// same JSON shape the runtime emits, so new event types need no schema change.
+package copilotpb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromSessionEvent converts a native session event.
+//
+// Example:
+//
+//	msg, err := copilotpb.FromSessionEvent(event)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	payload, err := proto.Marshal(msg)
+func FromSessionEvent(event copilot.SessionEvent) (*SessionEvent, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+	var envelope struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode event %s: %w", event.ID, err)
+	}
+	data, err := structpb.NewStruct(envelope.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert data of event %s: %w", event.ID, err)
+	}
+	return &SessionEvent{
+		Id:        event.ID,
+		ParentId:  event.ParentID,
+		Type:      string(event.Type()),
+		Timestamp: timestamppb.New(event.Timestamp),
+		Ephemeral: event.Ephemeral != nil && *event.Ephemeral,
+		AgentId:   event.AgentID,
+		Data:      data,
+	}, nil
+}
+
+// ToSessionEvent converts msg back to a native session event.
+func ToSessionEvent(msg *SessionEvent) (copilot.SessionEvent, error) {
+	envelope := map[string]any{
+		"id":        msg.GetId(),
+		"parentId":  msg.ParentId,
+		"type":      msg.GetType(),
+		"timestamp": msg.GetTimestamp().AsTime().Format(time.RFC3339Nano),
+		"data":      msg.GetData().AsMap(),
+	}
+	if msg.GetEphemeral() {
+		envelope["ephemeral"] = true
+	}
+	if msg.AgentId != nil {
+		envelope["agentId"] = *msg.AgentId
+	}
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return copilot.SessionEvent{}, fmt.Errorf("failed to encode event %s: %w", msg.GetId(), err)
+	}
+	var event copilot.SessionEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return copilot.SessionEvent{}, fmt.Errorf("failed to decode event %s: %w", msg.GetId(), err)
+	}
+	return event, nil
+}
+
+// FromToolCallRecord converts a native tool call record.
+func FromToolCallRecord(record copilot.ToolCallRecord) (*ToolCallRecord, error) {
+	arguments, err := toValue(record.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert arguments of tool call %s: %w", record.ToolCallID, err)
+	}
+	msg := &ToolCallRecord{
+		ToolCallId: record.ToolCallID,
+		ToolName:   record.ToolName,
+		Arguments:  arguments,
+		StartedAt:  timestamppb.New(record.StartedAt),
+		Success:    record.Success,
+		Result:     record.Result,
+	}
+	if !record.CompletedAt.IsZero() {
+		msg.CompletedAt = timestamppb.New(record.CompletedAt)
+	}
+	msg.McpServerName = optionalString(record.MCPServerName)
+	msg.TurnId = optionalString(record.TurnID)
+	msg.Error = optionalString(record.Error)
+	msg.ErrorCode = optionalString(record.ErrorCode)
+	if usage := record.ResourceUsage; usage != nil {
+		msg.ResourceUsage = &ToolResourceUsage{
+			WallTime:      durationpb.New(usage.WallTime),
+			UserCpuTime:   durationpb.New(usage.UserCPUTime),
+			SystemCpuTime: durationpb.New(usage.SystemCPUTime),
+			MaxRssBytes:   usage.MaxRSSBytes,
+			ReadBytes:     usage.ReadBytes,
+			WriteBytes:    usage.WriteBytes,
+		}
+	}
+	return msg, nil
+}
+
+// ToToolCallRecord converts msg back to a native tool call record.
+func ToToolCallRecord(msg *ToolCallRecord) copilot.ToolCallRecord {
+	record := copilot.ToolCallRecord{
+		ToolCallID:    msg.GetToolCallId(),
+		ToolName:      msg.GetToolName(),
+		MCPServerName: msg.GetMcpServerName(),
+		TurnID:        msg.GetTurnId(),
+		StartedAt:     msg.GetStartedAt().AsTime(),
+		Success:       msg.GetSuccess(),
+		Result:        msg.GetResult(),
+		Error:         msg.GetError(),
+		ErrorCode:     msg.GetErrorCode(),
+	}
+	if msg.Arguments != nil {
+		record.Arguments = msg.Arguments.AsInterface()
+	}
+	if msg.CompletedAt != nil {
+		record.CompletedAt = msg.CompletedAt.AsTime()
+	}
+	if usage := msg.ResourceUsage; usage != nil {
+		record.ResourceUsage = &copilot.ToolResourceUsage{
+			WallTime:      usage.GetWallTime().AsDuration(),
+			UserCPUTime:   usage.GetUserCpuTime().AsDuration(),
+			SystemCPUTime: usage.GetSystemCpuTime().AsDuration(),
+			MaxRSSBytes:   usage.GetMaxRssBytes(),
+			ReadBytes:     usage.GetReadBytes(),
+			WriteBytes:    usage.GetWriteBytes(),
+		}
+	}
+	return record
+}
+
+// FromTranscript converts a native transcript.
+//
+// Example:
+//
+//	transcript := copilot.NewTranscript(session.SessionID, events)
+//	msg, err := copilotpb.FromTranscript(transcript)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	payload, err := proto.Marshal(msg)
+func FromTranscript(transcript copilot.Transcript) (*Transcript, error) {
+	msg := &Transcript{
+		SessionId:  transcript.SessionID,
+		ExportedAt: timestamppb.New(transcript.ExportedAt),
+		Events:     make([]*SessionEvent, 0, len(transcript.Events)),
+		ToolCalls:  make([]*ToolCallRecord, 0, len(transcript.ToolCalls)),
+	}
+	for _, event := range transcript.Events {
+		converted, err := FromSessionEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		msg.Events = append(msg.Events, converted)
+	}
+	for _, record := range transcript.ToolCalls {
+		converted, err := FromToolCallRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		msg.ToolCalls = append(msg.ToolCalls, converted)
+	}
+	return msg, nil
+}
+
+// ToTranscript converts msg back to a native transcript.
+func ToTranscript(msg *Transcript) (copilot.Transcript, error) {
+	transcript := copilot.Transcript{
+		SessionID:  msg.GetSessionId(),
+		ExportedAt: msg.GetExportedAt().AsTime(),
+		Events:     make([]copilot.SessionEvent, 0, len(msg.GetEvents())),
+	}
+	for _, event := range msg.GetEvents() {
+		converted, err := ToSessionEvent(event)
+		if err != nil {
+			return copilot.Transcript{}, err
+		}
+		transcript.Events = append(transcript.Events, converted)
+	}
+	for _, record := range msg.GetToolCalls() {
+		transcript.ToolCalls = append(transcript.ToolCalls, ToToolCallRecord(record))
+	}
+	return transcript, nil
+}
+
+// EventSerializer encodes events as [PublishedEvent] messages. It is a
+// [copilot.EventSerializer] for publishing protobuf with an
+// [copilot.EventPublisher].
+//
+// Example:
+//
+//	publisher := copilot.NewEventPublisher(broker, copilot.EventPublisherOptions{
+//	    Serializer: copilotpb.EventSerializer,
+//	})
+func EventSerializer(sessionID string, event copilot.SessionEvent) ([]byte, error) {
+	msg, err := FromSessionEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&PublishedEvent{SessionId: sessionID, Event: msg})
+}
+
+// toValue converts v to a protobuf Value through its JSON encoding, so any
+// JSON-marshalable argument type is accepted.
+func toValue(v any) (*structpb.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(decoded)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
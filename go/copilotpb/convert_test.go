@@ -0,0 +1,113 @@
+package copilotpb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	var events []copilot.SessionEvent
+	if err := json.Unmarshal([]byte(`[
+		{"id": "e1", "parentId": null, "timestamp": "2026-01-01T00:00:00Z", "type": "user.message", "data": {"content": "find TODOs"}},
+		{"id": "e2", "parentId": "e1", "timestamp": "2026-01-01T00:00:01Z", "agentId": "agent-1", "type": "tool.execution_start",
+		 "data": {"toolCallId": "call-1", "toolName": "grep", "arguments": {"pattern": "TODO", "limit": 5}}},
+		{"id": "e3", "parentId": "e2", "timestamp": "2026-01-01T00:00:02Z", "ephemeral": true, "type": "tool.execution_complete",
+		 "data": {"toolCallId": "call-1", "success": true, "result": {"content": "3 matches"},
+		          "toolTelemetry": {"resourceUsage": {"wallTime": 1500000000, "userCpuTime": 200000000, "maxRssBytes": 1048576}}}}
+	]`), &events); err != nil {
+		t.Fatalf("failed to unmarshal events: %v", err)
+	}
+	transcript := copilot.NewTranscript("s1", events)
+
+	msg, err := FromTranscript(transcript)
+	if err != nil {
+		t.Fatalf("FromTranscript failed: %v", err)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("proto.Marshal failed: %v", err)
+	}
+	var decoded Transcript
+	if err := proto.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("proto.Unmarshal failed: %v", err)
+	}
+	if got := decoded.GetEvents()[1]; got.GetType() != "tool.execution_start" || got.GetAgentId() != "agent-1" || got.GetParentId() != "e1" {
+		t.Errorf("unexpected event envelope: %v", got)
+	}
+
+	restored, err := ToTranscript(&decoded)
+	if err != nil {
+		t.Fatalf("ToTranscript failed: %v", err)
+	}
+	if restored.SessionID != "s1" || !restored.ExportedAt.Equal(transcript.ExportedAt) || len(restored.Events) != 3 {
+		t.Fatalf("unexpected transcript: %+v", restored)
+	}
+
+	if restored.Events[0].ParentID != nil {
+		t.Errorf("expected no parent for the first event, got %v", *restored.Events[0].ParentID)
+	}
+	start, ok := restored.Events[1].Data.(*copilot.ToolExecutionStartData)
+	if !ok {
+		t.Fatalf("expected tool start data, got %T", restored.Events[1].Data)
+	}
+	if start.ToolName != "grep" || start.Arguments.(map[string]any)["limit"] != float64(5) {
+		t.Errorf("unexpected tool start data: %+v", start)
+	}
+	if e := restored.Events[2]; e.Ephemeral == nil || !*e.Ephemeral || !e.Timestamp.Equal(time.Date(2026, 1, 1, 0, 0, 2, 0, time.UTC)) {
+		t.Errorf("unexpected envelope for the last event: %+v", e)
+	}
+
+	if len(restored.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(restored.ToolCalls))
+	}
+	call := restored.ToolCalls[0]
+	if call.ToolName != "grep" || !call.Success || call.Result != "3 matches" || call.MCPServerName != "" {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+	if call.CompletedAt.IsZero() || call.Arguments.(map[string]any)["pattern"] != "TODO" {
+		t.Errorf("unexpected tool call details: %+v", call)
+	}
+	if u := call.ResourceUsage; u == nil || u.WallTime != 1500*time.Millisecond || u.CPUTime() != 200*time.Millisecond || u.MaxRSSBytes != 1<<20 {
+		t.Errorf("unexpected resource usage: %+v", u)
+	}
+}
+
+func TestFromToolCallRecordOmitsIncompleteFields(t *testing.T) {
+	msg, err := FromToolCallRecord(copilot.ToolCallRecord{ToolCallID: "call-1", ToolName: "bash"})
+	if err != nil {
+		t.Fatalf("FromToolCallRecord failed: %v", err)
+	}
+	if msg.CompletedAt != nil || msg.Error != nil || msg.Arguments != nil {
+		t.Errorf("expected unset optional fields, got %v", msg)
+	}
+	if record := ToToolCallRecord(msg); !record.CompletedAt.IsZero() || record.Arguments != nil {
+		t.Errorf("unexpected round-tripped record: %+v", record)
+	}
+}
+
+func TestEventSerializer(t *testing.T) {
+	var serialize copilot.EventSerializer = EventSerializer
+	event := copilot.SessionEvent{ID: "e1", Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Data: &copilot.AssistantMessageData{MessageID: "m1", Content: "hello"}}
+	payload, err := serialize("s1", event)
+	if err != nil {
+		t.Fatalf("EventSerializer failed: %v", err)
+	}
+	var decoded PublishedEvent
+	if err := proto.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("proto.Unmarshal failed: %v", err)
+	}
+	if decoded.GetSessionId() != "s1" {
+		t.Errorf("expected session s1, got %q", decoded.GetSessionId())
+	}
+	restored, err := ToSessionEvent(decoded.GetEvent())
+	if err != nil {
+		t.Fatalf("ToSessionEvent failed: %v", err)
+	}
+	if data, ok := restored.Data.(*copilot.AssistantMessageData); !ok || data.Content != "hello" || restored.ID != "e1" {
+		t.Errorf("unexpected event: %+v", restored)
+	}
+}
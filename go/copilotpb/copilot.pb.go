@@ -0,0 +1,612 @@
+// Protobuf definitions for data exported from Copilot SDK sessions.
+//
+// Regenerate copilot.pb.go after editing:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative copilot.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: copilot.proto
+
+package copilotpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SessionEvent is a single event emitted by a session.
+type SessionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique event identifier.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// ID of the chronologically preceding event. Unset for the first event.
+	ParentId *string `protobuf:"bytes,2,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	// Event type discriminator, e.g. "assistant.message".
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// When the event was created.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Transient events are not persisted to the session event log.
+	Ephemeral bool `protobuf:"varint,5,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// Sub-agent instance identifier. Unset for events from the root agent.
+	AgentId *string `protobuf:"bytes,6,opt,name=agent_id,json=agentId,proto3,oneof" json:"agent_id,omitempty"`
+	// Event payload, in the same JSON shape the runtime emits for type.
+	Data          *structpb.Struct `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	mi := &file_copilot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_copilot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_copilot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SessionEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetParentId() string {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetEphemeral() bool {
+	if x != nil {
+		return x.Ephemeral
+	}
+	return false
+}
+
+func (x *SessionEvent) GetAgentId() string {
+	if x != nil && x.AgentId != nil {
+		return *x.AgentId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// ToolCallRecord summarizes one tool invocation, joined from its
+// tool.execution_start and tool.execution_complete events.
+type ToolCallRecord struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ToolCallId string                 `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	ToolName   string                 `protobuf:"bytes,2,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	// Name of the MCP server providing the tool, if any.
+	McpServerName *string `protobuf:"bytes,3,opt,name=mcp_server_name,json=mcpServerName,proto3,oneof" json:"mcp_server_name,omitempty"`
+	TurnId        *string `protobuf:"bytes,4,opt,name=turn_id,json=turnId,proto3,oneof" json:"turn_id,omitempty"`
+	// Arguments the tool was called with.
+	Arguments *structpb.Value        `protobuf:"bytes,5,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// Unset when the tool call never completed.
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	Success     bool                   `protobuf:"varint,8,opt,name=success,proto3" json:"success,omitempty"`
+	// Result text sent to the model.
+	Result string `protobuf:"bytes,9,opt,name=result,proto3" json:"result,omitempty"`
+	// Error message and code when the tool failed.
+	Error     *string `protobuf:"bytes,10,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	ErrorCode *string `protobuf:"bytes,11,opt,name=error_code,json=errorCode,proto3,oneof" json:"error_code,omitempty"`
+	// Resources the tool execution consumed, when measured.
+	ResourceUsage *ToolResourceUsage `protobuf:"bytes,12,opt,name=resource_usage,json=resourceUsage,proto3" json:"resource_usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallRecord) Reset() {
+	*x = ToolCallRecord{}
+	mi := &file_copilot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallRecord) ProtoMessage() {}
+
+func (x *ToolCallRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_copilot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallRecord.ProtoReflect.Descriptor instead.
+func (*ToolCallRecord) Descriptor() ([]byte, []int) {
+	return file_copilot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ToolCallRecord) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetMcpServerName() string {
+	if x != nil && x.McpServerName != nil {
+		return *x.McpServerName
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetTurnId() string {
+	if x != nil && x.TurnId != nil {
+		return *x.TurnId
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetArguments() *structpb.Value {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+func (x *ToolCallRecord) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *ToolCallRecord) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *ToolCallRecord) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ToolCallRecord) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetErrorCode() string {
+	if x != nil && x.ErrorCode != nil {
+		return *x.ErrorCode
+	}
+	return ""
+}
+
+func (x *ToolCallRecord) GetResourceUsage() *ToolResourceUsage {
+	if x != nil {
+		return x.ResourceUsage
+	}
+	return nil
+}
+
+// Operating-system resources consumed by a tool execution.
+type ToolResourceUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WallTime      *durationpb.Duration   `protobuf:"bytes,1,opt,name=wall_time,json=wallTime,proto3" json:"wall_time,omitempty"`
+	UserCpuTime   *durationpb.Duration   `protobuf:"bytes,2,opt,name=user_cpu_time,json=userCpuTime,proto3" json:"user_cpu_time,omitempty"`
+	SystemCpuTime *durationpb.Duration   `protobuf:"bytes,3,opt,name=system_cpu_time,json=systemCpuTime,proto3" json:"system_cpu_time,omitempty"`
+	MaxRssBytes   int64                  `protobuf:"varint,4,opt,name=max_rss_bytes,json=maxRssBytes,proto3" json:"max_rss_bytes,omitempty"`
+	// Storage I/O; only reported on Linux.
+	ReadBytes     int64 `protobuf:"varint,5,opt,name=read_bytes,json=readBytes,proto3" json:"read_bytes,omitempty"`
+	WriteBytes    int64 `protobuf:"varint,6,opt,name=write_bytes,json=writeBytes,proto3" json:"write_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolResourceUsage) Reset() {
+	*x = ToolResourceUsage{}
+	mi := &file_copilot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolResourceUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolResourceUsage) ProtoMessage() {}
+
+func (x *ToolResourceUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_copilot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolResourceUsage.ProtoReflect.Descriptor instead.
+func (*ToolResourceUsage) Descriptor() ([]byte, []int) {
+	return file_copilot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ToolResourceUsage) GetWallTime() *durationpb.Duration {
+	if x != nil {
+		return x.WallTime
+	}
+	return nil
+}
+
+func (x *ToolResourceUsage) GetUserCpuTime() *durationpb.Duration {
+	if x != nil {
+		return x.UserCpuTime
+	}
+	return nil
+}
+
+func (x *ToolResourceUsage) GetSystemCpuTime() *durationpb.Duration {
+	if x != nil {
+		return x.SystemCpuTime
+	}
+	return nil
+}
+
+func (x *ToolResourceUsage) GetMaxRssBytes() int64 {
+	if x != nil {
+		return x.MaxRssBytes
+	}
+	return 0
+}
+
+func (x *ToolResourceUsage) GetReadBytes() int64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
+
+func (x *ToolResourceUsage) GetWriteBytes() int64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
+}
+
+// Transcript is a portable record of a session.
+type Transcript struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ExportedAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=exported_at,json=exportedAt,proto3" json:"exported_at,omitempty"`
+	Events        []*SessionEvent        `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	ToolCalls     []*ToolCallRecord      `protobuf:"bytes,4,rep,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transcript) Reset() {
+	*x = Transcript{}
+	mi := &file_copilot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transcript) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transcript) ProtoMessage() {}
+
+func (x *Transcript) ProtoReflect() protoreflect.Message {
+	mi := &file_copilot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transcript.ProtoReflect.Descriptor instead.
+func (*Transcript) Descriptor() ([]byte, []int) {
+	return file_copilot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Transcript) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Transcript) GetExportedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExportedAt
+	}
+	return nil
+}
+
+func (x *Transcript) GetEvents() []*SessionEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Transcript) GetToolCalls() []*ToolCallRecord {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return nil
+}
+
+// PublishedEvent is a session event mirrored to a message broker by the
+// SDK's EventPublisher.
+type PublishedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Event         *SessionEvent          `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishedEvent) Reset() {
+	*x = PublishedEvent{}
+	mi := &file_copilot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishedEvent) ProtoMessage() {}
+
+func (x *PublishedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_copilot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishedEvent.ProtoReflect.Descriptor instead.
+func (*PublishedEvent) Descriptor() ([]byte, []int) {
+	return file_copilot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PublishedEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *PublishedEvent) GetEvent() *SessionEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+var File_copilot_proto protoreflect.FileDescriptor
+
+const file_copilot_proto_rawDesc = "" +
+	"\n" +
+	"\rcopilot.proto\x12\x15github.copilot.sdk.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x94\x02\n" +
+	"\fSessionEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\tparent_id\x18\x02 \x01(\tH\x00R\bparentId\x88\x01\x01\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
+	"\tephemeral\x18\x05 \x01(\bR\tephemeral\x12\x1e\n" +
+	"\bagent_id\x18\x06 \x01(\tH\x01R\aagentId\x88\x01\x01\x12+\n" +
+	"\x04data\x18\a \x01(\v2\x17.google.protobuf.StructR\x04dataB\f\n" +
+	"\n" +
+	"_parent_idB\v\n" +
+	"\t_agent_id\"\xc5\x04\n" +
+	"\x0eToolCallRecord\x12 \n" +
+	"\ftool_call_id\x18\x01 \x01(\tR\n" +
+	"toolCallId\x12\x1b\n" +
+	"\ttool_name\x18\x02 \x01(\tR\btoolName\x12+\n" +
+	"\x0fmcp_server_name\x18\x03 \x01(\tH\x00R\rmcpServerName\x88\x01\x01\x12\x1c\n" +
+	"\aturn_id\x18\x04 \x01(\tH\x01R\x06turnId\x88\x01\x01\x124\n" +
+	"\targuments\x18\x05 \x01(\v2\x16.google.protobuf.ValueR\targuments\x129\n" +
+	"\n" +
+	"started_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12\x18\n" +
+	"\asuccess\x18\b \x01(\bR\asuccess\x12\x16\n" +
+	"\x06result\x18\t \x01(\tR\x06result\x12\x19\n" +
+	"\x05error\x18\n" +
+	" \x01(\tH\x02R\x05error\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"error_code\x18\v \x01(\tH\x03R\terrorCode\x88\x01\x01\x12O\n" +
+	"\x0eresource_usage\x18\f \x01(\v2(.github.copilot.sdk.v1.ToolResourceUsageR\rresourceUsageB\x12\n" +
+	"\x10_mcp_server_nameB\n" +
+	"\n" +
+	"\b_turn_idB\b\n" +
+	"\x06_errorB\r\n" +
+	"\v_error_code\"\xb1\x02\n" +
+	"\x11ToolResourceUsage\x126\n" +
+	"\twall_time\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\bwallTime\x12=\n" +
+	"\ruser_cpu_time\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vuserCpuTime\x12A\n" +
+	"\x0fsystem_cpu_time\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\rsystemCpuTime\x12\"\n" +
+	"\rmax_rss_bytes\x18\x04 \x01(\x03R\vmaxRssBytes\x12\x1d\n" +
+	"\n" +
+	"read_bytes\x18\x05 \x01(\x03R\treadBytes\x12\x1f\n" +
+	"\vwrite_bytes\x18\x06 \x01(\x03R\n" +
+	"writeBytes\"\xeb\x01\n" +
+	"\n" +
+	"Transcript\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12;\n" +
+	"\vexported_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"exportedAt\x12;\n" +
+	"\x06events\x18\x03 \x03(\v2#.github.copilot.sdk.v1.SessionEventR\x06events\x12D\n" +
+	"\n" +
+	"tool_calls\x18\x04 \x03(\v2%.github.copilot.sdk.v1.ToolCallRecordR\ttoolCalls\"j\n" +
+	"\x0ePublishedEvent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x129\n" +
+	"\x05event\x18\x02 \x01(\v2#.github.copilot.sdk.v1.SessionEventR\x05eventB,Z*github.com/github/copilot-sdk/go/copilotpbb\x06proto3"
+
+var (
+	file_copilot_proto_rawDescOnce sync.Once
+	file_copilot_proto_rawDescData []byte
+)
+
+func file_copilot_proto_rawDescGZIP() []byte {
+	file_copilot_proto_rawDescOnce.Do(func() {
+		file_copilot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_copilot_proto_rawDesc), len(file_copilot_proto_rawDesc)))
+	})
+	return file_copilot_proto_rawDescData
+}
+
+var file_copilot_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_copilot_proto_goTypes = []any{
+	(*SessionEvent)(nil),          // 0: github.copilot.sdk.v1.SessionEvent
+	(*ToolCallRecord)(nil),        // 1: github.copilot.sdk.v1.ToolCallRecord
+	(*ToolResourceUsage)(nil),     // 2: github.copilot.sdk.v1.ToolResourceUsage
+	(*Transcript)(nil),            // 3: github.copilot.sdk.v1.Transcript
+	(*PublishedEvent)(nil),        // 4: github.copilot.sdk.v1.PublishedEvent
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 6: google.protobuf.Struct
+	(*structpb.Value)(nil),        // 7: google.protobuf.Value
+	(*durationpb.Duration)(nil),   // 8: google.protobuf.Duration
+}
+var file_copilot_proto_depIdxs = []int32{
+	5,  // 0: github.copilot.sdk.v1.SessionEvent.timestamp:type_name -> google.protobuf.Timestamp
+	6,  // 1: github.copilot.sdk.v1.SessionEvent.data:type_name -> google.protobuf.Struct
+	7,  // 2: github.copilot.sdk.v1.ToolCallRecord.arguments:type_name -> google.protobuf.Value
+	5,  // 3: github.copilot.sdk.v1.ToolCallRecord.started_at:type_name -> google.protobuf.Timestamp
+	5,  // 4: github.copilot.sdk.v1.ToolCallRecord.completed_at:type_name -> google.protobuf.Timestamp
+	2,  // 5: github.copilot.sdk.v1.ToolCallRecord.resource_usage:type_name -> github.copilot.sdk.v1.ToolResourceUsage
+	8,  // 6: github.copilot.sdk.v1.ToolResourceUsage.wall_time:type_name -> google.protobuf.Duration
+	8,  // 7: github.copilot.sdk.v1.ToolResourceUsage.user_cpu_time:type_name -> google.protobuf.Duration
+	8,  // 8: github.copilot.sdk.v1.ToolResourceUsage.system_cpu_time:type_name -> google.protobuf.Duration
+	5,  // 9: github.copilot.sdk.v1.Transcript.exported_at:type_name -> google.protobuf.Timestamp
+	0,  // 10: github.copilot.sdk.v1.Transcript.events:type_name -> github.copilot.sdk.v1.SessionEvent
+	1,  // 11: github.copilot.sdk.v1.Transcript.tool_calls:type_name -> github.copilot.sdk.v1.ToolCallRecord
+	0,  // 12: github.copilot.sdk.v1.PublishedEvent.event:type_name -> github.copilot.sdk.v1.SessionEvent
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_copilot_proto_init() }
+func file_copilot_proto_init() {
+	if File_copilot_proto != nil {
+		return
+	}
+	file_copilot_proto_msgTypes[0].OneofWrappers = []any{}
+	file_copilot_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_copilot_proto_rawDesc), len(file_copilot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_copilot_proto_goTypes,
+		DependencyIndexes: file_copilot_proto_depIdxs,
+		MessageInfos:      file_copilot_proto_msgTypes,
+	}.Build()
+	File_copilot_proto = out.File
+	file_copilot_proto_goTypes = nil
+	file_copilot_proto_depIdxs = nil
+}
@@ -0,0 +1,359 @@
+package copilottest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// ChaosConfig configures the faults a [ChaosTransport] injects.
+type ChaosConfig struct {
+	// Latency delays every message in both directions.
+	Latency time.Duration
+	// Jitter adds a random delay of up to Jitter to each message. Messages
+	// are still delivered in order.
+	Jitter time.Duration
+	// DropNotifications is the probability, from 0 to 1, that a
+	// notification from the runtime, such as a session event, is dropped.
+	DropNotifications float64
+	// DuplicateEvents is the probability, from 0 to 1, that a session event
+	// is delivered twice.
+	DuplicateEvents float64
+	// Disconnects schedules mid-turn disconnects: the i-th connection is
+	// closed when the runtime sends it a session event after the first
+	// Disconnects[i]. Connections beyond the schedule are left alone, so a
+	// client that reconnects recovers.
+	Disconnects []int
+	// Seed seeds the random faults, so a failing run can be reproduced. When
+	// zero, a random seed is used and logged.
+	Seed uint64
+}
+
+// ChaosStats counts the faults a [ChaosTransport] injected.
+type ChaosStats struct {
+	// Connections is the number of connections the client made.
+	Connections int
+	// Dropped is the number of notifications dropped.
+	Dropped int
+	// Duplicated is the number of session events delivered twice.
+	Duplicated int
+	// Disconnects is the number of connections closed by the transport.
+	Disconnects int
+}
+
+// A ChaosTransport sits between a client and a runtime and injects
+// latency, dropped notifications, duplicated events and disconnects, to
+// test how an application copes with an unreliable connection before
+// production does. Connect a client to it with [ChaosTransport.Connection].
+type ChaosTransport struct {
+	t       testing.TB
+	network string
+	address string
+	token   string
+	config  ChaosConfig
+
+	listener net.Listener
+
+	mu     sync.Mutex
+	random *rand.Rand
+	conns  map[*chaosConn]struct{}
+	stats  ChaosStats
+}
+
+// NewChaosTransport starts a ChaosTransport relaying to the runtime at
+// target, which must be a [copilot.URIConnection] without TLS or headers,
+// or a [copilot.UnixSocketConnection]. To test a runtime the SDK would
+// otherwise spawn, run it with a port, such as with
+// "copilot --headless --port 3000", and pass a URIConnection. The transport
+// stops when the test ends.
+//
+// Example:
+//
+//	chaos := copilottest.NewChaosTransport(t, copilot.URIConnection{URL: runtimeAddr}, copilottest.ChaosConfig{
+//	    Latency:     50 * time.Millisecond,
+//	    Jitter:      100 * time.Millisecond,
+//	    Disconnects: []int{3},
+//	})
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    Connection: chaos.Connection(),
+//	    Reconnect:  &copilot.ReconnectPolicy{},
+//	})
+func NewChaosTransport(t testing.TB, target copilot.RuntimeConnection, config ChaosConfig) *ChaosTransport {
+	t.Helper()
+	c := &ChaosTransport{t: t, config: config, conns: make(map[*chaosConn]struct{})}
+	switch target := target.(type) {
+	case copilot.URIConnection:
+		if target.TLS != nil || target.Headers != nil || target.BearerToken != "" || strings.HasPrefix(target.URL, "https://") {
+			t.Fatalf("ChaosTransport does not support URIConnection with TLS or headers")
+		}
+		c.network, c.address, c.token = "tcp", runtimeAddress(target.URL), target.ConnectionToken
+	case copilot.UnixSocketConnection:
+		c.network, c.address, c.token = "unix", target.Path, target.ConnectionToken
+	default:
+		t.Fatalf("ChaosTransport requires a URIConnection or UnixSocketConnection, got %T", target)
+	}
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+		t.Logf("chaos transport seed %d", seed)
+	}
+	c.random = rand.New(rand.NewPCG(seed, seed))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start chaos transport: %v", err)
+	}
+	c.listener = listener
+	t.Cleanup(c.close)
+	go c.accept()
+	return c
+}
+
+// runtimeAddress returns the host:port of a URIConnection URL, defaulting
+// the host to localhost as the client does.
+func runtimeAddress(url string) string {
+	address, _ := strings.CutPrefix(url, "http://")
+	address, _, _ = strings.Cut(address, "/")
+	host, port, found := strings.Cut(address, ":")
+	if !found {
+		host, port = "", address
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Connection returns the connection for a client to use, through the
+// transport.
+func (c *ChaosTransport) Connection() copilot.RuntimeConnection {
+	return copilot.URIConnection{URL: c.listener.Addr().String(), ConnectionToken: c.token}
+}
+
+// Disconnect closes the current connections now, as if the network failed.
+func (c *ChaosTransport) Disconnect() {
+	c.mu.Lock()
+	conns := make([]*chaosConn, 0, len(c.conns))
+	for conn := range c.conns {
+		conns = append(conns, conn)
+	}
+	c.mu.Unlock()
+	for _, conn := range conns {
+		c.disconnect(conn, "Disconnect called")
+	}
+}
+
+// Stats returns the faults injected so far.
+func (c *ChaosTransport) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *ChaosTransport) close() {
+	c.listener.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for conn := range c.conns {
+		conn.close()
+	}
+	clear(c.conns)
+}
+
+// closed reports whether the connection has been closed.
+func (conn *chaosConn) closed() bool {
+	select {
+	case <-conn.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// chaosConn is a client connection and its connection to the runtime.
+type chaosConn struct {
+	client  net.Conn
+	runtime net.Conn
+	// disconnectAfter is the number of session events relayed before the
+	// connection is closed, or -1. events counts them; only the goroutine
+	// relaying from the runtime uses it.
+	disconnectAfter int
+	events          int
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (conn *chaosConn) close() {
+	conn.closeOnce.Do(func() {
+		close(conn.done)
+		conn.client.Close()
+		conn.runtime.Close()
+	})
+}
+
+func (c *ChaosTransport) accept() {
+	for {
+		client, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		runtime, err := net.DialTimeout(c.network, c.address, 10*time.Second)
+		if err != nil {
+			c.t.Logf("chaos transport failed to connect to the runtime: %v", err)
+			client.Close()
+			continue
+		}
+		conn := &chaosConn{client: client, runtime: runtime, disconnectAfter: -1, done: make(chan struct{})}
+		c.mu.Lock()
+		if c.stats.Connections < len(c.config.Disconnects) {
+			conn.disconnectAfter = c.config.Disconnects[c.stats.Connections]
+		}
+		c.stats.Connections++
+		c.conns[conn] = struct{}{}
+		c.mu.Unlock()
+		go c.relay(conn, conn.client, conn.runtime, false)
+		go c.relay(conn, conn.runtime, conn.client, true)
+	}
+}
+
+// delivery is a message due to be written at a time.
+type delivery struct {
+	data []byte
+	due  time.Time
+	// disconnect, when set, closes the connection instead, for the reason
+	// given.
+	disconnect string
+}
+
+// relay copies messages from src to dst, injecting faults into messages
+// from the runtime.
+func (c *ChaosTransport) relay(conn *chaosConn, src, dst net.Conn, fromRuntime bool) {
+	deliveries := make(chan delivery, 256)
+	go func() {
+		defer conn.close()
+		for d := range deliveries {
+			timer := time.NewTimer(time.Until(d.due))
+			select {
+			case <-timer.C:
+			case <-conn.done:
+				timer.Stop()
+				return
+			}
+			if d.disconnect != "" {
+				c.disconnect(conn, d.disconnect)
+				return
+			}
+			if jsonrpc2.WriteFrame(dst, d.data) != nil {
+				return
+			}
+		}
+	}()
+	defer close(deliveries)
+
+	reader := jsonrpc2.NewFrameReader(src)
+	var last time.Time
+	for {
+		data, err := reader.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !conn.closed() {
+				c.t.Logf("chaos transport failed to read a message: %v", err)
+			}
+			c.mu.Lock()
+			delete(c.conns, conn)
+			c.mu.Unlock()
+			conn.close()
+			return
+		}
+		copies, disconnect := 1, ""
+		if fromRuntime {
+			copies, disconnect = c.fault(conn, data)
+		}
+		if disconnect != "" {
+			// Close after the messages already on their way are delivered.
+			select {
+			case deliveries <- delivery{due: last, disconnect: disconnect}:
+			case <-conn.done:
+			}
+			return
+		}
+		for range copies {
+			due := time.Now().Add(c.delay())
+			if due.Before(last) {
+				due = last
+			}
+			last = due
+			select {
+			case deliveries <- delivery{data: data, due: due}:
+			case <-conn.done:
+				return
+			}
+		}
+	}
+}
+
+// fault decides what happens to a message from the runtime, and returns
+// the number of times to deliver it, or why to disconnect instead.
+func (c *ChaosTransport) fault(conn *chaosConn, data []byte) (int, string) {
+	var message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal(data, &message) != nil || message.Method == "" || len(message.ID) > 0 {
+		return 1, "" // not a notification
+	}
+	if message.Method == "session.event" {
+		if conn.disconnectAfter >= 0 && conn.events >= conn.disconnectAfter {
+			return 0, fmt.Sprintf("after %d session events", conn.events)
+		}
+		conn.events++
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.DropNotifications > 0 && c.random.Float64() < c.config.DropNotifications {
+		c.stats.Dropped++
+		return 0, ""
+	}
+	if message.Method == "session.event" && c.config.DuplicateEvents > 0 && c.random.Float64() < c.config.DuplicateEvents {
+		c.stats.Duplicated++
+		return 2, ""
+	}
+	return 1, ""
+}
+
+// disconnect closes conn and counts it.
+func (c *ChaosTransport) disconnect(conn *chaosConn, reason string) {
+	c.mu.Lock()
+	_, open := c.conns[conn]
+	delete(c.conns, conn)
+	if open {
+		c.stats.Disconnects++
+	}
+	c.mu.Unlock()
+	if open {
+		c.t.Logf("chaos transport disconnected the client: %s", reason)
+	}
+	conn.close()
+}
+
+// delay returns the delay for the next message.
+func (c *ChaosTransport) delay() time.Duration {
+	delay := c.config.Latency
+	if c.config.Jitter > 0 {
+		c.mu.Lock()
+		delay += time.Duration(c.random.Int64N(int64(c.config.Jitter)))
+		c.mu.Unlock()
+	}
+	return delay
+}
@@ -0,0 +1,120 @@
+package copilottest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// startChaos starts a replay runtime serving one turn of events behind a
+// chaos transport, and a client connected through it.
+func startChaos(t *testing.T, config ChaosConfig, options copilot.ClientOptions, events ...copilot.SessionEventData) (*ChaosTransport, *copilot.Client) {
+	t.Helper()
+	turn := recordedTurn{Prompt: "hi"}
+	for i, data := range events {
+		params, err := json.Marshal(map[string]any{"sessionId": "recorded", "event": copilot.SessionEvent{ID: "e" + string(rune('0'+i)), Timestamp: time.Now(), Data: data}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		turn.Messages = append(turn.Messages, recordedMessage{Method: "session.event", Params: params})
+	}
+	runtime := startReplayRuntime(t, &recording{Turns: []recordedTurn{turn}})
+	chaos := NewChaosTransport(t, copilot.URIConnection{URL: runtime}, config)
+	options.Connection = chaos.Connection()
+	client := copilot.NewClient(&options)
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { client.Stop() })
+	return chaos, client
+}
+
+func TestChaosTransport(t *testing.T) {
+	newSession := func(t *testing.T, client *copilot.Client) (*copilot.Session, chan copilot.SessionEvent) {
+		t.Helper()
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		events := make(chan copilot.SessionEvent, 16)
+		session.On(func(event copilot.SessionEvent) { events <- event })
+		return session, events
+	}
+	message := &copilot.AssistantMessageData{MessageID: "m1", Content: "hello"}
+
+	t.Run("delays messages", func(t *testing.T) {
+		_, client := startChaos(t, ChaosConfig{Latency: 50 * time.Millisecond, Seed: 1}, copilot.ClientOptions{})
+		start := time.Now()
+		if _, err := client.Ping(t.Context(), "ping"); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected a round trip of at least 100ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("duplicates session events", func(t *testing.T) {
+		chaos, client := startChaos(t, ChaosConfig{DuplicateEvents: 1, Seed: 1}, copilot.ClientOptions{}, message, &copilot.SessionIdleData{})
+		session, events := newSession(t, client)
+		if _, err := session.SendAndWait(t.Context(), copilot.MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("SendAndWait failed: %v", err)
+		}
+		if first, second := <-events, <-events; first.ID != "e0" || second.ID != "e0" {
+			t.Errorf("expected the message twice, got %s and %s", first.ID, second.ID)
+		}
+		if stats := chaos.Stats(); stats.Duplicated != 2 {
+			t.Errorf("expected 2 duplicated events, got %+v", stats)
+		}
+	})
+
+	t.Run("drops notifications", func(t *testing.T) {
+		chaos, client := startChaos(t, ChaosConfig{DropNotifications: 1, Seed: 1}, copilot.ClientOptions{}, message, &copilot.SessionIdleData{})
+		session, events := newSession(t, client)
+		if _, err := session.Send(t.Context(), copilot.MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for chaos.Stats().Dropped < 2 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if stats := chaos.Stats(); stats.Dropped != 2 {
+			t.Errorf("expected 2 dropped events, got %+v", stats)
+		}
+		select {
+		case event := <-events:
+			t.Errorf("expected no events, got %s", event.Type())
+		default:
+		}
+	})
+
+	t.Run("disconnects mid-turn on schedule", func(t *testing.T) {
+		chaos, client := startChaos(t, ChaosConfig{Disconnects: []int{1}, Seed: 1}, copilot.ClientOptions{
+			Reconnect: &copilot.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond},
+		}, message, &copilot.SessionIdleData{})
+		states := make(chan copilot.ClientState, 8)
+		client.OnStateChange(func(change copilot.ClientStateChange) { states <- change.State })
+		session, events := newSession(t, client)
+		// The disconnect can come before the send's response, failing it.
+		session.Send(t.Context(), copilot.MessageOptions{Prompt: "hi"})
+
+		if event := <-events; event.ID != "e0" {
+			t.Errorf("expected the first event before the disconnect, got %s", event.ID)
+		}
+		// Changes from Start may still be queued, so wait for the client to
+		// degrade and then recover.
+		for _, want := range []copilot.ClientState{copilot.ClientStateDegraded, copilot.ClientStateReady} {
+			for got := copilot.ClientState(""); got != want; {
+				select {
+				case got = <-states:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for %s", want)
+				}
+			}
+		}
+		if stats := chaos.Stats(); stats.Disconnects != 1 || stats.Connections != 2 {
+			t.Errorf("expected 1 disconnect and a reconnection, got %+v", stats)
+		}
+	})
+}
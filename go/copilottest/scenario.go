@@ -0,0 +1,625 @@
+package copilottest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+// ScenarioDir is the directory scenario recordings are stored in, relative
+// to the package directory a test runs in.
+const ScenarioDir = "testdata/scenarios"
+
+// RecordScenariosEnv is the environment variable that, set to a non-empty
+// value, makes [Scenario.Run] run scenarios against the live runtime and
+// overwrite their recordings.
+const RecordScenariosEnv = "COPILOT_RECORD_SCENARIOS"
+
+// A Scenario is a declarative integration test of a session: a list of
+// steps that send prompts and check what the session did in response.
+//
+// A scenario runs against a live runtime the first time and records, for
+// each prompt, the session's messages from the runtime: its events, and its
+// requests to the SDK, such as hook invocations, user input and plan
+// approval requests. After that it replays the recording from a fake
+// runtime, so it runs quickly, offline and deterministically, while the
+// session's handlers, tools and hooks are still the real ones and answer
+// the replayed requests. Only the runtime's side is replayed: requests the
+// SDK makes other than session.send, such as tool results and permission
+// decisions, are answered with an empty success, and their parameters are
+// not checked against the recording.
+// Recordings are JSON files in [ScenarioDir], named after the test, meant to
+// be committed. Set [RecordScenariosEnv] to record them again after a
+// change in prompts or behavior. With the CI environment variable set, a
+// missing recording fails the test instead of being recorded.
+type Scenario struct {
+	// Client configures the client for live runs. Replays connect to the
+	// fake runtime instead, keeping only the Logger.
+	Client *copilot.ClientOptions
+	// Session configures the session the steps run in. Its
+	// OnPermissionRequest is replaced: permission requests are answered by
+	// [ApprovePermission] and [DenyPermission] steps.
+	Session *copilot.SessionConfig
+	// Steps are run in order. Expectations after a [Send] step check the
+	// turn it started.
+	Steps []Step
+	// Timeout bounds each [Send] step. Defaults to 2 minutes.
+	Timeout time.Duration
+	// Live makes the scenario always run against the live runtime, without
+	// reading or writing a recording, such as for a nightly job.
+	Live bool
+}
+
+type stepKind int
+
+const (
+	stepSend stepKind = iota
+	stepExpectEvent
+	stepExpectFileChange
+	stepPermission
+)
+
+// A Step is one action or expectation of a [Scenario]. Create steps with
+// [Send], [ExpectEvent], [ExpectFileChange], [ApprovePermission] and
+// [DenyPermission].
+type Step struct {
+	kind stepKind
+	// text is the prompt of a Send step and the path of an
+	// ExpectFileChange step.
+	text       string
+	eventType  copilot.SessionEventType
+	contains   []string
+	operation  copilot.FileEditOperation
+	permission copilot.PermissionRequestKind
+	approve    bool
+}
+
+// Send sends prompt to the session and waits for the turn to finish.
+func Send(prompt string) Step {
+	return Step{kind: stepSend, text: prompt}
+}
+
+// ExpectEvent expects the turn to deliver an event of the given type whose
+// JSON contains each of the strings in contains. Successive expectations
+// match successive events: each searches the events after the one the
+// previous expectation matched.
+func ExpectEvent(eventType copilot.SessionEventType, contains ...string) Step {
+	return Step{kind: stepExpectEvent, eventType: eventType, contains: contains}
+}
+
+// ExpectFileChange expects the turn to edit the file at path, relative to
+// the session's working directory, reported by a file.edited event. An
+// empty operation matches any change.
+func ExpectFileChange(path string, operation copilot.FileEditOperation) Step {
+	return Step{kind: stepExpectFileChange, text: path, operation: operation}
+}
+
+// ApprovePermission expects the turn to request permission of the given
+// kind, and approves the request. Permission steps after a [Send] answer the
+// turn's permission requests in order; any other request is denied and
+// fails the test.
+func ApprovePermission(kind copilot.PermissionRequestKind) Step {
+	return Step{kind: stepPermission, permission: kind, approve: true}
+}
+
+// DenyPermission expects the turn to request permission of the given kind,
+// and denies the request.
+func DenyPermission(kind copilot.PermissionRequestKind) Step {
+	return Step{kind: stepPermission, permission: kind}
+}
+
+// String returns the step as it is written, for failure messages.
+func (s Step) String() string {
+	switch s.kind {
+	case stepSend:
+		return fmt.Sprintf("Send(%q)", s.text)
+	case stepExpectEvent:
+		args := []string{string(s.eventType)}
+		for _, c := range s.contains {
+			args = append(args, fmt.Sprintf("%q", c))
+		}
+		return "ExpectEvent(" + strings.Join(args, ", ") + ")"
+	case stepExpectFileChange:
+		if s.operation == "" {
+			return fmt.Sprintf("ExpectFileChange(%q)", s.text)
+		}
+		return fmt.Sprintf("ExpectFileChange(%q, %s)", s.text, s.operation)
+	case stepPermission:
+		if s.approve {
+			return fmt.Sprintf("ApprovePermission(%s)", s.permission)
+		}
+		return fmt.Sprintf("DenyPermission(%s)", s.permission)
+	}
+	return "Step(?)"
+}
+
+// recording is the runtime's side of a scenario: the messages it sent for
+// each prompt.
+type recording struct {
+	Turns []recordedTurn `json:"turns"`
+}
+
+type recordedTurn struct {
+	Prompt string `json:"prompt"`
+	// Messages are the session's notifications and requests from the
+	// runtime, in the order they were received.
+	Messages []recordedMessage `json:"messages"`
+}
+
+// recordedMessage is a notification or request from the runtime. Requests
+// are replayed in order: the next message is sent once the SDK answered.
+type recordedMessage struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	// Request is set for a request, which expects an answer.
+	Request bool `json:"request,omitempty"`
+}
+
+// permissionAnswer is a permission step waiting for its request.
+type permissionAnswer struct {
+	step     Step
+	answered bool
+	// mismatch describes a request of another kind than the step's.
+	mismatch string
+}
+
+// scenarioRun is the state of one run of a scenario.
+type scenarioRun struct {
+	t testing.TB
+
+	mu sync.Mutex
+	// events are the events of the current turn.
+	events []copilot.SessionEvent
+	// answers are the current turn's permission steps not yet answered,
+	// and unexpected the requests that arrived when there were none.
+	answers    []*permissionAnswer
+	unexpected []string
+}
+
+// Run runs the scenario as part of test t, failing t with a description of
+// the step and the turn's events for each expectation that is not met.
+//
+// Example:
+//
+//	func TestCreatesGreeting(t *testing.T) {
+//	    scenario := copilottest.Scenario{
+//	        Client:  &copilot.ClientOptions{WorkingDirectory: workDir},
+//	        Session: &copilot.SessionConfig{Model: "gpt-5"},
+//	        Steps: []copilottest.Step{
+//	            copilottest.Send("Create hello.txt containing a greeting"),
+//	            copilottest.ApprovePermission(copilot.PermissionRequestKindWrite),
+//	            copilottest.ExpectFileChange("hello.txt", copilot.FileEditOperationCreated),
+//	            copilottest.ExpectEvent(copilot.SessionEventTypeAssistantMessage, "hello.txt"),
+//	        },
+//	    }
+//	    scenario.Run(t)
+//	}
+func (s *Scenario) Run(t testing.TB) {
+	t.Helper()
+	path := filepath.Join(ScenarioDir, fileName(t.Name())+".json")
+	var replay *recording
+	record := false
+	switch {
+	case s.Live:
+	case os.Getenv(RecordScenariosEnv) != "":
+		record = true
+	default:
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			if os.Getenv("CI") != "" {
+				t.Fatalf("scenario recording %s does not exist; run the test with %s=1 to record it", path, RecordScenariosEnv)
+			}
+			record = true
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read scenario recording: %v", err)
+		}
+		replay = &recording{}
+		if err := json.Unmarshal(data, replay); err != nil {
+			t.Fatalf("failed to parse scenario recording %s: %v", path, err)
+		}
+	}
+
+	var options copilot.ClientOptions
+	if s.Client != nil {
+		options = *s.Client
+	}
+	var wire *wireRecorder
+	if replay != nil {
+		options = copilot.ClientOptions{
+			Connection: copilot.URIConnection{URL: startReplayRuntime(t, replay)},
+			Logger:     options.Logger,
+		}
+	} else if record {
+		logger := options.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		wire = &wireRecorder{next: logger.Handler(), messages: &wireMessages{}}
+		options.Logger = slog.New(wire)
+	}
+	client := copilot.NewClient(&options)
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("failed to start client: %v", err)
+	}
+	t.Cleanup(func() { client.Stop() })
+
+	run := &scenarioRun{t: t}
+	var config copilot.SessionConfig
+	if s.Session != nil {
+		config = *s.Session
+	}
+	config.OnPermissionRequest = run.answerPermission
+	session, err := client.CreateSession(t.Context(), &config)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	session.On(func(event copilot.SessionEvent) {
+		run.mu.Lock()
+		run.events = append(run.events, event)
+		run.mu.Unlock()
+	})
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	recorded := &recording{}
+	answers := make(map[int]*permissionAnswer)
+	turns, cursor := 0, 0
+	for i, step := range s.Steps {
+		if step.kind != stepSend && turns == 0 {
+			t.Fatalf("step %d %s: no turn has run; add a Send step first", i+1, step)
+		}
+		switch step.kind {
+		case stepSend:
+			turns++
+			if replay != nil {
+				if turns > len(replay.Turns) {
+					t.Fatalf("step %d %s: the recording %s has only %d turns (set %s=1 to record it again)", i+1, step, path, len(replay.Turns), RecordScenariosEnv)
+				}
+				if want := replay.Turns[turns-1].Prompt; want != step.text {
+					t.Fatalf("step %d %s: the prompt differs from the recording %s (set %s=1 to record it again)\n--- recording\n%s\n+++ scenario\n%s", i+1, step, path, RecordScenariosEnv, want, step.text)
+				}
+			}
+			run.mu.Lock()
+			run.events, run.unexpected, run.answers = nil, nil, nil
+			for j := i + 1; j < len(s.Steps) && s.Steps[j].kind != stepSend; j++ {
+				if s.Steps[j].kind == stepPermission {
+					answers[j] = &permissionAnswer{step: s.Steps[j]}
+					run.answers = append(run.answers, answers[j])
+				}
+			}
+			run.mu.Unlock()
+			mark := wire.mark(session.SessionID)
+
+			ctx, cancel := context.WithTimeout(t.Context(), timeout)
+			_, err := session.SendAndWait(ctx, copilot.MessageOptions{Prompt: step.text})
+			if err == nil {
+				// Let permission and tool handlers still running finish.
+				err = session.WaitIdle(ctx)
+			}
+			cancel()
+			events, unexpected := run.turn()
+			if err != nil {
+				t.Fatalf("step %d %s: %v\nevents of turn %d:\n%s", i+1, step, err, turns, listEvents(events, -1))
+			}
+			for _, request := range unexpected {
+				t.Errorf("step %d %s: unexpected permission request, denied: %s", i+1, step, request)
+			}
+			if wire != nil {
+				recorded.Turns = append(recorded.Turns, recordedTurn{Prompt: step.text, Messages: wire.since(session.SessionID, mark)})
+			}
+			cursor = 0
+
+		case stepExpectEvent, stepExpectFileChange:
+			events, _ := run.turn()
+			found := -1
+			for j := cursor; j < len(events) && found < 0; j++ {
+				if step.matches(events[j]) {
+					found = j
+				}
+			}
+			if found < 0 {
+				t.Errorf("step %d %s: no matching event in turn %d after event %d\nevents of turn %d:\n%s", i+1, step, turns, cursor, turns, listEvents(events, cursor))
+				continue
+			}
+			cursor = found + 1
+
+		case stepPermission:
+			run.mu.Lock()
+			answer := *answers[i]
+			run.mu.Unlock()
+			switch {
+			case !answer.answered:
+				t.Errorf("step %d %s: turn %d made no more permission requests", i+1, step, turns)
+			case answer.mismatch != "":
+				t.Errorf("step %d %s: got another request, denied: %s", i+1, step, answer.mismatch)
+			}
+		}
+	}
+
+	if replay != nil && turns < len(replay.Turns) {
+		t.Errorf("the recording %s has %d turns but the scenario sent %d prompts (set %s=1 to record it again)", path, len(replay.Turns), turns, RecordScenariosEnv)
+	}
+	if record && !t.Failed() {
+		data, err := json.MarshalIndent(recorded, "", "  ")
+		if err == nil {
+			err = writeSnapshot(path, string(data)+"\n")
+		}
+		if err != nil {
+			t.Fatalf("failed to write scenario recording: %v", err)
+		}
+		t.Logf("recorded scenario %s", path)
+	}
+}
+
+// turn returns the events of the current turn and the unexpected
+// permission requests it made.
+func (r *scenarioRun) turn() ([]copilot.SessionEvent, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]copilot.SessionEvent(nil), r.events...), append([]string(nil), r.unexpected...)
+}
+
+// answerPermission answers a permission request with the turn's next
+// permission step.
+func (r *scenarioRun) answerPermission(request copilot.PermissionRequest, _ copilot.PermissionInvocation) (rpc.PermissionDecision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.answers) == 0 {
+		r.unexpected = append(r.unexpected, describeRequest(request))
+		return &rpc.PermissionDecisionReject{}, nil
+	}
+	answer := r.answers[0]
+	r.answers = r.answers[1:]
+	answer.answered = true
+	if request.Kind() != answer.step.permission {
+		answer.mismatch = describeRequest(request)
+		return &rpc.PermissionDecisionReject{}, nil
+	}
+	if !answer.step.approve {
+		return &rpc.PermissionDecisionReject{}, nil
+	}
+	return &rpc.PermissionDecisionApproveOnce{}, nil
+}
+
+func describeRequest(request copilot.PermissionRequest) string {
+	data, _ := json.Marshal(request)
+	return fmt.Sprintf("%s %s", request.Kind(), truncate(string(data), 160))
+}
+
+// matches reports whether event meets an ExpectEvent or ExpectFileChange
+// step.
+func (s Step) matches(event copilot.SessionEvent) bool {
+	if s.kind == stepExpectFileChange {
+		edit, ok := event.Data.(*copilot.FileEditedData)
+		if !ok || (s.operation != "" && edit.Operation != s.operation) {
+			return false
+		}
+		got, want := filepath.ToSlash(edit.Path), filepath.ToSlash(s.text)
+		return got == want || strings.HasSuffix(got, "/"+strings.TrimPrefix(want, "./"))
+	}
+	if event.Type() != s.eventType {
+		return false
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	for _, c := range s.contains {
+		if !strings.Contains(string(data), c) {
+			return false
+		}
+	}
+	return true
+}
+
+// listEvents lists events one per line for failure messages, marking the
+// one at cursor.
+func listEvents(events []copilot.SessionEvent, cursor int) string {
+	if len(events) == 0 {
+		return "  (none)"
+	}
+	var b strings.Builder
+	for i, event := range events {
+		marker := " "
+		if i == cursor {
+			marker = ">"
+		}
+		data, _ := json.Marshal(event.Data)
+		fmt.Fprintf(&b, "%s %3d %s %s\n", marker, i, event.Type(), truncate(string(data), 160))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
+
+// wireMessages holds the messages received from the runtime, per session.
+type wireMessages struct {
+	mu       sync.Mutex
+	sessions map[string][]recordedMessage
+}
+
+// wireRecorder is a log handler that records the messages the runtime sends
+// for a session, taken from the JSON-RPC client's debug log, which has
+// every message as it was read from the connection. Recording what the
+// runtime sent, rather than what handlers received, leaves out the events
+// the SDK derives from them, which a replay derives again.
+type wireRecorder struct {
+	next     slog.Handler
+	messages *wireMessages
+}
+
+func (w *wireRecorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (w *wireRecorder) Handle(ctx context.Context, record slog.Record) error {
+	if record.Message == "jsonrpc receive" {
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == "message" {
+				w.record(attr.Value.String())
+			}
+			return true
+		})
+	}
+	if !w.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return w.next.Handle(ctx, record)
+}
+
+func (w *wireRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &wireRecorder{next: w.next.WithAttrs(attrs), messages: w.messages}
+}
+
+func (w *wireRecorder) WithGroup(name string) slog.Handler {
+	return &wireRecorder{next: w.next.WithGroup(name), messages: w.messages}
+}
+
+// record records a notification or request of a session. Responses to the
+// SDK's requests are left out.
+func (w *wireRecorder) record(message string) {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if json.Unmarshal([]byte(message), &msg) != nil || msg.Method == "" {
+		return
+	}
+	var params struct {
+		SessionID string `json:"sessionId"`
+	}
+	if json.Unmarshal(msg.Params, &params) != nil || params.SessionID == "" {
+		return
+	}
+	w.messages.mu.Lock()
+	defer w.messages.mu.Unlock()
+	if w.messages.sessions == nil {
+		w.messages.sessions = make(map[string][]recordedMessage)
+	}
+	id := params.SessionID
+	w.messages.sessions[id] = append(w.messages.sessions[id], recordedMessage{
+		Method:  msg.Method,
+		Params:  msg.Params,
+		Request: len(msg.ID) > 0 && string(msg.ID) != "null",
+	})
+}
+
+// mark returns the number of messages recorded for the session so far.
+func (w *wireRecorder) mark(sessionID string) int {
+	if w == nil {
+		return 0
+	}
+	w.messages.mu.Lock()
+	defer w.messages.mu.Unlock()
+	return len(w.messages.sessions[sessionID])
+}
+
+// since returns the messages recorded for the session after mark.
+func (w *wireRecorder) since(sessionID string, mark int) []recordedMessage {
+	w.messages.mu.Lock()
+	defer w.messages.mu.Unlock()
+	return append([]recordedMessage(nil), w.messages.sessions[sessionID][mark:]...)
+}
+
+// startReplayRuntime serves a fake runtime that answers each session.send
+// with the next turn of the recording, and returns its address. The turn's
+// messages are sent for the session that sent the prompt, waiting for the
+// SDK's answer to each request before the next message.
+func startReplayRuntime(t testing.TB, replay *recording) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start replay runtime: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	turns := replay.Turns
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server := jsonrpc2.NewClient(conn, conn)
+			server.SetRequestHandler("connect", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return replayResult(map[string]any{"ok": true, "protocolVersion": copilot.SDKProtocolVersion, "version": "replay"})
+			})
+			sessionID := func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct {
+					SessionID string `json:"sessionId"`
+				}
+				json.Unmarshal(params, &req)
+				return replayResult(map[string]any{"sessionId": req.SessionID})
+			}
+			server.SetRequestHandler("session.create", sessionID)
+			server.SetRequestHandler("session.resume", sessionID)
+			server.SetRequestHandler("session.send", func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct {
+					SessionID string `json:"sessionId"`
+				}
+				json.Unmarshal(params, &req)
+				mu.Lock()
+				if len(turns) == 0 {
+					mu.Unlock()
+					return nil, &jsonrpc2.Error{Code: -32603, Message: "the scenario recording has no more turns"}
+				}
+				turn := turns[0]
+				turns = turns[1:]
+				mu.Unlock()
+				go func() {
+					for _, message := range turn.Messages {
+						params := map[string]json.RawMessage{}
+						json.Unmarshal(message.Params, &params)
+						params["sessionId"], _ = json.Marshal(req.SessionID)
+						if message.Request {
+							server.Request(context.Background(), message.Method, params)
+						} else {
+							server.Notify(context.Background(), message.Method, params)
+						}
+					}
+				}()
+				return replayResult(map[string]any{"messageId": uuid.NewString()})
+			})
+			// Answer everything else, such as permission decisions and
+			// tool results, with an empty success.
+			server.SetDefaultHandler(func(string, json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return json.RawMessage(`{}`), nil
+			})
+			server.Start()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func replayResult(result any) (json.RawMessage, *jsonrpc2.Error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, &jsonrpc2.Error{Code: -32603, Message: err.Error()}
+	}
+	return data, nil
+}
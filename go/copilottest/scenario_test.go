@@ -0,0 +1,165 @@
+package copilottest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// writeRecording writes a recording of one turn for the test named name,
+// with a session.event notification for each event and a request for each
+// recordedMessage.
+func writeRecording(t *testing.T, name, prompt string, messages ...any) {
+	t.Helper()
+	turn := recordedTurn{Prompt: prompt}
+	for i, message := range messages {
+		if request, ok := message.(recordedMessage); ok {
+			turn.Messages = append(turn.Messages, request)
+			continue
+		}
+		event := copilot.SessionEvent{ID: "e" + string(rune('0'+i)), Timestamp: time.Now(), Data: message.(copilot.SessionEventData)}
+		params, err := json.Marshal(map[string]any{"sessionId": "recorded", "event": event})
+		if err != nil {
+			t.Fatal(err)
+		}
+		turn.Messages = append(turn.Messages, recordedMessage{Method: "session.event", Params: params})
+	}
+	data, err := json.Marshal(recording{Turns: []recordedTurn{turn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSnapshot(filepath.Join(ScenarioDir, name+".json"), string(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fatalRecorder is a recorder whose Fatalf stops the goroutine, as the
+// scenario runner does not expect to continue after it.
+type fatalRecorder struct {
+	*recorder
+}
+
+func (r fatalRecorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// runAs runs the scenario as a test named name, and returns its failures.
+func runAs(t *testing.T, name string, scenario *Scenario) []string {
+	r := &recorder{name: name}
+	t.Run(name, func(t *testing.T) {
+		r.TB = t
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			scenario.Run(fatalRecorder{r})
+		}()
+		<-done
+	})
+	return r.failures
+}
+
+func TestScenario(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("CI", "")
+	t.Setenv(RecordScenariosEnv, "")
+
+	prompt := "Create hello.txt"
+	writeRecording(t, "TestGreeting", prompt,
+		&copilot.ToolExecutionStartData{ToolCallID: "c1", ToolName: "create"},
+		&copilot.PermissionRequestedData{RequestID: "p1", PermissionRequest: &copilot.PermissionRequestWrite{
+			ToolCallID: ptr("c1"), FileName: "/repo/hello.txt", Diff: "--- /dev/null\n+++ /repo/hello.txt\n@@ -0,0 +1 @@\n+hi\n",
+		}},
+		&copilot.ToolExecutionCompleteData{ToolCallID: "c1", Success: true, Result: &copilot.ToolExecutionCompleteResult{Content: "Created file /repo/hello.txt"}},
+		&copilot.AssistantMessageData{MessageID: "m1", Content: "I created hello.txt."},
+		&copilot.SessionIdleData{},
+	)
+
+	t.Run("replays a recorded turn through the session", func(t *testing.T) {
+		failures := runAs(t, "TestGreeting", &Scenario{
+			Steps: []Step{
+				Send(prompt),
+				ApprovePermission(copilot.PermissionRequestKindWrite),
+				ExpectFileChange("hello.txt", copilot.FileEditOperationCreated),
+				ExpectEvent(copilot.SessionEventTypeAssistantMessage, "created hello.txt"),
+			},
+		})
+		if len(failures) != 0 {
+			t.Errorf("expected the scenario to pass, got %v", failures)
+		}
+	})
+
+	t.Run("describes unmet expectations with the turn's events", func(t *testing.T) {
+		failures := runAs(t, "TestGreeting", &Scenario{
+			Steps: []Step{
+				Send(prompt),
+				DenyPermission(copilot.PermissionRequestKindShell),
+				ExpectEvent(copilot.SessionEventTypeAssistantMessage, "goodbye"),
+			},
+		})
+		if len(failures) != 2 {
+			t.Fatalf("expected 2 failures, got %q", failures)
+		}
+		if !strings.Contains(failures[0], "DenyPermission(shell)") || !strings.Contains(failures[0], "write") {
+			t.Errorf("expected the permission failure to name the step and the request, got %q", failures[0])
+		}
+		if !strings.Contains(failures[1], `ExpectEvent(assistant.message, "goodbye")`) || !strings.Contains(failures[1], "I created hello.txt.") {
+			t.Errorf("expected the event failure to name the step and list the events, got %q", failures[1])
+		}
+	})
+
+	t.Run("fails with a diff when the prompt no longer matches", func(t *testing.T) {
+		failures := runAs(t, "TestGreeting", &Scenario{Steps: []Step{Send("Create goodbye.txt")}})
+		if len(failures) == 0 || !strings.Contains(failures[0], "--- recording\n"+prompt+"\n+++ scenario\nCreate goodbye.txt") {
+			t.Errorf("expected a prompt diff, got %q", failures)
+		}
+	})
+
+	t.Run("replays the runtime's requests to the session's handlers", func(t *testing.T) {
+		writeRecording(t, "TestAsksUser", "Pick a color",
+			recordedMessage{Method: "userInput.request", Params: json.RawMessage(`{"sessionId":"recorded","question":"Which color?","choices":["red","blue"]}`), Request: true},
+			&copilot.AssistantMessageData{MessageID: "m1", Content: "You picked blue."},
+			&copilot.SessionIdleData{},
+		)
+		var questions []string
+		failures := runAs(t, "TestAsksUser", &Scenario{
+			Session: &copilot.SessionConfig{
+				OnUserInputRequest: func(request copilot.UserInputRequest, _ copilot.UserInputInvocation) (copilot.UserInputResponse, error) {
+					questions = append(questions, request.Question)
+					return copilot.UserInputResponse{Answer: "blue"}, nil
+				},
+			},
+			Steps: []Step{
+				Send("Pick a color"),
+				ExpectEvent(copilot.SessionEventTypeAssistantMessage, "blue"),
+			},
+		})
+		if len(failures) != 0 {
+			t.Errorf("expected the scenario to pass, got %v", failures)
+		}
+		if len(questions) != 1 || questions[0] != "Which color?" {
+			t.Errorf("expected the recorded question to reach the handler, got %q", questions)
+		}
+	})
+
+	t.Run("requires a recording in CI", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		failures := runAs(t, "TestMissing", &Scenario{Steps: []Step{Send(prompt)}})
+		if len(failures) == 0 || !strings.Contains(failures[0], RecordScenariosEnv) {
+			t.Errorf("expected a failure naming %s, got %q", RecordScenariosEnv, failures)
+		}
+		if _, err := os.Stat(filepath.Join(ScenarioDir, "TestMissing.json")); !os.IsNotExist(err) {
+			t.Errorf("expected no recording to be written, got %v", err)
+		}
+	})
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
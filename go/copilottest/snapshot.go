@@ -0,0 +1,336 @@
+// Package copilottest provides helpers for testing code built on the Copilot
+// SDK, such as golden tests of assistant responses.
+package copilottest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+)
+
+// SnapshotDir is the directory snapshots are stored in, relative to the
+// package directory a test runs in.
+const SnapshotDir = "testdata/snapshots"
+
+// UpdateSnapshotsEnv is the environment variable that, set to a non-empty
+// value, makes [MatchSnapshot] overwrite snapshots with the responses it is
+// given instead of comparing them.
+const UpdateSnapshotsEnv = "COPILOT_UPDATE_SNAPSHOTS"
+
+// A Normalizer lets [MatchSnapshot] tolerate a kind of benign variation
+// between a response and its snapshot, either by rewriting the varying parts
+// of both before they are compared, or by accepting responses that differ
+// from the snapshot but are close enough.
+type Normalizer struct {
+	// Normalize, if set, rewrites a response or a snapshot. It is applied
+	// to the response before it is stored as a snapshot, and must be
+	// idempotent.
+	Normalize func(text string) string
+	// Match, if set, reports whether a normalized response that differs
+	// from its normalized snapshot should still match it. It returns an
+	// error if it cannot decide.
+	Match func(ctx context.Context, response, snapshot string) (bool, error)
+}
+
+// timestampPattern matches ISO 8601 and RFC 3339 date-times, such as
+// "2025-03-01T12:34:56.789Z" and "2025-03-01 12:34:56+01:00", and
+// RFC 1123 date-times, such as "Sat, 01 Mar 2025 12:34:56 GMT".
+var timestampPattern = regexp.MustCompile(
+	`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?\b|` +
+		`\b(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun), \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [A-Z]{3}\b`)
+
+// Timestamps replaces date-times in responses, such as
+// "2025-03-01T12:34:56Z", with "<timestamp>".
+func Timestamps() Normalizer {
+	return Replace(timestampPattern, "<timestamp>")
+}
+
+// FilePaths replaces paths under roots, such as a test's t.TempDir or the
+// repository a session works in, with "<root>" followed by the path relative
+// to the root, using forward slashes. Paths under the system temporary
+// directory and the user's home directory are also replaced, with "<tmp>"
+// and "<home>".
+//
+// Example:
+//
+//	dir := t.TempDir()
+//	// "Created /tmp/TestAgent123/001/main.go" becomes "Created <root>/main.go".
+//	copilottest.MatchSnapshot(t, answer, copilottest.FilePaths(dir))
+func FilePaths(roots ...string) Normalizer {
+	type root struct{ path, placeholder string }
+	var all []root
+	add := func(path, placeholder string) {
+		if path == "" {
+			return
+		}
+		path = filepath.Clean(path)
+		all = append(all, root{path, placeholder})
+		// Temporary directories are often reached through symlinks, such
+		// as /var to /private/var on macOS, and either form can appear.
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path {
+			all = append(all, root{resolved, placeholder})
+		}
+	}
+	for _, r := range roots {
+		add(r, "<root>")
+	}
+	add(os.TempDir(), "<tmp>")
+	if home, err := os.UserHomeDir(); err == nil {
+		add(home, "<home>")
+	}
+	// Replace the longest roots first, so a root under the temporary
+	// directory is not replaced as "<tmp>/...".
+	for i := 1; i < len(all); i++ {
+		for j := i; j > 0 && len(all[j].path) > len(all[j-1].path); j-- {
+			all[j], all[j-1] = all[j-1], all[j]
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	var placeholders []string
+	for _, r := range all {
+		// A root is replaced where it is a whole path or the start of one,
+		// together with the rest of the path, but not where it is the start
+		// of a longer name, as "/tmp" is of "/tmpfs".
+		patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(r.path)+`(?:([/\\][^\s"'`+"`"+`<>|:*?]*)|\b|$)`))
+		placeholders = append(placeholders, r.placeholder)
+	}
+	return Normalizer{Normalize: func(text string) string {
+		for i, pattern := range patterns {
+			text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+				rest := pattern.FindStringSubmatch(match)[1]
+				return placeholders[i] + strings.ReplaceAll(rest, `\`, "/")
+			})
+		}
+		return text
+	}}
+}
+
+// Replace replaces matches of pattern in responses with replacement, which
+// may refer to submatches as in [regexp.Regexp.ReplaceAllString], for
+// variation the built-in normalizers do not cover, such as generated IDs.
+//
+// Example:
+//
+//	ids := copilottest.Replace(regexp.MustCompile(`\bPR #\d+`), "PR #<n>")
+func Replace(pattern *regexp.Regexp, replacement string) Normalizer {
+	return Normalizer{Normalize: func(text string) string {
+		return pattern.ReplaceAllString(text, replacement)
+	}}
+}
+
+// EmbedFunc returns an embedding vector of text, such as from an embeddings
+// API. Vectors of the same EmbedFunc must have the same length.
+type EmbedFunc func(ctx context.Context, text string) ([]float64, error)
+
+// SimilarPhrasing accepts a response that differs from its snapshot if the
+// cosine similarity of their embeddings is at least threshold, between 0 and
+// 1, so a test tolerates rephrased answers. Thresholds depend on the
+// embedding model: sentence embeddings typically put paraphrases above 0.9.
+//
+// If embed is nil, [WordEmbedding] is used, which tolerates reordered and
+// repeated words but not synonyms.
+//
+// Example:
+//
+//	copilottest.MatchSnapshot(t, answer, copilottest.SimilarPhrasing(embed, 0.92))
+func SimilarPhrasing(embed EmbedFunc, threshold float64) Normalizer {
+	if embed == nil {
+		embed = WordEmbedding
+	}
+	return Normalizer{Match: func(ctx context.Context, response, snapshot string) (bool, error) {
+		a, err := embed(ctx, response)
+		if err != nil {
+			return false, fmt.Errorf("failed to embed response: %w", err)
+		}
+		b, err := embed(ctx, snapshot)
+		if err != nil {
+			return false, fmt.Errorf("failed to embed snapshot: %w", err)
+		}
+		similarity, err := cosineSimilarity(a, b)
+		if err != nil {
+			return false, err
+		}
+		return similarity >= threshold, nil
+	}}
+}
+
+// wordEmbeddingSize is the length of [WordEmbedding] vectors.
+const wordEmbeddingSize = 1024
+
+// WordEmbedding is an [EmbedFunc] that needs no model: it counts the
+// case-folded words of text, hashed into a fixed-size vector.
+func WordEmbedding(_ context.Context, text string) ([]float64, error) {
+	vector := make([]float64, wordEmbeddingSize)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vector[h.Sum32()%wordEmbeddingSize]++
+	}
+	return vector, nil
+}
+
+func cosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("embeddings have different lengths %d and %d", len(a), len(b))
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		// Two empty texts are alike; an empty and a non-empty one are not.
+		if normA == normB {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return dot / math.Sqrt(normA*normB), nil
+}
+
+// snapshotCounts counts the snapshots each test has matched, so a test can
+// call [MatchSnapshot] more than once.
+var snapshotCounts = struct {
+	sync.Mutex
+	m map[string]int
+}{m: map[string]int{}}
+
+// MatchSnapshot compares response, such as an assistant message, with the
+// test's snapshot file in [SnapshotDir], and fails the test if they differ.
+// normalizers make the comparison tolerate benign variation; the response is
+// stored normalized, so snapshot files show placeholders such as
+// "<timestamp>" where responses vary.
+//
+// A missing snapshot is created from the response, unless the CI
+// environment variable is set, in which case the test fails. Set
+// [UpdateSnapshotsEnv] to overwrite snapshots after an intended change in
+// behavior.
+//
+// Snapshots are named after the test, and numbered when a test matches more
+// than one. They are plain text files meant to be committed and reviewed.
+//
+// Example:
+//
+//	func TestSummarizesChangelog(t *testing.T) {
+//	    response, err := session.SendAndWaitResponse(ctx, copilot.MessageOptions{
+//	        Prompt: "Summarize CHANGELOG.md in one paragraph",
+//	    })
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    copilottest.MatchSnapshot(t, response.Content,
+//	        copilottest.Timestamps(),
+//	        copilottest.FilePaths(repoDir),
+//	        copilottest.SimilarPhrasing(nil, 0.8),
+//	    )
+//	}
+func MatchSnapshot(t testing.TB, response string, normalizers ...Normalizer) {
+	t.Helper()
+	path := snapshotPath(t)
+	got := normalize(response, normalizers)
+
+	if os.Getenv(UpdateSnapshotsEnv) != "" {
+		if err := writeSnapshot(path, got); err != nil {
+			t.Fatalf("failed to update snapshot: %v", err)
+		}
+		return
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if os.Getenv("CI") != "" {
+			t.Errorf("snapshot %s does not exist; run the test with %s=1 to create it", path, UpdateSnapshotsEnv)
+			return
+		}
+		if err := writeSnapshot(path, got); err != nil {
+			t.Fatalf("failed to create snapshot: %v", err)
+		}
+		t.Logf("created snapshot %s", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+
+	want := normalize(string(data), normalizers)
+	if got == want {
+		return
+	}
+	for _, n := range normalizers {
+		if n.Match == nil {
+			continue
+		}
+		ok, err := n.Match(t.Context(), got, want)
+		if err != nil {
+			t.Fatalf("failed to compare with snapshot %s: %v", path, err)
+		}
+		if ok {
+			return
+		}
+	}
+	t.Errorf("response does not match snapshot %s (set %s=1 to update it)\n--- snapshot\n%s\n+++ response\n%s",
+		path, UpdateSnapshotsEnv, want, got)
+}
+
+func normalize(text string, normalizers []Normalizer) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, n := range normalizers {
+		if n.Normalize != nil {
+			text = n.Normalize(text)
+		}
+	}
+	return text
+}
+
+// snapshotPath returns the path of the test's next snapshot.
+func snapshotPath(t testing.TB) string {
+	snapshotCounts.Lock()
+	snapshotCounts.m[t.Name()]++
+	n := snapshotCounts.m[t.Name()]
+	snapshotCounts.Unlock()
+	if n == 1 {
+		// Number from 1 again when the test runs again, as with -count.
+		t.Cleanup(func() {
+			snapshotCounts.Lock()
+			delete(snapshotCounts.m, t.Name())
+			snapshotCounts.Unlock()
+		})
+	}
+
+	name := fileName(t.Name())
+	if n > 1 {
+		name += fmt.Sprintf("_%d", n)
+	}
+	return filepath.Join(SnapshotDir, name+".snap")
+}
+
+// fileName makes a test name safe to use as a file name, keeping subtests
+// apart with "__".
+func fileName(testName string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.ReplaceAll(testName, "/", "__"))
+}
+
+func writeSnapshot(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
@@ -0,0 +1,148 @@
+package copilottest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder is a testing.TB that records failures instead of failing.
+type recorder struct {
+	testing.TB
+	name     string
+	failures []string
+}
+
+func (r *recorder) Name() string {
+	if r.name != "" {
+		return r.name
+	}
+	return r.TB.Name()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(string, ...any) {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+// matchAs runs MatchSnapshot as a test named name, and returns its failures.
+func matchAs(t *testing.T, name, response string, normalizers ...Normalizer) []string {
+	r := &recorder{name: name}
+	t.Run(name, func(t *testing.T) {
+		r.TB = t
+		MatchSnapshot(r, response, normalizers...)
+	})
+	return r.failures
+}
+
+func TestMatchSnapshot(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("CI", "")
+	t.Setenv(UpdateSnapshotsEnv, "")
+
+	t.Run("creates a missing snapshot and then compares with it", func(t *testing.T) {
+		matchAs(t, "TestArithmetic", "The answer is 4.")
+		data, err := os.ReadFile(filepath.Join(SnapshotDir, "TestArithmetic.snap"))
+		if err != nil || string(data) != "The answer is 4." {
+			t.Fatalf("unexpected snapshot %q, %v", data, err)
+		}
+
+		if failures := matchAs(t, "TestArithmetic", "The answer is 4."); len(failures) != 0 {
+			t.Errorf("expected a match, got %v", failures)
+		}
+		failures := matchAs(t, "TestArithmetic", "The answer is 5.")
+		if len(failures) != 1 || !strings.Contains(failures[0], "+++ response\nThe answer is 5.") {
+			t.Errorf("expected a mismatch, got %v", failures)
+		}
+	})
+
+	t.Run("numbers snapshots of the same test", func(t *testing.T) {
+		r := &recorder{TB: t}
+		MatchSnapshot(r, "first")
+		MatchSnapshot(r, "second")
+		data, _ := os.ReadFile(filepath.Join(SnapshotDir, "TestMatchSnapshot__numbers_snapshots_of_the_same_test_2.snap"))
+		if string(data) != "second" || len(r.failures) != 0 {
+			t.Errorf("unexpected second snapshot %q, failures %v", data, r.failures)
+		}
+	})
+
+	t.Run("fails on a missing snapshot in CI", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		r := &recorder{TB: t}
+		MatchSnapshot(r, "answer")
+		if len(r.failures) != 1 || !strings.Contains(r.failures[0], "does not exist") {
+			t.Errorf("expected a missing snapshot failure, got %v", r.failures)
+		}
+	})
+
+	t.Run("updates snapshots", func(t *testing.T) {
+		MatchSnapshot(&recorder{TB: t}, "old")
+		t.Setenv(UpdateSnapshotsEnv, "1")
+		MatchSnapshot(&recorder{TB: t}, "new")
+		data, _ := os.ReadFile(filepath.Join(SnapshotDir, "TestMatchSnapshot__updates_snapshots_2.snap"))
+		if string(data) != "new" {
+			t.Errorf("unexpected snapshot %q", data)
+		}
+	})
+
+	t.Run("stores and compares normalized responses", func(t *testing.T) {
+		dir := t.TempDir()
+		r := &recorder{TB: t}
+		MatchSnapshot(r, "Wrote "+filepath.Join(dir, "out", "report.md")+" at 2025-03-01T12:34:56Z.",
+			Timestamps(), FilePaths(dir))
+		data, _ := os.ReadFile(filepath.Join(SnapshotDir, "TestMatchSnapshot__stores_and_compares_normalized_responses.snap"))
+		if string(data) != "Wrote <root>/out/report.md at <timestamp>." {
+			t.Fatalf("unexpected snapshot %q", data)
+		}
+	})
+
+	t.Run("accepts similar phrasing above the threshold", func(t *testing.T) {
+		os.MkdirAll(SnapshotDir, 0o755)
+		os.WriteFile(filepath.Join(SnapshotDir, "TestBuild.snap"), []byte("The build failed because the test TestParse timed out."), 0o644)
+
+		if failures := matchAs(t, "TestBuild", "The build failed because TestParse, a test, timed out.", SimilarPhrasing(nil, 0.8)); len(failures) != 0 {
+			t.Errorf("expected similar phrasing to match, got %v", failures)
+		}
+		if failures := matchAs(t, "TestBuild", "All tests passed.", SimilarPhrasing(nil, 0.8)); len(failures) != 1 {
+			t.Errorf("expected different content to fail, got %v", failures)
+		}
+	})
+}
+
+func TestNormalizers(t *testing.T) {
+	t.Run("replaces timestamps", func(t *testing.T) {
+		got := Timestamps().Normalize("at 2025-03-01T12:34:56.789+01:00, 2025-03-01 12:34 and Sat, 01 Mar 2025 12:34:56 GMT; v2025-03")
+		if want := "at <timestamp>, <timestamp> and <timestamp>; v2025-03"; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("replaces paths under roots", func(t *testing.T) {
+		n := FilePaths("/work/repo", `C:\work\repo`)
+		got := n.Normalize(`Edited /work/repo/src/main.go, C:\work\repo\src\main.go and "/work/repo" but not /work/repository.`)
+		if want := `Edited <root>/src/main.go, <root>/src/main.go and "<root>" but not /work/repository.`; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+		if again := n.Normalize(got); again != got {
+			t.Errorf("expected normalizing to be idempotent, got %q", again)
+		}
+	})
+
+	t.Run("reports embedding errors", func(t *testing.T) {
+		failing := func(context.Context, string) ([]float64, error) { return nil, errors.New("quota exceeded") }
+		if _, err := SimilarPhrasing(failing, 0.9).Match(t.Context(), "a", "b"); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+			t.Errorf("expected the embedding error, got %v", err)
+		}
+	})
+}
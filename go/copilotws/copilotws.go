@@ -0,0 +1,170 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Microsoft Corporation. All rights reserved.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package copilotws forwards the runtime's WebSocket model requests to the
+// real upstream, for copilot.CopilotRequestHandler.OpenWebSocket.
+//
+// Example:
+//
+//	handler := &copilot.CopilotRequestHandler{OpenWebSocket: copilotws.Open}
+package copilotws
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coder/websocket"
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Hop-by-hop and handshake headers the dialer sets itself; forwarding them
+// verbatim corrupts the handshake.
+var forbiddenRequestHeaders = map[string]struct{}{
+	"host":              {},
+	"connection":        {},
+	"content-length":    {},
+	"transfer-encoding": {},
+	"keep-alive":        {},
+	"upgrade":           {},
+	"proxy-connection":  {},
+	"te":                {},
+	"trailer":           {},
+}
+
+func isForbiddenRequestHeader(name string) bool {
+	lower := strings.ToLower(name)
+	if _, ok := forbiddenRequestHeaders[lower]; ok {
+		return true
+	}
+	return strings.HasPrefix(lower, "sec-websocket-")
+}
+
+// Open returns a [Forwarder] to the request's original URL. Use it as
+// copilot.CopilotRequestHandler.OpenWebSocket to forward WebSocket requests
+// unchanged.
+func Open(rctx *copilot.CopilotRequestContext) (copilot.CopilotWebSocketHandler, error) {
+	return NewForwarder(rctx.URL, rctx.Headers), nil
+}
+
+// Forwarder is a [copilot.CopilotWebSocketHandler] that dials the real
+// upstream and runs a receive loop forwarding upstream→runtime messages. Set OnSendRequestMessage / OnSendResponseMessage to observe,
+// transform, or drop messages in either direction.
+type Forwarder struct {
+	URL     string
+	Headers http.Header
+	// OnSendRequestMessage observes or transforms each runtime→upstream frame.
+	// The frame type (text vs binary) is available via the message's Binary
+	// field and may be changed in the returned message. Return nil to drop the
+	// frame.
+	OnSendRequestMessage func(msg copilot.CopilotWebSocketMessage) *copilot.CopilotWebSocketMessage
+	// OnSendResponseMessage observes or transforms each upstream→runtime frame.
+	// The frame type (text vs binary) is available via the message's Binary
+	// field and may be changed in the returned message. Return nil to drop the
+	// frame.
+	OnSendResponseMessage func(msg copilot.CopilotWebSocketMessage) *copilot.CopilotWebSocketMessage
+
+	conn      *websocket.Conn
+	resp      copilot.WebSocketResponseWriter
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// NewForwarder creates a forwarding handler targeting
+// url with the given handshake headers.
+func NewForwarder(url string, headers http.Header) *Forwarder {
+	return &Forwarder{URL: url, Headers: headers, done: make(chan struct{})}
+}
+
+func (f *Forwarder) Open(ctx context.Context, resp copilot.WebSocketResponseWriter) error {
+	f.resp = resp
+	if f.done == nil {
+		f.done = make(chan struct{})
+	}
+	opts := &websocket.DialOptions{HTTPHeader: f.dialHeaders()}
+	conn, _, err := websocket.Dial(ctx, f.URL, opts)
+	if err != nil {
+		return err
+	}
+	conn.SetReadLimit(-1)
+	f.conn = conn
+	go f.receiveLoop(ctx)
+	return nil
+}
+
+func (f *Forwarder) dialHeaders() http.Header {
+	out := http.Header{}
+	for name, values := range f.Headers {
+		if isForbiddenRequestHeader(name) {
+			continue
+		}
+		for _, v := range values {
+			out.Add(name, v)
+		}
+	}
+	return out
+}
+
+func (f *Forwarder) receiveLoop(ctx context.Context) {
+	defer close(f.done)
+	for {
+		typ, data, err := f.conn.Read(ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) == websocket.StatusNormalClosure || websocket.CloseStatus(err) == websocket.StatusGoingAway {
+				f.err = nil
+			} else if ctx.Err() != nil {
+				f.err = nil
+			} else {
+				f.err = err
+			}
+			return
+		}
+		out := copilot.CopilotWebSocketMessage{Data: data, Binary: typ == websocket.MessageBinary}
+		if f.OnSendResponseMessage != nil {
+			transformed := f.OnSendResponseMessage(out)
+			if transformed == nil {
+				continue
+			}
+			out = *transformed
+		}
+		if out.Binary {
+			_ = f.resp.SendBinary(out.Data)
+		} else {
+			_ = f.resp.SendText(out.Data)
+		}
+	}
+}
+
+func (f *Forwarder) SendRequestMessage(ctx context.Context, msg copilot.CopilotWebSocketMessage) error {
+	out := msg
+	if f.OnSendRequestMessage != nil {
+		transformed := f.OnSendRequestMessage(msg)
+		if transformed == nil {
+			return nil
+		}
+		out = *transformed
+	}
+	if f.conn == nil {
+		return nil
+	}
+	msgType := websocket.MessageText
+	if out.Binary {
+		msgType = websocket.MessageBinary
+	}
+	return f.conn.Write(ctx, msgType, out.Data)
+}
+
+func (f *Forwarder) Done() <-chan struct{} { return f.done }
+func (f *Forwarder) Err() error            { return f.err }
+
+func (f *Forwarder) Close() error {
+	f.closeOnce.Do(func() {
+		if f.conn != nil {
+			_ = f.conn.Close(websocket.StatusNormalClosure, "")
+		}
+	})
+	return nil
+}
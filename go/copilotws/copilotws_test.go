@@ -0,0 +1,112 @@
+package copilotws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// recordingWriter collects the frames forwarded to the runtime.
+type recordingWriter struct {
+	mu     sync.Mutex
+	frames []string
+	got    chan struct{}
+}
+
+func (w *recordingWriter) SendText(data []byte) error {
+	w.mu.Lock()
+	w.frames = append(w.frames, "text:"+string(data))
+	w.mu.Unlock()
+	w.got <- struct{}{}
+	return nil
+}
+
+func (w *recordingWriter) SendBinary(data []byte) error {
+	w.mu.Lock()
+	w.frames = append(w.frames, "binary:"+string(data))
+	w.mu.Unlock()
+	w.got <- struct{}{}
+	return nil
+}
+
+func TestForwarder(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close(websocket.StatusNormalClosure, "")
+		for {
+			typ, data, err := c.Read(r.Context())
+			if err != nil {
+				return
+			}
+			if err := c.Write(r.Context(), typ, append([]byte("echo "), data...)); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	handler, err := Open(&copilot.CopilotRequestContext{
+		URL:     "ws" + strings.TrimPrefix(server.URL, "http"),
+		Headers: http.Header{"Authorization": {"Bearer tok"}, "Sec-Websocket-Key": {"stale"}, "Connection": {"Upgrade"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarder := handler.(*Forwarder)
+	forwarder.OnSendRequestMessage = func(msg copilot.CopilotWebSocketMessage) *copilot.CopilotWebSocketMessage {
+		if msg.Text() == "drop" {
+			return nil
+		}
+		return &msg
+	}
+	resp := &recordingWriter{got: make(chan struct{}, 4)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := forwarder.Open(ctx, resp); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got := <-headers; got.Get("Authorization") != "Bearer tok" || got.Get("Sec-Websocket-Key") == "stale" {
+		t.Errorf("expected the request headers without the handshake's, got %v", got)
+	}
+
+	for _, msg := range []copilot.CopilotWebSocketMessage{copilot.NewTextMessage("drop"), copilot.NewTextMessage("hi"), {Data: []byte("raw"), Binary: true}} {
+		if err := forwarder.SendRequestMessage(ctx, msg); err != nil {
+			t.Fatalf("SendRequestMessage failed: %v", err)
+		}
+	}
+	for range 2 {
+		select {
+		case <-resp.got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the upstream's reply")
+		}
+	}
+	resp.mu.Lock()
+	frames := strings.Join(resp.frames, ",")
+	resp.mu.Unlock()
+	if frames != "text:echo hi,binary:echo raw" {
+		t.Errorf("unexpected frames: %s", frames)
+	}
+
+	forwarder.Close()
+	select {
+	case <-forwarder.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the connection to close")
+	}
+	if err := forwarder.Err(); err != nil {
+		t.Errorf("expected a clean close, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@
 package copilot
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -141,6 +142,7 @@ func ConvertMCPCallToolResult(value any) (ToolResult, bool) {
 
 	var textParts []string
 	var binaryResults []ToolBinaryResult
+	var contents []ToolResultContent
 
 	for _, item := range contentSlice {
 		block := item.(map[string]any)
@@ -181,6 +183,22 @@ func ConvertMCPCallToolResult(value any) (ToolResult, bool) {
 					})
 				}
 			}
+		case "audio":
+			data, _ := block["data"].(string)
+			mimeType, _ := block["mimeType"].(string)
+			if decoded, err := base64.StdEncoding.DecodeString(data); err == nil && data != "" {
+				contents = append(contents, AudioContent(decoded, mimeType))
+			}
+		case "resource_link":
+			uri, _ := block["uri"].(string)
+			if uri == "" {
+				continue
+			}
+			name, _ := block["name"].(string)
+			mimeType, _ := block["mimeType"].(string)
+			link := FileReference(uri, name, mimeType)
+			link.Description, _ = block["description"].(string)
+			contents = append(contents, link)
 		}
 	}
 
@@ -196,6 +214,9 @@ func ConvertMCPCallToolResult(value any) (ToolResult, bool) {
 	if len(binaryResults) > 0 {
 		tr.BinaryResultsForLLM = binaryResults
 	}
+	if len(contents) > 0 {
+		tr.Contents = contents
+	}
 	return tr, true
 }
 
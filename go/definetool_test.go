@@ -404,6 +404,29 @@ func TestConvertMCPCallToolResult(t *testing.T) {
 		}
 	})
 
+	t.Run("audio and resource links go to Contents", func(t *testing.T) {
+		input := map[string]any{
+			"content": []any{
+				map[string]any{"type": "audio", "data": "d2F2", "mimeType": "audio/wav"},
+				map[string]any{"type": "resource_link", "uri": "file:///big.log", "name": "big.log", "description": "full log"},
+			},
+		}
+
+		result, ok := ConvertMCPCallToolResult(input)
+		if !ok {
+			t.Fatal("Expected ConvertMCPCallToolResult to succeed")
+		}
+		if len(result.Contents) != 2 {
+			t.Fatalf("Expected 2 content blocks, got %d", len(result.Contents))
+		}
+		if audio := result.Contents[0]; audio.Type != ToolResultContentAudio || string(audio.Data) != "wav" {
+			t.Errorf("Unexpected audio block %+v", audio)
+		}
+		if link := result.Contents[1]; link.Type != ToolResultContentResourceLink || link.URI != "file:///big.log" || link.Description != "full log" {
+			t.Errorf("Unexpected resource link block %+v", link)
+		}
+	})
+
 	t.Run("non-CallToolResult map returns false", func(t *testing.T) {
 		input := map[string]any{
 			"key": "value",
@@ -0,0 +1,336 @@
+package copilot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type FlushPointData = rpc.FlushPointData
+
+const SessionEventTypeFlushPoint = rpc.SessionEventTypeFlushPoint
+
+// DeltaSegmentation is how the SDK segments streamed assistant messages.
+// See [SessionConfig.DeltaSegmentation].
+//
+// The runtime streams assistant.message_delta events carrying whatever the
+// model produced: fragments of words, or several sentences at once. With a
+// segmentation set, the SDK buffers the deltas and re-delivers them as one
+// delta per complete word or sentence, which suits text-to-speech and
+// reduces re-rendering in chat UIs.
+type DeltaSegmentation string
+
+const (
+	// DeltaSegmentationWord delivers each word with the whitespace after
+	// it.
+	DeltaSegmentationWord DeltaSegmentation = "word"
+	// DeltaSegmentationSentence delivers each sentence with the whitespace
+	// after it. A sentence ends at a line break, or at a ".", "!", "?" or
+	// "…", and any closing quotes or brackets, followed by whitespace. A
+	// full stop in an abbreviation such as "e.g." also ends a sentence.
+	DeltaSegmentationSentence DeltaSegmentation = "sentence"
+	// DeltaSegmentationSpeech delivers sentences like
+	// DeltaSegmentationSentence, for incremental text-to-speech. Each
+	// sentence delta is followed by an assistant.flush_point event
+	// ([FlushPointData]) carrying the sentence, and the message's last one,
+	// delivered just before its assistant.message event, is marked Final.
+	// Delivered text is never revised: when the final message does not start
+	// with it, only the rest of the streamed text follows. Messages that were
+	// not streamed are delivered as sentence deltas too.
+	DeltaSegmentationSpeech DeltaSegmentation = "speech"
+)
+
+// deltaSegmenter re-segments the assistant.message_delta events of a
+// session. It is only accessed from the session's event consumer goroutine
+// and needs no locking.
+type deltaSegmenter struct {
+	split func(text string) (segments []string, rest string)
+	// speech adds flush points and keeps delivered text final.
+	speech bool
+	// output converts segments to the session's OutputProfile, if set.
+	output *outputFormatter
+	// pending holds the text of each message in progress that does not
+	// make a complete segment yet, with the last delta event, in the order
+	// the messages started.
+	pending []*pendingDelta
+}
+
+type pendingDelta struct {
+	event SessionEvent
+	data  *AssistantMessageDeltaData
+	text  string
+	// delivered is the text delivered in segments so far, as the model
+	// wrote it.
+	delivered string
+	converter *outputConverter
+}
+
+// newDeltaSegmenter returns the segmenter for mode, or nil when deltas are
+// delivered as the runtime sends them.
+func newDeltaSegmenter(mode DeltaSegmentation) (*deltaSegmenter, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case DeltaSegmentationWord:
+		return &deltaSegmenter{split: splitWords}, nil
+	case DeltaSegmentationSentence:
+		return &deltaSegmenter{split: splitSentences}, nil
+	case DeltaSegmentationSpeech:
+		return &deltaSegmenter{split: splitSentences, speech: true}, nil
+	default:
+		return nil, fmt.Errorf("invalid DeltaSegmentation %q", mode)
+	}
+}
+
+// segment returns the events to deliver in place of event: the complete
+// segments of a delta, or event after the rest of the messages it ends.
+func (s *deltaSegmenter) segment(event SessionEvent) []SessionEvent {
+	switch d := event.Data.(type) {
+	case *AssistantMessageDeltaData:
+		p := s.find(d.MessageID)
+		if p == nil {
+			p = &pendingDelta{converter: s.output.converter()}
+			s.pending = append(s.pending, p)
+		}
+		p.event, p.data = event, d
+		segments, rest := s.split(p.text + d.DeltaContent)
+		p.text = rest
+		var events []SessionEvent
+		for _, segment := range segments {
+			events = append(events, s.deliver(p, segment, false)...)
+		}
+		return events
+	case *AssistantMessageData:
+		p := s.find(d.MessageID)
+		if s.speech {
+			if p == nil {
+				p = &pendingDelta{event: event, data: &AssistantMessageDeltaData{
+					MessageID:        d.MessageID,
+					ParentToolCallID: d.ParentToolCallID,
+				}, converter: s.output.converter()}
+			}
+			return append(s.finish(p, d.Content), event)
+		}
+		var events []SessionEvent
+		if p != nil {
+			events = s.flush(p)
+		}
+		return append(events, event)
+	case *SessionIdleData, *SessionErrorData:
+		var events []SessionEvent
+		for len(s.pending) > 0 {
+			if p := s.pending[0]; s.speech {
+				events = append(events, s.finish(p, p.delivered+p.text)...)
+			} else {
+				events = append(events, s.flush(p)...)
+			}
+		}
+		return append(events, event)
+	}
+	return []SessionEvent{event}
+}
+
+func (s *deltaSegmenter) find(messageID string) *pendingDelta {
+	for _, p := range s.pending {
+		if p.data.MessageID == messageID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *deltaSegmenter) forget(p *pendingDelta) {
+	for i := range s.pending {
+		if s.pending[i] == p {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// flush returns the delta for the rest of p's message, if any, and forgets
+// the message.
+func (s *deltaSegmenter) flush(p *pendingDelta) []SessionEvent {
+	s.forget(p)
+	if p.text == "" {
+		return nil
+	}
+	return []SessionEvent{p.delta(p.convert(p.text))}
+}
+
+// finish returns the sentences of p's message with final content that were
+// not delivered yet, each with its flush point, and forgets the message. The
+// last flush point is final. If content does not start with the delivered
+// text, the runtime revised the message; the delivered text stands and only
+// the rest of the streamed text follows.
+func (s *deltaSegmenter) finish(p *pendingDelta, content string) []SessionEvent {
+	s.forget(p)
+	rest := p.text
+	if strings.HasPrefix(content, p.delivered) {
+		rest = content[len(p.delivered):]
+	}
+	segments, last := s.split(rest)
+	if n := len(segments); n > 0 && strings.TrimSpace(last) == "" {
+		segments[n-1] += last
+	} else if last != "" {
+		segments = append(segments, last)
+	}
+	if len(segments) == 0 {
+		if p.delivered == "" {
+			return nil
+		}
+		return []SessionEvent{p.flushPoint("", true)}
+	}
+	var events []SessionEvent
+	for i, segment := range segments {
+		events = append(events, s.deliver(p, segment, i == len(segments)-1)...)
+	}
+	return events
+}
+
+// deliver returns the delta for segment of p's message, followed by its flush
+// point in speech mode.
+func (s *deltaSegmenter) deliver(p *pendingDelta, segment string, final bool) []SessionEvent {
+	p.delivered += segment
+	segment = p.convert(segment)
+	if !s.speech {
+		return []SessionEvent{p.delta(segment)}
+	}
+	return []SessionEvent{p.delta(segment), p.flushPoint(segment, final)}
+}
+
+// convert converts text of p's message to the session's OutputProfile.
+func (p *pendingDelta) convert(text string) string {
+	if p.converter == nil {
+		return text
+	}
+	return p.converter.convert(text)
+}
+
+// delta returns a delta event for segment, like the last delta of p's
+// message.
+func (p *pendingDelta) delta(segment string) SessionEvent {
+	ephemeral := true
+	parentID := p.event.ID
+	return SessionEvent{
+		AgentID: p.event.AgentID,
+		Data: &AssistantMessageDeltaData{
+			MessageID:        p.data.MessageID,
+			DeltaContent:     segment,
+			ParentToolCallID: p.data.ParentToolCallID,
+		},
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		ParentID:  &parentID,
+		Timestamp: p.event.Timestamp,
+	}
+}
+
+// flushPoint returns a flush point event after text of p's message.
+func (p *pendingDelta) flushPoint(text string, final bool) SessionEvent {
+	ephemeral := true
+	parentID := p.event.ID
+	return SessionEvent{
+		AgentID: p.event.AgentID,
+		Data: &FlushPointData{
+			MessageID:        p.data.MessageID,
+			ParentToolCallID: p.data.ParentToolCallID,
+			Text:             text,
+			Final:            final,
+		},
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		ParentID:  &parentID,
+		Timestamp: p.event.Timestamp,
+	}
+}
+
+// splitWords splits the complete words off text.
+func splitWords(text string) ([]string, string) {
+	return splitAt(text, func(text string, i int) int {
+		if r, _ := utf8.DecodeRuneInString(text[i:]); !unicode.IsSpace(r) {
+			return -1
+		}
+		return skipSpace(text, i)
+	})
+}
+
+const (
+	sentenceTerminators = ".!?…"
+	// fullWidthTerminators end a sentence without whitespace after them.
+	fullWidthTerminators = "。！？"
+	sentenceClosers      = `"')]”’」』`
+)
+
+// splitSentences splits the complete sentences off text.
+func splitSentences(text string) ([]string, string) {
+	return splitAt(text, func(text string, i int) int {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\n':
+			return skipSpace(text, i)
+		case strings.ContainsRune(fullWidthTerminators, r):
+			return skip(text, i+size, sentenceTerminators+fullWidthTerminators+sentenceClosers)
+		case strings.ContainsRune(sentenceTerminators, r):
+			end := skip(text, i+size, sentenceTerminators+sentenceClosers)
+			if next, _ := utf8.DecodeRuneInString(text[end:]); end == len(text) || !unicode.IsSpace(next) {
+				return -1
+			}
+			return skipSpace(text, end)
+		}
+		return -1
+	})
+}
+
+// splitAt splits text into segments that end where boundary, called at each
+// rune, returns the end of a segment, or -1 where there is none. A segment
+// must contain more than whitespace. The text after the last segment is
+// returned as the rest.
+func splitAt(text string, boundary func(text string, i int) int) ([]string, string) {
+	var segments []string
+	start := 0
+	for i := 0; i < len(text); {
+		end := boundary(text, i)
+		if end < 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		if strings.TrimSpace(text[start:end]) != "" {
+			segments = append(segments, text[start:end])
+			start = end
+		}
+		i = end
+	}
+	return segments, text[start:]
+}
+
+// skip returns the index of the first rune at or after i that is not in
+// chars.
+func skip(text string, i int, chars string) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(chars, r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// skipSpace returns the index of the first rune at or after i that is not
+// whitespace.
+func skipSpace(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
@@ -0,0 +1,137 @@
+package copilot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeltaSegmentation(t *testing.T) {
+	t.Run("splits complete words", func(t *testing.T) {
+		segments, rest := splitWords("Hello, wor")
+		if !slices.Equal(segments, []string{"Hello, "}) || rest != "wor" {
+			t.Errorf("unexpected segments %q and rest %q", segments, rest)
+		}
+		segments, rest = splitWords("  leading space  and more")
+		if !slices.Equal(segments, []string{"  leading ", "space  ", "and "}) || rest != "more" {
+			t.Errorf("unexpected segments %q and rest %q", segments, rest)
+		}
+	})
+
+	t.Run("splits complete sentences", func(t *testing.T) {
+		segments, rest := splitSentences(`It costs 3.50 dollars. "Really?" Yes!` + "\n\n- item one\n- item")
+		want := []string{"It costs 3.50 dollars. ", `"Really?" `, "Yes!\n\n", "- item one\n"}
+		if !slices.Equal(segments, want) || rest != "- item" {
+			t.Errorf("unexpected segments %q and rest %q", segments, rest)
+		}
+		segments, rest = splitSentences("Done.")
+		if len(segments) != 0 || rest != "Done." {
+			t.Errorf("expected a sentence without whitespace after it to be pending, got %q and %q", segments, rest)
+		}
+		segments, rest = splitSentences("你好。再见")
+		if !slices.Equal(segments, []string{"你好。"}) || rest != "再见" {
+			t.Errorf("unexpected segments %q and rest %q", segments, rest)
+		}
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		if _, err := newDeltaSegmenter("paragraph"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("re-delivers deltas as segments", func(t *testing.T) {
+		segmenter, err := newDeltaSegmenter(DeltaSegmentationSentence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session := newSession("s1", nil, "")
+		session.segmenter = segmenter
+		t.Cleanup(func() { close(session.done) })
+		var deltas []string
+		done := make(chan string, 1)
+		session.On(func(event SessionEvent) {
+			switch d := event.Data.(type) {
+			case *AssistantMessageDeltaData:
+				deltas = append(deltas, d.DeltaContent)
+			case *AssistantMessageData:
+				done <- d.Content
+			}
+		})
+
+		for _, delta := range []string{"Hel", "lo there. How a", "re you? I'm", " fine"} {
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: delta}})
+		}
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "m1", Content: "Hello there. How are you? I'm fine"}})
+		select {
+		case content := <-done:
+			want := []string{"Hello there. ", "How are you? ", "I'm fine"}
+			if !slices.Equal(deltas, want) || strings.Join(deltas, "") != content {
+				t.Errorf("expected deltas %q, got %q", want, deltas)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the message")
+		}
+	})
+
+	t.Run("marks sentences with flush points for speech", func(t *testing.T) {
+		segmenter, err := newDeltaSegmenter(DeltaSegmentationSpeech)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []string
+		for _, event := range []SessionEvent{
+			{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: "Sure. Let me"}},
+			{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: " check"}},
+			// The final content revises the delivered sentence.
+			{Data: &AssistantMessageData{MessageID: "m1", Content: "OK. Let me check"}},
+			// Messages that were not streamed are segmented too.
+			{Data: &AssistantMessageData{MessageID: "m2", Content: "Found it. Done.\n"}},
+			{Data: &AssistantMessageData{MessageID: "m3"}},
+		} {
+			for _, segmented := range segmenter.segment(event) {
+				switch d := segmented.Data.(type) {
+				case *AssistantMessageDeltaData:
+					events = append(events, "delta "+d.MessageID+" "+d.DeltaContent)
+				case *FlushPointData:
+					events = append(events, fmt.Sprintf("flush %s %q final=%v", d.MessageID, d.Text, d.Final))
+				case *AssistantMessageData:
+					events = append(events, "message "+d.MessageID)
+				}
+			}
+		}
+		want := []string{
+			"delta m1 Sure. ",
+			`flush m1 "Sure. " final=false`,
+			"delta m1 Let me check",
+			`flush m1 "Let me check" final=true`,
+			"message m1",
+			"delta m2 Found it. ",
+			`flush m2 "Found it. " final=false`,
+			"delta m2 Done.\n",
+			`flush m2 "Done.\n" final=true`,
+			"message m2",
+			"message m3",
+		}
+		if !slices.Equal(events, want) {
+			t.Errorf("expected events\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(events, "\n"))
+		}
+	})
+
+	t.Run("ends a message cut off by an error with a final flush point", func(t *testing.T) {
+		segmenter, err := newDeltaSegmenter(DeltaSegmentationSpeech)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segmenter.segment(SessionEvent{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: "All done. "}})
+		events := segmenter.segment(SessionEvent{Data: &SessionErrorData{Message: "boom"}})
+		if len(events) != 2 {
+			t.Fatalf("expected a flush point and the error, got %d events", len(events))
+		}
+		if d, ok := events[0].Data.(*FlushPointData); !ok || !d.Final || d.Text != "" {
+			t.Errorf("expected an empty final flush point, got %+v", events[0].Data)
+		}
+	})
+}
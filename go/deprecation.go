@@ -0,0 +1,40 @@
+package copilot
+
+import "sync"
+
+// MetricDeprecatedAPIUses counts uses of deprecated SDK APIs, so operators
+// can find code to migrate before the APIs are removed. Labels: api.
+const MetricDeprecatedAPIUses = "copilot_deprecated_api_uses_total"
+
+// reportedDeprecations holds the deprecated APIs already logged by this
+// process.
+var reportedDeprecations sync.Map
+
+// deprecated reports a use of the deprecated API api, which is replaced by
+// replacement. The first use in the process logs a warning; every use
+// increments [MetricDeprecatedAPIUses].
+func (c *Client) deprecated(api, replacement string) {
+	c.incrementCounter(MetricDeprecatedAPIUses, 1, map[string]string{"api": api})
+	if _, reported := reportedDeprecations.LoadOrStore(api, struct{}{}); reported {
+		return
+	}
+	c.logger().Warn("deprecated Copilot SDK API used; it will be removed in the next major version",
+		"api", api, "replacement", replacement)
+}
+
+// reportDeprecatedMCPServers reports MCP servers, including those of custom
+// agents, that use a deprecated transport.
+func (c *Client) reportDeprecatedMCPServers(servers map[string]MCPServerConfig, agents []CustomAgentConfig) {
+	check := func(servers map[string]MCPServerConfig) {
+		for _, server := range servers {
+			switch server.(type) {
+			case MCPSSEServerConfig, *MCPSSEServerConfig:
+				c.deprecated("MCPSSEServerConfig", "MCPHTTPServerConfig")
+			}
+		}
+	}
+	check(servers)
+	for _, agent := range agents {
+		check(agent.MCPServers)
+	}
+}
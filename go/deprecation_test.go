@@ -0,0 +1,53 @@
+package copilot
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	t.Run("logs once per process and counts every use", func(t *testing.T) {
+		reportedDeprecations.Clear()
+		t.Cleanup(reportedDeprecations.Clear)
+
+		var logs bytes.Buffer
+		sink := &recordingSink{}
+		client := NewClient(&ClientOptions{
+			Logger:      slog.New(slog.NewTextHandler(&logs, nil)),
+			MetricsSink: sink,
+		})
+		servers := map[string]MCPServerConfig{
+			"legacy": MCPSSEServerConfig{URL: "https://mcp.example.com/sse"},
+			"modern": MCPHTTPServerConfig{URL: "https://mcp.example.com/mcp"},
+		}
+		agents := []CustomAgentConfig{{Name: "a", MCPServers: map[string]MCPServerConfig{"legacy": &MCPSSEServerConfig{URL: "https://mcp.example.com/sse"}}}}
+
+		client.reportDeprecatedMCPServers(servers, agents)
+		client.reportDeprecatedMCPServers(servers, nil)
+
+		if got := strings.Count(logs.String(), "deprecated Copilot SDK API used"); got != 1 {
+			t.Errorf("expected one warning, got %d:\n%s", got, logs.String())
+		}
+		if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "api=MCPSSEServerConfig replacement=MCPHTTPServerConfig") {
+			t.Errorf("expected a structured warning, got:\n%s", logs.String())
+		}
+		want := strings.Repeat(`copilot_deprecated_api_uses_total{api="MCPSSEServerConfig"} += 1`+"\n", 3)
+		if got := sink.recordsOf(MetricDeprecatedAPIUses); got != strings.TrimSuffix(want, "\n") {
+			t.Errorf("expected 3 counted uses, got:\n%s", got)
+		}
+	})
+
+	t.Run("reports nothing for current APIs", func(t *testing.T) {
+		reportedDeprecations.Clear()
+		t.Cleanup(reportedDeprecations.Clear)
+
+		var logs bytes.Buffer
+		client := NewClient(&ClientOptions{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+		client.reportDeprecatedMCPServers(map[string]MCPServerConfig{"modern": MCPHTTPServerConfig{URL: "https://mcp.example.com/mcp"}}, nil)
+		if logs.Len() != 0 {
+			t.Errorf("expected no warning, got:\n%s", logs.String())
+		}
+	})
+}
@@ -0,0 +1,537 @@
+package copilot
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type (
+	EgressDeniedData = rpc.EgressDeniedData
+	EgressSource     = rpc.EgressSource
+)
+
+const (
+	SessionEventTypeEgressDenied = rpc.SessionEventTypeEgressDenied
+
+	EgressSourceFetch = rpc.EgressSourceFetch
+	EgressSourceShell = rpc.EgressSourceShell
+	EgressSourceProxy = rpc.EgressSourceProxy
+)
+
+// EgressPolicy restricts the network destinations an agent can reach. See
+// [SessionConfig.EgressPolicy].
+//
+// The runtime asks for permission before the fetch tool accesses a URL and
+// lists the URLs a shell command may access in its permission request. The
+// SDK checks those URLs before the session's permission handler sees the
+// request and rejects requests for blocked destinations.
+//
+// Domain patterns are either an exact host name ("api.github.com") or a
+// wildcard matching any subdomain ("*.github.com", which does not match
+// "github.com" itself). CIDRs apply to IP address destinations and, for
+// proxied shell connections, to the addresses host names resolve to. Deny
+// rules take precedence over allow rules.
+type EgressPolicy struct {
+	// AllowDomains and AllowCIDRs, when either is non-empty, limit access to
+	// matching destinations.
+	AllowDomains []string
+	AllowCIDRs   []string
+	// DenyDomains and DenyCIDRs block matching destinations.
+	DenyDomains []string
+	DenyCIDRs   []string
+	// ProxyShellCommands routes the network traffic of shell commands through
+	// a local proxy that enforces the policy on every connection, by setting
+	// HTTP_PROXY, HTTPS_PROXY and ALL_PROXY in their environment. Like
+	// [SessionConfig.PTYShell], this enables the SDK-hosted shell.
+	// Programs that ignore proxy variables are not restricted; combine with
+	// OS-level network isolation where that matters.
+	ProxyShellCommands bool
+}
+
+// errEgressDenied is returned by the proxy's dialer for blocked destinations.
+var errEgressDenied = errors.New("blocked by egress policy")
+
+// egressGuard enforces an [EgressPolicy] for one session.
+type egressGuard struct {
+	policy     EgressPolicy
+	allowCIDRs []netip.Prefix
+	denyCIDRs  []netip.Prefix
+	session    *Session
+	// upstream is the proxy the egress proxy forwards through, from
+	// [ClientOptions.Network].
+	upstream func(*http.Request) (*url.URL, error)
+
+	proxyOnce sync.Once
+	proxy     *http.Server
+	proxyAddr string
+	proxyErr  error
+}
+
+func newEgressGuard(policy *EgressPolicy, network *NetworkConfig) (*egressGuard, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	g := &egressGuard{policy: *policy}
+	var err error
+	if g.upstream, err = network.proxy(); err != nil {
+		return nil, err
+	}
+	if g.allowCIDRs, err = parseCIDRs(policy.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if g.denyCIDRs, err = parseCIDRs(policy.DenyCIDRs); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func parseCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress policy CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// attachEgress connects guard to the session so it can enforce the policy
+// and report violations.
+func (s *Session) attachEgress(guard *egressGuard) {
+	if guard == nil {
+		return
+	}
+	guard.session = s
+	s.egress = guard
+}
+
+// check returns why host may not be accessed, or "" if it may.
+func (g *egressGuard) check(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if host == "" {
+		return "destination has no host"
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(addr, true)
+	}
+	if matchesDomain(host, g.policy.DenyDomains) {
+		return fmt.Sprintf("%s is a denied domain", host)
+	}
+	if g.allowlisted() && !matchesDomain(host, g.policy.AllowDomains) {
+		return fmt.Sprintf("%s is not an allowed domain", host)
+	}
+	return ""
+}
+
+// checkAddr returns why addr may not be accessed, or "" if it may. The
+// allowlist only applies to addresses the agent named directly, not to
+// addresses an allowed domain resolved to.
+func (g *egressGuard) checkAddr(addr netip.Addr, direct bool) string {
+	addr = addr.Unmap()
+	for _, prefix := range g.denyCIDRs {
+		if prefix.Contains(addr) {
+			return fmt.Sprintf("%s is in denied range %s", addr, prefix)
+		}
+	}
+	if !direct || !g.allowlisted() {
+		return ""
+	}
+	for _, prefix := range g.allowCIDRs {
+		if prefix.Contains(addr) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s is not in an allowed range", addr)
+}
+
+func (g *egressGuard) allowlisted() bool {
+	return len(g.policy.AllowDomains) > 0 || len(g.allowCIDRs) > 0
+}
+
+func matchesDomain(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL returns the violation for rawURL, or nil if it may be accessed.
+func (g *egressGuard) checkURL(rawURL string, source EgressSource, toolCallID string) *EgressDeniedData {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		// Shell permission requests may name scheme-less URLs.
+		u, err = url.Parse("//" + rawURL)
+	}
+	host := ""
+	if err == nil {
+		host = u.Hostname()
+	}
+	reason := g.check(host)
+	if reason == "" {
+		return nil
+	}
+	return &EgressDeniedData{ToolCallID: toolCallID, Source: source, URL: rawURL, Host: host, Reason: reason}
+}
+
+// permissionViolations returns the destinations in request the policy blocks.
+func (g *egressGuard) permissionViolations(request PermissionRequest) []EgressDeniedData {
+	var violations []EgressDeniedData
+	switch r := request.(type) {
+	case *PermissionRequestURL:
+		if v := g.checkURL(r.URL, EgressSourceFetch, derefString(r.ToolCallID)); v != nil {
+			violations = append(violations, *v)
+		}
+	case *PermissionRequestShell:
+		for _, possible := range r.PossibleURLs {
+			if v := g.checkURL(possible.URL, EgressSourceShell, derefString(r.ToolCallID)); v != nil {
+				violations = append(violations, *v)
+			}
+		}
+	}
+	return violations
+}
+
+// enforcePermission rejects the permission request if it names blocked
+// destinations, reporting whether it did.
+func (g *egressGuard) enforcePermission(d *PermissionRequestedData) bool {
+	violations := g.permissionViolations(d.PermissionRequest)
+	if len(violations) == 0 {
+		return false
+	}
+	reasons := make([]string, 0, len(violations))
+	for i := range violations {
+		g.report(&violations[i])
+		reasons = append(reasons, violations[i].Reason)
+	}
+	feedback := "Blocked by the session's network egress policy: " + strings.Join(reasons, "; ")
+	g.session.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		RequestID: d.RequestID,
+		Result:    &rpc.PermissionDecisionReject{Feedback: &feedback},
+	})
+	return true
+}
+
+// report delivers an egress.denied event to the session's handlers.
+func (g *egressGuard) report(violation *EgressDeniedData) {
+	if g.session == nil {
+		return
+	}
+	ephemeral := true
+	g.session.dispatchEvent(SessionEvent{
+		Data:      violation,
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+}
+
+// proxyEnv returns the environment that routes a shell command through the
+// egress proxy, starting the proxy on first use. It returns nil when the
+// policy does not proxy shell commands.
+func (g *egressGuard) proxyEnv() ([]string, error) {
+	if g == nil || !g.policy.ProxyShellCommands {
+		return nil, nil
+	}
+	g.proxyOnce.Do(g.startProxy)
+	if g.proxyErr != nil {
+		return nil, g.proxyErr
+	}
+	proxyURL := "http://" + g.proxyAddr
+	return []string{
+		"HTTP_PROXY=" + proxyURL, "http_proxy=" + proxyURL,
+		"HTTPS_PROXY=" + proxyURL, "https_proxy=" + proxyURL,
+		"ALL_PROXY=" + proxyURL, "all_proxy=" + proxyURL,
+		"NO_PROXY=", "no_proxy=",
+	}, nil
+}
+
+func (g *egressGuard) startProxy() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		g.proxyErr = fmt.Errorf("failed to start egress proxy: %w", err)
+		return
+	}
+	transport := &http.Transport{
+		DialContext:         g.dial,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	proxied := &http.Transport{
+		Proxy:               g.upstream,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	g.proxyAddr = listener.Addr().String()
+	g.proxy = &http.Server{
+		Handler:           &egressProxy{guard: g, transport: transport, proxied: proxied},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go g.proxy.Serve(listener)
+}
+
+// close stops the egress proxy, if running.
+func (g *egressGuard) close() {
+	if g == nil {
+		return
+	}
+	// Claim the Once so a concurrent first use cannot start a proxy
+	// after it was closed.
+	g.proxyOnce.Do(func() { g.proxyErr = errors.New("egress proxy is closed") })
+	if g.proxy != nil {
+		g.proxy.Close()
+	}
+}
+
+type egressURLKey struct{}
+
+// resolve checks host and the addresses it resolves to against the policy,
+// so an allowed name cannot point at a denied network, and returns the
+// addresses. rawURL is reported with a violation.
+func (g *egressGuard) resolve(ctx context.Context, host, rawURL string) ([]netip.Addr, error) {
+	deny := func(reason string) ([]netip.Addr, error) {
+		g.report(&EgressDeniedData{Source: EgressSourceProxy, URL: rawURL, Host: host, Reason: reason})
+		return nil, fmt.Errorf("%w: %s", errEgressDenied, reason)
+	}
+	if reason := g.check(host); reason != "" {
+		return deny(reason)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", strings.Trim(host, "[]"))
+	if err != nil {
+		return nil, err
+	}
+	for _, resolved := range addrs {
+		if reason := g.checkAddr(resolved, false); reason != "" {
+			return deny(fmt.Sprintf("%s resolves to %s", host, reason))
+		}
+	}
+	return addrs, nil
+}
+
+// dial connects to addr if the policy allows it.
+func (g *egressGuard) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	rawURL, _ := ctx.Value(egressURLKey{}).(string)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := g.resolve(ctx, host, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	var dialErr error
+	for _, resolved := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(resolved.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = err
+	}
+	return nil, dialErr
+}
+
+// egressProxy is an HTTP proxy that forwards plain HTTP requests and tunnels
+// CONNECT requests to destinations the guard allows, through the guard's
+// upstream proxy when one applies.
+type egressProxy struct {
+	guard *egressGuard
+	// transport connects directly and proxied through the upstream proxy.
+	transport *http.Transport
+	proxied   *http.Transport
+}
+
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "this is a forward proxy; request an absolute URL", http.StatusBadRequest)
+		return
+	}
+	ctx := context.WithValue(r.Context(), egressURLKey{}, r.URL.String())
+	out := r.Clone(ctx)
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+	transport := p.transport
+	upstream, err := p.guard.upstream(out)
+	if err != nil {
+		proxyError(w, err)
+		return
+	}
+	if upstream != nil {
+		// The upstream proxy connects, so check the destination here.
+		if _, err := p.guard.resolve(ctx, out.URL.Hostname(), out.URL.String()); err != nil {
+			proxyError(w, err)
+			return
+		}
+		transport = p.proxied
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		proxyError(w, err)
+		return
+	}
+	defer resp.Body.Close()
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dialTunnel(r.Context(), r.Host)
+	if err != nil {
+		proxyError(w, err)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "connection hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+	go func() {
+		io.Copy(upstream, buffered)
+		upstream.Close()
+	}()
+	io.Copy(client, upstream)
+	client.Close()
+}
+
+// dialTunnel connects to addr, through a CONNECT request to the upstream
+// proxy when one applies.
+func (p *egressProxy) dialTunnel(ctx context.Context, addr string) (net.Conn, error) {
+	proxyURL, err := p.guard.upstream(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return p.guard.dial(ctx, "tcp", addr)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.guard.resolve(ctx, host, addr); err != nil {
+		return nil, err
+	}
+	return dialThroughProxy(ctx, proxyURL, addr)
+}
+
+// dialThroughProxy opens a tunnel to addr with a CONNECT request to the
+// HTTP or HTTPS proxy at proxyURL.
+func dialThroughProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach proxy: %w", err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to reach proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		delete(req.Header, "Authorization")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to reach proxy: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to reach proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused the tunnel: %s", resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose reads are served from a bufio.Reader.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func proxyError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if errors.Is(err, errEgressDenied) {
+		status = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
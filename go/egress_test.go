@@ -0,0 +1,200 @@
+package copilot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEgressGuard(t *testing.T) {
+	t.Run("applies domain and CIDR rules", func(t *testing.T) {
+		guard, err := newEgressGuard(&EgressPolicy{
+			AllowDomains: []string{"api.github.com", "*.githubusercontent.com"},
+			AllowCIDRs:   []string{"10.0.0.0/8"},
+			DenyDomains:  []string{"gist.githubusercontent.com"},
+			DenyCIDRs:    []string{"10.1.0.0/16"},
+		}, nil)
+		if err != nil {
+			t.Fatalf("newEgressGuard failed: %v", err)
+		}
+		for host, allowed := range map[string]bool{
+			"api.github.com":             true,
+			"API.GitHub.com.":            true,
+			"github.com":                 false,
+			"raw.githubusercontent.com":  true,
+			"githubusercontent.com":      false,
+			"gist.githubusercontent.com": false,
+			"evilgithubusercontent.com":  false,
+			"10.2.3.4":                   true,
+			"10.1.2.3":                   false,
+			"192.168.0.1":                false,
+			"[::ffff:10.1.2.3]":          false,
+			"":                           false,
+		} {
+			if got := guard.check(host) == ""; got != allowed {
+				t.Errorf("check(%q) allowed = %v, want %v", host, got, allowed)
+			}
+		}
+	})
+
+	t.Run("allows everything not denied without an allowlist", func(t *testing.T) {
+		guard, _ := newEgressGuard(&EgressPolicy{DenyCIDRs: []string{"169.254.169.254/32"}}, nil)
+		if reason := guard.check("example.com"); reason != "" {
+			t.Errorf("expected example.com to be allowed, got %q", reason)
+		}
+		if reason := guard.check("169.254.169.254"); reason == "" {
+			t.Error("expected the metadata address to be denied")
+		}
+	})
+
+	t.Run("rejects invalid CIDRs", func(t *testing.T) {
+		if _, err := newEgressGuard(&EgressPolicy{DenyCIDRs: []string{"10.0.0.0/33"}}, nil); err == nil {
+			t.Error("expected an error for an invalid CIDR")
+		}
+	})
+
+	t.Run("finds blocked URLs in permission requests", func(t *testing.T) {
+		guard, _ := newEgressGuard(&EgressPolicy{AllowDomains: []string{"docs.github.com"}}, nil)
+		if v := guard.permissionViolations(&PermissionRequestURL{URL: "https://docs.github.com/en"}); len(v) != 0 {
+			t.Errorf("expected no violations, got %+v", v)
+		}
+		v := guard.permissionViolations(&PermissionRequestURL{URL: "https://paste.example.com/x", ToolCallID: ptr("call-1")})
+		if len(v) != 1 || v[0].Source != EgressSourceFetch || v[0].Host != "paste.example.com" || v[0].ToolCallID != "call-1" {
+			t.Errorf("unexpected violations: %+v", v)
+		}
+		v = guard.permissionViolations(&PermissionRequestShell{PossibleURLs: []PermissionRequestShellPossibleURL{
+			{URL: "docs.github.com/en"},
+			{URL: "evil.example.com:8443/upload"},
+		}})
+		if len(v) != 1 || v[0].Source != EgressSourceShell || v[0].Host != "evil.example.com" {
+			t.Errorf("unexpected violations: %+v", v)
+		}
+	})
+}
+
+func TestEgressProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+
+	get := func(t *testing.T, policy *EgressPolicy, network *NetworkConfig) (int, []EgressDeniedData) {
+		t.Helper()
+		session, cleanup := newTestSession()
+		defer cleanup()
+		denied := make(chan EgressDeniedData, 1)
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*EgressDeniedData); ok {
+				denied <- *d
+			}
+		})
+		guard, err := newEgressGuard(policy, network)
+		if err != nil {
+			t.Fatalf("newEgressGuard failed: %v", err)
+		}
+		session.attachEgress(guard)
+		defer guard.close()
+
+		env, err := guard.proxyEnv()
+		if err != nil {
+			t.Fatalf("proxyEnv failed: %v", err)
+		}
+		proxyURL, _ := url.Parse(strings.TrimPrefix(env[0], "HTTP_PROXY="))
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		var events []EgressDeniedData
+		select {
+		case d := <-denied:
+			events = append(events, d)
+		case <-time.After(100 * time.Millisecond):
+		}
+		return resp.StatusCode, events
+	}
+
+	t.Run("forwards allowed requests", func(t *testing.T) {
+		status, events := get(t, &EgressPolicy{AllowCIDRs: []string{"127.0.0.1/32"}, ProxyShellCommands: true}, nil)
+		if status != http.StatusOK || len(events) != 0 {
+			t.Errorf("got status %d and events %+v", status, events)
+		}
+	})
+
+	t.Run("blocks denied requests and reports them", func(t *testing.T) {
+		status, events := get(t, &EgressPolicy{DenyCIDRs: []string{"127.0.0.0/8"}, ProxyShellCommands: true}, nil)
+		if status != http.StatusForbidden {
+			t.Errorf("got status %d, want 403", status)
+		}
+		if len(events) != 1 || events[0].Source != EgressSourceProxy || events[0].URL != upstream.URL+"/" {
+			t.Errorf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("forwards through the configured proxy after checking the destination", func(t *testing.T) {
+		var proxied []string
+		var mu sync.Mutex
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			proxied = append(proxied, r.URL.String())
+			mu.Unlock()
+			w.Write([]byte("via proxy"))
+		}))
+		defer proxy.Close()
+		network := &NetworkConfig{ProxyURL: proxy.URL}
+
+		status, events := get(t, &EgressPolicy{AllowCIDRs: []string{"127.0.0.1/32"}, ProxyShellCommands: true}, network)
+		if status != http.StatusOK || len(events) != 0 || len(proxied) != 1 || proxied[0] != upstream.URL+"/" {
+			t.Errorf("got status %d, events %+v and proxied %q", status, events, proxied)
+		}
+		status, events = get(t, &EgressPolicy{DenyCIDRs: []string{"127.0.0.0/8"}, ProxyShellCommands: true}, network)
+		if status != http.StatusForbidden || len(events) != 1 || len(proxied) != 1 {
+			t.Errorf("expected the denied request to stop before the proxy, got status %d, events %+v and proxied %q", status, events, proxied)
+		}
+	})
+}
+
+func TestDialThroughProxy(t *testing.T) {
+	var authorization string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Proxy-Authorization")
+		if r.Method != http.MethodConnect || r.Host != "example.test:443" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\n\r\ntunneled")
+		buf.Flush()
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	conn, err := dialThroughProxy(t.Context(), proxyURL, "example.test:443")
+	if err != nil {
+		t.Fatalf("dialThroughProxy failed: %v", err)
+	}
+	defer conn.Close()
+	data, _ := io.ReadAll(conn)
+	if string(data) != "tunneled" {
+		t.Errorf("expected the tunnel to carry the destination's bytes, got %q", data)
+	}
+	if authorization != "Basic dXNlcjpzZWNyZXQ=" {
+		t.Errorf("expected the proxy credentials, got %q", authorization)
+	}
+
+	if _, err := dialThroughProxy(t.Context(), proxyURL, "other.test:443"); err == nil {
+		t.Error("expected an error when the proxy refuses the tunnel")
+	}
+}
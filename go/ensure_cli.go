@@ -0,0 +1,520 @@
+package copilot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/embeddedcli"
+	"github.com/github/copilot-sdk/go/internal/flock"
+)
+
+// MinCLIVersion is the oldest Copilot CLI release supported by this SDK.
+const MinCLIVersion = "1.0.73"
+
+// DefaultCLIVersion is the CLI release installed by [EnsureCLI] when no
+// explicit version is requested.
+const DefaultCLIVersion = MinCLIVersion
+
+const defaultNPMRegistryURL = "https://registry.npmjs.org"
+
+// CLISource describes where [EnsureCLI] found the Copilot CLI.
+type CLISource string
+
+const (
+	// CLISourceExplicit is a CLI located at [EnsureOptions.Path].
+	CLISourceExplicit CLISource = "explicit"
+	// CLISourceEnv is a CLI located via the COPILOT_CLI_PATH environment variable.
+	CLISourceEnv CLISource = "env"
+	// CLISourceEmbedded is the CLI bundled into the application via the embeddedcli package.
+	CLISourceEmbedded CLISource = "embedded"
+	// CLISourcePath is a copilot executable found on the system PATH.
+	CLISourcePath CLISource = "path"
+	// CLISourceCache is a CLI previously installed into the cache directory.
+	CLISourceCache CLISource = "cache"
+	// CLISourceDownload is a CLI downloaded and installed by this call.
+	CLISourceDownload CLISource = "download"
+)
+
+// EnsureOptions configures [EnsureCLI].
+type EnsureOptions struct {
+	// Path is an explicit path to a CLI executable or .js entry point. When set,
+	// no other locations are searched and the CLI is only verified.
+	Path string
+	// Version is the CLI version to install when no compatible CLI is found.
+	// Defaults to [DefaultCLIVersion]. Versions older than [MinCLIVersion] are rejected.
+	Version string
+	// CacheDirectory is where downloaded CLIs are installed, one subdirectory per
+	// version. Defaults to $COPILOT_HOME/cache/copilot-sdk, or the user cache
+	// directory when COPILOT_HOME is unset.
+	CacheDirectory string
+	// Download allows EnsureCLI to fetch the CLI from the npm registry when no
+	// compatible installation is found. When false, a missing CLI is an error.
+	Download bool
+	// RegistryURL overrides the npm registry used for downloads.
+	// Defaults to https://registry.npmjs.org.
+	RegistryURL string
+	// HTTPClient is used for downloads. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+	// Env is the environment used to resolve COPILOT_CLI_PATH, COPILOT_HOME and
+	// PATH lookups, and to run the CLI for version checks. Defaults to os.Environ().
+	Env []string
+}
+
+// CLIInfo describes a Copilot CLI located by [EnsureCLI].
+type CLIInfo struct {
+	// Path is the absolute path to the CLI. Pass it to [StdioConnection] or
+	// [TCPConnection] to use this CLI.
+	Path string
+	// Version is the version reported by the CLI.
+	Version string
+	// Source records where the CLI was found.
+	Source CLISource
+}
+
+// EnsureCLI locates a Copilot CLI whose version is supported by this SDK,
+// optionally downloading and installing one into a cache directory.
+//
+// Candidates are searched in order: [EnsureOptions.Path], COPILOT_CLI_PATH, the
+// embedded CLI, copilot on the PATH, and finally the cache directory. The first
+// candidate reporting a version of at least [MinCLIVersion] is returned. When
+// none qualifies and [EnsureOptions.Download] is set, the requested version is
+// downloaded from npm, verified against the registry's integrity hash, and
+// installed atomically so concurrent processes can share the cache. A cached
+// CLI is checked against the checksum recorded at install, and downloaded
+// again if it no longer matches.
+//
+// Example:
+//
+//	cli, err := copilot.EnsureCLI(ctx, copilot.EnsureOptions{Download: true})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    Connection: copilot.StdioConnection{Path: cli.Path},
+//	})
+func EnsureCLI(ctx context.Context, opts EnsureOptions) (*CLIInfo, error) {
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	version := opts.Version
+	if version == "" {
+		version = DefaultCLIVersion
+	}
+	if compareCLIVersions(version, MinCLIVersion) < 0 {
+		return nil, fmt.Errorf("CLI version %s is older than the minimum supported version %s", version, MinCLIVersion)
+	}
+
+	if opts.Path != "" {
+		return verifyCLI(ctx, opts.Path, CLISourceExplicit, env)
+	}
+
+	var rejected []string
+	try := func(path string, source CLISource) *CLIInfo {
+		if path == "" {
+			return nil
+		}
+		info, err := verifyCLI(ctx, path, source, env)
+		if err != nil {
+			rejected = append(rejected, err.Error())
+			return nil
+		}
+		return info
+	}
+
+	if info := try(getEnvValue(env, "COPILOT_CLI_PATH"), CLISourceEnv); info != nil {
+		return info, nil
+	}
+	if info := try(embeddedcli.Path(), CLISourceEmbedded); info != nil {
+		return info, nil
+	}
+	if path, err := lookPathEnv("copilot", env); err == nil {
+		if info := try(path, CLISourcePath); info != nil {
+			return info, nil
+		}
+	}
+
+	cacheDir, err := ensureCacheDirectory(opts.CacheDirectory, env)
+	if err != nil {
+		return nil, err
+	}
+	installDir := filepath.Join(cacheDir, sanitizeCLIVersion(version))
+	cachedPath := filepath.Join(installDir, cliBinaryName())
+	if _, err := os.Stat(cachedPath); err == nil {
+		// A cached CLI that no longer matches its checksum is installed again.
+		if err := verifyInstalledCLI(cachedPath); err != nil {
+			rejected = append(rejected, err.Error())
+		} else if info := try(cachedPath, CLISourceCache); info != nil {
+			return info, nil
+		}
+	}
+
+	if !opts.Download {
+		if len(rejected) > 0 {
+			return nil, fmt.Errorf("no supported Copilot CLI found: %s", strings.Join(rejected, "; "))
+		}
+		return nil, errors.New("no Copilot CLI found; set EnsureOptions.Download to install one")
+	}
+
+	path, err := installCLI(ctx, opts, version, installDir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := verifyCLI(ctx, path, CLISourceDownload, env)
+	if err != nil {
+		return nil, fmt.Errorf("downloaded CLI failed verification: %w", err)
+	}
+	return info, nil
+}
+
+// verifyCLI runs the CLI with --version and checks the result against MinCLIVersion.
+func verifyCLI(ctx context.Context, path string, source CLISource, env []string) (*CLIInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve CLI path %s: %w", path, err)
+	}
+	version, err := readCLIVersion(ctx, absPath, env)
+	if err != nil {
+		return nil, err
+	}
+	if compareCLIVersions(version, MinCLIVersion) < 0 {
+		return nil, fmt.Errorf("CLI at %s has version %s, but this SDK requires %s or newer", absPath, version, MinCLIVersion)
+	}
+	return &CLIInfo{Path: absPath, Version: version, Source: source}, nil
+}
+
+var cliVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?`)
+
+// readCLIVersion runs the CLI with --version and extracts the version number.
+func readCLIVersion(ctx context.Context, path string, env []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	command, args := path, []string{"--version"}
+	if strings.HasSuffix(path, ".js") {
+		command, args = "node", []string{path, "--version"}
+	}
+	cmd := exec.CommandContext(ctx, command, args...)
+	configureProcAttr(cmd)
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %w", path, err)
+	}
+	version := cliVersionPattern.FindString(string(out))
+	if version == "" {
+		return "", fmt.Errorf("failed to parse CLI version from %q", strings.TrimSpace(string(out)))
+	}
+	return version, nil
+}
+
+// compareCLIVersions compares two semver strings, returning -1, 0 or 1.
+// A pre-release sorts before its release; pre-release tags are compared
+// as plain strings.
+func compareCLIVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < 3; i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// lookPathEnv is like exec.LookPath but searches the PATH from env.
+func lookPathEnv(file string, env []string) (string, error) {
+	pathEnv := getEnvValue(env, "PATH")
+	if pathEnv == "" {
+		return exec.LookPath(file)
+	}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		candidates := []string{filepath.Join(dir, file)}
+		if runtime.GOOS == "windows" {
+			candidates = []string{filepath.Join(dir, file+".exe"), filepath.Join(dir, file+".cmd")}
+		}
+		for _, candidate := range candidates {
+			if st, err := os.Stat(candidate); err == nil && !st.IsDir() && (runtime.GOOS == "windows" || st.Mode()&0111 != 0) {
+				return candidate, nil
+			}
+		}
+	}
+	return "", exec.ErrNotFound
+}
+
+func ensureCacheDirectory(dir string, env []string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	if copilotHome := getEnvValue(env, "COPILOT_HOME"); copilotHome != "" {
+		return filepath.Join(copilotHome, "cache", "copilot-sdk"), nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "copilot-sdk"), nil
+}
+
+func cliBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "copilot.exe"
+	}
+	return "copilot"
+}
+
+// cliNPMPlatform returns the npm platform suffix of the @github/copilot-* package
+// that ships the CLI binary for the current platform.
+func cliNPMPlatform() (string, error) {
+	var arch string
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = "x64"
+	case "arm64":
+		arch = "arm64"
+	default:
+		return "", fmt.Errorf("no Copilot CLI package for architecture %s", runtime.GOARCH)
+	}
+	switch runtime.GOOS {
+	case "linux", "darwin":
+		return runtime.GOOS + "-" + arch, nil
+	case "windows":
+		return "win32-" + arch, nil
+	default:
+		return "", fmt.Errorf("no Copilot CLI package for OS %s", runtime.GOOS)
+	}
+}
+
+// sanitizeCLIVersion makes a version string safe to use as a directory name.
+func sanitizeCLIVersion(version string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, version)
+	if sanitized == "." || sanitized == ".." {
+		return strings.Repeat("_", len(sanitized))
+	}
+	return sanitized
+}
+
+// installCLI downloads the platform package for version from npm and installs
+// its CLI binary into installDir, returning the installed path.
+func installCLI(ctx context.Context, opts EnsureOptions, version, installDir string) (string, error) {
+	platform, err := cliNPMPlatform()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create install directory: %w", err)
+	}
+
+	// Serialize installs across processes sharing the cache.
+	release, err := flock.Acquire(filepath.Join(installDir, ".copilot-cli.lock"))
+	if err != nil {
+		return "", fmt.Errorf("failed to lock install directory: %w", err)
+	}
+	defer release()
+
+	finalPath := filepath.Join(installDir, cliBinaryName())
+	if _, err := os.Stat(finalPath); err == nil {
+		// Another process finished the install while we waited for the lock.
+		if verifyInstalledCLI(finalPath) == nil {
+			return finalPath, nil
+		}
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	registry := strings.TrimSuffix(opts.RegistryURL, "/")
+	if registry == "" {
+		registry = defaultNPMRegistryURL
+	}
+
+	pkg := "@github/copilot-" + platform
+	var manifest struct {
+		Dist struct {
+			Tarball   string `json:"tarball"`
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	}
+	manifestURL := registry + "/" + url.PathEscape(pkg) + "/" + url.PathEscape(version)
+	manifestBody, err := httpGet(ctx, httpClient, manifestURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s@%s metadata: %w", pkg, version, err)
+	}
+	if err := json.Unmarshal(manifestBody, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse %s@%s metadata: %w", pkg, version, err)
+	}
+	if manifest.Dist.Tarball == "" {
+		return "", fmt.Errorf("registry metadata for %s@%s has no tarball", pkg, version)
+	}
+
+	tarball, err := httpGet(ctx, httpClient, manifest.Dist.Tarball)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s@%s: %w", pkg, version, err)
+	}
+	if err := verifyNPMIntegrity(tarball, manifest.Dist.Integrity); err != nil {
+		return "", fmt.Errorf("failed to verify %s@%s: %w", pkg, version, err)
+	}
+
+	tmpFile, err := os.CreateTemp(installDir, ".copilot-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	hash := sha512.New()
+	if err := extractFromNPMTarball(bytes.NewReader(tarball), "package/"+cliBinaryName(), io.MultiWriter(tmpFile, hash)); err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("failed to extract CLI from %s@%s: %w", pkg, version, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to chmod CLI: %w", err)
+	}
+	// The checksum is written first, so an installed CLI always has one.
+	if err := os.WriteFile(finalPath+cliChecksumSuffix, []byte(hex.EncodeToString(hash.Sum(nil))), 0644); err != nil {
+		return "", fmt.Errorf("failed to write CLI checksum: %w", err)
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", fmt.Errorf("failed to install CLI: %w", err)
+	}
+	return finalPath, nil
+}
+
+// cliChecksumSuffix names the file beside an installed CLI that holds the
+// hex SHA-512 of the binary.
+const cliChecksumSuffix = ".sha512"
+
+// verifyInstalledCLI checks the CLI installed at path against the checksum
+// recorded when it was installed.
+func verifyInstalledCLI(path string) error {
+	want, err := os.ReadFile(path + cliChecksumSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum of cached CLI %s: %w", path, err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open cached CLI %s: %w", path, err)
+	}
+	defer f.Close()
+	hash := sha512.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return fmt.Errorf("failed to read cached CLI %s: %w", path, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("cached CLI %s does not match its checksum", path)
+	}
+	return nil
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verifyNPMIntegrity checks data against an npm "sha512-<base64>" integrity string.
+func verifyNPMIntegrity(data []byte, integrity string) error {
+	encoded, ok := strings.CutPrefix(integrity, "sha512-")
+	if !ok {
+		return fmt.Errorf("unsupported integrity %q", integrity)
+	}
+	want, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid integrity %q: %w", integrity, err)
+	}
+	got := sha512.Sum512(data)
+	if !bytes.Equal(got[:], want) {
+		return errors.New("integrity mismatch")
+	}
+	return nil
+}
+
+// extractFromNPMTarball copies the tar entry named target from a .tgz stream to w.
+func extractFromNPMTarball(r io.Reader, target string, w io.Writer) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in package", target)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+		if header.Name == target {
+			_, err := io.Copy(w, tr)
+			return err
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package copilot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompareCLIVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.73", "1.0.73", 0},
+		{"1.0.74", "1.0.73", 1},
+		{"1.0.9", "1.0.73", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.73-1", "1.0.73", -1},
+		{"1.0.73", "1.0.73-1", 1},
+		{"1.0.73-1", "1.0.73-2", -1},
+	}
+	for _, tc := range cases {
+		if got := compareCLIVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareCLIVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMinCLIVersionMatchesLock(t *testing.T) {
+	// The dependency update workflow bumps MinCLIVersion from this lock.
+	data, err := os.ReadFile(filepath.Join("..", "nodejs", "package-lock.json"))
+	if os.IsNotExist(err) {
+		t.Skip("no nodejs/package-lock.json outside the repository")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatal(err)
+	}
+	if locked := lock.Packages["node_modules/@github/copilot"].Version; locked != MinCLIVersion {
+		t.Errorf("MinCLIVersion is %s, but nodejs/package-lock.json locks @github/copilot %s", MinCLIVersion, locked)
+	}
+}
+
+// writeFakeCLI writes a shell script that prints the given --version output.
+func writeFakeCLI(t *testing.T, dir, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLI scripts require a POSIX shell")
+	}
+	path := filepath.Join(dir, "copilot")
+	script := fmt.Sprintf("#!/bin/sh\necho %q\n", output)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnsureCLI(t *testing.T) {
+	t.Run("verifies an explicit path", func(t *testing.T) {
+		path := writeFakeCLI(t, t.TempDir(), "GitHub Copilot CLI 1.0.80")
+		info, err := EnsureCLI(t.Context(), EnsureOptions{Path: path, Env: []string{}})
+		if err != nil {
+			t.Fatalf("EnsureCLI failed: %v", err)
+		}
+		if info.Path != path || info.Version != "1.0.80" || info.Source != CLISourceExplicit {
+			t.Errorf("unexpected info: %+v", info)
+		}
+	})
+
+	t.Run("rejects a CLI older than the minimum version", func(t *testing.T) {
+		path := writeFakeCLI(t, t.TempDir(), "1.0.1")
+		_, err := EnsureCLI(t.Context(), EnsureOptions{Path: path, Env: []string{}})
+		if err == nil || !strings.Contains(err.Error(), MinCLIVersion) {
+			t.Fatalf("expected minimum version error, got %v", err)
+		}
+	})
+
+	t.Run("prefers COPILOT_CLI_PATH over PATH", func(t *testing.T) {
+		envCLI := writeFakeCLI(t, t.TempDir(), "1.0.90")
+		pathDir := t.TempDir()
+		writeFakeCLI(t, pathDir, "1.0.91")
+		info, err := EnsureCLI(t.Context(), EnsureOptions{
+			Env: []string{"COPILOT_CLI_PATH=" + envCLI, "PATH=" + pathDir},
+		})
+		if err != nil {
+			t.Fatalf("EnsureCLI failed: %v", err)
+		}
+		if info.Source != CLISourceEnv || info.Version != "1.0.90" {
+			t.Errorf("unexpected info: %+v", info)
+		}
+	})
+
+	t.Run("errors without Download when nothing is found", func(t *testing.T) {
+		_, err := EnsureCLI(t.Context(), EnsureOptions{
+			CacheDirectory: t.TempDir(),
+			Env:            []string{"PATH=" + t.TempDir()},
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("downloads, verifies and caches the pinned version", func(t *testing.T) {
+		platform, err := cliNPMPlatform()
+		if err != nil {
+			t.Skip(err)
+		}
+		if runtime.GOOS == "windows" {
+			t.Skip("fake CLI scripts require a POSIX shell")
+		}
+		tarball := buildTestTarball(t, "package/copilot", "#!/bin/sh\necho 1.0.99\n")
+		sum := sha512.Sum512(tarball)
+		integrity := "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+
+		var requests []string
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			switch r.URL.Path {
+			case "/@github%2Fcopilot-" + platform + "/1.0.99", "/@github/copilot-" + platform + "/1.0.99":
+				fmt.Fprintf(w, `{"dist":{"tarball":%q,"integrity":%q}}`, server.URL+"/pkg.tgz", integrity)
+			case "/pkg.tgz":
+				w.Write(tarball)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		cacheDir := t.TempDir()
+		opts := EnsureOptions{
+			Version:        "1.0.99",
+			CacheDirectory: cacheDir,
+			Download:       true,
+			RegistryURL:    server.URL,
+			Env:            []string{"PATH=" + t.TempDir()},
+		}
+		info, err := EnsureCLI(t.Context(), opts)
+		if err != nil {
+			t.Fatalf("EnsureCLI failed: %v (requests: %v)", err, requests)
+		}
+		if info.Source != CLISourceDownload || info.Version != "1.0.99" {
+			t.Errorf("unexpected info: %+v", info)
+		}
+		if want := filepath.Join(cacheDir, "1.0.99", "copilot"); info.Path != want {
+			t.Errorf("expected path %s, got %s", want, info.Path)
+		}
+
+		info, err = EnsureCLI(t.Context(), opts)
+		if err != nil {
+			t.Fatalf("second EnsureCLI failed: %v", err)
+		}
+		if info.Source != CLISourceCache {
+			t.Errorf("expected cached CLI on second call, got %s", info.Source)
+		}
+		if len(requests) != 2 {
+			t.Errorf("expected 2 HTTP requests, got %v", requests)
+		}
+
+		// A cached CLI that no longer matches its checksum is downloaded again.
+		if err := os.WriteFile(info.Path, []byte("#!/bin/sh\necho 1.0.98\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		info, err = EnsureCLI(t.Context(), opts)
+		if err != nil {
+			t.Fatalf("third EnsureCLI failed: %v", err)
+		}
+		if info.Source != CLISourceDownload || info.Version != "1.0.99" {
+			t.Errorf("expected the tampered CLI to be downloaded again, got %+v", info)
+		}
+		if len(requests) != 4 {
+			t.Errorf("expected 4 HTTP requests, got %v", requests)
+		}
+	})
+
+	t.Run("rejects a tarball with a mismatched integrity hash", func(t *testing.T) {
+		if _, err := cliNPMPlatform(); err != nil {
+			t.Skip(err)
+		}
+		tarball := buildTestTarball(t, "package/"+cliBinaryName(), "binary")
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/pkg.tgz" {
+				w.Write(tarball)
+				return
+			}
+			fmt.Fprintf(w, `{"dist":{"tarball":%q,"integrity":"sha512-AAAA"}}`, server.URL+"/pkg.tgz")
+		}))
+		defer server.Close()
+
+		_, err := installCLI(context.Background(), EnsureOptions{RegistryURL: server.URL}, "1.0.99", t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), "integrity mismatch") {
+			t.Fatalf("expected integrity error, got %v", err)
+		}
+	})
+}
+
+func buildTestTarball(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
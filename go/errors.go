@@ -0,0 +1,130 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// Errors matched with [errors.Is] against the errors SDK methods return,
+// whether they arise in the SDK or in a response from the runtime:
+//
+//	if _, err := client.ResumeSession(ctx, id, config); errors.Is(err, copilot.ErrSessionNotFound) {
+//	    session, err = client.CreateSession(ctx, newConfig)
+//	}
+var (
+	// ErrCLINotFound is returned by [Client.Start] when there is no Copilot
+	// CLI to run: none at the connection's path or COPILOT_CLI_PATH, none
+	// embedded, and none in PATH.
+	ErrCLINotFound = errors.New("copilot CLI not found")
+	// ErrProtocolMismatch is returned by [Client.Start] when the runtime
+	// speaks a protocol version the SDK does not support.
+	ErrProtocolMismatch = errors.New("SDK protocol version mismatch")
+	// ErrSessionNotFound is returned for a session that does not exist, such
+	// as by [Client.ResumeSession] for an unknown or deleted session, or by
+	// [Session.PostMessageTo] for a session not open on the same client.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrPermissionDenied is returned when the runtime refuses an operation,
+	// and matches a [SessionError] for a model request that the account is
+	// not authorized to make.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrTurnAborted matches the [TurnAbortedError] returned by
+	// [Session.SendAndWait] when the turn is aborted, for instance by
+	// [Session.Abort] from another goroutine.
+	ErrTurnAborted = errors.New("turn aborted")
+	// ErrContextWindowExceeded matches a [SessionError] for a conversation
+	// that no longer fits the model's context window.
+	ErrContextWindowExceeded = errors.New("context window exceeded")
+)
+
+// TurnAbortedError is returned by [Session.SendAndWait] when the turn is
+// aborted before it completes. It matches [ErrTurnAborted] with [errors.Is].
+type TurnAbortedError struct {
+	// Reason is the runtime's reason for the abort, such as "user_initiated".
+	Reason AbortReason
+}
+
+func (e *TurnAbortedError) Error() string {
+	if e.Reason == "" {
+		return "turn aborted"
+	}
+	return "turn aborted: " + string(e.Reason)
+}
+
+func (e *TurnAbortedError) Is(target error) bool {
+	return target == ErrTurnAborted
+}
+
+// cliStartError marks a failure to start the CLI process as [ErrCLINotFound]
+// when the executable does not exist.
+func cliStartError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrCLINotFound, err)
+	}
+	return err
+}
+
+// runtimeError is an error response from the runtime that matches one of
+// the SDK's errors. It reads as the response and still unwraps to the
+// [jsonrpc2.Error].
+type runtimeError struct {
+	response *jsonrpc2.Error
+	kind     error
+}
+
+func (e *runtimeError) Error() string {
+	return e.response.Error()
+}
+
+func (e *runtimeError) Unwrap() []error {
+	return []error{e.kind, e.response}
+}
+
+// classifyRuntimeError matches an error response from the runtime to the
+// SDK error it reports. The runtime reports these conditions only in the
+// message, so it is matched here once rather than by every caller.
+func classifyRuntimeError(response *jsonrpc2.Error) error {
+	message := strings.ToLower(response.Message)
+	var kind error
+	switch {
+	case sessionNotFoundMessage.MatchString(message):
+		kind = ErrSessionNotFound
+	case strings.Contains(message, "permission denied") || strings.Contains(message, "not permitted"):
+		kind = ErrPermissionDenied
+	case strings.Contains(message, "context window") || strings.Contains(message, "context length"):
+		kind = ErrContextWindowExceeded
+	case isAbortMessage(message):
+		kind = ErrTurnAborted
+	default:
+		return response
+	}
+	return &runtimeError{response: response, kind: kind}
+}
+
+// sessionNotFoundMessage matches the lower-cased messages the runtime
+// reports an unknown session with: "Session not found", "Session not found:
+// <id>" and "Session <id> not found". Other messages that mention a session
+// and something not found, such as a missing file, are not matched.
+var sessionNotFoundMessage = regexp.MustCompile(`^session (not found(: \S+)?|\S+ not found)\.?$`)
+
+// abortMessages are the messages the runtime reports a cancelled request
+// with. Other messages that mention an abort, such as a tool's own failure,
+// are not turn aborts.
+var abortMessages = []string{"request aborted", "operation was aborted", "turn aborted"}
+
+// isAbortMessage reports whether the lower-cased message ends with one of
+// [abortMessages], ignoring a trailing period.
+func isAbortMessage(message string) bool {
+	message = strings.TrimSuffix(strings.TrimSpace(message), ".")
+	for _, suffix := range abortMessages {
+		if strings.HasSuffix(message, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestErrors(t *testing.T) {
+	t.Run("reports a missing CLI", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Connection: StdioConnection{Path: filepath.Join(t.TempDir(), "copilot")}})
+		err := client.Start(t.Context())
+		if !errors.Is(err, ErrCLINotFound) {
+			t.Errorf("expected ErrCLINotFound, got %v", err)
+		}
+	})
+
+	t.Run("classifies error responses from the runtime", func(t *testing.T) {
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.resume": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return nil, &jsonrpc2.Error{Code: -32602, Message: "Session s1 not found"}
+			},
+		})
+		_, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		var rpcErr *jsonrpc2.Error
+		if !errors.Is(err, ErrSessionNotFound) || !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+			t.Fatalf("expected ErrSessionNotFound wrapping the response, got %v", err)
+		}
+		if want := "failed to resume session: JSON-RPC Error -32602: Session s1 not found"; err.Error() != want {
+			t.Errorf("expected the message %q, got %q", want, err.Error())
+		}
+
+		for message, want := range map[string]error{
+			"Session not found: s1":                  ErrSessionNotFound,
+			"session not found":                      ErrSessionNotFound,
+			"session s1: file not found":             nil,
+			"Session config file not found":          nil,
+			"Permission denied: shell":               ErrPermissionDenied,
+			"prompt exceeds the context window":      ErrContextWindowExceeded,
+			"Request aborted":                        ErrTurnAborted,
+			"AbortError: The operation was aborted.": ErrTurnAborted,
+			"upload aborted: file too large":         nil,
+			"Unhandled method session.unknownOne":    nil,
+		} {
+			err := classifyRuntimeError(&jsonrpc2.Error{Message: message})
+			for _, sentinel := range []error{ErrSessionNotFound, ErrPermissionDenied, ErrContextWindowExceeded, ErrTurnAborted} {
+				if errors.Is(err, sentinel) != (sentinel == want) {
+					t.Errorf("%q: errors.Is(%v) = %v", message, sentinel, !(sentinel == want))
+				}
+			}
+		}
+	})
+
+	t.Run("matches session errors by type", func(t *testing.T) {
+		if err := error(&SessionError{ErrorType: "context_limit"}); !errors.Is(err, ErrContextWindowExceeded) || errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("expected a context_limit error to match only ErrContextWindowExceeded")
+		}
+		if err := error(&SessionError{ErrorType: "authorization", StatusCode: 403}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("expected an authorization error to match ErrPermissionDenied")
+		}
+	})
+
+	t.Run("fails SendAndWait with the last message when the turn is aborted", func(t *testing.T) {
+		sent := make(chan struct{}, 1)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				sent <- struct{}{}
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		go func() {
+			<-sent
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{Content: "Working on it"}})
+			session.dispatchEvent(SessionEvent{Data: &AbortData{Reason: AbortReasonUserInitiated}})
+			session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		}()
+
+		message, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "hi"})
+		var abortErr *TurnAbortedError
+		if !errors.Is(err, ErrTurnAborted) || !errors.As(err, &abortErr) || abortErr.Reason != AbortReasonUserInitiated {
+			t.Errorf("expected a TurnAbortedError, got %v", err)
+		}
+		if d, ok := message.Data.(*AssistantMessageData); !ok || d.Content != "Working on it" {
+			t.Errorf("expected the last assistant message with the error, got %+v", message)
+		}
+	})
+}
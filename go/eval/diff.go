@@ -0,0 +1,351 @@
+// Package eval compares runs of Copilot SDK sessions, for A/B comparisons
+// of configurations and prompt regression analysis.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// TranscriptDiff is a structured comparison of two runs of the same
+// prompts, A and B, such as under different models or system messages.
+type TranscriptDiff struct {
+	// Turns compares the runs turn by turn, pairing turns by position.
+	// When one run has more turns, the extra turns are compared against an
+	// empty turn.
+	Turns []TurnDiff `json:"turns"`
+	// UsageA and UsageB are the model usage of each run, and UsageDelta
+	// is UsageB minus UsageA.
+	UsageA     copilot.TurnUsage `json:"usageA"`
+	UsageB     copilot.TurnUsage `json:"usageB"`
+	UsageDelta copilot.TurnUsage `json:"usageDelta"`
+}
+
+// TurnDiff compares one turn of two runs.
+type TurnDiff struct {
+	// Index is the position of the turn, from 0.
+	Index int `json:"index"`
+	// PromptA and PromptB are the user messages that started the turn.
+	// They differ only when the runs were not given the same prompts.
+	PromptA string `json:"promptA"`
+	PromptB string `json:"promptB"`
+	// AnswerA and AnswerB are the turn's final assistant messages.
+	AnswerA string `json:"answerA"`
+	AnswerB string `json:"answerB"`
+	// AnswerSimilarity is how alike the answers are, from 0 (no words in
+	// common) to 1 (the same words in the same order), comparing
+	// whitespace-separated words.
+	AnswerSimilarity float64 `json:"answerSimilarity"`
+	// ToolCalls compares the tools the turn called.
+	ToolCalls ToolCallDiff `json:"toolCalls"`
+	// UsageA and UsageB are the model usage of the turn in each run, and
+	// UsageDelta is UsageB minus UsageA.
+	UsageA     copilot.TurnUsage `json:"usageA"`
+	UsageB     copilot.TurnUsage `json:"usageB"`
+	UsageDelta copilot.TurnUsage `json:"usageDelta"`
+}
+
+// ToolCallDiff compares the tool calls of a turn in two runs. Calls are
+// matched in order by tool name, so a call to the same tool with other
+// arguments is reported as changed rather than as removed and added.
+type ToolCallDiff struct {
+	// Removed are the calls made only in run A.
+	Removed []copilot.ToolCallRecord `json:"removed,omitempty"`
+	// Added are the calls made only in run B.
+	Added []copilot.ToolCallRecord `json:"added,omitempty"`
+	// Changed are the matched calls whose arguments or outcome differ.
+	Changed []ToolCallChange `json:"changed,omitempty"`
+	// Unchanged is the number of matched calls that are the same.
+	Unchanged int `json:"unchanged"`
+}
+
+// ToolCallChange is a call to the same tool in two runs with different
+// arguments or outcome.
+type ToolCallChange struct {
+	A copilot.ToolCallRecord `json:"a"`
+	B copilot.ToolCallRecord `json:"b"`
+	// ArgumentsChanged reports whether the arguments differ, and
+	// OutcomeChanged whether one call succeeded and the other failed.
+	ArgumentsChanged bool `json:"argumentsChanged"`
+	OutcomeChanged   bool `json:"outcomeChanged"`
+}
+
+// Drifted reports whether the answers or tool calls of the turn differ.
+func (d *TurnDiff) Drifted() bool {
+	return d.AnswerA != d.AnswerB || len(d.ToolCalls.Removed) > 0 || len(d.ToolCalls.Added) > 0 || len(d.ToolCalls.Changed) > 0
+}
+
+// Drifted returns the turns whose answers or tool calls differ.
+func (d *TranscriptDiff) Drifted() []TurnDiff {
+	var drifted []TurnDiff
+	for _, turn := range d.Turns {
+		if turn.Drifted() {
+			drifted = append(drifted, turn)
+		}
+	}
+	return drifted
+}
+
+// DiffTranscripts compares two runs of the same prompts, such as transcripts
+// exported with [copilot.Session.Export] from sessions with different
+// configurations. Turns are the top-level exchanges started by each user
+// message; sub-agent messages and tool calls count towards the turn that
+// started them.
+//
+// Example:
+//
+//	diff := eval.DiffTranscripts(baseline, candidate)
+//	for _, turn := range diff.Drifted() {
+//	    fmt.Printf("turn %d: similarity %.2f, %d tool calls added, %d removed\n",
+//	        turn.Index, turn.AnswerSimilarity, len(turn.ToolCalls.Added), len(turn.ToolCalls.Removed))
+//	}
+//	fmt.Printf("output tokens: %+d\n", diff.UsageDelta.OutputTokens)
+func DiffTranscripts(a, b *copilot.Transcript) *TranscriptDiff {
+	turnsA, turnsB := splitTurns(a), splitTurns(b)
+	diff := &TranscriptDiff{}
+	for i := range max(len(turnsA), len(turnsB)) {
+		var ta, tb turn
+		if i < len(turnsA) {
+			ta = turnsA[i]
+		}
+		if i < len(turnsB) {
+			tb = turnsB[i]
+		}
+		diff.Turns = append(diff.Turns, TurnDiff{
+			Index:            i,
+			PromptA:          ta.prompt,
+			PromptB:          tb.prompt,
+			AnswerA:          ta.answer,
+			AnswerB:          tb.answer,
+			AnswerSimilarity: similarity(ta.answer, tb.answer),
+			ToolCalls:        diffToolCalls(ta.toolCalls, tb.toolCalls),
+			UsageA:           ta.usage,
+			UsageB:           tb.usage,
+			UsageDelta:       subtractUsage(tb.usage, ta.usage),
+		})
+		addUsage(&diff.UsageA, ta.usage)
+		addUsage(&diff.UsageB, tb.usage)
+	}
+	diff.UsageDelta = subtractUsage(diff.UsageB, diff.UsageA)
+	return diff
+}
+
+// String formats the diff as a plain-text report.
+func (d *TranscriptDiff) String() string {
+	var b strings.Builder
+	for _, turn := range d.Turns {
+		status := "unchanged"
+		if turn.Drifted() {
+			status = fmt.Sprintf("drifted (answer similarity %.2f)", turn.AnswerSimilarity)
+		}
+		fmt.Fprintf(&b, "turn %d: %s\n", turn.Index, status)
+		if turn.PromptA != turn.PromptB {
+			fmt.Fprintf(&b, "  prompts differ: %q vs %q\n", truncate(turn.PromptA), truncate(turn.PromptB))
+		}
+		for _, call := range turn.ToolCalls.Removed {
+			fmt.Fprintf(&b, "  - %s\n", call.ToolName)
+		}
+		for _, call := range turn.ToolCalls.Added {
+			fmt.Fprintf(&b, "  + %s\n", call.ToolName)
+		}
+		for _, change := range turn.ToolCalls.Changed {
+			var what []string
+			if change.ArgumentsChanged {
+				what = append(what, "arguments")
+			}
+			if change.OutcomeChanged {
+				what = append(what, "outcome")
+			}
+			fmt.Fprintf(&b, "  ~ %s (%s)\n", change.A.ToolName, strings.Join(what, ", "))
+		}
+		if delta := turn.UsageDelta; delta != (copilot.TurnUsage{}) {
+			fmt.Fprintf(&b, "  tokens: %+d input, %+d output\n", delta.InputTokens, delta.OutputTokens)
+		}
+	}
+	fmt.Fprintf(&b, "total tokens: %+d input, %+d output, %+d model calls\n",
+		d.UsageDelta.InputTokens, d.UsageDelta.OutputTokens, d.UsageDelta.ModelCalls)
+	return b.String()
+}
+
+func truncate(s string) string {
+	if len(s) > 60 {
+		return s[:57] + "..."
+	}
+	return s
+}
+
+// turn is the part of a transcript compared for one turn.
+type turn struct {
+	prompt    string
+	answer    string
+	toolCalls []copilot.ToolCallRecord
+	usage     copilot.TurnUsage
+}
+
+// splitTurns splits a transcript into turns at each user message. Events
+// before the first user message are ignored.
+func splitTurns(t *copilot.Transcript) []turn {
+	if t == nil {
+		return nil
+	}
+	var turns []turn
+	var events []copilot.SessionEvent
+	finish := func() {
+		if len(turns) > 0 {
+			turns[len(turns)-1].toolCalls = copilot.CollectToolCalls(events)
+		}
+		events = nil
+	}
+	for _, event := range t.Events {
+		if d, ok := event.Data.(*copilot.UserMessageData); ok {
+			finish()
+			turns = append(turns, turn{prompt: d.Content})
+			continue
+		}
+		if len(turns) == 0 {
+			continue
+		}
+		current := &turns[len(turns)-1]
+		events = append(events, event)
+		switch d := event.Data.(type) {
+		case *copilot.AssistantMessageData:
+			if d.ParentToolCallID == nil && d.Content != "" {
+				current.answer = d.Content
+			}
+		case *copilot.AssistantUsageData:
+			current.usage.ModelCalls++
+			current.usage.InputTokens += deref(d.InputTokens)
+			current.usage.OutputTokens += deref(d.OutputTokens)
+			current.usage.CacheReadTokens += deref(d.CacheReadTokens)
+			current.usage.CacheWriteTokens += deref(d.CacheWriteTokens)
+			current.usage.ReasoningTokens += deref(d.ReasoningTokens)
+			if d.Cost != nil {
+				current.usage.Cost += *d.Cost
+			}
+		}
+	}
+	finish()
+	return turns
+}
+
+func deref(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func addUsage(total *copilot.TurnUsage, u copilot.TurnUsage) {
+	*total = copilot.TurnUsage{
+		ModelCalls:       total.ModelCalls + u.ModelCalls,
+		InputTokens:      total.InputTokens + u.InputTokens,
+		OutputTokens:     total.OutputTokens + u.OutputTokens,
+		CacheReadTokens:  total.CacheReadTokens + u.CacheReadTokens,
+		CacheWriteTokens: total.CacheWriteTokens + u.CacheWriteTokens,
+		ReasoningTokens:  total.ReasoningTokens + u.ReasoningTokens,
+		Cost:             total.Cost + u.Cost,
+	}
+}
+
+func subtractUsage(a, b copilot.TurnUsage) copilot.TurnUsage {
+	return copilot.TurnUsage{
+		ModelCalls:       a.ModelCalls - b.ModelCalls,
+		InputTokens:      a.InputTokens - b.InputTokens,
+		OutputTokens:     a.OutputTokens - b.OutputTokens,
+		CacheReadTokens:  a.CacheReadTokens - b.CacheReadTokens,
+		CacheWriteTokens: a.CacheWriteTokens - b.CacheWriteTokens,
+		ReasoningTokens:  a.ReasoningTokens - b.ReasoningTokens,
+		Cost:             a.Cost - b.Cost,
+	}
+}
+
+// similarity returns 2*LCS/(len(a)+len(b)) over the words of a and b.
+func similarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+	common := lcs(len(wordsA), len(wordsB), func(i, j int) bool { return wordsA[i] == wordsB[j] })
+	return 2 * float64(len(common)) / float64(len(wordsA)+len(wordsB))
+}
+
+// lcs returns the index pairs of a longest common subsequence of two
+// sequences of lengths n and m.
+func lcs(n, m int, equal func(i, j int) bool) [][2]int {
+	// lengths[i][j] is the LCS length of the suffixes from i and j.
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i, j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(i, j):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// diffToolCalls matches the calls of a turn by tool name, in order.
+func diffToolCalls(a, b []copilot.ToolCallRecord) ToolCallDiff {
+	var diff ToolCallDiff
+	pairs := lcs(len(a), len(b), func(i, j int) bool {
+		return a[i].ToolName == b[j].ToolName && a[i].MCPServerName == b[j].MCPServerName
+	})
+	i, j := 0, 0
+	for _, pair := range append(pairs, [2]int{len(a), len(b)}) {
+		diff.Removed = append(diff.Removed, a[i:pair[0]]...)
+		diff.Added = append(diff.Added, b[j:pair[1]]...)
+		if pair[0] == len(a) && pair[1] == len(b) {
+			break
+		}
+		callA, callB := a[pair[0]], b[pair[1]]
+		change := ToolCallChange{
+			A:                callA,
+			B:                callB,
+			ArgumentsChanged: !sameJSON(callA.Arguments, callB.Arguments),
+			OutcomeChanged:   callA.Success != callB.Success,
+		}
+		if change.ArgumentsChanged || change.OutcomeChanged {
+			diff.Changed = append(diff.Changed, change)
+		} else {
+			diff.Unchanged++
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+	return diff
+}
+
+// sameJSON reports whether a and b have the same JSON encoding, so that
+// arguments decoded into different Go types compare equal.
+func sameJSON(a, b any) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	var va, vb any
+	json.Unmarshal(dataA, &va)
+	json.Unmarshal(dataB, &vb)
+	return reflect.DeepEqual(va, vb)
+}
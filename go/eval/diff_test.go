@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// run builds a transcript from turns of prompt, tool calls and answer.
+func run(turns ...[]copilot.SessionEventData) *copilot.Transcript {
+	var events []copilot.SessionEvent
+	for _, turn := range turns {
+		for _, data := range turn {
+			events = append(events, copilot.SessionEvent{Data: data})
+		}
+	}
+	t := copilot.NewTranscript("s1", events)
+	return &t
+}
+
+func toolCall(id, name string, args map[string]any, success bool) []copilot.SessionEventData {
+	return []copilot.SessionEventData{
+		&copilot.ToolExecutionStartData{ToolCallID: id, ToolName: name, Arguments: args},
+		&copilot.ToolExecutionCompleteData{ToolCallID: id, Success: success},
+	}
+}
+
+func userTurn(prompt, answer string, inputTokens, outputTokens int64, calls ...[]copilot.SessionEventData) []copilot.SessionEventData {
+	events := []copilot.SessionEventData{&copilot.UserMessageData{Content: prompt}}
+	for _, call := range calls {
+		events = append(events, call...)
+	}
+	return append(events,
+		&copilot.AssistantMessageData{Content: "sub-agent notes", ParentToolCallID: ptr("task-1")},
+		&copilot.AssistantMessageData{Content: answer},
+		&copilot.AssistantUsageData{InputTokens: ptr(inputTokens), OutputTokens: ptr(outputTokens)},
+	)
+}
+
+func TestDiffTranscripts(t *testing.T) {
+	a := run(
+		userTurn("list files", "There are three files.", 100, 10,
+			toolCall("a1", "glob", map[string]any{"pattern": "*"}, true)),
+		userTurn("fix the test", "Fixed the failing assertion in main_test.go.", 200, 40,
+			toolCall("a2", "view", map[string]any{"path": "main_test.go"}, true),
+			toolCall("a3", "edit", map[string]any{"path": "main_test.go"}, true),
+			toolCall("a4", "bash", map[string]any{"command": "go test"}, true)),
+	)
+	b := run(
+		userTurn("list files", "There are three files.", 100, 10,
+			toolCall("b1", "glob", map[string]any{"pattern": "*"}, true)),
+		userTurn("fix the test", "Fixed the assertion in main_test.go.", 250, 30,
+			toolCall("b2", "grep", map[string]any{"pattern": "assert"}, true),
+			toolCall("b3", "edit", map[string]any{"path": "main_test.go"}, false),
+			toolCall("b4", "bash", map[string]any{"command": "go test ./..."}, true)),
+		userTurn("commit", "Committed.", 50, 5),
+	)
+
+	diff := DiffTranscripts(a, b)
+	if len(diff.Turns) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(diff.Turns))
+	}
+
+	t.Run("reports unchanged turns", func(t *testing.T) {
+		first := diff.Turns[0]
+		if first.Drifted() || first.AnswerSimilarity != 1 || first.ToolCalls.Unchanged != 1 || first.UsageDelta != (copilot.TurnUsage{}) {
+			t.Errorf("unexpected first turn %+v", first)
+		}
+	})
+
+	t.Run("reports answer drift, tool call differences and token deltas", func(t *testing.T) {
+		second := diff.Turns[1]
+		if !second.Drifted() || second.AnswerA != "Fixed the failing assertion in main_test.go." {
+			t.Errorf("unexpected second turn %+v", second)
+		}
+		// 5 of the 6 and 5 words are in common.
+		if want := 10.0 / 11; second.AnswerSimilarity != want {
+			t.Errorf("expected similarity %v, got %v", want, second.AnswerSimilarity)
+		}
+		calls := second.ToolCalls
+		if len(calls.Removed) != 1 || calls.Removed[0].ToolName != "view" || len(calls.Added) != 1 || calls.Added[0].ToolName != "grep" {
+			t.Errorf("unexpected removed and added calls %+v", calls)
+		}
+		if len(calls.Changed) != 2 || !calls.Changed[0].OutcomeChanged || calls.Changed[0].ArgumentsChanged ||
+			calls.Changed[1].OutcomeChanged || !calls.Changed[1].ArgumentsChanged {
+			t.Errorf("unexpected changed calls %+v", calls.Changed)
+		}
+		if second.UsageDelta.InputTokens != 50 || second.UsageDelta.OutputTokens != -10 {
+			t.Errorf("unexpected usage delta %+v", second.UsageDelta)
+		}
+	})
+
+	t.Run("compares extra turns against an empty turn", func(t *testing.T) {
+		third := diff.Turns[2]
+		if third.PromptA != "" || third.PromptB != "commit" || !third.Drifted() || third.AnswerSimilarity != 0 {
+			t.Errorf("unexpected third turn %+v", third)
+		}
+		if diff.UsageDelta.InputTokens != 100 || diff.UsageDelta.ModelCalls != 1 {
+			t.Errorf("unexpected total usage delta %+v", diff.UsageDelta)
+		}
+		if len(diff.Drifted()) != 2 {
+			t.Errorf("expected 2 drifted turns, got %d", len(diff.Drifted()))
+		}
+	})
+
+	t.Run("formats a report", func(t *testing.T) {
+		report := diff.String()
+		for _, want := range []string{
+			"turn 0: unchanged\n",
+			"turn 1: drifted (answer similarity 0.91)\n  - view\n  + grep\n  ~ edit (outcome)\n  ~ bash (arguments)\n  tokens: +50 input, -10 output\n",
+			"total tokens: +100 input, -5 output, +1 model calls\n",
+		} {
+			if !strings.Contains(report, want) {
+				t.Errorf("expected the report to contain %q, got:\n%s", want, report)
+			}
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// EventBroker is a minimal message-broker abstraction for [EventPublisher].
+// Implement it on top of Kafka, NATS, or similar.
+type EventBroker interface {
+	// Publish sends value on topic. key is the session ID; brokers that
+	// partition by key (such as Kafka) should use it so each session's events
+	// stay in order on one partition.
+	Publish(ctx context.Context, topic string, key string, value []byte) error
+}
+
+// EventSerializer encodes a session event for publishing.
+type EventSerializer func(sessionID string, event SessionEvent) ([]byte, error)
+
+// ErrEventDropped is reported to [EventPublisherOptions.OnError] when an event
+// is discarded because the publish buffer is full.
+var ErrEventDropped = errors.New("event publisher buffer full; event dropped")
+
+// publishedEvent is the JSON envelope written by [JSONEventSerializer].
+type publishedEvent struct {
+	SessionID string       `json:"sessionId"`
+	Event     SessionEvent `json:"event"`
+}
+
+// JSONEventSerializer encodes events as {"sessionId": "...", "event": {...}},
+// where event has the same JSON shape the runtime emits.
+func JSONEventSerializer(sessionID string, event SessionEvent) ([]byte, error) {
+	return json.Marshal(publishedEvent{SessionID: sessionID, Event: event})
+}
+
+// EventPublisherOptions configures an [EventPublisher].
+type EventPublisherOptions struct {
+	// Topic is the topic events are published to. Defaults to
+	// "copilot.session.events". Ignored when TopicFunc is set.
+	Topic string
+	// TopicFunc, when set, picks the topic per event, e.g. to route each
+	// session to its own NATS subject.
+	TopicFunc func(sessionID string, event SessionEvent) string
+	// Serializer encodes events. Defaults to [JSONEventSerializer]; the
+	// copilotpb package's EventSerializer encodes them as protobuf.
+	Serializer EventSerializer
+	// EventTypes limits publishing to the listed event types. When empty,
+	// every event is published.
+	EventTypes []SessionEventType
+	// BufferSize is the number of events queued for publishing before new
+	// events are dropped. Defaults to 1024.
+	BufferSize int
+	// OnError is called (from the publishing goroutine, or from the session's
+	// event dispatch for [ErrEventDropped]) when an event cannot be published.
+	OnError func(sessionID string, event SessionEvent, err error)
+}
+
+// EventPublisher mirrors session events to a message broker so downstream
+// consumers can follow agent activity without holding an SDK connection.
+//
+// Events are published in order from a single background goroutine, keyed by
+// session ID. Publishing never blocks a session: when the buffer is full the
+// event is dropped and reported to OnError.
+//
+// Example:
+//
+//	publisher := copilot.NewEventPublisher(kafkaBroker, copilot.EventPublisherOptions{
+//	    Topic:      "agent-activity",
+//	    EventTypes: []copilot.SessionEventType{copilot.SessionEventTypeAssistantMessage},
+//	})
+//	defer publisher.Close()
+//
+//	session, err := client.CreateSession(ctx, config)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	publisher.Attach(session)
+type EventPublisher struct {
+	broker EventBroker
+	opts   EventPublisherOptions
+	queue  chan queuedEvent
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type queuedEvent struct {
+	sessionID string
+	event     SessionEvent
+}
+
+// NewEventPublisher starts a publisher for broker.
+func NewEventPublisher(broker EventBroker, opts EventPublisherOptions) *EventPublisher {
+	if opts.Topic == "" {
+		opts.Topic = "copilot.session.events"
+	}
+	if opts.Serializer == nil {
+		opts.Serializer = JSONEventSerializer
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+	p := &EventPublisher{
+		broker: broker,
+		opts:   opts,
+		queue:  make(chan queuedEvent, opts.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Attach mirrors the session's events to the broker. Returns a function that
+// stops mirroring.
+func (p *EventPublisher) Attach(session *Session) func() {
+	sessionID := session.SessionID
+	return session.On(func(event SessionEvent) {
+		p.Publish(sessionID, event)
+	})
+}
+
+// Publish queues a single event for publishing. It is called by sessions
+// attached with [EventPublisher.Attach] and can also be used to forward
+// events received elsewhere.
+func (p *EventPublisher) Publish(sessionID string, event SessionEvent) {
+	if len(p.opts.EventTypes) > 0 && !slices.Contains(p.opts.EventTypes, event.Type()) {
+		return
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.queue <- queuedEvent{sessionID: sessionID, event: event}:
+	default:
+		p.reportError(sessionID, event, ErrEventDropped)
+	}
+}
+
+// Close stops accepting events and waits until queued events are published.
+func (p *EventPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	<-p.done
+	return nil
+}
+
+func (p *EventPublisher) run() {
+	defer close(p.done)
+	for item := range p.queue {
+		value, err := p.opts.Serializer(item.sessionID, item.event)
+		if err != nil {
+			p.reportError(item.sessionID, item.event, err)
+			continue
+		}
+		topic := p.opts.Topic
+		if p.opts.TopicFunc != nil {
+			topic = p.opts.TopicFunc(item.sessionID, item.event)
+		}
+		if err := p.broker.Publish(context.Background(), topic, item.sessionID, value); err != nil {
+			p.reportError(item.sessionID, item.event, err)
+		}
+	}
+}
+
+func (p *EventPublisher) reportError(sessionID string, event SessionEvent, err error) {
+	if p.opts.OnError == nil {
+		return
+	}
+	defer func() { recover() }() // Ignore handler panics
+	p.opts.OnError(sessionID, event, err)
+}
@@ -0,0 +1,129 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type publishedMessage struct {
+	topic, key string
+	value      []byte
+}
+
+type recordingBroker struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+	block    chan struct{}
+}
+
+func (b *recordingBroker) Publish(_ context.Context, topic, key string, value []byte) error {
+	if b.block != nil {
+		<-b.block
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, publishedMessage{topic, key, value})
+	return nil
+}
+
+func TestEventPublisher(t *testing.T) {
+	t.Run("mirrors attached session events keyed by session ID", func(t *testing.T) {
+		broker := &recordingBroker{}
+		publisher := NewEventPublisher(broker, EventPublisherOptions{
+			EventTypes: []SessionEventType{SessionEventTypeAssistantMessage},
+		})
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.SessionID = "s1"
+
+		delivered := make(chan struct{}, 2)
+		publisher.Attach(session)
+		session.On(func(SessionEvent) { delivered <- struct{}{} })
+
+		session.dispatchEvent(SessionEvent{ID: "e1", Data: &AssistantMessageData{Content: "hello"}})
+		session.dispatchEvent(SessionEvent{ID: "e2", Data: &SessionIdleData{}})
+		for range 2 {
+			select {
+			case <-delivered:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for events")
+			}
+		}
+		publisher.Close()
+
+		if len(broker.messages) != 1 {
+			t.Fatalf("expected only the assistant message to be published, got %d messages", len(broker.messages))
+		}
+		msg := broker.messages[0]
+		if msg.topic != "copilot.session.events" || msg.key != "s1" {
+			t.Errorf("unexpected topic/key: %q %q", msg.topic, msg.key)
+		}
+		var envelope struct {
+			SessionID string          `json:"sessionId"`
+			Event     json.RawMessage `json:"event"`
+		}
+		if err := json.Unmarshal(msg.value, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		var event SessionEvent
+		if err := json.Unmarshal(envelope.Event, &event); err != nil {
+			t.Fatal(err)
+		}
+		data, ok := event.Data.(*AssistantMessageData)
+		if envelope.SessionID != "s1" || event.ID != "e1" || !ok || data.Content != "hello" {
+			t.Errorf("unexpected payload: %s", msg.value)
+		}
+	})
+
+	t.Run("uses TopicFunc and a custom serializer", func(t *testing.T) {
+		broker := &recordingBroker{}
+		publisher := NewEventPublisher(broker, EventPublisherOptions{
+			TopicFunc:  func(sessionID string, _ SessionEvent) string { return "sessions." + sessionID },
+			Serializer: func(_ string, event SessionEvent) ([]byte, error) { return []byte(event.Type()), nil },
+		})
+		publisher.Publish("s2", newTestEvent())
+		publisher.Close()
+		if len(broker.messages) != 1 || broker.messages[0].topic != "sessions.s2" || string(broker.messages[0].value) != "session.idle" {
+			t.Errorf("unexpected messages: %+v", broker.messages)
+		}
+	})
+
+	t.Run("drops events when the buffer is full", func(t *testing.T) {
+		broker := &recordingBroker{block: make(chan struct{})}
+		var dropped []error
+		var mu sync.Mutex
+		publisher := NewEventPublisher(broker, EventPublisherOptions{
+			BufferSize: 1,
+			OnError: func(_ string, _ SessionEvent, err error) {
+				mu.Lock()
+				dropped = append(dropped, err)
+				mu.Unlock()
+			},
+		})
+		// The first event is taken by the publishing goroutine (blocked in the
+		// broker), the second fills the buffer, and later ones are dropped.
+		for range 5 {
+			publisher.Publish("s", newTestEvent())
+		}
+		close(broker.block)
+		publisher.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(dropped) == 0 {
+			t.Fatal("expected dropped events to be reported")
+		}
+		for _, err := range dropped {
+			if !errors.Is(err, ErrEventDropped) {
+				t.Errorf("expected ErrEventDropped, got %v", err)
+			}
+		}
+		if len(broker.messages)+len(dropped) != 5 {
+			t.Errorf("expected every event to be published or reported, got %d published and %d dropped", len(broker.messages), len(dropped))
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package copilot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type (
+	FileEditedData    = rpc.FileEditedData
+	FileEditOperation = rpc.FileEditOperation
+	DiffHunk          = rpc.DiffHunk
+	DiffLine          = rpc.DiffLine
+	DiffLineKind      = rpc.DiffLineKind
+)
+
+const (
+	SessionEventTypeFileEdited = rpc.SessionEventTypeFileEdited
+
+	FileEditOperationCreated  = rpc.FileEditOperationCreated
+	FileEditOperationModified = rpc.FileEditOperationModified
+	FileEditOperationDeleted  = rpc.FileEditOperationDeleted
+
+	DiffLineKindContext = rpc.DiffLineKindContext
+	DiffLineKindAdded   = rpc.DiffLineKindAdded
+	DiffLineKindDeleted = rpc.DiffLineKindDeleted
+)
+
+// fileEditTools are the built-in tools whose results describe file edits.
+var fileEditTools = map[string]bool{
+	"edit":               true,
+	"create":             true,
+	"str_replace_editor": true,
+	"apply_patch":        true,
+}
+
+// pendingFileEdit is an edit tool call that has started but not completed.
+type pendingFileEdit struct {
+	toolName string
+	path     string
+	// permissionDiff is the diff from the write permission request, used
+	// when the tool result carries no diff.
+	permissionDiff string
+}
+
+// fileEditTracker pairs edit tool starts with their completions. Built-in
+// edit tools report their changes as unified diffs, either in the detailed
+// result of tool.execution_complete or in the write permission request that
+// preceded the edit. The tracker parses those diffs into a synthesized
+// file.edited event after each successful edit, so consumers can render
+// "files changed" views without re-reading and diffing files. It is only
+// accessed from the session's event consumer goroutine and needs no locking.
+type fileEditTracker struct {
+	pending map[string]*pendingFileEdit
+}
+
+// observe records event and returns the file.edited events it produces.
+func (t *fileEditTracker) observe(event SessionEvent) []SessionEvent {
+	switch d := event.Data.(type) {
+	case *ToolExecutionStartData:
+		if !fileEditTools[d.ToolName] || d.MCPServerName != nil {
+			return nil
+		}
+		if t.pending == nil {
+			t.pending = make(map[string]*pendingFileEdit)
+		}
+		edit := &pendingFileEdit{toolName: d.ToolName}
+		if args, ok := d.Arguments.(map[string]any); ok {
+			edit.path, _ = args["path"].(string)
+		}
+		t.pending[d.ToolCallID] = edit
+	case *PermissionRequestedData:
+		write, ok := d.PermissionRequest.(*PermissionRequestWrite)
+		if !ok || write.ToolCallID == nil {
+			return nil
+		}
+		if edit, ok := t.pending[*write.ToolCallID]; ok {
+			edit.permissionDiff = write.Diff
+			if edit.path == "" {
+				edit.path = write.FileName
+			}
+		}
+	case *ToolExecutionCompleteData:
+		edit, ok := t.pending[d.ToolCallID]
+		if !ok {
+			return nil
+		}
+		delete(t.pending, d.ToolCallID)
+		if !d.Success {
+			return nil
+		}
+		var files []FileEditedData
+		if d.Result != nil && d.Result.DetailedContent != nil {
+			files = parseUnifiedDiff(*d.Result.DetailedContent)
+		}
+		if len(files) == 0 {
+			files = parseUnifiedDiff(edit.permissionDiff)
+		}
+		events := make([]SessionEvent, 0, len(files))
+		for i := range files {
+			file := &files[i]
+			file.ToolCallID = d.ToolCallID
+			file.ToolName = edit.toolName
+			if file.Path == "" {
+				file.Path = edit.path
+			}
+			ephemeral := true
+			parentID := event.ID
+			events = append(events, SessionEvent{
+				AgentID:   event.AgentID,
+				Data:      file,
+				Ephemeral: &ephemeral,
+				ID:        uuid.NewString(),
+				ParentID:  &parentID,
+				Timestamp: event.Timestamp,
+			})
+		}
+		return events
+	}
+	return nil
+}
+
+// parseUnifiedDiff parses a unified diff into one entry per file. Files are
+// delimited by ---/+++ headers; a headerless diff yields a single entry with
+// an empty Path. Text that contains no hunks yields nil.
+func parseUnifiedDiff(diff string) []FileEditedData {
+	if diff == "" {
+		return nil
+	}
+	var files []FileEditedData
+	var file *FileEditedData
+	var hunk *DiffHunk
+	var oldPath string
+	oldRemaining, newRemaining := 0, 0
+	oldLine, newLine := 0, 0
+
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if hunk != nil && (oldRemaining > 0 || newRemaining > 0) {
+			if line == "" {
+				// Some producers drop the leading space of empty context lines.
+				line = " "
+			}
+			switch line[0] {
+			case ' ':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineKindContext, Content: line[1:], OldLine: oldLine, NewLine: newLine})
+				oldLine++
+				newLine++
+				oldRemaining--
+				newRemaining--
+				continue
+			case '-':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineKindDeleted, Content: line[1:], OldLine: oldLine})
+				file.Deletions++
+				oldLine++
+				oldRemaining--
+				continue
+			case '+':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineKindAdded, Content: line[1:], NewLine: newLine})
+				file.Additions++
+				newLine++
+				newRemaining--
+				continue
+			case '\\':
+				continue // "\ No newline at end of file"
+			}
+		}
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldPath = diffPath(line[4:])
+			file, hunk = nil, nil
+		case strings.HasPrefix(line, "+++ "):
+			newPath := diffPath(line[4:])
+			files = append(files, FileEditedData{Path: newPath, Operation: FileEditOperationModified})
+			file, hunk = &files[len(files)-1], nil
+			switch {
+			case oldPath == "/dev/null":
+				file.Operation = FileEditOperationCreated
+			case newPath == "/dev/null":
+				file.Operation = FileEditOperationDeleted
+				file.Path = oldPath
+			}
+			oldPath = ""
+		case strings.HasPrefix(line, "@@ "):
+			parsed, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			if file == nil {
+				files = append(files, FileEditedData{Operation: FileEditOperationModified})
+				file = &files[len(files)-1]
+			}
+			file.Hunks = append(file.Hunks, parsed)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldLine, newLine = parsed.OldStart, parsed.NewStart
+			oldRemaining, newRemaining = parsed.OldLines, parsed.NewLines
+		}
+	}
+
+	result := files[:0]
+	for _, f := range files {
+		if len(f.Hunks) > 0 {
+			result = append(result, f)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// parseHunkHeader parses "@@ -oldStart,oldLines +newStart,newLines @@ header".
+func parseHunkHeader(line string) (DiffHunk, bool) {
+	rest := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return DiffHunk{}, false
+	}
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return DiffHunk{}, false
+	}
+	oldStart, oldLines, ok1 := parseHunkRange(ranges[0][1:])
+	newStart, newLines, ok2 := parseHunkRange(ranges[1][1:])
+	if !ok1 || !ok2 {
+		return DiffHunk{}, false
+	}
+	return DiffHunk{
+		OldStart: oldStart,
+		OldLines: oldLines,
+		NewStart: newStart,
+		NewLines: newLines,
+		Header:   strings.TrimSpace(rest[end+3:]),
+	}, true
+}
+
+// parseHunkRange parses "start,count" or "start" (count defaults to 1).
+func parseHunkRange(s string) (start, count int, ok bool) {
+	startText, countText, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !hasCount {
+		return start, 1, true
+	}
+	count, err = strconv.Atoi(countText)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, count, true
+}
+
+// diffPath extracts the path from a ---/+++ header, dropping timestamps and
+// git's a/ and b/ prefixes.
+func diffPath(header string) string {
+	path, _, _ := strings.Cut(header, "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
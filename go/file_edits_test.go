@@ -0,0 +1,129 @@
+package copilot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	t.Run("parses git diffs with line numbers", func(t *testing.T) {
+		files := parseUnifiedDiff(`diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,4 @@ func main() {
+ 	a := 1
+--- removed decrement
++++ added increment
+ 	b := 2
+ 
+`)
+		if len(files) != 1 {
+			t.Fatalf("expected 1 file, got %d", len(files))
+		}
+		file := files[0]
+		if file.Path != "main.go" || file.Operation != FileEditOperationModified || file.Additions != 1 || file.Deletions != 1 {
+			t.Errorf("unexpected file: %+v", file)
+		}
+		hunk := file.Hunks[0]
+		if hunk.OldStart != 10 || hunk.NewLines != 4 || hunk.Header != "func main() {" || len(hunk.Lines) != 5 {
+			t.Fatalf("unexpected hunk: %+v", hunk)
+		}
+		want := []DiffLine{
+			{Kind: DiffLineKindContext, Content: "\ta := 1", OldLine: 10, NewLine: 10},
+			{Kind: DiffLineKindDeleted, Content: "-- removed decrement", OldLine: 11},
+			{Kind: DiffLineKindAdded, Content: "++ added increment", NewLine: 11},
+			{Kind: DiffLineKindContext, Content: "\tb := 2", OldLine: 12, NewLine: 12},
+			{Kind: DiffLineKindContext, Content: "", OldLine: 13, NewLine: 13},
+		}
+		for i, line := range want {
+			if hunk.Lines[i] != line {
+				t.Errorf("line %d: expected %+v, got %+v", i, line, hunk.Lines[i])
+			}
+		}
+	})
+
+	t.Run("detects created and deleted files", func(t *testing.T) {
+		files := parseUnifiedDiff(`--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+\ No newline at end of file
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`)
+		if len(files) != 2 {
+			t.Fatalf("expected 2 files, got %d", len(files))
+		}
+		if files[0].Path != "new.txt" || files[0].Operation != FileEditOperationCreated || files[0].Additions != 2 {
+			t.Errorf("unexpected created file: %+v", files[0])
+		}
+		if files[1].Path != "old.txt" || files[1].Operation != FileEditOperationDeleted || files[1].Deletions != 1 {
+			t.Errorf("unexpected deleted file: %+v", files[1])
+		}
+	})
+
+	t.Run("returns nil for text without hunks", func(t *testing.T) {
+		if files := parseUnifiedDiff("File created successfully."); files != nil {
+			t.Errorf("expected nil, got %+v", files)
+		}
+	})
+}
+
+func TestSession_FileEditedEvents(t *testing.T) {
+	diff := "@@ -1 +1 @@\n-old\n+new\n"
+	complete := func(toolCallID string, success bool, detailed *string) SessionEvent {
+		return SessionEvent{ID: "complete-" + toolCallID, Timestamp: time.Now(), Data: &ToolExecutionCompleteData{
+			ToolCallID: toolCallID,
+			Success:    success,
+			Result:     &ToolExecutionCompleteResult{DetailedContent: detailed},
+		}}
+	}
+
+	session, cleanup := newTestSession()
+	defer cleanup()
+	received := make(chan SessionEvent, 16)
+	session.On(func(event SessionEvent) { received <- event })
+
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c1", ToolName: "edit", Arguments: map[string]any{"path": "/repo/a.txt"}}})
+	session.dispatchEvent(complete("c1", true, &diff))
+	// The diff comes from the permission request when the result has none.
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c2", ToolName: "create"}})
+	session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{PermissionRequest: &PermissionRequestWrite{
+		ToolCallID: ptr("c2"), FileName: "/repo/b.txt", Diff: "--- /dev/null\n+++ /repo/b.txt\n@@ -0,0 +1 @@\n+hi\n",
+	}}})
+	session.dispatchEvent(complete("c2", true, ptr("Created file /repo/b.txt")))
+	// Failed edits and non-edit tools produce no file.edited events.
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c3", ToolName: "edit"}})
+	session.dispatchEvent(complete("c3", false, &diff))
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c4", ToolName: "bash"}})
+	session.dispatchEvent(complete("c4", true, &diff))
+	session.dispatchEvent(newTestEvent())
+
+	var edits []SessionEvent
+	for event := range received {
+		if event.Type() == SessionEventTypeSessionIdle {
+			break
+		}
+		if event.Type() == SessionEventTypeFileEdited {
+			edits = append(edits, event)
+		}
+	}
+	if len(edits) != 2 {
+		t.Fatalf("expected 2 file.edited events, got %d", len(edits))
+	}
+	first := edits[0].Data.(*FileEditedData)
+	if first.Path != "/repo/a.txt" || first.ToolCallID != "c1" || first.ToolName != "edit" || first.Additions != 1 || first.Deletions != 1 {
+		t.Errorf("unexpected first edit: %+v", first)
+	}
+	if edits[0].ParentID == nil || *edits[0].ParentID != "complete-c1" || edits[0].Ephemeral == nil || !*edits[0].Ephemeral {
+		t.Errorf("unexpected envelope: %+v", edits[0])
+	}
+	second := edits[1].Data.(*FileEditedData)
+	if second.Path != "/repo/b.txt" || second.Operation != FileEditOperationCreated {
+		t.Errorf("unexpected second edit: %+v", second)
+	}
+}
@@ -0,0 +1,93 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// Fork creates a new session that shares this session's history up to the
+// current point and returns it ready to use. The two sessions evolve
+// independently afterwards, so alternative follow-up prompts can be explored
+// side by side without re-sending the context.
+//
+// config configures the new session the same way as for
+// [Client.CreateSession]; handlers, tools and Metadata are not inherited and
+// must be supplied again. SessionID and Cloud cannot be set. Fork an idle
+// session (for example after [Session.SendAndWait] returns) so the fork does
+// not capture a half-finished turn.
+//
+// Example:
+//
+//	branch, err := session.Fork(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	a, _ := session.SendPromptAndWait(ctx, "Make it shorter")
+//	b, _ := branch.SendPromptAndWait(ctx, "Make it funnier")
+func (s *Session) Fork(ctx context.Context, config *SessionConfig) (*Session, error) {
+	if s.owner == nil {
+		return nil, errors.New("session is not attached to a client")
+	}
+	if config == nil {
+		config = &SessionConfig{}
+	}
+	if config.SessionID != "" || config.Cloud != nil {
+		return nil, errors.New("SessionID and Cloud cannot be set when forking a session")
+	}
+	if err := validateSessionMetadata(config.Metadata); err != nil {
+		return nil, err
+	}
+
+	forkedID, err := s.forkHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.Metadata != nil {
+		if err := s.owner.saveSessionMetadata(ctx, forkedID, config.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to save metadata for forked session %s: %w", forkedID, err)
+		}
+	}
+
+	forked, err := s.owner.ResumeSessionWithOptions(ctx, forkedID, resumeConfigFor(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach forked session %s: %w", forkedID, err)
+	}
+	return forked, nil
+}
+
+// forkHistory copies the session's persisted history into a new session,
+// without attaching it, and returns the new session's ID.
+func (s *Session) forkHistory(ctx context.Context) (string, error) {
+	result, err := s.conn.Load().client.Request(ctx, "sessions.fork", rpc.SessionsForkRequest{SessionID: s.SessionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to fork session: %w", err)
+	}
+	var response rpc.SessionsForkResult
+	if err := json.Unmarshal(result, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal fork response: %w", err)
+	}
+	return response.SessionID, nil
+}
+
+// resumeConfigFor copies the fields SessionConfig and ResumeSessionConfig
+// share, so a create-style config can be used to attach an existing session.
+func resumeConfigFor(config *SessionConfig) *ResumeSessionConfig {
+	resume := &ResumeSessionConfig{}
+	src := reflect.ValueOf(config).Elem()
+	dst := reflect.ValueOf(resume).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Type().Field(i)
+		target := dst.FieldByName(field.Name)
+		if target.IsValid() && target.Type() == field.Type {
+			target.Set(src.Field(i))
+		}
+	}
+	return resume
+}
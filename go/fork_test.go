@@ -0,0 +1,62 @@
+package copilot
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_Fork(t *testing.T) {
+	var mu sync.Mutex
+	var forkParams, resumeParams map[string]any
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var p map[string]any
+			json.Unmarshal(params, &p)
+			result, _ := json.Marshal(map[string]any{"sessionId": p["sessionId"]})
+			return result, nil
+		},
+		"sessions.fork": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			mu.Lock()
+			json.Unmarshal(params, &forkParams)
+			mu.Unlock()
+			return []byte(`{"sessionId":"forked-1"}`), nil
+		},
+		"session.resume": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			mu.Lock()
+			json.Unmarshal(params, &resumeParams)
+			mu.Unlock()
+			return []byte(`{"sessionId":"forked-1"}`), nil
+		},
+	})
+
+	session, err := client.CreateSession(t.Context(), &SessionConfig{SessionID: "parent-1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	forked, err := session.Fork(t.Context(), &SessionConfig{Model: "gpt-5", Tools: []Tool{{Name: "lookup"}}})
+	if err != nil {
+		t.Fatalf("Fork failed: %v", err)
+	}
+	if forked.SessionID != "forked-1" {
+		t.Errorf("expected the forked session ID, got %q", forked.SessionID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if forkParams["sessionId"] != "parent-1" {
+		t.Errorf("expected to fork parent-1, got %v", forkParams)
+	}
+	if resumeParams["sessionId"] != "forked-1" || resumeParams["model"] != "gpt-5" {
+		t.Errorf("expected the fork to be resumed with the config, got %v", resumeParams)
+	}
+	if tools, _ := resumeParams["tools"].([]any); len(tools) != 1 {
+		t.Errorf("expected tools to be passed when resuming the fork, got %v", resumeParams["tools"])
+	}
+
+	if _, err := session.Fork(t.Context(), &SessionConfig{SessionID: "custom"}); err == nil {
+		t.Error("expected an error when SessionID is set")
+	}
+}
@@ -0,0 +1,134 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerationConfig configures how the model samples its responses.
+type GenerationConfig struct {
+	// Seed requests deterministic sampling: requests with the same seed,
+	// model and conversation then return the same response, as far as the
+	// provider can guarantee. The runtime has no seed option, so the SDK
+	// adds the seed to the model requests of a BYOK provider of Type
+	// "openai" or "azure" using the "completions" wire API, through a local
+	// proxy. Sessions with other providers, or none, are rejected. The seed
+	// is recorded in the session's [Transcript], so an evaluation or a
+	// debugging session can be run again with it.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// validate returns an error if the session's provider cannot be given the
+// seed.
+func (g *GenerationConfig) validate(provider *ProviderConfig) error {
+	if g == nil || g.Seed == nil {
+		return nil
+	}
+	if provider == nil {
+		return errors.New("Generation.Seed requires a Provider of Type \"openai\" or \"azure\"; Copilot-hosted models do not accept a seed")
+	}
+	if provider.Type != "" && provider.Type != "openai" && provider.Type != "azure" {
+		return fmt.Errorf("Generation.Seed is not supported by providers of Type %q; use \"openai\" or \"azure\"", provider.Type)
+	}
+	if provider.WireAPI != "" && provider.WireAPI != "completions" {
+		return fmt.Errorf("Generation.Seed is not supported with WireAPI %q; use \"completions\"", provider.WireAPI)
+	}
+	if provider.Transport != "" && provider.Transport != "http" {
+		return fmt.Errorf("Generation.Seed is not supported with Transport %q", provider.Transport)
+	}
+	return nil
+}
+
+// seedProxy forwards the runtime's model requests for one provider, adding
+// the session's seed to chat completion requests. It adds no credentials:
+// the runtime's requests carry the provider's own.
+type seedProxy struct {
+	seed   int64
+	server *http.Server
+}
+
+// startSeedProxy starts the proxy for provider if generation has a seed,
+// and returns it with the provider config to send to the runtime in its
+// place. Otherwise it returns nil and provider unchanged. The proxy listens
+// on the loopback interface, so a seed is refused for a runtime reached
+// over the network.
+func (c *Client) startSeedProxy(generation *GenerationConfig, provider *ProviderConfig) (*seedProxy, *ProviderConfig, error) {
+	if generation == nil || generation.Seed == nil || provider == nil {
+		return nil, provider, nil
+	}
+	if c.isExternalServer && c.socketPath == "" {
+		return nil, nil, errors.New("Generation.Seed is not supported with a URIConnection: the runtime cannot reach the SDK's seed proxy")
+	}
+	target, err := url.Parse(provider.BaseURL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, nil, fmt.Errorf("invalid provider BaseURL %q", provider.BaseURL)
+	}
+	client, err := c.httpClient(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start seed proxy: %w", err)
+	}
+	p := &seedProxy{seed: *generation.Seed}
+	p.server = &http.Server{
+		Handler: &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(target)
+				p.addSeed(r.Out)
+			},
+			Transport: transport,
+		},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go p.server.Serve(listener)
+
+	wire := *provider
+	wire.BaseURL = "http://" + listener.Addr().String()
+	return p, &wire, nil
+}
+
+// addSeed sets the seed in the body of a chat completion request. Other
+// requests, and bodies that are not a JSON object, are sent as they are.
+func (p *seedProxy) addSeed(r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") || r.Body == nil {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err == nil {
+		var request map[string]json.RawMessage
+		if json.Unmarshal(body, &request) == nil && request != nil {
+			request["seed"] = json.RawMessage(strconv.FormatInt(p.seed, 10))
+			if seeded, err := json.Marshal(request); err == nil {
+				body = seeded
+			}
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
+
+// close stops the proxy.
+func (p *seedProxy) close() {
+	if p != nil {
+		p.server.Close()
+	}
+}
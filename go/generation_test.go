@@ -0,0 +1,132 @@
+package copilot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestGenerationSeed(t *testing.T) {
+	t.Run("is rejected for providers that cannot be given it", func(t *testing.T) {
+		generation := &GenerationConfig{Seed: Int64(42)}
+		for name, provider := range map[string]*ProviderConfig{
+			"Copilot-hosted":     nil,
+			"anthropic":          {Type: "anthropic", BaseURL: "https://api.anthropic.com"},
+			"bedrock":            {Type: "bedrock"},
+			"responses wire API": {Type: "openai", WireAPI: "responses", BaseURL: "https://api.openai.com/v1"},
+			"websockets":         {Type: "openai", Transport: "websockets", BaseURL: "https://api.openai.com/v1"},
+		} {
+			if err := generation.validate(provider); err == nil || !strings.Contains(err.Error(), "Generation.Seed") {
+				t.Errorf("%s: expected an error naming Generation.Seed, got %v", name, err)
+			}
+		}
+		for _, provider := range []*ProviderConfig{{BaseURL: "https://api.openai.com/v1"}, {Type: "azure", BaseURL: "https://x.openai.azure.com"}} {
+			if err := generation.validate(provider); err != nil {
+				t.Errorf("expected %s to accept a seed, got %v", provider.Type, err)
+			}
+		}
+	})
+
+	t.Run("is added to chat completion requests", func(t *testing.T) {
+		type received struct {
+			path, query, auth string
+			body              map[string]any
+		}
+		requests := make(chan received, 2)
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			var body map[string]any
+			json.Unmarshal(data, &body)
+			requests <- received{r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), body}
+			w.Write([]byte(`{"ok":true}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		client := NewClient(nil)
+		proxy, wire, err := client.startSeedProxy(&GenerationConfig{Seed: Int64(42)}, &ProviderConfig{BaseURL: upstream.URL + "/v1", APIKey: "sk-test"})
+		if err != nil {
+			t.Fatalf("startSeedProxy failed: %v", err)
+		}
+		t.Cleanup(proxy.close)
+		if wire.APIKey != "sk-test" || !strings.HasPrefix(wire.BaseURL, "http://127.0.0.1:") {
+			t.Fatalf("unexpected wire provider: %+v", wire)
+		}
+
+		req, _ := http.NewRequest(http.MethodPost, wire.BaseURL+"/chat/completions?api-version=1", strings.NewReader(`{"model":"gpt-5","stream":true}`))
+		req.Header.Set("Authorization", "Bearer sk-test")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got := <-requests
+		if got.path != "/v1/chat/completions" || got.query != "api-version=1" || got.auth != "Bearer sk-test" {
+			t.Errorf("expected the request to be forwarded as sent, got %+v", got)
+		}
+		if got.body["seed"] != float64(42) || got.body["model"] != "gpt-5" || got.body["stream"] != true {
+			t.Errorf("expected the seed to be added to the body, got %v", got.body)
+		}
+
+		resp, err = http.Get(wire.BaseURL + "/models")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := <-requests; got.path != "/v1/models" || got.body != nil {
+			t.Errorf("expected other requests to be forwarded unchanged, got %+v", got)
+		}
+	})
+
+	t.Run("is recorded in exports and not sent to the runtime", func(t *testing.T) {
+		stateDir := t.TempDir()
+		creates := make(chan map[string]json.RawMessage, 1)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req map[string]json.RawMessage
+				json.Unmarshal(params, &req)
+				creates <- req
+				return sessionIDResult(params), nil
+			},
+			"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(testConversation), nil
+			},
+			"sessions.getEventFilePath": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{"filePath":"` + filepath.ToSlash(filepath.Join(stateDir, "events.jsonl")) + `"}`), nil
+			},
+		})
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Model:               "gpt-5",
+			Provider:            &ProviderConfig{Type: "openai", BaseURL: "https://api.openai.com/v1", APIKey: "sk-test"},
+			Generation:          &GenerationConfig{Seed: Int64(42)},
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		t.Cleanup(func() { session.seed.close() })
+		req := <-creates
+		if _, ok := req["generation"]; ok {
+			t.Errorf("expected no generation option in session.create, got %s", req["generation"])
+		}
+		var provider ProviderConfig
+		json.Unmarshal(req["provider"], &provider)
+		if !strings.HasPrefix(provider.BaseURL, "http://127.0.0.1:") {
+			t.Errorf("expected the provider to be served by the seed proxy, got %q", provider.BaseURL)
+		}
+
+		transcript, err := session.Export(t.Context())
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if transcript.Generation == nil || transcript.Generation.Seed == nil || *transcript.Generation.Seed != 42 {
+			t.Fatalf("expected the seed in the transcript, got %+v", transcript.Generation)
+		}
+	})
+}
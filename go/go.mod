@@ -2,22 +2,9 @@ module github.com/github/copilot-sdk/go
 
 go 1.24
 
-require (
-	github.com/google/jsonschema-go v0.4.2
-	github.com/klauspost/compress v1.18.3
-)
+require github.com/google/jsonschema-go v0.4.2
 
 require (
-	github.com/coder/websocket v1.8.15
 	github.com/ebitengine/purego v0.10.1
 	github.com/google/uuid v1.6.0
-	go.opentelemetry.io/otel v1.35.0
-	go.opentelemetry.io/otel/trace v1.35.0
-)
-
-require (
-	github.com/go-logr/logr v1.4.3 // indirect
-	github.com/go-logr/stdr v1.2.2 // indirect
-	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
-	go.opentelemetry.io/otel/metric v1.35.0 // indirect
 )
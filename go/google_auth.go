@@ -0,0 +1,256 @@
+package copilot
+
+import (
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// googleCloudPlatformScope is the OAuth scope Vertex AI requests need.
+const googleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// googleTokenURL is Google's OAuth token endpoint.
+const googleTokenURL = "https://oauth2.googleapis.com/token"
+
+// googleCredentialsFile is the subset of a Google credentials JSON file the
+// SDK uses: a service account key or gcloud user credentials. The SDK gets
+// OAuth access tokens for the Vertex AI provider itself, for the subset
+// Vertex needs, so it does not depend on the Google auth libraries.
+type googleCredentialsFile struct {
+	Type string `json:"type"`
+	// Service account keys.
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ProjectID    string `json:"project_id"`
+	// User credentials.
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// parseGoogleCredentials parses a credentials JSON file and checks it has
+// what its type needs.
+func parseGoogleCredentials(data []byte) (*googleCredentialsFile, error) {
+	var f googleCredentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid Google credentials: %w", err)
+	}
+	switch f.Type {
+	case "service_account":
+		if f.ClientEmail == "" || f.PrivateKey == "" {
+			return nil, errors.New("service account key has no client_email or private_key")
+		}
+		if _, err := parseRSAPrivateKey(f.PrivateKey); err != nil {
+			return nil, err
+		}
+	case "authorized_user":
+		if f.ClientID == "" || f.ClientSecret == "" || f.RefreshToken == "" {
+			return nil, errors.New("user credentials have no client_id, client_secret or refresh_token")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Google credentials type %q: use a service account key or gcloud user credentials", f.Type)
+	}
+	return &f, nil
+}
+
+func parseRSAPrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("service account private_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private_key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private_key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// findDefaultGoogleCredentials returns the Application Default Credentials
+// file: GOOGLE_APPLICATION_CREDENTIALS, or the file written by
+// "gcloud auth application-default login". It returns nil if there is
+// none, in which case the metadata server is used.
+func findDefaultGoogleCredentials() (*googleCredentialsFile, error) {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		return parseGoogleCredentials(data)
+	}
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "application_default_credentials.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Application Default Credentials: %w", err)
+	}
+	return parseGoogleCredentials(data)
+}
+
+// googleTokenSource fetches OAuth access tokens and caches them until
+// shortly before they expire.
+type googleTokenSource struct {
+	client *http.Client
+	// credentials is nil to use Application Default Credentials.
+	credentials *googleCredentialsFile
+	// tokenURL is the OAuth token endpoint for user credentials, and
+	// metadataURL the metadata server's.
+	tokenURL    string
+	metadataURL string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newGoogleTokenSource(client *http.Client, credentials *googleCredentialsFile) *googleTokenSource {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "169.254.169.254"
+	}
+	return &googleTokenSource{
+		client:      client,
+		credentials: credentials,
+		tokenURL:    googleTokenURL,
+		metadataURL: "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token",
+	}
+}
+
+// Token returns a valid access token.
+func (s *googleTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expiry) {
+		return s.token, nil
+	}
+	if s.credentials == nil {
+		credentials, err := findDefaultGoogleCredentials()
+		if err != nil {
+			return "", err
+		}
+		s.credentials = credentials
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case s.credentials == nil:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, s.metadataURL, nil)
+		if err == nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	case s.credentials.Type == "service_account":
+		var assertion string
+		assertion, err = s.credentials.assertion(time.Now())
+		if err == nil {
+			req, err = newTokenRequest(ctx, cmp.Or(s.credentials.TokenURI, s.tokenURL), url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}
+	default:
+		req, err = newTokenRequest(ctx, s.tokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {s.credentials.ClientID},
+			"client_secret": {s.credentials.ClientSecret},
+			"refresh_token": {s.credentials.RefreshToken},
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if s.credentials == nil {
+			return "", fmt.Errorf("no Google credentials found: set GOOGLE_APPLICATION_CREDENTIALS or run \"gcloud auth application-default login\" (metadata server: %w)", err)
+		}
+		return "", fmt.Errorf("failed to fetch Google access token: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch Google access token: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return "", errors.New("failed to fetch Google access token: response has no access_token")
+	}
+	s.token = token.AccessToken
+	// Refresh a minute early so a token does not expire in flight.
+	s.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+func newTokenRequest(ctx context.Context, tokenURL string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// assertion returns the signed JWT a service account exchanges for an
+// access token.
+func (f *googleCredentialsFile) assertion(now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(f.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": f.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   f.ClientEmail,
+		"scope": googleCloudPlatformScope,
+		"aud":   cmp.Or(f.TokenURI, googleTokenURL),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
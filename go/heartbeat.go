@@ -0,0 +1,157 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HeartbeatConfig configures liveness monitoring of the runtime connection.
+// See [ClientOptions.Heartbeat].
+type HeartbeatConfig struct {
+	// Interval is the time between pings. Defaults to 30s.
+	Interval time.Duration
+	// Timeout bounds each ping. Defaults to 10s.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed pings after which
+	// the connection is considered unhealthy. Defaults to 1.
+	FailureThreshold int
+}
+
+func (h HeartbeatConfig) withDefaults() HeartbeatConfig {
+	if h.Interval <= 0 {
+		h.Interval = 30 * time.Second
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = 10 * time.Second
+	}
+	if h.FailureThreshold <= 0 {
+		h.FailureThreshold = 1
+	}
+	return h
+}
+
+// ErrConnectionLost is the [ClientStateChange.Err] of the change to
+// [ClientStateDegraded] when the connection to the runtime closes
+// unexpectedly. [Session.SendAndWait] returns it when the CLI process exits
+// during the turn.
+var ErrConnectionLost = errors.New("connection to the runtime was lost")
+
+// UnhealthyHandler is called when the runtime connection becomes unhealthy.
+type UnhealthyHandler func(err error)
+
+// Healthy reports whether the client is connected and, when
+// [ClientOptions.Heartbeat] is set, the runtime answered its most recent
+// pings: whether it is in [ClientStateReady].
+func (c *Client) Healthy() bool {
+	return c.State() == ClientStateReady
+}
+
+// OnUnhealthy subscribes to liveness failures: missed heartbeats (see
+// [ClientOptions.Heartbeat]) and unexpected connection loss, reported as
+// [ErrConnectionLost]. The handler is called with the
+// [ClientStateChange.Err] of each change to [ClientStateDegraded] from
+// another state, from a background goroutine.
+//
+// Returns a function that, when called, unsubscribes the handler.
+//
+// Example:
+//
+//	unsubscribe := client.OnUnhealthy(func(err error) {
+//	    log.Printf("copilot runtime unhealthy: %v", err)
+//	    readiness.Set(false)
+//	})
+//	defer unsubscribe()
+func (c *Client) OnUnhealthy(handler UnhealthyHandler) func() {
+	return c.OnStateChange(func(change ClientStateChange) {
+		if change.State == ClientStateDegraded && change.Previous != ClientStateDegraded {
+			handler(change.Err)
+		}
+	})
+}
+
+// markUnhealthy records a liveness failure and moves the client to
+// [ClientStateDegraded] on the transition from healthy.
+func (c *Client) markUnhealthy(err error) {
+	if !c.healthy.CompareAndSwap(true, false) {
+		return
+	}
+	c.setState(ClientStateDegraded, err, ClientStateReady)
+}
+
+// startHeartbeat starts the heartbeat loop for the current connection.
+// Must be called with startStopMux held.
+func (c *Client) startHeartbeat() {
+	c.stopHeartbeat()
+	if c.options.Heartbeat == nil {
+		return
+	}
+	stop := make(chan struct{})
+	c.heartbeatStop = stop
+	go c.runHeartbeat(c.options.Heartbeat.withDefaults(), stop)
+}
+
+// stopHeartbeat stops the heartbeat loop, if running. Must be called with
+// startStopMux held.
+func (c *Client) stopHeartbeat() {
+	if c.heartbeatStop != nil {
+		close(c.heartbeatStop)
+		c.heartbeatStop = nil
+	}
+}
+
+func (c *Client) runHeartbeat(config HeartbeatConfig, stop <-chan struct{}) {
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		err := c.heartbeat(config.Timeout)
+		select {
+		case <-stop:
+			return // stopped while pinging; the failure is expected
+		default:
+		}
+		if err == nil {
+			failures = 0
+			if c.healthy.CompareAndSwap(false, true) {
+				c.setState(ClientStateReady, nil, ClientStateDegraded)
+			}
+			continue
+		}
+		failures++
+		if failures < config.FailureThreshold {
+			continue
+		}
+		c.markUnhealthy(fmt.Errorf("runtime missed %d heartbeat(s): %w", failures, err))
+
+		// A runtime that stopped answering without closing the connection
+		// would otherwise never trigger a reconnect.
+		if c.isExternalServer && c.options.Reconnect != nil {
+			c.startStopMux.Lock()
+			if c.conn != nil {
+				c.conn.Close()
+			}
+			c.startStopMux.Unlock()
+		}
+	}
+}
+
+func (c *Client) heartbeat(timeout time.Duration) error {
+	c.startStopMux.RLock()
+	client := c.client
+	c.startStopMux.RUnlock()
+	if client == nil {
+		return errors.New("client not connected")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := client.Request(ctx, "ping", pingRequest{})
+	return err
+}
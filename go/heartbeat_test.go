@@ -0,0 +1,154 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// startHeartbeatTestServer serves connect and ping on a unix socket. Pings
+// stall while stall is set.
+func startHeartbeatTestServer(t *testing.T, stall *atomic.Bool) (string, <-chan net.Conn) {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "rt.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		server := jsonrpc2.NewClient(conn, conn)
+		server.SetRequestHandler("connect", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+		})
+		server.SetRequestHandler("ping", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			if stall.Load() {
+				time.Sleep(200 * time.Millisecond)
+			}
+			return []byte(`{"message":"pong","timestamp":0}`), nil
+		})
+		server.Start()
+		conns <- conn
+	}()
+	return socketPath, conns
+}
+
+func TestClient_Heartbeat(t *testing.T) {
+	t.Run("reports missed heartbeats and recovers", func(t *testing.T) {
+		var stall atomic.Bool
+		socketPath, _ := startHeartbeatTestServer(t, &stall)
+		client := NewClient(&ClientOptions{
+			Connection: UnixSocketConnection{Path: socketPath},
+			Heartbeat:  &HeartbeatConfig{Interval: 10 * time.Millisecond, Timeout: 20 * time.Millisecond, FailureThreshold: 2},
+		})
+		unhealthy := make(chan error, 4)
+		client.OnStateChange(func(change ClientStateChange) {
+			if change.State == ClientStateDegraded {
+				unhealthy <- change.Err
+			}
+		})
+
+		if client.State() == ClientStateReady {
+			t.Error("expected an unstarted client not to be ready")
+		}
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.Stop()
+		if client.State() != ClientStateReady {
+			t.Error("expected a started client to be ready")
+		}
+
+		stall.Store(true)
+		select {
+		case err := <-unhealthy:
+			if err == nil || errors.Is(err, ErrConnectionLost) {
+				t.Errorf("expected a heartbeat error, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the client to degrade")
+		}
+		if client.State() != ClientStateDegraded {
+			t.Error("expected the client to be degraded after missed heartbeats")
+		}
+
+		stall.Store(false)
+		deadline := time.Now().Add(5 * time.Second)
+		for client.State() != ClientStateReady {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the client to recover")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("reports connection loss", func(t *testing.T) {
+		var stall atomic.Bool
+		socketPath, conns := startHeartbeatTestServer(t, &stall)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: socketPath}})
+		unhealthy := make(chan error, 1)
+		client.OnStateChange(func(change ClientStateChange) {
+			if change.State == ClientStateDegraded {
+				unhealthy <- change.Err
+			}
+		})
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.Stop()
+
+		(<-conns).Close()
+		select {
+		case err := <-unhealthy:
+			if !errors.Is(err, ErrConnectionLost) {
+				t.Errorf("expected ErrConnectionLost, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the client to degrade")
+		}
+	})
+
+	t.Run("reports liveness through OnUnhealthy and Healthy", func(t *testing.T) {
+		var stall atomic.Bool
+		socketPath, conns := startHeartbeatTestServer(t, &stall)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: socketPath}})
+		unhealthy := make(chan error, 1)
+		client.OnUnhealthy(func(err error) { unhealthy <- err })
+
+		if client.Healthy() {
+			t.Error("expected an unstarted client to be unhealthy")
+		}
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.Stop()
+		if !client.Healthy() {
+			t.Error("expected a started client to be healthy")
+		}
+
+		(<-conns).Close()
+		select {
+		case err := <-unhealthy:
+			if !errors.Is(err, ErrConnectionLost) {
+				t.Errorf("expected ErrConnectionLost, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for OnUnhealthy")
+		}
+		if client.Healthy() {
+			t.Error("expected the client to be unhealthy after losing the connection")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package copilot
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrStopHookChain is returned by a hook in a [ChainHooks] chain to end the
+// chain with its output, even if that output is nil. The chain returns the
+// output without an error.
+var ErrStopHookChain = errors.New("stop hook chain")
+
+// ChainHooks composes hooks into one [SessionHooks], so that logging,
+// security and product hooks can be written independently and set together
+// as [SessionConfig.Hooks].
+//
+// Hooks that return an output are called in order until one returns a
+// non-nil output or an error, which becomes the result: the first decision
+// wins. Return a nil output to pass the call on to the next hook, and
+// [ErrStopHookChain] to end the chain without deciding. OnSuspiciousContent
+// works the same way, with the first action other than
+// [SuspiciousContentAllow] winning. Hooks that only observe, such as
+// OnTurnComplete, OnToolResultModified and OnCompaction, are all called.
+// Nil hooks, and nil fields, are skipped.
+//
+// Example:
+//
+//	security := &copilot.SessionHooks{
+//	    OnPreToolUse: func(input copilot.PreToolUseHookInput, _ copilot.HookInvocation) (*copilot.PreToolUseHookOutput, error) {
+//	        if input.ToolName == "bash" {
+//	            return &copilot.PreToolUseHookOutput{PermissionDecision: "deny"}, nil
+//	        }
+//	        return nil, nil // let the next hook decide
+//	    },
+//	}
+//	audit := &copilot.SessionHooks{
+//	    OnPreToolUse: func(input copilot.PreToolUseHookInput, _ copilot.HookInvocation) (*copilot.PreToolUseHookOutput, error) {
+//	        log.Printf("tool %s", input.ToolName)
+//	        return nil, nil
+//	    },
+//	}
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Hooks:               copilot.ChainHooks(audit, security),
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	})
+func ChainHooks(hooks ...*SessionHooks) *SessionHooks {
+	var c struct {
+		preToolUse          []PreToolUseHandler
+		postToolUse         []PostToolUseHandler
+		postToolUseFailure  []PostToolUseFailureHandler
+		userPromptSubmitted []UserPromptSubmittedHandler
+		sessionStart        []SessionStartHandler
+		sessionEnd          []SessionEndHandler
+		errorOccurred       []ErrorOccurredHandler
+		preMCPToolCall      []PreMCPToolCallHandler
+		preCompact          []PreCompactHandler
+		compaction          []CompactionHandler
+		toolResultModified  []ToolResultModifiedHandler
+		suspiciousContent   []SuspiciousContentHandler
+		turnStart           []TurnStartHandler
+		turnComplete        []TurnCompleteHandler
+		turnResult          []TurnResultHandler
+	}
+	for _, h := range hooks {
+		if h == nil {
+			continue
+		}
+		c.preToolUse = append(c.preToolUse, h.OnPreToolUse)
+		c.postToolUse = append(c.postToolUse, h.OnPostToolUse)
+		c.postToolUseFailure = append(c.postToolUseFailure, h.OnPostToolUseFailure)
+		c.userPromptSubmitted = append(c.userPromptSubmitted, h.OnUserPromptSubmitted)
+		c.sessionStart = append(c.sessionStart, h.OnSessionStart)
+		c.sessionEnd = append(c.sessionEnd, h.OnSessionEnd)
+		c.errorOccurred = append(c.errorOccurred, h.OnErrorOccurred)
+		c.preMCPToolCall = append(c.preMCPToolCall, h.OnPreMCPToolCall)
+		c.preCompact = append(c.preCompact, h.OnPreCompact)
+		c.compaction = append(c.compaction, h.OnCompaction)
+		c.toolResultModified = append(c.toolResultModified, h.OnToolResultModified)
+		c.suspiciousContent = append(c.suspiciousContent, h.OnSuspiciousContent)
+		c.turnStart = append(c.turnStart, h.OnTurnStart)
+		c.turnComplete = append(c.turnComplete, h.OnTurnComplete)
+		c.turnResult = append(c.turnResult, h.OnTurnResult)
+	}
+	return &SessionHooks{
+		OnPreToolUse:          chainHook(c.preToolUse),
+		OnPostToolUse:         chainHook(c.postToolUse),
+		OnPostToolUseFailure:  chainHook(c.postToolUseFailure),
+		OnUserPromptSubmitted: chainHook(c.userPromptSubmitted),
+		OnSessionStart:        chainHook(c.sessionStart),
+		OnSessionEnd:          chainHook(c.sessionEnd),
+		OnErrorOccurred:       chainHook(c.errorOccurred),
+		OnPreMCPToolCall:      chainHook(c.preMCPToolCall),
+		OnPreCompact:          chainHook(c.preCompact),
+		OnCompaction:          chainObservers(c.compaction),
+		OnToolResultModified:  chainObservers(c.toolResultModified),
+		OnSuspiciousContent:   chainSuspiciousContent(c.suspiciousContent),
+		OnTurnStart:           chainObservers(c.turnStart),
+		OnTurnComplete:        chainObservers(c.turnComplete),
+		OnTurnResult:          chainTurnResult(c.turnResult),
+	}
+}
+
+// chainHook returns a hook calling the non-nil handlers in order until one
+// decides, or nil if there are none.
+func chainHook[H ~func(I, HookInvocation) (*O, error), I, O any](handlers []H) H {
+	handlers = slices.DeleteFunc(handlers, func(h H) bool { return h == nil })
+	if len(handlers) == 0 {
+		return nil
+	}
+	return func(input I, invocation HookInvocation) (*O, error) {
+		for _, handler := range handlers {
+			output, err := handler(input, invocation)
+			if errors.Is(err, ErrStopHookChain) {
+				return output, nil
+			}
+			if err != nil || output != nil {
+				return output, err
+			}
+		}
+		return nil, nil
+	}
+}
+
+// chainObservers returns a hook calling every non-nil handler, or nil if
+// there are none.
+func chainObservers[H ~func(T), T any](handlers []H) H {
+	handlers = slices.DeleteFunc(handlers, func(h H) bool { return h == nil })
+	if len(handlers) == 0 {
+		return nil
+	}
+	return func(value T) {
+		for _, handler := range handlers {
+			handler(value)
+		}
+	}
+}
+
+func chainSuspiciousContent(handlers []SuspiciousContentHandler) SuspiciousContentHandler {
+	handlers = slices.DeleteFunc(handlers, func(h SuspiciousContentHandler) bool { return h == nil })
+	if len(handlers) == 0 {
+		return nil
+	}
+	return func(input SuspiciousContentInput) SuspiciousContentAction {
+		for _, handler := range handlers {
+			if action := handler(input); action != SuspiciousContentAllow {
+				return action
+			}
+		}
+		return SuspiciousContentAllow
+	}
+}
+
+func chainTurnResult(handlers []TurnResultHandler) TurnResultHandler {
+	handlers = slices.DeleteFunc(handlers, func(h TurnResultHandler) bool { return h == nil })
+	if len(handlers) == 0 {
+		return nil
+	}
+	return func(info TurnCompleteInfo) *TurnResult {
+		for _, handler := range handlers {
+			if result := handler(info); result != nil {
+				return result
+			}
+		}
+		return nil
+	}
+}
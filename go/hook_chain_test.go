@@ -0,0 +1,75 @@
+package copilot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChainHooks(t *testing.T) {
+	allow := &PreToolUseHookOutput{PermissionDecision: "allow"}
+	deny := &PreToolUseHookOutput{PermissionDecision: "deny"}
+
+	t.Run("the first non-nil output wins", func(t *testing.T) {
+		var calls []string
+		hook := func(name string, output *PreToolUseHookOutput) *SessionHooks {
+			return &SessionHooks{OnPreToolUse: func(PreToolUseHookInput, HookInvocation) (*PreToolUseHookOutput, error) {
+				calls = append(calls, name)
+				return output, nil
+			}}
+		}
+		hooks := ChainHooks(hook("audit", nil), nil, &SessionHooks{}, hook("security", deny), hook("product", allow))
+
+		output, err := hooks.OnPreToolUse(PreToolUseHookInput{}, HookInvocation{})
+		if err != nil || output != deny {
+			t.Errorf("expected the security decision, got %+v, %v", output, err)
+		}
+		if !reflect.DeepEqual(calls, []string{"audit", "security"}) {
+			t.Errorf("unexpected calls %v", calls)
+		}
+	})
+
+	t.Run("errors end the chain and ErrStopHookChain ends it without one", func(t *testing.T) {
+		boom := errors.New("boom")
+		failing := &SessionHooks{OnPreToolUse: func(PreToolUseHookInput, HookInvocation) (*PreToolUseHookOutput, error) {
+			return nil, boom
+		}}
+		stopping := &SessionHooks{OnPreToolUse: func(PreToolUseHookInput, HookInvocation) (*PreToolUseHookOutput, error) {
+			return nil, ErrStopHookChain
+		}}
+		deciding := &SessionHooks{OnPreToolUse: func(PreToolUseHookInput, HookInvocation) (*PreToolUseHookOutput, error) {
+			return allow, nil
+		}}
+
+		if _, err := ChainHooks(failing, deciding).OnPreToolUse(PreToolUseHookInput{}, HookInvocation{}); !errors.Is(err, boom) {
+			t.Errorf("expected the hook's error, got %v", err)
+		}
+		if output, err := ChainHooks(stopping, deciding).OnPreToolUse(PreToolUseHookInput{}, HookInvocation{}); output != nil || err != nil {
+			t.Errorf("expected the chain to stop without a decision, got %+v, %v", output, err)
+		}
+	})
+
+	t.Run("calls every observer and leaves unset hooks nil", func(t *testing.T) {
+		var completed int
+		observer := &SessionHooks{OnTurnComplete: func(TurnCompleteInfo) { completed++ }}
+		hooks := ChainHooks(observer, observer)
+
+		hooks.OnTurnComplete(TurnCompleteInfo{})
+		if completed != 2 {
+			t.Errorf("expected both observers to be called, got %d calls", completed)
+		}
+		if hooks.OnPostToolUse != nil || hooks.OnTurnStart != nil || hooks.OnSuspiciousContent != nil {
+			t.Error("expected hooks set by no SessionHooks to stay nil")
+		}
+	})
+
+	t.Run("the first action other than allow wins", func(t *testing.T) {
+		action := func(a SuspiciousContentAction) *SessionHooks {
+			return &SessionHooks{OnSuspiciousContent: func(SuspiciousContentInput) SuspiciousContentAction { return a }}
+		}
+		hooks := ChainHooks(action(SuspiciousContentAllow), action(SuspiciousContentQuarantine), action(SuspiciousContentAllow))
+		if got := hooks.OnSuspiciousContent(SuspiciousContentInput{}); got != SuspiciousContentQuarantine {
+			t.Errorf("expected %q, got %q", SuspiciousContentQuarantine, got)
+		}
+	})
+}
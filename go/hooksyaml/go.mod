@@ -0,0 +1,19 @@
+module github.com/github/copilot-sdk/go/hooksyaml
+
+go 1.24
+
+require (
+	github.com/github/copilot-sdk/go v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/ebitengine/purego v0.10.1 // indirect
+	github.com/google/jsonschema-go v0.4.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.13.1 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
+
+replace github.com/github/copilot-sdk/go => ../
@@ -0,0 +1,83 @@
+package hooksyaml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+const testHooksYAML = `
+preToolUse:
+  - tool: "bash"
+    command: "rm -rf *"
+    decision: deny
+    reason: "destructive commands are not allowed"
+  - tool: "bash"
+    env:
+      CI: "true"
+    decision: allow
+sessionStart:
+  context: "Go 1.24 repo."
+`
+
+func TestFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	if err := os.WriteFile(path, []byte(testHooksYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks := &copilot.SessionHooks{}
+	if err := FromFile(hooks, path); err != nil {
+		t.Fatalf("FromFile failed: %v", err)
+	}
+
+	out, err := hooks.OnPreToolUse(copilot.PreToolUseHookInput{ToolName: "bash", ToolArgs: map[string]any{"command": "rm -rf /tmp/x"}}, copilot.HookInvocation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil || out.PermissionDecision != "deny" || out.PermissionDecisionReason != "destructive commands are not allowed" {
+		t.Errorf("expected the command to be denied, got %+v", out)
+	}
+	out, err = hooks.OnPreToolUse(copilot.PreToolUseHookInput{ToolName: "bash", ToolArgs: map[string]any{"command": "make test"}}, copilot.HookInvocation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil || out.PermissionDecision != "allow" || out.ModifiedArgs.(map[string]any)["command"] != "CI='true' make test" {
+		t.Errorf("expected the command to be allowed with its environment, got %+v", out)
+	}
+	start, err := hooks.OnSessionStart(copilot.SessionStartHookInput{}, copilot.HookInvocation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start == nil || start.AdditionalContext != "Go 1.24 repo." {
+		t.Errorf("unexpected session start output: %+v", start)
+	}
+}
+
+func TestFromYAMLErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "unknown field", yaml: "preToolUse:\n  - tol: bash\n", want: `unknown field "tol"`},
+		{name: "invalid decision", yaml: "preToolUse:\n  - decision: maybe\n", want: "decision must be"},
+		{name: "invalid syntax", yaml: "preToolUse: [\n", want: "yaml"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := FromYAML(&copilot.SessionHooks{}, []byte(tc.yaml))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	t.Run("empty file", func(t *testing.T) {
+		if err := FromYAML(&copilot.SessionHooks{}, nil); err != nil {
+			t.Errorf("expected an empty file to install nothing, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// idleState tracks whether a session has work in progress, for
+// [Session.WaitIdle]. The zero value is an idle session.
+type idleState struct {
+	mu sync.Mutex
+	// turn is set while a turn runs: from a send or a top-level
+	// user.message until session.idle or session.error has been delivered.
+	turn bool
+	// ctx carries the values of the context of the send that started the
+	// running turn, without its deadline or cancellation; tool handlers of
+	// the turn run under it. Nil outside a send's turn.
+	ctx context.Context
+	// dispatched counts events queued for processEvents and observed the
+	// events it has taken, so the n-th observed event is the n-th queued.
+	dispatched atomic.Uint64
+	observed   uint64
+	// start is the number of events queued when the running turn began.
+	// A session.idle among them belongs to an earlier turn and does not
+	// end this one.
+	start uint64
+	// callbacks counts broadcast handlers (tools, permissions, ...) running.
+	callbacks int
+	// changed is closed and replaced whenever the session becomes idle.
+	changed chan struct{}
+	// turnEnded is closed and replaced whenever a turn ends, whether or not
+	// callbacks are still running.
+	turnEnded chan struct{}
+}
+
+func (st *idleState) isIdle() bool {
+	return !st.turn && st.callbacks == 0
+}
+
+// update applies fn and wakes waiters if the session became idle.
+func (st *idleState) update(fn func()) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	fn()
+	if st.isIdle() && st.changed != nil {
+		close(st.changed)
+		st.changed = nil
+	}
+	if !st.turn && st.turnEnded != nil {
+		close(st.turnEnded)
+		st.turnEnded = nil
+	}
+}
+
+func (st *idleState) setTurn(active bool) {
+	st.update(func() {
+		st.turn = active
+		if !active {
+			st.ctx = nil
+		}
+	})
+}
+
+// beginTurn marks a turn started by a send with context ctx as running and
+// reports whether one already was. A send during a running turn does not
+// replace the context of the send that started it.
+func (st *idleState) beginTurn(ctx context.Context) (wasActive bool) {
+	st.update(func() {
+		wasActive = st.turn
+		if !st.turn {
+			st.turn = true
+			st.ctx = context.WithoutCancel(ctx)
+			st.start = st.dispatched.Load()
+		}
+	})
+	return wasActive
+}
+
+// tryBeginTurn marks a turn started by a send with context ctx as running,
+// unless one already is, in which case it returns a channel that is closed
+// when that turn ends.
+func (st *idleState) tryBeginTurn(ctx context.Context) (ended <-chan struct{}, ok bool) {
+	st.update(func() {
+		if st.turn {
+			if st.turnEnded == nil {
+				st.turnEnded = make(chan struct{})
+			}
+			ended = st.turnEnded
+			return
+		}
+		st.turn = true
+		st.ctx = context.WithoutCancel(ctx)
+		st.start = st.dispatched.Load()
+		ok = true
+	})
+	return ended, ok
+}
+
+// turnContext returns the values of the context of the send that started
+// the running turn, or context.Background() if no send did.
+func (st *idleState) turnContext() context.Context {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.ctx == nil {
+		return context.Background()
+	}
+	return st.ctx
+}
+
+func (st *idleState) addCallbacks(delta int) {
+	st.update(func() { st.callbacks += delta })
+}
+
+// queued counts an event queued for processEvents. Called only from
+// dispatchEvent.
+func (st *idleState) queued() {
+	st.dispatched.Add(1)
+}
+
+// observe updates the turn state from an event after it was delivered to
+// handlers. Called only from processEvents, once per queued event.
+func (st *idleState) observe(event SessionEvent) {
+	st.update(func() {
+		st.observed++
+		switch event.Data.(type) {
+		case *UserMessageData:
+			if event.AgentID == nil && !st.turn {
+				st.turn = true
+				st.start = st.observed
+			}
+		case *SessionIdleData:
+			if st.observed > st.start {
+				st.turn = false
+				st.ctx = nil
+			}
+		case *SessionErrorData:
+			st.turn = false
+			st.ctx = nil
+		}
+	})
+}
+
+// wait returns nil if the session is idle, otherwise a channel that is
+// closed when it next becomes idle.
+func (st *idleState) wait() <-chan struct{} {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.isIdle() {
+		return nil
+	}
+	if st.changed == nil {
+		st.changed = make(chan struct{})
+	}
+	return st.changed
+}
+
+// waitTurn returns nil if no turn is running, otherwise a channel that is
+// closed when it ends.
+func (st *idleState) waitTurn() <-chan struct{} {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.turn {
+		return nil
+	}
+	if st.turnEnded == nil {
+		st.turnEnded = make(chan struct{})
+	}
+	return st.turnEnded
+}
+
+// WaitIdle blocks until the session is idle: no turn is running and no
+// tool, permission or other callback handler is still executing. A turn
+// counts as running from a [Session.Send] (or a message from another
+// client) until the session.idle or session.error event has been
+// delivered to the handlers registered with [Session.On]. A session.idle
+// that arrived before the send belongs to an earlier turn and does not end
+// it.
+//
+// WaitIdle returns immediately if the session is already idle. It is
+// useful after fire-and-forget sends and before [Session.Disconnect] in a
+// graceful shutdown. It returns ctx's error if ctx ends first, and
+// [ErrSessionClosed] if the session is disconnected while waiting.
+//
+// Example:
+//
+//	if _, err := session.Send(ctx, copilot.MessageOptions{Prompt: "Run the tests"}); err != nil {
+//		log.Fatal(err)
+//	}
+//	// ... later, on shutdown:
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	if err := session.WaitIdle(ctx); err != nil {
+//		log.Printf("session still busy: %v", err)
+//	}
+//	session.Disconnect()
+func (s *Session) WaitIdle(ctx context.Context) error {
+	for {
+		ch := s.idle.wait()
+		if ch == nil {
+			return nil
+		}
+		select {
+		case <-ch:
+		case <-s.done:
+			return ErrSessionClosed
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for session to become idle: %w", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// waitIdleAsync runs WaitIdle in the background and returns its result
+// channel.
+func waitIdleAsync(ctx context.Context, session *Session) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- session.WaitIdle(ctx) }()
+	return result
+}
+
+func expectStillWaiting(t *testing.T, result <-chan error) {
+	t.Helper()
+	select {
+	case err := <-result:
+		t.Fatalf("expected WaitIdle to block, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func expectIdle(t *testing.T, result <-chan error) {
+	t.Helper()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("WaitIdle failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitIdle did not return")
+	}
+}
+
+func TestSession_WaitIdle(t *testing.T) {
+	t.Run("returns at once for an idle session", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		if err := session.WaitIdle(t.Context()); err != nil {
+			t.Errorf("WaitIdle failed: %v", err)
+		}
+	})
+
+	t.Run("waits for the turn started by Send", func(t *testing.T) {
+		session, _ := startConcurrencyTestSession(t)
+		t.Cleanup(func() { session.Disconnect() })
+		var idleDelivered atomic.Bool
+		session.On(func(event SessionEvent) {
+			if _, ok := event.Data.(*SessionIdleData); ok {
+				time.Sleep(10 * time.Millisecond)
+				idleDelivered.Store(true)
+			}
+		})
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		result := waitIdleAsync(t.Context(), session)
+		expectStillWaiting(t, result)
+
+		session.dispatchEvent(newTestEvent())
+		expectIdle(t, result)
+		if !idleDelivered.Load() {
+			t.Error("expected handlers to see session.idle before WaitIdle returned")
+		}
+	})
+
+	t.Run("is not ended by an idle event queued before Send", func(t *testing.T) {
+		session, _ := startConcurrencyTestSession(t)
+		t.Cleanup(func() { session.Disconnect() })
+		release := make(chan struct{})
+		var delivered atomic.Int32
+		session.On(func(event SessionEvent) {
+			if _, ok := event.Data.(*SessionIdleData); ok && delivered.Add(1) == 1 {
+				<-release
+			}
+		})
+
+		// The previous turn's session.idle is still being delivered when
+		// the next send begins.
+		session.dispatchEvent(newTestEvent())
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		close(release)
+		result := waitIdleAsync(t.Context(), session)
+		expectStillWaiting(t, result)
+
+		session.dispatchEvent(newTestEvent())
+		expectIdle(t, result)
+	})
+
+	t.Run("waits for running tool handlers", func(t *testing.T) {
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.tools.handlePendingToolCall": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		release := make(chan struct{})
+		session.registerTools([]Tool{{
+			Name: "slow",
+			Handler: func(ToolInvocation) (ToolResult, error) {
+				<-release
+				return ToolResult{TextResultForLLM: "done"}, nil
+			},
+		}})
+
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "slow"}})
+		result := waitIdleAsync(t.Context(), session)
+		expectStillWaiting(t, result)
+		close(release)
+		expectIdle(t, result)
+	})
+
+	t.Run("returns when the context ends or the session closes", func(t *testing.T) {
+		session, _ := startConcurrencyTestSession(t)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		if err := session.WaitIdle(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+
+		result := waitIdleAsync(t.Context(), session)
+		if err := session.Disconnect(); err != nil {
+			t.Fatalf("Disconnect failed: %v", err)
+		}
+		select {
+		case err := <-result:
+			if !errors.Is(err, ErrSessionClosed) {
+				t.Errorf("expected ErrSessionClosed, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("WaitIdle did not return after Disconnect")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Image is an image to show a vision-capable model with a message. Set
+// exactly one of Path, Data or URL. See [MessageOptions.Images].
+//
+// The image is sent as inline image content rather than as text, whatever
+// its source: files are read and URLs downloaded by the SDK when the
+// message is sent.
+type Image struct {
+	// Path is a local image file. A relative Path is resolved against the
+	// session's [SessionConfig.WorkingDirectory], when set.
+	Path string
+	// Data holds the image bytes.
+	Data []byte
+	// URL is an http or https URL the SDK downloads the image from, using
+	// the HTTP client of [ClientOptions.Network] when set.
+	URL string
+	// MIMEType is the image type, e.g. "image/png". When empty it is taken
+	// from the file extension, the response's Content-Type, or the data.
+	MIMEType string
+	// DisplayName is shown in the timeline. Defaults to the file name.
+	DisplayName string
+}
+
+// maxImageBytes bounds the size of an image, to fail fast on a wrong URL
+// or file rather than send it to the model.
+const maxImageBytes = 20 << 20
+
+// attachment loads the image and returns it as a blob attachment.
+func (img Image) attachment(ctx context.Context, httpClient *http.Client) (*AttachmentBlob, error) {
+	sources := 0
+	for _, set := range []bool{img.Path != "", img.Data != nil, img.URL != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("exactly one of Path, Data and URL must be set")
+	}
+
+	data, mimeType, name := img.Data, img.MIMEType, img.DisplayName
+	switch {
+	case img.Path != "":
+		var err error
+		if data, err = readImageFile(img.Path); err != nil {
+			return nil, fmt.Errorf("failed to read image %s: %w", img.Path, err)
+		}
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(filepath.Ext(img.Path))
+		}
+		if name == "" {
+			name = filepath.Base(img.Path)
+		}
+	case img.URL != "":
+		var err error
+		var contentType string
+		if data, contentType, err = downloadImage(ctx, httpClient, img.URL); err != nil {
+			return nil, fmt.Errorf("failed to download image %s: %w", img.URL, err)
+		}
+		if mimeType == "" {
+			mimeType = contentType
+		}
+		if name == "" {
+			if u, err := url.Parse(img.URL); err == nil {
+				name = path.Base(u.Path)
+			}
+		}
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("not an image: %s", mimeType)
+	}
+	return BytesAttachment(name, data, mimeType), nil
+}
+
+// readImageFile reads a file, stopping past maxImageBytes.
+func readImageFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxImageBytes+1))
+}
+
+// downloadImage fetches an image and returns its bytes and Content-Type.
+func downloadImage(ctx context.Context, httpClient *http.Client, imageURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// resolvePath resolves a relative path against dir, when set. Without dir,
+// the path stays relative to the current process's working directory.
+func resolvePath(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// imageAttachments loads the images of a message.
+func (s *Session) imageAttachments(ctx context.Context, images []Image) ([]Attachment, error) {
+	httpClient, err := s.fetchClient()
+	if err != nil {
+		return nil, err
+	}
+	attachments := make([]Attachment, 0, len(images))
+	for i, img := range images {
+		if img.Path != "" {
+			img.Path = resolvePath(s.workingDirectory, img.Path)
+		}
+		if img.URL != "" {
+			u, err := url.Parse(img.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid image %d: %w", i, err)
+			}
+			if err := s.checkURLEgress(u); err != nil {
+				return nil, fmt.Errorf("invalid image %d: %w", i, err)
+			}
+		}
+		blob, err := img.attachment(ctx, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image %d: %w", i, err)
+		}
+		attachments = append(attachments, blob)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,137 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// testPNG is the start of a PNG file, enough for content sniffing.
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestImage_Attachment(t *testing.T) {
+	dir := t.TempDir()
+	pngPath := filepath.Join(dir, "screen.png")
+	if err := os.WriteFile(pngPath, testPNG, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shot.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html></html>"))
+		case "/redirect":
+			http.Redirect(w, r, "http://blocked.example/shot.jpg", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	valid := map[string]struct {
+		image    Image
+		mimeType string
+		name     string
+	}{
+		"from a file":      {Image{Path: pngPath}, "image/png", "screen.png"},
+		"from bytes":       {Image{Data: testPNG, DisplayName: "shot"}, "image/png", "shot"},
+		"from a URL":       {Image{URL: server.URL + "/shot.jpg"}, "image/jpeg", "shot.jpg"},
+		"with a MIME type": {Image{Data: []byte("webp"), MIMEType: "image/webp"}, "image/webp", ""},
+	}
+	for name, tc := range valid {
+		t.Run(name, func(t *testing.T) {
+			blob, err := tc.image.attachment(t.Context(), http.DefaultClient)
+			if err != nil {
+				t.Fatalf("attachment failed: %v", err)
+			}
+			if blob.MIMEType != tc.mimeType || blob.Data == nil {
+				t.Errorf("unexpected blob %+v", blob)
+			}
+			if got := ""; blob.DisplayName != nil {
+				got = *blob.DisplayName
+				if got != tc.name {
+					t.Errorf("expected display name %q, got %q", tc.name, got)
+				}
+			} else if tc.name != "" {
+				t.Errorf("expected display name %q", tc.name)
+			}
+		})
+	}
+
+	invalid := map[string]Image{
+		"no source":        {},
+		"two sources":      {Path: pngPath, Data: testPNG},
+		"a missing file":   {Path: filepath.Join(dir, "missing.png")},
+		"a failed request": {URL: server.URL + "/missing.png"},
+		"not an image":     {URL: server.URL + "/page"},
+	}
+	for name, image := range invalid {
+		t.Run("rejects "+name, func(t *testing.T) {
+			if _, err := image.attachment(t.Context(), http.DefaultClient); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("honors the egress policy across redirects", func(t *testing.T) {
+		guard, err := newEgressGuard(&EgressPolicy{DenyDomains: []string{"blocked.example"}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session := &Session{egress: guard}
+		for _, rawURL := range []string{"http://blocked.example/shot.jpg", server.URL + "/redirect"} {
+			if _, err := session.imageAttachments(t.Context(), []Image{{URL: rawURL}}); !errors.Is(err, errEgressDenied) {
+				t.Errorf("expected %s to be denied, got %v", rawURL, err)
+			}
+		}
+		if _, err := session.imageAttachments(t.Context(), []Image{{URL: server.URL + "/shot.jpg"}}); err != nil {
+			t.Errorf("expected an allowed host to be fetched, got %v", err)
+		}
+	})
+}
+
+func TestSession_SendImages(t *testing.T) {
+	type sendRequest struct {
+		Attachments []map[string]any `json:"attachments"`
+	}
+	requests := make(chan sendRequest, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req sendRequest
+			json.Unmarshal(params, &req)
+			requests <- req
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	_, err := session.Send(t.Context(), MessageOptions{
+		Prompt:      "Review this UI",
+		Attachments: []Attachment{&AttachmentDirectory{Path: "/src", DisplayName: "src"}},
+		Images:      []Image{{Data: testPNG}},
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	req := <-requests
+	if len(req.Attachments) != 2 {
+		t.Fatalf("expected 2 attachments, got %+v", req.Attachments)
+	}
+	if blob := req.Attachments[1]; blob["type"] != "blob" || blob["mimeType"] != "image/png" {
+		t.Errorf("expected the image as a blob, got %+v", req.Attachments[1])
+	}
+
+	if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi", Images: []Image{{}}}); err == nil {
+		t.Error("expected an invalid image to fail the send")
+	}
+}
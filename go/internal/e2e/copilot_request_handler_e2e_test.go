@@ -16,6 +16,7 @@ import (
 
 	"github.com/coder/websocket"
 	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/copilotws"
 	"github.com/github/copilot-sdk/go/internal/e2e/testharness"
 )
 
@@ -146,7 +147,7 @@ func TestCopilotRequestHandler(t *testing.T) {
 			}
 			parsed.Scheme = wsBase.Scheme
 			parsed.Host = wsBase.Host
-			fwd := copilot.NewCopilotWebSocketForwarder(parsed.String(), rctx.Headers)
+			fwd := copilotws.NewForwarder(parsed.String(), rctx.Headers)
 			fwd.OnSendRequestMessage = func(msg copilot.CopilotWebSocketMessage) *copilot.CopilotWebSocketMessage {
 				counters.wsRequestMessages.Add(1)
 				return &msg
@@ -0,0 +1,20 @@
+module github.com/github/copilot-sdk/go/internal/e2e
+
+go 1.24
+
+require (
+	github.com/coder/websocket v1.8.15
+	github.com/github/copilot-sdk/go v0.0.0
+	github.com/github/copilot-sdk/go/copilotws v0.0.0
+	github.com/google/uuid v1.6.0
+)
+
+require (
+	github.com/ebitengine/purego v0.10.1 // indirect
+	github.com/google/jsonschema-go v0.4.2 // indirect
+)
+
+replace (
+	github.com/github/copilot-sdk/go => ../../
+	github.com/github/copilot-sdk/go/copilotws => ../../copilotws
+)
@@ -90,3 +90,24 @@ func (w *headerWriter) Write(data []byte) error {
 	_, err := w.out.Write(data)
 	return err
 }
+
+// FrameReader reads the messages of a JSON-RPC stream without decoding them,
+// for relaying them.
+type FrameReader struct {
+	reader *headerReader
+}
+
+// NewFrameReader returns a FrameReader reading from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{reader: newHeaderReader(r)}
+}
+
+// Read returns the next message, or io.EOF at a clean end of the stream.
+func (r *FrameReader) Read() ([]byte, error) {
+	return r.reader.Read()
+}
+
+// WriteFrame writes data to w as one JSON-RPC message.
+func WriteFrame(w io.Writer, data []byte) error {
+	return newHeaderWriter(w).Write(data)
+}
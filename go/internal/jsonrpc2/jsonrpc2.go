@@ -7,8 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"os"
 	"reflect"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 )
@@ -24,6 +27,45 @@ var (
 	ErrInternal       = &Error{Code: -32603, Message: "internal error"}
 )
 
+// ErrStopped is returned for requests made or pending when the client stops.
+var ErrStopped = errors.New("client stopped")
+
+// ErrProcessExited matches the errors returned for requests that fail because
+// the process set with SetProcessDone exited.
+var ErrProcessExited = errors.New("process exited unexpectedly")
+
+// processExitError is the process's exit error, matching ErrProcessExited.
+type processExitError struct {
+	err error
+}
+
+func (e *processExitError) Error() string        { return e.err.Error() }
+func (e *processExitError) Unwrap() error        { return e.err }
+func (e *processExitError) Is(target error) bool { return target == ErrProcessExited }
+
+// ErrNotSent matches the errors returned for requests that failed before they
+// were written to the stream, so the server cannot have seen them.
+var ErrNotSent = errors.New("request not sent")
+
+// notSentError wraps an error returned before a request was written, matching
+// ErrNotSent.
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string        { return e.err.Error() }
+func (e *notSentError) Unwrap() error        { return e.err }
+func (e *notSentError) Is(target error) bool { return target == ErrNotSent }
+
+// NotSent wraps err to match ErrNotSent, for callers that fail before making
+// a request.
+func NotSent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &notSentError{err}
+}
+
 // Error represents a JSON-RPC error response.
 type Error struct {
 	Code    int             `json:"code"`
@@ -70,6 +112,7 @@ type Client struct {
 	pendingRequests        map[string]chan *Response
 	pendingInlineCallbacks map[string]func(json.RawMessage) error
 	requestHandlers        map[string]RequestHandler
+	defaultHandler         func(method string, params json.RawMessage) (json.RawMessage, *Error)
 	running                atomic.Bool
 	stopChan               chan struct{}
 	wg                     sync.WaitGroup
@@ -77,6 +120,9 @@ type Client struct {
 	processErrorPtr        *error        // points to the process error
 	processErrorMu         sync.RWMutex  // protects processErrorPtr
 	onClose                func()        // called when the read loop exits unexpectedly
+	onPanic                func(method string, value any, stack []byte)
+	mapError               func(*Error) error
+	logger                 *slog.Logger
 }
 
 // NewClient creates a new JSON-RPC client.
@@ -89,6 +135,7 @@ func NewClient(stdin io.WriteCloser, stdout io.ReadCloser) *Client {
 		pendingInlineCallbacks: make(map[string]func(json.RawMessage) error),
 		requestHandlers:        make(map[string]RequestHandler),
 		stopChan:               make(chan struct{}),
+		logger:                 slog.Default(),
 	}
 	c.writer <- newHeaderWriter(stdin)
 	return c
@@ -117,6 +164,12 @@ func (c *Client) getProcessError() error {
 	return nil
 }
 
+// ProcessDone returns the channel set by SetProcessDone, which is closed when
+// the process exits, or nil if none was set.
+func (c *Client) ProcessDone() <-chan struct{} {
+	return c.processDone
+}
+
 // Start begins listening for messages in a background goroutine
 func (c *Client) Start() {
 	c.running.Store(true)
@@ -202,6 +255,28 @@ func (c *Client) SetRequestHandler(method string, handler RequestHandler) {
 	c.requestHandlers[method] = handler
 }
 
+// SetDefaultHandler registers a handler for incoming requests and
+// notifications with no handler of their own, instead of answering calls
+// with a method-not-found error.
+func (c *Client) SetDefaultHandler(handler func(method string, params json.RawMessage) (json.RawMessage, *Error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultHandler = handler
+}
+
+// Notify sends a notification, a request without a response.
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return c.sendMessage(ctx, struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+	}{version, method, data})
+}
+
 // Request sends a JSON-RPC request and waits for the response
 func (c *Client) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
 	return c.RequestWithInlineResponse(ctx, method, params, nil)
@@ -248,9 +323,9 @@ func (c *Client) RequestWithInlineResponse(ctx context.Context, method string, p
 			return nil, ctx.Err()
 		case <-c.processDone:
 			if err := c.getProcessError(); err != nil {
-				return nil, err
+				return nil, NotSent(&processExitError{err})
 			}
-			return nil, fmt.Errorf("process exited unexpectedly")
+			return nil, NotSent(ErrProcessExited)
 		default:
 			// Process still running, continue
 		}
@@ -279,7 +354,7 @@ func (c *Client) RequestWithInlineResponse(ctx context.Context, method string, p
 		if ctxErr := ctx.Err(); ctxErr != nil {
 			return nil, ctxErr
 		}
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, NotSent(fmt.Errorf("failed to send request: %w", err))
 	}
 
 	// Wait for response, also checking for process exit
@@ -289,16 +364,16 @@ func (c *Client) RequestWithInlineResponse(ctx context.Context, method string, p
 			return nil, ctx.Err()
 		case response := <-responseChan:
 			if response.Error != nil {
-				return nil, response.Error
+				return nil, c.responseError(response.Error)
 			}
 			return response.Result, nil
 		case <-c.processDone:
 			if err := c.getProcessError(); err != nil {
-				return nil, err
+				return nil, &processExitError{err}
 			}
-			return nil, fmt.Errorf("process exited unexpectedly")
+			return nil, ErrProcessExited
 		case <-c.stopChan:
-			return nil, fmt.Errorf("client stopped")
+			return nil, ErrStopped
 		}
 	}
 	select {
@@ -306,11 +381,11 @@ func (c *Client) RequestWithInlineResponse(ctx context.Context, method string, p
 		return nil, ctx.Err()
 	case response := <-responseChan:
 		if response.Error != nil {
-			return nil, response.Error
+			return nil, c.responseError(response.Error)
 		}
 		return response.Result, nil
 	case <-c.stopChan:
-		return nil, fmt.Errorf("client stopped")
+		return nil, ErrStopped
 	}
 }
 
@@ -334,10 +409,11 @@ func (c *Client) sendMessage(ctx context.Context, message any) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-c.stopChan:
-		return fmt.Errorf("client stopped")
+		return ErrStopped
 	case w = <-c.writer:
 	}
 	defer func() { c.writer <- w }()
+	c.logMessage("send", data)
 	return w.Write(data)
 }
 
@@ -347,6 +423,40 @@ func (c *Client) SetOnClose(fn func()) {
 	c.onClose = fn
 }
 
+// SetLogger sets the logger for errors and, at debug level, every message
+// sent and received. It must be called before Start.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// SetErrorMapper sets a function that replaces the error responses returned
+// by Request, e.g. to classify them. It must be called before Start.
+func (c *Client) SetErrorMapper(fn func(*Error) error) {
+	c.mapError = fn
+}
+
+func (c *Client) responseError(err *Error) error {
+	if c.mapError == nil {
+		return err
+	}
+	return c.mapError(err)
+}
+
+// logMessage logs a raw message at debug level.
+func (c *Client) logMessage(direction string, data []byte) {
+	if !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	c.logger.Debug("jsonrpc "+direction, "message", string(data))
+}
+
+// SetOnPanic sets a callback invoked with the method, panic value and stack
+// trace when a request handler panics. The panic is still answered with an
+// internal error.
+func (c *Client) SetOnPanic(fn func(method string, value any, stack []byte)) {
+	c.onPanic = fn
+}
+
 // readLoop reads messages from the stream in a background goroutine.
 func (c *Client) readLoop() {
 	defer c.wg.Done()
@@ -362,17 +472,18 @@ func (c *Client) readLoop() {
 		// Read the next frame.
 		data, err := c.reader.Read()
 		if err != nil {
-			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, os.ErrClosed) && c.running.Load() {
-				fmt.Printf("Error reading message: %v\n", err)
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, os.ErrClosed) && !errors.Is(err, net.ErrClosed) && c.running.Load() {
+				c.logger.Error("failed to read JSON-RPC message", "error", err)
 			}
 			return
 		}
+		c.logMessage("receive", data)
 
 		// Decode using a single unmarshal into the combined wire format.
 		msg, err := decodeMessage(data)
 		if err != nil {
 			if c.running.Load() {
-				fmt.Printf("Error decoding message: %v\n", err)
+				c.logger.Error("failed to decode JSON-RPC message", "error", err)
 			}
 			continue
 		}
@@ -435,6 +546,12 @@ func (c *Client) handleRequest(request *Request) {
 
 	c.mu.Lock()
 	handler := c.requestHandlers[request.Method]
+	if handler == nil && c.defaultHandler != nil {
+		defaultHandler, method := c.defaultHandler, request.Method
+		handler = func(params json.RawMessage) (json.RawMessage, *Error) {
+			return defaultHandler(method, params)
+		}
+	}
 	c.mu.Unlock()
 
 	if handler == nil {
@@ -456,6 +573,9 @@ func (c *Client) handleRequest(request *Request) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
+				if c.onPanic != nil {
+					c.onPanic(request.Method, r, debug.Stack())
+				}
 				c.sendErrorResponse(ctx, request.ID, &Error{
 					Code:    ErrInternal.Code,
 					Message: fmt.Sprintf("request handler panic: %v", r),
@@ -479,7 +599,7 @@ func (c *Client) sendResponse(ctx context.Context, id json.RawMessage, result js
 		Result:  result,
 	}
 	if err := c.sendMessage(ctx, response); err != nil {
-		fmt.Printf("Failed to send JSON-RPC response: %v\n", err)
+		c.logger.Error("failed to send JSON-RPC response", "error", err)
 	}
 }
 
@@ -490,7 +610,7 @@ func (c *Client) sendErrorResponse(ctx context.Context, id json.RawMessage, rpcE
 		Error:   rpcErr,
 	}
 	if err := c.sendMessage(ctx, response); err != nil {
-		fmt.Printf("Failed to send JSON-RPC error response: %v\n", err)
+		c.logger.Error("failed to send JSON-RPC error response", "error", err)
 	}
 }
 
@@ -3,8 +3,12 @@ package jsonrpc2
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
+	"net"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -291,3 +295,75 @@ func waitForPendingRequest(t *testing.T, client *Client) {
 		}
 	}
 }
+
+func TestOnPanicCalledWhenRequestHandlerPanics(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := NewClient(serverConn, serverConn)
+	type report struct {
+		method string
+		value  any
+		stack  []byte
+	}
+	reports := make(chan report, 1)
+	server.SetOnPanic(func(method string, value any, stack []byte) {
+		reports <- report{method, value, stack}
+	})
+	server.SetRequestHandler("boom", func(json.RawMessage) (json.RawMessage, *Error) {
+		panic("kaboom")
+	})
+	server.Start()
+	defer server.Stop()
+
+	client := NewClient(clientConn, clientConn)
+	client.Start()
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.Request(ctx, "boom", nil)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || !strings.Contains(rpcErr.Message, "kaboom") {
+		t.Fatalf("expected an internal error response, got %v", err)
+	}
+	select {
+	case r := <-reports:
+		if r.method != "boom" || r.value != "kaboom" || !bytes.Contains(r.stack, []byte("TestOnPanicCalledWhenRequestHandlerPanics")) {
+			t.Errorf("unexpected report: %s %v\n%s", r.method, r.value, r.stack)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for onPanic")
+	}
+}
+
+func TestSetLoggerLogsMessagesAtDebug(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := NewClient(serverConn, serverConn)
+	server.SetRequestHandler("ping", func(json.RawMessage) (json.RawMessage, *Error) {
+		return json.RawMessage(`{"pong":true}`), nil
+	})
+	server.Start()
+	defer server.Stop()
+
+	var logs bytes.Buffer
+	client := NewClient(clientConn, clientConn)
+	client.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	client.Start()
+	defer client.Stop()
+
+	if _, err := client.Request(context.Background(), "ping", map[string]string{"token": "x"}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	out := logs.String()
+	if !strings.Contains(out, `msg="jsonrpc send"`) || !strings.Contains(out, `\"method\":\"ping\"`) {
+		t.Errorf("expected the request to be logged, got:\n%s", out)
+	}
+	if !strings.Contains(out, `msg="jsonrpc receive"`) || !strings.Contains(out, `\"pong\":true`) {
+		t.Errorf("expected the response to be logged, got:\n%s", out)
+	}
+}
@@ -0,0 +1,50 @@
+package copilot
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// InternalError describes a panic the SDK recovered from in application code,
+// such as a tool, permission or event handler. It is passed to
+// [ClientOptions.OnInternalError].
+type InternalError struct {
+	// SessionID is the session the panicking callback belongs to, if known.
+	SessionID string
+	// Source is the kind of callback that panicked: "tool",
+	// "permission handler", "event handler", "command", "turn hook" or
+	// "rpc handler".
+	Source string
+	// Name identifies the callback within Source: the tool or command name,
+	// the event type, or the JSON-RPC method.
+	Name string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *InternalError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("panic in %s: %v", e.Source, e.Value)
+	}
+	return fmt.Sprintf("panic in %s %s: %v", e.Source, e.Name, e.Value)
+}
+
+// reportInternalError passes err to ClientOptions.OnInternalError, if set.
+func (c *Client) reportInternalError(err *InternalError) {
+	if c == nil || c.options.OnInternalError == nil {
+		return
+	}
+	defer func() { recover() }() // Ignore handler panics
+	c.options.OnInternalError(err)
+}
+
+// recovered reports a panic recovered from a callback of s and returns it as
+// an error. It must be called from the deferred function that recovered, so
+// the stack trace shows where the panic happened.
+func (s *Session) recovered(source, name string, value any) *InternalError {
+	err := &InternalError{SessionID: s.SessionID, Source: source, Name: name, Value: value, Stack: debug.Stack()}
+	s.owner.reportInternalError(err)
+	return err
+}
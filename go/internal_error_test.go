@@ -0,0 +1,87 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_PanicIsolation(t *testing.T) {
+	responses := make(chan map[string]any, 4)
+	respond := func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+		var req map[string]any
+		json.Unmarshal(params, &req)
+		responses <- req
+		return []byte(`{"success":true}`), nil
+	}
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.tools.handlePendingToolCall":   respond,
+		"session.commands.handlePendingCommand": respond,
+	})
+	reports := make(chan *InternalError, 4)
+	client.options.OnInternalError = func(err *InternalError) { reports <- err }
+
+	session := newSession("s1", client.client, "")
+	session.owner = client
+	t.Cleanup(func() { close(session.done) })
+	session.registerTools([]Tool{{
+		Name:    "explode",
+		Handler: func(ToolInvocation) (ToolResult, error) { panic("tool blew up") },
+	}})
+	session.registerCommands([]CommandDefinition{{
+		Name:    "deploy",
+		Handler: func(CommandContext) error { panic("command blew up") },
+	}})
+	session.On(func(event SessionEvent) {
+		if _, ok := event.Data.(*SessionIdleData); ok {
+			panic("handler blew up")
+		}
+	})
+
+	expectReport := func(source, name, value string) {
+		t.Helper()
+		select {
+		case err := <-reports:
+			if err.SessionID != "s1" || err.Source != source || err.Name != name || err.Value != value {
+				t.Errorf("unexpected report: %+v", err)
+			}
+			if !bytes.Contains(err.Stack, []byte("TestSession_PanicIsolation")) {
+				t.Errorf("expected the stack to show the panicking handler:\n%s", err.Stack)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the %s panic", source)
+		}
+	}
+	expectResponse := func(message string) {
+		t.Helper()
+		select {
+		case req := <-responses:
+			if errMsg, _ := req["error"].(string); !strings.Contains(errMsg, message) {
+				t.Errorf("expected an error response containing %q, got %v", message, req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the response")
+		}
+	}
+
+	t.Run("tool panics fail the tool call", func(t *testing.T) {
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "explode"}})
+		expectReport("tool", "explode", "tool blew up")
+		expectResponse("tool blew up")
+	})
+
+	t.Run("command panics fail the command", func(t *testing.T) {
+		session.dispatchEvent(SessionEvent{Data: &CommandExecuteData{RequestID: "r2", CommandName: "deploy"}})
+		expectReport("command", "deploy", "command blew up")
+		expectResponse("command blew up")
+	})
+
+	t.Run("event handler panics are reported", func(t *testing.T) {
+		session.dispatchEvent(newTestEvent())
+		expectReport("event handler", string(SessionEventTypeSessionIdle), "handler blew up")
+	})
+}
@@ -0,0 +1,185 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// UnitSystem is the measurement system of a [LocaleContext].
+type UnitSystem string
+
+const (
+	UnitsMetric      UnitSystem = "metric"
+	UnitsImperial    UnitSystem = "imperial"
+	UnitsUSCustomary UnitSystem = "us_customary"
+)
+
+// LocaleContext tells the model the user's conventions, so that numbers,
+// dates, measurements and prices in its answers default to them. See
+// [SessionConfig.Locale] and [MessageOptions.Locale].
+//
+// Like [TimeContext], it is added to every prompt through the
+// userPromptSubmitted hook.
+type LocaleContext struct {
+	// Locale is a BCP 47 language tag, such as "de-DE" or "en-GB".
+	Locale string
+	// Units is the preferred measurement system.
+	Units UnitSystem
+	// Currency is an ISO 4217 currency code, such as "EUR".
+	Currency string
+}
+
+var localeTagPattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+func (l LocaleContext) validate() error {
+	if l.Locale != "" && !localeTagPattern.MatchString(l.Locale) {
+		return fmt.Errorf("invalid locale %q", l.Locale)
+	}
+	switch l.Units {
+	case "", UnitsMetric, UnitsImperial, UnitsUSCustomary:
+	default:
+		return fmt.Errorf("invalid unit system %q", l.Units)
+	}
+	if l.Currency != "" && !isCurrencyCode(l.Currency) {
+		return fmt.Errorf("invalid currency code %q", l.Currency)
+	}
+	return nil
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// merge returns l with the fields set in override replaced.
+func (l LocaleContext) merge(override LocaleContext) LocaleContext {
+	if override.Locale != "" {
+		l.Locale = override.Locale
+	}
+	if override.Units != "" {
+		l.Units = override.Units
+	}
+	if override.Currency != "" {
+		l.Currency = override.Currency
+	}
+	return l
+}
+
+// context returns the text to add to a prompt, or "" when nothing is set.
+func (l LocaleContext) context() string {
+	var sentences []string
+	if l.Locale != "" {
+		sentences = append(sentences, fmt.Sprintf("The user's locale is %s: format numbers, dates and times accordingly.", l.Locale))
+	}
+	switch l.Units {
+	case UnitsMetric:
+		sentences = append(sentences, "Use metric units.")
+	case UnitsImperial:
+		sentences = append(sentences, "Use imperial units.")
+	case UnitsUSCustomary:
+		sentences = append(sentences, "Use US customary units.")
+	}
+	if l.Currency != "" {
+		sentences = append(sentences, fmt.Sprintf("Give amounts of money in %s unless asked otherwise.", strings.ToUpper(l.Currency)))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// localeState holds a session's locale and the per-turn overrides of
+// messages that have been sent but not yet submitted to the model.
+type localeState struct {
+	base LocaleContext
+
+	mu      sync.Mutex
+	pending []pendingLocale
+}
+
+type pendingLocale struct {
+	prompt string
+	locale LocaleContext
+}
+
+func newLocaleState(config *LocaleContext) (*localeState, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &localeState{base: *config}, nil
+}
+
+// push records the locale override of a prompt being sent. The returned
+// function forgets it again, for sends that fail.
+func (l *localeState) push(prompt string, locale LocaleContext) func() {
+	entry := pendingLocale{prompt: prompt, locale: locale}
+	l.mu.Lock()
+	l.pending = append(l.pending, entry)
+	l.mu.Unlock()
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if i := slices.IndexFunc(l.pending, func(p pendingLocale) bool { return p == entry }); i >= 0 {
+			l.pending = slices.Delete(l.pending, i, i+1)
+		}
+	}
+}
+
+// forPrompt returns the locale for a submitted prompt, consuming its
+// override if one was sent with it.
+func (l *localeState) forPrompt(prompt string) LocaleContext {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i := slices.IndexFunc(l.pending, func(p pendingLocale) bool { return p.prompt == prompt }); i >= 0 {
+		override := l.pending[i].locale
+		l.pending = slices.Delete(l.pending, i, i+1)
+		return l.base.merge(override)
+	}
+	return l.base
+}
+
+// errLocaleNotEnabled is returned by [Session.Send] for a per-turn locale
+// in a session created without one.
+var errLocaleNotEnabled = errors.New("MessageOptions.Locale requires SessionConfig.Locale to be set")
+
+// addPromptContext appends the session's time, locale, output profile,
+// workspace and scratch directory context to the output of a
+// userPromptSubmitted hook.
+func (s *Session) addPromptContext(output *UserPromptSubmittedHookOutput, prompt string) *UserPromptSubmittedHookOutput {
+	if s.timeContext != nil {
+		output = appendPromptContext(output, s.timeContext.context())
+	}
+	if s.locale != nil {
+		if text := s.locale.forPrompt(prompt).context(); text != "" {
+			output = appendPromptContext(output, text)
+		}
+	}
+	if s.output != nil {
+		output = appendPromptContext(output, s.output.instruction)
+	}
+	if s.folders != nil {
+		output = appendPromptContext(output, s.folders.context())
+	}
+	if s.scratch != nil {
+		if text := s.scratch.context(); text != "" {
+			output = appendPromptContext(output, text)
+		}
+	}
+	return output
+}
+
+// hasPromptContext reports whether the SDK adds context to every prompt.
+func (s *Session) hasPromptContext() bool {
+	return s.timeContext != nil || s.locale != nil || s.output != nil || s.folders != nil || s.scratch != nil
+}
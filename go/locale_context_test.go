@@ -0,0 +1,88 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestLocaleContext(t *testing.T) {
+	promptSubmitted := func(t *testing.T, session *Session, prompt string) string {
+		t.Helper()
+		raw, _ := json.Marshal(map[string]any{"sessionId": "s1", "timestamp": 0, "cwd": "", "prompt": prompt})
+		output, err := session.handleHooksInvoke("userPromptSubmitted", raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out, _ := output.(*UserPromptSubmittedHookOutput)
+		if out == nil {
+			return ""
+		}
+		return out.AdditionalContext
+	}
+
+	t.Run("adds the session's conventions to every prompt", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.locale, _ = newLocaleState(&LocaleContext{Locale: "de-DE", Units: UnitsMetric, Currency: "eur"})
+
+		want := "The user's locale is de-DE: format numbers, dates and times accordingly. Use metric units. Give amounts of money in EUR unless asked otherwise."
+		for range 2 {
+			if got := promptSubmitted(t, session, "how far is it?"); got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		}
+	})
+
+	t.Run("applies a message's locale to its turn only", func(t *testing.T) {
+		fail := false
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				if fail {
+					return nil, &jsonrpc2.Error{Code: -32000, Message: "busy"}
+				}
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.locale, _ = newLocaleState(&LocaleContext{Locale: "en-US", Units: UnitsUSCustomary})
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "price?", Locale: &LocaleContext{Currency: "GBP"}}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		want := "The user's locale is en-US: format numbers, dates and times accordingly. Use US customary units. Give amounts of money in GBP unless asked otherwise."
+		if got := promptSubmitted(t, session, "price?"); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+		if got := promptSubmitted(t, session, "price?"); got != "The user's locale is en-US: format numbers, dates and times accordingly. Use US customary units." {
+			t.Errorf("expected the override to apply once, got %q", got)
+		}
+
+		fail = true
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "again", Locale: &LocaleContext{Locale: "fr-FR"}}); err == nil {
+			t.Fatal("expected the send to fail")
+		}
+		if len(session.locale.pending) != 0 {
+			t.Errorf("expected the failed send's locale to be forgotten, got %+v", session.locale.pending)
+		}
+	})
+
+	t.Run("rejects invalid locales", func(t *testing.T) {
+		for _, locale := range []LocaleContext{{Locale: "de_DE!"}, {Units: "furlongs"}, {Currency: "EURO"}} {
+			if _, err := newLocaleState(&locale); err == nil {
+				t.Errorf("expected %+v to be rejected", locale)
+			}
+		}
+	})
+
+	t.Run("requires a session locale for per-turn locales", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi", Locale: &LocaleContext{Locale: "ja-JP"}}); !errors.Is(err, errLocaleNotEnabled) {
+			t.Errorf("expected errLocaleNotEnabled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package copilot
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/internal/truncbuffer"
+)
+
+// logger returns the logger of the client: [ClientOptions.Logger], or
+// [slog.Default] when none is set.
+func (c *Client) logger() *slog.Logger {
+	if c != nil && c.options.Logger != nil {
+		return c.options.Logger
+	}
+	return slog.Default()
+}
+
+// logger returns the logger of the session's client, annotated with the
+// session ID.
+func (s *Session) logger() *slog.Logger {
+	return s.owner.logger().With("sessionId", s.SessionID)
+}
+
+// cliStderr captures the stderr of a spawned runtime. It keeps the tail for
+// error messages and logs each complete line at debug level, so that a host
+// logging at the default level does not receive the runtime's diagnostics.
+type cliStderr struct {
+	*truncbuffer.TruncBuffer
+
+	logger  *slog.Logger
+	mu      sync.Mutex
+	partial []byte
+}
+
+func newCLIStderr(logger *slog.Logger) *cliStderr {
+	return &cliStderr{TruncBuffer: truncbuffer.NewTruncBuffer(stderrBufferSize), logger: logger}
+}
+
+func (w *cliStderr) Write(p []byte) (int, error) {
+	w.TruncBuffer.Write(p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	// A runtime that never ends its line must not grow the buffer unbounded.
+	if len(w.partial) >= stderrBufferSize {
+		w.logLine(w.partial)
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+// flush logs a final line that was not terminated by a newline.
+func (w *cliStderr) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logLine(w.partial)
+	w.partial = nil
+}
+
+func (w *cliStderr) logLine(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+	w.logger.Debug("copilot runtime stderr", "line", string(line))
+}
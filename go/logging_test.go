@@ -0,0 +1,85 @@
+package copilot
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	newLogger := func(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		return slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})), &buf
+	}
+
+	t.Run("logs each runtime stderr line at debug level and keeps the tail", func(t *testing.T) {
+		logger, logs := newLogger(slog.LevelDebug)
+		stderr := newCLIStderr(logger)
+		stderr.Write([]byte("first line\r\nsecond "))
+		stderr.Write([]byte("line\n\nunterminated"))
+		if got := strings.Count(logs.String(), "copilot runtime stderr"); got != 2 {
+			t.Errorf("expected 2 complete lines to be logged, got %d:\n%s", got, logs)
+		}
+		stderr.flush()
+
+		out := logs.String()
+		for _, line := range []string{`line="first line"`, `line="second line"`, `line=unterminated`} {
+			if !strings.Contains(out, line) {
+				t.Errorf("expected %s in logs, got:\n%s", line, out)
+			}
+		}
+		if got := stderr.String(); got != "first line\r\nsecond line\n\nunterminated" {
+			t.Errorf("expected the raw output to be kept, got %q", got)
+		}
+
+		logger, logs = newLogger(slog.LevelInfo)
+		newCLIStderr(logger).Write([]byte("noise\n"))
+		if logs.Len() != 0 {
+			t.Errorf("expected nothing to be logged at info level, got:\n%s", logs)
+		}
+	})
+
+	t.Run("logs recovered handler panics with the session ID", func(t *testing.T) {
+		logger, logs := newLogger(slog.LevelInfo)
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.SessionID = "s1"
+		session.owner = NewClient(&ClientOptions{Logger: logger})
+		session.On(func(SessionEvent) { panic("boom") })
+
+		session.deliverEvent(newTestEvent())
+
+		out := logs.String()
+		if !strings.Contains(out, `level=ERROR msg="panic in session event handler"`) ||
+			!strings.Contains(out, "sessionId=s1") || !strings.Contains(out, "error=boom") {
+			t.Errorf("expected the panic to be logged, got:\n%s", out)
+		}
+	})
+
+	t.Run("logs lifecycle events and wire traffic at debug level", func(t *testing.T) {
+		logger, logs := newLogger(slog.LevelDebug)
+		client := startTestRuntimeWithOptions(t, &ClientOptions{Logger: logger}, nil)
+		if err := client.Stop(); err != nil {
+			t.Fatalf("Stop failed: %v", err)
+		}
+
+		out := logs.String()
+		for _, entry := range []string{`msg="jsonrpc send"`, `msg="jsonrpc receive"`, `msg="copilot client started"`, `msg="copilot client stopped"`} {
+			if !strings.Contains(out, entry) {
+				t.Errorf("expected %s in logs, got:\n%s", entry, out)
+			}
+		}
+	})
+
+	t.Run("logs nothing below the logger's level", func(t *testing.T) {
+		logger, logs := newLogger(slog.LevelInfo)
+		client := startTestRuntimeWithOptions(t, &ClientOptions{Logger: logger}, nil)
+		if err := client.Stop(); err != nil {
+			t.Fatalf("Stop failed: %v", err)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no logs at info level, got:\n%s", logs)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MessagePart is one part of a prompt composed of parts: a [TextPart],
+// [CodePart], [FilePart] or [ImagePart]. See [MessageOptions.Parts].
+type MessagePart interface {
+	messagePart()
+}
+
+// TextPart is prose in a prompt.
+type TextPart struct {
+	Text string
+}
+
+// CodePart is code in a prompt. It is sent as a fenced code block, fenced
+// so that backticks in the code cannot end it.
+type CodePart struct {
+	Code string
+	// Language is the code's language, such as "go", used as the fence's
+	// info string.
+	Language string
+}
+
+// FilePart refers to a file, or lines of one, in a prompt. The file is
+// attached to the message, for the runtime to read, and the prompt refers
+// to it by Path where the part is. A relative Path is resolved against the
+// session's [SessionConfig.WorkingDirectory], when set.
+type FilePart struct {
+	Path string
+	// StartLine and EndLine, if set, limit the reference to those lines,
+	// from 1 and inclusive. EndLine defaults to StartLine.
+	StartLine int
+	EndLine   int
+	// DisplayName is shown in the timeline. Defaults to the file name.
+	DisplayName string
+}
+
+// ImagePart is an image in a prompt. It is sent as image content, as in
+// [MessageOptions.Images].
+type ImagePart struct {
+	Image Image
+}
+
+func (TextPart) messagePart()  {}
+func (CodePart) messagePart()  {}
+func (FilePart) messagePart()  {}
+func (ImagePart) messagePart() {}
+
+// composeParts returns the prompt and attachments for options.Parts.
+func (s *Session) composeParts(ctx context.Context, parts []MessagePart) (string, []Attachment, error) {
+	var text []string
+	var attachments []Attachment
+	var images []Image
+	for i, part := range parts {
+		switch p := part.(type) {
+		case TextPart:
+			text = append(text, p.Text)
+		case CodePart:
+			text = append(text, codeBlock(p.Code, p.Language))
+		case FilePart:
+			attachment, reference, err := p.attachment(s.workingDirectory)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid part %d: %w", i, err)
+			}
+			text = append(text, reference)
+			attachments = append(attachments, attachment)
+		case ImagePart:
+			images = append(images, p.Image)
+		default:
+			return "", nil, fmt.Errorf("invalid part %d: unsupported type %T", i, part)
+		}
+	}
+	if len(images) > 0 {
+		blobs, err := s.imageAttachments(ctx, images)
+		if err != nil {
+			return "", nil, err
+		}
+		attachments = append(attachments, blobs...)
+	}
+	return strings.Join(text, "\n\n"), attachments, nil
+}
+
+// codeBlock fences code with more backticks than any run of them in it.
+func codeBlock(code, language string) string {
+	longest, run := 0, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + language + "\n" + strings.TrimSuffix(code, "\n") + "\n" + fence
+}
+
+// attachment returns the file attachment for the part and the text that
+// refers to it in the prompt. A relative Path is resolved against dir, when
+// set.
+func (p FilePart) attachment(dir string) (Attachment, string, error) {
+	if p.Path == "" {
+		return nil, "", errors.New("FilePart.Path is required")
+	}
+	path, err := filepath.Abs(resolvePath(dir, p.Path))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve %s: %w", p.Path, err)
+	}
+	attachment := &AttachmentFile{
+		Path:        path,
+		DisplayName: p.DisplayName,
+	}
+	if attachment.DisplayName == "" {
+		attachment.DisplayName = filepath.Base(path)
+	}
+	reference := "`" + p.Path + "`"
+	if p.StartLine > 0 {
+		end := p.EndLine
+		if end == 0 {
+			end = p.StartLine
+		}
+		if end < p.StartLine {
+			return nil, "", fmt.Errorf("FilePart.EndLine %d is before StartLine %d", end, p.StartLine)
+		}
+		attachment.LineRange = &AttachmentFileLineRange{Start: int64(p.StartLine), End: int64(end)}
+		if end == p.StartLine {
+			reference += fmt.Sprintf(" (line %d)", p.StartLine)
+		} else {
+			reference += fmt.Sprintf(" (lines %d-%d)", p.StartLine, end)
+		}
+	}
+	return attachment, reference, nil
+}
@@ -0,0 +1,110 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestCodeBlock(t *testing.T) {
+	cases := map[string]struct{ code, language, want string }{
+		"fences code":                 {"x := 1\n", "go", "```go\nx := 1\n```"},
+		"fences around backtick runs": {"s := ```", "", "````\ns := ```\n````"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := codeBlock(tc.code, tc.language); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSession_SendParts(t *testing.T) {
+	type sendRequest struct {
+		Prompt      string           `json:"prompt"`
+		Attachments []map[string]any `json:"attachments"`
+	}
+	requests := make(chan sendRequest, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req sendRequest
+			json.Unmarshal(params, &req)
+			requests <- req
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	t.Run("composes the prompt and attachments in order", func(t *testing.T) {
+		_, err := session.Send(t.Context(), MessageOptions{
+			Parts: []MessagePart{
+				TextPart{Text: "Why does this panic?"},
+				CodePart{Code: "var m map[string]int\nm[\"a\"] = 1", Language: "go"},
+				FilePart{Path: "main.go", StartLine: 10, EndLine: 20},
+				ImagePart{Image: Image{Data: testPNG}},
+				TextPart{Text: "The trace is in the screenshot."},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		req := <-requests
+		want := "Why does this panic?\n\n```go\nvar m map[string]int\nm[\"a\"] = 1\n```\n\n`main.go` (lines 10-20)\n\nThe trace is in the screenshot."
+		if req.Prompt != want {
+			t.Errorf("expected prompt %q, got %q", want, req.Prompt)
+		}
+		if len(req.Attachments) != 2 {
+			t.Fatalf("expected 2 attachments, got %+v", req.Attachments)
+		}
+		abs, _ := filepath.Abs("main.go")
+		file := req.Attachments[0]
+		if file["type"] != "file" || file["path"] != abs || file["displayName"] != "main.go" || file["lineRange"] == nil {
+			t.Errorf("unexpected file attachment %+v", file)
+		}
+		if req.Attachments[1]["type"] != "blob" {
+			t.Errorf("expected the image as a blob, got %+v", req.Attachments[1])
+		}
+	})
+
+	t.Run("resolves relative paths against the session's working directory", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "shot.png"), testPNG, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		session.workingDirectory = dir
+		defer func() { session.workingDirectory = "" }()
+		_, err := session.Send(t.Context(), MessageOptions{
+			Parts: []MessagePart{
+				FilePart{Path: "src/main.go"},
+				ImagePart{Image: Image{Path: "shot.png"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		req := <-requests
+		if req.Prompt != "`src/main.go`" {
+			t.Errorf("expected the prompt to keep the path as given, got %q", req.Prompt)
+		}
+		if len(req.Attachments) != 2 || req.Attachments[0]["path"] != filepath.Join(dir, "src", "main.go") || req.Attachments[1]["type"] != "blob" {
+			t.Errorf("unexpected attachments %+v", req.Attachments)
+		}
+	})
+
+	t.Run("rejects Prompt with Parts", func(t *testing.T) {
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi", Parts: []MessagePart{TextPart{Text: "hi"}}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("rejects invalid line ranges", func(t *testing.T) {
+		if _, err := session.Send(t.Context(), MessageOptions{Parts: []MessagePart{FilePart{Path: "a.go", StartLine: 5, EndLine: 2}}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
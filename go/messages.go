@@ -0,0 +1,192 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned by [Session.Messages] for a cursor that does
+// not belong to the session's history.
+var ErrInvalidCursor = errors.New("invalid message cursor")
+
+// MessageRole is the author of a [Message].
+type MessageRole string
+
+const (
+	MessageRoleUser      MessageRole = "user"
+	MessageRoleAssistant MessageRole = "assistant"
+)
+
+// Message is one message of a session's conversation, as returned by
+// [Session.Messages].
+type Message struct {
+	// ID is the ID of the event that recorded the message.
+	ID        string
+	Role      MessageRole
+	Content   string
+	Timestamp time.Time
+	// Attachments are the attachments of a user message.
+	Attachments []Attachment
+	// ToolCalls are the tools an assistant message called, with their
+	// results once they completed.
+	ToolCalls []ToolCallRecord
+	// Annotations are the annotations of the turn a user message started.
+	// See [Session.Annotate].
+	Annotations map[string]string
+}
+
+// Page selects a page of [Session.Messages].
+type Page struct {
+	// Cursor is the NextCursor of the previous page; empty for the first
+	// page.
+	Cursor string
+	// Limit is the maximum number of messages in the page. Defaults to 50.
+	Limit int
+}
+
+// MessagePage is a page of a session's conversation.
+type MessagePage struct {
+	// Messages are in chronological order.
+	Messages []Message
+	// NextCursor fetches the page of older messages; empty when this page
+	// reaches the start of the conversation.
+	NextCursor string
+}
+
+const defaultMessagePageSize = 50
+
+// Messages returns a page of the session's conversation as typed user and
+// assistant messages, so a UI can render past conversation after a resume
+// without replaying events. Sub-agent messages are left out.
+//
+// Pages run backwards from the most recent message: the first page holds
+// the last Limit messages, and each NextCursor fetches the ones before.
+// Messages within a page are in chronological order. The history is read
+// with [Session.GetEvents] on every call.
+//
+// Example:
+//
+//	page, err := session.Messages(ctx, copilot.Page{Limit: 20})
+//	for err == nil {
+//		for _, msg := range page.Messages {
+//			fmt.Printf("%s: %s\n", msg.Role, msg.Content)
+//		}
+//		if page.NextCursor == "" {
+//			break
+//		}
+//		page, err = session.Messages(ctx, copilot.Page{Cursor: page.NextCursor, Limit: 20})
+//	}
+func (s *Session) Messages(ctx context.Context, page Page) (*MessagePage, error) {
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := paginateMessages(collectMessages(events), page)
+	if err != nil {
+		return nil, err
+	}
+	s.annotateMessages(ctx, result.Messages)
+	return result, nil
+}
+
+// GetMessages returns the session's whole conversation as typed user and
+// assistant messages, with each assistant message's tool calls joined with
+// their results. It works the same for live and resumed sessions, because
+// the history is read from the runtime rather than recorded by the client.
+// Sub-agent messages are left out. Use [Session.Messages] to fetch long
+// histories a page at a time.
+//
+// Example:
+//
+//	messages, err := session.GetMessages(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, msg := range messages {
+//		fmt.Printf("%s: %s\n", msg.Role, msg.Content)
+//		for _, call := range msg.ToolCalls {
+//			fmt.Printf("  %s -> %s\n", call.ToolName, call.Result)
+//		}
+//	}
+func (s *Session) GetMessages(ctx context.Context) ([]Message, error) {
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	messages := collectMessages(events)
+	s.annotateMessages(ctx, messages)
+	return messages, nil
+}
+
+// collectMessages converts top-level user and assistant message events to
+// messages, joining assistant tool requests with their executions.
+func collectMessages(events []SessionEvent) []Message {
+	calls := make(map[string]ToolCallRecord)
+	for _, record := range CollectToolCalls(events) {
+		calls[record.ToolCallID] = record
+	}
+
+	var messages []Message
+	for _, event := range events {
+		switch d := event.Data.(type) {
+		case *UserMessageData:
+			if event.AgentID != nil {
+				continue
+			}
+			messages = append(messages, Message{
+				ID:          event.ID,
+				Role:        MessageRoleUser,
+				Content:     d.Content,
+				Timestamp:   event.Timestamp,
+				Attachments: d.Attachments,
+			})
+		case *AssistantMessageData:
+			if d.ParentToolCallID != nil || event.AgentID != nil {
+				continue
+			}
+			msg := Message{
+				ID:        event.ID,
+				Role:      MessageRoleAssistant,
+				Content:   d.Content,
+				Timestamp: event.Timestamp,
+			}
+			for _, req := range d.ToolRequests {
+				record, ok := calls[req.ToolCallID]
+				if !ok {
+					record = ToolCallRecord{ToolCallID: req.ToolCallID, ToolName: req.Name, Arguments: req.Arguments}
+				}
+				msg.ToolCalls = append(msg.ToolCalls, record)
+			}
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// paginateMessages returns the page of messages ending before the cursor.
+func paginateMessages(messages []Message, page Page) (*MessagePage, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultMessagePageSize
+	}
+	end := len(messages)
+	if page.Cursor != "" {
+		end = -1
+		for i, msg := range messages {
+			if msg.ID == page.Cursor {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, ErrInvalidCursor
+		}
+	}
+	start := max(end-limit, 0)
+	result := &MessagePage{Messages: messages[start:end]}
+	if start > 0 {
+		result.NextCursor = messages[start].ID
+	}
+	return result, nil
+}
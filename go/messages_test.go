@@ -0,0 +1,103 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+const testConversation = `{"events": [
+	{"id": "u1", "timestamp": "2026-01-01T00:00:00Z", "type": "user.message", "data": {"content": "list files"}},
+	{"id": "a1", "timestamp": "2026-01-01T00:00:01Z", "type": "assistant.message", "data": {"messageId": "m1", "content": "", "toolRequests": [{"toolCallId": "c1", "name": "ls", "arguments": {"path": "."}}]}},
+	{"id": "t1", "timestamp": "2026-01-01T00:00:02Z", "type": "tool.execution_start", "data": {"toolCallId": "c1", "toolName": "ls", "arguments": {"path": "."}}},
+	{"id": "t2", "timestamp": "2026-01-01T00:00:03Z", "type": "tool.execution_complete", "data": {"toolCallId": "c1", "success": true, "result": {"content": "go.mod"}}},
+	{"id": "s1", "timestamp": "2026-01-01T00:00:04Z", "agentId": "sub", "type": "user.message", "data": {"content": "sub-agent task"}},
+	{"id": "a2", "timestamp": "2026-01-01T00:00:05Z", "type": "assistant.message", "data": {"messageId": "m2", "content": "There is go.mod."}},
+	{"id": "u2", "timestamp": "2026-01-01T00:00:06Z", "type": "user.message", "data": {"content": "thanks"}},
+	{"id": "a3", "timestamp": "2026-01-01T00:00:07Z", "type": "assistant.message", "data": {"messageId": "m3", "content": "You're welcome."}}
+]}`
+
+func TestSession_Messages(t *testing.T) {
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(testConversation), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	ids := func(messages []Message) []string {
+		var result []string
+		for _, msg := range messages {
+			result = append(result, msg.ID)
+		}
+		return result
+	}
+
+	t.Run("pages backwards from the latest message", func(t *testing.T) {
+		page, err := session.Messages(t.Context(), Page{Limit: 2})
+		if err != nil {
+			t.Fatalf("Messages failed: %v", err)
+		}
+		if got := ids(page.Messages); len(got) != 2 || got[0] != "u2" || got[1] != "a3" || page.NextCursor != "u2" {
+			t.Fatalf("unexpected first page %v, cursor %q", got, page.NextCursor)
+		}
+
+		page, err = session.Messages(t.Context(), Page{Cursor: page.NextCursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("Messages failed: %v", err)
+		}
+		if got := ids(page.Messages); len(got) != 2 || got[0] != "a1" || got[1] != "a2" || page.NextCursor != "a1" {
+			t.Fatalf("unexpected second page %v, cursor %q", got, page.NextCursor)
+		}
+
+		page, err = session.Messages(t.Context(), Page{Cursor: page.NextCursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("Messages failed: %v", err)
+		}
+		if got := ids(page.Messages); len(got) != 1 || got[0] != "u1" || page.NextCursor != "" {
+			t.Fatalf("unexpected last page %v, cursor %q", got, page.NextCursor)
+		}
+	})
+
+	t.Run("types messages with roles and tool calls", func(t *testing.T) {
+		page, err := session.Messages(t.Context(), Page{})
+		if err != nil {
+			t.Fatalf("Messages failed: %v", err)
+		}
+		if len(page.Messages) != 5 {
+			t.Fatalf("expected 5 messages without the sub-agent's, got %v", ids(page.Messages))
+		}
+		user, call := page.Messages[0], page.Messages[1]
+		if user.Role != MessageRoleUser || user.Content != "list files" || user.Timestamp.IsZero() {
+			t.Errorf("unexpected user message %+v", user)
+		}
+		if call.Role != MessageRoleAssistant || len(call.ToolCalls) != 1 {
+			t.Fatalf("unexpected assistant message %+v", call)
+		}
+		if record := call.ToolCalls[0]; record.ToolName != "ls" || !record.Success || record.Result != "go.mod" {
+			t.Errorf("unexpected tool call %+v", record)
+		}
+	})
+
+	t.Run("returns the whole conversation", func(t *testing.T) {
+		messages, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("GetMessages failed: %v", err)
+		}
+		if got := ids(messages); len(got) != 5 || got[0] != "u1" || got[4] != "a3" {
+			t.Fatalf("unexpected messages %v", got)
+		}
+		if calls := messages[1].ToolCalls; len(calls) != 1 || calls[0].Result != "go.mod" {
+			t.Errorf("expected the tool call with its result, got %+v", calls)
+		}
+	})
+
+	t.Run("rejects an unknown cursor", func(t *testing.T) {
+		if _, err := session.Messages(t.Context(), Page{Cursor: "nope"}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
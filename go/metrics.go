@@ -0,0 +1,138 @@
+package copilot
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricsSink receives the SDK's operational metrics, for export to a
+// monitoring system such as Prometheus. See [ClientOptions.MetricsSink].
+//
+// Metric names follow Prometheus conventions and are listed as the Metric
+// constants. Labels have low cardinality: they never include session IDs,
+// prompts or arguments. Methods are called from the goroutines that observe
+// the measured events, so implementations must be safe for concurrent use
+// and must not block.
+//
+// Example:
+//
+//	type promSink struct {
+//	    counters   map[string]*prometheus.CounterVec
+//	    histograms map[string]*prometheus.HistogramVec
+//	}
+//
+//	func (p *promSink) IncrementCounter(name string, delta float64, labels map[string]string) {
+//	    p.counters[name].With(labels).Add(delta)
+//	}
+//
+//	func (p *promSink) ObserveHistogram(name string, value float64, labels map[string]string) {
+//	    p.histograms[name].With(labels).Observe(value)
+//	}
+type MetricsSink interface {
+	// IncrementCounter adds delta to the counter name.
+	IncrementCounter(name string, delta float64, labels map[string]string)
+	// ObserveHistogram records value in the histogram name.
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+
+// Metrics reported to a [MetricsSink].
+const (
+	// MetricTurnsStarted counts turns started. No labels.
+	MetricTurnsStarted = "copilot_turns_started_total"
+	// MetricTurnDuration observes the duration of each turn in seconds.
+	// Labels: outcome (a [TurnOutcome]).
+	MetricTurnDuration = "copilot_turn_duration_seconds"
+	// MetricToolCalls counts completed tool calls, including built-in and
+	// MCP tools. Labels: tool, outcome ("success" or "failure").
+	MetricToolCalls = "copilot_tool_calls_total"
+	// MetricToolDuration observes the duration of each tool call in
+	// seconds. Labels: tool.
+	MetricToolDuration = "copilot_tool_duration_seconds"
+	// MetricPermissionDenials counts denied permission requests. Labels:
+	// reason (the [PermissionResultKind]).
+	MetricPermissionDenials = "copilot_permission_denials_total"
+	// MetricReconnects counts reconnections to the runtime. Labels: outcome
+	// ("success", or "failure" when the client gave up).
+	MetricReconnects = "copilot_reconnects_total"
+	// MetricRetries counts retries of operations that failed with a
+	// transient error (see [ClientOptions.Retry]). Labels: operation
+	// ("send", "send and wait" or "create session").
+	MetricRetries = "copilot_retries_total"
+	// MetricCLIRestarts counts relaunches of the CLI after it crashed (see
+	// [ClientOptions.Restart]). Labels: outcome ("success", or "failure" when
+	// the client gave up).
+	MetricCLIRestarts = "copilot_cli_restarts_total"
+)
+
+// incrementCounter passes a counter increment to ClientOptions.MetricsSink,
+// if set.
+func (c *Client) incrementCounter(name string, delta float64, labels map[string]string) {
+	if c == nil || c.options.MetricsSink == nil {
+		return
+	}
+	defer func() { recover() }() // Ignore sink panics
+	c.options.MetricsSink.IncrementCounter(name, delta, labels)
+}
+
+// observeHistogram passes an observation to ClientOptions.MetricsSink, if
+// set.
+func (c *Client) observeHistogram(name string, value float64, labels map[string]string) {
+	if c == nil || c.options.MetricsSink == nil {
+		return
+	}
+	defer func() { recover() }() // Ignore sink panics
+	c.options.MetricsSink.ObserveHistogram(name, value, labels)
+}
+
+// metricsTracker pairs tool call start and completion events. It is only
+// accessed from the session's event consumer goroutine and needs no locking.
+type metricsTracker struct {
+	toolCalls map[string]toolCallStart
+}
+
+type toolCallStart struct {
+	name string
+	at   time.Time
+}
+
+// recordMetrics reports the metrics of event, and of the turn notifications
+// derived from it, to the client's sink.
+func (s *Session) recordMetrics(event SessionEvent, start *TurnStartInfo, complete *TurnCompleteInfo) {
+	owner := s.owner
+	if owner == nil || owner.options.MetricsSink == nil {
+		return
+	}
+	if start != nil {
+		owner.incrementCounter(MetricTurnsStarted, 1, nil)
+	}
+	if complete != nil {
+		owner.observeHistogram(MetricTurnDuration, complete.Duration.Seconds(), map[string]string{"outcome": string(complete.Outcome)})
+	}
+
+	switch d := event.Data.(type) {
+	case *ToolExecutionStartData:
+		if s.metrics.toolCalls == nil {
+			s.metrics.toolCalls = make(map[string]toolCallStart)
+		}
+		s.metrics.toolCalls[d.ToolCallID] = toolCallStart{name: d.ToolName, at: event.Timestamp}
+	case *ToolExecutionCompleteData:
+		call, ok := s.metrics.toolCalls[d.ToolCallID]
+		if !ok {
+			return
+		}
+		delete(s.metrics.toolCalls, d.ToolCallID)
+		outcome := "success"
+		if !d.Success {
+			outcome = "failure"
+		}
+		owner.incrementCounter(MetricToolCalls, 1, map[string]string{"tool": call.name, "outcome": outcome})
+		owner.observeHistogram(MetricToolDuration, event.Timestamp.Sub(call.at).Seconds(), map[string]string{"tool": call.name})
+	case *PermissionCompletedData:
+		if d.Result == nil {
+			return
+		}
+		if kind := d.Result.Kind(); strings.HasPrefix(string(kind), "denied") {
+			owner.incrementCounter(MetricPermissionDenials, 1, map[string]string{"reason": string(kind)})
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package copilot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (r *recordingSink) IncrementCounter(name string, delta float64, labels map[string]string) {
+	r.record(fmt.Sprintf("%s%s += %g", name, formatLabels(labels), delta))
+}
+
+func (r *recordingSink) ObserveHistogram(name string, value float64, labels map[string]string) {
+	r.record(fmt.Sprintf("%s%s = %g", name, formatLabels(labels), value))
+}
+
+func (r *recordingSink) record(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, entry)
+}
+
+// recordsOf returns the records of metric name, joined by newlines.
+func (r *recordingSink) recordsOf(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []string
+	for _, record := range r.records {
+		if strings.HasPrefix(record, name) {
+			matched = append(matched, record)
+		}
+	}
+	return strings.Join(matched, "\n")
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := "{"
+	for i, k := range keys {
+		if i > 0 {
+			out += ","
+		}
+		out += fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return out + "}"
+}
+
+func TestMetricsSink(t *testing.T) {
+	t.Run("records turn, tool and permission metrics from session events", func(t *testing.T) {
+		sink := &recordingSink{}
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.owner = NewClient(&ClientOptions{MetricsSink: sink})
+		idle := make(chan struct{})
+		session.On(func(event SessionEvent) {
+			if event.Type() == SessionEventTypeSessionIdle {
+				close(idle)
+			}
+		})
+
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) time.Time { return start.Add(d) }
+		session.dispatchEvent(SessionEvent{ID: "u1", Timestamp: at(0), Data: &UserMessageData{Content: "fix it"}})
+		session.dispatchEvent(SessionEvent{Timestamp: at(time.Second), Data: &ToolExecutionStartData{ToolCallID: "c1", ToolName: "bash"}})
+		session.dispatchEvent(SessionEvent{Timestamp: at(1500 * time.Millisecond), Data: &PermissionCompletedData{RequestID: "p1", Result: &PermissionDeniedInteractivelyByUser{}}})
+		session.dispatchEvent(SessionEvent{Timestamp: at(2 * time.Second), Data: &ToolExecutionCompleteData{ToolCallID: "c1", Success: false}})
+		session.dispatchEvent(SessionEvent{Timestamp: at(3 * time.Second), Data: &PermissionCompletedData{RequestID: "p2", Result: &PermissionApproved{}}})
+		session.dispatchEvent(SessionEvent{Timestamp: at(4 * time.Second), Data: &SessionIdleData{}})
+		<-idle
+
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		want := []string{
+			`copilot_turns_started_total += 1`,
+			`copilot_permission_denials_total{reason="denied-interactively-by-user"} += 1`,
+			`copilot_tool_calls_total{outcome="failure",tool="bash"} += 1`,
+			`copilot_tool_duration_seconds{tool="bash"} = 1`,
+			`copilot_turn_duration_seconds{outcome="completed"} = 4`,
+		}
+		if fmt.Sprint(sink.records) != fmt.Sprint(want) {
+			t.Errorf("unexpected metrics:\n got: %q\nwant: %q", sink.records, want)
+		}
+	})
+
+	t.Run("ignores sink panics", func(t *testing.T) {
+		client := NewClient(&ClientOptions{MetricsSink: panickingSink{}})
+		client.incrementCounter(MetricReconnects, 1, map[string]string{"outcome": "success"})
+		client.observeHistogram(MetricTurnDuration, 1, nil)
+	})
+}
+
+type panickingSink struct{}
+
+func (panickingSink) IncrementCounter(string, float64, map[string]string) { panic("boom") }
+func (panickingSink) ObserveHistogram(string, float64, map[string]string) { panic("boom") }
@@ -261,7 +261,7 @@ func (c *Client) updateSessionOptionsForMode(ctx context.Context, session *Sessi
 	if !hasAny {
 		return nil
 	}
-	if _, err := session.RPC.Options.Update(ctx, patch); err != nil {
+	if _, err := session.conn.Load().rpc.Options.Update(ctx, patch); err != nil {
 		// The runtime session exists but the post-create options patch
 		// failed — best-effort disconnect so we don't leak it (in empty
 		// mode it would otherwise keep running with permissive defaults).
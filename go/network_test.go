@@ -0,0 +1,183 @@
+package copilot
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// writeServerCA writes the certificate of a TLS test server to a PEM file.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNetworkConfig(t *testing.T) {
+	t.Run("configures the runtime environment", func(t *testing.T) {
+		client := NewClient(&ClientOptions{
+			Connection: InProcessConnection{},
+			Network: &NetworkConfig{
+				ProxyURL:   "http://proxy:3128",
+				NoProxy:    []string{"localhost", ".corp.example"},
+				CACertFile: "/etc/ssl/corp.pem",
+			},
+		})
+		env := client.inProcessHostConfig().Environment
+		want := map[string]string{
+			"HTTPS_PROXY":         "http://proxy:3128",
+			"HTTP_PROXY":          "http://proxy:3128",
+			"NO_PROXY":            "localhost,.corp.example",
+			"NODE_EXTRA_CA_CERTS": "/etc/ssl/corp.pem",
+		}
+		for key, value := range want {
+			if env[key] != value {
+				t.Errorf("expected %s=%s, got %q", key, value, env[key])
+			}
+		}
+	})
+
+	t.Run("trusts the configured certificate authorities", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer server.Close()
+
+		plain, err := (&NetworkConfig{}).HTTPClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := plain.Get(server.URL); err == nil {
+			t.Error("expected the test server's certificate to be rejected")
+		}
+
+		trusting, err := (&NetworkConfig{CACertFile: writeServerCA(t, server)}).HTTPClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := trusting.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected the certificate to be trusted: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("routes requests through the proxy except for NoProxy hosts", func(t *testing.T) {
+		proxy, err := (&NetworkConfig{ProxyURL: "http://proxy:3128", NoProxy: []string{".corp.example"}}).proxy()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for target, want := range map[string]string{
+			"https://api.github.com/":    "http://proxy:3128",
+			"https://git.corp.example/x": "",
+		} {
+			req, _ := http.NewRequest(http.MethodGet, target, nil)
+			got, err := proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil && want != "") || (got != nil && got.String() != want) {
+				t.Errorf("%s: expected proxy %q, got %v", target, want, got)
+			}
+		}
+	})
+
+	t.Run("builds the SDK's client once", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Connection: InProcessConnection{}, Network: &NetworkConfig{}})
+		first, err := client.httpClient(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second, _ := client.httpClient(nil); second != first {
+			t.Error("expected the client to be reused")
+		}
+	})
+
+	t.Run("rejects an invalid proxy URL", func(t *testing.T) {
+		if _, err := (&NetworkConfig{ProxyURL: "not a url"}).HTTPClient(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := []string{"localhost", ".corp.example", "10.0.0.0/8"}
+	cases := map[string]bool{
+		"localhost":        true,
+		"corp.example":     true,
+		"git.corp.example": true,
+		"notcorp.example":  false,
+		"10.1.2.3":         true,
+		"192.168.1.1":      false,
+		"github.com":       false,
+	}
+	for host, want := range cases {
+		if got := bypassProxy(host, noProxy); got != want {
+			t.Errorf("bypassProxy(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestClient_TestConnectivity(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tlsServer.Close()
+
+	var mu sync.Mutex
+	var proxied []string
+	// The proxy refuses tunnels, so no probe leaves the machine, and answers
+	// plain HTTP requests itself.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.Host)
+		mu.Unlock()
+		if r.Method == http.MethodConnect {
+			http.Error(w, "blocked", http.StatusForbidden)
+		}
+	}))
+	defer proxy.Close()
+
+	client := NewClient(&ClientOptions{
+		Network: &NetworkConfig{
+			ProxyURL:   proxy.URL,
+			NoProxy:    []string{"127.0.0.1"},
+			CACertFile: writeServerCA(t, tlsServer),
+		},
+	})
+	checks, err := client.TestConnectivity(t.Context(), "http://mcp.internal/mcp", tlsServer.URL)
+	if err != nil {
+		t.Fatalf("TestConnectivity failed: %v", err)
+	}
+	if len(checks) != 6 {
+		t.Fatalf("expected 6 checks, got %+v", checks)
+	}
+	for _, check := range checks[:4] {
+		if check.Reachable || check.Err == nil {
+			t.Errorf("expected %s to be blocked by the proxy, got %+v", check.Name, check)
+		}
+	}
+	if checks[3].Name != "npm registry" || checks[3].URL != defaultNPMRegistryURL {
+		t.Errorf("unexpected registry check: %+v", checks[3])
+	}
+	if !checks[4].Reachable || checks[4].StatusCode != http.StatusOK {
+		t.Errorf("expected the MCP server to be reachable through the proxy, got %+v", checks[4])
+	}
+	if !checks[5].Reachable || checks[5].StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the bypassed server to be reachable, got %+v", checks[5])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, host := range proxied {
+		if host == tlsServer.Listener.Addr().String() {
+			t.Error("expected NoProxy hosts to bypass the proxy")
+		}
+	}
+}
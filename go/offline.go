@@ -0,0 +1,51 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOffline matches the [OfflineError] returned when a client with
+// [ClientOptions.Offline] set is asked for a feature that needs network
+// access.
+var ErrOffline = errors.New("client is offline")
+
+// OfflineError reports a feature that cannot work because the client is in
+// offline mode. It matches [ErrOffline] with [errors.Is].
+type OfflineError struct {
+	// Feature names what needs network access, e.g. "cloud sessions".
+	Feature string
+}
+
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("%s requires network access, but ClientOptions.Offline is set", e.Feature)
+}
+
+func (e *OfflineError) Is(target error) bool {
+	return target == ErrOffline
+}
+
+// requireOnline returns an [OfflineError] for feature if the client is
+// offline.
+func (c *Client) requireOnline(feature string) error {
+	if !c.options.Offline {
+		return nil
+	}
+	return &OfflineError{Feature: feature}
+}
+
+// checkOfflineSession rejects session configurations that need GitHub when
+// the client is offline: cloud sessions, and sessions without a custom
+// provider (byok) to serve the model.
+func (c *Client) checkOfflineSession(byok, cloud bool) error {
+	if !c.options.Offline {
+		return nil
+	}
+	if cloud {
+		return &OfflineError{Feature: "cloud sessions"}
+	}
+	if !byok && c.options.RequestHandler == nil {
+		return &OfflineError{Feature: "GitHub Copilot models (set SessionConfig.Provider)"}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestClient_Offline(t *testing.T) {
+	t.Run("does not log in to GitHub", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Connection: InProcessConnection{}, Offline: true})
+		if args := client.inProcessHostConfig().Args; !slices.Contains(args, "--no-auto-login") {
+			t.Errorf("expected --no-auto-login, got %v", args)
+		}
+	})
+
+	t.Run("rejects sessions that need GitHub before starting the runtime", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Offline: true})
+		configs := map[string]*SessionConfig{
+			"no provider": {OnPermissionRequest: PermissionHandler.ApproveAll},
+			"cloud": {
+				OnPermissionRequest: PermissionHandler.ApproveAll,
+				Provider:            &ProviderConfig{BaseURL: "http://localhost:11434/v1"},
+				Cloud:               &CloudSessionOptions{},
+			},
+		}
+		for name, config := range configs {
+			_, err := client.CreateSession(t.Context(), config)
+			var offline *OfflineError
+			if !errors.Is(err, ErrOffline) || !errors.As(err, &offline) {
+				t.Errorf("%s: expected an OfflineError, got %v", name, err)
+			}
+		}
+		if _, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll}); !errors.Is(err, ErrOffline) {
+			t.Errorf("resume: expected ErrOffline, got %v", err)
+		}
+		if client.process != nil {
+			t.Error("expected the runtime not to be started")
+		}
+	})
+
+	t.Run("rejects remote sessions on start", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Offline: true, EnableRemoteSessions: true})
+		if err := client.Start(t.Context()); !errors.Is(err, ErrOffline) {
+			t.Errorf("expected ErrOffline, got %v", err)
+		}
+	})
+
+	t.Run("lists models only through OnListModels", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Offline: true})
+		if _, err := client.ListModels(t.Context()); !errors.Is(err, ErrOffline) {
+			t.Errorf("expected ErrOffline, got %v", err)
+		}
+
+		client = NewClient(&ClientOptions{
+			Offline: true,
+			OnListModels: func(context.Context) ([]ModelInfo, error) {
+				return []ModelInfo{{ID: "llama3"}}, nil
+			},
+		})
+		models, err := client.ListModels(t.Context())
+		if err != nil || len(models) != 1 || models[0].ID != "llama3" {
+			t.Errorf("got %v, %v", models, err)
+		}
+	})
+
+	t.Run("does not check for CLI updates", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Offline: true})
+		if _, err := client.CheckForCLIUpdate(t.Context()); !errors.Is(err, ErrOffline) {
+			t.Errorf("expected ErrOffline, got %v", err)
+		}
+	})
+}
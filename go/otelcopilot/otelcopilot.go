@@ -0,0 +1,43 @@
+// Package otelcopilot propagates OpenTelemetry trace context between the
+// Copilot SDK and the runtime.
+//
+// Example:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    Telemetry:       &copilot.TelemetryConfig{OTLPEndpoint: "http://localhost:4318"},
+//	    TracePropagator: otelcopilot.Propagator{},
+//	})
+package otelcopilot
+
+import (
+	"context"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator is a [copilot.TracePropagator] that uses the global
+// OpenTelemetry propagator, so it follows otel.SetTextMapPropagator.
+type Propagator struct{}
+
+var _ copilot.TracePropagator = Propagator{}
+
+// Inject returns the W3C Trace Context of the span in ctx.
+func (Propagator) Inject(ctx context.Context) (traceparent, tracestate string) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier.Get("traceparent"), carrier.Get("tracestate")
+}
+
+// Extract returns ctx with the span described by traceparent and
+// tracestate as its remote parent.
+func (Propagator) Extract(ctx context.Context, traceparent, tracestate string) context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": traceparent,
+	}
+	if tracestate != "" {
+		carrier["tracestate"] = tracestate
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
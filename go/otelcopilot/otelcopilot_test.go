@@ -0,0 +1,45 @@
+package otelcopilot
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagator(t *testing.T) {
+	t.Run("injects nothing without a global propagator", func(t *testing.T) {
+		tp, ts := Propagator{}.Inject(context.Background())
+		if tp != "" || ts != "" {
+			t.Errorf("expected empty trace context, got traceparent=%q tracestate=%q", tp, ts)
+		}
+	})
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("extracts the remote span", func(t *testing.T) {
+		ctx := Propagator{}.Extract(context.Background(), traceparent, "")
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			t.Fatal("expected a valid span context")
+		}
+		if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("unexpected trace ID: %s", sc.TraceID())
+		}
+		if sc.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("unexpected span ID: %s", sc.SpanID())
+		}
+	})
+
+	t.Run("round-trips the trace context", func(t *testing.T) {
+		ctx := Propagator{}.Extract(context.Background(), traceparent, "vendor=value")
+		tp, ts := Propagator{}.Inject(ctx)
+		if tp != traceparent || ts != "vendor=value" {
+			t.Errorf("unexpected trace context: traceparent=%q tracestate=%q", tp, ts)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package copilot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// OutputProfile is the formatting a session's answers must fit, for channels
+// that cannot render Markdown. See [SessionConfig.OutputProfile].
+//
+// Models answer in Markdown, with emoji, which arrives mangled in SMS, voice
+// and Slack. An output profile tells the model the channel's formatting
+// constraints with every prompt, and converts what it writes anyway: the SDK
+// rewrites the content of assistant.message events, and of streamed deltas
+// when they are segmented, before delivering them.
+type OutputProfile string
+
+const (
+	// OutputProfileMarkdown asks for Markdown and leaves answers unchanged.
+	OutputProfileMarkdown OutputProfile = "markdown"
+	// OutputProfilePlainText asks for plain text without emoji. Markdown in
+	// answers is reduced to its text: headings, emphasis, code fences and
+	// quotes are removed, bullets become "- ", links become "text (url)"
+	// and images their alt text. Emoji are removed.
+	OutputProfilePlainText OutputProfile = "plaintext"
+	// OutputProfileSlack asks for Slack's mrkdwn. Markdown in answers is
+	// converted to it: **bold** becomes *bold*, *italic* _italic_, ~~struck~~
+	// ~struck~, headings bold lines, bullets "• ", and links and images
+	// <url|text>. "&", "<" and ">" are escaped as Slack requires.
+	OutputProfileSlack OutputProfile = "slack"
+)
+
+// outputFormatter applies an [OutputProfile] to a session.
+type outputFormatter struct {
+	profile     OutputProfile
+	instruction string
+}
+
+// newOutputFormatter returns the formatter for profile, or nil when answers
+// are delivered as the model writes them.
+func newOutputFormatter(profile OutputProfile) (*outputFormatter, error) {
+	f := &outputFormatter{profile: profile}
+	switch profile {
+	case "":
+		return nil, nil
+	case OutputProfileMarkdown:
+		f.instruction = "Your answers are rendered as GitHub-flavored Markdown."
+	case OutputProfilePlainText:
+		f.instruction = "Your answers are shown as plain text that cannot render Markdown: " +
+			"do not use headings, bold or italic text, tables, code fences or emoji. " +
+			"Write lists as lines starting with \"- \" and give links as bare URLs."
+	case OutputProfileSlack:
+		f.instruction = "Your answers are posted to Slack, which uses its own mrkdwn format instead of Markdown: " +
+			"write *bold*, _italic_, ~strikethrough~, `code`, ``` code blocks without a language and <https://example.com|links>, " +
+			"and do not use headings or tables."
+	default:
+		return nil, fmt.Errorf("invalid OutputProfile %q", profile)
+	}
+	return f, nil
+}
+
+// attachOutputProfile makes the session format answers with f, including
+// segmented deltas.
+func (s *Session) attachOutputProfile(f *outputFormatter) {
+	s.output = f
+	if s.segmenter != nil {
+		s.segmenter.output = f
+	}
+}
+
+// converter returns a converter for one message, or nil if the profile does
+// not change answers.
+func (f *outputFormatter) converter() *outputConverter {
+	if f == nil || f.profile == OutputProfileMarkdown {
+		return nil
+	}
+	return &outputConverter{profile: f.profile}
+}
+
+// formatMessage returns event with its content converted if it is an
+// assistant message.
+func (f *outputFormatter) formatMessage(event SessionEvent) SessionEvent {
+	d, ok := event.Data.(*AssistantMessageData)
+	if !ok {
+		return event
+	}
+	c := f.converter()
+	if c == nil {
+		return event
+	}
+	converted := *d
+	converted.Content = c.convert(d.Content)
+	event.Data = &converted
+	return event
+}
+
+// outputConverter converts the text of one message, which may arrive in
+// pieces: it remembers whether the last piece ended inside a code block or
+// in the middle of a line.
+type outputConverter struct {
+	profile OutputProfile
+	inFence bool
+	midLine bool
+}
+
+func (c *outputConverter) convert(text string) string {
+	var b strings.Builder
+	for text != "" {
+		line, rest, newline := strings.Cut(text, "\n")
+		b.WriteString(c.line(line, !c.midLine))
+		if newline {
+			b.WriteByte('\n')
+			c.midLine = false
+		} else {
+			c.midLine = true
+		}
+		text = rest
+	}
+	return b.String()
+}
+
+var (
+	fencePattern          = regexp.MustCompile("^ {0,3}(```|~~~)")
+	headingPattern        = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	ruleLinePattern       = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	quotePattern          = regexp.MustCompile(`^ {0,3}>\s?(.*)$`)
+	bulletPattern         = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	tableSeparatorPattern = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	codeSpanPattern       = regexp.MustCompile("`[^`]+`")
+	linkPattern           = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)|<(https?://[^>\s]+)>`)
+	boldPattern           = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	italicPattern         = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	underscorePattern     = regexp.MustCompile(`(^|\W)_(\S(?:[^_]*?\S)?)_(\W|$)`)
+	strikePattern         = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+)
+
+// line converts one line, or the rest of one when start is false.
+func (c *outputConverter) line(line string, start bool) string {
+	if start && fencePattern.MatchString(line) {
+		c.inFence = !c.inFence
+		if c.profile == OutputProfileSlack {
+			return "```"
+		}
+		return ""
+	}
+	if c.inFence {
+		if c.profile == OutputProfileSlack {
+			return slackEscape(line)
+		}
+		return line
+	}
+	if start {
+		if ruleLinePattern.MatchString(line) || tableSeparatorPattern.MatchString(line) {
+			return ""
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			if c.profile == OutputProfileSlack {
+				return "*" + c.inline(m[1]) + "*"
+			}
+			return c.inline(m[1])
+		}
+		if m := quotePattern.FindStringSubmatch(line); m != nil {
+			if c.profile == OutputProfileSlack {
+				return "> " + c.inline(m[1])
+			}
+			return c.inline(m[1])
+		}
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			if c.profile == OutputProfileSlack {
+				return m[1] + "• " + c.inline(m[2])
+			}
+			return m[1] + "- " + c.inline(m[2])
+		}
+	}
+	return c.inline(line)
+}
+
+// inline converts the spans of text and code in a line.
+func (c *outputConverter) inline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpanPattern.FindAllStringIndex(line, -1) {
+		b.WriteString(c.text(line[last:m[0]]))
+		if code := line[m[0]:m[1]]; c.profile == OutputProfileSlack {
+			b.WriteString(slackEscape(code))
+		} else {
+			b.WriteString(code[1 : len(code)-1])
+		}
+		last = m[1]
+	}
+	b.WriteString(c.text(line[last:]))
+	return b.String()
+}
+
+// text converts text outside code.
+func (c *outputConverter) text(text string) string {
+	if c.profile == OutputProfileSlack {
+		var b strings.Builder
+		last := 0
+		for _, m := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+			b.WriteString(slackEmphasis(slackEscape(text[last:m[0]])))
+			if m[8] >= 0 {
+				b.WriteString("<" + text[m[8]:m[9]] + ">")
+			} else if label := text[m[4]:m[5]]; label == "" {
+				b.WriteString("<" + text[m[6]:m[7]] + ">")
+			} else {
+				b.WriteString("<" + text[m[6]:m[7]] + "|" + slackEscape(label) + ">")
+			}
+			last = m[1]
+		}
+		b.WriteString(slackEmphasis(slackEscape(text[last:])))
+		return b.String()
+	}
+
+	text = linkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := linkPattern.FindStringSubmatch(link)
+		switch {
+		case m[4] != "":
+			return m[4]
+		case m[1] == "!" || m[2] == m[3]:
+			return m[2]
+		case m[2] == "":
+			return m[3]
+		default:
+			return m[2] + " (" + m[3] + ")"
+		}
+	})
+	text = boldPattern.ReplaceAllString(text, "$1$2")
+	text = strikePattern.ReplaceAllString(text, "$1")
+	text = italicPattern.ReplaceAllString(text, "$1")
+	text = underscorePattern.ReplaceAllString(text, "$1$2$3")
+	return stripEmoji(text)
+}
+
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackEmphasis converts Markdown emphasis to mrkdwn. Bold is converted
+// last, so the asterisks it leaves are not taken for italics.
+func slackEmphasis(text string) string {
+	text = boldPattern.ReplaceAllString(text, "\x00$1$2\x00")
+	text = italicPattern.ReplaceAllString(text, "_${1}_")
+	text = strikePattern.ReplaceAllString(text, "~${1}~")
+	return strings.ReplaceAll(text, "\x00", "*")
+}
+
+// stripEmoji removes emoji, and the space that would be left before
+// punctuation or double up where one was removed.
+func stripEmoji(text string) string {
+	out := make([]rune, 0, len(text))
+	stripped := false
+	for _, r := range text {
+		if isEmoji(r) {
+			stripped = true
+			continue
+		}
+		if stripped {
+			last := len(out) - 1
+			if r == ' ' && (last < 0 || out[last] == ' ') {
+				continue
+			}
+			if unicode.IsPunct(r) && last >= 0 && out[last] == ' ' {
+				out = out[:last]
+			}
+		}
+		stripped = false
+		out = append(out, r)
+	}
+	if stripped {
+		return strings.TrimRight(string(out), " ")
+	}
+	return string(out)
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, flags
+	case r >= 0x2600 && r <= 0x27BF: // symbols and dingbats
+	case r == 0x2B50 || r == 0x2B55: // star, circle
+	case r == 0x200D || r == 0xFE0F || r == 0x20E3: // joiner, variation selector, keycap
+	case r >= 0xE0020 && r <= 0xE007F: // tags of subdivision flags
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package copilot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputProfile(t *testing.T) {
+	answer := strings.Join([]string{
+		"## Summary 🎉",
+		"",
+		"The **build** is *fixed* and ~~broken~~ tests pass ✅. See [the PR](https://github.com/o/r/pull/1) and `go test`.",
+		"",
+		"* first_step",
+		"> quoted",
+		"---",
+		"```go",
+		"x := a*b*c // <ok>",
+		"```",
+	}, "\n")
+
+	t.Run("reduces Markdown to plain text", func(t *testing.T) {
+		got := (&outputConverter{profile: OutputProfilePlainText}).convert(answer)
+		want := strings.Join([]string{
+			"Summary",
+			"",
+			"The build is fixed and broken tests pass. See the PR (https://github.com/o/r/pull/1) and go test.",
+			"",
+			"- first_step",
+			"quoted",
+			"",
+			"",
+			"x := a*b*c // <ok>",
+			"",
+		}, "\n")
+		if got != want {
+			t.Errorf("expected\n%s\ngot\n%s", want, got)
+		}
+	})
+
+	t.Run("converts Markdown to Slack mrkdwn", func(t *testing.T) {
+		got := (&outputConverter{profile: OutputProfileSlack}).convert(answer)
+		want := strings.Join([]string{
+			"*Summary 🎉*",
+			"",
+			"The *build* is _fixed_ and ~broken~ tests pass ✅. See <https://github.com/o/r/pull/1|the PR> and `go test`.",
+			"",
+			"• first_step",
+			"> quoted",
+			"",
+			"```",
+			"x := a*b*c // &lt;ok&gt;",
+			"```",
+		}, "\n")
+		if got != want {
+			t.Errorf("expected\n%s\ngot\n%s", want, got)
+		}
+	})
+
+	t.Run("keeps code block state across pieces", func(t *testing.T) {
+		c := &outputConverter{profile: OutputProfilePlainText}
+		var b strings.Builder
+		for _, piece := range []string{"Run:\n", "```\n", "**not bold** ", "here\n", "```\n", "**bold**"} {
+			b.WriteString(c.convert(piece))
+		}
+		if want := "Run:\n\n**not bold** here\n\nbold"; b.String() != want {
+			t.Errorf("expected %q, got %q", want, b.String())
+		}
+	})
+
+	t.Run("rejects unknown profiles", func(t *testing.T) {
+		if _, err := newOutputFormatter("html"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("converts messages and segmented deltas", func(t *testing.T) {
+		output, err := newOutputFormatter(OutputProfilePlainText)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segmenter, err := newDeltaSegmenter(DeltaSegmentationSentence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session := newSession("s1", nil, "")
+		t.Cleanup(func() { close(session.done) })
+		session.segmenter = segmenter
+		session.attachOutputProfile(output)
+
+		var deltas []string
+		var content string
+		for _, event := range []SessionEvent{
+			{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: "It **works**. "}},
+			{Data: &AssistantMessageDeltaData{MessageID: "m1", DeltaContent: "Great 🚀"}},
+			{Data: &AssistantMessageData{MessageID: "m1", Content: "It **works**. Great 🚀"}},
+		} {
+			for _, segmented := range segmenter.segment(event) {
+				switch d := session.output.formatMessage(segmented).Data.(type) {
+				case *AssistantMessageDeltaData:
+					deltas = append(deltas, d.DeltaContent)
+				case *AssistantMessageData:
+					content = d.Content
+				}
+			}
+		}
+		if strings.Join(deltas, "") != "It works. Great" || content != "It works. Great" {
+			t.Errorf("unexpected deltas %q and content %q", deltas, content)
+		}
+
+		hook := session.addPromptContext(nil, "hello")
+		if hook == nil || !strings.Contains(hook.AdditionalContext, "plain text") {
+			t.Errorf("expected the profile's instruction in the prompt context, got %+v", hook)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type PathDeniedData = rpc.PathDeniedData
+
+const SessionEventTypePathDenied = rpc.SessionEventTypePathDenied
+
+// pathGuard enforces [SessionConfig.AllowedPaths] and
+// [SessionConfig.DeniedPaths] for one session.
+//
+// The runtime asks for permission before reading or writing a file and lists
+// the paths a shell command may touch in its permission request. The guard
+// resolves the requested paths, including "..", "." and symbolic links, and
+// rejects requests for paths outside the policy before the session's
+// permission handler sees them, so a buggy handler cannot approve them.
+// Handler approvals for the session or a location are narrowed to the one
+// request, as the runtime would stop asking about the requests they cover.
+// Accesses the runtime does not ask about, such as reads in the working
+// directory, are not checked.
+type pathGuard struct {
+	// dir resolves relative paths: the session's working directory, or the
+	// current process's when it has none.
+	dir     string
+	allowed []string
+	denied  []string
+	session *Session
+}
+
+func newPathGuard(allowed, denied []string, workingDirectory string) (*pathGuard, error) {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil, nil
+	}
+	g := &pathGuard{dir: workingDirectory}
+	for _, roots := range []struct {
+		paths []string
+		into  *[]string
+	}{{allowed, &g.allowed}, {denied, &g.denied}} {
+		for _, root := range roots.paths {
+			if root == "" {
+				return nil, errors.New("invalid path policy: empty path")
+			}
+			resolved, err := g.resolve(root)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path policy path %q: %w", root, err)
+			}
+			*roots.into = append(*roots.into, resolved)
+		}
+	}
+	return g, nil
+}
+
+// checkShell returns an error if the session runs shell commands in the
+// SDK-hosted shell, as its permission requests name no paths the policy
+// could check.
+func (g *pathGuard) checkShell(shell *ptyShell) error {
+	if g == nil || shell == nil {
+		return nil
+	}
+	return errors.New("AllowedPaths and DeniedPaths cannot be combined with the SDK-hosted shell (PTYShell or EgressPolicy.ProxyShellCommands), as its commands are not checked against the path policy")
+}
+
+// attachPaths connects guard to the session so it can enforce the policy and
+// report violations.
+func (s *Session) attachPaths(guard *pathGuard) {
+	if guard == nil {
+		return
+	}
+	guard.session = s
+	s.paths = guard
+}
+
+// resolve returns path as an absolute path without "..", "." or symbolic
+// links, relative to the working directory.
+func (g *pathGuard) resolve(path string) (string, error) {
+	return evalPath(g.dir, path)
+}
+
+// evalPath returns path as an absolute path without "..", "." or
+// symbolic links, resolving a relative path against dir, or the current
+// directory if dir is empty. Components are resolved in order, as the OS
+// does, so "link/.." leaves the directory the link points to. Paths that
+// do not exist yet resolve too; a dangling link does not.
+func evalPath(dir, path string) (string, error) {
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", err
+		}
+		path = dir + string(filepath.Separator) + path
+	}
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+	for _, part := range strings.Split(path[len(volume):], string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+		case "..":
+			resolved = filepath.Dir(resolved)
+		default:
+			next := filepath.Join(resolved, part)
+			if info, err := os.Lstat(next); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if next, err = filepath.EvalSymlinks(next); err != nil {
+					return "", err
+				}
+			}
+			resolved = next
+		}
+	}
+	return resolved, nil
+}
+
+// check returns the resolved path and why it may not be accessed, or "" if
+// it may.
+func (g *pathGuard) check(path string) (string, string) {
+	resolved, err := g.resolve(path)
+	if err != nil {
+		return path, fmt.Sprintf("%s cannot be resolved: %v", path, err)
+	}
+	for _, root := range g.denied {
+		if withinPath(root, resolved) {
+			return resolved, fmt.Sprintf("%s is in the denied path %s", path, root)
+		}
+	}
+	if len(g.allowed) == 0 {
+		return resolved, ""
+	}
+	for _, root := range g.allowed {
+		if withinPath(root, resolved) {
+			return resolved, ""
+		}
+	}
+	return resolved, fmt.Sprintf("%s is outside the allowed paths", path)
+}
+
+// withinPath reports whether path is root or inside it. Both are resolved.
+func withinPath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// permissionViolations returns the blocked paths named by a permission
+// request.
+func (g *pathGuard) permissionViolations(request PermissionRequest) []PathDeniedData {
+	var kind PermissionRequestKind
+	var toolCallID *string
+	var paths []string
+	switch r := request.(type) {
+	case *PermissionRequestRead:
+		kind, toolCallID, paths = r.Kind(), r.ToolCallID, []string{r.Path}
+	case *PermissionRequestWrite:
+		kind, toolCallID, paths = r.Kind(), r.ToolCallID, []string{r.FileName}
+	case *PermissionRequestShell:
+		kind, toolCallID, paths = r.Kind(), r.ToolCallID, r.PossiblePaths
+	}
+	var violations []PathDeniedData
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if resolved, reason := g.check(path); reason != "" {
+			violations = append(violations, PathDeniedData{
+				ToolCallID:   derefString(toolCallID),
+				Kind:         kind,
+				Path:         path,
+				ResolvedPath: resolved,
+				Reason:       reason,
+			})
+		}
+	}
+	return violations
+}
+
+// enforcePermission rejects the permission request if it names blocked
+// paths, reporting whether it did.
+func (g *pathGuard) enforcePermission(d *PermissionRequestedData) bool {
+	violations := g.permissionViolations(d.PermissionRequest)
+	if len(violations) == 0 {
+		return false
+	}
+	reasons := make([]string, 0, len(violations))
+	for i := range violations {
+		g.report(&violations[i])
+		reasons = append(reasons, violations[i].Reason)
+	}
+	feedback := "Blocked by the session's filesystem policy: " + strings.Join(reasons, "; ")
+	g.session.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		RequestID: d.RequestID,
+		Result:    &rpc.PermissionDecisionReject{Feedback: &feedback},
+	})
+	return true
+}
+
+// narrow turns approvals that would stop the runtime asking about similar
+// requests into approve-once, so later requests still reach the policy.
+func (g *pathGuard) narrow(decision rpc.PermissionDecision) rpc.PermissionDecision {
+	switch decision.(type) {
+	case *rpc.PermissionDecisionApproveForSession, rpc.PermissionDecisionApproveForSession,
+		*rpc.PermissionDecisionApproveForLocation, rpc.PermissionDecisionApproveForLocation,
+		*rpc.PermissionDecisionApprovePermanently, rpc.PermissionDecisionApprovePermanently:
+		return &rpc.PermissionDecisionApproveOnce{}
+	}
+	return decision
+}
+
+// report delivers a path.denied event to the session's handlers.
+func (g *pathGuard) report(violation *PathDeniedData) {
+	ephemeral := true
+	g.session.dispatchEvent(SessionEvent{
+		Data:      violation,
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+}
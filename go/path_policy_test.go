@@ -0,0 +1,159 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestPathGuard(t *testing.T) {
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{filepath.Join(workspace, "src"), filepath.Join(workspace, "secrets"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(workspace, "escape")); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	guard, err := newPathGuard([]string{workspace}, []string{"secrets"}, workspace)
+	if err != nil {
+		t.Fatalf("newPathGuard failed: %v", err)
+	}
+
+	t.Run("resolves paths before checking them", func(t *testing.T) {
+		for path, allowed := range map[string]bool{
+			filepath.Join(workspace, "src", "main.go"): true,
+			"src/main.go":                                  true,
+			"new/dir/file.txt":                             true,
+			"src/../README.md":                             true,
+			workspace:                                      true,
+			"../outside/file":                              false,
+			workspace + "/../outside/file":                 false,
+			"src/../../outside/file":                       false,
+			"escape/file":                                  false,
+			"escape/../workspace/src/main.go":              true,
+			"escape/../outside/file":                       false,
+			"secrets/key.pem":                              false,
+			"src/../secrets/key.pem":                       false,
+			filepath.Join(root, "workspace-other", "file"): false,
+			filepath.Join(workspace, "new", "..", "..", "outside"): false,
+		} {
+			if _, reason := guard.check(path); (reason == "") != allowed {
+				t.Errorf("check(%q) allowed = %v, want %v (%s)", path, reason == "", allowed, reason)
+			}
+		}
+	})
+
+	t.Run("finds blocked paths in permission requests", func(t *testing.T) {
+		if v := guard.permissionViolations(&PermissionRequestRead{Path: "src/main.go"}); len(v) != 0 {
+			t.Errorf("expected no violations, got %+v", v)
+		}
+		v := guard.permissionViolations(&PermissionRequestWrite{FileName: "escape/run.sh", ToolCallID: ptr("call-1")})
+		if len(v) != 1 || v[0].Kind != PermissionRequestKindWrite || v[0].ToolCallID != "call-1" || v[0].ResolvedPath != filepath.Join(outside, "run.sh") {
+			t.Errorf("unexpected violations: %+v", v)
+		}
+		v = guard.permissionViolations(&PermissionRequestShell{PossiblePaths: []string{"src", "/etc/passwd"}})
+		if len(v) != 1 || v[0].Kind != PermissionRequestKindShell || v[0].Path != "/etc/passwd" {
+			t.Errorf("unexpected violations: %+v", v)
+		}
+	})
+
+	t.Run("rejects blocked requests before the permission handler", func(t *testing.T) {
+		decisions := make(chan map[string]any, 1)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.permissions.handlePendingPermissionRequest": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req map[string]any
+				json.Unmarshal(params, &req)
+				decisions <- req
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.attachPaths(guard)
+		denied := make(chan PathDeniedData, 1)
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*PathDeniedData); ok {
+				denied <- *d
+			}
+		})
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+			t.Error("expected the handler not to be called")
+			return &rpc.PermissionDecisionApproveOnce{}, nil
+		})
+
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: &PermissionRequestRead{Path: "../outside/notes.txt"}}})
+		select {
+		case req := <-decisions:
+			result, _ := req["result"].(map[string]any)
+			if result["kind"] != "reject" || !strings.Contains(result["feedback"].(string), "outside the allowed paths") {
+				t.Errorf("expected a rejection, got %v", req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the decision")
+		}
+		if d := <-denied; d.Kind != PermissionRequestKindRead || d.Path != "../outside/notes.txt" {
+			t.Errorf("unexpected event: %+v", d)
+		}
+	})
+
+	t.Run("narrows approvals for the session to the request", func(t *testing.T) {
+		decisions := make(chan map[string]any, 1)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.permissions.handlePendingPermissionRequest": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req map[string]any
+				json.Unmarshal(params, &req)
+				decisions <- req
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.attachPaths(guard)
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+			return &rpc.PermissionDecisionApproveForSession{}, nil
+		})
+
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: &PermissionRequestRead{Path: "src/main.go"}}})
+		select {
+		case req := <-decisions:
+			if result, _ := req["result"].(map[string]any); result["kind"] != "approve-once" {
+				t.Errorf("expected the approval to be narrowed to approve-once, got %v", req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the decision")
+		}
+	})
+
+	t.Run("rejects sessions whose shell commands it cannot check", func(t *testing.T) {
+		client := startTestRuntime(t, nil)
+		for name, config := range map[string]SessionConfig{
+			"PTYShell":           {PTYShell: &PTYShellConfig{}},
+			"ProxyShellCommands": {EgressPolicy: &EgressPolicy{AllowDomains: []string{"example.com"}, ProxyShellCommands: true}},
+		} {
+			config.OnPermissionRequest = PermissionHandler.ApproveAll
+			config.WorkingDirectory = workspace
+			config.DeniedPaths = []string{"secrets"}
+			if _, err := client.CreateSession(t.Context(), &config); err == nil || !strings.Contains(err.Error(), "SDK-hosted shell") {
+				t.Errorf("%s: expected CreateSession to reject the path policy, got %v", name, err)
+			}
+			if _, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{
+				OnPermissionRequest: PermissionHandler.ApproveAll,
+				PTYShell:            config.PTYShell,
+				EgressPolicy:        config.EgressPolicy,
+				AllowedPaths:        []string{workspace},
+			}); err == nil || !strings.Contains(err.Error(), "SDK-hosted shell") {
+				t.Errorf("%s: expected ResumeSession to reject the path policy, got %v", name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package copilot
+
+import (
+	"net/url"
+	"strings"
+)
+
+// newPermissionInvocation returns the invocation passed to the permission
+// handler for request, with the structured context parsed from it.
+func newPermissionInvocation(sessionID string, request PermissionRequest) PermissionInvocation {
+	invocation := PermissionInvocation{SessionID: sessionID}
+	switch r := request.(type) {
+	case *PermissionRequestWrite:
+		invocation.Diff = parseUnifiedDiff(r.Diff)
+		if invocation.Diff == nil && r.NewFileContents != nil {
+			invocation.Diff = newFileDiff(*r.NewFileContents)
+		}
+		for i := range invocation.Diff {
+			if invocation.Diff[i].Path == "" {
+				invocation.Diff[i].Path = r.FileName
+			}
+			if r.ToolCallID != nil {
+				invocation.Diff[i].ToolCallID = *r.ToolCallID
+			}
+		}
+	case *PermissionRequestShell:
+		invocation.Command, _ = ParseShellCommand(r.FullCommandText)
+	case *PermissionRequestURL:
+		invocation.URL, _ = url.Parse(r.URL)
+	}
+	return invocation
+}
+
+// newFileDiff returns the diff creating a file with contents.
+func newFileDiff(contents string) []FileEditedData {
+	lines := strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+	if contents == "" {
+		lines = nil
+	}
+	hunk := DiffHunk{NewStart: 1, NewLines: len(lines)}
+	for i, line := range lines {
+		hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineKindAdded, Content: line, NewLine: i + 1})
+	}
+	return []FileEditedData{{
+		Operation: FileEditOperationCreated,
+		Hunks:     []DiffHunk{hunk},
+		Additions: len(lines),
+	}}
+}
@@ -0,0 +1,38 @@
+package copilot
+
+import "testing"
+
+func TestNewPermissionInvocation(t *testing.T) {
+	t.Run("parses the diff of a write request", func(t *testing.T) {
+		invocation := newPermissionInvocation("s1", &PermissionRequestWrite{
+			FileName:   "main.go",
+			Diff:       "@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n",
+			ToolCallID: ptr("t1"),
+		})
+		if invocation.SessionID != "s1" || len(invocation.Diff) != 1 {
+			t.Fatalf("unexpected invocation %+v", invocation)
+		}
+		diff := invocation.Diff[0]
+		if diff.Path != "main.go" || diff.ToolCallID != "t1" || diff.Additions != 1 || diff.Deletions != 1 {
+			t.Errorf("unexpected diff %+v", diff)
+		}
+	})
+
+	t.Run("describes a new file without a diff as created", func(t *testing.T) {
+		invocation := newPermissionInvocation("s1", &PermissionRequestWrite{FileName: "new.txt", NewFileContents: ptr("a\nb\n")})
+		if len(invocation.Diff) != 1 || invocation.Diff[0].Operation != FileEditOperationCreated || invocation.Diff[0].Additions != 2 {
+			t.Errorf("unexpected diff %+v", invocation.Diff)
+		}
+	})
+
+	t.Run("parses shell commands and URLs", func(t *testing.T) {
+		invocation := newPermissionInvocation("s1", &PermissionRequestShell{FullCommandText: "curl -s https://example.com > page.html"})
+		if invocation.Command == nil || len(invocation.Command.Commands) != 1 || invocation.Command.Commands[0].Binary != "curl" {
+			t.Errorf("unexpected command %+v", invocation.Command)
+		}
+		invocation = newPermissionInvocation("s1", &PermissionRequestURL{URL: "https://api.example.com/v1?q=1"})
+		if invocation.URL == nil || invocation.URL.Host != "api.example.com" {
+			t.Errorf("unexpected URL %v", invocation.URL)
+		}
+	})
+}
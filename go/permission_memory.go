@@ -0,0 +1,333 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+type (
+	PermissionDecisionRemember = rpc.PermissionDecisionRemember
+	PermissionRememberScope    = rpc.PermissionRememberScope
+)
+
+const (
+	PermissionRememberScopeTool      = rpc.PermissionRememberScopeTool
+	PermissionRememberScopeCommand   = rpc.PermissionRememberScopeCommand
+	PermissionRememberScopePath      = rpc.PermissionRememberScopePath
+	PermissionRememberScopeDirectory = rpc.PermissionRememberScopeDirectory
+)
+
+// RememberedPermission is a permission decision the SDK applies to matching
+// requests without calling the permission handler. A handler remembers a
+// decision by returning [PermissionDecisionRemember], the way the CLI offers
+// "always allow". Remembered decisions last for the session, or across
+// sessions when saved to a [PermissionStore].
+type RememberedPermission struct {
+	// Kind is the kind of request the decision applies to.
+	Kind  PermissionRequestKind   `json:"kind"`
+	Scope PermissionRememberScope `json:"scope"`
+	// Match is what a request must match for the scope: the MCP tool as
+	// "server/tool" or the custom tool name (empty for any request of Kind),
+	// the command identifier, the file, or the directory.
+	Match    string `json:"match,omitempty"`
+	Approved bool   `json:"approved"`
+}
+
+// PermissionStore persists remembered permission decisions across
+// sessions, for example per workspace. See [SessionConfig.PermissionStore].
+type PermissionStore interface {
+	// Load returns the remembered decisions. It is called when a session
+	// is created or resumed.
+	Load(ctx context.Context) ([]RememberedPermission, error)
+	// Save adds newly remembered decisions.
+	Save(ctx context.Context, permissions []RememberedPermission) error
+}
+
+// FilePermissionStore is a [PermissionStore] backed by a JSON file. Keep
+// one file per workspace to offer "always allow for this workspace".
+type FilePermissionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFilePermissionStore returns a store that keeps decisions in the JSON
+// file at path. The file is created on the first save.
+func NewFilePermissionStore(path string) *FilePermissionStore {
+	return &FilePermissionStore{path: path}
+}
+
+// Load implements [PermissionStore].
+func (f *FilePermissionStore) Load(context.Context) ([]RememberedPermission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.loadLocked()
+}
+
+func (f *FilePermissionStore) loadLocked() ([]RememberedPermission, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission store: %w", err)
+	}
+	var permissions []RememberedPermission
+	if err := json.Unmarshal(data, &permissions); err != nil {
+		return nil, fmt.Errorf("failed to parse permission store %s: %w", f.path, err)
+	}
+	return permissions, nil
+}
+
+// Save implements [PermissionStore].
+func (f *FilePermissionStore) Save(_ context.Context, permissions []RememberedPermission) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, err := f.loadLocked()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(append(existing, permissions...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode permissions: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create permission store directory: %w", err)
+	}
+	if err := os.WriteFile(f.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write permission store: %w", err)
+	}
+	return nil
+}
+
+// permissionMemory holds a session's remembered decisions. The zero value
+// remembers decisions for the session only.
+type permissionMemory struct {
+	mu          sync.Mutex
+	store       PermissionStore
+	permissions []RememberedPermission
+}
+
+// loadRememberedPermissions loads the decisions of store, if set.
+func loadRememberedPermissions(ctx context.Context, store PermissionStore) ([]RememberedPermission, error) {
+	if store == nil {
+		return nil, nil
+	}
+	permissions, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load remembered permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// init sets the store and its loaded decisions.
+func (m *permissionMemory) init(store PermissionStore, permissions []RememberedPermission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.permissions = permissions
+}
+
+// decide returns the remembered decision for request, if there is one.
+// Remembered rejections win over approvals.
+func (m *permissionMemory) decide(request PermissionRequest) (rpc.PermissionDecision, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.permissions) == 0 {
+		return nil, false
+	}
+	approved := false
+	if shell, ok := request.(*PermissionRequestShell); ok && len(shell.Commands) > 0 {
+		// Every command must be approved; any rejected command rejects.
+		approvedCommands := 0
+		for _, command := range shell.Commands {
+			switch m.commandDecisionLocked(command.Identifier) {
+			case decisionRejected:
+				return rememberedRejection(), true
+			case decisionApproved:
+				approvedCommands++
+			}
+		}
+		approved = approvedCommands == len(shell.Commands)
+	}
+	for _, p := range m.permissions {
+		if p.Kind != request.Kind() || p.Scope == PermissionRememberScopeCommand || !rememberedMatches(p, request) {
+			continue
+		}
+		if !p.Approved {
+			return rememberedRejection(), true
+		}
+		approved = true
+	}
+	if approved {
+		return &rpc.PermissionDecisionApproveOnce{}, true
+	}
+	return nil, false
+}
+
+type rememberedDecision int
+
+const (
+	decisionNone rememberedDecision = iota
+	decisionApproved
+	decisionRejected
+)
+
+func (m *permissionMemory) commandDecisionLocked(identifier string) rememberedDecision {
+	decision := decisionNone
+	for _, p := range m.permissions {
+		if p.Kind != PermissionRequestKindShell || p.Scope != PermissionRememberScopeCommand || p.Match != identifier {
+			continue
+		}
+		if !p.Approved {
+			return decisionRejected
+		}
+		decision = decisionApproved
+	}
+	return decision
+}
+
+func rememberedRejection() rpc.PermissionDecision {
+	feedback := "Denied by a remembered permission decision"
+	return &rpc.PermissionDecisionReject{Feedback: &feedback}
+}
+
+// rememberedMatches reports whether a tool- or path-scoped decision
+// applies to request.
+func rememberedMatches(p RememberedPermission, request PermissionRequest) bool {
+	switch p.Scope {
+	case PermissionRememberScopeTool:
+		return toolScopeApplies(request.Kind()) && p.Match == permissionToolIdentity(request)
+	case PermissionRememberScopePath, PermissionRememberScopeDirectory:
+		path := permissionRequestPath(request)
+		if path == "" {
+			return false
+		}
+		// Both sides are resolved now, so a link created or changed since
+		// the decision cannot lead outside what was approved.
+		resolved, err := evalPath("", path)
+		if err != nil {
+			return false
+		}
+		match, err := evalPath("", p.Match)
+		if err != nil {
+			return false
+		}
+		if p.Scope == PermissionRememberScopePath {
+			return resolved == match
+		}
+		return withinPath(match, resolved)
+	}
+	return false
+}
+
+// toolScopeApplies reports whether a tool-scoped decision can be remembered
+// for requests of kind. Shell, read, write and URL requests have no tool to
+// match, so a tool scope would approve every request of the kind; they are
+// remembered by command, path or not at all.
+func toolScopeApplies(kind PermissionRequestKind) bool {
+	switch kind {
+	case PermissionRequestKindShell, PermissionRequestKindRead, PermissionRequestKindWrite, PermissionRequestKindURL:
+		return false
+	}
+	return true
+}
+
+// permissionToolIdentity identifies the tool of an MCP or custom tool
+// request, or returns "" for other kinds.
+func permissionToolIdentity(request PermissionRequest) string {
+	switch r := request.(type) {
+	case *PermissionRequestMCP:
+		return r.ServerName + "/" + r.ToolName
+	case *PermissionRequestCustomTool:
+		return r.ToolName
+	}
+	return ""
+}
+
+// permissionRequestPath returns the path of a read or write request.
+func permissionRequestPath(request PermissionRequest) string {
+	switch r := request.(type) {
+	case *PermissionRequestRead:
+		return r.Path
+	case *PermissionRequestWrite:
+		return r.FileName
+	}
+	return ""
+}
+
+// remember records decision for requests like request, saving it to the
+// store if it asks to persist. It returns the decisions recorded.
+func (m *permissionMemory) remember(ctx context.Context, request PermissionRequest, decision *PermissionDecisionRemember) ([]RememberedPermission, error) {
+	permissions := rememberedPermissionsFor(request, decision)
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("cannot remember a %s-scoped decision for a %s request", decision.Scope, request.Kind())
+	}
+	m.mu.Lock()
+	m.permissions = append(m.permissions, permissions...)
+	store := m.store
+	m.mu.Unlock()
+	if decision.Persist {
+		if store == nil {
+			return permissions, errors.New("cannot persist a permission decision without SessionConfig.PermissionStore")
+		}
+		if err := store.Save(ctx, permissions); err != nil {
+			return permissions, fmt.Errorf("failed to save remembered permissions: %w", err)
+		}
+	}
+	return permissions, nil
+}
+
+// rememberedPermissionsFor returns the decisions that remembering decision
+// for request records.
+func rememberedPermissionsFor(request PermissionRequest, decision *PermissionDecisionRemember) []RememberedPermission {
+	approved := false
+	switch decision.Decision.(type) {
+	case *rpc.PermissionDecisionApproveOnce, rpc.PermissionDecisionApproveOnce,
+		*rpc.PermissionDecisionApproveForSession, rpc.PermissionDecisionApproveForSession,
+		*rpc.PermissionDecisionApproveForLocation, rpc.PermissionDecisionApproveForLocation,
+		*rpc.PermissionDecisionApprovePermanently, rpc.PermissionDecisionApprovePermanently:
+		approved = true
+	}
+	remembered := RememberedPermission{Kind: request.Kind(), Scope: decision.Scope, Approved: approved}
+	switch decision.Scope {
+	case PermissionRememberScopeTool:
+		if !toolScopeApplies(request.Kind()) {
+			return nil
+		}
+		remembered.Match = permissionToolIdentity(request)
+		return []RememberedPermission{remembered}
+	case PermissionRememberScopePath, PermissionRememberScopeDirectory:
+		path := permissionRequestPath(request)
+		if path == "" {
+			return nil
+		}
+		resolved, err := evalPath("", path)
+		if err != nil {
+			return nil
+		}
+		remembered.Match = resolved
+		if decision.Scope == PermissionRememberScopeDirectory {
+			remembered.Match = filepath.Dir(resolved)
+		}
+		return []RememberedPermission{remembered}
+	case PermissionRememberScopeCommand:
+		shell, ok := request.(*PermissionRequestShell)
+		if !ok {
+			return nil
+		}
+		var permissions []RememberedPermission
+		for _, command := range shell.Commands {
+			remembered.Match = command.Identifier
+			permissions = append(permissions, remembered)
+		}
+		return permissions
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestPermissionMemory(t *testing.T) {
+	shell := func(identifiers ...string) *PermissionRequestShell {
+		r := &PermissionRequestShell{}
+		for _, id := range identifiers {
+			r.Commands = append(r.Commands, PermissionRequestShellCommand{Identifier: id})
+		}
+		return r
+	}
+	approve := func(scope PermissionRememberScope) *PermissionDecisionRemember {
+		return &PermissionDecisionRemember{Decision: &rpc.PermissionDecisionApproveOnce{}, Scope: scope}
+	}
+	kindOf := func(decision rpc.PermissionDecision, ok bool) string {
+		if !ok {
+			return "none"
+		}
+		return string(decision.Kind())
+	}
+
+	t.Run("approves shell requests whose commands were all approved", func(t *testing.T) {
+		var m permissionMemory
+		if _, err := m.remember(t.Context(), shell("git status", "npm test"), approve(PermissionRememberScopeCommand)); err != nil {
+			t.Fatal(err)
+		}
+		if got := kindOf(m.decide(shell("git status"))); got != "approve-once" {
+			t.Errorf("expected a remembered approval, got %s", got)
+		}
+		if got := kindOf(m.decide(shell("git status", "rm -rf"))); got != "none" {
+			t.Errorf("expected an unremembered command to reach the handler, got %s", got)
+		}
+	})
+
+	t.Run("refuses tool-scoped decisions for shell, read, write and URL requests", func(t *testing.T) {
+		var m permissionMemory
+		if _, err := m.remember(t.Context(), shell("git status"), approve(PermissionRememberScopeTool)); err == nil {
+			t.Error("expected a tool-scoped shell decision to be refused")
+		}
+		// As a store written before this was refused may hold.
+		m.permissions = []RememberedPermission{{Kind: PermissionRequestKindShell, Scope: PermissionRememberScopeTool, Approved: true}}
+		if got := kindOf(m.decide(shell("rm -rf /"))); got != "none" {
+			t.Errorf("expected a stored tool-scoped shell decision to be ignored, got %s", got)
+		}
+	})
+
+	t.Run("matches only the remembered file for a path scope", func(t *testing.T) {
+		var m permissionMemory
+		dir := filepath.Join("ws", "src")
+		m.remember(t.Context(), &PermissionRequestWrite{FileName: filepath.Join(dir, "a.go")}, approve(PermissionRememberScopePath))
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "a.go")})); got != "approve-once" {
+			t.Errorf("expected the same file to be approved, got %s", got)
+		}
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "pkg", "b.go")})); got != "none" {
+			t.Errorf("expected a file below it not to match, got %s", got)
+		}
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "b.go")})); got != "none" {
+			t.Errorf("expected a sibling file not to match, got %s", got)
+		}
+	})
+
+	t.Run("resolves symbolic links on both sides", func(t *testing.T) {
+		root := t.TempDir()
+		workspace := filepath.Join(root, "ws")
+		outside := filepath.Join(root, "outside")
+		for _, dir := range []string{workspace, outside} {
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Symlink(outside, filepath.Join(workspace, "escape")); err != nil {
+			t.Skipf("cannot create symbolic links: %v", err)
+		}
+		var m permissionMemory
+		m.remember(t.Context(), &PermissionRequestWrite{FileName: filepath.Join(workspace, "a.go")}, approve(PermissionRememberScopeDirectory))
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(workspace, "escape", "b.go")})); got != "none" {
+			t.Errorf("expected a link out of the directory not to match, got %s", got)
+		}
+
+		// A decision remembered through a link applies to its target.
+		m = permissionMemory{}
+		m.remember(t.Context(), &PermissionRequestWrite{FileName: filepath.Join(workspace, "escape", "b.go")}, approve(PermissionRememberScopePath))
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(outside, "b.go")})); got != "approve-once" {
+			t.Errorf("expected the link target to match, got %s", got)
+		}
+	})
+
+	t.Run("matches paths below the remembered directory and lets rejections win", func(t *testing.T) {
+		var m permissionMemory
+		dir := filepath.Join("ws", "src")
+		m.remember(t.Context(), &PermissionRequestWrite{FileName: filepath.Join(dir, "a.go")}, approve(PermissionRememberScopeDirectory))
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "pkg", "b.go")})); got != "approve-once" {
+			t.Errorf("expected a nested path to be approved, got %s", got)
+		}
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join("ws", "srcs", "c.go")})); got != "none" {
+			t.Errorf("expected a sibling directory not to match, got %s", got)
+		}
+		if got := kindOf(m.decide(&PermissionRequestRead{Path: filepath.Join(dir, "a.go")})); got != "none" {
+			t.Errorf("expected reads not to match a write approval, got %s", got)
+		}
+
+		m.remember(t.Context(), &PermissionRequestWrite{FileName: filepath.Join(dir, "pkg", "b.go")},
+			&PermissionDecisionRemember{Decision: &rpc.PermissionDecisionReject{}, Scope: PermissionRememberScopePath})
+		if got := kindOf(m.decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "pkg", "b.go")})); got != "reject" {
+			t.Errorf("expected the rejection to win, got %s", got)
+		}
+	})
+
+	t.Run("persists decisions across sessions through the store", func(t *testing.T) {
+		store := NewFilePermissionStore(filepath.Join(t.TempDir(), "workspace", "permissions.json"))
+		var first permissionMemory
+		first.init(store, nil)
+		decision := approve(PermissionRememberScopeTool)
+		decision.Persist = true
+		if _, err := first.remember(t.Context(), &PermissionRequestMCP{ServerName: "github", ToolName: "list_issues"}, decision); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := loadRememberedPermissions(t.Context(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var second permissionMemory
+		second.init(store, loaded)
+		if got := kindOf(second.decide(&PermissionRequestMCP{ServerName: "github", ToolName: "list_issues"})); got != "approve-once" {
+			t.Errorf("expected the persisted approval, got %s", got)
+		}
+		if got := kindOf(second.decide(&PermissionRequestMCP{ServerName: "github", ToolName: "merge_pr"})); got != "none" {
+			t.Errorf("expected other tools not to match, got %s", got)
+		}
+	})
+
+	t.Run("answers later requests without calling the handler", func(t *testing.T) {
+		decisions := make(chan map[string]any, 2)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.permissions.handlePendingPermissionRequest": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req map[string]any
+				json.Unmarshal(params, &req)
+				decisions <- req
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		calls := 0
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+			calls++
+			return approve(PermissionRememberScopeCommand), nil
+		})
+
+		for _, id := range []string{"p1", "p2"} {
+			session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: id, PermissionRequest: shell("go test")}})
+			select {
+			case req := <-decisions:
+				if result, _ := req["result"].(map[string]any); req["requestId"] != id || result["kind"] != "approve-once" {
+					t.Errorf("expected %s to be approved once, got %v", id, req)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for the decision on %s", id)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected the handler to be called once, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package copilot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// Evaluate runs the permission handler against each request outside of a
+// session and returns the decision a session would send for it. It lets a
+// permission policy be tested against recorded requests before it is used
+// in production sessions.
+//
+// Decisions are normalised as a session does: a handler error, panic or nil
+// decision yields [rpc.PermissionDecisionUserNotAvailable], and
+// [rpc.PermissionDecisionRemember] yields its Decision, without remembering
+// it. [rpc.PermissionDecisionNoResult] is returned as is. The invocation
+// passed to the handler has no SessionID.
+//
+// Example:
+//
+//	decisions := policy.Evaluate([]copilot.PermissionRequest{
+//	    &copilot.PermissionRequestShell{FullCommandText: "rm -rf /"},
+//	})
+//	if decisions[0].Kind() != rpc.PermissionDecisionKindReject {
+//	    log.Fatal("policy allows rm -rf /")
+//	}
+func (h PermissionHandlerFunc) Evaluate(requests []PermissionRequest) []rpc.PermissionDecision {
+	decisions := make([]rpc.PermissionDecision, len(requests))
+	for i, request := range requests {
+		decisions[i], _ = h.evaluate(request)
+	}
+	return decisions
+}
+
+// evaluate runs the handler against request and returns the normalised
+// decision with the handler's error, if any.
+func (h PermissionHandlerFunc) evaluate(request PermissionRequest) (decision rpc.PermissionDecision, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			decision, err = &rpc.PermissionDecisionUserNotAvailable{}, fmt.Errorf("permission handler panicked: %v", r)
+		}
+	}()
+	decision, err = h(request, newPermissionInvocation("", request))
+	if err != nil {
+		return &rpc.PermissionDecisionUserNotAvailable{}, err
+	}
+	switch d := decision.(type) {
+	case rpc.PermissionDecisionRemember:
+		decision = d.Decision
+	case *rpc.PermissionDecisionRemember:
+		decision = d.Decision
+	}
+	if decision == nil {
+		decision = &rpc.PermissionDecisionUserNotAvailable{}
+	}
+	return decision, nil
+}
+
+// Check evaluates the permission handler against each case and returns an
+// error describing every case whose decision does not have the expected
+// kind, or nil if all pass. Use it to unit test a permission policy against
+// a corpus of recorded requests.
+//
+// Example:
+//
+//	func TestPermissionPolicy(t *testing.T) {
+//	    f, err := os.Open("testdata/permissions.jsonl")
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    defer f.Close()
+//	    cases, err := copilot.ReadPermissionCorpus(f)
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    if err := policy.Check(cases); err != nil {
+//	        t.Error(err)
+//	    }
+//	}
+func (h PermissionHandlerFunc) Check(cases []PermissionCase) error {
+	var errs []error
+	for i, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		decision, err := h.evaluate(c.Request)
+		if decision.Kind() == c.Want {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("case %s: got %s (handler failed: %w), want %s", name, decision.Kind(), err, c.Want))
+		} else {
+			errs = append(errs, fmt.Errorf("case %s: got %s, want %s", name, decision.Kind(), c.Want))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PermissionCase is a permission request and the kind of decision a
+// permission policy is expected to make for it. See
+// [PermissionHandlerFunc.Check].
+type PermissionCase struct {
+	// Name identifies the case in failures. Defaults to its position.
+	Name    string
+	Request PermissionRequest
+	Want    rpc.PermissionDecisionKind
+}
+
+// permissionCaseJSON is the corpus encoding of a PermissionCase. The
+// request is carried as in the permission.requested event, so it can be
+// decoded as the event's data.
+type permissionCaseJSON struct {
+	Name              string                     `json:"name,omitempty"`
+	PermissionRequest PermissionRequest          `json:"permissionRequest"`
+	Want              rpc.PermissionDecisionKind `json:"want"`
+}
+
+// MarshalJSON encodes the case as a line of a permission corpus.
+func (c PermissionCase) MarshalJSON() ([]byte, error) {
+	return json.Marshal(permissionCaseJSON{Name: c.Name, PermissionRequest: c.Request, Want: c.Want})
+}
+
+// UnmarshalJSON decodes a line of a permission corpus.
+func (c *PermissionCase) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Name string                     `json:"name"`
+		Want rpc.PermissionDecisionKind `json:"want"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var request PermissionRequestedData
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	if request.PermissionRequest == nil {
+		return errors.New("missing permissionRequest")
+	}
+	*c = PermissionCase{Name: fields.Name, Request: request.PermissionRequest, Want: fields.Want}
+	return nil
+}
+
+// ReadPermissionCorpus reads a permission corpus: one JSON-encoded
+// [PermissionCase] per line, such as
+//
+//	{"name": "force push", "permissionRequest": {"kind": "shell", "fullCommandText": "git push -f", ...}, "want": "reject"}
+//
+// Blank lines are skipped.
+func ReadPermissionCorpus(r io.Reader) ([]PermissionCase, error) {
+	var cases []PermissionCase
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		var c PermissionCase
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to parse permission corpus line %d: %w", line, err)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read permission corpus: %w", err)
+	}
+	return cases, nil
+}
+
+// WritePermissionCorpus writes cases as a permission corpus, one per line.
+func WritePermissionCorpus(w io.Writer, cases []PermissionCase) error {
+	encoder := json.NewEncoder(w)
+	for _, c := range cases {
+		if err := encoder.Encode(c); err != nil {
+			return fmt.Errorf("failed to write permission corpus: %w", err)
+		}
+	}
+	return nil
+}
+
+// PermissionCasesFromEvents records the permission requests in a session's
+// events, such as those returned by [Session.GetEvents], as a corpus. Each
+// case expects the decision the session actually made, so the corpus
+// checks that a new policy agrees with past decisions; edit Want where it
+// should not. Requests resolved by a hook or with no recorded outcome are
+// skipped.
+//
+// Example:
+//
+//	events, err := session.GetEvents(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = copilot.WritePermissionCorpus(f, copilot.PermissionCasesFromEvents(events))
+func PermissionCasesFromEvents(events []SessionEvent) []PermissionCase {
+	requests := make(map[string]*PermissionRequestedData)
+	var cases []PermissionCase
+	for _, event := range events {
+		switch d := event.Data.(type) {
+		case *PermissionRequestedData:
+			if d.ResolvedByHook == nil || !*d.ResolvedByHook {
+				requests[d.RequestID] = d
+			}
+		case *PermissionCompletedData:
+			request, ok := requests[d.RequestID]
+			if !ok || d.Result == nil {
+				continue
+			}
+			delete(requests, d.RequestID)
+			cases = append(cases, PermissionCase{
+				Name:    d.RequestID,
+				Request: request.PermissionRequest,
+				Want:    permissionDecisionKindForResult(d.Result.Kind()),
+			})
+		}
+	}
+	return cases
+}
+
+// permissionDecisionKindForResult maps the outcome recorded in a
+// permission.completed event to the decision kind a handler returns for it.
+func permissionDecisionKindForResult(kind PermissionResultKind) rpc.PermissionDecisionKind {
+	switch kind {
+	case PermissionResultKindApproved:
+		return rpc.PermissionDecisionKindApproveOnce
+	case PermissionResultKindApprovedForSession:
+		return rpc.PermissionDecisionKindApproveForSession
+	case PermissionResultKindApprovedForLocation:
+		return rpc.PermissionDecisionKindApproveForLocation
+	case PermissionResultKindDeniedInteractivelyByUser:
+		return rpc.PermissionDecisionKindReject
+	case PermissionResultKindDeniedNoApprovalRuleAndCouldNotRequestFromUser:
+		return rpc.PermissionDecisionKindUserNotAvailable
+	default:
+		return rpc.PermissionDecisionKind(kind)
+	}
+}
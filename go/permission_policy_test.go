@@ -0,0 +1,110 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// denyForcePush rejects force pushes and approves other shell commands.
+func denyForcePush(request PermissionRequest, invocation PermissionInvocation) (rpc.PermissionDecision, error) {
+	if _, ok := request.(*PermissionRequestShell); !ok {
+		return nil, errors.New("not a shell request")
+	}
+	for _, command := range invocation.Command.Commands {
+		if command.Binary == "git" && len(command.Args) > 0 && command.Args[0] == "push" {
+			for _, arg := range command.Args {
+				if arg == "-f" || arg == "--force" {
+					return &rpc.PermissionDecisionReject{}, nil
+				}
+			}
+		}
+	}
+	return &rpc.PermissionDecisionRemember{Decision: &rpc.PermissionDecisionApproveOnce{}, Scope: rpc.PermissionRememberScopeCommand}, nil
+}
+
+func TestPermissionHandlerFunc_Evaluate(t *testing.T) {
+	policy := PermissionHandlerFunc(denyForcePush)
+
+	t.Run("returns the decision a session would send", func(t *testing.T) {
+		decisions := policy.Evaluate([]PermissionRequest{
+			&PermissionRequestShell{FullCommandText: "git push -f origin main"},
+			&PermissionRequestShell{FullCommandText: "go test ./..."},
+			&PermissionRequestURL{URL: "https://example.com"},
+		})
+		var kinds []string
+		for _, d := range decisions {
+			kinds = append(kinds, string(d.Kind()))
+		}
+		if got := strings.Join(kinds, ","); got != "reject,approve-once,user-not-available" {
+			t.Errorf("unexpected decisions %s", got)
+		}
+	})
+
+	t.Run("treats a panic as user-not-available", func(t *testing.T) {
+		panics := PermissionHandlerFunc(func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+			panic("boom")
+		})
+		if d := panics.Evaluate([]PermissionRequest{&PermissionRequestRead{Path: "a"}})[0]; d.Kind() != rpc.PermissionDecisionKindUserNotAvailable {
+			t.Errorf("unexpected decision %s", d.Kind())
+		}
+	})
+}
+
+func TestPermissionHandlerFunc_Check(t *testing.T) {
+	corpus := `{"name":"force push","permissionRequest":{"kind":"shell","fullCommandText":"git push --force","intention":"push","commands":[],"possiblePaths":[],"possibleUrls":[],"hasWriteFileRedirection":false,"canOfferSessionApproval":false},"want":"reject"}
+
+{"name":"url","permissionRequest":{"kind":"url","url":"https://example.com","intention":"fetch"},"want":"approve-once"}
+`
+	cases, err := ReadPermissionCorpus(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("ReadPermissionCorpus failed: %v", err)
+	}
+	if len(cases) != 2 || cases[0].Name != "force push" || cases[0].Want != rpc.PermissionDecisionKindReject {
+		t.Fatalf("unexpected cases %+v", cases)
+	}
+	if shell, ok := cases[0].Request.(*PermissionRequestShell); !ok || shell.FullCommandText != "git push --force" {
+		t.Fatalf("unexpected request %#v", cases[0].Request)
+	}
+
+	err = PermissionHandlerFunc(denyForcePush).Check(cases)
+	if err == nil || strings.Contains(err.Error(), "force push") || !strings.Contains(err.Error(), "case url: got user-not-available (handler failed: not a shell request), want approve-once") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	t.Run("round-trips cases recorded from events", func(t *testing.T) {
+		events := []SessionEvent{
+			{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: &PermissionRequestShell{FullCommandText: "ls"}}},
+			{Data: &PermissionRequestedData{RequestID: "p2", PermissionRequest: &PermissionRequestShell{FullCommandText: "rm -rf /"}}},
+			{Data: &PermissionRequestedData{RequestID: "p3", PermissionRequest: &PermissionRequestRead{Path: "a"}, ResolvedByHook: Bool(true)}},
+			{Data: &PermissionCompletedData{RequestID: "p2", Result: &PermissionDeniedInteractivelyByUser{}}},
+			{Data: &PermissionCompletedData{RequestID: "p1", Result: &PermissionApproved{}}},
+			{Data: &PermissionCompletedData{RequestID: "p3", Result: &PermissionApproved{}}},
+		}
+		var buf bytes.Buffer
+		if err := WritePermissionCorpus(&buf, PermissionCasesFromEvents(events)); err != nil {
+			t.Fatalf("WritePermissionCorpus failed: %v", err)
+		}
+		cases, err := ReadPermissionCorpus(&buf)
+		if err != nil {
+			t.Fatalf("ReadPermissionCorpus failed: %v", err)
+		}
+		if len(cases) != 2 || cases[0].Name != "p2" || cases[0].Want != rpc.PermissionDecisionKindReject ||
+			cases[1].Name != "p1" || cases[1].Want != rpc.PermissionDecisionKindApproveOnce {
+			t.Fatalf("unexpected cases %+v", cases)
+		}
+		if shell, ok := cases[0].Request.(*PermissionRequestShell); !ok || shell.FullCommandText != "rm -rf /" {
+			t.Errorf("unexpected request %#v", cases[0].Request)
+		}
+	})
+
+	t.Run("reports the line of invalid cases", func(t *testing.T) {
+		_, err := ReadPermissionCorpus(strings.NewReader("{\"want\":\"reject\"}\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 1: missing permissionRequest") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
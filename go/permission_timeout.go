@@ -0,0 +1,83 @@
+package copilot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type PermissionTimedOutData = rpc.PermissionTimedOutData
+
+const SessionEventTypePermissionTimedOut = rpc.SessionEventTypePermissionTimedOut
+
+// permissionTimeout bounds how long a session waits for its permission
+// handler. The zero value waits forever.
+type permissionTimeout struct {
+	timeout  time.Duration
+	decision rpc.PermissionDecision // nil rejects
+}
+
+// timeoutDecision returns the decision for a request whose handler did
+// not return in time.
+func (p permissionTimeout) timeoutDecision() rpc.PermissionDecision {
+	if p.decision != nil {
+		return p.decision
+	}
+	feedback := fmt.Sprintf("The user did not respond to the permission request within %s", p.timeout)
+	return &rpc.PermissionDecisionReject{Feedback: &feedback}
+}
+
+// callPermissionHandler runs handler, giving up after the session's
+// permission timeout. It reports whether the handler timed out, in which
+// case the timeout decision is returned and the handler's eventual result
+// is discarded.
+func (s *Session) callPermissionHandler(handler PermissionHandlerFunc, request PermissionRequest, invocation PermissionInvocation) (decision rpc.PermissionDecision, timedOut bool, err error) {
+	if s.permissionTimeout.timeout <= 0 {
+		decision, err = handler(request, invocation)
+		return decision, false, err
+	}
+	type outcome struct {
+		decision rpc.PermissionDecision
+		err      error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		// Runs in its own goroutine, where an unrecovered panic would crash
+		// the process.
+		defer func() {
+			if r := recover(); r != nil {
+				s.recovered("permission handler", "", r)
+				done <- outcome{err: fmt.Errorf("permission handler panicked: %v", r)}
+			}
+		}()
+		decision, err := handler(request, invocation)
+		done <- outcome{decision, err}
+	}()
+	timer := time.NewTimer(s.permissionTimeout.timeout)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		return o.decision, false, o.err
+	case <-timer.C:
+		return s.permissionTimeout.timeoutDecision(), true, nil
+	}
+}
+
+// reportPermissionTimeout delivers a permission.timed_out event.
+func (s *Session) reportPermissionTimeout(requestID string, request PermissionRequest, decision rpc.PermissionDecision) {
+	s.logger().Warn("permission handler timed out", "requestId", requestID, "kind", request.Kind(), "decision", decision.Kind())
+	ephemeral := true
+	s.dispatchEvent(SessionEvent{
+		Data: &PermissionTimedOutData{
+			RequestID:   requestID,
+			RequestKind: request.Kind(),
+			TimeoutMs:   s.permissionTimeout.timeout.Milliseconds(),
+			Decision:    decision.Kind(),
+		},
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,88 @@
+package copilot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestPermissionTimeout(t *testing.T) {
+	start := func(t *testing.T, timeout permissionTimeout, handler PermissionHandlerFunc) (*Session, chan map[string]any) {
+		t.Helper()
+		decisions := make(chan map[string]any, 1)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.permissions.handlePendingPermissionRequest": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req map[string]any
+				json.Unmarshal(params, &req)
+				decisions <- req
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.permissionTimeout = timeout
+		session.registerPermissionHandler(handler)
+		return session, decisions
+	}
+	decisionKind := func(t *testing.T, decisions chan map[string]any) any {
+		t.Helper()
+		select {
+		case req := <-decisions:
+			result, _ := req["result"].(map[string]any)
+			return result["kind"]
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the decision")
+			return nil
+		}
+	}
+	request := &PermissionRequestShell{FullCommandText: "rm -rf build"}
+
+	t.Run("rejects and reports a request the handler does not answer in time", func(t *testing.T) {
+		answer := make(chan struct{})
+		defer close(answer)
+		session, decisions := start(t, permissionTimeout{timeout: 20 * time.Millisecond}, func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+			<-answer
+			return &rpc.PermissionDecisionApproveOnce{}, nil
+		})
+		timedOut := make(chan PermissionTimedOutData, 1)
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*PermissionTimedOutData); ok {
+				timedOut <- *d
+			}
+		})
+
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: request}})
+		if kind := decisionKind(t, decisions); kind != "reject" {
+			t.Errorf("expected a rejection, got %v", kind)
+		}
+		want := PermissionTimedOutData{RequestID: "p1", RequestKind: PermissionRequestKindShell, TimeoutMs: 20, Decision: "reject"}
+		if got := <-timedOut; got != want {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("uses the configured timeout decision", func(t *testing.T) {
+		answer := make(chan struct{})
+		defer close(answer)
+		session, decisions := start(t, permissionTimeout{timeout: 20 * time.Millisecond, decision: &rpc.PermissionDecisionUserNotAvailable{}},
+			func(PermissionRequest, PermissionInvocation) (rpc.PermissionDecision, error) {
+				<-answer
+				return &rpc.PermissionDecisionApproveOnce{}, nil
+			})
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: request}})
+		if kind := decisionKind(t, decisions); kind != "user-not-available" {
+			t.Errorf("expected user-not-available, got %v", kind)
+		}
+	})
+
+	t.Run("returns the handler's decision when it answers in time", func(t *testing.T) {
+		session, decisions := start(t, permissionTimeout{timeout: time.Minute}, PermissionHandler.ApproveAll)
+		session.dispatchEvent(SessionEvent{Data: &PermissionRequestedData{RequestID: "p1", PermissionRequest: request}})
+		if kind := decisionKind(t, decisions); kind != "approve-once" {
+			t.Errorf("expected approve-once, got %v", kind)
+		}
+	})
+}
@@ -0,0 +1,331 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPoolClosed is returned by [ClientPool.CreateSession] after [ClientPool.Stop].
+	ErrPoolClosed = errors.New("client pool is closed")
+	// ErrPoolExhausted is returned by [ClientPool.CreateSession] when every
+	// client in the pool holds [ClientPoolOptions.MaxSessionsPerClient]
+	// sessions.
+	ErrPoolExhausted = errors.New("all pooled clients are at MaxSessionsPerClient")
+)
+
+// ClientPoolOptions configures a [ClientPool].
+type ClientPoolOptions struct {
+	// ClientOptions are used to create each client in the pool. When nil,
+	// default options are used.
+	ClientOptions *ClientOptions
+	// Size is the number of clients (runtime connections) in the pool.
+	// Defaults to 1.
+	Size int
+	// MaxSessions caps the number of sessions open across the whole pool.
+	// [ClientPool.CreateSession] blocks until a slot frees up or its context
+	// is done. 0 means unlimited.
+	MaxSessions int
+	// MaxSessionsPerClient caps the sessions placed on a single client.
+	// 0 means unlimited.
+	MaxSessionsPerClient int
+	// IdleTimeout stops clients that have had no open sessions for this long.
+	// A stopped client restarts automatically on the next session placed on
+	// it. 0 disables idle reaping.
+	IdleTimeout time.Duration
+	// MinWarmClients is the number of clients kept running by the idle reaper
+	// once started. Defaults to 0.
+	MinWarmClients int
+}
+
+// ClientPool shares a fixed set of [Client] connections across many
+// short-lived sessions, for backends that create one session per request.
+//
+// Sessions are placed on the least-loaded client. Clients start lazily on
+// first use, or eagerly via [ClientPool.Start].
+//
+// Example:
+//
+//	pool := copilot.NewClientPool(copilot.ClientPoolOptions{
+//	    Size:        4,
+//	    MaxSessions: 64,
+//	    IdleTimeout: 5 * time.Minute,
+//	})
+//	if err := pool.Start(ctx); err != nil { // optional warm-up
+//	    log.Fatal(err)
+//	}
+//	defer pool.Stop()
+//
+//	http.HandleFunc("/ask", func(w http.ResponseWriter, r *http.Request) {
+//	    session, err := pool.CreateSession(r.Context(), &copilot.SessionConfig{})
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    defer session.Release()
+//	    // ...
+//	})
+type ClientPool struct {
+	opts  ClientPoolOptions
+	slots chan struct{} // nil when MaxSessions is unlimited
+
+	mu         sync.Mutex
+	members    []*poolMember
+	closed     bool
+	stopReaper chan struct{}
+	reaperDone chan struct{}
+}
+
+type poolMember struct {
+	client *Client
+	// lifecycle is held for reading while sessions are created and for
+	// writing while the idle reaper stops the client, so a client is never
+	// stopped underneath a session that is being created on it.
+	lifecycle sync.RWMutex
+
+	// Guarded by ClientPool.mu.
+	sessions int
+	lastUsed time.Time
+	started  bool
+}
+
+// PooledSession is a [Session] borrowed from a [ClientPool]. Call
+// [PooledSession.Release] when done to disconnect it and free its slot.
+type PooledSession struct {
+	*Session
+	pool    *ClientPool
+	member  *poolMember
+	release sync.Once
+}
+
+// NewClientPool creates a pool of clients. No runtimes are started until
+// [ClientPool.Start] or the first [ClientPool.CreateSession].
+func NewClientPool(opts ClientPoolOptions) *ClientPool {
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+	if opts.MaxSessions < 0 || opts.MaxSessionsPerClient < 0 {
+		panic("ClientPoolOptions session limits must not be negative")
+	}
+	p := &ClientPool{opts: opts}
+	if opts.MaxSessions > 0 {
+		p.slots = make(chan struct{}, opts.MaxSessions)
+	}
+	for i := 0; i < opts.Size; i++ {
+		p.members = append(p.members, &poolMember{client: NewClient(opts.ClientOptions), lastUsed: time.Now()})
+	}
+	if opts.IdleTimeout > 0 {
+		p.stopReaper = make(chan struct{})
+		p.reaperDone = make(chan struct{})
+		go p.reapIdle()
+	}
+	return p
+}
+
+// Start warms up the pool by starting every client.
+func (p *ClientPool) Start(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	members := append([]*poolMember(nil), p.members...)
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(members))
+	for i, m := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.client.Start(ctx); err != nil {
+				errs[i] = fmt.Errorf("failed to start pooled client %d: %w", i, err)
+				return
+			}
+			p.mu.Lock()
+			m.started = true
+			m.lastUsed = time.Now()
+			p.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// CreateSession creates a session on the least-loaded client, waiting for
+// capacity when the pool is at MaxSessions.
+func (p *ClientPool) CreateSession(ctx context.Context, config *SessionConfig) (*PooledSession, error) {
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	member, err := p.reserve()
+	if err != nil {
+		p.releaseSlot()
+		return nil, err
+	}
+
+	member.lifecycle.RLock()
+	session, err := member.client.CreateSession(ctx, config)
+	// The client starts with its first session, unless that fails.
+	running := err == nil || member.client.State() != ClientStateStopped
+	member.lifecycle.RUnlock()
+	if running {
+		p.mu.Lock()
+		member.started = true
+		p.mu.Unlock()
+	}
+	if err != nil {
+		p.unreserve(member)
+		p.releaseSlot()
+		return nil, err
+	}
+	return &PooledSession{Session: session, pool: p, member: member}, nil
+}
+
+// Release disconnects the session and returns its slot to the pool. It is
+// safe to call more than once.
+func (s *PooledSession) Release() error {
+	var err error
+	s.release.Do(func() {
+		err = s.Session.Disconnect()
+		s.pool.unreserve(s.member)
+		s.pool.releaseSlot()
+	})
+	return err
+}
+
+// Stats reports the number of open sessions on each client in the pool.
+func (p *ClientPool) Stats() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]int, len(p.members))
+	for i, m := range p.members {
+		stats[i] = m.sessions
+	}
+	return stats
+}
+
+// Stop stops every client in the pool. Sessions that have not been released
+// are disconnected. The pool cannot be used after Stop.
+func (p *ClientPool) Stop() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	members := append([]*poolMember(nil), p.members...)
+	p.mu.Unlock()
+
+	if p.stopReaper != nil {
+		close(p.stopReaper)
+		<-p.reaperDone
+	}
+
+	var errs []error
+	for i, m := range members {
+		if err := m.client.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop pooled client %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reserve picks the least-loaded client with spare capacity.
+func (p *ClientPool) reserve() (*poolMember, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	var best *poolMember
+	for _, m := range p.members {
+		if p.opts.MaxSessionsPerClient > 0 && m.sessions >= p.opts.MaxSessionsPerClient {
+			continue
+		}
+		// Prefer running clients over cold ones at equal load.
+		if best == nil || m.sessions < best.sessions || (m.sessions == best.sessions && m.started && !best.started) {
+			best = m
+		}
+	}
+	if best == nil {
+		return nil, ErrPoolExhausted
+	}
+	best.sessions++
+	best.lastUsed = time.Now()
+	return best, nil
+}
+
+func (p *ClientPool) unreserve(m *poolMember) {
+	p.mu.Lock()
+	m.sessions--
+	m.lastUsed = time.Now()
+	p.mu.Unlock()
+}
+
+func (p *ClientPool) releaseSlot() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+// reapIdle periodically stops clients that have been idle for IdleTimeout.
+func (p *ClientPool) reapIdle() {
+	defer close(p.reaperDone)
+	interval := p.opts.IdleTimeout / 2
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopReaper:
+			return
+		case <-ticker.C:
+			p.reapOnce(time.Now())
+		}
+	}
+}
+
+func (p *ClientPool) reapOnce(now time.Time) {
+	p.mu.Lock()
+	var candidates []*poolMember
+	warm := 0
+	for _, m := range p.members {
+		if !m.started {
+			continue
+		}
+		if m.sessions > 0 || now.Sub(m.lastUsed) < p.opts.IdleTimeout || warm < p.opts.MinWarmClients {
+			warm++
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	p.mu.Unlock()
+
+	for _, m := range candidates {
+		m.lifecycle.Lock()
+		p.mu.Lock()
+		// A session may have been reserved since the scan above.
+		idle := m.sessions == 0 && !p.closed
+		if idle {
+			m.started = false
+		}
+		p.mu.Unlock()
+		if idle {
+			if err := m.client.Stop(); err != nil {
+				m.client.logger().Warn("failed to stop idle pooled client", "error", err)
+			}
+		}
+		m.lifecycle.Unlock()
+	}
+}
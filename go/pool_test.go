@@ -0,0 +1,142 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestPool builds a pool whose clients talk to in-memory runtimes.
+func newTestPool(t *testing.T, size int, opts ClientPoolOptions) *ClientPool {
+	t.Helper()
+	opts.Size = size
+	pool := NewClientPool(opts)
+	for _, m := range pool.members {
+		client, _, cleanup := newInMemoryClient(t)
+		t.Cleanup(cleanup)
+		m.client = client
+	}
+	return pool
+}
+
+func TestClientPool(t *testing.T) {
+	config := &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll}
+
+	t.Run("places sessions on the least-loaded client", func(t *testing.T) {
+		pool := newTestPool(t, 2, ClientPoolOptions{})
+		var sessions []*PooledSession
+		for i := 0; i < 3; i++ {
+			s, err := pool.CreateSession(t.Context(), config)
+			if err != nil {
+				t.Fatalf("CreateSession failed: %v", err)
+			}
+			sessions = append(sessions, s)
+		}
+		if got := pool.Stats(); !reflect.DeepEqual(got, []int{2, 1}) {
+			t.Errorf("expected load [2 1], got %v", got)
+		}
+		for _, s := range sessions {
+			if err := s.Release(); err != nil {
+				t.Errorf("Release failed: %v", err)
+			}
+		}
+		if got := pool.Stats(); !reflect.DeepEqual(got, []int{0, 0}) {
+			t.Errorf("expected load [0 0] after release, got %v", got)
+		}
+	})
+
+	t.Run("waits for capacity when MaxSessions is reached", func(t *testing.T) {
+		pool := newTestPool(t, 1, ClientPoolOptions{MaxSessions: 1})
+		first, err := pool.CreateSession(t.Context(), config)
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := pool.CreateSession(ctx, config); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded while pool is full, got %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			s, err := pool.CreateSession(t.Context(), config)
+			if err == nil {
+				s.Release()
+			}
+			done <- err
+		}()
+		first.Release()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("CreateSession after release failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("CreateSession did not proceed after a slot was released")
+		}
+	})
+
+	t.Run("rejects sessions beyond MaxSessionsPerClient", func(t *testing.T) {
+		pool := newTestPool(t, 1, ClientPoolOptions{MaxSessionsPerClient: 1})
+		s, err := pool.CreateSession(t.Context(), config)
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer s.Release()
+		if _, err := pool.CreateSession(t.Context(), config); !errors.Is(err, ErrPoolExhausted) {
+			t.Errorf("expected ErrPoolExhausted when every client is full, got %v", err)
+		}
+	})
+
+	t.Run("marks a client started only once it starts", func(t *testing.T) {
+		pool := newTestPool(t, 1, ClientPoolOptions{})
+		pool.members[0].client = NewClient(&ClientOptions{Connection: StdioConnection{Path: filepath.Join(t.TempDir(), "copilot")}})
+		if _, err := pool.CreateSession(t.Context(), config); err == nil {
+			t.Fatal("expected CreateSession to fail without a CLI")
+		}
+		if pool.members[0].started {
+			t.Error("expected a client that failed to start not to be marked started")
+		}
+
+		pool = newTestPool(t, 1, ClientPoolOptions{})
+		s, err := pool.CreateSession(t.Context(), config)
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer s.Release()
+		if !pool.members[0].started {
+			t.Error("expected the client to be marked started")
+		}
+	})
+
+	t.Run("reaps idle clients but keeps MinWarmClients", func(t *testing.T) {
+		pool := newTestPool(t, 2, ClientPoolOptions{MinWarmClients: 1})
+		pool.opts.IdleTimeout = time.Minute
+		for _, m := range pool.members {
+			m.started = true
+			m.lastUsed = time.Now().Add(-2 * time.Minute)
+		}
+		pool.reapOnce(time.Now())
+		if !pool.members[0].started || pool.members[1].started {
+			t.Errorf("expected only the first client to stay warm, got started=[%v %v]",
+				pool.members[0].started, pool.members[1].started)
+		}
+		if pool.members[1].client.client != nil {
+			t.Error("expected the reaped client to be stopped")
+		}
+	})
+
+	t.Run("fails after Stop", func(t *testing.T) {
+		pool := newTestPool(t, 1, ClientPoolOptions{})
+		if err := pool.Stop(); err != nil {
+			t.Fatalf("Stop failed: %v", err)
+		}
+		if _, err := pool.CreateSession(t.Context(), config); !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("expected ErrPoolClosed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package copilot
+
+import "slices"
+
+// Provenance lists what influenced a turn, for "powered by" details and
+// for debugging unexpected answers. See [Response.Provenance].
+type Provenance struct {
+	// Skills are the names of the skills invoked during the turn.
+	Skills []string
+	// Agents are the names of the custom agent selected and the sub-agents
+	// started during the turn.
+	Agents []string
+	// MCPServers are the names of the MCP servers whose tools were called.
+	MCPServers []string
+	// Attachments are the attachments of the turn's user messages.
+	Attachments []Attachment
+}
+
+// observe records what event contributes to the turn. Names are listed
+// once, in the order they first appear.
+func (p *Provenance) observe(event SessionEvent) {
+	appendOnce := func(names []string, name string) []string {
+		if name == "" || slices.Contains(names, name) {
+			return names
+		}
+		return append(names, name)
+	}
+	switch d := event.Data.(type) {
+	case *SkillInvokedData:
+		p.Skills = appendOnce(p.Skills, d.Name)
+	case *SubagentSelectedData:
+		p.Agents = appendOnce(p.Agents, d.AgentName)
+	case *SubagentStartedData:
+		p.Agents = appendOnce(p.Agents, d.AgentName)
+	case *ToolExecutionStartData:
+		if d.MCPServerName != nil {
+			p.MCPServers = appendOnce(p.MCPServers, *d.MCPServerName)
+		}
+	case *UserMessageData:
+		p.Attachments = append(p.Attachments, d.Attachments...)
+	}
+}
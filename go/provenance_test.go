@@ -0,0 +1,40 @@
+package copilot
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSession_Provenance(t *testing.T) {
+	t.Run("lists the skills, agents, MCP servers and attachments of the turn", func(t *testing.T) {
+		attachment := &AttachmentFile{DisplayName: "main.go", Path: "/ws/main.go"}
+		session := startReplyTestSession(t,
+			&UserMessageData{Content: "fix it", Attachments: []Attachment{attachment}},
+			&SkillInvokedData{Name: "go-testing"},
+			&SubagentStartedData{AgentName: "reviewer", ToolCallID: "t1"},
+			&ToolExecutionStartData{ToolCallID: "t2", ToolName: "github-list_issues", MCPServerName: ptr("github")},
+			&ToolExecutionStartData{ToolCallID: "t3", ToolName: "github-get_issue", MCPServerName: ptr("github")},
+			&ToolExecutionStartData{ToolCallID: "t4", ToolName: "bash"},
+			&SkillInvokedData{Name: "go-testing"},
+			&AssistantMessageData{MessageID: "a1", Content: "Fixed."},
+		)
+
+		response, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "fix it"})
+		if err != nil {
+			t.Fatalf("SendAndWaitResponse failed: %v", err)
+		}
+		p := response.Provenance
+		if !slices.Equal(p.Skills, []string{"go-testing"}) {
+			t.Errorf("unexpected skills %v", p.Skills)
+		}
+		if !slices.Equal(p.Agents, []string{"reviewer"}) {
+			t.Errorf("unexpected agents %v", p.Agents)
+		}
+		if !slices.Equal(p.MCPServers, []string{"github"}) {
+			t.Errorf("unexpected MCP servers %v", p.MCPServers)
+		}
+		if len(p.Attachments) != 1 || p.Attachments[0] != attachment {
+			t.Errorf("unexpected attachments %v", p.Attachments)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OpenAIProvider configures an OpenAI or OpenAI-compatible API, such as
+// Ollama or vLLM, as a BYOK provider. It holds only the options that apply
+// to OpenAI; use [OpenAIProvider.Config] to get the [ProviderConfig] for
+// [SessionConfig.Provider].
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Model: "gpt-4.1",
+//	    Provider: copilot.OpenAIProvider{
+//	        BaseURL: "https://api.openai.com/v1",
+//	        APIKey:  os.Getenv("OPENAI_API_KEY"),
+//	    }.Config(),
+//	})
+type OpenAIProvider struct {
+	// BaseURL is the API endpoint URL, e.g. "http://localhost:11434/v1".
+	// Required.
+	BaseURL string
+	// APIKey is the API key. Optional for local providers like Ollama.
+	APIKey string
+	// BearerToken sets the Authorization header directly, and takes
+	// precedence over APIKey.
+	BearerToken string
+	// WireAPI is "completions" (the default) or "responses".
+	WireAPI string
+	// Transport is "http" (the default) or "websockets", which requires
+	// WireAPI "responses".
+	Transport string
+	// Headers are custom HTTP headers included in provider requests.
+	Headers map[string]string
+	// ModelID, WireModel, MaxPromptTokens and MaxOutputTokens are as in
+	// [ProviderConfig].
+	ModelID         string
+	WireModel       string
+	MaxPromptTokens int
+	MaxOutputTokens int
+	// BearerTokenProvider resolves a bearer token on demand. See
+	// [ProviderConfig.BearerTokenProvider].
+	BearerTokenProvider BearerTokenProvider
+}
+
+// Config returns the provider as a [ProviderConfig].
+func (p OpenAIProvider) Config() *ProviderConfig {
+	return &ProviderConfig{
+		Type:                "openai",
+		WireAPI:             p.WireAPI,
+		Transport:           p.Transport,
+		BaseURL:             p.BaseURL,
+		APIKey:              p.APIKey,
+		BearerToken:         p.BearerToken,
+		Headers:             p.Headers,
+		ModelID:             p.ModelID,
+		WireModel:           p.WireModel,
+		MaxPromptTokens:     p.MaxPromptTokens,
+		MaxOutputTokens:     p.MaxOutputTokens,
+		BearerTokenProvider: p.BearerTokenProvider,
+	}
+}
+
+// Validate reports the first problem with the provider's options.
+func (p OpenAIProvider) Validate() error {
+	return p.Config().Validate()
+}
+
+// AzureProvider configures an Azure OpenAI resource as a BYOK provider. Use
+// [AzureProvider.Config] to get the [ProviderConfig] for
+// [SessionConfig.Provider].
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Model: "gpt-4.1",
+//	    Provider: copilot.AzureProvider{
+//	        BaseURL:    "https://my-resource.openai.azure.com",
+//	        APIVersion: "2024-10-21",
+//	        APIKey:     os.Getenv("AZURE_OPENAI_KEY"),
+//	        WireModel:  "my-gpt-deployment",
+//	    }.Config(),
+//	})
+type AzureProvider struct {
+	// BaseURL is the resource endpoint, without a path, e.g.
+	// "https://my-resource.openai.azure.com". Required.
+	BaseURL string
+	// APIVersion is the Azure OpenAI API version, e.g. "2024-10-21".
+	// Required.
+	APIVersion string
+	// APIKey is the resource's API key.
+	APIKey string
+	// BearerToken sets the Authorization header directly, such as a
+	// Microsoft Entra ID token, and takes precedence over APIKey.
+	BearerToken string
+	// WireAPI is "completions" (the default) or "responses".
+	WireAPI string
+	// Headers are custom HTTP headers included in provider requests.
+	Headers map[string]string
+	// ModelID is as in [ProviderConfig].
+	ModelID string
+	// WireModel is the deployment name, when it differs from ModelID.
+	WireModel       string
+	MaxPromptTokens int
+	MaxOutputTokens int
+	// BearerTokenProvider resolves a bearer token on demand, such as a
+	// managed identity token. See [ProviderConfig.BearerTokenProvider].
+	BearerTokenProvider BearerTokenProvider
+}
+
+// Config returns the provider as a [ProviderConfig].
+func (p AzureProvider) Config() *ProviderConfig {
+	return &ProviderConfig{
+		Type:                "azure",
+		WireAPI:             p.WireAPI,
+		BaseURL:             p.BaseURL,
+		APIKey:              p.APIKey,
+		BearerToken:         p.BearerToken,
+		Azure:               &AzureProviderOptions{APIVersion: p.APIVersion},
+		Headers:             p.Headers,
+		ModelID:             p.ModelID,
+		WireModel:           p.WireModel,
+		MaxPromptTokens:     p.MaxPromptTokens,
+		MaxOutputTokens:     p.MaxOutputTokens,
+		BearerTokenProvider: p.BearerTokenProvider,
+	}
+}
+
+// Validate reports the first problem with the provider's options.
+func (p AzureProvider) Validate() error {
+	return p.Config().Validate()
+}
+
+// AnthropicProvider configures the Anthropic API, or an API compatible with
+// it, as a BYOK provider. Use [AnthropicProvider.Config] to get the
+// [ProviderConfig] for [SessionConfig.Provider].
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Model: "claude-sonnet-4.5",
+//	    Provider: copilot.AnthropicProvider{
+//	        BaseURL: "https://api.anthropic.com",
+//	        APIKey:  os.Getenv("ANTHROPIC_API_KEY"),
+//	    }.Config(),
+//	})
+type AnthropicProvider struct {
+	// BaseURL is the API endpoint URL. Required.
+	BaseURL string
+	// APIKey is the API key.
+	APIKey string
+	// BearerToken sets the Authorization header directly, and takes
+	// precedence over APIKey.
+	BearerToken string
+	// Headers are custom HTTP headers included in provider requests.
+	Headers map[string]string
+	// ModelID, WireModel, MaxPromptTokens and MaxOutputTokens are as in
+	// [ProviderConfig].
+	ModelID         string
+	WireModel       string
+	MaxPromptTokens int
+	MaxOutputTokens int
+	// BearerTokenProvider resolves a bearer token on demand. See
+	// [ProviderConfig.BearerTokenProvider].
+	BearerTokenProvider BearerTokenProvider
+}
+
+// Config returns the provider as a [ProviderConfig].
+func (p AnthropicProvider) Config() *ProviderConfig {
+	return &ProviderConfig{
+		Type:                "anthropic",
+		BaseURL:             p.BaseURL,
+		APIKey:              p.APIKey,
+		BearerToken:         p.BearerToken,
+		Headers:             p.Headers,
+		ModelID:             p.ModelID,
+		WireModel:           p.WireModel,
+		MaxPromptTokens:     p.MaxPromptTokens,
+		MaxOutputTokens:     p.MaxOutputTokens,
+		BearerTokenProvider: p.BearerTokenProvider,
+	}
+}
+
+// Validate reports the first problem with the provider's options.
+func (p AnthropicProvider) Validate() error {
+	return p.Config().Validate()
+}
+
+// Validate reports the first problem with the provider's options, such as
+// a missing BaseURL or an option that does not apply to its Type.
+// [Client.CreateSession] and [Client.ResumeSessionWithOptions] call it
+// before creating the session, so that a misconfigured provider fails
+// there rather than on the first turn.
+func (p *ProviderConfig) Validate() error {
+	if p.MaxPromptTokens < 0 || p.MaxOutputTokens < 0 {
+		return errors.New("MaxPromptTokens and MaxOutputTokens must not be negative")
+	}
+	switch {
+	case p.Type == "bedrock":
+		return validateBedrockProvider(p)
+	case p.Type == "vertex":
+		return validateVertexProvider(p)
+	case p.Bedrock != nil:
+		return errors.New(`Bedrock options require Type "bedrock"`)
+	case p.Vertex != nil:
+		return errors.New(`Vertex options require Type "vertex"`)
+	}
+	return validateProvider(p.Type, p.BaseURL, p.WireAPI, p.Transport, p.Azure)
+}
+
+// Validate reports the first problem with the provider's options. See
+// [ProviderConfig.Validate].
+func (p *NamedProviderConfig) Validate() error {
+	if p.Name == "" {
+		return errors.New("Name is required")
+	}
+	if strings.Contains(p.Name, "/") {
+		return fmt.Errorf("Name %q must not contain \"/\"", p.Name)
+	}
+	if p.Type == "bedrock" || p.Type == "vertex" {
+		return fmt.Errorf("Type %q is supported only for SessionConfig.Provider", p.Type)
+	}
+	return validateProvider(p.Type, p.BaseURL, p.WireAPI, "", p.Azure)
+}
+
+func validateProvider(providerType, baseURL, wireAPI, transport string, azure *AzureProviderOptions) error {
+	switch providerType {
+	case "", "openai", "azure", "anthropic":
+	default:
+		return fmt.Errorf(`unknown Type %q: use "openai", "azure", "anthropic", "bedrock" or "vertex"`, providerType)
+	}
+
+	if baseURL == "" {
+		return errors.New("BaseURL is required")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid BaseURL %q: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("BaseURL %q must start with http:// or https://", baseURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("BaseURL %q has no host", baseURL)
+	}
+	isAzureHost := strings.HasSuffix(strings.ToLower(u.Hostname()), ".openai.azure.com")
+	if isAzureHost && providerType != "azure" {
+		return fmt.Errorf(`BaseURL %q is an Azure OpenAI endpoint: set Type to "azure"`, baseURL)
+	}
+	if providerType == "azure" && strings.HasPrefix(u.Path, "/openai") {
+		return fmt.Errorf("BaseURL %q should be the resource endpoint only: the /openai path is added automatically", baseURL)
+	}
+
+	switch wireAPI {
+	case "", "completions", "responses":
+	default:
+		return fmt.Errorf(`unknown WireAPI %q: use "completions" or "responses"`, wireAPI)
+	}
+	if wireAPI != "" && providerType == "anthropic" {
+		return errors.New("WireAPI applies only to openai and azure providers")
+	}
+	switch transport {
+	case "", "http":
+	case "websockets":
+		if wireAPI != "responses" {
+			return errors.New(`Transport "websockets" requires WireAPI "responses"`)
+		}
+	default:
+		return fmt.Errorf(`unknown Transport %q: use "http" or "websockets"`, transport)
+	}
+
+	if azure != nil {
+		if providerType != "azure" {
+			return errors.New(`Azure options require Type "azure"`)
+		}
+		if azure.APIVersion == "" {
+			return errors.New("Azure.APIVersion is required when Azure options are set")
+		}
+	}
+	return nil
+}
+
+// validateProviders validates a session's providers before it is created.
+func validateProviders(provider *ProviderConfig, providers []NamedProviderConfig) error {
+	if provider != nil {
+		if err := provider.Validate(); err != nil {
+			return fmt.Errorf("invalid provider: %w", err)
+		}
+	}
+	for i := range providers {
+		if err := providers[i].Validate(); err != nil {
+			return fmt.Errorf("invalid provider %q: %w", providers[i].Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package copilot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProviderConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		provider *ProviderConfig
+		want     string
+	}{
+		{name: "openai", provider: OpenAIProvider{BaseURL: "http://localhost:11434/v1"}.Config()},
+		{name: "azure", provider: AzureProvider{BaseURL: "https://r.openai.azure.com", APIVersion: "2024-10-21"}.Config()},
+		{name: "anthropic", provider: AnthropicProvider{BaseURL: "https://api.anthropic.com"}.Config()},
+		{name: "untyped azure with the default API version", provider: &ProviderConfig{Type: "azure", BaseURL: "https://r.openai.azure.com"}},
+		{name: "missing BaseURL", provider: OpenAIProvider{}.Config(), want: "BaseURL is required"},
+		{name: "BaseURL without scheme", provider: &ProviderConfig{BaseURL: "localhost:11434/v1"}, want: "must start with http:// or https://"},
+		{name: "missing Azure API version", provider: AzureProvider{BaseURL: "https://r.openai.azure.com"}.Config(), want: "Azure.APIVersion is required"},
+		{name: "Azure endpoint as openai", provider: &ProviderConfig{BaseURL: "https://r.openai.azure.com"}, want: `set Type to "azure"`},
+		{name: "Azure BaseURL with path", provider: AzureProvider{BaseURL: "https://r.openai.azure.com/openai/v1", APIVersion: "v1"}.Config(), want: "resource endpoint only"},
+		{name: "unknown type", provider: &ProviderConfig{Type: "gemini", BaseURL: "https://x"}, want: `unknown Type "gemini"`},
+		{name: "WireAPI for anthropic", provider: &ProviderConfig{Type: "anthropic", BaseURL: "https://x", WireAPI: "responses"}, want: "WireAPI applies only"},
+		{name: "websockets without responses", provider: OpenAIProvider{BaseURL: "https://x", Transport: "websockets"}.Config(), want: `requires WireAPI "responses"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.provider.Validate()
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	t.Run("typed providers set their type", func(t *testing.T) {
+		config := AzureProvider{BaseURL: "https://r.openai.azure.com", APIVersion: "2024-10-21", WireModel: "deployment"}.Config()
+		if config.Type != "azure" || config.Azure == nil || config.Azure.APIVersion != "2024-10-21" || config.WireModel != "deployment" {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+
+	t.Run("CreateSession rejects invalid providers before contacting the runtime", func(t *testing.T) {
+		client, requests, cleanup := newInMemoryClient(t)
+		defer cleanup()
+		_, err := client.CreateSession(t.Context(), &SessionConfig{
+			Model:     "gpt-4.1",
+			Providers: []NamedProviderConfig{{Name: "local", BaseURL: "ftp://models"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), `invalid provider "local"`) {
+			t.Fatalf("expected an invalid provider error, got %v", err)
+		}
+		if got := requests.snapshot(); len(got) != 0 {
+			t.Errorf("expected no requests, got %v", got)
+		}
+	})
+}
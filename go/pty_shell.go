@@ -0,0 +1,340 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PTYShellConfig configures the SDK-hosted shell tool enabled by
+// [SessionConfig.PTYShell].
+//
+// The tool replaces the built-in bash tool. Each command runs in its own
+// pseudo-terminal, its output is streamed to session handlers as
+// tool.output_delta events, and the host can type into it with
+// [Session.SendToolInput] — for example to answer a package manager's
+// confirmation prompt. Not supported on Windows.
+type PTYShellConfig struct {
+	// Shell runs each command as `Shell -c <command>`. Defaults to $SHELL,
+	// falling back to /bin/sh.
+	Shell string
+	// WorkingDirectory is the directory commands run in. Defaults to the
+	// session's [SessionConfig.WorkingDirectory], or else the current
+	// process's working directory.
+	WorkingDirectory string
+	// Env is added to the process environment for each command, after
+	// [SessionConfig.ShellEnvPolicy] filtering, which by default removes
+	// [SensitiveEnvPatterns].
+	Env []string
+	// Timeout bounds each command. Defaults to 10 minutes.
+	Timeout time.Duration
+	// Rows and Cols set the terminal size. Default to 40 rows by 120 columns.
+	Rows, Cols uint16
+	// MaxOutputBytes caps the output returned to the model; earlier output is
+	// dropped. Streamed output is never truncated. Defaults to 64 KiB.
+	MaxOutputBytes int
+	// Start starts cmd in a pseudo-terminal of the given size and returns
+	// the terminal. Set it to Start of the
+	// github.com/github/copilot-sdk/go/ptyshell module. When nil, commands
+	// run with pipes instead: output still streams and
+	// [Session.SendToolInput] writes to the command's standard input, but
+	// programs that check for a terminal behave as they would in a script.
+	Start func(cmd *exec.Cmd, rows, cols uint16) (*os.File, error)
+}
+
+func (c PTYShellConfig) withDefaults() PTYShellConfig {
+	if c.Shell == "" {
+		c.Shell = os.Getenv("SHELL")
+	}
+	if c.Shell == "" {
+		c.Shell = "/bin/sh"
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Minute
+	}
+	if c.Rows == 0 {
+		c.Rows = 40
+	}
+	if c.Cols == 0 {
+		c.Cols = 120
+	}
+	if c.MaxOutputBytes <= 0 {
+		c.MaxOutputBytes = 64 * 1024
+	}
+	return c
+}
+
+// ErrNoRunningTool is returned by [Session.SendToolInput] when no
+// PTY-backed tool call with the given ID is running.
+var ErrNoRunningTool = errors.New("no running tool call accepts input")
+
+// ptyShell runs shell tool calls for one session.
+type ptyShell struct {
+	config  PTYShellConfig
+	env     *ShellEnvPolicy
+	egress  *egressGuard
+	session *Session
+
+	mu      sync.Mutex
+	running map[string]io.Writer
+}
+
+func newPTYShell(config PTYShellConfig, env *ShellEnvPolicy, egress *egressGuard) *ptyShell {
+	return &ptyShell{config: config.withDefaults(), env: env, egress: egress, running: make(map[string]io.Writer)}
+}
+
+// withPTYShell returns tools with the PTY shell tool appended when config is
+// set or the session's egress policy needs the SDK to run shell commands.
+// The shell's environment is filtered by env.
+func withPTYShell(tools []Tool, config *PTYShellConfig, env *ShellEnvPolicy, egress *egressGuard) ([]Tool, *ptyShell) {
+	proxied := egress != nil && egress.policy.ProxyShellCommands
+	if config == nil && !proxied {
+		return tools, nil
+	}
+	if config == nil {
+		config = &PTYShellConfig{}
+	}
+	shell := newPTYShell(*config, env, egress)
+	return append(append([]Tool(nil), tools...), shell.tool()), shell
+}
+
+// attachPTYShell connects shell to the session so it can stream output.
+func (s *Session) attachPTYShell(shell *ptyShell) {
+	if shell == nil {
+		return
+	}
+	shell.session = s
+	s.ptyShell = shell
+}
+
+func (p *ptyShell) tool() Tool {
+	return Tool{
+		Name: "bash",
+		Description: "Run a shell command in an interactive terminal and return its output and exit code. " +
+			"The user can type into the terminal if the command prompts for input.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"command": map[string]any{
+					"type":        "string",
+					"description": "The command to run.",
+				},
+				"description": map[string]any{
+					"type":        "string",
+					"description": "A short description of what the command does.",
+				},
+			},
+			"required": []string{"command"},
+		},
+		OverridesBuiltInTool: true,
+		Handler:              p.run,
+	}
+}
+
+func (p *ptyShell) run(inv ToolInvocation) (ToolResult, error) {
+	args, _ := inv.Arguments.(map[string]any)
+	command, _ := args["command"].(string)
+	if command == "" {
+		return ToolResult{}, errors.New("command is required")
+	}
+
+	ctx := inv.TraceContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	proxyEnv, err := p.egress.proxyEnv()
+	if err != nil {
+		return ToolResult{}, err
+	}
+	cmd := exec.CommandContext(ctx, p.config.Shell, "-c", command)
+	cmd.Dir = p.config.WorkingDirectory
+	if cmd.Dir == "" && p.session != nil {
+		cmd.Dir = p.session.workingDirectory
+	}
+	cmd.Env = append(append(append(p.env.Environ(), "TERM=xterm-256color"), p.config.Env...), proxyEnv...)
+	start := time.Now()
+	output, input, err := p.start(cmd)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	p.mu.Lock()
+	p.running[inv.ToolCallID] = input
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, inv.ToolCallID)
+		p.mu.Unlock()
+		input.Close()
+		output.Close()
+	}()
+
+	// Reads block while background processes keep the terminal open, so
+	// close it once the deadline passes.
+	stop := context.AfterFunc(ctx, func() { output.Close() })
+	defer stop()
+
+	// Only the tail of the output is kept. It holds twice the returned size
+	// so that terminal escape sequences stripped from it do not leave the
+	// result short.
+	var collected []byte
+	keep := 2 * p.config.MaxOutputBytes
+	total := 0
+	buf := make([]byte, 4096)
+	for {
+		n, err := output.Read(buf)
+		if n > 0 {
+			p.emitOutput(inv, string(buf[:n]), total)
+			total += n
+			collected = append(collected, buf[:n]...)
+			if len(collected) > keep {
+				collected = append(collected[:0], collected[len(collected)-keep:]...)
+			}
+		}
+		if err != nil {
+			break // EIO or EOF once the command and its children exit
+		}
+	}
+	waitErr := cmd.Wait()
+	usage := ToolResourceUsage{WallTime: time.Since(start)}
+	if cmd.ProcessState != nil {
+		processResourceUsage(cmd.ProcessState, &usage)
+	}
+
+	text := stripANSI(string(collected))
+	truncated := total > len(collected)
+	if len(text) > p.config.MaxOutputBytes {
+		text = text[len(text)-p.config.MaxOutputBytes:]
+		truncated = true
+	}
+	if truncated {
+		text = "[earlier output truncated]\n" + text
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ToolResult{
+			TextResultForLLM: fmt.Sprintf("%s\n<command timed out after %s>", text, p.config.Timeout),
+			ResultType:       "failure",
+			Error:            "command timed out",
+			ToolTelemetry:    withResourceUsage(nil, usage),
+		}, nil
+	}
+	exitCode := 0
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return ToolResult{}, fmt.Errorf("failed to run command: %w", waitErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return ToolResult{
+		TextResultForLLM: fmt.Sprintf("%s\n<exited with exit code %d>", text, exitCode),
+		ResultType:       "success",
+		ToolTelemetry:    withResourceUsage(nil, usage),
+	}, nil
+}
+
+// start starts cmd with PTYShellConfig.Start, or with pipes when it is nil,
+// and returns the command's output and input.
+func (p *ptyShell) start(cmd *exec.Cmd) (io.ReadCloser, io.WriteCloser, error) {
+	if p.config.Start != nil {
+		terminal, err := p.config.Start(cmd, p.config.Rows, p.config.Cols)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to start command in a terminal: %w", err)
+		}
+		return terminal, terminal, nil
+	}
+	input, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	output, outputWriter, err := os.Pipe()
+	if err != nil {
+		input.Close()
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	err = cmd.Start()
+	// The command holds its own copy; closing ours lets reads end once the
+	// command and its children exit.
+	outputWriter.Close()
+	if err != nil {
+		input.Close()
+		output.Close()
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	return output, input, nil
+}
+
+// emitOutput delivers a chunk of terminal output to the session's handlers.
+func (p *ptyShell) emitOutput(inv ToolInvocation, chunk string, offset int) {
+	if p.session == nil {
+		return
+	}
+	ephemeral := true
+	p.session.dispatchEvent(SessionEvent{
+		Data: &ToolOutputDeltaData{
+			ToolCallID: inv.ToolCallID,
+			ToolName:   inv.ToolName,
+			Delta:      chunk,
+			Offset:     offset,
+		},
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+}
+
+func (p *ptyShell) write(toolCallID string, data []byte) error {
+	p.mu.Lock()
+	input, ok := p.running[toolCallID]
+	p.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoRunningTool, toolCallID)
+	}
+	if _, err := input.Write(data); err != nil {
+		return fmt.Errorf("failed to write tool input: %w", err)
+	}
+	return nil
+}
+
+// SendToolInput types data into the terminal of a running shell tool call,
+// as if a user pressed the keys, or writes it to the command's standard
+// input when [PTYShellConfig.Start] is nil. It requires [SessionConfig.PTYShell];
+// toolCallID comes from the tool.execution_start or tool.output_delta events
+// of the call. Returns [ErrNoRunningTool] if the call has finished.
+//
+// Example:
+//
+//	session.On(func(event copilot.SessionEvent) {
+//	    if d, ok := event.Data.(*copilot.ToolOutputDeltaData); ok && strings.Contains(d.Delta, "Proceed? [y/N]") {
+//	        session.SendToolInput(d.ToolCallID, []byte("y\n"))
+//	    }
+//	})
+func (s *Session) SendToolInput(toolCallID string, data []byte) error {
+	if s.ptyShell == nil {
+		return errors.New("session was not created with PTYShell enabled")
+	}
+	return s.ptyShell.write(toolCallID, data)
+}
+
+// ansiSequence matches CSI and OSC escape sequences and lone escapes.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// stripANSI removes terminal escape sequences and carriage returns so output
+// reads cleanly for the model.
+func stripANSI(s string) string {
+	s = ansiSequence.ReplaceAllString(s, "")
+	return strings.ReplaceAll(s, "\r", "")
+}
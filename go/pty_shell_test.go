@@ -0,0 +1,81 @@
+package copilot
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPTYShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("PTY shell is not supported on Windows")
+	}
+
+	t.Run("answers prompts sent with SendToolInput", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		tools, shell := withPTYShell(nil, &PTYShellConfig{Shell: "/bin/sh", Timeout: 10 * time.Second}, nil, nil)
+		session.attachPTYShell(shell)
+		if len(tools) != 1 || tools[0].Name != "bash" || !tools[0].OverridesBuiltInTool {
+			t.Fatalf("unexpected tools: %+v", tools)
+		}
+
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*ToolOutputDeltaData); ok && strings.Contains(d.Delta, "Proceed?") {
+				if err := session.SendToolInput(d.ToolCallID, []byte("y\n")); err != nil {
+					t.Errorf("SendToolInput failed: %v", err)
+				}
+			}
+		})
+
+		result, err := tools[0].Handler(ToolInvocation{
+			ToolCallID: "call-1",
+			ToolName:   "bash",
+			Arguments:  map[string]any{"command": `printf '\033[1mProceed?\033[0m '; read answer; echo "got $answer"; exit 3`},
+		})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if !strings.Contains(result.TextResultForLLM, "got y") || !strings.Contains(result.TextResultForLLM, "exit code 3") {
+			t.Errorf("unexpected result: %q", result.TextResultForLLM)
+		}
+		if strings.Contains(result.TextResultForLLM, "\x1b") {
+			t.Errorf("expected ANSI sequences to be stripped: %q", result.TextResultForLLM)
+		}
+		if err := session.SendToolInput("call-1", []byte("y\n")); !errors.Is(err, ErrNoRunningTool) {
+			t.Errorf("expected ErrNoRunningTool after completion, got %v", err)
+		}
+	})
+
+	t.Run("times out commands", func(t *testing.T) {
+		_, shell := withPTYShell(nil, &PTYShellConfig{Shell: "/bin/sh", Timeout: 100 * time.Millisecond}, nil, nil)
+		result, err := shell.run(ToolInvocation{ToolCallID: "call-2", Arguments: map[string]any{"command": "sleep 5"}})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if result.ResultType != "failure" || !strings.Contains(result.TextResultForLLM, "timed out") {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("keeps only the tail of long output", func(t *testing.T) {
+		_, shell := withPTYShell(nil, &PTYShellConfig{Shell: "/bin/sh", Timeout: 10 * time.Second, MaxOutputBytes: 16}, nil, nil)
+		result, err := shell.run(ToolInvocation{ToolCallID: "call-3", Arguments: map[string]any{"command": "seq 1 10000"}})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if !strings.HasPrefix(result.TextResultForLLM, "[earlier output truncated]\n") || !strings.Contains(result.TextResultForLLM, "9999\n10000\n") {
+			t.Errorf("unexpected result: %q", result.TextResultForLLM)
+		}
+	})
+
+	t.Run("requires PTYShell for SendToolInput", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		if err := session.SendToolInput("call-1", []byte("y")); err == nil {
+			t.Error("expected an error without PTYShell")
+		}
+	})
+}
@@ -0,0 +1,5 @@
+module github.com/github/copilot-sdk/go/ptyshell
+
+go 1.24
+
+require github.com/creack/pty v1.1.24
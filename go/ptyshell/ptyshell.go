@@ -0,0 +1,23 @@
+// Package ptyshell runs the commands of the SDK-hosted shell tool in
+// pseudo-terminals, for copilot.PTYShellConfig.Start. Not supported on
+// Windows.
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    PTYShell: &copilot.PTYShellConfig{Start: ptyshell.Start},
+//	})
+package ptyshell
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// Start starts cmd in a new pseudo-terminal of rows by cols and returns
+// the terminal.
+func Start(cmd *exec.Cmd, rows, cols uint16) (*os.File, error) {
+	return pty.StartWithSize(cmd, &pty.Winsize{Rows: rows, Cols: cols})
+}
@@ -0,0 +1,25 @@
+package ptyshell
+
+import (
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals are not supported on Windows")
+	}
+	terminal, err := Start(exec.Command("/bin/sh", "-c", "stty size; test -t 0 && echo tty"), 30, 90)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer terminal.Close()
+	// Reads end with EIO once the command exits.
+	output, _ := io.ReadAll(terminal)
+	if !strings.Contains(string(output), "30 90") || !strings.Contains(string(output), "tty") {
+		t.Errorf("expected a 30x90 terminal, got %q", output)
+	}
+}
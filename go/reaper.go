@@ -0,0 +1,243 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// SessionReaper closes sessions the runtime keeps in memory for clients that
+// are gone. See [ClientOptions.SessionReaper].
+type SessionReaper struct {
+	// OlderThan is how long a session's owner must go without recording a
+	// heartbeat before the session is considered orphaned. Owners record one
+	// every minute. Defaults to 1 hour.
+	OlderThan time.Duration
+	// Interval is the time between automatic reaps. Defaults to 10 minutes.
+	Interval time.Duration
+}
+
+func (r SessionReaper) withDefaults() SessionReaper {
+	if r.OlderThan <= 0 {
+		r.OlderThan = time.Hour
+	}
+	if r.Interval <= 0 {
+		r.Interval = 10 * time.Minute
+	}
+	return r
+}
+
+// sessionOwnerFile is the name of the owner record in a session's state
+// directory.
+const sessionOwnerFile = "sdk-owner.json"
+
+// ownerHeartbeatInterval is how often a client records that it still owns
+// its open sessions.
+var ownerHeartbeatInterval = time.Minute
+
+// sessionOwner records the client that has a session open. It is kept next
+// to the session's event log, like its metadata.
+type sessionOwner struct {
+	ClientID  string    `json:"clientId"`
+	Heartbeat time.Time `json:"heartbeat"`
+}
+
+// readSessionOwner reads the owner record in the session directory dir. It
+// returns nil if there is none.
+func readSessionOwner(dir string) (*sessionOwner, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionOwnerFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session owner: %w", err)
+	}
+	var owner sessionOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, fmt.Errorf("failed to decode session owner: %w", err)
+	}
+	return &owner, nil
+}
+
+// ReapOrphanedSessions closes local sessions whose owner has stopped
+// heartbeating, and returns their IDs.
+//
+// A client that crashes leaves its sessions loaded in the runtime; when the
+// runtime outlives the client, as it does with [URIConnection] or
+// [UnixSocketConnection], each crash-restart leaks their memory. Every
+// client records, next to the event log of each session it has open, its
+// ID and a heartbeat refreshed every minute. A session not open on this
+// client whose owner's last heartbeat is older than olderThan is closed:
+// its events are flushed and it is released from the runtime's memory, but
+// it stays on disk, so it can still be resumed with [Client.ResumeSession].
+// Sessions without an owner record, such as those created by other tools,
+// are left alone. Like [SessionConfig.Metadata], owner records require the
+// runtime's session store to be on a filesystem this process can reach.
+//
+// An error is returned if any close failed; sessions closed before the
+// failure are still reported.
+//
+// Example:
+//
+//	reaped, err := client.ReapOrphanedSessions(ctx, time.Hour)
+//	if err != nil {
+//	    log.Printf("failed to reap sessions: %v", err)
+//	}
+//	log.Printf("closed %d orphaned sessions", len(reaped))
+func (c *Client) ReapOrphanedSessions(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	return c.reapOrphanedSessions(ctx, time.Now().Add(-olderThan))
+}
+
+// reapOrphanedSessions closes the sessions not open on this client whose
+// owner's last heartbeat was before cutoff.
+func (c *Client) reapOrphanedSessions(ctx context.Context, cutoff time.Time) ([]string, error) {
+	sessions, err := c.ListSessions(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	c.sessionsMux.Lock()
+	open := make(map[string]bool, len(c.sessions))
+	for id := range c.sessions {
+		open[id] = true
+	}
+	c.sessionsMux.Unlock()
+
+	var reaped []string
+	var errs []error
+	for i, eventFile := range c.sessionEventFiles(ctx, sessions) {
+		id := sessions[i].SessionID
+		if eventFile == "" || open[id] {
+			continue
+		}
+		dir := filepath.Dir(eventFile)
+		owner, err := readSessionOwner(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", id, err))
+			continue
+		}
+		if owner == nil || owner.ClientID == c.id || !owner.Heartbeat.Before(cutoff) {
+			continue
+		}
+		if _, err := c.RPC.Sessions.Close(ctx, &rpc.SessionsCloseRequest{SessionID: id}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close session %s: %w", id, err))
+			continue
+		}
+		// The session has no owner now, so later runs skip it. Should the
+		// record survive, they close it again, which is a no-op.
+		os.Remove(filepath.Join(dir, sessionOwnerFile))
+		reaped = append(reaped, id)
+	}
+	return reaped, errors.Join(errs...)
+}
+
+// recordOwnership writes this client's owner record, with a fresh heartbeat,
+// for each of its open sessions. dirs caches the state directories of the
+// sessions by ID and is updated to hold only the open ones.
+func (c *Client) recordOwnership(ctx context.Context, dirs map[string]string) {
+	c.sessionsMux.Lock()
+	ids := make([]string, 0, len(c.sessions))
+	for id := range c.sessions {
+		ids = append(ids, id)
+	}
+	c.sessionsMux.Unlock()
+
+	data, _ := json.Marshal(sessionOwner{ClientID: c.id, Heartbeat: time.Now()})
+	open := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		open[id] = true
+		dir, ok := dirs[id]
+		if !ok {
+			eventFile, err := c.sessionEventFilePath(ctx, id)
+			if err != nil {
+				c.logger().Debug("failed to locate session to record its owner", "sessionId", id, "error", err)
+				continue
+			}
+			dir = filepath.Dir(eventFile)
+			dirs[id] = dir
+		}
+		if err := writeSessionFile(dir, sessionOwnerFile, data); err != nil {
+			c.logger().Debug("failed to record session owner", "sessionId", id, "error", err)
+		}
+	}
+	for id := range dirs {
+		if !open[id] {
+			delete(dirs, id)
+		}
+	}
+}
+
+// startReaper starts the orphaned session reaper loop for the current
+// connection, and the loop recording this client as the owner of its open
+// sessions, which runs whether or not this client reaps. Must be called
+// with startStopMux held.
+func (c *Client) startReaper() {
+	c.stopReaper()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ownerCancel = cancel
+	go c.runOwnerHeartbeat(ctx)
+	if c.options.SessionReaper == nil {
+		return
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	c.reaperCancel = cancel
+	go c.runReaper(ctx, c.options.SessionReaper.withDefaults())
+}
+
+// stopReaper stops the orphaned session reaper and owner heartbeat loops,
+// if running. Must be called with startStopMux held.
+func (c *Client) stopReaper() {
+	if c.reaperCancel != nil {
+		c.reaperCancel()
+		c.reaperCancel = nil
+	}
+	if c.ownerCancel != nil {
+		c.ownerCancel()
+		c.ownerCancel = nil
+	}
+}
+
+// claimSessions asks the owner heartbeat loop to record this client as the
+// owner of a session it just opened, without waiting for the next tick.
+func (c *Client) claimSessions() {
+	select {
+	case c.ownerWake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) runOwnerHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(ownerHeartbeatInterval)
+	defer ticker.Stop()
+	dirs := make(map[string]string)
+	for {
+		c.recordOwnership(ctx, dirs)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.ownerWake:
+		}
+	}
+}
+
+func (c *Client) runReaper(ctx context.Context, reaper SessionReaper) {
+	ticker := time.NewTicker(reaper.Interval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		// Failures are retried on the next tick; callers who need to observe
+		// them can call ReapOrphanedSessions directly.
+		c.reapOrphanedSessions(ctx, time.Now().Add(-reaper.OlderThan))
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
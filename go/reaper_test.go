@@ -0,0 +1,149 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestClient_ReapOrphanedSessions(t *testing.T) {
+	now := time.Now()
+	sessions := []map[string]any{
+		{"sessionId": "mine", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour)},
+		{"sessionId": "theirs", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour)},
+		{"sessionId": "crashed", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour)},
+		{"sessionId": "unowned", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour)},
+		{"sessionId": "remote", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour), "isRemote": true},
+	}
+	// startRuntime starts a client whose runtime shares stateDir with the
+	// other clients of the test, as clients of one runtime do.
+	startRuntime := func(t *testing.T, stateDir string) (*Client, func() []string) {
+		var mu sync.Mutex
+		var closed []string
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.list": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				result, _ := json.Marshal(map[string]any{"sessions": sessions})
+				return result, nil
+			},
+			"sessions.getEventFilePath": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req rpc.SessionsGetEventFilePathRequest
+				json.Unmarshal(params, &req)
+				result, _ := json.Marshal(rpc.SessionsGetEventFilePathResult{FilePath: filepath.Join(stateDir, req.SessionID, "events.jsonl")})
+				return result, nil
+			},
+			"sessions.close": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct{ SessionID string }
+				json.Unmarshal(params, &req)
+				mu.Lock()
+				closed = append(closed, req.SessionID)
+				mu.Unlock()
+				return []byte(`{}`), nil
+			},
+		})
+		return client, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(closed)
+		}
+	}
+	open := func(t *testing.T, client *Client, sessionID string) {
+		client.sessionsMux.Lock()
+		client.sessions[sessionID] = &Session{SessionID: sessionID}
+		client.sessionsMux.Unlock()
+		t.Cleanup(func() {
+			client.sessionsMux.Lock()
+			delete(client.sessions, sessionID)
+			client.sessionsMux.Unlock()
+		})
+	}
+	// setup opens "mine" on one client and "theirs" on another, and leaves
+	// an owner record for "crashed" that stopped heartbeating two hours ago.
+	setup := func(t *testing.T) (mine, theirs *Client, requested func() []string, stateDir string) {
+		stateDir = t.TempDir()
+		mine, requested = startRuntime(t, stateDir)
+		theirs, _ = startRuntime(t, stateDir)
+		open(t, mine, "mine")
+		open(t, theirs, "theirs")
+		mine.recordOwnership(t.Context(), make(map[string]string))
+		theirs.recordOwnership(t.Context(), make(map[string]string))
+		data, _ := json.Marshal(sessionOwner{ClientID: "gone", Heartbeat: now.Add(-2 * time.Hour)})
+		if err := writeSessionFile(filepath.Join(stateDir, "crashed"), sessionOwnerFile, data); err != nil {
+			t.Fatal(err)
+		}
+		return mine, theirs, requested, stateDir
+	}
+
+	t.Run("closes only sessions whose owner stopped heartbeating", func(t *testing.T) {
+		client, _, requested, stateDir := setup(t)
+		reaped, err := client.ReapOrphanedSessions(t.Context(), time.Hour)
+		if err != nil {
+			t.Fatalf("ReapOrphanedSessions failed: %v", err)
+		}
+		if !slices.Equal(reaped, []string{"crashed"}) {
+			t.Errorf("reaped %v, want [crashed]", reaped)
+		}
+		if got := requested(); !slices.Equal(got, []string{"crashed"}) {
+			t.Errorf("requested close of %v, want [crashed]", got)
+		}
+		if _, err := os.Stat(filepath.Join(stateDir, "crashed", sessionOwnerFile)); !os.IsNotExist(err) {
+			t.Errorf("expected the owner record of the reaped session to be removed, got %v", err)
+		}
+		if reaped, _ := client.ReapOrphanedSessions(t.Context(), time.Hour); len(reaped) != 0 {
+			t.Errorf("expected a later run to skip reaped sessions, got %v", reaped)
+		}
+	})
+
+	t.Run("closes the sessions of another client once it stops heartbeating", func(t *testing.T) {
+		client, _, _, _ := setup(t)
+		// A cutoff after every heartbeat so far is what a reap sees once the
+		// other client has crashed and olderThan has passed.
+		reaped, err := client.reapOrphanedSessions(t.Context(), time.Now().Add(time.Second))
+		if err != nil {
+			t.Fatalf("reapOrphanedSessions failed: %v", err)
+		}
+		if !slices.Equal(reaped, []string{"theirs", "crashed"}) {
+			t.Errorf("reaped %v, want [theirs crashed]", reaped)
+		}
+	})
+
+	t.Run("records the owner of sessions as they are opened", func(t *testing.T) {
+		stateDir := t.TempDir()
+		client, _ := startRuntime(t, stateDir)
+		open(t, client, "mine")
+		client.claimSessions()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			owner, _ := readSessionOwner(filepath.Join(stateDir, "mine"))
+			if owner != nil && owner.ClientID == client.id {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for the owner record, got %+v", owner)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("runs automatically when SessionReaper is set", func(t *testing.T) {
+		client, _, requested, _ := setup(t)
+		client.startStopMux.Lock()
+		client.options.SessionReaper = &SessionReaper{OlderThan: time.Hour}
+		client.startReaper()
+		client.startStopMux.Unlock()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !slices.Contains(requested(), "crashed") {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for automatic reap, requested %v", requested())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ReconnectPolicy controls automatic reconnection to an existing runtime.
+// See [ClientOptions.Reconnect].
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of reconnection attempts before giving up.
+	// 0 means retry until [Client.Stop] is called.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt. Defaults to 500ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each failed attempt.
+	// Defaults to 2.
+	Multiplier float64
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	return p
+}
+
+// backoff returns the delay before the given (1-based) attempt.
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	return min(time.Duration(delay), p.MaxBackoff)
+}
+
+// ConnectionState is the state of the client's connection to the runtime, as
+// reported to [Client.OnConnectionStateChange] handlers.
+type ConnectionState string
+
+const (
+	// ConnectionStateReconnecting means the connection dropped and the client
+	// is trying to restore it.
+	ConnectionStateReconnecting ConnectionState = "reconnecting"
+	// ConnectionStateConnected means the connection was restored and open
+	// sessions were re-attached.
+	ConnectionStateConnected ConnectionState = "connected"
+	// ConnectionStateDisconnected means the client gave up reconnecting.
+	ConnectionStateDisconnected ConnectionState = "disconnected"
+)
+
+// ConnectionStateChange describes a connection state transition.
+type ConnectionStateChange struct {
+	State ConnectionState
+	// Attempt is the number of reconnection attempts made so far.
+	Attempt int
+	// Err is the error from the last failed attempt while reconnecting, or
+	// the reason the client gave up. For [ConnectionStateConnected] it joins
+	// the errors of any sessions that could not be re-attached.
+	Err error
+	// FailedSessions lists the IDs of sessions that could not be re-attached
+	// after the connection was restored. Those sessions are no longer usable.
+	FailedSessions []string
+}
+
+// ConnectionStateHandler handles connection state changes.
+type ConnectionStateHandler func(change ConnectionStateChange)
+
+// OnConnectionStateChange subscribes to connection state changes caused by
+// automatic reconnection (see [ClientOptions.Reconnect]). It reports the
+// [Client.OnStateChange] changes made while a dropped connection is
+// restored: losing it, each failed attempt, and the reconnection or giving
+// up. A connection lost while heartbeats were already failing is first
+// reported with the first failed attempt.
+//
+// Handlers are called from a background goroutine. Returns a function that,
+// when called, unsubscribes the handler.
+//
+// Example:
+//
+//	unsubscribe := client.OnConnectionStateChange(func(change copilot.ConnectionStateChange) {
+//	    log.Printf("runtime connection %s (attempt %d): %v", change.State, change.Attempt, change.Err)
+//	})
+//	defer unsubscribe()
+func (c *Client) OnConnectionStateChange(handler ConnectionStateHandler) func() {
+	return c.OnStateChange(func(change ClientStateChange) {
+		if connection, ok := c.connectionStateChange(change); ok {
+			handler(connection)
+		}
+	})
+}
+
+// connectionStateChange returns the connection state change that change
+// reports, if it was made by reconnection.
+func (c *Client) connectionStateChange(change ClientStateChange) (ConnectionStateChange, bool) {
+	switch {
+	case change.State == ClientStateDegraded && change.Attempt == 0:
+		if !errors.Is(change.Err, ErrConnectionLost) || !c.isExternalServer || c.options.Reconnect == nil {
+			return ConnectionStateChange{}, false
+		}
+		return ConnectionStateChange{State: ConnectionStateReconnecting}, true
+	case change.State == ClientStateDegraded:
+		return ConnectionStateChange{State: ConnectionStateReconnecting, Attempt: change.Attempt, Err: change.Err}, true
+	case change.State == ClientStateReady && change.Attempt > 0:
+		connected := ConnectionStateChange{State: ConnectionStateConnected, Attempt: change.Attempt, Err: change.Err}
+		if change.Reattached != nil {
+			connected.FailedSessions = slices.Sorted(maps.Keys(change.Reattached.Failed))
+		}
+		return connected, true
+	case change.State == ClientStateStopped && change.Attempt > 0:
+		return ConnectionStateChange{State: ConnectionStateDisconnected, Attempt: change.Attempt, Err: change.Err}, true
+	}
+	return ConnectionStateChange{}, false
+}
+
+// errReconnectAborted is returned by reconnectOnce when the client was
+// stopped or restarted while reconnecting.
+var errReconnectAborted = errors.New("reconnect aborted")
+
+// reconnect restores a dropped connection to an external runtime and
+// re-attaches open sessions. dead is the JSON-RPC client whose connection
+// dropped; reconnection is abandoned if the client moves on from it (for
+// example because Stop was called).
+func (c *Client) reconnect(dead *jsonrpc2.Client) {
+	c.startStopMux.Lock()
+	if c.client != dead || c.reconnectCancel != nil {
+		c.startStopMux.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.reconnectCancel = cancel
+	c.state = stateConnecting
+	c.startStopMux.Unlock()
+
+	defer cancel()
+
+	policy := c.options.Reconnect.withDefaults()
+	current := dead
+
+	var lastErr error
+	attempt := 0
+	for policy.MaxAttempts == 0 || attempt < policy.MaxAttempts {
+		attempt++
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(policy.backoff(attempt)):
+		}
+
+		lastErr = c.reconnectOnce(ctx, &current)
+		if ctx.Err() != nil {
+			return // Stop was called
+		}
+		if errors.Is(lastErr, errReconnectAborted) {
+			c.startStopMux.Lock()
+			c.reconnectCancel = nil
+			c.startStopMux.Unlock()
+			return
+		}
+		if lastErr == nil {
+			c.incrementCounter(MetricReconnects, 1, map[string]string{"outcome": "success"})
+			result := c.reattachSessions(ctx, c.openSessions())
+			// Ready once sessions are usable again, not just the connection.
+			c.changeState(ClientStateChange{
+				State:      ClientStateReady,
+				Err:        result.err(),
+				Attempt:    attempt,
+				Reattached: result,
+			}, ClientStateDegraded)
+			return
+		}
+		c.changeState(ClientStateChange{State: ClientStateDegraded, Err: lastErr, Attempt: attempt}, ClientStateDegraded)
+	}
+
+	c.startStopMux.Lock()
+	c.reconnectCancel = nil
+	if c.client == current {
+		c.state = stateDisconnected
+	}
+	c.startStopMux.Unlock()
+	c.incrementCounter(MetricReconnects, 1, map[string]string{"outcome": "failure"})
+	c.changeState(ClientStateChange{
+		State:   ClientStateStopped,
+		Err:     fmt.Errorf("failed to reconnect after %d attempts: %w", attempt, lastErr),
+		Attempt: attempt,
+	}, ClientStateDegraded)
+}
+
+// reconnectOnce makes a single attempt to connect and handshake with the
+// runtime. current tracks the most recent JSON-RPC client so a Stop or Start
+// that happens in the meantime can be detected.
+func (c *Client) reconnectOnce(ctx context.Context, current **jsonrpc2.Client) error {
+	c.startStopMux.Lock()
+	defer c.startStopMux.Unlock()
+
+	if c.client != *current {
+		return errReconnectAborted
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.client.Stop()
+
+	if err := c.connectToServer(ctx); err != nil {
+		return err
+	}
+	*current = c.client
+	if err := c.verifyProtocolVersion(ctx); err != nil {
+		return err
+	}
+	if err := c.registerProviders(ctx); err != nil {
+		return err
+	}
+	c.state = stateConnected
+	c.healthy.Store(true)
+	// From here on a new drop starts a fresh reconnect loop.
+	c.reconnectCancel = nil
+	return nil
+}
+
+// openSessions returns the client's sessions.
+func (c *Client) openSessions() []*Session {
+	c.sessionsMux.Lock()
+	defer c.sessionsMux.Unlock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// reattachSessions resumes sessions that are still open on the current
+// connection.
+func (c *Client) reattachSessions(ctx context.Context, sessions []*Session) *RestartResult {
+	c.startStopMux.RLock()
+	client := c.client
+	c.startStopMux.RUnlock()
+
+	result := &RestartResult{}
+	for _, session := range sessions {
+		if session.isClosed() {
+			continue
+		}
+		if err := c.reattachSession(ctx, client, session); err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]error)
+			}
+			result.Failed[session.SessionID] = err
+			continue
+		}
+		result.Recovered = append(result.Recovered, session.SessionID)
+	}
+	return result
+}
+
+func (c *Client) reattachSession(ctx context.Context, client *jsonrpc2.Client, session *Session) error {
+	sessionRPC := rpc.NewSessionRPC(client, session.SessionID)
+	session.RPC = sessionRPC
+	session.conn.Store(&sessionConn{client: client, rpc: sessionRPC})
+
+	params, err := reattachParams(session.attachRequest, session.SessionID)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Request(ctx, "session.resume", params); err != nil {
+		return err
+	}
+	if err := session.folders.allow(ctx, session); err != nil {
+		return err
+	}
+
+	session.mcpAuthMu.RLock()
+	mcpAuth := session.mcpAuthHandler != nil
+	session.mcpAuthMu.RUnlock()
+	if mcpAuth {
+		if _, err := client.Request(ctx, "session.eventLog.registerInterest", map[string]any{
+			"sessionId": session.SessionID,
+			"eventType": "mcp.oauth_required",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reattachParams turns the wire request a session was created or resumed
+// with into a session.resume request that silently re-attaches it.
+func reattachParams(attachRequest json.RawMessage, sessionID string) (map[string]any, error) {
+	params := map[string]any{}
+	if attachRequest != nil {
+		if err := json.Unmarshal(attachRequest, &params); err != nil {
+			return nil, fmt.Errorf("failed to decode session request: %w", err)
+		}
+	}
+	// Options that only apply when the session is first created, or to the
+	// original call, do not carry over.
+	delete(params, "cloud")
+	delete(params, "continuePendingWork")
+	delete(params, "traceparent")
+	delete(params, "tracestate")
+	params["sessionId"] = sessionID
+	params["disableResume"] = true
+	return params, nil
+}
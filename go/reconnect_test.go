@@ -0,0 +1,248 @@
+package copilot
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestReconnectPolicy_backoff(t *testing.T) {
+	policy := ReconnectPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, expected := range want {
+		if got := policy.backoff(i + 1); got != expected {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, expected, got)
+		}
+	}
+}
+
+func TestReattachParams(t *testing.T) {
+	raw := json.RawMessage(`{"sessionId":"","model":"gpt-5","cloud":{},"continuePendingWork":true,"traceparent":"00-abc"}`)
+	params, err := reattachParams(raw, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["sessionId"] != "s1" || params["model"] != "gpt-5" || params["disableResume"] != true {
+		t.Errorf("unexpected params: %v", params)
+	}
+	for _, key := range []string{"cloud", "continuePendingWork", "traceparent"} {
+		if _, ok := params[key]; ok {
+			t.Errorf("expected %s to be dropped, got %v", key, params)
+		}
+	}
+}
+
+// reconnectTestServer is a fake runtime on a unix socket whose connections
+// can be dropped from the server side.
+type reconnectTestServer struct {
+	listener net.Listener
+	conns    chan net.Conn
+	resumed  chan json.RawMessage
+	// rejectResume makes session.resume fail.
+	rejectResume atomic.Bool
+}
+
+func newReconnectTestServer(t *testing.T) *reconnectTestServer {
+	t.Helper()
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "rt.sock"))
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &reconnectTestServer{listener: listener, conns: make(chan net.Conn, 8), resumed: make(chan json.RawMessage, 8)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server := jsonrpc2.NewClient(conn, conn)
+			server.SetRequestHandler("connect", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+			})
+			server.SetRequestHandler("session.create", func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return sessionIDResult(params), nil
+			})
+			server.SetRequestHandler("session.resume", func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				s.resumed <- append(json.RawMessage(nil), params...)
+				if s.rejectResume.Load() {
+					return nil, &jsonrpc2.Error{Code: -32602, Message: "session not found"}
+				}
+				return sessionIDResult(params), nil
+			})
+			server.SetRequestHandler("session.destroy", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{}`), nil
+			})
+			server.Start()
+			s.conns <- conn
+		}
+	}()
+	return s
+}
+
+func sessionIDResult(params json.RawMessage) json.RawMessage {
+	var req struct {
+		SessionID string `json:"sessionId"`
+	}
+	json.Unmarshal(params, &req)
+	result, _ := json.Marshal(map[string]any{"sessionId": req.SessionID})
+	return result
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	t.Run("reconnects and re-attaches sessions after the connection drops", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		sink := &recordingSink{}
+		client := NewClient(&ClientOptions{
+			Connection:  UnixSocketConnection{Path: server.listener.Addr().String()},
+			Reconnect:   &ReconnectPolicy{InitialBackoff: 10 * time.Millisecond},
+			MetricsSink: sink,
+		})
+		changes := make(chan ClientStateChange, 16)
+		client.OnStateChange(func(change ClientStateChange) { changes <- change })
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Model:               "gpt-5",
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer client.Stop()
+
+		(<-server.conns).Close()
+
+		var states []ClientState
+		timeout := time.After(5 * time.Second)
+		for reconnected := false; !reconnected; {
+			select {
+			case change := <-changes:
+				states = append(states, change.State)
+				if change.State == ClientStateReady && change.Previous == ClientStateDegraded {
+					reconnected = true
+					if change.Err != nil || change.Reattached == nil || len(change.Reattached.Failed) > 0 || len(change.Reattached.Recovered) != 1 {
+						t.Errorf("unexpected re-attach result: %v %+v", change.Err, change.Reattached)
+					}
+					if change.Attempt != 1 {
+						t.Errorf("expected to reconnect on the first attempt, got %d", change.Attempt)
+					}
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting to reconnect, saw %v", states)
+			}
+		}
+		if want := []ClientState{ClientStateStarting, ClientStateReady, ClientStateDegraded, ClientStateReady}; !slices.Equal(states, want) {
+			t.Errorf("expected states %v, got %v", want, states)
+		}
+		if got := sink.recordsOf(MetricReconnects); got != `copilot_reconnects_total{outcome="success"} += 1` {
+			t.Errorf("expected a successful reconnect metric, got %q", got)
+		}
+
+		var params map[string]any
+		if err := json.Unmarshal(<-server.resumed, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params["sessionId"] != session.SessionID || params["model"] != "gpt-5" || params["disableResume"] != true {
+			t.Errorf("unexpected re-attach request: %v", params)
+		}
+		if session.conn.Load().client != client.client {
+			t.Error("expected the session to use the new connection")
+		}
+	})
+
+	t.Run("reports reconnection through OnConnectionStateChange", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{
+			Connection: UnixSocketConnection{Path: server.listener.Addr().String()},
+			Reconnect:  &ReconnectPolicy{InitialBackoff: 10 * time.Millisecond},
+		})
+		changes := make(chan ConnectionStateChange, 8)
+		client.OnConnectionStateChange(func(change ConnectionStateChange) { changes <- change })
+		if _, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll}); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer client.Stop()
+
+		(<-server.conns).Close()
+
+		var states []ConnectionState
+		timeout := time.After(5 * time.Second)
+		for len(states) == 0 || states[len(states)-1] != ConnectionStateConnected {
+			select {
+			case change := <-changes:
+				states = append(states, change.State)
+				if change.State == ConnectionStateConnected && (change.Err != nil || len(change.FailedSessions) > 0 || change.Attempt != 1) {
+					t.Errorf("unexpected reconnection %+v", change)
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting to reconnect, saw %v", states)
+			}
+		}
+		if want := []ConnectionState{ConnectionStateReconnecting, ConnectionStateConnected}; !slices.Equal(states, want) {
+			t.Errorf("expected connection states %v, got %v", want, states)
+		}
+	})
+
+	t.Run("gives up after MaxAttempts", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		sink := &recordingSink{}
+		client := NewClient(&ClientOptions{
+			Connection:  UnixSocketConnection{Path: server.listener.Addr().String()},
+			Reconnect:   &ReconnectPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+			MetricsSink: sink,
+		})
+		changes := make(chan ClientStateChange, 16)
+		client.OnStateChange(func(change ClientStateChange) { changes <- change })
+		connection := make(chan ConnectionStateChange, 8)
+		client.OnConnectionStateChange(func(change ConnectionStateChange) { connection <- change })
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer client.Stop()
+
+		server.listener.Close()
+		(<-server.conns).Close()
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case change := <-changes:
+				if change.State != ClientStateStopped || change.Previous != ClientStateDegraded {
+					continue
+				}
+				if change.Attempt != 2 || change.Err == nil {
+					t.Errorf("expected to give up after 2 attempts with an error, got %+v", change)
+				}
+				if got := sink.recordsOf(MetricReconnects); got != `copilot_reconnects_total{outcome="failure"} += 1` {
+					t.Errorf("expected a failed reconnect metric, got %q", got)
+				}
+				var states []ConnectionState
+				for len(states) == 0 || states[len(states)-1] != ConnectionStateDisconnected {
+					select {
+					case change := <-connection:
+						states = append(states, change.State)
+						if change.State == ConnectionStateDisconnected && (change.Attempt != 2 || change.Err == nil) {
+							t.Errorf("expected OnConnectionStateChange to give up after 2 attempts, got %+v", change)
+						}
+					case <-time.After(time.Second):
+						t.Fatalf("timed out waiting for OnConnectionStateChange, saw %v", states)
+					}
+				}
+				want := []ConnectionState{ConnectionStateReconnecting, ConnectionStateReconnecting, ConnectionStateReconnecting, ConnectionStateDisconnected}
+				if !slices.Equal(states, want) {
+					t.Errorf("expected connection states %v, got %v", want, states)
+				}
+				return
+			case <-timeout:
+				t.Fatal("timed out waiting for the client to give up")
+			}
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package copilot
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ToolResourceUsage is the operating-system resources a tool execution
+// consumed, including any processes it started. WallTime is measured for
+// every tool the SDK runs. The other fields are measured for commands run
+// by the SDK-hosted shell ([SessionConfig.PTYShell]); handlers of other
+// tools run in this process, whose usage cannot be split between them.
+// Built-in tools run inside the runtime, which does not report usage.
+type ToolResourceUsage struct {
+	// WallTime is the elapsed time from start to exit.
+	WallTime time.Duration `json:"wallTime"`
+	// UserCPUTime and SystemCPUTime are the CPU time spent in user and kernel
+	// mode.
+	UserCPUTime   time.Duration `json:"userCpuTime"`
+	SystemCPUTime time.Duration `json:"systemCpuTime"`
+	// MaxRSSBytes is the peak resident set size of the largest process.
+	MaxRSSBytes int64 `json:"maxRssBytes"`
+	// ReadBytes and WriteBytes are the bytes read from and written to storage.
+	// Only reported on Linux.
+	ReadBytes  int64 `json:"readBytes"`
+	WriteBytes int64 `json:"writeBytes"`
+}
+
+// CPUTime returns the total user and system CPU time.
+func (u ToolResourceUsage) CPUTime() time.Duration {
+	return u.UserCPUTime + u.SystemCPUTime
+}
+
+// resourceUsageTelemetryKey is the [ToolResult.ToolTelemetry] key under which
+// tools report their [ToolResourceUsage]. The runtime passes tool telemetry
+// through to tool.execution_complete events.
+const resourceUsageTelemetryKey = "resourceUsage"
+
+// withResourceUsage returns telemetry with usage added.
+func withResourceUsage(telemetry map[string]any, usage ToolResourceUsage) map[string]any {
+	if telemetry == nil {
+		telemetry = make(map[string]any)
+	}
+	telemetry[resourceUsageTelemetryKey] = usage
+	return telemetry
+}
+
+// resourceUsageFromTelemetry extracts the usage a tool reported in its
+// telemetry, or nil if it reported none.
+func resourceUsageFromTelemetry(telemetry map[string]any) *ToolResourceUsage {
+	value, ok := telemetry[resourceUsageTelemetryKey]
+	if !ok {
+		return nil
+	}
+	if usage, ok := value.(ToolResourceUsage); ok {
+		return &usage
+	}
+	// Telemetry that went over the wire is decoded as generic JSON.
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var usage ToolResourceUsage
+	if err := json.Unmarshal(raw, &usage); err != nil {
+		return nil
+	}
+	return &usage
+}
+
+// measureToolCall adds the usage of a tool call that started at start to
+// result's telemetry, keeping any usage the tool reported itself, and keeps
+// it for the session's postToolUse hook.
+func (s *Session) measureToolCall(toolName string, args any, start time.Time, result *ToolResult) {
+	usage := resourceUsageFromTelemetry(result.ToolTelemetry)
+	if usage == nil {
+		usage = &ToolResourceUsage{}
+	}
+	if usage.WallTime == 0 {
+		usage.WallTime = time.Since(start)
+	}
+	result.ToolTelemetry = withResourceUsage(maps.Clone(result.ToolTelemetry), *usage)
+	if hooks := s.getHooks(); hooks != nil && hooks.OnPostToolUse != nil {
+		s.toolUsage.record(toolName, args, *usage)
+	}
+}
+
+// toolUsageLog holds the usage of SDK-run tool calls until the postToolUse
+// hook for the call arrives. Hook input does not carry the tool call ID, so
+// calls are matched by tool name and arguments.
+type toolUsageLog struct {
+	mu      sync.Mutex
+	pending map[string][]ToolResourceUsage
+	// order lists the keys of pending from least to most recently recorded.
+	order []string
+}
+
+// maxPendingToolUsage bounds the log when hooks never consume entries; the
+// least recently recorded calls are dropped first.
+const maxPendingToolUsage = 64
+
+func toolUsageKey(toolName string, args any) string {
+	raw, _ := json.Marshal(args)
+	return toolName + "\x00" + string(raw)
+}
+
+func (l *toolUsageLog) record(toolName string, args any, usage ToolResourceUsage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending == nil {
+		l.pending = make(map[string][]ToolResourceUsage)
+	}
+	key := toolUsageKey(toolName, args)
+	l.order = append(slices.DeleteFunc(l.order, func(k string) bool { return k == key }), key)
+	l.pending[key] = append(l.pending[key], usage)
+	if len(l.order) > maxPendingToolUsage {
+		delete(l.pending, l.order[0])
+		l.order = slices.Delete(l.order, 0, 1)
+	}
+}
+
+// take removes and returns the oldest usage recorded for the call, if any.
+func (l *toolUsageLog) take(toolName string, args any) *ToolResourceUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := toolUsageKey(toolName, args)
+	entries := l.pending[key]
+	if len(entries) == 0 {
+		return nil
+	}
+	usage := entries[0]
+	if len(entries) == 1 {
+		delete(l.pending, key)
+		l.order = slices.DeleteFunc(l.order, func(k string) bool { return k == key })
+	} else {
+		l.pending[key] = entries[1:]
+	}
+	return &usage
+}
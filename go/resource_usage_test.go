@@ -0,0 +1,107 @@
+package copilot
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestPTYShellResourceUsage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("PTY shell is not supported on Windows")
+	}
+	_, shell := withPTYShell(nil, &PTYShellConfig{Shell: "/bin/sh", Timeout: 10 * time.Second}, nil, nil)
+	args := map[string]any{"command": "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done"}
+	result, err := shell.run(ToolInvocation{ToolCallID: "call-1", ToolName: "bash", Arguments: args})
+	if err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	usage := resourceUsageFromTelemetry(result.ToolTelemetry)
+	if usage == nil || usage.WallTime <= 0 || usage.CPUTime() <= 0 {
+		t.Fatalf("expected wall and CPU time in tool telemetry, got %+v", usage)
+	}
+	if runtime.GOOS == "linux" && usage.MaxRSSBytes < 1024*1024 {
+		t.Errorf("expected max RSS of at least 1 MiB, got %d", usage.MaxRSSBytes)
+	}
+}
+
+func TestSession_ToolResourceUsage(t *testing.T) {
+	telemetry := make(chan map[string]any, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.tools.handlePendingToolCall": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req struct {
+				Result struct {
+					ToolTelemetry map[string]any `json:"toolTelemetry"`
+				} `json:"result"`
+			}
+			json.Unmarshal(params, &req)
+			telemetry <- req.Result.ToolTelemetry
+			return []byte(`{"success":true}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+	var hookUsage *ToolResourceUsage
+	session.registerHooks(&SessionHooks{
+		OnPostToolUse: func(input PostToolUseHookInput, _ HookInvocation) (*PostToolUseHookOutput, error) {
+			hookUsage = input.ResourceUsage
+			return nil, nil
+		},
+	})
+	session.registerTools([]Tool{{
+		Name: "wait",
+		Handler: func(ToolInvocation) (ToolResult, error) {
+			time.Sleep(10 * time.Millisecond)
+			return ToolResult{TextResultForLLM: "done", ToolTelemetry: map[string]any{"attempts": 1}}, nil
+		},
+	}})
+
+	args := map[string]any{"seconds": 0.01}
+	session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "wait", Arguments: args}})
+	var usage *ToolResourceUsage
+	select {
+	case got := <-telemetry:
+		usage = resourceUsageFromTelemetry(got)
+		if usage == nil || usage.WallTime < 10*time.Millisecond || got["attempts"] != float64(1) {
+			t.Fatalf("expected the wall time beside the tool's own telemetry, got %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the tool result")
+	}
+
+	// The runtime's hook input carries the decoded arguments, not the
+	// original map.
+	input, _ := json.Marshal(map[string]any{"toolName": "wait", "toolArgs": args, "toolResult": "done", "timestamp": 0})
+	if _, err := session.handleHooksInvoke("postToolUse", input); err != nil {
+		t.Fatalf("postToolUse failed: %v", err)
+	}
+	if hookUsage == nil || *hookUsage != *usage {
+		t.Errorf("expected hook to receive %+v, got %+v", usage, hookUsage)
+	}
+	if _, err := session.handleHooksInvoke("postToolUse", input); err != nil || hookUsage != nil {
+		t.Errorf("expected usage to be delivered once, got %+v (err %v)", hookUsage, err)
+	}
+}
+
+func TestToolUsageLog(t *testing.T) {
+	var log toolUsageLog
+	log.record("bash", "first", ToolResourceUsage{WallTime: 1})
+	for i := range maxPendingToolUsage - 1 {
+		log.record("bash", i, ToolResourceUsage{})
+	}
+	// Recording again makes "first" the most recent call.
+	log.record("bash", "first", ToolResourceUsage{WallTime: 2})
+	log.record("bash", "last", ToolResourceUsage{})
+	if usage := log.take("bash", 0); usage != nil {
+		t.Errorf("expected the least recently recorded call to be dropped, got %+v", usage)
+	}
+	if usage := log.take("bash", "first"); usage == nil || usage.WallTime != 1 {
+		t.Errorf("expected the first usage of the call, got %+v", usage)
+	}
+	if usage := log.take("bash", 1); usage == nil {
+		t.Error("expected other calls to be kept")
+	}
+}
@@ -0,0 +1,260 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RestartResult reports the outcome of [Client.Restart].
+type RestartResult struct {
+	// Recovered lists the IDs of sessions that were re-attached to the new
+	// runtime and can keep being used.
+	Recovered []string
+	// Failed maps the IDs of sessions that could not be re-attached to the
+	// reason. Those sessions are no longer usable; their data on disk is
+	// untouched, so they may still be resumed later with
+	// [Client.ResumeSession].
+	Failed map[string]error
+}
+
+// err joins the reasons in r.Failed.
+func (r *RestartResult) err() error {
+	var errs []error
+	for id, err := range r.Failed {
+		errs = append(errs, fmt.Errorf("failed to re-attach session %s: %w", id, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Restart stops the runtime and starts a fresh one, then re-attaches the
+// client's open sessions to it. Use it to pick up an upgraded CLI binary or
+// to recover from a runtime that stopped responding.
+//
+// Sessions keep their [Session] values, handlers and configuration; they
+// are resumed from the state the runtime persisted, so a turn that was in
+// progress when Restart was called is abandoned. For an external runtime
+// (see [ClientOptions.Connection]) the process is not restarted and only the
+// connection is re-established.
+//
+// The returned error is non-nil only if the runtime could not be started
+// again; sessions that could not be recovered are reported in
+// [RestartResult.Failed]. The client reports [ClientStateReady] once the
+// sessions are re-attached, with the result in [ClientStateChange.Reattached].
+//
+// Example:
+//
+//	result, err := client.Restart(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for id, err := range result.Failed {
+//	    log.Printf("session %s was lost: %v", id, err)
+//	}
+func (c *Client) Restart(ctx context.Context) (*RestartResult, error) {
+	return c.restart(ctx, crashRecovery{})
+}
+
+// crashRecovery identifies the recovery from a crash of the CLI process, for
+// the state changes it makes. It is the zero value for other starts.
+type crashRecovery struct {
+	// count is the [ClientStateChange.Crash] of the recovery.
+	count int
+	// exitErr is why the process exited.
+	exitErr error
+}
+
+// restart implements Restart, reporting crash with the resulting state
+// change.
+func (c *Client) restart(ctx context.Context, crash crashRecovery) (*RestartResult, error) {
+	sessions := c.openSessions()
+
+	// Errors from a graceful shutdown do not matter here: the old runtime is
+	// torn down either way, and the new one is checked by start.
+	_ = c.shutdown()
+	if err := c.start(ctx, crash, false); err != nil {
+		return nil, fmt.Errorf("failed to restart runtime: %w", err)
+	}
+
+	result := c.reattachSessions(ctx, sessions)
+	c.changeState(ClientStateChange{
+		State:      ClientStateReady,
+		Err:        result.err(),
+		Crash:      crash.count,
+		Reattached: result,
+		exitErr:    crash.exitErr,
+	}, ClientStateStarting)
+	return result, nil
+}
+
+// RestartPolicy controls automatic relaunching of a CLI process spawned by
+// the SDK when it exits unexpectedly. See [ClientOptions.Restart].
+type RestartPolicy struct {
+	// MaxRestarts is the number of crashes within Window the client recovers
+	// from. A crash beyond that leaves the client stopped. Defaults to 5.
+	MaxRestarts int
+	// Window is the period over which crashes are counted. Defaults to 10
+	// minutes.
+	Window time.Duration
+	// InitialBackoff is the delay before relaunching after the first crash
+	// in Window. It doubles with each further crash. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay before relaunching. Defaults to 30s.
+	MaxBackoff time.Duration
+}
+
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.MaxRestarts <= 0 {
+		p.MaxRestarts = 5
+	}
+	if p.Window <= 0 {
+		p.Window = 10 * time.Minute
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	return p
+}
+
+// backoff returns the delay before relaunching after the given (1-based)
+// crash in the window.
+func (p RestartPolicy) backoff(crash int) time.Duration {
+	return ReconnectPolicy{InitialBackoff: p.InitialBackoff, MaxBackoff: p.MaxBackoff}.withDefaults().backoff(crash)
+}
+
+// CLIRestart describes the recovery from a crash of the CLI process, as
+// reported to [Client.OnCLIRestart] handlers.
+type CLIRestart struct {
+	// Crash counts the crashes within [RestartPolicy.Window], including this
+	// one.
+	Crash int
+	// ExitErr is why the process exited, with the end of its stderr.
+	ExitErr error
+	// Result lists the sessions that were and were not re-attached to the
+	// relaunched CLI, or is nil if it was not relaunched.
+	Result *RestartResult
+	// Err is why the CLI was not relaunched: it crashed more than
+	// [RestartPolicy.MaxRestarts] times within the window, or could not be
+	// started again. Calls then fail until [Client.Restart] is called.
+	Err error
+}
+
+// CLIRestartHandler handles the recovery from a CLI crash.
+type CLIRestartHandler func(restart CLIRestart)
+
+// OnCLIRestart subscribes to the recovery from crashes of the CLI process
+// (see [ClientOptions.Restart]). The handler is called once per crash, for
+// the [Client.OnStateChange] change that ends the recovery: to
+// [ClientStateReady] after the CLI was relaunched and sessions re-attached,
+// or to [ClientStateStopped] when the client gives up.
+//
+// Handlers are called from a background goroutine. Returns a function that,
+// when called, unsubscribes the handler.
+//
+// Example:
+//
+//	unsubscribe := client.OnCLIRestart(func(restart copilot.CLIRestart) {
+//	    if restart.Err != nil {
+//	        log.Printf("copilot CLI is down: %v", restart.Err)
+//	        return
+//	    }
+//	    log.Printf("copilot CLI relaunched after %v; lost sessions: %v", restart.ExitErr, restart.Result.Failed)
+//	})
+//	defer unsubscribe()
+func (c *Client) OnCLIRestart(handler CLIRestartHandler) func() {
+	return c.OnStateChange(func(change ClientStateChange) {
+		if change.Crash == 0 {
+			return
+		}
+		restart := CLIRestart{Crash: change.Crash, ExitErr: change.exitErr}
+		switch change.State {
+		case ClientStateReady:
+			restart.Result = change.Reattached
+		case ClientStateStopped:
+			restart.Err = change.Err
+		default:
+			return
+		}
+		handler(restart)
+	})
+}
+
+// recoverCrash relaunches the CLI after proc exited with exitErr, if the
+// exit was not caused by the client and a restart policy is set.
+func (c *Client) recoverCrash(proc *os.Process, exitErr error) {
+	c.startStopMux.Lock()
+	// Stop, Restart and failed starts kill the process through killProcess,
+	// which clears osProcess first; an exit without that is a crash.
+	if c.osProcess.Load() != proc {
+		c.startStopMux.Unlock()
+		return
+	}
+	c.logger().Warn("copilot CLI exited unexpectedly", "pid", proc.Pid, "error", exitErr)
+	if c.options.Restart == nil || c.restartCancel != nil {
+		c.startStopMux.Unlock()
+		return
+	}
+	policy := c.options.Restart.withDefaults()
+	now := time.Now()
+	crashes := c.crashes[:0]
+	for _, at := range c.crashes {
+		if now.Sub(at) < policy.Window {
+			crashes = append(crashes, at)
+		}
+	}
+	c.crashes = append(crashes, now)
+	crash := crashRecovery{count: len(c.crashes), exitErr: exitErr}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.restartCancel = cancel
+	c.startStopMux.Unlock()
+
+	defer func() {
+		c.startStopMux.Lock()
+		c.restartCancel = nil
+		c.startStopMux.Unlock()
+		cancel()
+	}()
+
+	if crash.count > policy.MaxRestarts {
+		c.incrementCounter(MetricCLIRestarts, 1, map[string]string{"outcome": "failure"})
+		c.changeState(ClientStateChange{
+			State:   ClientStateStopped,
+			Err:     fmt.Errorf("CLI crashed %d times within %s, last with: %w", crash.count, policy.Window, exitErr),
+			Crash:   crash.count,
+			exitErr: exitErr,
+		}, ClientStateReady, ClientStateDegraded)
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return // Stop was called
+	case <-time.After(policy.backoff(crash.count)):
+	}
+	_, err := c.restart(ctx, crash)
+	if ctx.Err() != nil {
+		// Stop was called while relaunching; do not leave the new CLI behind.
+		if err == nil {
+			_ = c.shutdown()
+		}
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	c.incrementCounter(MetricCLIRestarts, 1, map[string]string{"outcome": outcome})
+}
+
+// cancelRestart abandons a relaunch in progress, for Stop and ForceStop.
+func (c *Client) cancelRestart() {
+	c.startStopMux.Lock()
+	defer c.startStopMux.Unlock()
+	if c.restartCancel != nil {
+		c.restartCancel()
+	}
+}
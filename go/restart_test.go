@@ -0,0 +1,237 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestClient_Restart(t *testing.T) {
+	t.Run("re-attaches open sessions to the new connection", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: server.listener.Addr().String()}})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Model:               "gpt-5",
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer client.Stop()
+		old := client.client
+
+		result, err := client.Restart(t.Context())
+		if err != nil {
+			t.Fatalf("Restart failed: %v", err)
+		}
+		if !slices.Equal(result.Recovered, []string{session.SessionID}) || len(result.Failed) != 0 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+
+		var params map[string]any
+		if err := json.Unmarshal(<-server.resumed, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params["sessionId"] != session.SessionID || params["model"] != "gpt-5" || params["disableResume"] != true {
+			t.Errorf("unexpected re-attach request: %v", params)
+		}
+		if client.client == old || session.conn.Load().client != client.client {
+			t.Error("expected the session to use the new connection")
+		}
+		if client.state != stateConnected {
+			t.Errorf("expected the client to be connected, got %v", client.state)
+		}
+	})
+
+	t.Run("reports sessions that could not be re-attached", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: server.listener.Addr().String()}})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer client.Stop()
+
+		server.rejectResume.Store(true)
+		result, err := client.Restart(t.Context())
+		if err != nil {
+			t.Fatalf("Restart failed: %v", err)
+		}
+		if len(result.Recovered) != 0 || result.Failed[session.SessionID] == nil {
+			t.Errorf("expected session %s to fail, got %+v", session.SessionID, result)
+		}
+	})
+
+	t.Run("skips disconnected sessions", func(t *testing.T) {
+		server := newReconnectTestServer(t)
+		client := NewClient(&ClientOptions{Connection: UnixSocketConnection{Path: server.listener.Addr().String()}})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		defer client.Stop()
+		if err := session.Disconnect(); err != nil {
+			t.Fatalf("Disconnect failed: %v", err)
+		}
+
+		result, err := client.Restart(t.Context())
+		if err != nil {
+			t.Fatalf("Restart failed: %v", err)
+		}
+		if len(result.Recovered) != 0 || len(result.Failed) != 0 {
+			t.Errorf("expected no sessions, got %+v", result)
+		}
+	})
+}
+
+// TestFakeRuntimeProcess is a helper that serves a minimal runtime over
+// stdio when the test binary is spawned as the CLI. Its session.send crashes
+// the process until FAKE_RUNTIME_CRASHES crashes are recorded in
+// FAKE_RUNTIME_DIR.
+func TestFakeRuntimeProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_FAKE_RUNTIME") != "1" {
+		return
+	}
+	dir := os.Getenv("FAKE_RUNTIME_DIR")
+	crashes, _ := strconv.Atoi(os.Getenv("FAKE_RUNTIME_CRASHES"))
+
+	server := jsonrpc2.NewClient(os.Stdout, os.Stdin)
+	server.SetRequestHandler("connect", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+		return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+	})
+	for _, method := range []string{"session.create", "session.resume"} {
+		server.SetRequestHandler(method, func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return sessionIDResult(params), nil
+		})
+	}
+	server.SetRequestHandler("session.send", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+		if entries, _ := os.ReadDir(dir); len(entries) < crashes {
+			os.WriteFile(filepath.Join(dir, strconv.Itoa(len(entries))), nil, 0o644)
+			os.Exit(1)
+		}
+		return []byte(`{"messageId":"m1"}`), nil
+	})
+	server.Start()
+	select {}
+}
+
+func TestClient_CrashRecovery(t *testing.T) {
+	startClient := func(t *testing.T, crashes int, policy *RestartPolicy) (*Client, chan ClientStateChange) {
+		t.Helper()
+		client := NewClient(&ClientOptions{
+			Connection: StdioConnection{Path: os.Args[0], Args: []string{"-test.run=^TestFakeRuntimeProcess$", "--"}},
+			Env: []string{
+				"GO_WANT_FAKE_RUNTIME=1",
+				"FAKE_RUNTIME_DIR=" + t.TempDir(),
+				"FAKE_RUNTIME_CRASHES=" + strconv.Itoa(crashes),
+			},
+			InheritEnv: true,
+			Restart:    policy,
+		})
+		restarts := make(chan ClientStateChange, 4)
+		client.OnStateChange(func(change ClientStateChange) {
+			if change.Crash > 0 {
+				restarts <- change
+			}
+		})
+		t.Cleanup(func() { client.Stop() })
+		return client, restarts
+	}
+	waitRestart := func(t *testing.T, restarts chan ClientStateChange) ClientStateChange {
+		t.Helper()
+		select {
+		case restart := <-restarts:
+			return restart
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the CLI to be restarted")
+			return ClientStateChange{}
+		}
+	}
+
+	t.Run("relaunches the CLI and resumes sessions", func(t *testing.T) {
+		client, restarts := startClient(t, 1, &RestartPolicy{InitialBackoff: time.Millisecond})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "crash"}); !IsTransient(err) {
+			t.Fatalf("expected a transient error from the crash, got %v", err)
+		}
+		restart := waitRestart(t, restarts)
+		if restart.State != ClientStateReady || restart.Err != nil || restart.Crash != 1 || restart.Reattached == nil {
+			t.Fatalf("unexpected restart %+v", restart)
+		}
+		if !slices.Equal(restart.Reattached.Recovered, []string{session.SessionID}) {
+			t.Errorf("expected session %s to be resumed, got %+v", session.SessionID, restart.Reattached)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hello"}); err != nil {
+			t.Errorf("expected the session to work after the restart, got %v", err)
+		}
+	})
+
+	t.Run("reports the recovery through OnCLIRestart", func(t *testing.T) {
+		client, _ := startClient(t, 1, &RestartPolicy{InitialBackoff: time.Millisecond})
+		restarts := make(chan CLIRestart, 1)
+		client.OnCLIRestart(func(restart CLIRestart) { restarts <- restart })
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		session.Send(t.Context(), MessageOptions{Prompt: "crash"})
+		select {
+		case restart := <-restarts:
+			if restart.Err != nil || restart.Crash != 1 || restart.ExitErr == nil || restart.Result == nil {
+				t.Fatalf("unexpected restart %+v", restart)
+			}
+			if !slices.Equal(restart.Result.Recovered, []string{session.SessionID}) {
+				t.Errorf("expected session %s to be resumed, got %+v", session.SessionID, restart.Result)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for OnCLIRestart")
+		}
+	})
+
+	t.Run("gives up after MaxRestarts crashes", func(t *testing.T) {
+		client, restarts := startClient(t, 2, &RestartPolicy{MaxRestarts: 1, InitialBackoff: time.Millisecond})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		session.Send(t.Context(), MessageOptions{Prompt: "crash"})
+		if restart := waitRestart(t, restarts); restart.Err != nil {
+			t.Fatalf("expected the first crash to be recovered, got %v", restart.Err)
+		}
+		session.Send(t.Context(), MessageOptions{Prompt: "crash again"})
+		restart := waitRestart(t, restarts)
+		if restart.State != ClientStateStopped || restart.Err == nil || restart.Crash != 2 || restart.Reattached != nil {
+			t.Fatalf("expected the client to give up, got %+v", restart)
+		}
+		_, err = session.Send(t.Context(), MessageOptions{Prompt: "hello"})
+		if !errors.Is(err, jsonrpc2.ErrProcessExited) {
+			t.Errorf("expected calls to fail after giving up, got %v", err)
+		}
+	})
+
+	t.Run("leaves the CLI alone when it is stopped", func(t *testing.T) {
+		client, restarts := startClient(t, 0, &RestartPolicy{InitialBackoff: time.Millisecond})
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		client.Stop()
+		select {
+		case restart := <-restarts:
+			t.Errorf("unexpected restart %+v", restart)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SessionRetention limits how much persisted session state the runtime keeps
+// on disk. See [ClientOptions.SessionRetention].
+type SessionRetention struct {
+	// MaxAge deletes sessions not modified within this duration. Zero keeps
+	// sessions regardless of age.
+	MaxAge time.Duration
+	// MaxCount keeps at most this many sessions, deleting the least recently
+	// modified ones first. Zero keeps any number of sessions.
+	MaxCount int
+	// MaxBytes keeps the most recently modified sessions whose state fits in
+	// this many bytes on disk, deleting the rest. Requires the runtime's
+	// session state to be on this machine's filesystem. Zero keeps sessions
+	// regardless of size.
+	MaxBytes int64
+	// Interval is the time between automatic prunes. Defaults to 1 hour.
+	Interval time.Duration
+}
+
+func (r SessionRetention) withDefaults() SessionRetention {
+	if r.Interval <= 0 {
+		r.Interval = time.Hour
+	}
+	return r
+}
+
+// PruneSessions deletes locally persisted sessions that fall outside policy
+// and returns the IDs it deleted. Remote and cloud sessions are neither
+// deleted nor counted. Sessions open on this client are never deleted, but
+// still count toward MaxCount and MaxBytes.
+//
+// [ClientOptions.SessionRetention] runs this automatically; call it directly
+// to prune on your own schedule or to observe failures. An error is returned
+// if any deletion failed; sessions deleted before the failure are still
+// reported.
+//
+// Example:
+//
+//	deleted, err := client.PruneSessions(ctx, copilot.SessionRetention{MaxAge: 30 * 24 * time.Hour})
+//	if err != nil {
+//	    log.Printf("failed to prune sessions: %v", err)
+//	}
+//	log.Printf("deleted %d old sessions", len(deleted))
+func (c *Client) PruneSessions(ctx context.Context, policy SessionRetention) ([]string, error) {
+	sessions, err := c.ListSessions(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sessions = slices.DeleteFunc(sessions, func(session SessionMetadata) bool { return session.IsRemote })
+
+	c.sessionsMux.Lock()
+	open := make(map[string]bool, len(c.sessions))
+	for id := range c.sessions {
+		open[id] = true
+	}
+	c.sessionsMux.Unlock()
+
+	var cutoff time.Time
+	if policy.MaxAge > 0 {
+		cutoff = time.Now().Add(-policy.MaxAge)
+	}
+	var deleted []string
+	var errs []error
+	var total int64
+	// ListSessions returns the most recently modified sessions first.
+	for i, session := range sessions {
+		expired := !cutoff.IsZero() && session.ModifiedTime.Before(cutoff)
+		excess := policy.MaxCount > 0 && i >= policy.MaxCount
+		if policy.MaxBytes > 0 {
+			size, err := c.sessionDiskUsage(ctx, session.SessionID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to measure session %s: %w", session.SessionID, err))
+				continue
+			}
+			total += size
+			excess = excess || total > policy.MaxBytes
+		}
+		if !expired && !excess || open[session.SessionID] {
+			continue
+		}
+		if err := c.DeleteSession(ctx, session.SessionID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, session.SessionID)
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// startRetention starts the session retention loop for the current
+// connection. Must be called with startStopMux held.
+func (c *Client) startRetention() {
+	c.stopRetention()
+	if c.options.SessionRetention == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.retentionCancel = cancel
+	go c.runRetention(ctx, c.options.SessionRetention.withDefaults())
+}
+
+// stopRetention stops the session retention loop, if running. Must be called
+// with startStopMux held.
+func (c *Client) stopRetention() {
+	if c.retentionCancel != nil {
+		c.retentionCancel()
+		c.retentionCancel = nil
+	}
+}
+
+func (c *Client) runRetention(ctx context.Context, policy SessionRetention) {
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		// Failures are retried on the next tick; callers who need to observe
+		// them can call PruneSessions directly.
+		_, _ = c.PruneSessions(ctx, policy)
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
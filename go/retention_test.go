@@ -0,0 +1,100 @@
+package copilot
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestClient_PruneSessions(t *testing.T) {
+	now := time.Now()
+	sessions := []map[string]any{
+		{"sessionId": "fresh", "startTime": now, "modifiedTime": now.Add(-time.Hour)},
+		{"sessionId": "stale", "startTime": now, "modifiedTime": now.Add(-48 * time.Hour)},
+		{"sessionId": "newest", "startTime": now, "modifiedTime": now},
+		{"sessionId": "old", "startTime": now, "modifiedTime": now.Add(-3 * time.Hour)},
+		// Remote sessions are neither deleted nor counted.
+		{"sessionId": "cloud", "startTime": now, "modifiedTime": now.Add(-72 * time.Hour), "isRemote": true},
+		{"sessionId": "remote", "startTime": now, "modifiedTime": now.Add(time.Minute), "isRemote": true},
+	}
+	startRuntime := func(t *testing.T) (*Client, func() []string) {
+		var mu sync.Mutex
+		var deleted []string
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.list": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				result, _ := json.Marshal(map[string]any{"sessions": sessions})
+				return result, nil
+			},
+			"session.delete": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req deleteSessionRequest
+				json.Unmarshal(params, &req)
+				mu.Lock()
+				deleted = append(deleted, req.SessionID)
+				mu.Unlock()
+				if req.SessionID == "old" {
+					return []byte(`{"success":false,"error":"locked"}`), nil
+				}
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		return client, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(deleted)
+		}
+	}
+
+	t.Run("deletes sessions older than MaxAge", func(t *testing.T) {
+		client, _ := startRuntime(t)
+		deleted, err := client.PruneSessions(t.Context(), SessionRetention{MaxAge: 24 * time.Hour})
+		if err != nil {
+			t.Fatalf("PruneSessions failed: %v", err)
+		}
+		if !slices.Equal(deleted, []string{"stale"}) {
+			t.Errorf("deleted %v, want [stale]", deleted)
+		}
+	})
+
+	t.Run("keeps the MaxCount most recent sessions and skips open ones", func(t *testing.T) {
+		client, requested := startRuntime(t)
+		client.sessionsMux.Lock()
+		client.sessions["stale"] = &Session{SessionID: "stale"}
+		client.sessionsMux.Unlock()
+		defer func() {
+			client.sessionsMux.Lock()
+			delete(client.sessions, "stale")
+			client.sessionsMux.Unlock()
+		}()
+
+		deleted, err := client.PruneSessions(t.Context(), SessionRetention{MaxCount: 2})
+		if err == nil {
+			t.Fatal("expected the failed deletion to be reported")
+		}
+		if len(deleted) != 0 {
+			t.Errorf("deleted %v, want none", deleted)
+		}
+		if got := requested(); !slices.Equal(got, []string{"old"}) {
+			t.Errorf("requested deletion of %v, want [old]", got)
+		}
+	})
+
+	t.Run("runs automatically when SessionRetention is set", func(t *testing.T) {
+		client, requested := startRuntime(t)
+		client.startStopMux.Lock()
+		client.options.SessionRetention = &SessionRetention{MaxAge: 24 * time.Hour}
+		client.startRetention()
+		client.startStopMux.Unlock()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !slices.Contains(requested(), "stale") {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for automatic prune, requested %v", requested())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// RetryPolicy retries operations that fail with transient errors. See
+// [ClientOptions.Retry].
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first. Defaults
+	// to 3.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each failed attempt.
+	// Defaults to 2.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it, so clients
+	// that failed together do not retry together. Defaults to 0.2; set it
+	// to a negative value for exact delays.
+	Jitter float64
+	// RetryOn reports whether a failed attempt is worth retrying. Defaults
+	// to [IsTransient]. Whatever it reports, a request is only sent again
+	// if it never reached the runtime, or if the runtime reported the turn
+	// failed with a [*SessionError]; a request lost after it was written may
+	// have been acted on.
+	RetryOn func(err error) bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter == 0 {
+		p.Jitter = 0.2
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.RetryOn == nil {
+		p.RetryOn = IsTransient
+	}
+	return p
+}
+
+// backoff returns the delay before the given (1-based) retry.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < retry && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxBackoff))
+	return time.Duration(delay * (1 + p.Jitter*(2*rand.Float64()-1)))
+}
+
+// SessionError is returned by [Session.SendAndWait] when the turn fails with
+// a session.error event, such as an error from the model endpoint.
+type SessionError struct {
+	// ErrorType is the category of the error, such as "rate_limit" or
+	// "authentication".
+	ErrorType string
+	// StatusCode is the HTTP status code of the failed request to the model
+	// endpoint, or 0.
+	StatusCode int
+	Message    string
+}
+
+func (e *SessionError) Error() string {
+	return "session error: " + e.Message
+}
+
+// Is matches [ErrContextWindowExceeded] for a "context_limit" error and
+// [ErrPermissionDenied] for an "authorization" one.
+func (e *SessionError) Is(target error) bool {
+	switch e.ErrorType {
+	case "context_limit":
+		return target == ErrContextWindowExceeded
+	case "authorization":
+		return target == ErrPermissionDenied
+	}
+	return false
+}
+
+func sessionError(d *SessionErrorData) *SessionError {
+	err := &SessionError{ErrorType: d.ErrorType, Message: d.Message}
+	if d.StatusCode != nil {
+		err.StatusCode = int(*d.StatusCode)
+	}
+	return err
+}
+
+// IsTransient reports whether err is likely to go away if the operation is
+// retried: the connection to the runtime dropped or was reset, the runtime
+// process exited, or the model endpoint rate limited the request (429) or
+// failed with a server error (5xx).
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var sessionErr *SessionError
+	if errors.As(err, &sessionErr) {
+		return sessionErr.StatusCode == http.StatusTooManyRequests || sessionErr.StatusCode >= 500
+	}
+	for _, transient := range []error{
+		ErrConnectionLost,
+		jsonrpc2.ErrStopped,
+		jsonrpc2.ErrProcessExited,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		net.ErrClosed,
+		syscall.ECONNRESET,
+		syscall.ECONNREFUSED,
+		syscall.ECONNABORTED,
+		syscall.EPIPE,
+	} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry calls attempt until it succeeds or fails with an error not worth
+// retrying under ClientOptions.Retry, waiting between attempts. Without a
+// policy, attempt is called once.
+//
+// The operations retried are not idempotent, so an attempt is only repeated
+// when it is known not to have taken effect; see retrySafe.
+func (c *Client) retry(ctx context.Context, operation string, attempt func() error) error {
+	if c == nil || c.options.Retry == nil {
+		return attempt()
+	}
+	policy := c.options.Retry.withDefaults()
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || n >= policy.MaxAttempts || ctx.Err() != nil || !retrySafe(err) || !policy.RetryOn(err) {
+			return err
+		}
+		delay := policy.backoff(n)
+		c.logger().Warn("retrying "+operation+" after transient error", "attempt", n, "delay", delay, "error", err)
+		c.incrementCounter(MetricRetries, 1, map[string]string{"operation": operation})
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retrySafe reports whether err shows that a failed attempt did not take
+// effect: its request was never written to the runtime, or the runtime
+// reported that the turn failed. A connection lost while waiting for a
+// response says nothing about whether the runtime acted on the request.
+func retrySafe(err error) bool {
+	var sessionErr *SessionError
+	return errors.Is(err, jsonrpc2.ErrNotSent) || errors.As(err, &sessionErr)
+}
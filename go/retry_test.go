@@ -0,0 +1,120 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestRetry(t *testing.T) {
+	t.Run("classifies transient errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			err  error
+			want bool
+		}{
+			{fmt.Errorf("failed to send message: %w", jsonrpc2.ErrStopped), true},
+			{fmt.Errorf("failed to send request: %w", syscall.ECONNRESET), true},
+			{jsonrpc2.ErrProcessExited, true},
+			{&SessionError{ErrorType: "rate_limit", StatusCode: 429}, true},
+			{&SessionError{StatusCode: 503}, true},
+			{&SessionError{ErrorType: "authentication", StatusCode: 401}, false},
+			{&jsonrpc2.Error{Code: -32602, Message: "invalid params"}, false},
+			{context.DeadlineExceeded, false},
+			{ErrSessionClosed, false},
+		} {
+			if got := IsTransient(tc.err); got != tc.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("backs off exponentially with jitter up to the cap", func(t *testing.T) {
+		policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+		for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+			for range 20 {
+				if got := policy.backoff(retry); got < want*8/10 || got > want*12/10 {
+					t.Errorf("backoff(%d) = %v, want %v ± 20%%", retry, got, want)
+				}
+			}
+		}
+		policy.Jitter = -1
+		if got := policy.withDefaults().backoff(2); got != 200*time.Millisecond {
+			t.Errorf("expected an exact delay without jitter, got %v", got)
+		}
+	})
+
+	t.Run("gives up after MaxAttempts", func(t *testing.T) {
+		client := &Client{options: ClientOptions{Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}}
+		attempts := 0
+		err := client.retry(t.Context(), "send", func() error {
+			attempts++
+			return jsonrpc2.NotSent(jsonrpc2.ErrStopped)
+		})
+		if !errors.Is(err, jsonrpc2.ErrStopped) || attempts != 3 {
+			t.Errorf("expected 3 attempts and the last error, got %d and %v", attempts, err)
+		}
+		attempts = 0
+		client.retry(t.Context(), "send", func() error {
+			attempts++
+			return ErrSessionClosed
+		})
+		if attempts != 1 {
+			t.Errorf("expected no retry of a permanent error, got %d attempts", attempts)
+		}
+	})
+
+	t.Run("does not resend a request that may have reached the runtime", func(t *testing.T) {
+		client := &Client{options: ClientOptions{Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}}
+		for _, lost := range []error{
+			fmt.Errorf("failed to send message: %w", jsonrpc2.ErrProcessExited),
+			fmt.Errorf("waiting for session.idle: %w", ErrConnectionLost),
+		} {
+			attempts := 0
+			err := client.retry(t.Context(), "send", func() error {
+				attempts++
+				return lost
+			})
+			if err != lost || attempts != 1 {
+				t.Errorf("expected %v to be returned without a retry, got %d attempts and %v", lost, attempts, err)
+			}
+		}
+	})
+
+	t.Run("sends the prompt again after a rate limit", func(t *testing.T) {
+		var sends atomic.Int32
+		sent := make(chan int32, 4)
+		client := startTestRuntimeWithOptions(t, &ClientOptions{
+			Retry: &RetryPolicy{InitialBackoff: time.Millisecond},
+		}, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				sent <- sends.Add(1)
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		session.owner = client
+		t.Cleanup(func() { close(session.done) })
+		go func() {
+			<-sent
+			session.dispatchEvent(SessionEvent{Data: &SessionErrorData{ErrorType: "rate_limit", Message: "slow down", StatusCode: ptr(int32(429))}})
+			<-sent
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "a1", Content: "4"}})
+			session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		}()
+
+		response, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Content != "4" || sends.Load() != 2 {
+			t.Errorf("expected the answer after 2 sends, got %q after %d", response.Content, sends.Load())
+		}
+	})
+}
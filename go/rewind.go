@@ -0,0 +1,83 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ErrMessageNotFound is returned by [Session.RewindTo], [Session.Annotate]
+// and [Session.StateAt] for a message ID that is not part of the session's conversation.
+var ErrMessageNotFound = errors.New("message not found")
+
+// RewindOptions configures [Session.RewindTo].
+type RewindOptions struct {
+	// KeepHistory keeps the removed events in persistence. Before rewinding,
+	// the session's full history is copied into a new session, which is not
+	// attached; its ID is returned in [RewindResult.HistorySessionID].
+	KeepHistory bool
+}
+
+// RewindResult describes a rewind by [Session.RewindTo].
+type RewindResult struct {
+	// EventsRemoved is the number of events removed from the session.
+	EventsRemoved int
+	// HistorySessionID is the ID of the session holding the full history
+	// when [RewindOptions.KeepHistory] was set, and empty otherwise.
+	HistorySessionID string
+}
+
+// RewindTo removes the message messageID and everything after it from the
+// session, so the conversation is as it was before that message was sent.
+// Use it for "edit and regenerate": rewind to a user message and send the
+// edited prompt. Use it to recover from a bad turn: rewind to the turn's
+// user message and continue from the turn before. messageID is the ID of a
+// [Message] returned by [Session.GetMessages] or [Session.Messages].
+//
+// The removed events are deleted from the session's persisted history as
+// well as from the model's context; the runtime rebuilds the context from
+// that history. Set [RewindOptions.KeepHistory] to keep them in a copy of
+// the session. opts may be nil. Rewind an idle session, so the rewind does
+// not race a running turn.
+//
+// Example:
+//
+//	// Regenerate the last answer from an edited prompt.
+//	messages, err := session.GetMessages(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	var prompt copilot.Message
+//	for _, msg := range messages {
+//	    if msg.Role == copilot.MessageRoleUser {
+//	        prompt = msg
+//	    }
+//	}
+//	if _, err := session.RewindTo(ctx, prompt.ID, nil); err != nil {
+//	    log.Fatal(err)
+//	}
+//	session.SendAndWait(ctx, copilot.MessageOptions{Prompt: prompt.Content + " Keep it short."})
+func (s *Session) RewindTo(ctx context.Context, messageID string, opts *RewindOptions) (*RewindResult, error) {
+	messages, err := s.GetMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(messages, func(m Message) bool { return m.ID == messageID }) {
+		return nil, fmt.Errorf("%w: %q", ErrMessageNotFound, messageID)
+	}
+	rewind := &RewindResult{}
+	if opts != nil && opts.KeepHistory {
+		if rewind.HistorySessionID, err = s.forkHistory(ctx); err != nil {
+			return nil, err
+		}
+	}
+	result, err := s.conn.Load().rpc.History.Truncate(ctx, &rpc.HistoryTruncateRequest{EventID: messageID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind session: %w", err)
+	}
+	rewind.EventsRemoved = int(result.EventsRemoved)
+	return rewind, nil
+}
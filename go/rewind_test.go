@@ -0,0 +1,74 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_RewindTo(t *testing.T) {
+	var truncated, forked []map[string]any
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"sessions.fork": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			forked = append(forked, req)
+			return []byte(`{"sessionId":"s1-history"}`), nil
+		},
+		"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(testConversation), nil
+		},
+		"session.history.truncate": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			truncated = append(truncated, req)
+			return []byte(`{"eventsRemoved":2}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	t.Run("truncates the history at the message", func(t *testing.T) {
+		result, err := session.RewindTo(t.Context(), "u2", nil)
+		if err != nil {
+			t.Fatalf("RewindTo failed: %v", err)
+		}
+		if result.EventsRemoved != 2 || result.HistorySessionID != "" {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if len(truncated) != 1 || truncated[0]["sessionId"] != "s1" || truncated[0]["eventId"] != "u2" {
+			t.Errorf("unexpected truncate requests %v", truncated)
+		}
+		if len(forked) != 0 {
+			t.Errorf("expected no copy of the history, got %v", forked)
+		}
+	})
+
+	t.Run("keeps the full history in a copy", func(t *testing.T) {
+		truncated = nil
+		result, err := session.RewindTo(t.Context(), "u2", &RewindOptions{KeepHistory: true})
+		if err != nil {
+			t.Fatalf("RewindTo failed: %v", err)
+		}
+		if result.HistorySessionID != "s1-history" || len(forked) != 1 || forked[0]["sessionId"] != "s1" {
+			t.Errorf("expected the history to be copied before rewinding, got %+v and %v", result, forked)
+		}
+		if len(truncated) != 1 {
+			t.Errorf("expected the session to be rewound, got %v", truncated)
+		}
+	})
+
+	t.Run("rejects IDs that are not messages", func(t *testing.T) {
+		truncated = nil
+		for _, id := range []string{"nope", "t1", "s1"} {
+			if _, err := session.RewindTo(t.Context(), id, nil); !errors.Is(err, ErrMessageNotFound) {
+				t.Errorf("expected ErrMessageNotFound for %q, got %v", id, err)
+			}
+		}
+		if len(truncated) != 0 {
+			t.Errorf("expected no truncation, got %v", truncated)
+		}
+	})
+}
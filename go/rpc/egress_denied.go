@@ -0,0 +1,40 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeEgressDenied identifies SDK-synthesized [EgressDeniedData]
+// events. The runtime never sends this type; the SDK delivers it to session
+// handlers as an ephemeral event whenever the session's egress policy blocks
+// a network destination.
+const SessionEventTypeEgressDenied SessionEventType = "egress.denied"
+
+// EgressSource identifies where a blocked network access originated.
+type EgressSource string
+
+const (
+	// A URL fetch permission request, such as from the web_fetch tool
+	EgressSourceFetch EgressSource = "fetch"
+	// A URL named in a shell command permission request
+	EgressSourceShell EgressSource = "shell"
+	// A connection made through the SDK's egress proxy by a shell command
+	EgressSourceProxy EgressSource = "proxy"
+)
+
+// EgressDeniedData reports a network destination blocked by the session's
+// egress policy.
+type EgressDeniedData struct {
+	// Tool call that attempted the access. Empty for proxied connections, which cannot be attributed to a tool call
+	ToolCallID string       `json:"toolCallId,omitempty"`
+	Source     EgressSource `json:"source"`
+	// URL that was blocked, when known. Proxied HTTPS connections only reveal the host and port
+	URL string `json:"url,omitempty"`
+	// Host name or IP address that was blocked
+	Host string `json:"host"`
+	// Why the destination was blocked
+	Reason string `json:"reason"`
+}
+
+func (*EgressDeniedData) sessionEventData() {}
+func (*EgressDeniedData) Type() SessionEventType {
+	return SessionEventTypeEgressDenied
+}
@@ -0,0 +1,70 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeFileEdited identifies SDK-synthesized [FileEditedData]
+// events. The runtime never sends this type; the SDK derives it from
+// completed built-in edit tool calls and delivers it to session handlers
+// as an ephemeral event, so it does not appear in the persisted event log.
+const SessionEventTypeFileEdited SessionEventType = "file.edited"
+
+// FileEditOperation describes what an edit did to a file.
+type FileEditOperation string
+
+const (
+	FileEditOperationCreated  FileEditOperation = "created"
+	FileEditOperationModified FileEditOperation = "modified"
+	FileEditOperationDeleted  FileEditOperation = "deleted"
+)
+
+// DiffLineKind identifies a line within a [DiffHunk].
+type DiffLineKind string
+
+const (
+	DiffLineKindContext DiffLineKind = "context"
+	DiffLineKindAdded   DiffLineKind = "added"
+	DiffLineKindDeleted DiffLineKind = "deleted"
+)
+
+// DiffLine is one line of a [DiffHunk].
+type DiffLine struct {
+	Kind DiffLineKind `json:"kind"`
+	// Text of the line, without the leading diff marker or trailing newline
+	Content string `json:"content"`
+	// 1-based line number in the original file. Zero for added lines.
+	OldLine int `json:"oldLine,omitempty"`
+	// 1-based line number in the edited file. Zero for deleted lines.
+	NewLine int `json:"newLine,omitempty"`
+}
+
+// DiffHunk is a contiguous changed region of a file, as in a unified diff.
+type DiffHunk struct {
+	OldStart int `json:"oldStart"`
+	OldLines int `json:"oldLines"`
+	NewStart int `json:"newStart"`
+	NewLines int `json:"newLines"`
+	// Optional section heading following the @@ range, such as an enclosing function
+	Header string     `json:"header,omitempty"`
+	Lines  []DiffLine `json:"lines"`
+}
+
+// FileEditedData reports a file changed by a built-in edit tool, with a
+// structured diff of the change.
+type FileEditedData struct {
+	// Tool call that made the edit
+	ToolCallID string `json:"toolCallId"`
+	// Name of the edit tool, e.g. "edit" or "create"
+	ToolName string `json:"toolName"`
+	// Path of the edited file, as reported by the tool
+	Path      string            `json:"path"`
+	Operation FileEditOperation `json:"operation"`
+	Hunks     []DiffHunk        `json:"hunks"`
+	// Number of added and deleted lines across all hunks
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+func (*FileEditedData) sessionEventData() {}
+func (*FileEditedData) Type() SessionEventType {
+	return SessionEventTypeFileEdited
+}
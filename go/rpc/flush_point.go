@@ -0,0 +1,27 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeFlushPoint identifies SDK-synthesized [FlushPointData]
+// events. The runtime never sends this type; with speech segmentation the
+// SDK delivers one as an ephemeral event after each sentence of a streamed
+// assistant message.
+const SessionEventTypeFlushPoint SessionEventType = "assistant.flush_point"
+
+// FlushPointData marks a point in a streamed assistant message where the text
+// delivered so far is final and can be spoken.
+type FlushPointData struct {
+	// Message the flush point belongs to
+	MessageID string `json:"messageId"`
+	// Tool call ID of the parent tool invocation when the message belongs to a sub-agent
+	ParentToolCallID *string `json:"parentToolCallId,omitempty"`
+	// Text delivered since the previous flush point of the message: one sentence, or empty
+	Text string `json:"text"`
+	// Whether this is the last flush point of the message
+	Final bool `json:"final"`
+}
+
+func (*FlushPointData) sessionEventData() {}
+func (*FlushPointData) Type() SessionEventType {
+	return SessionEventTypeFlushPoint
+}
@@ -0,0 +1,29 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypePathDenied identifies SDK-synthesized [PathDeniedData]
+// events. The runtime never sends this type; the SDK delivers it to session
+// handlers as an ephemeral event whenever the session's path policy blocks a
+// file access.
+const SessionEventTypePathDenied SessionEventType = "path.denied"
+
+// PathDeniedData reports a file access blocked by the session's allowed and
+// denied paths.
+type PathDeniedData struct {
+	// Tool call that attempted the access
+	ToolCallID string `json:"toolCallId,omitempty"`
+	// Kind of the rejected permission request: read, write or shell
+	Kind PermissionRequestKind `json:"kind"`
+	// Path as named in the permission request
+	Path string `json:"path"`
+	// Absolute path after resolving "..", "." and symbolic links
+	ResolvedPath string `json:"resolvedPath"`
+	// Why the path was blocked
+	Reason string `json:"reason"`
+}
+
+func (*PathDeniedData) sessionEventData() {}
+func (*PathDeniedData) Type() SessionEventType {
+	return SessionEventTypePathDenied
+}
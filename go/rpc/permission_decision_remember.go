@@ -0,0 +1,42 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// PermissionRememberScope selects which later permission requests a
+// remembered decision applies to.
+type PermissionRememberScope string
+
+const (
+	// Requests for the same tool: the same MCP server tool or custom tool,
+	// or any request of the same kind for other kinds
+	PermissionRememberScopeTool PermissionRememberScope = "tool"
+	// Shell requests whose commands all have a remembered decision, keyed by
+	// command identifier (e.g. "git" or "npm test")
+	PermissionRememberScopeCommand PermissionRememberScope = "command"
+	// Read and write requests for the same file, after resolving symbolic
+	// links
+	PermissionRememberScopePath PermissionRememberScope = "path"
+	// Read and write requests for paths in the same directory as the file or
+	// below it, after resolving symbolic links
+	PermissionRememberScopeDirectory PermissionRememberScope = "directory"
+)
+
+// PermissionDecisionRemember is an SDK-only [PermissionDecision] value
+// returned by a permission handler to apply Decision to the request and
+// remember it for later matching requests, as selected by Scope. The SDK
+// answers those requests itself, without calling the handler. When Persist
+// is set, the decision is also saved to the session's permission store so
+// it applies to later sessions using that store; otherwise it lasts for the
+// rest of the session. The SDK sends only Decision on the wire.
+type PermissionDecisionRemember struct {
+	// Decision is applied to this request. Approvals remember an approval;
+	// any other decision remembers a rejection.
+	Decision PermissionDecision
+	Scope    PermissionRememberScope
+	Persist  bool
+}
+
+func (PermissionDecisionRemember) permissionDecision() {}
+func (PermissionDecisionRemember) Kind() PermissionDecisionKind {
+	return PermissionDecisionKind("remember")
+}
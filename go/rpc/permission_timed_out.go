@@ -0,0 +1,29 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypePermissionTimedOut identifies SDK-synthesized
+// [PermissionTimedOutData] events. The runtime never sends this type; the
+// SDK delivers it to session handlers as an ephemeral event when a
+// permission handler does not decide within the session's permission
+// timeout.
+const SessionEventTypePermissionTimedOut SessionEventType = "permission.timed_out"
+
+// PermissionTimedOutData reports a permission request that the SDK resolved
+// with the session's timeout decision because the handler did not return in
+// time.
+type PermissionTimedOutData struct {
+	// Request ID of the permission request
+	RequestID string `json:"requestId"`
+	// Kind of the permission request
+	RequestKind PermissionRequestKind `json:"requestKind"`
+	// How long the SDK waited for the handler, in milliseconds
+	TimeoutMs int64 `json:"timeoutMs"`
+	// Kind of the decision the request was resolved with
+	Decision PermissionDecisionKind `json:"decision"`
+}
+
+func (*PermissionTimedOutData) sessionEventData() {}
+func (*PermissionTimedOutData) Type() SessionEventType {
+	return SessionEventTypePermissionTimedOut
+}
@@ -0,0 +1,23 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeSessionMessageReceived identifies SDK-synthesized
+// [SessionMessageReceivedData] events. The runtime never sends this type; the
+// SDK delivers it to the receiving session's handlers as an ephemeral event
+// when another session posts it a message.
+const SessionEventTypeSessionMessageReceived SessionEventType = "session.message_received"
+
+// SessionMessageReceivedData reports a message posted to the session by
+// another session. The message is added to the session's next turn.
+type SessionMessageReceivedData struct {
+	// ID of the session that posted the message
+	FromSessionID string `json:"fromSessionId"`
+	// Message content
+	Content string `json:"content"`
+}
+
+func (*SessionMessageReceivedData) sessionEventData() {}
+func (*SessionMessageReceivedData) Type() SessionEventType {
+	return SessionEventTypeSessionMessageReceived
+}
@@ -0,0 +1,28 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeToolOutputDelta identifies SDK-synthesized
+// [ToolOutputDeltaData] events. The runtime never sends this type; the SDK
+// derives it from the partial results of running shell tools and delivers it
+// to session handlers as an ephemeral event.
+const SessionEventTypeToolOutputDelta SessionEventType = "tool.output_delta"
+
+// ToolOutputDeltaData carries a chunk of terminal output from a running shell
+// tool. Output is passed through unmodified, including ANSI escape sequences,
+// so it can be written straight to a terminal emulator.
+type ToolOutputDeltaData struct {
+	// Tool call producing the output
+	ToolCallID string `json:"toolCallId"`
+	// Name of the shell tool, e.g. "bash"
+	ToolName string `json:"toolName"`
+	// Output written since the previous delta. Shell tools run in a pseudo-terminal, so stdout and stderr arrive interleaved as a terminal would show them
+	Delta string `json:"delta"`
+	// Number of bytes of output that preceded this delta for the tool call
+	Offset int `json:"offset"`
+}
+
+func (*ToolOutputDeltaData) sessionEventData() {}
+func (*ToolOutputDeltaData) Type() SessionEventType {
+	return SessionEventTypeToolOutputDelta
+}
@@ -0,0 +1,26 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeToolProgress identifies SDK-synthesized [ToolProgressData]
+// events. The runtime never sends this type; the SDK emits it when a custom
+// tool handler reports progress and delivers it to session handlers as an
+// ephemeral event. The model does not see it.
+const SessionEventTypeToolProgress SessionEventType = "tool.progress"
+
+// ToolProgressData reports intermediate progress of a running custom tool.
+type ToolProgressData struct {
+	// Tool call reporting progress
+	ToolCallID string `json:"toolCallId"`
+	// Name of the tool
+	ToolName string `json:"toolName"`
+	// Human-readable status, e.g. "compiling 12/40 packages"
+	Message string `json:"message"`
+	// Completion percentage from 0 to 100, or nil when the tool cannot estimate it
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+func (*ToolProgressData) sessionEventData() {}
+func (*ToolProgressData) Type() SessionEventType {
+	return SessionEventTypeToolProgress
+}
@@ -0,0 +1,36 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeTurnLimitExceeded identifies SDK-synthesized
+// [TurnLimitExceededData] events. The runtime never sends this type; the SDK
+// delivers it to session handlers as an ephemeral event when a turn exceeds
+// a limit set with its message, just before it aborts the turn.
+const SessionEventTypeTurnLimitExceeded SessionEventType = "turn.limit_exceeded"
+
+// TurnLimit identifies a per-turn limit.
+type TurnLimit string
+
+const (
+	// The number of tool calls started by the turn
+	TurnLimitToolCalls TurnLimit = "maxToolCalls"
+	// The number of output tokens the model produced in the turn
+	TurnLimitOutputTokens TurnLimit = "maxOutputTokens"
+	// The wall-clock time since the message was sent, in milliseconds
+	TurnLimitDuration TurnLimit = "maxDuration"
+)
+
+// TurnLimitExceededData reports that a turn exceeded one of its limits and
+// is being aborted.
+type TurnLimitExceededData struct {
+	Limit TurnLimit `json:"limit"`
+	// The configured maximum
+	Max int64 `json:"max"`
+	// The amount used when the limit was exceeded
+	Used int64 `json:"used"`
+}
+
+func (*TurnLimitExceededData) sessionEventData() {}
+func (*TurnLimitExceededData) Type() SessionEventType {
+	return SessionEventTypeTurnLimitExceeded
+}
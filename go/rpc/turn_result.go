@@ -0,0 +1,29 @@
+// Copyright (c) GitHub. All rights reserved.
+
+package rpc
+
+// SessionEventTypeTurnResult identifies SDK-synthesized [TurnResultData]
+// events. The runtime never sends this type; the SDK delivers it to session
+// handlers as an ephemeral event, just before the turn's session.idle, when
+// the session's OnTurnResult hook returns a result.
+const SessionEventTypeTurnResult SessionEventType = "turn.result"
+
+// TurnResultData reports the postscript and review flag the application's
+// OnTurnResult hook added to a completed turn.
+type TurnResultData struct {
+	// ID of the turn: the ID of the user.message event that started it
+	TurnID string `json:"turnId"`
+	// Final assistant content of the turn, with the postscript appended
+	Content string `json:"content"`
+	// Text appended to the turn's final assistant content, if any
+	Postscript string `json:"postscript,omitempty"`
+	// Whether the turn was flagged for human review
+	FlaggedForReview bool `json:"flaggedForReview,omitempty"`
+	// Why the turn was flagged for review, if given
+	ReviewReason string `json:"reviewReason,omitempty"`
+}
+
+func (*TurnResultData) sessionEventData() {}
+func (*TurnResultData) Type() SessionEventType {
+	return SessionEventTypeTurnResult
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package copilot
+
+import "os"
+
+// processResourceUsage fills in the CPU time of an exited process. Memory
+// and I/O usage are not available on this platform.
+func processResourceUsage(state *os.ProcessState, usage *ToolResourceUsage) {
+	usage.UserCPUTime = state.UserTime()
+	usage.SystemCPUTime = state.SystemTime()
+}
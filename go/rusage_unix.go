@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package copilot
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// processResourceUsage fills in the usage reported by wait4 for an exited
+// process, which covers the process and its waited-for descendants.
+func processResourceUsage(state *os.ProcessState, usage *ToolResourceUsage) {
+	usage.UserCPUTime = state.UserTime()
+	usage.SystemCPUTime = state.SystemTime()
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return
+	}
+	usage.MaxRSSBytes = int64(rusage.Maxrss)
+	if runtime.GOOS != "darwin" {
+		usage.MaxRSSBytes *= 1024 // kilobytes everywhere but macOS
+	}
+	if runtime.GOOS == "linux" {
+		// Linux counts block I/O in 512-byte units.
+		usage.ReadBytes = int64(rusage.Inblock) * 512
+		usage.WriteBytes = int64(rusage.Oublock) * 512
+	}
+}
@@ -0,0 +1,235 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ScratchDirConfig configures the session's scratch directory. See
+// [SessionConfig.ScratchDir].
+//
+// Agents produce intermediate files: downloads, generated data, build
+// outputs, notes. A session with a scratch directory gets a directory of its
+// own outside the user's workspace, tells the model to put such files there,
+// approves the runtime's reads and writes inside it without asking, and
+// deletes it when the session is disconnected.
+type ScratchDirConfig struct {
+	// Root is the directory the scratch directory is created in. Defaults
+	// to [os.TempDir].
+	Root string
+	// MaxBytes caps the total size of the files in the scratch directory.
+	// Writes that would exceed it are rejected. Defaults to 256 MiB.
+	MaxBytes int64
+}
+
+// ErrScratchDirFull is returned by [ToolInvocation.ScratchDir] and
+// [Session.ScratchDir] when the files in the scratch directory have reached
+// [ScratchDirConfig.MaxBytes].
+var ErrScratchDirFull = errors.New("scratch directory is full")
+
+// errScratchDirNotEnabled is returned for the scratch directory of a session
+// created without one.
+var errScratchDirNotEnabled = errors.New("ScratchDir requires SessionConfig.ScratchDir to be set")
+
+// scratchDir is a session's scratch directory, created on first use.
+type scratchDir struct {
+	root     string
+	maxBytes int64
+	session  *Session
+
+	mu sync.Mutex
+	// path is empty until the directory is created. It is resolved, so it
+	// can be compared with resolved request paths.
+	path string
+}
+
+// newScratchDir returns the scratch directory for session, or nil if config
+// is nil. Nothing is created until the directory is used.
+func newScratchDir(config *ScratchDirConfig, session *Session) *scratchDir {
+	if config == nil {
+		return nil
+	}
+	root := config.Root
+	if root == "" {
+		root = os.TempDir()
+	}
+	maxBytes := config.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 256 << 20
+	}
+	return &scratchDir{root: root, maxBytes: maxBytes, session: session}
+}
+
+// scratchName makes a session ID safe to use as a file name.
+func scratchName(sessionID string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, sessionID)
+}
+
+// create creates the directory if it does not exist yet and returns its
+// path. It gets a new, unpredictable name, so a directory or link someone
+// else put in the root in advance is never used.
+func (d *scratchDir) create() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.path != "" {
+		return d.path, nil
+	}
+	if err := os.MkdirAll(d.root, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	path, err := os.MkdirTemp(d.root, "copilot-scratch-"+scratchName(d.session.SessionID)+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	if resolved, err := (&pathGuard{}).resolve(path); err == nil {
+		path = resolved
+	}
+	d.path = path
+	return path, nil
+}
+
+// created returns the directory's path, or "" if it has not been created.
+func (d *scratchDir) created() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.path
+}
+
+// get creates the directory and returns its path, or [ErrScratchDirFull].
+func (d *scratchDir) get() (string, error) {
+	if d == nil {
+		return "", errScratchDirNotEnabled
+	}
+	path, err := d.create()
+	if err != nil {
+		return "", err
+	}
+	if used := d.usage(path); used >= d.maxBytes {
+		return "", fmt.Errorf("%w: %s holds %d of %d bytes", ErrScratchDirFull, path, used, d.maxBytes)
+	}
+	return path, nil
+}
+
+// usage returns the total size of the files in the directory at path.
+func (d *scratchDir) usage(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// remove deletes the directory and everything in it.
+func (d *scratchDir) remove() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.path == "" {
+		return
+	}
+	if err := os.RemoveAll(d.path); err != nil {
+		d.session.logger().Warn("failed to remove scratch directory", "path", d.path, "error", err)
+	}
+	d.path = ""
+}
+
+// contains reports whether path, relative to the session's working
+// directory, is in the scratch directory.
+func (d *scratchDir) contains(path string) bool {
+	dir := d.created()
+	if path == "" || dir == "" {
+		return false
+	}
+	resolved, err := (&pathGuard{dir: d.session.workingDirectory}).resolve(path)
+	return err == nil && withinPath(dir, resolved)
+}
+
+// enforcePermission answers read and write requests for files in the
+// scratch directory, approving them unless a write would exceed the size
+// cap, and reports whether it did.
+func (d *scratchDir) enforcePermission(req *PermissionRequestedData) bool {
+	var path string
+	var size int64
+	switch r := req.PermissionRequest.(type) {
+	case *PermissionRequestRead:
+		path = r.Path
+	case *PermissionRequestWrite:
+		path = r.FileName
+		size = int64(len(derefString(r.NewFileContents)))
+		if size == 0 {
+			size = 1 // an edit; its final size is unknown
+		}
+	}
+	if !d.contains(path) {
+		return false
+	}
+
+	var decision rpc.PermissionDecision = &rpc.PermissionDecisionApproveOnce{}
+	if dir := d.created(); size > 0 {
+		if used := d.usage(dir); used+size > d.maxBytes {
+			feedback := fmt.Sprintf("The scratch directory %s holds %d bytes and is limited to %d. Delete files from it that are no longer needed.", dir, used, d.maxBytes)
+			decision = &rpc.PermissionDecisionReject{Feedback: &feedback}
+		}
+	}
+	d.session.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		RequestID: req.RequestID,
+		Result:    decision,
+	})
+	return true
+}
+
+// context returns the prompt context telling the model about the directory.
+func (d *scratchDir) context() string {
+	path, err := d.create()
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("Put intermediate and temporary files, such as downloads, generated data and build outputs, in the scratch directory %s rather than in the workspace. It is deleted when the session ends and holds at most %d MiB.", path, max(d.maxBytes>>20, 1))
+}
+
+// ScratchDir returns the session's scratch directory, creating it if needed.
+// It returns an error if the session has no [SessionConfig.ScratchDir], and
+// [ErrScratchDirFull] when the directory has reached its size cap.
+func (s *Session) ScratchDir() (string, error) {
+	return s.scratch.get()
+}
+
+// ScratchDir returns the session's scratch directory (see
+// [SessionConfig.ScratchDir]), creating it if needed, for files the tool
+// produces that do not belong in the user's workspace. The model is told
+// about the same directory, so the tool can return paths in it.
+//
+// Example:
+//
+//	copilot.DefineTool("fetch_logs", "Download the build logs",
+//	    func(params FetchParams, inv copilot.ToolInvocation) (string, error) {
+//	        dir, err := inv.ScratchDir()
+//	        if err != nil {
+//	            return "", err
+//	        }
+//	        path := filepath.Join(dir, params.Build+".log")
+//	        return path, download(params.Build, path)
+//	    })
+func (inv ToolInvocation) ScratchDir() (string, error) {
+	return inv.sessionScope().scratch.get()
+}
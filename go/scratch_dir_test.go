@@ -0,0 +1,127 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestScratchDir(t *testing.T) {
+	t.Run("is created on demand and removed on disconnect", func(t *testing.T) {
+		root := t.TempDir()
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return sessionIDResult(params), nil
+			},
+			"session.destroy": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{}`), nil
+			},
+		})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			SessionID:           "team/a b",
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			ScratchDir:          &ScratchDirConfig{Root: root},
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		if entries, _ := os.ReadDir(root); len(entries) != 0 {
+			t.Fatalf("expected nothing to be created before use, got %v", entries)
+		}
+
+		// A directory planted where the name is predictable is not used.
+		planted := filepath.Join(root, "copilot-scratch-team_a_b")
+		if err := os.Mkdir(planted, 0o777); err != nil {
+			t.Fatal(err)
+		}
+		dir, err := ToolInvocation{scope: &toolCallScope{scratch: session.scratch}}.ScratchDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(filepath.Base(dir), "copilot-scratch-team_a_b-") {
+			t.Errorf("expected a directory named after the session, got %q", dir)
+		}
+		if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+			t.Errorf("expected a new directory only this user can use, got %v, %v", info, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if hook := session.addPromptContext(nil, "hi"); hook == nil || !strings.Contains(hook.AdditionalContext, dir) {
+			t.Errorf("expected the prompt context to name %q, got %+v", dir, hook)
+		}
+
+		if err := session.Disconnect(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("expected the directory to be removed, got %v", err)
+		}
+		if _, err := (ToolInvocation{}).ScratchDir(); err == nil {
+			t.Error("expected an error without SessionConfig.ScratchDir")
+		}
+	})
+
+	t.Run("approves access inside the directory up to the size cap", func(t *testing.T) {
+		decisions := make(chan map[string]any, 4)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.permissions.handlePendingPermissionRequest": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct {
+					Result map[string]any `json:"result"`
+				}
+				json.Unmarshal(params, &req)
+				decisions <- req.Result
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.owner = client
+		session.scratch = newScratchDir(&ScratchDirConfig{Root: t.TempDir(), MaxBytes: 8}, session)
+		dir, err := session.ScratchDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decide := func(request PermissionRequest) map[string]any {
+			t.Helper()
+			if !session.scratch.enforcePermission(&PermissionRequestedData{RequestID: "p1", PermissionRequest: request}) {
+				return nil
+			}
+			select {
+			case result := <-decisions:
+				return result
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the decision")
+				return nil
+			}
+		}
+
+		if got := decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "a.txt"), NewFileContents: ptr("12345")}); got["kind"] != "approve-once" {
+			t.Errorf("expected a write within the cap to be approved, got %v", got)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("12345"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := decide(&PermissionRequestWrite{FileName: filepath.Join(dir, "b.txt"), NewFileContents: ptr("6789")}); got["kind"] != "reject" {
+			t.Errorf("expected a write past the cap to be rejected, got %v", got)
+		}
+		if got := decide(&PermissionRequestRead{Path: filepath.Join(dir, "a.txt")}); got["kind"] != "approve-once" {
+			t.Errorf("expected a read to be approved, got %v", got)
+		}
+		if got := decide(&PermissionRequestRead{Path: filepath.Join(filepath.Dir(dir), "other.txt")}); got != nil {
+			t.Errorf("expected a read outside the directory to be left to the handler, got %v", got)
+		}
+
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte("678"), 0o600)
+		if _, err := session.ScratchDir(); !errors.Is(err, ErrScratchDirFull) {
+			t.Errorf("expected ErrScratchDirFull, got %v", err)
+		}
+		session.scratch.remove()
+	})
+}
@@ -0,0 +1,156 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// BusyPolicy selects what [Session.Send] and [Session.SendAndWait] do when
+// called while a turn is running. See [SessionConfig.BusyPolicy].
+type BusyPolicy string
+
+const (
+	// BusyPolicyQueue holds the message until the running turn and the
+	// messages queued before it have finished, then sends it. Send and
+	// SendAndWait block while the message is queued.
+	BusyPolicyQueue BusyPolicy = "queue"
+	// BusyPolicyReject fails the send with [ErrSessionBusy].
+	BusyPolicyReject BusyPolicy = "reject"
+	// BusyPolicyInterrupt stops the running turn, as [Session.Interrupt]
+	// does, and sends the message in its place.
+	BusyPolicyInterrupt BusyPolicy = "interrupt"
+)
+
+// ErrSessionBusy is returned by [Session.Send] and [Session.SendAndWait]
+// for a message sent while a turn is running, when the session's
+// [BusyPolicy] is [BusyPolicyReject].
+var ErrSessionBusy = errors.New("session is busy")
+
+func (p BusyPolicy) validate() error {
+	switch p {
+	case "", BusyPolicyQueue, BusyPolicyReject, BusyPolicyInterrupt:
+		return nil
+	}
+	return fmt.Errorf("invalid BusyPolicy %q", p)
+}
+
+// sendQueue orders the sends waiting for the running turn to end. Each
+// send holds a ticket, its place in the queue. The zero value is ready to
+// use.
+type sendQueue struct {
+	mu      sync.Mutex
+	tickets uint64
+	waiting []uint64
+	// changed is closed and replaced whenever a send leaves the queue.
+	changed chan struct{}
+}
+
+func (q *sendQueue) push() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tickets++
+	q.waiting = append(q.waiting, q.tickets)
+	return q.tickets
+}
+
+func (q *sendQueue) remove(ticket uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.waiting = slices.DeleteFunc(q.waiting, func(t uint64) bool { return t == ticket })
+	if q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
+
+// next reports whether ticket is first in the queue, and returns a channel
+// that is closed when the queue next changes.
+func (q *sendQueue) next(ticket uint64) (bool, <-chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.changed == nil {
+		q.changed = make(chan struct{})
+	}
+	return len(q.waiting) > 0 && q.waiting[0] == ticket, q.changed
+}
+
+func (q *sendQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// QueueLength returns the number of sends blocked waiting for the running
+// turn to end. Messages the runtime has queued are not counted.
+func (s *Session) QueueLength() int {
+	return s.queue.len()
+}
+
+// enterHandler records that one of the session's handlers is running, and
+// returns a function that records its return.
+func (s *Session) enterHandler() func() {
+	s.runningHandlers.Add(1)
+	return func() { s.runningHandlers.Add(-1) }
+}
+
+// inHandler reports whether one of the session's handlers is running. A
+// send made from a handler must not wait for the running turn, which may
+// itself be waiting for the handler.
+func (s *Session) inHandler() bool {
+	return s.runningHandlers.Load() > 0
+}
+
+// startTurn starts a turn for a send with context ctx, first queueing,
+// rejecting or interrupting according to the session's BusyPolicy if a
+// turn is running. It reports whether the send owns the turn; if not, the
+// message goes to the runtime, which runs it after the running turn.
+func (s *Session) startTurn(ctx context.Context) (bool, error) {
+	switch s.busyPolicy {
+	case "":
+		return false, nil
+	case BusyPolicyReject:
+		if _, ok := s.idle.tryBeginTurn(ctx); !ok {
+			return false, ErrSessionBusy
+		}
+		return true, nil
+	case BusyPolicyInterrupt:
+		for {
+			if _, ok := s.idle.tryBeginTurn(ctx); ok {
+				return true, nil
+			}
+			if s.inHandler() {
+				return false, nil
+			}
+			if err := s.Interrupt(ctx); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if s.inHandler() && s.idle.waitTurn() != nil {
+		return false, nil
+	}
+	ticket := s.queue.push()
+	defer s.queue.remove(ticket)
+	for {
+		first, changed := s.queue.next(ticket)
+		var ended <-chan struct{}
+		if first {
+			var ok bool
+			if ended, ok = s.idle.tryBeginTurn(ctx); ok {
+				return true, nil
+			}
+		}
+		select {
+		case <-ended:
+		case <-changed:
+		case <-s.done:
+			return false, ErrSessionClosed
+		case <-ctx.Done():
+			return false, fmt.Errorf("waiting for the running turn to end: %w", ctx.Err())
+		}
+	}
+}
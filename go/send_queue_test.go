@@ -0,0 +1,175 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_BusyPolicy(t *testing.T) {
+	// newBusySession returns a session with a running turn, and the prompts
+	// and abort requests the runtime receives.
+	newBusySession := func(t *testing.T, policy BusyPolicy) (*Session, chan string, chan struct{}) {
+		t.Helper()
+		prompts := make(chan string, 4)
+		aborts := make(chan struct{}, 4)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req sessionSendRequest
+				json.Unmarshal(params, &req)
+				prompts <- req.Prompt
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+			"session.abort": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				aborts <- struct{}{}
+				return []byte(`{}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		session.busyPolicy = policy
+		session.idle.beginTurn(context.Background())
+		return session, prompts, aborts
+	}
+	waitQueueLength := func(t *testing.T, session *Session, want int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for session.QueueLength() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a queue length of %d, got %d", want, session.QueueLength())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	expectPrompt := func(t *testing.T, prompts chan string, want string) {
+		t.Helper()
+		select {
+		case got := <-prompts:
+			if got != want {
+				t.Errorf("expected %q to be sent, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q to be sent", want)
+		}
+	}
+	expectNoPrompt := func(t *testing.T, prompts chan string) {
+		t.Helper()
+		select {
+		case got := <-prompts:
+			t.Fatalf("expected nothing to be sent, got %q", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("queues sends in order until the running turn ends", func(t *testing.T) {
+		session, prompts, _ := newBusySession(t, BusyPolicyQueue)
+		errs := make(chan error, 2)
+		for i, prompt := range []string{"first", "second"} {
+			go func() {
+				_, err := session.Send(t.Context(), MessageOptions{Prompt: prompt})
+				errs <- err
+			}()
+			waitQueueLength(t, session, i+1)
+		}
+		expectNoPrompt(t, prompts)
+
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		expectPrompt(t, prompts, "first")
+		if err := <-errs; err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		waitQueueLength(t, session, 1)
+		expectNoPrompt(t, prompts)
+
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		expectPrompt(t, prompts, "second")
+		if err := <-errs; err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		waitQueueLength(t, session, 0)
+	})
+
+	t.Run("rejects sends with ErrSessionBusy", func(t *testing.T) {
+		session, prompts, _ := newBusySession(t, BusyPolicyReject)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi"}); !errors.Is(err, ErrSessionBusy) {
+			t.Errorf("expected ErrSessionBusy from Send, got %v", err)
+		}
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "hi"}); !errors.Is(err, ErrSessionBusy) {
+			t.Errorf("expected ErrSessionBusy from SendAndWait, got %v", err)
+		}
+		expectNoPrompt(t, prompts)
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "steer", Mode: "immediate"}); err != nil {
+			t.Fatalf("expected an immediate message to be sent, got %v", err)
+		}
+		expectPrompt(t, prompts, "steer")
+
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		if err := session.WaitIdle(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "next"}); err != nil {
+			t.Fatalf("expected Send to succeed once idle, got %v", err)
+		}
+		expectPrompt(t, prompts, "next")
+	})
+
+	t.Run("interrupts the running turn and sends in its place", func(t *testing.T) {
+		session, prompts, aborts := newBusySession(t, BusyPolicyInterrupt)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := session.Send(t.Context(), MessageOptions{Prompt: "instead"})
+			errs <- err
+		}()
+		<-aborts
+		expectNoPrompt(t, prompts)
+
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{Aborted: Bool(true)}})
+		expectPrompt(t, prompts, "instead")
+		if err := <-errs; err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	})
+
+	t.Run("sends at once without a policy", func(t *testing.T) {
+		session, prompts, _ := newBusySession(t, "")
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		expectPrompt(t, prompts, "hi")
+		if n := session.QueueLength(); n != 0 {
+			t.Errorf("expected nothing to be queued, got %d", n)
+		}
+	})
+
+	t.Run("sends at once from the session's handlers", func(t *testing.T) {
+		for _, policy := range []BusyPolicy{BusyPolicyQueue, BusyPolicyInterrupt} {
+			session, prompts, aborts := newBusySession(t, policy)
+			errs := make(chan error, 1)
+			session.On(func(event SessionEvent) {
+				if _, ok := event.Data.(*AssistantMessageData); ok {
+					_, err := session.Send(t.Context(), MessageOptions{Prompt: "follow-up"})
+					errs <- err
+				}
+			})
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "m0", Content: "hello"}})
+			expectPrompt(t, prompts, "follow-up")
+			if err := <-errs; err != nil {
+				t.Fatalf("Send with %s failed: %v", policy, err)
+			}
+			if len(aborts) != 0 || session.QueueLength() != 0 {
+				t.Errorf("expected %s to leave the running turn alone", policy)
+			}
+		}
+	})
+
+	t.Run("rejects unknown policies", func(t *testing.T) {
+		if err := BusyPolicy("drop").validate(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// SendTo sends a message and streams the assistant's reply to w as it is
+// generated, then returns the final assistant message like
+// [Session.SendAndWait].
+//
+// Text deltas are written as they arrive when the session was created with
+// Streaming enabled; otherwise each assistant message is written whole
+// once complete. Messages of one turn are separated by a blank line, and
+// sub-agent output is not written. After each write, w is flushed if it
+// implements [http.Flusher] or has a Flush() error method, such as
+// [bufio.Writer].
+//
+// If a write fails, SendTo stops waiting and returns the write error; the
+// turn itself keeps running. Timeouts and errors otherwise behave as for
+// [Session.SendAndWait].
+//
+// Example:
+//
+//	http.HandleFunc("/ask", func(w http.ResponseWriter, r *http.Request) {
+//		_, err := session.SendTo(r.Context(), copilot.MessageOptions{
+//			Prompt: r.FormValue("q"),
+//		}, w)
+//		if err != nil {
+//			log.Printf("ask: %v", err)
+//		}
+//	})
+func (s *Session) SendTo(ctx context.Context, options MessageOptions, w io.Writer) (*SessionEvent, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	sw := &streamWriter{w: w}
+	unsubscribe := s.On(func(event SessionEvent) {
+		var err error
+		switch d := event.Data.(type) {
+		case *AssistantMessageDeltaData:
+			if d.ParentToolCallID == nil {
+				err = sw.writeDelta(d.MessageID, d.DeltaContent)
+			}
+		case *AssistantMessageData:
+			if d.ParentToolCallID == nil {
+				err = sw.writeMessage(d.MessageID, d.Content)
+			}
+		}
+		if err != nil {
+			cancel(err)
+		}
+	})
+	defer unsubscribe()
+
+	result, err := s.SendAndWait(ctx, options)
+	if werr := sw.error(); werr != nil {
+		return nil, fmt.Errorf("failed to write response: %w", werr)
+	}
+	return result, err
+}
+
+// streamWriter writes the assistant messages of a turn to an io.Writer,
+// remembering which messages have been streamed as deltas.
+type streamWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	streamed map[string]bool
+	current  string
+	wrote    bool
+	err      error
+}
+
+func (sw *streamWriter) writeDelta(messageID, delta string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.streamed == nil {
+		sw.streamed = make(map[string]bool)
+	}
+	sw.streamed[messageID] = true
+	return sw.write(messageID, delta)
+}
+
+// writeMessage writes a complete message unless it was already streamed.
+func (sw *streamWriter) writeMessage(messageID, content string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.streamed[messageID] {
+		return nil
+	}
+	return sw.write(messageID, content)
+}
+
+func (sw *streamWriter) write(messageID, text string) error {
+	if sw.err != nil || text == "" {
+		return sw.err
+	}
+	if sw.wrote && messageID != sw.current {
+		text = "\n\n" + text
+	}
+	sw.current = messageID
+	sw.wrote = true
+	if _, err := io.WriteString(sw.w, text); err != nil {
+		sw.err = err
+		return err
+	}
+	switch f := sw.w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface{ Flush() error }:
+		sw.err = f.Flush()
+	}
+	return sw.err
+}
+
+func (sw *streamWriter) error() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.err
+}
@@ -0,0 +1,80 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// startReplyTestSession returns a session whose session.send dispatches
+// events as the runtime's reply.
+func startReplyTestSession(t *testing.T, reply ...SessionEventData) *Session {
+	t.Helper()
+	var session *Session
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			for _, data := range reply {
+				session.dispatchEvent(SessionEvent{Data: data})
+			}
+			session.dispatchEvent(newTestEvent())
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+	})
+	session = newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+	return session
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestSession_SendTo(t *testing.T) {
+	t.Run("streams deltas and returns the final message", func(t *testing.T) {
+		session := startReplyTestSession(t,
+			&AssistantMessageDeltaData{MessageID: "a1", DeltaContent: "Let me "},
+			&AssistantMessageDeltaData{MessageID: "a1", DeltaContent: "check."},
+			&AssistantMessageData{MessageID: "a1", Content: "Let me check."},
+			&AssistantMessageDeltaData{MessageID: "sub", DeltaContent: "sub-agent", ParentToolCallID: ptr("t1")},
+			&AssistantMessageDeltaData{MessageID: "a2", DeltaContent: "It is 4."},
+			&AssistantMessageData{MessageID: "a2", Content: "It is 4."},
+		)
+		w := httptest.NewRecorder()
+		result, err := session.SendTo(t.Context(), MessageOptions{Prompt: "2+2?"}, w)
+		if err != nil {
+			t.Fatalf("SendTo failed: %v", err)
+		}
+		if got := w.Body.String(); got != "Let me check.\n\nIt is 4." {
+			t.Errorf("unexpected output %q", got)
+		}
+		if !w.Flushed {
+			t.Error("expected the writer to be flushed")
+		}
+		if d, ok := result.Data.(*AssistantMessageData); !ok || d.MessageID != "a2" {
+			t.Errorf("expected the final message, got %+v", result)
+		}
+	})
+
+	t.Run("writes whole messages without streaming", func(t *testing.T) {
+		session := startReplyTestSession(t, &AssistantMessageData{MessageID: "a1", Content: "It is 4."})
+		var out strings.Builder
+		if _, err := session.SendTo(t.Context(), MessageOptions{Prompt: "2+2?"}, &out); err != nil {
+			t.Fatalf("SendTo failed: %v", err)
+		}
+		if out.String() != "It is 4." {
+			t.Errorf("unexpected output %q", out.String())
+		}
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		session := startReplyTestSession(t, &AssistantMessageDeltaData{MessageID: "a1", DeltaContent: "hi"})
+		_, err := session.SendTo(t.Context(), MessageOptions{Prompt: "hi"}, failingWriter{})
+		if err == nil || !strings.Contains(err.Error(), "connection reset") {
+			t.Errorf("expected the write error, got %v", err)
+		}
+	})
+}
@@ -4,13 +4,16 @@ package copilot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
 	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/jsonschema-go/jsonschema"
 )
 
 // toolSearchToolName is the fixed name of the runtime's built-in tool-search
@@ -21,8 +24,15 @@ const toolSearchToolName = "tool_search_tool"
 type sessionHandler struct {
 	id uint64
 	fn SessionEventHandler
+	// removed is set when the handler is unsubscribed, so an event already
+	// being delivered skips it.
+	removed atomic.Bool
 }
 
+// ErrSessionClosed is returned by [Session.Send] and [Session.SendAndWait]
+// once the session has been disconnected.
+var ErrSessionClosed = errors.New("session is closed")
+
 // Session represents a single conversation session with the Copilot CLI.
 //
 // A session maintains conversation state, handles events, and manages tool execution.
@@ -56,14 +66,17 @@ type sessionHandler struct {
 //	})
 type Session struct {
 	// SessionID is the unique identifier for this session.
-	SessionID             string
-	workspacePath         string
-	client                *jsonrpc2.Client
+	SessionID     string
+	workspacePath string
+	// conn is the connection to the runtime. Reconnects and restarts
+	// replace it, so read it once per call with s.conn.Load().
+	conn                  atomic.Pointer[sessionConn]
 	clientSessionAPIs     *rpc.ClientSessionAPIHandlers
-	handlers              []sessionHandler
+	handlers              []*sessionHandler
 	nextHandlerID         uint64
 	handlerMutex          sync.RWMutex
 	toolHandlers          map[string]ToolHandler
+	toolSchemas           map[string]*jsonschema.Resolved
 	toolHandlersM         sync.RWMutex
 	permissionHandler     PermissionHandlerFunc
 	permissionMux         sync.RWMutex
@@ -77,6 +90,16 @@ type Session struct {
 	autoModeSwitchMu      sync.RWMutex
 	hooks                 *SessionHooks
 	hooksMux              sync.RWMutex
+	turns                 turnTracker // owned by processEvents
+	idle                  idleState
+	fileEdits             fileEditTracker
+	toolOutput            toolOutputTracker
+	usage                 usageTracker
+	metrics               metricsTracker // owned by processEvents
+	runningTools          runningToolCalls
+	limits                turnLimiter
+	deadline              turnDeadline
+	toolUsage             toolUsageLog
 	transformCallbacks    map[string]SectionTransformFn
 	transformMu           sync.Mutex
 	commandHandlers       map[string]CommandHandler
@@ -94,13 +117,89 @@ type Session struct {
 
 	// eventCh serializes user event handler dispatch. dispatchEvent enqueues;
 	// a single goroutine (processEvents) dequeues and invokes handlers in FIFO order.
-	eventCh   chan SessionEvent
-	closeOnce sync.Once // guards eventCh close so Disconnect is safe to call more than once
-
-	// RPC provides typed session-scoped RPC methods.
+	eventCh chan SessionEvent
+	// done is closed when Disconnect succeeds. It stops processEvents and
+	// wakes blocked senders and waiters; eventCh itself is never closed, so
+	// a late dispatch cannot race with shutdown.
+	done         chan struct{}
+	disconnectMu sync.Mutex // serializes Disconnect
+
+	// attachRequest is the wire create/resume request, kept so the session
+	// can be re-attached after the client reconnects or restarts.
+	attachRequest json.RawMessage
+
+	// ptyShell runs the shell tool when SessionConfig.PTYShell is set.
+	ptyShell *ptyShell
+
+	// egress enforces SessionConfig.EgressPolicy, when set.
+	egress *egressGuard
+	// paths enforces SessionConfig.AllowedPaths and DeniedPaths, when set.
+	paths *pathGuard
+
+	// bedrock serves the runtime's model requests for a Bedrock provider.
+	bedrock *bedrockProxy
+	// vertex serves the runtime's model requests for a Vertex AI provider.
+	vertex *vertexProxy
+	// seed adds SessionConfig.Generation's seed to the provider's requests,
+	// and generation is recorded in exports.
+	seed       *seedProxy
+	generation *GenerationConfig
+
+	// breaker enforces SessionConfig.CircuitBreaker, when set.
+	breaker *circuitBreaker
+	// segmenter applies SessionConfig.DeltaSegmentation, when set.
+	segmenter *deltaSegmenter
+	// output applies SessionConfig.OutputProfile, when set.
+	output *outputFormatter
+	// workingDirectory is SessionConfig.WorkingDirectory, or the first of
+	// its WorkspaceFolders.
+	workingDirectory string
+	// folders holds SessionConfig.WorkspaceFolders, when set.
+	folders *workspaceFolders
+	// scratch is the SessionConfig.ScratchDir directory, when set.
+	scratch *scratchDir
+	// busyPolicy is SessionConfig.BusyPolicy, and queue holds the sends
+	// waiting for the running turn to end.
+	busyPolicy BusyPolicy
+	queue      sendQueue
+	// runningHandlers counts the session's handlers that are running.
+	runningHandlers atomic.Int32
+
+	// permissions answers requests matching remembered permission decisions.
+	permissions permissionMemory
+	// permissionTimeout bounds how long OnPermissionRequest may take.
+	permissionTimeout permissionTimeout
+
+	// urlAttachments bounds the fetches of URL attachments.
+	urlAttachments URLAttachmentConfig
+
+	// timeContext adds SessionConfig.TimeContext to every prompt, when set.
+	timeContext *timeContext
+
+	// locale adds SessionConfig.Locale, or a message's own locale, to every
+	// prompt, when set.
+	locale *localeState
+
+	// inbox holds messages posted by other sessions until the next turn.
+	inbox sessionInbox
+
+	// annotationsMu serializes updates of the session's annotations file.
+	annotationsMu sync.Mutex
+
+	// owner is the client that created or resumed the session.
+	owner *Client
+
+	// RPC provides typed session-scoped RPC methods. It is replaced when the
+	// client reconnects or restarts, so do not hold on to it across those.
 	RPC *rpc.SessionRPC
 }
 
+// sessionConn is the connection a session talks to the runtime over.
+type sessionConn struct {
+	client *jsonrpc2.Client
+	rpc    *rpc.SessionRPC
+}
+
 // WorkspacePath returns the path to the session workspace directory when infinite
 // sessions are enabled. Contains checkpoints/, plan.md, and files/ subdirectories.
 // Returns empty string if infinite sessions are disabled.
@@ -157,7 +256,7 @@ func (s *Session) updateOpenCanvasesFromEvent(event SessionEvent) {
 	switch data := event.Data.(type) {
 	case *SessionCanvasOpenedData:
 		if data.InstanceID == "" || data.CanvasID == "" || data.ExtensionID == "" {
-			fmt.Printf("failed to deserialize session.canvas.opened payload\n")
+			s.logger().Warn("failed to deserialize session.canvas.opened payload")
 			return
 		}
 		s.upsertOpenCanvas(rpc.OpenCanvasInstance{
@@ -173,7 +272,7 @@ func (s *Session) updateOpenCanvasesFromEvent(event SessionEvent) {
 		})
 	case *SessionCanvasClosedData:
 		if data.InstanceID == "" {
-			fmt.Printf("failed to deserialize session.canvas.closed payload\n")
+			s.logger().Warn("failed to deserialize session.canvas.closed payload")
 			return
 		}
 		s.removeOpenCanvas(data.InstanceID)
@@ -369,14 +468,15 @@ func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string)
 	s := &Session{
 		SessionID:         sessionID,
 		workspacePath:     workspacePath,
-		client:            client,
 		clientSessionAPIs: &rpc.ClientSessionAPIHandlers{},
-		handlers:          make([]sessionHandler, 0),
+		handlers:          make([]*sessionHandler, 0),
 		toolHandlers:      make(map[string]ToolHandler),
 		commandHandlers:   make(map[string]CommandHandler),
 		eventCh:           make(chan SessionEvent, 128),
+		done:              make(chan struct{}),
 		RPC:               rpc.NewSessionRPC(client, sessionID),
 	}
+	s.conn.Store(&sessionConn{client: client, rpc: s.RPC})
 	s.clientSessionAPIs.Canvas = newCanvasClientSessionAdapter(s)
 	s.clientSessionAPIs.ProviderToken = newProviderTokenClientSessionAdapter(s)
 	go s.processEvents()
@@ -393,6 +493,12 @@ func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string)
 //
 // Returns the message ID of the response, which can be used to correlate events,
 // or an error if the session has been disconnected or the connection fails.
+// With [ClientOptions.Retry] set, a request that fails with a transient error
+// is retried.
+//
+// A message sent while a turn is running is queued, rejected or interrupts
+// the turn according to [SessionConfig.BusyPolicy]. With no policy set, Send
+// returns at once and the runtime runs the message after the running turn.
 //
 // Example:
 //
@@ -406,12 +512,72 @@ func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string)
 //	    log.Printf("Failed to send message: %v", err)
 //	}
 func (s *Session) Send(ctx context.Context, options MessageOptions) (string, error) {
-	traceparent, tracestate := getTraceContext(ctx)
+	return s.send(ctx, options, true, false)
+}
+
+// send sends a message, retrying the request under ClientOptions.Retry if
+// retry is set. turnStarted is set when the caller has already started the
+// message's turn with startTurn; send then ends it if it fails.
+func (s *Session) send(ctx context.Context, options MessageOptions, retry, turnStarted bool) (messageID string, err error) {
+	ownsTurn := turnStarted
+	defer func() {
+		if err != nil && ownsTurn {
+			s.idle.setTurn(false)
+		}
+	}()
+	if s.isClosed() {
+		return "", ErrSessionClosed
+	}
+	if options.Locale != nil {
+		if s.locale == nil {
+			return "", errLocaleNotEnabled
+		}
+		if err := options.Locale.validate(); err != nil {
+			return "", err
+		}
+	}
+	prompt, attachments := options.Prompt, options.Attachments
+	if len(options.Parts) > 0 {
+		if prompt != "" {
+			return "", errors.New("Prompt and Parts cannot both be set")
+		}
+		text, partAttachments, err := s.composeParts(ctx, options.Parts)
+		if err != nil {
+			return "", err
+		}
+		prompt = text
+		attachments = append(slices.Clip(attachments), partAttachments...)
+	}
+	if len(options.Images) > 0 {
+		images, err := s.imageAttachments(ctx, options.Images)
+		if err != nil {
+			return "", err
+		}
+		attachments = append(slices.Clip(attachments), images...)
+	}
+	attachments, err = s.resolveURLAttachments(ctx, attachments)
+	if err != nil {
+		return "", err
+	}
+	// Messages for the running turn bypass the busy policy.
+	if !ownsTurn && options.Mode != "immediate" {
+		if ownsTurn, err = s.startTurn(ctx); err != nil {
+			return "", err
+		}
+	}
+	posted := s.inbox.take()
+	if len(posted) > 0 {
+		attachments = append(slices.Clip(attachments), inboxAttachments(posted)...)
+	}
+	if hooks := s.getHooks(); hooks != nil && hooks.OnSuspiciousContent != nil {
+		attachments = s.screenAttachments(hooks.OnSuspiciousContent, attachments)
+	}
+	traceparent, tracestate := getTraceContext(s.tracePropagator(), ctx)
 	req := sessionSendRequest{
 		SessionID:      s.SessionID,
-		Prompt:         options.Prompt,
+		Prompt:         prompt,
 		DisplayPrompt:  options.DisplayPrompt,
-		Attachments:    options.Attachments,
+		Attachments:    attachments,
 		Mode:           options.Mode,
 		AgentMode:      options.AgentMode,
 		Traceparent:    traceparent,
@@ -419,10 +585,45 @@ func (s *Session) Send(ctx context.Context, options MessageOptions) (string, err
 		RequestHeaders: options.RequestHeaders,
 	}
 
-	result, err := s.client.Request(ctx, "session.send", req)
+	// Mark the turn before sending, so an idle event that races the
+	// response still ends it.
+	if !ownsTurn {
+		ownsTurn = !s.idle.beginTurn(ctx)
+	}
+	stopLimits := func() {}
+	if options.Limits != nil {
+		stopLimits = s.limits.begin(*options.Limits, func(exceeded *TurnLimitExceededData) {
+			s.exceedTurnLimit(exceeded, s.dispatchEvent)
+		})
+	}
+	stopDeadline := func() {}
+	if deadline := options.turnDeadline(time.Now()); !deadline.IsZero() {
+		stopDeadline = s.deadline.begin(deadline, s.interruptTurn)
+	}
+	// Record the turn's locale before sending too, as the runtime may
+	// submit the prompt before it responds.
+	forgetLocale := func() {}
+	if options.Locale != nil {
+		forgetLocale = s.locale.push(prompt, *options.Locale)
+	}
+	var result json.RawMessage
+	send := func() (err error) {
+		result, err = s.conn.Load().client.Request(ctx, "session.send", req)
+		return err
+	}
+	if retry {
+		err = s.owner.retry(ctx, "send", send)
+	} else {
+		err = send()
+	}
 	if err != nil {
+		forgetLocale()
+		stopLimits()
+		stopDeadline()
+		s.inbox.restore(posted)
 		return "", fmt.Errorf("failed to send message: %w", err)
 	}
+	ownsTurn = false // the runtime ends it now
 
 	var response sessionSendResponse
 	if err := json.Unmarshal(result, &response); err != nil {
@@ -447,13 +648,27 @@ func (s *Session) SendPrompt(ctx context.Context, prompt string) (string, error)
 //
 // Events are still delivered to handlers registered via [Session.On] while waiting.
 //
+// A message sent while a turn is running is queued, rejected or interrupts
+// the turn according to [SessionConfig.BusyPolicy]. With no policy set, it
+// is sent at once and the runtime runs it after the running turn.
+//
 // Parameters:
 //   - options: The message options including the prompt and optional attachments.
 //   - timeout: How long to wait for completion. Defaults to 60 seconds if zero.
 //     Controls how long to wait; does not abort in-flight agent work.
 //
 // Returns the final assistant message event, or nil if none was received.
-// Returns an error if the timeout is reached or the connection fails.
+// Returns an error if the timeout is reached or the connection fails,
+// [ErrSessionClosed] if the session is disconnected while waiting, and an
+// error wrapping [ErrTurnLimitExceeded] if the turn exceeded
+// [MessageOptions.Limits]. If the turn is interrupted at
+// [MessageOptions.Deadline] or [MessageOptions.Timeout], it returns the last
+// complete assistant message, if any, with [ErrTurnDeadlineExceeded]. A turn
+// that fails with a session.error event returns a [*SessionError]; with
+// [ClientOptions.Retry] set, the prompt is sent again if the error is
+// transient. A turn that is aborted returns the last complete assistant
+// message, if any, with a [*TurnAbortedError]; earlier releases returned
+// the message with a nil error.
 //
 // Example:
 //
@@ -469,24 +684,98 @@ func (s *Session) SendPrompt(ctx context.Context, prompt string) (string, error)
 //	    }
 //	}
 func (s *Session) SendAndWait(ctx context.Context, options MessageOptions) (*SessionEvent, error) {
-	if _, ok := ctx.Deadline(); !ok {
+	response, err := s.SendAndWaitResponse(ctx, options)
+	if response == nil {
+		return nil, err
+	}
+	return response.Message, err
+}
+
+// SendAndWaitResponse is like [Session.SendAndWait], but returns a [Response]
+// that also carries the token usage and premium requests of the turn, for
+// chargeback and budgeting. Use [Session.Usage] for the session's totals.
+//
+// If the turn is interrupted at [MessageOptions.Deadline] or
+// [MessageOptions.Timeout], it returns [ErrTurnDeadlineExceeded] together
+// with a [Response] marked Partial, holding what the assistant had said by
+// then. An aborted turn likewise returns a Partial [Response] with a
+// [*TurnAbortedError].
+//
+// Example:
+//
+//	response, err := session.SendAndWaitResponse(ctx, copilot.MessageOptions{Prompt: "What is 2+2?"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(response.Content)
+//	log.Printf("%d input, %d output, %d cached tokens, %.1f premium requests",
+//		response.Usage.InputTokens, response.Usage.OutputTokens,
+//		response.Usage.CacheReadTokens, response.Usage.Cost)
+func (s *Session) SendAndWaitResponse(ctx context.Context, options MessageOptions) (*Response, error) {
+	// The turn's deadline is fixed now, so that the wait and the SDK's
+	// interruption of the turn agree on it, across retries too.
+	options.Deadline = options.turnDeadline(time.Now())
+	options.Timeout = 0
+	if _, ok := ctx.Deadline(); !ok && options.Deadline.IsZero() {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
 		defer cancel()
 	}
+	var response *Response
+	err := s.owner.retry(ctx, "send and wait", func() (err error) {
+		response, err = s.sendAndWait(ctx, options)
+		return err
+	})
+	return response, err
+}
+
+// sendAndWait makes one attempt of [Session.SendAndWaitResponse].
+func (s *Session) sendAndWait(ctx context.Context, options MessageOptions) (*Response, error) {
+	var deadlineCh <-chan time.Time
+	if !options.Deadline.IsZero() {
+		timer := time.NewTimer(time.Until(options.Deadline))
+		defer timer.Stop()
+		deadlineCh = timer.C
+	}
+
+	// Take the turn before subscribing, so the events of a turn that was
+	// running are not taken for this one's.
+	turnStarted := false
+	if options.Mode != "immediate" {
+		var err error
+		if turnStarted, err = s.startTurn(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	idleCh := make(chan struct{}, 1)
 	errCh := make(chan error, 1)
 	var lastAssistantMessage *SessionEvent
+	var usage TurnUsage
+	var provenance Provenance
+	var result *TurnResultData
+	var partial partialContent
 	var mu sync.Mutex
 
 	unsubscribe := s.On(func(event SessionEvent) {
+		mu.Lock()
+		provenance.observe(event)
+		partial.observe(event)
+		mu.Unlock()
 		switch d := event.Data.(type) {
 		case *AssistantMessageData:
 			mu.Lock()
 			eventCopy := event
 			lastAssistantMessage = &eventCopy
 			mu.Unlock()
+		case *AssistantUsageData:
+			mu.Lock()
+			usage.add(d)
+			mu.Unlock()
+		case *TurnResultData:
+			mu.Lock()
+			result = d
+			mu.Unlock()
 		case *SessionIdleData:
 			select {
 			case idleCh <- struct{}{}:
@@ -494,26 +783,88 @@ func (s *Session) SendAndWait(ctx context.Context, options MessageOptions) (*Ses
 			}
 		case *SessionErrorData:
 			select {
-			case errCh <- fmt.Errorf("session error: %s", d.Message):
+			case errCh <- sessionError(d):
+			default:
+			}
+		case *TurnLimitExceededData:
+			select {
+			case errCh <- turnLimitError(d):
+			default:
+			}
+		case *AbortData:
+			select {
+			case errCh <- &TurnAbortedError{Reason: d.Reason}:
 			default:
 			}
 		}
 	})
 	defer unsubscribe()
 
-	_, err := s.Send(ctx, options)
+	// The turn ends with the runtime process; without this the wait would
+	// last until the context expires.
+	var processDone <-chan struct{}
+	if client := s.conn.Load().client; client != nil {
+		processDone = client.ProcessDone()
+	}
+
+	_, err := s.send(ctx, options, false, turnStarted)
 	if err != nil {
 		return nil, err
 	}
 
+	// interrupted returns what the assistant had said when the turn was
+	// interrupted.
+	interrupted := func() *Response {
+		mu.Lock()
+		defer mu.Unlock()
+		return &Response{
+			Message:    lastAssistantMessage,
+			Content:    partial.content(),
+			Usage:      usage,
+			Provenance: provenance,
+			Partial:    true,
+		}
+	}
+	// failed returns the result of a turn that ended with err. An aborted
+	// turn still returns the last message, as it did before aborts were
+	// reported as errors.
+	failed := func(err error) (*Response, error) {
+		if errors.Is(err, ErrTurnAborted) {
+			return interrupted(), err
+		}
+		return nil, err
+	}
+
 	select {
 	case <-idleCh:
+		// An error delivered before the idle event ends the turn.
+		select {
+		case err := <-errCh:
+			return failed(err)
+		default:
+		}
 		mu.Lock()
-		result := lastAssistantMessage
-		mu.Unlock()
-		return result, nil
+		defer mu.Unlock()
+		response := &Response{Message: lastAssistantMessage, Usage: usage, Provenance: provenance, Result: result}
+		if lastAssistantMessage != nil {
+			if d, ok := lastAssistantMessage.Data.(*AssistantMessageData); ok {
+				response.Content = d.Content
+			}
+		}
+		if result != nil {
+			response.Content = result.Content
+		}
+		return response, nil
 	case err := <-errCh:
-		return nil, err
+		return failed(err)
+	case <-deadlineCh:
+		// The SDK aborts the turn at its deadline; the caller gets what
+		// the assistant has said so far without waiting for the abort.
+		return interrupted(), ErrTurnDeadlineExceeded
+	case <-s.done:
+		return nil, ErrSessionClosed
+	case <-processDone:
+		return nil, fmt.Errorf("waiting for session.idle: %w", ErrConnectionLost)
 	case <-ctx.Done():
 		return nil, fmt.Errorf("waiting for session.idle: %w", ctx.Err())
 	}
@@ -537,6 +888,14 @@ func (s *Session) SendPromptAndWait(ctx context.Context, prompt string) (*Sessio
 // The returned function can be called to unsubscribe the handler. It is safe
 // to call the unsubscribe function multiple times.
 //
+// On and the unsubscribe function may be called from any goroutine, including
+// from inside a handler. A handler subscribed while an event is being
+// delivered first receives the next event; a handler unsubscribed while an
+// event is being delivered is not called for it unless it is already running.
+// Once unsubscribe or [Session.Disconnect] returns, the handler receives no
+// further events, apart from an invocation already in progress. On is a no-op
+// after Disconnect.
+//
 // Example:
 //
 //	unsubscribe := session.On(func(event copilot.SessionEvent) {
@@ -554,9 +913,12 @@ func (s *Session) On(handler SessionEventHandler) func() {
 	s.handlerMutex.Lock()
 	defer s.handlerMutex.Unlock()
 
+	if s.isClosed() {
+		return func() {}
+	}
 	id := s.nextHandlerID
 	s.nextHandlerID++
-	s.handlers = append(s.handlers, sessionHandler{id: id, fn: handler})
+	s.handlers = append(s.handlers, &sessionHandler{id: id, fn: handler})
 
 	// Return unsubscribe function
 	return func() {
@@ -565,6 +927,7 @@ func (s *Session) On(handler SessionEventHandler) func() {
 
 		for i, h := range s.handlers {
 			if h.id == id {
+				h.removed.Store(true)
 				s.handlers = append(s.handlers[:i], s.handlers[i+1:]...)
 				break
 			}
@@ -583,14 +946,72 @@ func (s *Session) registerTools(tools []Tool) {
 	defer s.toolHandlersM.Unlock()
 
 	s.toolHandlers = make(map[string]ToolHandler)
+	s.toolSchemas = make(map[string]*jsonschema.Resolved)
 	for _, tool := range tools {
-		if tool.Name == "" || tool.Handler == nil {
+		if tool.Name == "" {
 			continue
 		}
-		s.toolHandlers[tool.Name] = tool.Handler
+		if resolved := resolveToolSchema(tool.Parameters); resolved != nil {
+			s.toolSchemas[tool.Name] = resolved
+		}
+		handler := tool.Handler
+		if handler == nil && tool.Endpoint != "" {
+			handler = endpointToolHandler(tool, s.tracePropagator())
+		}
+		if handler == nil {
+			continue
+		}
+		if tool.RetryPolicy != nil {
+			handler = retryingToolHandler(handler, *tool.RetryPolicy)
+		}
+		s.toolHandlers[tool.Name] = handler
 	}
 }
 
+// resolveToolSchema converts a tool's parameter schema into a form that can
+// validate arguments. It returns nil when the tool has no schema or the schema
+// cannot be resolved, in which case arguments are not validated by the SDK.
+func resolveToolSchema(parameters map[string]any) *jsonschema.Resolved {
+	if len(parameters) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return nil
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil
+	}
+	return resolved
+}
+
+// validateToolArguments checks args against the registered schema for toolName.
+// Tools without a known schema (such as built-in tools) are not validated.
+func (s *Session) validateToolArguments(toolName string, args any) error {
+	s.toolHandlersM.RLock()
+	resolved := s.toolSchemas[toolName]
+	s.toolHandlersM.RUnlock()
+	if resolved == nil {
+		return nil
+	}
+	// Round-trip through JSON so typed structs validate the same way as the
+	// arguments the runtime will actually pass to the tool.
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return err
+	}
+	return resolved.Validate(instance)
+}
+
 // getToolHandler retrieves a registered tool handler by name.
 // Returns the handler and true if found, or nil and false if not registered.
 func (s *Session) getToolHandler(name string) (ToolHandler, bool) {
@@ -641,6 +1062,7 @@ func (s *Session) getUserInputHandler() UserInputHandler {
 // handleUserInputRequest handles a user input request from the Copilot CLI.
 // This is an internal method called by the SDK when the CLI requests user input.
 func (s *Session) handleUserInputRequest(request UserInputRequest) (UserInputResponse, error) {
+	defer s.enterHandler()()
 	handler := s.getUserInputHandler()
 
 	if handler == nil {
@@ -667,6 +1089,7 @@ func (s *Session) getExitPlanModeHandler() ExitPlanModeRequestHandler {
 }
 
 func (s *Session) handleExitPlanModeRequest(request ExitPlanModeRequest) (ExitPlanModeResult, error) {
+	defer s.enterHandler()()
 	handler := s.getExitPlanModeHandler()
 	if handler == nil {
 		return ExitPlanModeResult{Approved: true}, nil
@@ -688,6 +1111,7 @@ func (s *Session) getAutoModeSwitchHandler() AutoModeSwitchRequestHandler {
 }
 
 func (s *Session) handleAutoModeSwitchRequest(request AutoModeSwitchRequest) (AutoModeSwitchResponse, error) {
+	defer s.enterHandler()()
 	handler := s.getAutoModeSwitchHandler()
 	if handler == nil {
 		return AutoModeSwitchResponseNo, nil
@@ -718,10 +1142,14 @@ func (s *Session) getHooks() *SessionHooks {
 // handleHooksInvoke handles a hook invocation from the Copilot CLI.
 // This is an internal method called by the SDK when the CLI invokes a hook.
 func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (any, error) {
+	defer s.enterHandler()()
 	hooks := s.getHooks()
 
 	if hooks == nil {
-		return nil, nil
+		if !s.hasPromptContext() {
+			return nil, nil
+		}
+		hooks = &SessionHooks{}
 	}
 
 	invocation := HookInvocation{
@@ -737,7 +1165,14 @@ func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (
 		if err := json.Unmarshal(rawInput, &input); err != nil {
 			return nil, fmt.Errorf("invalid hook input: %w", err)
 		}
-		return hooks.OnPreToolUse(input, invocation)
+		output, err := hooks.OnPreToolUse(input, invocation)
+		if err != nil || output == nil || output.ModifiedArgs == nil {
+			return output, err
+		}
+		if err := s.validateToolArguments(input.ToolName, output.ModifiedArgs); err != nil {
+			return nil, fmt.Errorf("modified arguments for tool %q do not match its schema: %w", input.ToolName, err)
+		}
+		return output, nil
 
 	case "preMcpToolCall":
 		if hooks.OnPreMCPToolCall == nil {
@@ -750,14 +1185,36 @@ func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (
 		return hooks.OnPreMCPToolCall(input, invocation)
 
 	case "postToolUse":
-		if hooks.OnPostToolUse == nil {
+		if hooks.OnPostToolUse == nil && hooks.OnSuspiciousContent == nil {
 			return nil, nil
 		}
 		var input PostToolUseHookInput
 		if err := json.Unmarshal(rawInput, &input); err != nil {
 			return nil, fmt.Errorf("invalid hook input: %w", err)
 		}
-		return hooks.OnPostToolUse(input, invocation)
+		input.ResourceUsage = s.toolUsage.take(input.ToolName, input.ToolArgs)
+		var output *PostToolUseHookOutput
+		if hooks.OnPostToolUse != nil {
+			var err error
+			output, err = hooks.OnPostToolUse(input, invocation)
+			if err != nil {
+				return output, err
+			}
+			if output != nil && output.ModifiedResult != nil && hooks.OnToolResultModified != nil {
+				hooks.OnToolResultModified(ToolResultModification{
+					SessionID:      input.SessionID,
+					Timestamp:      input.Timestamp,
+					ToolName:       input.ToolName,
+					ToolArgs:       input.ToolArgs,
+					OriginalResult: input.ToolResult,
+					ModifiedResult: output.ModifiedResult,
+				})
+			}
+		}
+		if hooks.OnSuspiciousContent != nil {
+			output = s.screenToolResult(hooks, input, output)
+		}
+		return output, nil
 
 	case "postToolUseFailure":
 		if hooks.OnPostToolUseFailure == nil {
@@ -770,14 +1227,21 @@ func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (
 		return hooks.OnPostToolUseFailure(input, invocation)
 
 	case "userPromptSubmitted":
-		if hooks.OnUserPromptSubmitted == nil {
+		if hooks.OnUserPromptSubmitted == nil && !s.hasPromptContext() {
 			return nil, nil
 		}
 		var input UserPromptSubmittedHookInput
 		if err := json.Unmarshal(rawInput, &input); err != nil {
 			return nil, fmt.Errorf("invalid hook input: %w", err)
 		}
-		return hooks.OnUserPromptSubmitted(input, invocation)
+		var output *UserPromptSubmittedHookOutput
+		if hooks.OnUserPromptSubmitted != nil {
+			var err error
+			if output, err = hooks.OnUserPromptSubmitted(input, invocation); err != nil {
+				return output, err
+			}
+		}
+		return s.addPromptContext(output, input.Prompt), nil
 
 	case "sessionStart":
 		if hooks.OnSessionStart == nil {
@@ -808,6 +1272,16 @@ func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (
 			return nil, fmt.Errorf("invalid hook input: %w", err)
 		}
 		return hooks.OnErrorOccurred(input, invocation)
+
+	case "preCompact":
+		if hooks.OnPreCompact == nil {
+			return nil, nil
+		}
+		var input PreCompactHookInput
+		if err := json.Unmarshal(rawInput, &input); err != nil {
+			return nil, fmt.Errorf("invalid hook input: %w", err)
+		}
+		return hooks.OnPreCompact(input, invocation)
 	default:
 		return nil, nil
 	}
@@ -887,11 +1361,21 @@ func (s *Session) getCommandHandler(name string) (CommandHandler, bool) {
 // executeCommandAndRespond dispatches a command.execute event to the registered handler
 // and sends the result (or error) back via the RPC layer.
 func (s *Session) executeCommandAndRespond(requestID, commandName, command, args string) {
+	defer s.enterHandler()()
 	ctx := context.Background()
+	defer func() {
+		if r := recover(); r != nil {
+			errMsg := s.recovered("command", commandName, r).Error()
+			s.conn.Load().rpc.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
+				RequestID: requestID,
+				Error:     &errMsg,
+			})
+		}
+	}()
 	handler, ok := s.getCommandHandler(commandName)
 	if !ok {
 		errMsg := fmt.Sprintf("Unknown command: %s", commandName)
-		s.RPC.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
+		s.conn.Load().rpc.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
 			RequestID: requestID,
 			Error:     &errMsg,
 		})
@@ -907,14 +1391,14 @@ func (s *Session) executeCommandAndRespond(requestID, commandName, command, args
 
 	if err := handler(cmdCtx); err != nil {
 		errMsg := err.Error()
-		s.RPC.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
+		s.conn.Load().rpc.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
 			RequestID: requestID,
 			Error:     &errMsg,
 		})
 		return
 	}
 
-	s.RPC.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
+	s.conn.Load().rpc.Commands.HandlePendingCommand(ctx, &rpc.CommandsHandlePendingCommandRequest{
 		RequestID: requestID,
 	})
 }
@@ -946,6 +1430,7 @@ func (s *Session) getMCPAuthHandler() MCPAuthHandler {
 }
 
 func (s *Session) handleMCPAuthRequest(request MCPAuthRequest) {
+	defer s.enterHandler()()
 	handler := s.getMCPAuthHandler()
 	if handler == nil {
 		return
@@ -955,22 +1440,17 @@ func (s *Session) handleMCPAuthRequest(request MCPAuthRequest) {
 	cancel := &rpc.MCPOauthPendingRequestResponseCancelled{}
 	result, err := handler(request, MCPAuthInvocation{SessionID: s.SessionID})
 	if err != nil {
-		log.Printf(
-			"MCP OAuth handler failed. SessionId=%s, RequestId=%s, Error=%v",
-			s.SessionID,
-			request.RequestID,
-			err,
-		)
+		s.logger().Error("MCP OAuth handler failed", "requestId", request.RequestID, "error", err)
 	}
 	if err != nil || result == nil || result.Kind == MCPAuthResultKindCancelled || result.Token == nil {
-		s.RPC.MCP.Oauth().HandlePendingRequest(ctx, &rpc.MCPOauthHandlePendingRequest{
+		s.conn.Load().rpc.MCP.Oauth().HandlePendingRequest(ctx, &rpc.MCPOauthHandlePendingRequest{
 			RequestID: request.RequestID,
 			Result:    cancel,
 		})
 		return
 	}
 
-	s.RPC.MCP.Oauth().HandlePendingRequest(ctx, &rpc.MCPOauthHandlePendingRequest{
+	s.conn.Load().rpc.MCP.Oauth().HandlePendingRequest(ctx, &rpc.MCPOauthHandlePendingRequest{
 		RequestID: request.RequestID,
 		Result: &rpc.MCPOauthPendingRequestResponseToken{
 			AccessToken: result.Token.AccessToken,
@@ -983,6 +1463,7 @@ func (s *Session) handleMCPAuthRequest(request MCPAuthRequest) {
 // handleElicitationRequest dispatches an elicitation.requested event to the registered handler
 // and sends the result back via the RPC layer. Auto-cancels on error.
 func (s *Session) handleElicitationRequest(elicitCtx ElicitationContext, requestID string) {
+	defer s.enterHandler()()
 	handler := s.getElicitationHandler()
 	if handler == nil {
 		return
@@ -993,7 +1474,7 @@ func (s *Session) handleElicitationRequest(elicitCtx ElicitationContext, request
 	result, err := handler(elicitCtx)
 	if err != nil {
 		// Handler failed — attempt to cancel so the request doesn't hang.
-		s.RPC.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
+		s.conn.Load().rpc.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
 			RequestID: requestID,
 			Result: rpc.UIElicitationResponse{
 				Action: rpc.UIElicitationResponseActionCancel,
@@ -1008,7 +1489,7 @@ func (s *Session) handleElicitationRequest(elicitCtx ElicitationContext, request
 		for k, v := range result.Content {
 			contentValue, err := toRPCContent(v)
 			if err != nil {
-				s.RPC.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
+				s.conn.Load().rpc.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
 					RequestID: requestID,
 					Result: rpc.UIElicitationResponse{
 						Action: rpc.UIElicitationResponseActionCancel,
@@ -1020,7 +1501,7 @@ func (s *Session) handleElicitationRequest(elicitCtx ElicitationContext, request
 		}
 	}
 
-	s.RPC.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
+	s.conn.Load().rpc.UI.HandlePendingElicitation(ctx, &rpc.UIHandlePendingElicitationRequest{
 		RequestID: requestID,
 		Result: rpc.UIElicitationResponse{
 			Action:  result.Action,
@@ -1129,7 +1610,7 @@ func (ui *SessionUI) Elicitation(ctx context.Context, message string, requestedS
 	if err != nil {
 		return nil, err
 	}
-	rpcResult, err := ui.session.RPC.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
+	rpcResult, err := ui.session.conn.Load().rpc.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
 		Message:         message,
 		RequestedSchema: rpcSchema,
 	})
@@ -1199,7 +1680,7 @@ func (ui *SessionUI) Confirm(ctx context.Context, message string) (bool, error)
 	if err := ui.session.assertElicitation(); err != nil {
 		return false, err
 	}
-	rpcResult, err := ui.session.RPC.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
+	rpcResult, err := ui.session.conn.Load().rpc.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
 		Message: message,
 		RequestedSchema: rpc.UIElicitationSchema{
 			Type: rpc.UIElicitationSchemaTypeObject,
@@ -1228,7 +1709,7 @@ func (ui *SessionUI) Select(ctx context.Context, message string, options []strin
 	if err := ui.session.assertElicitation(); err != nil {
 		return "", false, err
 	}
-	rpcResult, err := ui.session.RPC.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
+	rpcResult, err := ui.session.conn.Load().rpc.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
 		Message: message,
 		RequestedSchema: rpc.UIElicitationSchema{
 			Type: rpc.UIElicitationSchemaTypeObject,
@@ -1281,7 +1762,7 @@ func (ui *SessionUI) Input(ctx context.Context, message string, opts *UIInputOpt
 			prop.Default = String(opts.Default)
 		}
 	}
-	rpcResult, err := ui.session.RPC.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
+	rpcResult, err := ui.session.conn.Load().rpc.UI.Elicitation(ctx, &rpc.UIElicitationRequest{
 		Message: message,
 		RequestedSchema: rpc.UIElicitationSchema{
 			Type: rpc.UIElicitationSchemaTypeObject,
@@ -1355,16 +1836,18 @@ func fromRPCElicitationRequestedSchema(schema *rpc.ElicitationRequestedSchema) *
 // serial, FIFO dispatch without blocking the read loop.
 func (s *Session) dispatchEvent(event SessionEvent) {
 	s.updateOpenCanvasesFromEvent(event)
-	go s.handleBroadcastEvent(event)
-
-	// Send to the event channel in a closure with a recover guard.
-	// Disconnect closes eventCh, and in Go sending on a closed channel
-	// panics — there is no non-panicking send primitive. We only want
-	// to suppress that specific panic; other panics are not expected here.
-	func() {
-		defer func() { recover() }()
-		s.eventCh <- event
+	s.idle.addCallbacks(1)
+	go func() {
+		defer s.idle.addCallbacks(-1)
+		s.handleBroadcastEvent(event)
 	}()
+
+	// Events that arrive after Disconnect are dropped.
+	s.idle.queued()
+	select {
+	case s.eventCh <- event:
+	case <-s.done:
+	}
 }
 
 // processEvents is the single consumer goroutine for the event channel.
@@ -1372,24 +1855,77 @@ func (s *Session) dispatchEvent(event SessionEvent) {
 // handlers are recovered so that one misbehaving handler does not prevent
 // others from receiving the event.
 func (s *Session) processEvents() {
-	for event := range s.eventCh {
-		s.handlerMutex.RLock()
-		handlers := make([]SessionEventHandler, 0, len(s.handlers))
-		for _, h := range s.handlers {
-			handlers = append(handlers, h.fn)
-		}
-		s.handlerMutex.RUnlock()
-
-		for _, handler := range handlers {
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						fmt.Printf("Error in session event handler: %v\n", r)
-					}
-				}()
-				handler(event)
-			}()
+	for {
+		var event SessionEvent
+		select {
+		case event = <-s.eventCh:
+		case <-s.done:
+			return
 		}
+		if s.isClosed() {
+			return // both were ready; prefer shutdown
+		}
+		s.trackTurn(event)
+		s.observeCompaction(event)
+		s.usage.observe(event)
+		events := []SessionEvent{event}
+		if s.segmenter != nil {
+			events = s.segmenter.segment(event)
+		}
+		for _, e := range events {
+			if s.output != nil {
+				e = s.output.formatMessage(e)
+			}
+			s.deliverEvent(e)
+		}
+		if delta := s.toolOutput.observe(event); delta != nil {
+			s.deliverEvent(*delta)
+		}
+		for _, edited := range s.fileEdits.observe(event) {
+			s.deliverEvent(edited)
+		}
+		if s.breaker != nil {
+			s.breaker.observe(event)
+		}
+		if exceeded := s.limits.observe(event); exceeded != nil {
+			s.exceedTurnLimit(exceeded, s.deliverEvent)
+		}
+		s.deadline.observe(event)
+		s.idle.observe(event)
+	}
+}
+
+// isClosed reports whether Disconnect has completed.
+func (s *Session) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliverEvent invokes the user event handlers for event. Called only from
+// processEvents.
+func (s *Session) deliverEvent(event SessionEvent) {
+	defer s.enterHandler()()
+	s.handlerMutex.RLock()
+	handlers := slices.Clone(s.handlers)
+	s.handlerMutex.RUnlock()
+
+	for _, handler := range handlers {
+		if handler.removed.Load() {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger().Error("panic in session event handler", "event", event.Type(), "error", r)
+					s.recovered("event handler", string(event.Type()), r)
+				}
+			}()
+			handler.fn(event)
+		}()
 	}
 }
 
@@ -1401,12 +1937,22 @@ func (s *Session) processEvents() {
 // event consumer loop) so that a stalled handler does not block event delivery or
 // cause RPC deadlocks.
 func (s *Session) handleBroadcastEvent(event SessionEvent) {
+	// Runs in its own goroutine, where an unrecovered panic in a handler
+	// would crash the process.
+	defer func() {
+		if r := recover(); r != nil {
+			s.recovered("event handler", string(event.Type()), r)
+		}
+	}()
 	switch d := event.Data.(type) {
 	case *ExternalToolRequestedData:
 		handler, ok := s.getToolHandler(d.ToolName)
 		if !ok {
 			return
 		}
+		if s.breaker != nil && s.breaker.shortCircuitTool(d) {
+			return
+		}
 		var tp, ts string
 		if d.Traceparent != nil {
 			tp = *d.Traceparent
@@ -1420,6 +1966,25 @@ func (s *Session) handleBroadcastEvent(event SessionEvent) {
 		if d.ResolvedByHook != nil && *d.ResolvedByHook {
 			return // Already resolved by a permissionRequest hook; no client action needed.
 		}
+		if s.egress != nil && s.egress.enforcePermission(d) {
+			return
+		}
+		if s.scratch != nil && s.scratch.enforcePermission(d) {
+			return
+		}
+		if s.paths != nil && s.paths.enforcePermission(d) {
+			return
+		}
+		if s.breaker != nil && s.breaker.enforcePermission(d) {
+			return
+		}
+		if decision, ok := s.permissions.decide(d.PermissionRequest); ok {
+			s.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+				RequestID: d.RequestID,
+				Result:    decision,
+			})
+			return
+		}
 		handler := s.getPermissionHandler()
 		if handler == nil {
 			return
@@ -1432,11 +1997,7 @@ func (s *Session) handleBroadcastEvent(event SessionEvent) {
 			return
 		}
 		if handler == nil {
-			log.Printf(
-				"Received MCP OAuth request without a registered MCP auth handler. SessionId=%s, RequestId=%s",
-				s.SessionID,
-				d.RequestID,
-			)
+			s.logger().Warn("received MCP OAuth request without a registered MCP auth handler", "requestId", d.RequestID)
 			return
 		}
 		var staticClientConfig *MCPAuthStaticClientConfig
@@ -1499,12 +2060,25 @@ func (s *Session) handleBroadcastEvent(event SessionEvent) {
 }
 
 // executeToolAndRespond executes a tool handler and sends the result back via RPC.
+// The handler runs under the values of the context of the send that started
+// the turn, with the CLI's execute_tool span as the parent span; the send's
+// deadline and cancellation end with the send and do not reach it. The result is sent even if that context has ended,
+// unless [Session.CancelToolCall] already answered the call.
 func (s *Session) executeToolAndRespond(requestID, toolName, toolCallID string, arguments any, handler ToolHandler, traceparent, tracestate string) {
-	ctx := contextWithTraceParent(context.Background(), traceparent, tracestate)
+	defer s.enterHandler()()
+	ctx := contextWithTraceParent(s.tracePropagator(), s.idle.turnContext(), traceparent, tracestate)
+	respondCtx := context.WithoutCancel(ctx)
+	ctx, cancel := context.WithCancelCause(ctx)
+	call := s.runningTools.start(toolCallID, requestID, cancel)
+	defer func() {
+		s.runningTools.finish(toolCallID, call)
+		cancel(nil)
+	}()
 	defer func() {
 		if r := recover(); r != nil {
+			s.recovered("tool", toolName, r)
 			errMsg := fmt.Sprintf("tool panic: %v", r)
-			s.RPC.Tools.HandlePendingToolCall(ctx, &rpc.HandlePendingToolCallRequest{
+			s.respondToolCall(respondCtx, call, &rpc.HandlePendingToolCallRequest{
 				RequestID: requestID,
 				Error:     &errMsg,
 			})
@@ -1517,6 +2091,12 @@ func (s *Session) executeToolAndRespond(requestID, toolName, toolCallID string,
 		ToolName:     toolName,
 		Arguments:    arguments,
 		TraceContext: ctx,
+		scope: &toolCallScope{
+			progress:         s.toolProgressReporter(toolCallID, toolName),
+			folders:          s.folders,
+			workingDirectory: s.workingDirectory,
+			scratch:          s.scratch,
+		},
 	}
 
 	// The built-in tool-search tool receives a snapshot of the session's
@@ -1525,46 +2105,33 @@ func (s *Session) executeToolAndRespond(requestID, toolName, toolCallID string,
 	// round-trip on every tool call; a failed fetch leaves the snapshot nil
 	// rather than failing the tool.
 	if toolName == toolSearchToolName {
-		if metadata, mErr := s.RPC.Tools.GetCurrentMetadata(ctx); mErr == nil && metadata != nil {
+		if metadata, mErr := s.conn.Load().rpc.Tools.GetCurrentMetadata(ctx); mErr == nil && metadata != nil {
 			invocation.AvailableTools = metadata.Tools
 		}
 	}
 
+	start := time.Now()
 	result, err := handler(invocation)
 	if err != nil {
 		errMsg := err.Error()
-		s.RPC.Tools.HandlePendingToolCall(ctx, &rpc.HandlePendingToolCallRequest{
+		s.respondToolCall(respondCtx, call, &rpc.HandlePendingToolCallRequest{
 			RequestID: requestID,
 			Error:     &errMsg,
 		})
 		return
 	}
+	s.measureToolCall(toolName, arguments, start, &result)
 
-	textResultForLLM := result.TextResultForLLM
-	if textResultForLLM == "" {
-		textResultForLLM = fmt.Sprintf("%v", result)
-	}
-
-	// Default ResultType to "success" when unset, or "failure" when there's an error.
-	effectiveResultType := result.ResultType
-	if effectiveResultType == "" {
-		if result.Error != "" {
-			effectiveResultType = "failure"
-		} else {
-			effectiveResultType = "success"
-		}
-	}
-
-	rpcResult := &rpc.ExternalToolTextResultForLlm{
-		TextResultForLlm: textResultForLLM,
-		ToolTelemetry:    result.ToolTelemetry,
-		ResultType:       &effectiveResultType,
-		ToolReferences:   result.ToolReferences,
-	}
-	if result.Error != "" {
-		rpcResult.Error = &result.Error
+	rpcResult, err := toolResultToRPC(result)
+	if err != nil {
+		errMsg := err.Error()
+		s.respondToolCall(respondCtx, call, &rpc.HandlePendingToolCallRequest{
+			RequestID: requestID,
+			Error:     &errMsg,
+		})
+		return
 	}
-	s.RPC.Tools.HandlePendingToolCall(ctx, &rpc.HandlePendingToolCallRequest{
+	s.respondToolCall(respondCtx, call, &rpc.HandlePendingToolCallRequest{
 		RequestID: requestID,
 		Result:    rpcResult,
 	})
@@ -1572,22 +2139,28 @@ func (s *Session) executeToolAndRespond(requestID, toolName, toolCallID string,
 
 // executePermissionAndRespond executes a permission handler and sends the result back via RPC.
 func (s *Session) executePermissionAndRespond(requestID string, permissionRequest PermissionRequest, handler PermissionHandlerFunc) {
+	defer s.enterHandler()()
 	defer func() {
 		if r := recover(); r != nil {
-			s.RPC.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+			s.recovered("permission handler", "", r)
+			s.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
 				RequestID: requestID,
 				Result:    &rpc.PermissionDecisionUserNotAvailable{},
 			})
 		}
 	}()
 
-	invocation := PermissionInvocation{
-		SessionID: s.SessionID,
+	invocation := newPermissionInvocation(s.SessionID, permissionRequest)
+	if s.folders != nil {
+		invocation.WorkspaceFolder = s.folders.requestFolder(permissionRequest)
 	}
 
-	decision, err := handler(permissionRequest, invocation)
+	decision, timedOut, err := s.callPermissionHandler(handler, permissionRequest, invocation)
+	if timedOut {
+		s.reportPermissionTimeout(requestID, permissionRequest, decision)
+	}
 	if err != nil {
-		s.RPC.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		s.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
 			RequestID: requestID,
 			Result:    &rpc.PermissionDecisionUserNotAvailable{},
 		})
@@ -1596,7 +2169,7 @@ func (s *Session) executePermissionAndRespond(requestID string, permissionReques
 	if decision == nil {
 		// Handler returned (nil, nil); treat as user-not-available rather
 		// than sending null on the wire.
-		s.RPC.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+		s.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
 			RequestID: requestID,
 			Result:    &rpc.PermissionDecisionUserNotAvailable{},
 		})
@@ -1608,8 +2181,23 @@ func (s *Session) executePermissionAndRespond(requestID string, permissionReques
 	if _, ok := decision.(rpc.PermissionDecisionNoResult); ok {
 		return
 	}
+	if remember, ok := decision.(rpc.PermissionDecisionRemember); ok {
+		decision = &remember
+	}
+	if remember, ok := decision.(*rpc.PermissionDecisionRemember); ok {
+		if _, err := s.permissions.remember(context.Background(), permissionRequest, remember); err != nil {
+			s.logger().Warn("failed to remember permission decision", "error", err)
+		}
+		decision = remember.Decision
+		if decision == nil {
+			decision = &rpc.PermissionDecisionUserNotAvailable{}
+		}
+	}
+	if s.paths != nil {
+		decision = s.paths.narrow(decision)
+	}
 
-	s.RPC.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
+	s.conn.Load().rpc.Permissions.HandlePendingPermissionRequest(context.Background(), &rpc.PermissionDecisionRequest{
 		RequestID: requestID,
 		Result:    decision,
 	})
@@ -1637,7 +2225,7 @@ func (s *Session) executePermissionAndRespond(requestID string, permissionReques
 //	}
 func (s *Session) GetEvents(ctx context.Context) ([]SessionEvent, error) {
 
-	result, err := s.client.Request(ctx, "session.getMessages", sessionGetMessagesRequest{SessionID: s.SessionID})
+	result, err := s.conn.Load().client.Request(ctx, "session.getMessages", sessionGetMessagesRequest{SessionID: s.SessionID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events: %w", err)
 	}
@@ -1661,9 +2249,15 @@ func (s *Session) GetEvents(ctx context.Context) ([]SessionEvent, error) {
 // [Client.ResumeSession] with the session ID. To permanently remove all
 // session data including files on disk, use [Client.DeleteSession] instead.
 //
-// After calling this method, the session object can no longer be used.
+// After calling this method, the session object can no longer be used:
+// [Session.Send] and [Session.SendAndWait] return [ErrSessionClosed], and a
+// SendAndWait already waiting returns it immediately. No event handler is
+// called after Disconnect returns, apart from an invocation already in
+// progress. Disconnect is safe to call concurrently, from event handlers,
+// and more than once; calls after the first successful one return nil.
 //
-// Returns an error if the connection fails.
+// Returns an error if the connection fails, in which case the session stays
+// open and Disconnect can be retried.
 //
 // Example:
 //
@@ -1672,15 +2266,31 @@ func (s *Session) GetEvents(ctx context.Context) ([]SessionEvent, error) {
 //	    log.Printf("Failed to disconnect session: %v", err)
 //	}
 func (s *Session) Disconnect() error {
-	_, err := s.client.Request(context.Background(), "session.destroy", sessionDestroyRequest{SessionID: s.SessionID})
+	s.disconnectMu.Lock()
+	defer s.disconnectMu.Unlock()
+	if s.isClosed() {
+		return nil
+	}
+
+	_, err := s.conn.Load().client.Request(context.Background(), "session.destroy", sessionDestroyRequest{SessionID: s.SessionID})
 	if err != nil {
 		return fmt.Errorf("failed to disconnect session: %w", err)
 	}
 
-	s.closeOnce.Do(func() { close(s.eventCh) })
+	s.egress.close()
+	s.bedrock.close()
+	s.vertex.close()
+	s.seed.close()
+	s.scratch.remove()
+	s.logger().Debug("disconnected session")
 
-	// Clear handlers
+	// Clear handlers. Closing done under handlerMutex keeps On from
+	// registering a handler after the list is cleared.
 	s.handlerMutex.Lock()
+	close(s.done)
+	for _, h := range s.handlers {
+		h.removed.Store(true)
+	}
 	s.handlers = nil
 	s.handlerMutex.Unlock()
 
@@ -1725,7 +2335,7 @@ func (s *Session) Disconnect() error {
 //	    log.Printf("Failed to abort: %v", err)
 //	}
 func (s *Session) Abort(ctx context.Context) error {
-	_, err := s.client.Request(ctx, "session.abort", sessionAbortRequest{SessionID: s.SessionID})
+	_, err := s.conn.Load().client.Request(ctx, "session.abort", sessionAbortRequest{SessionID: s.SessionID})
 	if err != nil {
 		return fmt.Errorf("failed to abort session: %w", err)
 	}
@@ -1733,6 +2343,62 @@ func (s *Session) Abort(ctx context.Context) error {
 	return nil
 }
 
+// Interrupt stops the running turn, like pressing Esc in the CLI, and
+// waits until it has stopped. The session stays usable and keeps its
+// history, including the interrupted turn up to where it stopped, so the
+// next prompt continues the conversation. Use it for "stop" buttons.
+//
+// Unlike [Session.Abort], which only asks the runtime to abort, Interrupt
+// also cancels the tool handlers this client is running for the turn,
+// with cause [ErrTurnAborted], and returns once the turn has ended. A
+// [Session.SendAndWait] waiting for the turn returns a [TurnAbortedError].
+// Interrupt returns nil at once if no turn is running, and ctx's error if
+// ctx ends before the turn does.
+//
+// Example:
+//
+//	stopButton.OnClick(func() {
+//	    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	    defer cancel()
+//	    if err := session.Interrupt(ctx); err != nil {
+//	        log.Printf("failed to stop: %v", err)
+//	    }
+//	})
+func (s *Session) Interrupt(ctx context.Context) error {
+	ended := s.idle.waitTurn()
+	if ended == nil {
+		return nil
+	}
+	if _, err := s.conn.Load().client.Request(ctx, "session.abort", sessionAbortRequest{SessionID: s.SessionID}); err != nil {
+		return fmt.Errorf("failed to interrupt session: %w", err)
+	}
+
+	// Stop the turn's tool handlers. The runtime discards their calls when
+	// it aborts; the results sent here only matter if it is still waiting.
+	resultType := "failure"
+	errMsg := ErrTurnAborted.Error()
+	for _, call := range s.runningTools.claimAll() {
+		call.cancel(ErrTurnAborted)
+		s.conn.Load().rpc.Tools.HandlePendingToolCall(ctx, &rpc.HandlePendingToolCallRequest{
+			RequestID: call.requestID,
+			Result: &rpc.ExternalToolTextResultForLlm{
+				TextResultForLlm: "The turn was interrupted by the user before the tool call completed.",
+				ResultType:       &resultType,
+				Error:            &errMsg,
+			},
+		})
+	}
+
+	select {
+	case <-ended:
+		return nil
+	case <-s.done:
+		return ErrSessionClosed
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for the interrupted turn to end: %w", ctx.Err())
+	}
+}
+
 // SetModelOptions configures optional parameters for SetModel.
 type SetModelOptions struct {
 	// ReasoningEffort sets the reasoning effort level for the new model (e.g., "low", "medium", "high", "xhigh").
@@ -1767,7 +2433,7 @@ func (s *Session) SetModel(ctx context.Context, model string, opts *SetModelOpti
 		params.ContextTier = opts.ContextTier
 		params.ModelCapabilities = opts.ModelCapabilities
 	}
-	_, err := s.RPC.Model.SwitchTo(ctx, params)
+	_, err := s.conn.Load().rpc.Model.SwitchTo(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to set model: %w", err)
 	}
@@ -1813,7 +2479,7 @@ func (s *Session) Log(ctx context.Context, message string, opts *LogOptions) err
 		}
 	}
 
-	_, err := s.RPC.Log(ctx, params)
+	_, err := s.conn.Load().rpc.Log(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to log message: %w", err)
 	}
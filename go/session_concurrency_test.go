@@ -0,0 +1,175 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// startConcurrencyTestSession returns a session attached to a test runtime
+// that accepts session.send and counts session.destroy calls.
+func startConcurrencyTestSession(t *testing.T) (*Session, *atomic.Int32) {
+	t.Helper()
+	var destroys atomic.Int32
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+		"session.destroy": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			destroys.Add(1)
+			return []byte(`{}`), nil
+		},
+	})
+	return newSession("s1", client.client, ""), &destroys
+}
+
+func TestSession_Concurrency(t *testing.T) {
+	t.Run("subscribes and unsubscribes concurrently with dispatch", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+
+		var delivered atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					unsubscribe := session.On(func(SessionEvent) { delivered.Add(1) })
+					if j%2 == 0 {
+						unsubscribe()
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					session.dispatchEvent(newTestEvent())
+				}
+			}()
+		}
+		wg.Wait()
+
+		// A handler subscribed after all dispatches sees only later events.
+		done := make(chan struct{})
+		session.On(func(event SessionEvent) {
+			if _, ok := event.Data.(*AssistantMessageData); ok {
+				close(done)
+			}
+		})
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{Content: "final"}})
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the final event")
+		}
+		if delivered.Load() == 0 {
+			t.Error("expected some events to be delivered")
+		}
+	})
+
+	t.Run("handlers changed during dispatch take effect for the next event", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+
+		var calls []string
+		var mu sync.Mutex
+		record := func(name string) {
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+		}
+		var unsubscribeB func()
+		session.On(func(SessionEvent) {
+			record("a")
+			if unsubscribeB != nil {
+				unsubscribeB()
+				unsubscribeB = nil
+				session.On(func(SessionEvent) { record("c") })
+			}
+		})
+		unsubscribeB = session.On(func(SessionEvent) { record("b") })
+
+		idle := make(chan struct{}, 2)
+		session.On(func(SessionEvent) { idle <- struct{}{} })
+		for i := 0; i < 2; i++ {
+			session.dispatchEvent(newTestEvent())
+			<-idle
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		want := []string{"a", "a", "c"}
+		if len(calls) != len(want) {
+			t.Fatalf("got calls %v, want %v", calls, want)
+		}
+		for i := range want {
+			if calls[i] != want[i] {
+				t.Fatalf("got calls %v, want %v", calls, want)
+			}
+		}
+	})
+
+	t.Run("Disconnect during SendAndWait returns ErrSessionClosed", func(t *testing.T) {
+		session, _ := startConcurrencyTestSession(t)
+		result := make(chan error, 1)
+		go func() {
+			_, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "hi"})
+			result <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		if err := session.Disconnect(); err != nil {
+			t.Fatalf("Disconnect failed: %v", err)
+		}
+		select {
+		case err := <-result:
+			if !errors.Is(err, ErrSessionClosed) {
+				t.Errorf("expected ErrSessionClosed, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("SendAndWait did not return after Disconnect")
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "again"}); !errors.Is(err, ErrSessionClosed) {
+			t.Errorf("expected ErrSessionClosed from Send, got %v", err)
+		}
+	})
+
+	t.Run("concurrent and repeated Disconnect destroy the session once", func(t *testing.T) {
+		session, destroys := startConcurrencyTestSession(t)
+		var handlerCalls atomic.Int32
+		session.On(func(SessionEvent) { handlerCalls.Add(1) })
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := session.Disconnect(); err != nil {
+					t.Errorf("Disconnect failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := session.Disconnect(); err != nil {
+			t.Errorf("repeated Disconnect failed: %v", err)
+		}
+		if got := destroys.Load(); got != 1 {
+			t.Errorf("expected 1 session.destroy call, got %d", got)
+		}
+
+		// Late events and subscriptions are ignored without blocking or panicking.
+		session.On(func(SessionEvent) { handlerCalls.Add(1) })()
+		for i := 0; i < 200; i++ {
+			session.dispatchEvent(newTestEvent())
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := handlerCalls.Load(); got != 0 {
+			t.Errorf("expected no handler calls after Disconnect, got %d", got)
+		}
+	})
+}
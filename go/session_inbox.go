@@ -0,0 +1,108 @@
+package copilot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type SessionMessageReceivedData = rpc.SessionMessageReceivedData
+
+const SessionEventTypeSessionMessageReceived = rpc.SessionEventTypeSessionMessageReceived
+
+// sessionInbox holds the messages posted to a session until its next turn.
+type sessionInbox struct {
+	mu       sync.Mutex
+	messages []SessionMessageReceivedData
+}
+
+func (b *sessionInbox) post(message SessionMessageReceivedData) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, message)
+}
+
+// take removes and returns the pending messages.
+func (b *sessionInbox) take() []SessionMessageReceivedData {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages := b.messages
+	b.messages = nil
+	return messages
+}
+
+// restore puts back messages taken for a turn that failed to start, ahead
+// of any posted since.
+func (b *sessionInbox) restore(messages []SessionMessageReceivedData) {
+	if len(messages) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(slices.Clip(messages), b.messages...)
+}
+
+// PostMessageTo posts content to the session sessionID, open on the same
+// client, for agent teams that hand work between sessions without routing
+// it through the application. The receiving session delivers a
+// session.message_received event ([SessionMessageReceivedData]) to its
+// handlers right away, and attaches the message to the next message it
+// sends as a text attachment named after this session, so the model reads
+// it alongside that prompt. Messages posted while the receiver is busy wait
+// for its next turn rather than steering the current one.
+//
+// Example:
+//
+//	// The planner hands a task to the coder, whose handler starts a turn.
+//	coder.On(func(event copilot.SessionEvent) {
+//	    if _, ok := event.Data.(*copilot.SessionMessageReceivedData); ok {
+//	        go coder.SendPrompt(ctx, "Work on the task you were sent.")
+//	    }
+//	})
+//	if err := planner.PostMessageTo(ctx, coder.SessionID, "Implement the parser in parse.go."); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Session) PostMessageTo(ctx context.Context, sessionID, content string) error {
+	if s.isClosed() {
+		return ErrSessionClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var target *Session
+	if s.owner != nil {
+		s.owner.sessionsMux.Lock()
+		target = s.owner.sessions[sessionID]
+		s.owner.sessionsMux.Unlock()
+	}
+	if target == nil || target.isClosed() {
+		return fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	message := SessionMessageReceivedData{FromSessionID: s.SessionID, Content: content}
+	target.inbox.post(message)
+	ephemeral := true
+	target.dispatchEvent(SessionEvent{
+		Data:      &message,
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+
+// inboxAttachments returns messages as text attachments.
+func inboxAttachments(messages []SessionMessageReceivedData) []Attachment {
+	attachments := make([]Attachment, 0, len(messages))
+	for _, message := range messages {
+		name := "Message from session " + message.FromSessionID
+		data := base64.StdEncoding.EncodeToString([]byte(message.Content))
+		attachments = append(attachments, &AttachmentBlob{MIMEType: "text/plain", DisplayName: &name, Data: &data})
+	}
+	return attachments
+}
@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_PostMessageTo(t *testing.T) {
+	sent := make(chan sessionSendRequest, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req struct {
+				SessionID   string            `json:"sessionId"`
+				Prompt      string            `json:"prompt"`
+				Attachments []json.RawMessage `json:"attachments"`
+			}
+			json.Unmarshal(params, &req)
+			var attachments []Attachment
+			for _, raw := range req.Attachments {
+				var blob AttachmentBlob
+				json.Unmarshal(raw, &blob)
+				attachments = append(attachments, &blob)
+			}
+			sent <- sessionSendRequest{SessionID: req.SessionID, Prompt: req.Prompt, Attachments: attachments}
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+	})
+	open := func(id string) *Session {
+		session := newSession(id, client.client, "")
+		session.owner = client
+		client.sessionsMux.Lock()
+		client.sessions[id] = session
+		client.sessionsMux.Unlock()
+		t.Cleanup(func() {
+			client.sessionsMux.Lock()
+			delete(client.sessions, id)
+			client.sessionsMux.Unlock()
+			close(session.done)
+		})
+		return session
+	}
+	planner, coder := open("planner"), open("coder")
+
+	t.Run("delivers an event and attaches the message to the receiver's next turn", func(t *testing.T) {
+		received := make(chan SessionMessageReceivedData, 1)
+		coder.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*SessionMessageReceivedData); ok {
+				received <- *d
+			}
+		})
+		if err := planner.PostMessageTo(t.Context(), "coder", "Implement the parser."); err != nil {
+			t.Fatalf("PostMessageTo failed: %v", err)
+		}
+		select {
+		case got := <-received:
+			if got.FromSessionID != "planner" || got.Content != "Implement the parser." {
+				t.Errorf("unexpected message %+v", got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the message event")
+		}
+
+		for _, wantAttachments := range []int{1, 0} {
+			if _, err := coder.SendPrompt(t.Context(), "go"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			req := <-sent
+			if len(req.Attachments) != wantAttachments {
+				t.Fatalf("expected %d attachments, got %d", wantAttachments, len(req.Attachments))
+			}
+			if wantAttachments == 0 {
+				continue
+			}
+			blob := req.Attachments[0].(*AttachmentBlob)
+			content, _ := base64.StdEncoding.DecodeString(*blob.Data)
+			if string(content) != "Implement the parser." || *blob.DisplayName != "Message from session planner" {
+				t.Errorf("unexpected attachment %q named %q", content, *blob.DisplayName)
+			}
+		}
+	})
+
+	t.Run("rejects sessions not open on the client", func(t *testing.T) {
+		if err := planner.PostMessageTo(t.Context(), "reviewer", "hi"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound, got %v", err)
+		}
+	})
+}
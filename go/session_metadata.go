@@ -0,0 +1,202 @@
+package copilot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// sessionMetadataFile is the name of the metadata file in a session's
+// state directory. The runtime has no place for arbitrary key/value data on
+// a session, so the SDK keeps SessionConfig.Metadata next to the session's
+// event log. It is deleted with the session and read back by ListSessions
+// and GetSessionMetadata, which also read the session's model from the event
+// log. Like ImportSession, this requires the runtime's session store to be
+// on a filesystem this process can reach.
+const sessionMetadataFile = "sdk-metadata.json"
+
+// sessionEventFilePath returns the path of the session's event log.
+func (c *Client) sessionEventFilePath(ctx context.Context, sessionID string) (string, error) {
+	response, err := c.internalRPC.Sessions.GetEventFilePath(ctx, &rpc.SessionsGetEventFilePathRequest{SessionID: sessionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to locate session store: %w", err)
+	}
+	if response.FilePath == "" {
+		return "", errors.New("runtime did not report an event file path")
+	}
+	return response.FilePath, nil
+}
+
+func validateSessionMetadata(metadata map[string]string) error {
+	for key := range metadata {
+		if key == "" {
+			return errors.New("session metadata keys must not be empty")
+		}
+	}
+	return nil
+}
+
+// saveSessionMetadata writes metadata for the session, replacing any
+// previously saved metadata.
+func (c *Client) saveSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error {
+	eventFile, err := c.sessionEventFilePath(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode session metadata: %w", err)
+	}
+	if err := writeSessionFile(filepath.Dir(eventFile), sessionMetadataFile, data); err != nil {
+		return fmt.Errorf("failed to write session metadata: %w", err)
+	}
+	return nil
+}
+
+// writeSessionFile writes data to the file name in the session directory
+// dir, creating the directory if needed.
+func writeSessionFile(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename so readers never see a partial file.
+	tmp, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// readSessionMetadata reads the metadata saved in the session directory dir.
+// It returns nil if none was saved.
+func readSessionMetadata(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionMetadataFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session metadata: %w", err)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode session metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// fillSessionMetadata loads the metadata and model of local sessions into
+// sessions[i]. Sessions whose files cannot be read are left without them, so
+// one unreadable file does not fail a listing.
+func (c *Client) fillSessionMetadata(ctx context.Context, sessions []SessionMetadata) {
+	for i, eventFile := range c.sessionEventFiles(ctx, sessions) {
+		if eventFile == "" {
+			continue
+		}
+		sessions[i].Metadata, _ = readSessionMetadata(filepath.Dir(eventFile))
+		sessions[i].Model, _ = sessionModel(eventFile)
+	}
+}
+
+// sessionEventFiles returns the event log paths of sessions, with "" for
+// remote sessions and those the runtime cannot locate. It asks the runtime
+// for the session store once and derives the rest.
+func (c *Client) sessionEventFiles(ctx context.Context, sessions []SessionMetadata) []string {
+	eventFiles := make([]string, len(sessions))
+	var root, eventFileName string
+	for i := range sessions {
+		id := sessions[i].SessionID
+		if sessions[i].IsRemote {
+			continue
+		}
+		// The runtime keeps each session's files in <root>/<session ID>; ask
+		// it only for the first session and for IDs that are not a plain
+		// file name.
+		eventFile := filepath.Join(root, id, eventFileName)
+		if root == "" || !filepath.IsLocal(id) || filepath.Base(id) != id {
+			var err error
+			if eventFile, err = c.sessionEventFilePath(ctx, id); err != nil {
+				continue
+			}
+			if root == "" && filepath.Base(filepath.Dir(eventFile)) == id {
+				root, eventFileName = filepath.Dir(filepath.Dir(eventFile)), filepath.Base(eventFile)
+			}
+		}
+		eventFiles[i] = eventFile
+	}
+	return eventFiles
+}
+
+// sessionModel returns the model last selected in the event log at
+// eventFile by a session.start, session.resume or session.model_change
+// event, or "" if none names one.
+func sessionModel(eventFile string) (string, error) {
+	f, err := os.Open(eventFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var model string
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		// Only decode the few events that can name a model.
+		if bytes.Contains(line, []byte(`"session.start"`)) || bytes.Contains(line, []byte(`"session.resume"`)) || bytes.Contains(line, []byte(`"session.model_change"`)) {
+			var event struct {
+				Type string `json:"type"`
+				Data struct {
+					SelectedModel string `json:"selectedModel"`
+					NewModel      string `json:"newModel"`
+				} `json:"data"`
+			}
+			if json.Unmarshal(line, &event) == nil {
+				switch {
+				case event.Type == string(SessionEventTypeSessionModelChange) && event.Data.NewModel != "":
+					model = event.Data.NewModel
+				case event.Type != string(SessionEventTypeSessionModelChange) && event.Data.SelectedModel != "":
+					model = event.Data.SelectedModel
+				}
+			}
+		}
+		if err == io.EOF {
+			return model, nil
+		}
+		if err != nil {
+			return model, err
+		}
+	}
+}
+
+// matchesMetadata reports whether metadata has every key/value pair in want.
+func matchesMetadata(metadata, want map[string]string) bool {
+	for key, value := range want {
+		if got, ok := metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package copilot
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestClient_SessionMetadata(t *testing.T) {
+	stateDir := t.TempDir()
+	var lookups atomic.Int32
+	now := time.Now()
+	sessions := []map[string]any{
+		{"sessionId": "s1", "startTime": now, "modifiedTime": now.Add(-time.Minute)},
+		{"sessionId": "s2", "startTime": now, "modifiedTime": now},
+		{"sessionId": "remote", "startTime": now, "modifiedTime": now, "isRemote": true},
+	}
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.list": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			result, _ := json.Marshal(map[string]any{"sessions": sessions})
+			return result, nil
+		},
+		"session.getMetadata": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			result, _ := json.Marshal(map[string]any{"session": sessions[0]})
+			return result, nil
+		},
+		"sessions.getEventFilePath": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			lookups.Add(1)
+			var req rpc.SessionsGetEventFilePathRequest
+			json.Unmarshal(params, &req)
+			result, _ := json.Marshal(rpc.SessionsGetEventFilePathResult{FilePath: filepath.Join(stateDir, req.SessionID, "events.jsonl")})
+			return result, nil
+		},
+	})
+	if err := client.saveSessionMetadata(t.Context(), "s1", map[string]string{"tenant": "acme", "ticket": "OPS-1"}); err != nil {
+		t.Fatalf("saveSessionMetadata failed: %v", err)
+	}
+	if err := client.saveSessionMetadata(t.Context(), "s2", map[string]string{"tenant": "globex"}); err != nil {
+		t.Fatalf("saveSessionMetadata failed: %v", err)
+	}
+	events := `{"type":"session.start","data":{"sessionId":"s1","selectedModel":"gpt-5"}}
+{"type":"user.message","data":{"content":"use session.model_change"}}
+{"type":"session.model_change","data":{"newModel":"claude-sonnet-4.5"}}
+`
+	if err := os.WriteFile(filepath.Join(stateDir, "s1", "events.jsonl"), []byte(events), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("returns metadata with listed sessions", func(t *testing.T) {
+		lookups.Store(0)
+		listed, err := client.ListSessions(t.Context(), nil)
+		if err != nil {
+			t.Fatalf("ListSessions failed: %v", err)
+		}
+		if len(listed) != 3 {
+			t.Fatalf("expected 3 sessions, got %+v", listed)
+		}
+		want := map[string]map[string]string{
+			"s1":     {"tenant": "acme", "ticket": "OPS-1"},
+			"s2":     {"tenant": "globex"},
+			"remote": nil,
+		}
+		for _, session := range listed {
+			if !maps.Equal(session.Metadata, want[session.SessionID]) {
+				t.Errorf("session %s has metadata %v, want %v", session.SessionID, session.Metadata, want[session.SessionID])
+			}
+			if wantModel := map[string]string{"s1": "claude-sonnet-4.5"}[session.SessionID]; session.Model != wantModel {
+				t.Errorf("session %s has model %q, want %q", session.SessionID, session.Model, wantModel)
+			}
+		}
+		if n := lookups.Load(); n != 1 {
+			t.Errorf("expected one lookup of the session store, got %d", n)
+		}
+	})
+
+	t.Run("filters by metadata", func(t *testing.T) {
+		listed, err := client.ListSessions(t.Context(), &SessionListFilter{Metadata: map[string]string{"tenant": "acme"}})
+		if err != nil {
+			t.Fatalf("ListSessions failed: %v", err)
+		}
+		if len(listed) != 1 || listed[0].SessionID != "s1" {
+			t.Errorf("expected only s1, got %+v", listed)
+		}
+	})
+
+	t.Run("returns metadata from GetSessionMetadata", func(t *testing.T) {
+		session, err := client.GetSessionMetadata(t.Context(), "s1")
+		if err != nil {
+			t.Fatalf("GetSessionMetadata failed: %v", err)
+		}
+		if session == nil || session.Metadata["tenant"] != "acme" || session.Model != "claude-sonnet-4.5" {
+			t.Errorf("unexpected metadata: %+v", session)
+		}
+	})
+
+	t.Run("rejects metadata for cloud sessions", func(t *testing.T) {
+		_, err := client.CreateSession(t.Context(), &SessionConfig{
+			Metadata: map[string]string{"tenant": "acme"},
+			Cloud:    &CloudSessionOptions{},
+		})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestClient_CreateSessionMetadataFailure(t *testing.T) {
+	destroyed := make(chan string, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return sessionIDResult(params), nil
+		},
+		"session.destroy": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req sessionDestroyRequest
+			json.Unmarshal(params, &req)
+			destroyed <- req.SessionID
+			return []byte(`{}`), nil
+		},
+		"sessions.getEventFilePath": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return nil, &jsonrpc2.Error{Code: -32603, Message: "no session store"}
+		},
+	})
+
+	_, err := client.CreateSession(t.Context(), &SessionConfig{
+		SessionID:           "s1",
+		OnPermissionRequest: PermissionHandler.ApproveAll,
+		Metadata:            map[string]string{"tenant": "acme"},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	select {
+	case id := <-destroyed:
+		if id != "s1" {
+			t.Errorf("expected s1 to be destroyed, got %s", id)
+		}
+	default:
+		t.Error("expected the runtime session to be destroyed")
+	}
+	client.sessionsMux.Lock()
+	defer client.sessionsMux.Unlock()
+	if len(client.sessions) != 0 {
+		t.Errorf("expected no registered sessions, got %v", client.sessions)
+	}
+}
@@ -0,0 +1,139 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SessionState is a session's state as a turn started, reconstructed from
+// the session's history by [Session.StateAt].
+type SessionState struct {
+	// TurnID is the ID of the user message that started the turn.
+	TurnID string
+	// Model is the model selected for the turn.
+	Model string
+	// Summary is the summary that replaced the older conversation at the
+	// last compaction before the turn, if any.
+	Summary string
+	// Messages is the conversation in the model's context: the messages
+	// since the last compaction, ending with the turn's prompt.
+	Messages []Message
+	// SystemMessages are the latest system and developer prompts recorded
+	// for each role and name, including those recorded for the turn before
+	// its first response.
+	SystemMessages []SystemMessageData
+	// Skills are the skills loaded in the session, and ActiveSkills those
+	// invoked before the turn, whose content is in the context.
+	Skills       []SkillsLoadedSkill
+	ActiveSkills []SkillInvokedData
+	// Tools lists the tools the history shows the model had: those it
+	// called and those activated by tool search. The history does not
+	// record the full tool list, so tools the model had but did not use
+	// are missing.
+	Tools []string
+}
+
+// StateAt returns the session's state as the turn turnID started: the
+// conversation in the model's context, the system prompts, the skills and
+// the tools, reconstructed from the history read with [Session.GetEvents].
+// It powers debugging UIs that show what the model saw at a turn. turnID is
+// the ID of a top-level user [Message], as in [TurnStartInfo.TurnID], or
+// [ErrMessageNotFound] is returned.
+//
+// Example:
+//
+//	state, err := session.StateAt(ctx, turnID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("model %s, %d messages, skills %v\n", state.Model, len(state.Messages), state.ActiveSkills)
+func (s *Session) StateAt(ctx context.Context, turnID string) (*SessionState, error) {
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := stateAt(events, turnID)
+	if state == nil {
+		return nil, fmt.Errorf("%w: %q", ErrMessageNotFound, turnID)
+	}
+	s.annotateMessages(ctx, state.Messages)
+	return state, nil
+}
+
+// stateAt replays events up to the turn turnID, or returns nil if no
+// top-level user message has that ID.
+func stateAt(events []SessionEvent, turnID string) *SessionState {
+	end := slices.IndexFunc(events, func(event SessionEvent) bool {
+		_, ok := event.Data.(*UserMessageData)
+		return ok && event.AgentID == nil && event.ID == turnID
+	})
+	if end < 0 {
+		return nil
+	}
+
+	state := &SessionState{TurnID: turnID}
+	conversationStart := 0
+	tools := make(map[string]bool)
+	addTool := func(name string) {
+		if name != "" && !tools[name] {
+			tools[name] = true
+			state.Tools = append(state.Tools, name)
+		}
+	}
+	for i, event := range events[:end+1] {
+		switch d := event.Data.(type) {
+		case *SessionStartData:
+			state.Model = derefString(d.SelectedModel)
+		case *SessionResumeData:
+			if d.SelectedModel != nil {
+				state.Model = *d.SelectedModel
+			}
+		case *SessionModelChangeData:
+			state.Model = d.NewModel
+		case *SessionCompactionCompleteData:
+			if d.Success {
+				state.Summary = derefString(d.SummaryContent)
+				conversationStart = i + 1
+			}
+		case *SystemMessageData:
+			state.setSystemMessage(d)
+		case *SessionSkillsLoadedData:
+			state.Skills = d.Skills
+		case *SkillInvokedData:
+			state.ActiveSkills = append(state.ActiveSkills, *d)
+		case *ToolExecutionStartData:
+			addTool(d.ToolName)
+		case *ToolSearchActivatedData:
+			for _, name := range d.ToolNames {
+				addTool(name)
+			}
+		}
+	}
+	// The runtime records the system prompts of a turn once it has started,
+	// before the model first responds.
+turn:
+	for _, event := range events[end+1:] {
+		switch d := event.Data.(type) {
+		case *SystemMessageData:
+			state.setSystemMessage(d)
+		case *AssistantMessageData, *SessionIdleData, *UserMessageData:
+			break turn
+		}
+	}
+	state.Messages = collectMessages(events[conversationStart : end+1])
+	return state
+}
+
+// setSystemMessage records d, replacing the earlier message of its role and
+// name.
+func (s *SessionState) setSystemMessage(d *SystemMessageData) {
+	i := slices.IndexFunc(s.SystemMessages, func(m SystemMessageData) bool {
+		return m.Role == d.Role && derefString(m.Name) == derefString(d.Name)
+	})
+	if i < 0 {
+		s.SystemMessages = append(s.SystemMessages, *d)
+	} else {
+		s.SystemMessages[i] = *d
+	}
+}
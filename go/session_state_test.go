@@ -0,0 +1,83 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+const testStateHistory = `{"events": [
+	{"id": "e1", "timestamp": "2026-01-01T00:00:00Z", "type": "session.start", "data": {"sessionId": "s1", "selectedModel": "gpt-5", "copilotVersion": "1.0.0", "producer": "copilot-agent", "startTime": "2026-01-01T00:00:00Z", "version": 1}},
+	{"id": "e2", "timestamp": "2026-01-01T00:00:00Z", "type": "session.skills_loaded", "data": {"skills": [{"name": "deploy", "description": "Deploys", "enabled": true, "source": "project", "userInvocable": true}]}},
+	{"id": "u1", "timestamp": "2026-01-01T00:00:01Z", "type": "user.message", "data": {"content": "list files"}},
+	{"id": "e3", "timestamp": "2026-01-01T00:00:01Z", "type": "system.message", "data": {"role": "system", "content": "You are helpful v1"}},
+	{"id": "a1", "timestamp": "2026-01-01T00:00:02Z", "type": "assistant.message", "data": {"messageId": "m1", "content": "go.mod"}},
+	{"id": "e4", "timestamp": "2026-01-01T00:00:03Z", "type": "tool.execution_start", "data": {"toolCallId": "c1", "toolName": "bash"}},
+	{"id": "e5", "timestamp": "2026-01-01T00:00:04Z", "type": "skill.invoked", "data": {"name": "deploy", "path": "/skills/deploy/SKILL.md", "content": "Deploy with make"}},
+	{"id": "e6", "timestamp": "2026-01-01T00:00:05Z", "type": "session.compaction_complete", "data": {"success": true, "summaryContent": "The user listed files."}},
+	{"id": "e7", "timestamp": "2026-01-01T00:00:06Z", "type": "session.model_change", "data": {"newModel": "claude-sonnet-4.5"}},
+	{"id": "u2", "timestamp": "2026-01-01T00:00:07Z", "type": "user.message", "data": {"content": "deploy it"}},
+	{"id": "e8", "timestamp": "2026-01-01T00:00:07Z", "type": "system.message", "data": {"role": "system", "content": "You are helpful v2"}},
+	{"id": "a2", "timestamp": "2026-01-01T00:00:08Z", "type": "assistant.message", "data": {"messageId": "m2", "content": "Deployed."}},
+	{"id": "e9", "timestamp": "2026-01-01T00:00:09Z", "type": "system.message", "data": {"role": "system", "content": "You are helpful v3"}}
+]}`
+
+func TestSession_StateAt(t *testing.T) {
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.getMessages": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(testStateHistory), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	t.Run("reconstructs the state as the first turn started", func(t *testing.T) {
+		state, err := session.StateAt(t.Context(), "u1")
+		if err != nil {
+			t.Fatalf("StateAt failed: %v", err)
+		}
+		if state.Model != "gpt-5" || state.Summary != "" {
+			t.Errorf("unexpected model %q or summary %q", state.Model, state.Summary)
+		}
+		if len(state.Messages) != 1 || state.Messages[0].Content != "list files" {
+			t.Errorf("expected only the prompt, got %+v", state.Messages)
+		}
+		if len(state.SystemMessages) != 1 || state.SystemMessages[0].Content != "You are helpful v1" {
+			t.Errorf("expected the turn's system message, got %+v", state.SystemMessages)
+		}
+		if len(state.Skills) != 1 || len(state.ActiveSkills) != 0 || len(state.Tools) != 0 {
+			t.Errorf("unexpected skills %+v, active %+v or tools %v", state.Skills, state.ActiveSkills, state.Tools)
+		}
+	})
+
+	t.Run("reconstructs the state after a compaction", func(t *testing.T) {
+		state, err := session.StateAt(t.Context(), "u2")
+		if err != nil {
+			t.Fatalf("StateAt failed: %v", err)
+		}
+		if state.Model != "claude-sonnet-4.5" || state.Summary != "The user listed files." {
+			t.Errorf("unexpected model %q or summary %q", state.Model, state.Summary)
+		}
+		if len(state.Messages) != 1 || state.Messages[0].ID != "u2" {
+			t.Errorf("expected the messages since the compaction, got %+v", state.Messages)
+		}
+		if len(state.SystemMessages) != 1 || state.SystemMessages[0].Content != "You are helpful v2" {
+			t.Errorf("expected the latest system message of the turn, got %+v", state.SystemMessages)
+		}
+		if len(state.ActiveSkills) != 1 || state.ActiveSkills[0].Name != "deploy" {
+			t.Errorf("expected the invoked skill, got %+v", state.ActiveSkills)
+		}
+		if !slices.Equal(state.Tools, []string{"bash"}) {
+			t.Errorf("expected the called tools, got %v", state.Tools)
+		}
+	})
+
+	t.Run("rejects IDs that are not turns", func(t *testing.T) {
+		if _, err := session.StateAt(t.Context(), "a1"); !errors.Is(err, ErrMessageNotFound) {
+			t.Errorf("expected ErrMessageNotFound, got %v", err)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -21,12 +22,13 @@ import (
 // Returns a cleanup function that closes the channel (stopping the consumer).
 func newTestSession() (*Session, func()) {
 	s := &Session{
-		handlers:        make([]sessionHandler, 0),
+		handlers:        make([]*sessionHandler, 0),
 		commandHandlers: make(map[string]CommandHandler),
 		eventCh:         make(chan SessionEvent, 128),
+		done:            make(chan struct{}),
 	}
 	go s.processEvents()
-	return s, func() { close(s.eventCh) }
+	return s, func() { close(s.done) }
 }
 
 func newTestEvent() SessionEvent {
@@ -115,9 +117,9 @@ func TestSession_MCPAuthRequestSendsHostToken(t *testing.T) {
 
 	session := &Session{
 		SessionID: "session-1",
-		client:    client,
 		RPC:       rpc.NewSessionRPC(client, "session-1"),
 	}
+	session.conn.Store(&sessionConn{client: client, rpc: session.RPC})
 	var observedRequest MCPAuthRequest
 	session.registerMCPAuthHandler(func(request MCPAuthRequest, invocation MCPAuthInvocation) (*MCPAuthResult, error) {
 		observedRequest = request
@@ -317,9 +319,9 @@ func captureSetModelRequest(t *testing.T, opts *SetModelOptions) map[string]any
 
 	session := &Session{
 		SessionID: "session-1",
-		client:    client,
 		RPC:       rpc.NewSessionRPC(client, "session-1"),
 	}
+	session.conn.Store(&sessionConn{client: client, rpc: session.RPC})
 	if err := session.SetModel(context.Background(), "gpt-4.1", opts); err != nil {
 		t.Fatalf("SetModel failed: %v", err)
 	}
@@ -1098,6 +1100,138 @@ func TestSession_PostToolUseFailureHook(t *testing.T) {
 	})
 }
 
+func TestSession_PreToolUseModifiedArgs(t *testing.T) {
+	tools := []Tool{{
+		Name: "run",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"command": map[string]any{"type": "string"},
+				"dryRun":  map[string]any{"type": "boolean"},
+			},
+			"required":             []any{"command"},
+			"additionalProperties": false,
+		},
+		Handler: func(ToolInvocation) (ToolResult, error) { return ToolResult{}, nil },
+	}}
+	raw := json.RawMessage(`{"sessionId":"sess-1","timestamp":0,"cwd":"","toolName":"run","toolArgs":{"command":"rm -rf /"}}`)
+
+	t.Run("returns rewritten arguments that match the tool schema", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.registerTools(tools)
+		session.registerHooks(&SessionHooks{
+			OnPreToolUse: func(input PreToolUseHookInput, _ HookInvocation) (*PreToolUseHookOutput, error) {
+				return &PreToolUseHookOutput{
+					PermissionDecision: "allow",
+					ModifiedArgs:       map[string]any{"command": "rm -rf ./sandbox", "dryRun": true},
+				}, nil
+			},
+		})
+
+		output, err := session.handleHooksInvoke("preToolUse", raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out, ok := output.(*PreToolUseHookOutput)
+		if !ok {
+			t.Fatalf("expected *PreToolUseHookOutput, got %T", output)
+		}
+		if args := out.ModifiedArgs.(map[string]any); args["command"] != "rm -rf ./sandbox" {
+			t.Errorf("unexpected modified args: %v", args)
+		}
+	})
+
+	t.Run("rejects rewritten arguments that violate the tool schema", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.registerTools(tools)
+		session.registerHooks(&SessionHooks{
+			OnPreToolUse: func(input PreToolUseHookInput, _ HookInvocation) (*PreToolUseHookOutput, error) {
+				return &PreToolUseHookOutput{ModifiedArgs: map[string]any{"dryRun": "yes"}}, nil
+			},
+		})
+
+		output, err := session.handleHooksInvoke("preToolUse", raw)
+		if err == nil {
+			t.Fatalf("expected schema validation error, got output %v", output)
+		}
+		if !strings.Contains(err.Error(), `tool "run"`) {
+			t.Errorf("expected error to name the tool, got %v", err)
+		}
+	})
+
+	t.Run("does not validate tools without a known schema", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.registerHooks(&SessionHooks{
+			OnPreToolUse: func(input PreToolUseHookInput, _ HookInvocation) (*PreToolUseHookOutput, error) {
+				return &PreToolUseHookOutput{ModifiedArgs: map[string]any{"anything": 1}}, nil
+			},
+		})
+
+		if _, err := session.handleHooksInvoke("preToolUse", json.RawMessage(`{"sessionId":"sess-1","timestamp":0,"cwd":"","toolName":"bash","toolArgs":{}}`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSession_PostToolUseModifiedResult(t *testing.T) {
+	raw := json.RawMessage(`{"sessionId":"sess-1","timestamp":1700000000,"cwd":"","toolName":"read","toolArgs":{"path":".env"},"toolResult":"TOKEN=secret"}`)
+
+	t.Run("reports the original result when a hook replaces it", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+
+		var audits []ToolResultModification
+		session.registerHooks(&SessionHooks{
+			OnPostToolUse: func(input PostToolUseHookInput, _ HookInvocation) (*PostToolUseHookOutput, error) {
+				return &PostToolUseHookOutput{ModifiedResult: "TOKEN=[redacted]"}, nil
+			},
+			OnToolResultModified: func(m ToolResultModification) {
+				audits = append(audits, m)
+			},
+		})
+
+		output, err := session.handleHooksInvoke("postToolUse", raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := output.(*PostToolUseHookOutput); out.ModifiedResult != "TOKEN=[redacted]" {
+			t.Errorf("unexpected ModifiedResult: %v", out.ModifiedResult)
+		}
+		if len(audits) != 1 {
+			t.Fatalf("expected 1 audit record, got %d", len(audits))
+		}
+		if audits[0].OriginalResult != "TOKEN=secret" || audits[0].ModifiedResult != "TOKEN=[redacted]" {
+			t.Errorf("unexpected audit record: %+v", audits[0])
+		}
+		if audits[0].ToolName != "read" || audits[0].SessionID != "sess-1" {
+			t.Errorf("unexpected audit record: %+v", audits[0])
+		}
+	})
+
+	t.Run("does not report results that were left unchanged", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+
+		called := false
+		session.registerHooks(&SessionHooks{
+			OnPostToolUse: func(input PostToolUseHookInput, _ HookInvocation) (*PostToolUseHookOutput, error) {
+				return &PostToolUseHookOutput{AdditionalContext: "ok"}, nil
+			},
+			OnToolResultModified: func(ToolResultModification) { called = true },
+		})
+
+		if _, err := session.handleHooksInvoke("postToolUse", raw); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if called {
+			t.Error("expected OnToolResultModified not to be called")
+		}
+	})
+}
+
 func TestSession_HookForwardCompatibility(t *testing.T) {
 	t.Run("unknown hook type returns nil without error when known hooks are registered", func(t *testing.T) {
 		session, cleanup := newTestSession()
@@ -1220,3 +1354,75 @@ func TestSession_ElicitationRequestSchema(t *testing.T) {
 		}
 	})
 }
+
+func TestSession_Interrupt(t *testing.T) {
+	aborts := make(chan struct{}, 2)
+	results := make(chan map[string]any, 2)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.abort": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			aborts <- struct{}{}
+			return []byte(`{}`), nil
+		},
+		"session.tools.handlePendingToolCall": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			results <- req
+			return []byte(`{"success":true}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+	started := make(chan struct{}, 1)
+	causes := make(chan error, 1)
+	session.registerTools([]Tool{{
+		Name: "build",
+		Handler: func(inv ToolInvocation) (ToolResult, error) {
+			started <- struct{}{}
+			<-inv.TraceContext.Done()
+			causes <- context.Cause(inv.TraceContext)
+			return ToolResult{TextResultForLLM: "partial build"}, nil
+		},
+	}})
+
+	t.Run("does nothing when no turn is running", func(t *testing.T) {
+		if err := session.Interrupt(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-aborts:
+			t.Error("expected no abort request")
+		default:
+		}
+	})
+
+	t.Run("aborts, cancels tool handlers and waits for the turn to end", func(t *testing.T) {
+		session.idle.beginTurn(context.Background())
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "build"}})
+		<-started
+
+		done := make(chan error, 1)
+		go func() { done <- session.Interrupt(t.Context()) }()
+		<-aborts
+		if cause := <-causes; !errors.Is(cause, ErrTurnAborted) {
+			t.Errorf("expected the handler context to be cancelled with ErrTurnAborted, got %v", cause)
+		}
+		if req := <-results; req["requestId"] != "r1" {
+			t.Errorf("expected an interrupted result for r1, got %v", req)
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("expected Interrupt to wait for the turn to end, got %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{Aborted: Bool(true)}})
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Interrupt failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for Interrupt")
+		}
+	})
+}
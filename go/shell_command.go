@@ -0,0 +1,394 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ShellCommandLine is a shell command line broken down into simple
+// commands. See [ParseShellCommand].
+type ShellCommandLine struct {
+	// Commands are the simple commands of the line, in order.
+	Commands []ShellCommand
+	// Complex reports that the line uses constructs the parser does not break
+	// down: command substitution, subshells, here-documents, or keywords such
+	// as if and for. Their text is kept verbatim in the words of Commands, so
+	// a policy should not approve a complex line from Commands alone.
+	Complex bool
+}
+
+// ShellCommand is a simple command of a [ShellCommandLine].
+type ShellCommand struct {
+	// Assignments are the NAME=value words before the binary.
+	Assignments []string
+	// Binary is the command name as written, e.g. "git" or "./build.sh".
+	Binary string
+	Args   []string
+	// Redirects are the command's redirections, in order.
+	Redirects []ShellRedirect
+	// Operator is the control operator after the command: "|", "&&", "||"
+	// or ";". It is "" for the last command, or "&" for any command run in
+	// the background.
+	Operator string
+}
+
+// ShellRedirect is an I/O redirection of a [ShellCommand].
+type ShellRedirect struct {
+	// FD is the redirected file descriptor: the number written before the
+	// operator, or 0 for input and 1 for output redirections. "&>" and "&>>"
+	// redirect both 1 and 2 and report 1.
+	FD int
+	// Op is the operator: "<", ">", ">>", ">|", "<>", "<&", ">&", "&>",
+	// "&>>", "<<", "<<-" or "<<<".
+	Op string
+	// Target is the file, the descriptor for "<&" and ">&" (as in 2>&1),
+	// the here-document delimiter, or the here-string.
+	Target string
+}
+
+// shellKeywords are the reserved words that start compound commands.
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "select": true, "function": true,
+	"{": true, "}": true, "[[": true,
+}
+
+// shellRedirectOps are the redirection operators, longest first.
+var shellRedirectOps = []string{"<<<", "<<-", "&>>", "<<", "<>", "<&", ">>", ">|", ">&", "&>", "<", ">"}
+
+// ParseShellCommand parses a shell command line into its simple commands.
+// Words have their quotes removed, but variables are not expanded. It
+// returns an error for unterminated quotes and misplaced operators.
+//
+// The runtime describes a shell permission request by its full command text
+// and the command identifiers it found. ParseShellCommand breaks the text
+// down further, into each simple command's binary, arguments and
+// redirections, so policy engines don't have to match regular expressions
+// against the raw text. It follows POSIX shell word splitting and quoting
+// but is not a full shell parser: constructs it does not model are reported
+// via [ShellCommandLine.Complex] rather than guessed at.
+//
+// Example:
+//
+//	line, err := copilot.ParseShellCommand(`git log --oneline | head -n 5 > "log.txt"`)
+//	// line.Commands[0]: Binary "git", Args ["log" "--oneline"], Operator "|"
+//	// line.Commands[1]: Binary "head", Args ["-n" "5"],
+//	//                   Redirects [{FD: 1, Op: ">", Target: "log.txt"}]
+func ParseShellCommand(command string) (*ShellCommandLine, error) {
+	p := &shellParser{input: command}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("failed to parse shell command: %w", err)
+	}
+	return &p.line, nil
+}
+
+// shellParser holds the state of [ParseShellCommand].
+type shellParser struct {
+	input string
+	pos   int
+	line  ShellCommandLine
+
+	cmd       ShellCommand
+	hasBinary bool
+	// empty reports whether cmd has no words or redirects yet.
+	empty bool
+
+	word   strings.Builder
+	inWord bool
+	// redirect is the redirection waiting for its target word.
+	redirect *ShellRedirect
+	// heredocs are the delimiters of here-documents whose bodies start on
+	// the next line.
+	heredocs []ShellRedirect
+}
+
+func (p *shellParser) parse() error {
+	p.empty = true
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			if err := p.endWord(); err != nil {
+				return err
+			}
+			p.pos++
+		case c == '\n':
+			if err := p.endCommand(";"); err != nil {
+				return err
+			}
+			p.pos++
+			p.skipHeredocs()
+		case c == '#' && !p.inWord:
+			for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '\'':
+			end := strings.IndexByte(p.input[p.pos+1:], '\'')
+			if end < 0 {
+				return errors.New("unterminated single quote")
+			}
+			p.inWord = true
+			p.word.WriteString(p.input[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+		case c == '"':
+			if err := p.doubleQuoted(); err != nil {
+				return err
+			}
+		case c == '\\':
+			p.pos++
+			if p.pos < len(p.input) {
+				if p.input[p.pos] != '\n' { // a line continuation is dropped
+					p.inWord = true
+					p.word.WriteByte(p.input[p.pos])
+				}
+				p.pos++
+			}
+		case c == '`' || strings.HasPrefix(p.input[p.pos:], "$("):
+			if err := p.substitution(); err != nil {
+				return err
+			}
+		case c == '(' || c == ')':
+			// Subshells and groupings: parse the commands inside as if
+			// the parentheses were not there.
+			p.line.Complex = true
+			if err := p.endWord(); err != nil {
+				return err
+			}
+			p.pos++
+		case c == '<' || c == '>' || strings.HasPrefix(p.input[p.pos:], "&>"):
+			if err := p.startRedirect(); err != nil {
+				return err
+			}
+		case c == '|' || c == '&' || c == ';':
+			op := string(c)
+			switch next := p.input[min(p.pos+1, len(p.input)-1)]; {
+			case p.pos+1 == len(p.input):
+			case c == '|' && next == '|', c == '&' && next == '&':
+				op += string(next)
+			case c == '|' && next == '&':
+				op = "|" // |& also pipes standard error
+				p.pos++
+			case c == ';' && next == ';':
+				return errors.New(`unexpected ";;" outside a case statement`)
+			}
+			if err := p.endCommand(op); err != nil {
+				return err
+			}
+			p.pos += len(op)
+		default:
+			p.inWord = true
+			p.word.WriteByte(c)
+			p.pos++
+		}
+	}
+	if err := p.endCommand(""); err != nil {
+		return err
+	}
+	if n := len(p.line.Commands); n > 0 {
+		last := &p.line.Commands[n-1]
+		switch last.Operator {
+		case "|", "&&", "||":
+			return fmt.Errorf("missing command after %q", last.Operator)
+		case ";":
+			last.Operator = ""
+		}
+	}
+	return nil
+}
+
+// doubleQuoted reads a double-quoted string starting at p.pos.
+func (p *shellParser) doubleQuoted() error {
+	p.inWord = true
+	p.pos++
+	for {
+		if p.pos >= len(p.input) {
+			return errors.New("unterminated double quote")
+		}
+		c := p.input[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return nil
+		case c == '\\' && p.pos+1 < len(p.input) && strings.IndexByte("\"\\$`\n", p.input[p.pos+1]) >= 0:
+			if p.input[p.pos+1] != '\n' {
+				p.word.WriteByte(p.input[p.pos+1])
+			}
+			p.pos += 2
+		case c == '`' || strings.HasPrefix(p.input[p.pos:], "$("):
+			if err := p.substitution(); err != nil {
+				return err
+			}
+		default:
+			p.word.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// substitution reads a $(...) or `...` command substitution starting at
+// p.pos and adds it to the current word verbatim.
+func (p *shellParser) substitution() error {
+	p.line.Complex = true
+	p.inWord = true
+	start := p.pos
+	if p.input[p.pos] == '`' {
+		for p.pos++; p.pos < len(p.input); p.pos++ {
+			switch p.input[p.pos] {
+			case '\\':
+				p.pos++
+			case '`':
+				p.pos++
+				p.word.WriteString(p.input[start:p.pos])
+				return nil
+			}
+		}
+		return errors.New("unterminated backquote")
+	}
+	depth := 0
+	for ; p.pos < len(p.input); p.pos++ {
+		switch p.input[p.pos] {
+		case '\\':
+			p.pos++
+		case '\'':
+			end := strings.IndexByte(p.input[p.pos+1:], '\'')
+			if end < 0 {
+				return errors.New("unterminated single quote")
+			}
+			p.pos += end + 1
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				p.pos++
+				p.word.WriteString(p.input[start:p.pos])
+				return nil
+			}
+		}
+	}
+	return errors.New(`unterminated "$("`)
+}
+
+// startRedirect reads a redirection operator at p.pos. A word of digits
+// written directly before it is its file descriptor.
+func (p *shellParser) startRedirect() error {
+	fd := -1
+	if digits := p.word.String(); p.inWord && digits != "" && strings.Trim(digits, "0123456789") == "" {
+		fd, _ = strconv.Atoi(digits)
+		p.word.Reset()
+		p.inWord = false
+	} else if err := p.endWord(); err != nil {
+		return err
+	}
+	if p.redirect != nil {
+		return fmt.Errorf("missing target for %q", p.redirect.Op)
+	}
+	var op string
+	for _, candidate := range shellRedirectOps {
+		if strings.HasPrefix(p.input[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if fd < 0 {
+		fd = 1
+		if op[0] == '<' {
+			fd = 0
+		}
+	}
+	if op == "<<" || op == "<<-" {
+		p.line.Complex = true
+	}
+	p.redirect = &ShellRedirect{FD: fd, Op: op}
+	p.empty = false
+	p.pos += len(op)
+	return nil
+}
+
+// endWord adds the word being read, if any, to the current command.
+func (p *shellParser) endWord() error {
+	if !p.inWord {
+		return nil
+	}
+	word := p.word.String()
+	p.word.Reset()
+	p.inWord = false
+	p.empty = false
+
+	switch {
+	case p.redirect != nil:
+		p.redirect.Target = word
+		p.cmd.Redirects = append(p.cmd.Redirects, *p.redirect)
+		if p.redirect.Op == "<<" || p.redirect.Op == "<<-" {
+			p.heredocs = append(p.heredocs, *p.redirect)
+		}
+		p.redirect = nil
+	case !p.hasBinary && isShellAssignment(word):
+		p.cmd.Assignments = append(p.cmd.Assignments, word)
+	case !p.hasBinary:
+		p.cmd.Binary = word
+		p.hasBinary = true
+		if shellKeywords[word] {
+			p.line.Complex = true
+		}
+	default:
+		p.cmd.Args = append(p.cmd.Args, word)
+	}
+	return nil
+}
+
+// endCommand ends the current command with the control operator op.
+func (p *shellParser) endCommand(op string) error {
+	if err := p.endWord(); err != nil {
+		return err
+	}
+	if p.redirect != nil {
+		return fmt.Errorf("missing target for %q", p.redirect.Op)
+	}
+	if p.empty {
+		if op == ";" || op == "" {
+			return nil // blank lines and lines continued after && or |
+		}
+		return fmt.Errorf("missing command before %q", op)
+	}
+	p.cmd.Operator = op
+	p.line.Commands = append(p.line.Commands, p.cmd)
+	p.cmd = ShellCommand{}
+	p.hasBinary = false
+	p.empty = true
+	return nil
+}
+
+// skipHeredocs skips the bodies of pending here-documents, which start at
+// p.pos.
+func (p *shellParser) skipHeredocs() {
+	for _, heredoc := range p.heredocs {
+		for p.pos < len(p.input) {
+			line, _, _ := strings.Cut(p.input[p.pos:], "\n")
+			p.pos = min(p.pos+len(line)+1, len(p.input))
+			if heredoc.Op == "<<-" {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == heredoc.Target {
+				break
+			}
+		}
+	}
+	p.heredocs = nil
+}
+
+// isShellAssignment reports whether word has the form NAME=value.
+func isShellAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && (i == 0 || !('0' <= c && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
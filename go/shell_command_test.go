@@ -0,0 +1,87 @@
+package copilot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShellCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    ShellCommandLine
+	}{
+		{
+			name:    "splits pipelines and lists",
+			command: "git status && git log --oneline | head -n 5; ls &",
+			want: ShellCommandLine{Commands: []ShellCommand{
+				{Binary: "git", Args: []string{"status"}, Operator: "&&"},
+				{Binary: "git", Args: []string{"log", "--oneline"}, Operator: "|"},
+				{Binary: "head", Args: []string{"-n", "5"}, Operator: ";"},
+				{Binary: "ls", Operator: "&"},
+			}},
+		},
+		{
+			name:    "removes quotes without expanding variables",
+			command: `echo 'a  b' "c \"d\" $HOME" e\ f`,
+			want: ShellCommandLine{Commands: []ShellCommand{
+				{Binary: "echo", Args: []string{"a  b", `c "d" $HOME`, "e f"}},
+			}},
+		},
+		{
+			name:    "separates assignments and redirects",
+			command: `GOOS=linux go build ./... >build.log 2>&1 < /dev/null`,
+			want: ShellCommandLine{Commands: []ShellCommand{{
+				Assignments: []string{"GOOS=linux"},
+				Binary:      "go",
+				Args:        []string{"build", "./..."},
+				Redirects: []ShellRedirect{
+					{FD: 1, Op: ">", Target: "build.log"},
+					{FD: 2, Op: ">&", Target: "1"},
+					{FD: 0, Op: "<", Target: "/dev/null"},
+				},
+			}}},
+		},
+		{
+			name:    "flags command substitution and keeps it verbatim",
+			command: `rm -rf "$(pwd)/build" ; echo done # cleanup`,
+			want: ShellCommandLine{
+				Commands: []ShellCommand{
+					{Binary: "rm", Args: []string{"-rf", "$(pwd)/build"}, Operator: ";"},
+					{Binary: "echo", Args: []string{"done"}},
+				},
+				Complex: true,
+			},
+		},
+		{
+			name:    "skips here-document bodies",
+			command: "cat <<EOF > out.txt\nrm -rf /\nEOF\nwc -l out.txt",
+			want: ShellCommandLine{
+				Commands: []ShellCommand{
+					{Binary: "cat", Redirects: []ShellRedirect{{FD: 0, Op: "<<", Target: "EOF"}, {FD: 1, Op: ">", Target: "out.txt"}}, Operator: ";"},
+					{Binary: "wc", Args: []string{"-l", "out.txt"}},
+				},
+				Complex: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShellCommand(tt.command)
+			if err != nil {
+				t.Fatalf("ParseShellCommand failed: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseShellCommand(%q)\n got %+v\nwant %+v", tt.command, *got, tt.want)
+			}
+		})
+	}
+
+	t.Run("rejects malformed command lines", func(t *testing.T) {
+		for _, command := range []string{`echo "unterminated`, "echo 'x", "| grep x", "ls &&", "cat >", "echo $(date"} {
+			if _, err := ParseShellCommand(command); err == nil {
+				t.Errorf("expected an error for %q", command)
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package copilot
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SensitiveEnvPatterns matches environment variable names that commonly hold
+// credentials. It is the default [ShellEnvPolicy.Deny]; include it in a
+// custom Deny list to keep it.
+var SensitiveEnvPatterns = []string{
+	"*TOKEN*",
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*CREDENTIAL*",
+	"*API_KEY*",
+	"*PRIVATE_KEY*",
+	"AWS_*",
+	"AZURE_*",
+	"GOOGLE_APPLICATION_CREDENTIALS",
+	"NPM_AUTH*",
+	"SSH_AUTH_SOCK",
+}
+
+// ShellEnvPolicy controls which host environment variables are visible to
+// commands the agent runs in the SDK-hosted shell. See
+// [SessionConfig.ShellEnvPolicy].
+//
+// Patterns are shell globs (as in [path.Match]) matched case-insensitively
+// against variable names.
+type ShellEnvPolicy struct {
+	// Allow, when non-empty, limits inherited variables to those matching at
+	// least one pattern.
+	Allow []string
+	// Deny removes inherited variables matching any pattern. It is applied
+	// after Allow. When nil, it defaults to [SensitiveEnvPatterns]; set it to
+	// an empty slice to inherit every variable Allow admits.
+	Deny []string
+	// Set adds or overrides variables after filtering.
+	Set map[string]string
+}
+
+// withDefaults returns the policy with a nil Deny replaced by
+// [SensitiveEnvPatterns]. A nil policy gets the default Deny too.
+func (p *ShellEnvPolicy) withDefaults() *ShellEnvPolicy {
+	var policy ShellEnvPolicy
+	if p != nil {
+		policy = *p
+	}
+	if policy.Deny == nil {
+		policy.Deny = SensitiveEnvPatterns
+	}
+	return &policy
+}
+
+// checkShell returns an error if the policy is set for a session without
+// the SDK-hosted shell, the only shell it can filter.
+func (p *ShellEnvPolicy) checkShell(shell *ptyShell) error {
+	if p == nil || shell != nil {
+		return nil
+	}
+	return errors.New("ShellEnvPolicy requires PTYShell, as the runtime's built-in bash tool inherits the runtime's whole environment and cannot be filtered per session")
+}
+
+// apply filters environ (KEY=value entries) according to the policy, as
+// set: a nil Deny denies nothing.
+func (p *ShellEnvPolicy) apply(environ []string) []string {
+	if p == nil {
+		return environ
+	}
+	result := make([]string, 0, len(environ)+len(p.Set))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if _, overridden := p.Set[name]; overridden {
+			continue
+		}
+		if len(p.Allow) > 0 && !matchesEnvPattern(name, p.Allow) {
+			continue
+		}
+		if matchesEnvPattern(name, p.Deny) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	names := make([]string, 0, len(p.Set))
+	for name := range p.Set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, name+"="+p.Set[name])
+	}
+	return result
+}
+
+// Environ returns the environment a command in the SDK-hosted shell would
+// see under the policy, starting from the current process environment. A
+// nil policy denies [SensitiveEnvPatterns].
+func (p *ShellEnvPolicy) Environ() []string {
+	return p.withDefaults().apply(os.Environ())
+}
+
+func matchesEnvPattern(name string, patterns []string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), upper); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellEnvPolicy(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/me", "GITHUB_TOKEN=ghp_x", "Npm_Config_Secret=s", "LANG=C"}
+
+	t.Run("denies matching variables case-insensitively", func(t *testing.T) {
+		policy := &ShellEnvPolicy{Deny: SensitiveEnvPatterns}
+		got := policy.apply(environ)
+		want := []string{"PATH=/usr/bin", "HOME=/home/me", "LANG=C"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("limits to allowed variables before denying", func(t *testing.T) {
+		policy := &ShellEnvPolicy{Allow: []string{"PATH", "HOME", "*TOKEN"}, Deny: []string{"GITHUB_*"}}
+		got := policy.apply(environ)
+		want := []string{"PATH=/usr/bin", "HOME=/home/me"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("sets extra variables over inherited ones", func(t *testing.T) {
+		policy := &ShellEnvPolicy{Allow: []string{"PATH", "HOME"}, Set: map[string]string{"HOME": "/tmp/agent", "CI": "true"}}
+		got := policy.apply(environ)
+		want := []string{"PATH=/usr/bin", "CI=true", "HOME=/tmp/agent"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("nil policy inherits everything", func(t *testing.T) {
+		var policy *ShellEnvPolicy
+		if got := policy.apply(environ); !slices.Equal(got, environ) {
+			t.Errorf("got %v, want %v", got, environ)
+		}
+	})
+
+	t.Run("applies to the SDK-hosted shell", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("PTY shell is not supported on Windows")
+		}
+		t.Setenv("SHELL_ENV_TEST_SECRET", "leaked")
+		tools, shell := withPTYShell(nil, &PTYShellConfig{}, &ShellEnvPolicy{
+			Deny: []string{"*SECRET*"},
+			Set:  map[string]string{"SHELL_ENV_TEST_EXTRA": "injected"},
+		}, nil)
+		if len(tools) != 1 || shell == nil {
+			t.Fatalf("expected the SDK-hosted shell, got %d tools", len(tools))
+		}
+		shell.config.Shell = "/bin/sh"
+		shell.config.Timeout = 10 * time.Second
+		result, err := shell.run(ToolInvocation{
+			ToolCallID: "call-1",
+			Arguments:  map[string]any{"command": `echo "secret=[$SHELL_ENV_TEST_SECRET] extra=[$SHELL_ENV_TEST_EXTRA]"`},
+		})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if !strings.Contains(result.TextResultForLLM, "secret=[] extra=[injected]") {
+			t.Errorf("unexpected result: %q", result.TextResultForLLM)
+		}
+	})
+
+	t.Run("denies sensitive variables by default", func(t *testing.T) {
+		t.Setenv("SHELL_ENV_TEST_TOKEN", "leaked")
+		t.Setenv("SHELL_ENV_TEST_PLAIN", "kept")
+		for name, policy := range map[string]*ShellEnvPolicy{
+			"nil policy": nil,
+			"nil Deny":   {Set: map[string]string{"CI": "true"}},
+		} {
+			environ := policy.Environ()
+			if slices.Contains(environ, "SHELL_ENV_TEST_TOKEN=leaked") || !slices.Contains(environ, "SHELL_ENV_TEST_PLAIN=kept") {
+				t.Errorf("%s: expected only the token to be removed, got %v", name, environ)
+			}
+		}
+		if environ := (&ShellEnvPolicy{Deny: []string{}}).Environ(); !slices.Contains(environ, "SHELL_ENV_TEST_TOKEN=leaked") {
+			t.Errorf("expected an empty Deny to inherit the token, got %v", environ)
+		}
+	})
+
+	t.Run("requires PTYShell", func(t *testing.T) {
+		client := startTestRuntime(t, nil)
+		if tools, shell := withPTYShell(nil, nil, &ShellEnvPolicy{}, nil); len(tools) != 0 || shell != nil {
+			t.Fatalf("expected a policy not to replace the built-in bash tool, got %d tools", len(tools))
+		}
+		_, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			ShellEnvPolicy:      &ShellEnvPolicy{},
+		})
+		if err == nil || !strings.Contains(err.Error(), "requires PTYShell") {
+			t.Errorf("expected CreateSession to require PTYShell, got %v", err)
+		}
+		_, err = client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			ShellEnvPolicy:      &ShellEnvPolicy{},
+		})
+		if err == nil || !strings.Contains(err.Error(), "requires PTYShell") {
+			t.Errorf("expected ResumeSession to require PTYShell, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,330 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrStateDirUnknown is returned by [Client.StateDir] and the helpers built
+// on it when the client connects to an existing runtime, whose state
+// directory the SDK cannot know.
+var ErrStateDirUnknown = errors.New("state directory of an external runtime is unknown")
+
+// sessionStateSubdir is where the runtime keeps per-session state, relative
+// to its state directory.
+const sessionStateSubdir = "session-state"
+
+// StateDir returns the directory the runtime keeps its persistent state in:
+// session state, configuration and logs. It is [ClientOptions.BaseDirectory]
+// when set, otherwise COPILOT_HOME from the runtime's environment, otherwise
+// ~/.copilot. Each session's state is in the session-state subdirectory,
+// under the session ID.
+//
+// Returns [ErrStateDirUnknown] for clients that connect to an existing
+// runtime.
+//
+// Example:
+//
+//	dir, err := client.StateDir()
+//	if err == nil {
+//	    fmt.Println("session state is kept in", filepath.Join(dir, "session-state"))
+//	}
+func (c *Client) StateDir() (string, error) {
+	if c.isExternalServer {
+		return "", ErrStateDirUnknown
+	}
+	if c.options.BaseDirectory != "" {
+		return c.options.BaseDirectory, nil
+	}
+	if home := getEnvValue(c.options.Env, "COPILOT_HOME"); home != "" {
+		return home, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".copilot"), nil
+}
+
+// StateUsage reports the disk space used by the runtime's state directory.
+// See [Client.StateDiskUsage].
+type StateUsage struct {
+	// Dir is the state directory.
+	Dir string
+	// TotalBytes is the size of everything in Dir.
+	TotalBytes int64
+	// Sessions lists the state of each persisted session, largest first.
+	Sessions []SessionDiskUsage
+}
+
+// SessionDiskUsage is the disk space used by one session's state.
+type SessionDiskUsage struct {
+	SessionID string
+	Bytes     int64
+	// ModifiedTime is the most recent modification of any file in the
+	// session's state.
+	ModifiedTime time.Time
+}
+
+// StateDiskUsage measures the disk space used by the runtime's state
+// directory (see [Client.StateDir]) and by each persisted session in it. The
+// runtime does not need to be running.
+//
+// Example:
+//
+//	usage, err := client.StateDiskUsage(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Printf("%s uses %d bytes across %d sessions", usage.Dir, usage.TotalBytes, len(usage.Sessions))
+func (c *Client) StateDiskUsage(ctx context.Context) (*StateUsage, error) {
+	dir, err := c.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	usage := &StateUsage{Dir: dir}
+	if usage.TotalBytes, _, err = diskUsage(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, sessionStateSubdir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read session state: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		size, modified, err := diskUsage(ctx, filepath.Join(dir, sessionStateSubdir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		usage.Sessions = append(usage.Sessions, SessionDiskUsage{SessionID: entry.Name(), Bytes: size, ModifiedTime: modified})
+	}
+	slices.SortFunc(usage.Sessions, func(a, b SessionDiskUsage) int {
+		switch {
+		case a.Bytes > b.Bytes:
+			return -1
+		case a.Bytes < b.Bytes:
+			return 1
+		}
+		return 0
+	})
+	return usage, nil
+}
+
+// diskUsage returns the total size of the regular files under dir and the
+// most recent modification time among them. A missing dir is empty.
+func diskUsage(ctx context.Context, dir string) (int64, time.Time, error) {
+	var size int64
+	var modified time.Time
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // removed while walking
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		size += info.Size()
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to measure %s: %w", dir, err)
+	}
+	return size, modified, nil
+}
+
+// sessionDiskUsage returns the size of a session's state, located through
+// the runtime so it also works for external runtimes that share this
+// machine's filesystem.
+func (c *Client) sessionDiskUsage(ctx context.Context, sessionID string) (int64, error) {
+	eventFile, err := c.sessionEventFilePath(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	size, _, err := diskUsage(ctx, filepath.Dir(eventFile))
+	return size, err
+}
+
+// RelocateState moves the runtime's session state (the session-state
+// subdirectory of [Client.StateDir]) into dir and configures the client to
+// use dir as its state directory from the next [Client.Start], so existing
+// sessions can still be listed and resumed. dir may already exist, but its
+// session-state subdirectory must not exist or must be empty. Moving across
+// filesystems copies the state, keeping file modes, modification times and
+// symbolic links, and then removes the original.
+//
+// Only session state is moved. Configuration, extensions and skills stay in
+// the old directory, so a Copilot CLI started by hand keeps them; copy any
+// the runtime needs into dir.
+//
+// The client must be stopped, and must spawn its own runtime.
+//
+// Example:
+//
+//	client.Stop()
+//	if err := client.RelocateState(ctx, "/var/lib/myapp/copilot"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	client.Start(ctx)
+func (c *Client) RelocateState(ctx context.Context, dir string) error {
+	c.startStopMux.Lock()
+	defer c.startStopMux.Unlock()
+
+	if c.isExternalServer {
+		return ErrStateDirUnknown
+	}
+	if c.state == stateConnected || c.state == stateConnecting || c.process != nil || c.ffiHost != nil {
+		return errors.New("client must be stopped before relocating its state")
+	}
+	srcDir, err := c.StateDir()
+	if err != nil {
+		return err
+	}
+	if srcDir, err = filepath.Abs(srcDir); err != nil {
+		return fmt.Errorf("failed to resolve state directory: %w", err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return fmt.Errorf("failed to resolve target directory: %w", err)
+	}
+	if srcDir == dir {
+		return nil
+	}
+	src := filepath.Join(srcDir, sessionStateSubdir)
+	dst := filepath.Join(dir, sessionStateSubdir)
+	if rel, err := filepath.Rel(src, dst); err == nil && filepath.IsLocal(rel) {
+		return fmt.Errorf("target directory %s is inside the session state", dir)
+	}
+
+	entries, err := os.ReadDir(dst)
+	switch {
+	case err == nil && len(entries) > 0:
+		return fmt.Errorf("target directory %s already has session state", dir)
+	case err == nil:
+		if err := os.Remove(dst); err != nil {
+			return fmt.Errorf("failed to replace target directory: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to read target directory: %w", err)
+	}
+
+	if _, err := os.Lstat(src); errors.Is(err, fs.ErrNotExist) {
+		// Nothing to move yet; the runtime creates the directory on start.
+		c.options.BaseDirectory = dir
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		// Typically a move across filesystems.
+		if err := copyTree(ctx, src, dst); err != nil {
+			os.RemoveAll(dst)
+			return fmt.Errorf("failed to copy state to %s: %w", dir, err)
+		}
+		if err := os.RemoveAll(src); err != nil {
+			c.options.BaseDirectory = dir
+			return fmt.Errorf("state copied to %s but the original could not be removed: %w", dir, err)
+		}
+	}
+	c.options.BaseDirectory = dir
+	return nil
+}
+
+// copyTree copies the directory src to dst, which must not exist, keeping
+// permissions, modification times and symbolic links. Other special files
+// are skipped.
+func copyTree(ctx context.Context, src, dst string) error {
+	type dirMode struct {
+		path string
+		perm fs.FileMode
+	}
+	var dirs []dirMode
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			// Owner access until the copy is done, so read-only directories
+			// can be filled.
+			dirs = append(dirs, dirMode{target, info.Mode().Perm()})
+			return os.Mkdir(target, 0o700)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, dir := range slices.Backward(dirs) {
+		if err := os.Chmod(dir.path, dir.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the regular file src to the new file dst with mode perm.
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// The umask may have narrowed the mode.
+	return os.Chmod(dst, perm)
+}
@@ -0,0 +1,201 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func writeStateFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_StateDir(t *testing.T) {
+	t.Run("prefers BaseDirectory over COPILOT_HOME", func(t *testing.T) {
+		client := NewClient(&ClientOptions{BaseDirectory: "/data/copilot", Env: []string{"COPILOT_HOME=/home/me/.copilot"}})
+		if dir, err := client.StateDir(); err != nil || dir != "/data/copilot" {
+			t.Errorf("got %q, %v", dir, err)
+		}
+	})
+
+	t.Run("uses COPILOT_HOME from the runtime environment", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Env: []string{"COPILOT_HOME=/home/me/.copilot"}})
+		if dir, err := client.StateDir(); err != nil || dir != "/home/me/.copilot" {
+			t.Errorf("got %q, %v", dir, err)
+		}
+	})
+
+	t.Run("is unknown for external runtimes", func(t *testing.T) {
+		client := NewClient(&ClientOptions{Connection: URIConnection{URL: "localhost:1234"}})
+		if _, err := client.StateDir(); !errors.Is(err, ErrStateDirUnknown) {
+			t.Errorf("expected ErrStateDirUnknown, got %v", err)
+		}
+	})
+}
+
+func TestClient_StateDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeStateFile(t, filepath.Join(dir, "config.json"), 10)
+	writeStateFile(t, filepath.Join(dir, "session-state", "small", "events.jsonl"), 100)
+	writeStateFile(t, filepath.Join(dir, "session-state", "large", "events.jsonl"), 300)
+	writeStateFile(t, filepath.Join(dir, "session-state", "large", "files", "a.txt"), 200)
+
+	client := NewClient(&ClientOptions{BaseDirectory: dir})
+	usage, err := client.StateDiskUsage(t.Context())
+	if err != nil {
+		t.Fatalf("StateDiskUsage failed: %v", err)
+	}
+	if usage.Dir != dir || usage.TotalBytes != 610 {
+		t.Errorf("unexpected totals: %+v", usage)
+	}
+	if len(usage.Sessions) != 2 || usage.Sessions[0].SessionID != "large" || usage.Sessions[0].Bytes != 500 ||
+		usage.Sessions[1].SessionID != "small" || usage.Sessions[1].Bytes != 100 {
+		t.Errorf("unexpected sessions: %+v", usage.Sessions)
+	}
+	if usage.Sessions[0].ModifiedTime.IsZero() {
+		t.Error("expected a modification time")
+	}
+}
+
+func TestClient_RelocateState(t *testing.T) {
+	t.Run("moves the session state and uses the new directory", func(t *testing.T) {
+		src := t.TempDir()
+		writeStateFile(t, filepath.Join(src, "session-state", "s1", "events.jsonl"), 5)
+		writeStateFile(t, filepath.Join(src, "config.json"), 5)
+		dst := filepath.Join(t.TempDir(), "moved")
+
+		client := NewClient(&ClientOptions{BaseDirectory: src})
+		if err := client.RelocateState(t.Context(), dst); err != nil {
+			t.Fatalf("RelocateState failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "session-state", "s1", "events.jsonl")); err != nil {
+			t.Errorf("expected the state to be moved: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(src, "session-state")); !os.IsNotExist(err) {
+			t.Errorf("expected the original to be gone, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(src, "config.json")); err != nil {
+			t.Errorf("expected the configuration to stay, got %v", err)
+		}
+		if dir, _ := client.StateDir(); dir != dst {
+			t.Errorf("expected StateDir %s, got %s", dst, dir)
+		}
+	})
+
+	t.Run("refuses a target inside the session state", func(t *testing.T) {
+		src := t.TempDir()
+		writeStateFile(t, filepath.Join(src, "session-state", "s1", "events.jsonl"), 5)
+		client := NewClient(&ClientOptions{BaseDirectory: src})
+		if err := client.RelocateState(t.Context(), filepath.Join(src, "session-state", "..nested")); err == nil {
+			t.Fatal("expected an error")
+		}
+		// A sibling whose name starts with ".." is not inside it.
+		if err := client.RelocateState(t.Context(), filepath.Join(src, "..moved")); err != nil {
+			t.Fatalf("RelocateState failed: %v", err)
+		}
+	})
+
+	t.Run("refuses a target with session state", func(t *testing.T) {
+		src := t.TempDir()
+		dst := t.TempDir()
+		writeStateFile(t, filepath.Join(dst, "session-state", "other", "events.jsonl"), 1)
+
+		client := NewClient(&ClientOptions{BaseDirectory: src})
+		if err := client.RelocateState(t.Context(), dst); err == nil {
+			t.Fatal("expected an error")
+		}
+		if dir, _ := client.StateDir(); dir != src {
+			t.Errorf("expected StateDir to be unchanged, got %s", dir)
+		}
+	})
+
+	t.Run("copies modes, times and symbolic links", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "session-state")
+		writeStateFile(t, filepath.Join(src, "s1", "events.jsonl"), 5)
+		if err := os.WriteFile(filepath.Join(src, "s1", "token"), []byte("secret"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		modified := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := os.Chtimes(filepath.Join(src, "s1", "events.jsonl"), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("events.jsonl", filepath.Join(src, "s1", "latest")); err != nil {
+			t.Skipf("symbolic links not supported: %v", err)
+		}
+		dst := filepath.Join(t.TempDir(), "session-state")
+		if err := copyTree(t.Context(), src, dst); err != nil {
+			t.Fatalf("copyTree failed: %v", err)
+		}
+		if info, err := os.Stat(filepath.Join(dst, "s1", "token")); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("expected the token to stay private, got %v, %v", info, err)
+		}
+		if info, err := os.Stat(filepath.Join(dst, "s1", "events.jsonl")); err != nil || !info.ModTime().Equal(modified) {
+			t.Errorf("expected the modification time to be kept, got %v, %v", info, err)
+		}
+		if link, err := os.Readlink(filepath.Join(dst, "s1", "latest")); err != nil || link != "events.jsonl" {
+			t.Errorf("expected the link to be copied, got %q, %v", link, err)
+		}
+	})
+
+	t.Run("refuses a running client", func(t *testing.T) {
+		client := NewClient(&ClientOptions{BaseDirectory: t.TempDir()})
+		client.state = stateConnected
+		if err := client.RelocateState(t.Context(), t.TempDir()); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestClient_PruneSessionsMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	sessions := []map[string]any{
+		{"sessionId": "newest", "startTime": now, "modifiedTime": now},
+		{"sessionId": "middle", "startTime": now, "modifiedTime": now.Add(-time.Hour)},
+		{"sessionId": "oldest", "startTime": now, "modifiedTime": now.Add(-2 * time.Hour)},
+	}
+	for _, session := range sessions {
+		writeStateFile(t, filepath.Join(dir, session["sessionId"].(string), "events.jsonl"), 100)
+	}
+	var deleted []string
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.list": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			result, _ := json.Marshal(map[string]any{"sessions": sessions})
+			return result, nil
+		},
+		"sessions.getEventFilePath": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req struct {
+				SessionID string `json:"sessionId"`
+			}
+			json.Unmarshal(params, &req)
+			result, _ := json.Marshal(map[string]any{"filePath": filepath.Join(dir, req.SessionID, "events.jsonl")})
+			return result, nil
+		},
+		"session.delete": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req deleteSessionRequest
+			json.Unmarshal(params, &req)
+			deleted = append(deleted, req.SessionID)
+			return []byte(`{"success":true}`), nil
+		},
+	})
+
+	pruned, err := client.PruneSessions(t.Context(), SessionRetention{MaxBytes: 250})
+	if err != nil {
+		t.Fatalf("PruneSessions failed: %v", err)
+	}
+	if !slices.Equal(pruned, []string{"oldest"}) || !slices.Equal(deleted, []string{"oldest"}) {
+		t.Errorf("pruned %v (requested %v), want [oldest]", pruned, deleted)
+	}
+}
@@ -0,0 +1,289 @@
+package copilot
+
+import (
+	"encoding/base64"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SuspiciousContentKind classifies a [SuspiciousFinding].
+type SuspiciousContentKind string
+
+const (
+	// SuspiciousInstructions is text addressed to the model rather than the
+	// reader, such as "ignore previous instructions" or chat-template
+	// markers like "<|im_start|>system".
+	SuspiciousInstructions SuspiciousContentKind = "instructions"
+	// SuspiciousHiddenUnicode is an invisible character that can hide
+	// instructions from a human reviewer: zero-width characters,
+	// bidirectional overrides and Unicode tag characters.
+	SuspiciousHiddenUnicode SuspiciousContentKind = "hidden_unicode"
+	// SuspiciousJailbreak is a role-play or jailbreak marker, such as
+	// "developer mode enabled" or "do anything now".
+	SuspiciousJailbreak SuspiciousContentKind = "jailbreak"
+)
+
+// SuspiciousFinding is a span of text flagged by [DetectSuspiciousContent].
+type SuspiciousFinding struct {
+	Kind SuspiciousContentKind
+	// Match is the flagged text.
+	Match string
+	// Offset is the byte offset of Match in the scanned text.
+	Offset int
+}
+
+// suspiciousPatterns are the heuristics of [DetectSuspiciousContent]. They
+// favor precision: ordinary prose about instructions should not match.
+var suspiciousPatterns = []struct {
+	kind SuspiciousContentKind
+	re   *regexp.Regexp
+}{
+	{SuspiciousInstructions, regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+)?(?:of\s+)?(?:the\s+|your\s+)?(?:previous|prior|above|earlier|preceding|original|system)\s+(?:instructions|prompts?|messages|rules|guidelines)`)},
+	{SuspiciousInstructions, regexp.MustCompile(`(?i)\b(?:new|updated|revised|real)\s+(?:system\s+)?instructions\s*:`)},
+	{SuspiciousInstructions, regexp.MustCompile(`(?i)\b(?:attention|note to|message to|instructions? for|dear)\s+(?:the\s+)?(?:ai|llm|language model|ai assistant|ai agent|assistant|copilot)\b`)},
+	{SuspiciousInstructions, regexp.MustCompile(`(?i)\bdo\s+not\s+(?:tell|inform|mention\s+(?:this\s+)?to|reveal\s+(?:this\s+)?to)\s+the\s+user\b`)},
+	{SuspiciousInstructions, regexp.MustCompile(`(?i)<\|?(?:im_start|im_end|system|endoftext)\|?>|\[/?(?:INST|SYS)\]|<</?SYS>>`)},
+	{SuspiciousJailbreak, regexp.MustCompile(`(?i)\b(?:developer|god|unrestricted|jailbreak)\s+mode\s+(?:enabled|activated|on)\b`)},
+	{SuspiciousJailbreak, regexp.MustCompile(`(?i)\bdo\s+anything\s+now\b|\bjailbr(?:eak|oken)\b`)},
+	{SuspiciousJailbreak, regexp.MustCompile(`(?i)\b(?:pretend|imagine)\s+(?:that\s+)?you\s+(?:are|have)\s+(?:no|an?\s+(?:unrestricted|unfiltered|uncensored|evil))\b`)},
+	{SuspiciousJailbreak, regexp.MustCompile(`(?i)\b(?:act|role-?play)\s+as\s+an?\s+(?:unrestricted|unfiltered|uncensored|evil)\b`)},
+}
+
+// isHiddenRune reports whether r is an invisible character used to smuggle
+// instructions past human review.
+func isHiddenRune(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, // zero-width space/joiners, LRM, RLM
+		r >= 0x202A && r <= 0x202E,   // bidirectional embeddings and overrides
+		r >= 0x2060 && r <= 0x2064,   // word joiner, invisible operators
+		r >= 0x2066 && r <= 0x2069,   // bidirectional isolates
+		r == 0xFEFF,                  // zero-width no-break space
+		r >= 0xE0000 && r <= 0xE007F: // tag characters
+		return true
+	}
+	return false
+}
+
+// DetectSuspiciousContent flags likely prompt-injection patterns in text:
+// instructions addressed to the model, hidden Unicode characters, and
+// role-play jailbreak markers. It is a heuristic that catches common
+// attacks, not a guarantee. Findings are ordered by offset; runs of hidden
+// characters are reported as one finding.
+//
+// The SDK runs it on tool results and attachments for
+// [SessionHooks.OnSuspiciousContent]; call it directly to screen other
+// content.
+func DetectSuspiciousContent(text string) []SuspiciousFinding {
+	var findings []SuspiciousFinding
+	for _, p := range suspiciousPatterns {
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			findings = append(findings, SuspiciousFinding{Kind: p.kind, Match: text[loc[0]:loc[1]], Offset: loc[0]})
+		}
+	}
+	start := -1
+	for i, r := range text {
+		hidden := isHiddenRune(r)
+		if hidden && start < 0 {
+			start = i
+		} else if !hidden && start >= 0 {
+			findings = append(findings, SuspiciousFinding{Kind: SuspiciousHiddenUnicode, Match: text[start:i], Offset: start})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		findings = append(findings, SuspiciousFinding{Kind: SuspiciousHiddenUnicode, Match: text[start:], Offset: start})
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Offset < findings[j].Offset })
+	return findings
+}
+
+// StripSuspiciousContent removes the findings from text: hidden characters
+// are deleted and other matches are replaced with "[removed]". Overlapping
+// findings are merged.
+func StripSuspiciousContent(text string, findings []SuspiciousFinding) string {
+	var b strings.Builder
+	pos := 0
+	for _, f := range findings {
+		end := f.Offset + len(f.Match)
+		if end <= pos {
+			continue
+		}
+		if f.Offset > pos {
+			b.WriteString(text[pos:f.Offset])
+		}
+		if f.Kind != SuspiciousHiddenUnicode {
+			b.WriteString("[removed]")
+		}
+		pos = end
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// SuspiciousContentSource says where flagged content came from.
+type SuspiciousContentSource string
+
+const (
+	// SuspiciousContentToolResult is the result of any tool: SDK, built-in
+	// or MCP.
+	SuspiciousContentToolResult SuspiciousContentSource = "tool_result"
+	// SuspiciousContentAttachment is a text blob attachment of a message.
+	SuspiciousContentAttachment SuspiciousContentSource = "attachment"
+)
+
+// SuspiciousContentInput is passed to [SessionHooks.OnSuspiciousContent].
+type SuspiciousContentInput struct {
+	SessionID string
+	Source    SuspiciousContentSource
+	// ToolName and ToolArgs are set for tool results.
+	ToolName string
+	ToolArgs any
+	// AttachmentName is the display name of an attachment.
+	AttachmentName string
+	// Content is the flagged text.
+	Content  string
+	Findings []SuspiciousFinding
+}
+
+// SuspiciousContentAction is what to do with flagged content.
+type SuspiciousContentAction string
+
+const (
+	// SuspiciousContentAllow passes the content to the model unchanged. It
+	// is the zero value.
+	SuspiciousContentAllow SuspiciousContentAction = ""
+	// SuspiciousContentStrip removes the findings with
+	// [StripSuspiciousContent].
+	SuspiciousContentStrip SuspiciousContentAction = "strip"
+	// SuspiciousContentQuarantine replaces the whole content with a notice
+	// that it was withheld.
+	SuspiciousContentQuarantine SuspiciousContentAction = "quarantine"
+)
+
+// quarantineNotice replaces quarantined content.
+const quarantineNotice = "[content withheld: flagged as possible prompt injection]"
+
+// SuspiciousContentHandler decides what to do with content flagged by
+// [DetectSuspiciousContent] before the model sees it.
+type SuspiciousContentHandler func(input SuspiciousContentInput) SuspiciousContentAction
+
+// screenContent runs the detector on input.Content and applies the
+// handler's decision. It returns the content to use and whether it changed.
+func (s *Session) screenContent(handler SuspiciousContentHandler, input SuspiciousContentInput) (string, bool) {
+	input.SessionID = s.SessionID
+	input.Findings = DetectSuspiciousContent(input.Content)
+	if len(input.Findings) == 0 {
+		return input.Content, false
+	}
+	switch handler(input) {
+	case SuspiciousContentStrip:
+		return StripSuspiciousContent(input.Content, input.Findings), true
+	case SuspiciousContentQuarantine:
+		return quarantineNotice, true
+	default:
+		return input.Content, false
+	}
+}
+
+// screenToolResult screens the text of a tool result, after any
+// OnPostToolUse hook, and records a replacement in output.
+func (s *Session) screenToolResult(hooks *SessionHooks, input PostToolUseHookInput, output *PostToolUseHookOutput) *PostToolUseHookOutput {
+	result := input.ToolResult
+	if output != nil && output.ModifiedResult != nil {
+		result = output.ModifiedResult
+	}
+	text, replace := toolResultText(result)
+	if replace == nil {
+		return output
+	}
+	screened, changed := s.screenContent(hooks.OnSuspiciousContent, SuspiciousContentInput{
+		Source:   SuspiciousContentToolResult,
+		ToolName: input.ToolName,
+		ToolArgs: input.ToolArgs,
+		Content:  text,
+	})
+	if !changed {
+		return output
+	}
+	if output == nil {
+		output = &PostToolUseHookOutput{}
+	}
+	output.ModifiedResult = replace(screened)
+	if hooks.OnToolResultModified != nil {
+		hooks.OnToolResultModified(ToolResultModification{
+			SessionID:      input.SessionID,
+			Timestamp:      input.Timestamp,
+			ToolName:       input.ToolName,
+			ToolArgs:       input.ToolArgs,
+			OriginalResult: result,
+			ModifiedResult: output.ModifiedResult,
+		})
+	}
+	return output
+}
+
+// toolResultText returns the model-facing text of a tool result as seen by
+// a postToolUse hook, and a function building the result with the text
+// replaced. replace is nil for results without text.
+func toolResultText(result any) (text string, replace func(string) any) {
+	switch r := result.(type) {
+	case string:
+		return r, func(text string) any { return text }
+	case ToolResult:
+		return r.TextResultForLLM, func(text string) any {
+			r.TextResultForLLM = text
+			return r
+		}
+	case map[string]any:
+		if text, ok := r["textResultForLlm"].(string); ok {
+			return text, func(text string) any {
+				m := maps.Clone(r)
+				m["textResultForLlm"] = text
+				return m
+			}
+		}
+	}
+	return "", nil
+}
+
+// screenAttachments screens the text blob attachments of a message. The
+// caller's attachments are not modified.
+func (s *Session) screenAttachments(handler SuspiciousContentHandler, attachments []Attachment) []Attachment {
+	var screened []Attachment
+	for i, attachment := range attachments {
+		blob, ok := attachment.(*AttachmentBlob)
+		if !ok || blob.Data == nil || !isTextMIMEType(blob.MIMEType) {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(*blob.Data)
+		if err != nil || !utf8.Valid(data) {
+			continue
+		}
+		var name string
+		if blob.DisplayName != nil {
+			name = *blob.DisplayName
+		}
+		text, changed := s.screenContent(handler, SuspiciousContentInput{
+			Source:         SuspiciousContentAttachment,
+			AttachmentName: name,
+			Content:        string(data),
+		})
+		if !changed {
+			continue
+		}
+		if screened == nil {
+			screened = append([]Attachment{}, attachments...)
+		}
+		replaced := *blob
+		encoded := base64.StdEncoding.EncodeToString([]byte(text))
+		replaced.Data = &encoded
+		screened[i] = &replaced
+	}
+	if screened == nil {
+		return attachments
+	}
+	return screened
+}
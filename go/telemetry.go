@@ -2,30 +2,44 @@ package copilot
 
 import (
 	"context"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
 )
 
+// TracePropagator carries W3C Trace Context (traceparent/tracestate)
+// between Go contexts and the runtime. Set [ClientOptions.TracePropagator]
+// to Propagator of the github.com/github/copilot-sdk/go/otelcopilot module
+// to use the global OpenTelemetry propagator.
+type TracePropagator interface {
+	// Inject returns the trace context of the span in ctx, or empty
+	// strings when ctx has none.
+	Inject(ctx context.Context) (traceparent, tracestate string)
+	// Extract returns ctx with the remote span described by traceparent
+	// and tracestate as its parent span.
+	Extract(ctx context.Context, traceparent, tracestate string) context.Context
+}
+
 // getTraceContext extracts the current W3C Trace Context (traceparent/tracestate)
-// from the Go context using the global OTel propagator.
-func getTraceContext(ctx context.Context) (traceparent, tracestate string) {
-	carrier := propagation.MapCarrier{}
-	otel.GetTextMapPropagator().Inject(ctx, carrier)
-	return carrier.Get("traceparent"), carrier.Get("tracestate")
+// from the Go context using propagator. Returns empty strings when
+// propagator is nil.
+func getTraceContext(propagator TracePropagator, ctx context.Context) (traceparent, tracestate string) {
+	if propagator == nil {
+		return "", ""
+	}
+	return propagator.Inject(ctx)
 }
 
 // contextWithTraceParent returns a new context with trace context extracted from
 // the provided W3C traceparent and tracestate headers.
-func contextWithTraceParent(ctx context.Context, traceparent, tracestate string) context.Context {
-	if traceparent == "" {
+func contextWithTraceParent(propagator TracePropagator, ctx context.Context, traceparent, tracestate string) context.Context {
+	if propagator == nil || traceparent == "" {
 		return ctx
 	}
-	carrier := propagation.MapCarrier{
-		"traceparent": traceparent,
-	}
-	if tracestate != "" {
-		carrier["tracestate"] = tracestate
+	return propagator.Extract(ctx, traceparent, tracestate)
+}
+
+// tracePropagator returns the propagator of the client that owns the session.
+func (s *Session) tracePropagator() TracePropagator {
+	if s.owner == nil {
+		return nil
 	}
-	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return s.owner.options.TracePropagator
 }
@@ -0,0 +1,148 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// TelemetrySink receives anonymized usage events about the SDK itself, so a
+// platform team can aggregate adoption and error trends across the services
+// that embed it. See [ClientOptions.TelemetrySink].
+//
+// Events never include session IDs, prompts, responses, tool names or
+// arguments, or error messages: only which options are used, how long
+// operations take, and classes of errors. RecordTelemetry is called from
+// the goroutine that completed the operation, so implementations must be
+// safe for concurrent use and must not block.
+//
+// Example:
+//
+//	type usageSink struct{ events chan<- copilot.TelemetryEvent }
+//
+//	func (s usageSink) RecordTelemetry(event copilot.TelemetryEvent) {
+//	    select {
+//	    case s.events <- event: // shipped to the internal usage service
+//	    default:
+//	    }
+//	}
+type TelemetrySink interface {
+	RecordTelemetry(event TelemetryEvent)
+}
+
+// TelemetryEvent is an anonymized SDK usage event reported to a
+// [TelemetrySink].
+type TelemetryEvent struct {
+	// Name is one of the TelemetryEvent constants.
+	Name      string
+	Timestamp time.Time
+	// Duration is how long the operation took.
+	Duration time.Duration
+	// Outcome is "success" or "failure", or the [TurnOutcome] of a turn.
+	Outcome string
+	// ErrorClass classifies the failure, when Outcome is not a success:
+	// "timeout", "canceled", "offline", "connection_lost", "rpc" or
+	// "other", or the runtime's error type for a failed turn.
+	ErrorClass string
+	// Features lists the names of the options set: the ClientOptions
+	// fields for [TelemetryEventClientStart], and the SessionConfig or
+	// ResumeSessionConfig fields for session events.
+	Features []string
+}
+
+// Events reported to a [TelemetrySink].
+const (
+	// TelemetryEventClientStart is reported when [Client.Start] returns.
+	TelemetryEventClientStart = "client.start"
+	// TelemetryEventSessionCreate is reported when [Client.CreateSession]
+	// returns.
+	TelemetryEventSessionCreate = "session.create"
+	// TelemetryEventSessionResume is reported when
+	// [Client.ResumeSessionWithOptions] returns.
+	TelemetryEventSessionResume = "session.resume"
+	// TelemetryEventTurn is reported when a turn completes, is aborted or
+	// fails.
+	TelemetryEventTurn = "turn"
+)
+
+// recordTelemetry passes event to ClientOptions.TelemetrySink, if set.
+func (c *Client) recordTelemetry(event TelemetryEvent) {
+	if c == nil || c.options.TelemetrySink == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	defer func() { recover() }() // Ignore sink panics
+	c.options.TelemetrySink.RecordTelemetry(event)
+}
+
+// recordOperation reports an operation that started at start and failed
+// with err, if not nil. options is the options struct whose set fields are
+// reported as features.
+func (c *Client) recordOperation(name string, start time.Time, err error, options any) {
+	if c == nil || c.options.TelemetrySink == nil {
+		return
+	}
+	event := TelemetryEvent{
+		Name:     name,
+		Duration: time.Since(start),
+		Outcome:  "success",
+		Features: featuresOf(options),
+	}
+	if err != nil {
+		event.Outcome = "failure"
+		event.ErrorClass = errorClass(err)
+	}
+	c.recordTelemetry(event)
+}
+
+// recordTurnTelemetry reports a finished turn.
+func (c *Client) recordTurnTelemetry(complete *TurnCompleteInfo) {
+	event := TelemetryEvent{
+		Name:     TelemetryEventTurn,
+		Duration: complete.Duration,
+		Outcome:  string(complete.Outcome),
+	}
+	if complete.Error != nil {
+		event.ErrorClass = complete.Error.ErrorType
+	}
+	c.recordTelemetry(event)
+}
+
+// featuresOf returns the names of the exported fields of the struct that
+// options points to that are not zero.
+func featuresOf(options any) []string {
+	v := reflect.ValueOf(options)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	var features []string
+	for i := range v.NumField() {
+		if field := v.Type().Field(i); field.IsExported() && !v.Field(i).IsZero() {
+			features = append(features, field.Name)
+		}
+	}
+	return features
+}
+
+// errorClass classifies err without including its message.
+func errorClass(err error) string {
+	var rpcErr *jsonrpc2.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrOffline):
+		return "offline"
+	case errors.Is(err, ErrConnectionLost):
+		return "connection_lost"
+	case errors.As(err, &rpcErr):
+		return "rpc"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,89 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTelemetrySink struct {
+	mu     sync.Mutex
+	events []TelemetryEvent
+}
+
+func (r *recordingTelemetrySink) RecordTelemetry(event TelemetryEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingTelemetrySink) named(name string) []TelemetryEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []TelemetryEvent
+	for _, event := range r.events {
+		if event.Name == name {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestTelemetrySink(t *testing.T) {
+	t.Run("reports client starts and failed session creation with their features", func(t *testing.T) {
+		sink := &recordingTelemetrySink{}
+		client := startTestRuntimeWithOptions(t, &ClientOptions{TelemetrySink: sink}, nil)
+
+		starts := sink.named(TelemetryEventClientStart)
+		if len(starts) != 1 || starts[0].Outcome != "success" || !slices.Contains(starts[0].Features, "TelemetrySink") {
+			t.Errorf("unexpected start events %+v", starts)
+		}
+
+		_, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Model: "gpt-5"})
+		if err == nil {
+			t.Fatal("expected session creation to fail against the fake runtime")
+		}
+		creates := sink.named(TelemetryEventSessionCreate)
+		if len(creates) != 1 || creates[0].Outcome != "failure" || creates[0].ErrorClass != "rpc" {
+			t.Fatalf("unexpected create events %+v", creates)
+		}
+		if want := []string{"Model", "OnPermissionRequest"}; !slices.Equal(creates[0].Features, want) {
+			t.Errorf("expected features %v, got %v", want, creates[0].Features)
+		}
+	})
+
+	t.Run("reports turns with their outcome and error type", func(t *testing.T) {
+		sink := &recordingTelemetrySink{}
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.owner = &Client{options: ClientOptions{TelemetrySink: sink}}
+
+		session.dispatchEvent(SessionEvent{ID: "u1", Timestamp: time.Now(), Data: &UserMessageData{Content: "hi"}})
+		session.dispatchEvent(SessionEvent{Timestamp: time.Now(), Data: &SessionErrorData{ErrorType: "quota", Message: "out of quota for tenant acme"}})
+		deadline := time.Now().Add(5 * time.Second)
+		for len(sink.named(TelemetryEventTurn)) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the turn event")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := sink.named(TelemetryEventTurn)[0]; got.Outcome != string(TurnOutcomeFailed) || got.ErrorClass != "quota" {
+			t.Errorf("unexpected turn event %+v", got)
+		}
+	})
+
+	t.Run("classifies errors without their messages", func(t *testing.T) {
+		for err, want := range map[error]string{
+			fmt.Errorf("failed to send message: %w", context.DeadlineExceeded): "timeout",
+			fmt.Errorf("failed to create session: %w", ErrConnectionLost):      "connection_lost",
+			fmt.Errorf("secret tenant acme"):                                   "other",
+		} {
+			if got := errorClass(err); got != want {
+				t.Errorf("errorClass(%v) = %q, want %q", err, got, want)
+			}
+		}
+	})
+}
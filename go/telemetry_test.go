@@ -2,85 +2,127 @@ package copilot
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
 )
 
-func TestGetTraceContextEmpty(t *testing.T) {
-	// Without any propagator configured, should return empty strings
-	tp, ts := getTraceContext(context.Background())
-	if tp != "" || ts != "" {
-		t.Errorf("expected empty trace context, got traceparent=%q tracestate=%q", tp, ts)
-	}
-}
+type testTraceKey struct{}
 
-func TestGetTraceContextWithPropagator(t *testing.T) {
-	// Set up W3C propagator
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+// testPropagator keeps the trace context as a context value.
+type testPropagator struct{}
 
-	// Inject known trace context
-	carrier := propagation.MapCarrier{
-		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
-	}
-	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+func (testPropagator) Inject(ctx context.Context) (traceparent, tracestate string) {
+	tc, _ := ctx.Value(testTraceKey{}).([2]string)
+	return tc[0], tc[1]
+}
 
-	tp, ts := getTraceContext(ctx)
-	if tp == "" {
-		t.Error("expected non-empty traceparent")
-	}
-	_ = ts // tracestate may be empty
+func (testPropagator) Extract(ctx context.Context, traceparent, tracestate string) context.Context {
+	return context.WithValue(ctx, testTraceKey{}, [2]string{traceparent, tracestate})
 }
 
-func TestContextWithTraceParentEmpty(t *testing.T) {
-	ctx := contextWithTraceParent(context.Background(), "", "")
-	if ctx == nil {
-		t.Error("expected non-nil context")
+func TestGetTraceContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testTraceKey{}, [2]string{"00-abc-01", "k=v"})
+	if tp, ts := getTraceContext(nil, ctx); tp != "" || ts != "" {
+		t.Errorf("expected no trace context without a propagator, got traceparent=%q tracestate=%q", tp, ts)
+	}
+	if tp, ts := getTraceContext(testPropagator{}, ctx); tp != "00-abc-01" || ts != "k=v" {
+		t.Errorf("expected the propagator's trace context, got traceparent=%q tracestate=%q", tp, ts)
 	}
 }
 
-func TestContextWithTraceParentValid(t *testing.T) {
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
-
-	ctx := contextWithTraceParent(context.Background(),
-		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
-
-	// Verify the context has trace info by extracting it back
-	carrier := propagation.MapCarrier{}
-	otel.GetTextMapPropagator().Inject(ctx, carrier)
-	if carrier.Get("traceparent") == "" {
-		t.Error("expected traceparent to be set in context")
+func TestContextWithTraceParent(t *testing.T) {
+	ctx := context.Background()
+	if got := contextWithTraceParent(nil, ctx, "00-abc-01", ""); got != ctx {
+		t.Error("expected the context to be unchanged without a propagator")
+	}
+	if got := contextWithTraceParent(testPropagator{}, ctx, "", ""); got != ctx {
+		t.Error("expected the context to be unchanged without a traceparent")
+	}
+	got := contextWithTraceParent(testPropagator{}, ctx, "00-abc-01", "k=v")
+	if tc := got.Value(testTraceKey{}); tc != [2]string{"00-abc-01", "k=v"} {
+		t.Errorf("expected the extracted trace context, got %v", tc)
 	}
 }
 
-func TestToolInvocationTraceContext(t *testing.T) {
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+func TestToolInvocationCallerContext(t *testing.T) {
+	responded := make(chan struct{}, 2)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+		"session.tools.handlePendingToolCall": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			responded <- struct{}{}
+			return []byte(`{"success":true}`), nil
+		},
+	})
+	client.options.TracePropagator = testPropagator{}
+	session := newSession("s1", client.client, "")
+	session.owner = client
+	t.Cleanup(func() { close(session.done) })
+	invocations := make(chan ToolInvocation, 2)
+	session.registerTools([]Tool{{
+		Name: "lookup",
+		Handler: func(inv ToolInvocation) (ToolResult, error) {
+			invocations <- inv
+			return ToolResult{TextResultForLLM: "ok"}, nil
+		},
+	}})
+	invoke := func(traceparent *string) ToolInvocation {
+		t.Helper()
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "lookup", Traceparent: traceparent}})
+		select {
+		case inv := <-invocations:
+			<-responded
+			return inv
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the tool call")
+			return ToolInvocation{}
+		}
+	}
 
-	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
-	ctx := contextWithTraceParent(context.Background(), traceparent, "")
+	type principalKey struct{}
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.WithValue(t.Context(), principalKey{}, "alice"), deadline)
+	defer cancel()
 
-	inv := ToolInvocation{
-		SessionID:    "sess-1",
-		ToolCallID:   "call-1",
-		ToolName:     "my_tool",
-		Arguments:    nil,
-		TraceContext: ctx,
-	}
+	t.Run("tool handlers see the sender's values and the CLI span", func(t *testing.T) {
+		if _, err := session.Send(ctx, MessageOptions{Prompt: "look it up"}); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		steer := context.WithValue(t.Context(), principalKey{}, "mallory")
+		if _, err := session.Send(steer, MessageOptions{Prompt: "hurry", Mode: "immediate"}); err != nil {
+			t.Fatal(err)
+		}
+		inv := invoke(ptr("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+		if got := inv.TraceContext.Value(principalKey{}); got != "alice" {
+			t.Errorf("expected the principal of the send that started the turn, got %v", got)
+		}
+		if got, ok := inv.TraceContext.Deadline(); ok {
+			t.Errorf("expected the sender's deadline not to carry over, got %v", got)
+		}
+		if err := inv.TraceContext.Err(); err != nil {
+			t.Errorf("expected the sender's cancellation not to carry over, got %v", err)
+		}
+		if tc, _ := inv.TraceContext.Value(testTraceKey{}).([2]string); tc[0] != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+			t.Errorf("expected the CLI span as parent, got %q", tc[0])
+		}
+	})
 
-	// The TraceContext should carry the remote span context
-	sc := trace.SpanContextFromContext(inv.TraceContext)
-	if !sc.IsValid() {
-		t.Fatal("expected valid span context on ToolInvocation.TraceContext")
-	}
-	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
-		t.Errorf("unexpected trace ID: %s", sc.TraceID())
-	}
-	if sc.SpanID().String() != "00f067aa0ba902b7" {
-		t.Errorf("unexpected span ID: %s", sc.SpanID())
-	}
+	t.Run("tool handlers run under a background context once the turn ends", func(t *testing.T) {
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		if err := session.WaitIdle(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		inv := invoke(nil)
+		if got := inv.TraceContext.Value(principalKey{}); got != nil {
+			t.Errorf("expected no principal outside the turn, got %v", got)
+		}
+		if _, ok := inv.TraceContext.Deadline(); ok {
+			t.Error("expected no deadline outside the turn")
+		}
+	})
 }
@@ -46,6 +46,7 @@ echo "=== Running Go SDK E2E Tests ==="
 echo
 
 go test -v ./... -race -timeout=20m
+(cd internal/e2e && go test -v ./... -race -timeout=20m)
 
 echo
 echo "✅ All tests passed!"
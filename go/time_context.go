@@ -0,0 +1,71 @@
+package copilot
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeContext tells the model the user's local time, so that questions like
+// "what's on my calendar tomorrow" are answered against the real date rather
+// than the model's training data. See [SessionConfig.TimeContext].
+//
+// The context is added to every prompt through the userPromptSubmitted hook,
+// so it is current for each turn rather than fixed when the session was
+// created. It is added after any [SessionHooks.OnUserPromptSubmitted]
+// context.
+type TimeContext struct {
+	// Timezone is an IANA time zone name, such as "Europe/Berlin". Defaults
+	// to the local time zone of the process.
+	Timezone string
+	// InjectCurrentTime adds the current date and time to every prompt. When
+	// false, only the time zone is added.
+	InjectCurrentTime bool
+}
+
+// timeContext produces the per-turn context of a [TimeContext].
+type timeContext struct {
+	location *time.Location
+	inject   bool
+	now      func() time.Time
+}
+
+func newTimeContext(config *TimeContext) (*timeContext, error) {
+	if config == nil {
+		return nil, nil
+	}
+	location := time.Local
+	if config.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(config.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", config.Timezone, err)
+		}
+	}
+	return &timeContext{location: location, inject: config.InjectCurrentTime, now: time.Now}, nil
+}
+
+// context returns the text to add to a prompt.
+func (t *timeContext) context() string {
+	now := t.now().In(t.location)
+	zone := t.location.String()
+	if zone == "Local" {
+		zone, _ = now.Zone()
+	}
+	if !t.inject {
+		return fmt.Sprintf("The user's time zone is %s.", zone)
+	}
+	return fmt.Sprintf("The current date and time for the user is %s (%s, UTC%s).",
+		now.Format("Monday, January 2, 2006 15:04"), zone, now.Format("-07:00"))
+}
+
+// appendPromptContext appends text to the context returned by a
+// userPromptSubmitted hook.
+func appendPromptContext(output *UserPromptSubmittedHookOutput, text string) *UserPromptSubmittedHookOutput {
+	if output == nil {
+		output = &UserPromptSubmittedHookOutput{}
+	}
+	if output.AdditionalContext != "" {
+		output.AdditionalContext += "\n\n"
+	}
+	output.AdditionalContext += text
+	return output
+}
@@ -0,0 +1,84 @@
+package copilot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeContext(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	raw := json.RawMessage(`{"sessionId":"sess-1","timestamp":0,"cwd":"","prompt":"what's on tomorrow?"}`)
+
+	newTestTimeContext := func(t *testing.T, config *TimeContext) *timeContext {
+		t.Helper()
+		tc, err := newTimeContext(config)
+		if err != nil {
+			t.Fatalf("newTimeContext failed: %v", err)
+		}
+		tc.now = func() time.Time { return now }
+		return tc
+	}
+
+	t.Run("adds the current time in the configured zone", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.timeContext = newTestTimeContext(t, &TimeContext{Timezone: "America/New_York", InjectCurrentTime: true})
+
+		output, err := session.handleHooksInvoke("userPromptSubmitted", raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "The current date and time for the user is Monday, March 2, 2026 03:30 (America/New_York, UTC-05:00)."
+		if got := output.(*UserPromptSubmittedHookOutput).AdditionalContext; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("refreshes the time every turn", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.timeContext = newTestTimeContext(t, &TimeContext{Timezone: "UTC", InjectCurrentTime: true})
+
+		first, _ := session.handleHooksInvoke("userPromptSubmitted", raw)
+		now = now.Add(26 * time.Hour)
+		second, _ := session.handleHooksInvoke("userPromptSubmitted", raw)
+		if first.(*UserPromptSubmittedHookOutput).AdditionalContext == second.(*UserPromptSubmittedHookOutput).AdditionalContext {
+			t.Error("expected the time to be refreshed for the second turn")
+		}
+	})
+
+	t.Run("adds only the zone without InjectCurrentTime", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.timeContext = newTestTimeContext(t, &TimeContext{Timezone: "Asia/Tokyo"})
+
+		output, _ := session.handleHooksInvoke("userPromptSubmitted", raw)
+		if got := output.(*UserPromptSubmittedHookOutput).AdditionalContext; got != "The user's time zone is Asia/Tokyo." {
+			t.Errorf("unexpected context %q", got)
+		}
+	})
+
+	t.Run("keeps the context of OnUserPromptSubmitted", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.timeContext = newTestTimeContext(t, &TimeContext{Timezone: "UTC"})
+		session.registerHooks(&SessionHooks{
+			OnUserPromptSubmitted: func(UserPromptSubmittedHookInput, HookInvocation) (*UserPromptSubmittedHookOutput, error) {
+				return &UserPromptSubmittedHookOutput{ModifiedPrompt: "rewritten", AdditionalContext: "The user is on call."}, nil
+			},
+		})
+
+		output, _ := session.handleHooksInvoke("userPromptSubmitted", raw)
+		out := output.(*UserPromptSubmittedHookOutput)
+		if out.ModifiedPrompt != "rewritten" || out.AdditionalContext != "The user is on call.\n\nThe user's time zone is UTC." {
+			t.Errorf("unexpected output %+v", out)
+		}
+	})
+
+	t.Run("rejects an unknown zone", func(t *testing.T) {
+		if _, err := newTimeContext(&TimeContext{Timezone: "Mars/Olympus_Mons"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
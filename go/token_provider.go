@@ -0,0 +1,192 @@
+package copilot
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// TokenProvider supplies GitHub tokens that expire, for
+// [ClientOptions.TokenProvider].
+//
+// Token returns a token and the time it expires, or the zero time if it
+// does not. The client calls it when it starts, and again shortly before
+// the token expires, logging the runtime in with the new token. Failed
+// refreshes are logged and retried.
+//
+// The runtime does not use the system keychain when a TokenProvider is set,
+// so refreshed tokens are not stored.
+type TokenProvider interface {
+	Token(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+// TokenProviderFunc adapts a function to a [TokenProvider].
+//
+// Example:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    TokenProvider: copilot.TokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+//	        token, err := app.InstallationToken(ctx)
+//	        if err != nil {
+//	            return "", time.Time{}, err
+//	        }
+//	        return token.Value, token.ExpiresAt, nil
+//	    }),
+//	})
+type TokenProviderFunc func(ctx context.Context) (string, time.Time, error)
+
+// Token calls f.
+func (f TokenProviderFunc) Token(ctx context.Context) (string, time.Time, error) {
+	return f(ctx)
+}
+
+const (
+	// tokenRefreshMargin is how long before a token expires it is refreshed.
+	tokenRefreshMargin = 5 * time.Minute
+	// tokenRefreshRetry is the delay between failed refresh attempts.
+	tokenRefreshRetry = 30 * time.Second
+	// tokenRefreshTimeout bounds a single refresh attempt.
+	tokenRefreshTimeout = 30 * time.Second
+)
+
+// gitHubToken returns the token to start the runtime with: GitHubToken, the
+// latest token from the TokenProvider, or the token ResolveToken found.
+func (c *Client) gitHubToken() string {
+	if token := c.providedToken.Load(); token != nil {
+		return *token
+	}
+	return c.options.GitHubToken
+}
+
+// fetchToken gets a token from the TokenProvider, if any, for the runtime to
+// start with, and returns its expiry. Without a TokenProvider or GitHubToken
+// it resolves one with [ResolveToken].
+func (c *Client) fetchToken(ctx context.Context) (time.Time, error) {
+	if c.options.TokenProvider == nil {
+		c.resolveDefaultToken(ctx)
+		return time.Time{}, nil
+	}
+	token, expiry, err := c.options.TokenProvider.Token(ctx)
+	if err == nil && token == "" {
+		err = errors.New("token is empty")
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get GitHub token: %w", err)
+	}
+	c.providedToken.Store(&token)
+	return expiry, nil
+}
+
+// startTokenRefresh starts the loop that refreshes the token from the
+// TokenProvider before expiry. Must be called with startStopMux held.
+func (c *Client) startTokenRefresh(expiry time.Time) {
+	c.stopTokenRefresh()
+	if c.options.TokenProvider == nil || expiry.IsZero() {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.tokenRefreshCancel = cancel
+	go c.runTokenRefresh(ctx, expiry)
+}
+
+// stopTokenRefresh stops the token refresh loop, if running. Must be called
+// with startStopMux held.
+func (c *Client) stopTokenRefresh() {
+	if c.tokenRefreshCancel != nil {
+		c.tokenRefreshCancel()
+		c.tokenRefreshCancel = nil
+	}
+}
+
+func (c *Client) runTokenRefresh(ctx context.Context, expiry time.Time) {
+	wait := tokenRefreshDelay(time.Until(expiry))
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		next, err := c.refreshToken(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			remaining := time.Until(expiry)
+			c.logger().Warn("failed to refresh GitHub token", "error", err, "expiresIn", remaining.Round(time.Second))
+			// Retry sooner when the token is about to expire.
+			wait = tokenRefreshRetry
+			if remaining > 0 && remaining/2 < wait {
+				wait = max(remaining/2, time.Second)
+			}
+			continue
+		}
+		if next.IsZero() {
+			return
+		}
+		expiry = next
+		wait = tokenRefreshDelay(time.Until(expiry))
+	}
+}
+
+// tokenRefreshDelay returns how long to wait before refreshing a token that
+// expires in remaining: until tokenRefreshMargin before expiry, or halfway
+// for tokens that live less than twice the margin.
+func tokenRefreshDelay(remaining time.Duration) time.Duration {
+	wait := remaining - tokenRefreshMargin
+	if wait < remaining/2 {
+		wait = remaining / 2
+	}
+	return max(wait, time.Second)
+}
+
+// refreshToken gets a new token from the TokenProvider and logs the runtime
+// in with it, then returns its expiry. Runtimes started later, such as by
+// [Client.Restart], start with the new token.
+func (c *Client) refreshToken(ctx context.Context) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRefreshTimeout)
+	defer cancel()
+	token, expiry, err := c.options.TokenProvider.Token(ctx)
+	if err == nil && token == "" {
+		err = errors.New("token is empty")
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	c.providedToken.Store(&token)
+
+	c.startStopMux.RLock()
+	server := c.RPC
+	c.startStopMux.RUnlock()
+	if server == nil {
+		return expiry, nil
+	}
+	// account.login needs the account's login and host, which are the same
+	// for a refreshed token as for the one it replaces.
+	status, err := c.GetAuthStatus(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get auth status: %w", err)
+	}
+	if status.Login == nil {
+		return time.Time{}, errors.New("failed to re-authenticate runtime: no authenticated account")
+	}
+	host := "https://github.com"
+	if status.Host != nil {
+		host = cmp.Or(*status.Host, host)
+	}
+	if _, err := server.Account.Login(ctx, &rpc.AccountLoginRequest{
+		Host:  host,
+		Login: *status.Login,
+		Token: token,
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("failed to re-authenticate runtime: %w", err)
+	}
+	c.logger().Debug("refreshed GitHub token", "expiresAt", expiry)
+	return expiry, nil
+}
@@ -0,0 +1,102 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestTokenProvider(t *testing.T) {
+	t.Run("panics when combined with GitHubToken", func(t *testing.T) {
+		defer func() {
+			if msg, _ := recover().(string); !strings.Contains(msg, "cannot both be set") {
+				t.Errorf("expected a panic about GitHubToken and TokenProvider, got %v", msg)
+			}
+		}()
+		NewClient(&ClientOptions{
+			GitHubToken:   "gho_static",
+			TokenProvider: TokenProviderFunc(func(context.Context) (string, time.Time, error) { return "", time.Time{}, nil }),
+		})
+	})
+
+	t.Run("starts the runtime with the provided token", func(t *testing.T) {
+		client := NewClient(&ClientOptions{
+			Connection: InProcessConnection{},
+			TokenProvider: TokenProviderFunc(func(context.Context) (string, time.Time, error) {
+				return "ghs_first", time.Now().Add(time.Hour), nil
+			}),
+		})
+		if _, err := client.fetchToken(t.Context()); err != nil {
+			t.Fatalf("fetchToken failed: %v", err)
+		}
+		config := client.inProcessHostConfig()
+		if config.Environment["COPILOT_SDK_AUTH_TOKEN"] != "ghs_first" || config.Environment["COPILOT_DISABLE_KEYTAR"] != "1" {
+			t.Errorf("unexpected environment %v", config.Environment)
+		}
+		if !strings.Contains(strings.Join(config.Args, " "), "--auth-token-env COPILOT_SDK_AUTH_TOKEN --no-auto-login") {
+			t.Errorf("unexpected args %v", config.Args)
+		}
+	})
+
+	t.Run("fails to start without a token", func(t *testing.T) {
+		client := NewClient(&ClientOptions{
+			TokenProvider: TokenProviderFunc(func(context.Context) (string, time.Time, error) {
+				return "", time.Time{}, errors.New("app not installed")
+			}),
+		})
+		if _, err := client.fetchToken(t.Context()); err == nil || !strings.Contains(err.Error(), "app not installed") {
+			t.Errorf("expected the provider's error, got %v", err)
+		}
+	})
+
+	t.Run("logs the runtime in with a refreshed token", func(t *testing.T) {
+		var login atomic.Value
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"auth.getStatus": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{"isAuthenticated":true,"authType":"token","host":"https://github.com","login":"my-app[bot]"}`), nil
+			},
+			"account.login": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				login.Store(string(params))
+				return []byte(`{"storedInVault":false}`), nil
+			},
+		})
+		expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+		client.options.TokenProvider = TokenProviderFunc(func(context.Context) (string, time.Time, error) {
+			return "ghs_second", expiry, nil
+		})
+
+		next, err := client.refreshToken(t.Context())
+		if err != nil {
+			t.Fatalf("refreshToken failed: %v", err)
+		}
+		if !next.Equal(expiry) || client.gitHubToken() != "ghs_second" {
+			t.Errorf("unexpected expiry %v and token %q", next, client.gitHubToken())
+		}
+		var params map[string]string
+		json.Unmarshal([]byte(login.Load().(string)), &params)
+		if params["token"] != "ghs_second" || params["login"] != "my-app[bot]" || params["host"] != "https://github.com" {
+			t.Errorf("unexpected account.login params %v", params)
+		}
+	})
+
+	t.Run("refreshes before expiry", func(t *testing.T) {
+		cases := []struct {
+			remaining, want time.Duration
+		}{
+			{time.Hour, 55 * time.Minute},
+			{6 * time.Minute, 3 * time.Minute},
+			{0, time.Second},
+		}
+		for _, tc := range cases {
+			if got := tokenRefreshDelay(tc.remaining); got != tc.want {
+				t.Errorf("tokenRefreshDelay(%v): expected %v, got %v", tc.remaining, tc.want, got)
+			}
+		}
+	})
+}
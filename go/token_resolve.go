@@ -0,0 +1,127 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoToken is returned by [ResolveToken] when no source has a token.
+var ErrNoToken = errors.New("no GitHub token found")
+
+// TokenSource is where [ResolveToken] found a token.
+type TokenSource string
+
+const (
+	// TokenSourceCopilotEnv is the COPILOT_GITHUB_TOKEN environment variable.
+	TokenSourceCopilotEnv TokenSource = "COPILOT_GITHUB_TOKEN"
+	// TokenSourceGHEnv is the GH_TOKEN environment variable.
+	TokenSourceGHEnv TokenSource = "GH_TOKEN"
+	// TokenSourceGitHubEnv is the GITHUB_TOKEN environment variable.
+	TokenSourceGitHubEnv TokenSource = "GITHUB_TOKEN"
+	// TokenSourceGHCLI is the GitHub CLI's login, from "gh auth token".
+	TokenSourceGHCLI TokenSource = "gh"
+	// TokenSourceKeychain is the Copilot CLI's login, stored in the OS
+	// keychain.
+	TokenSourceKeychain TokenSource = "keychain"
+)
+
+// ResolvedToken is a token found by [ResolveToken].
+type ResolvedToken struct {
+	Token  string
+	Source TokenSource
+}
+
+// keychainService is the OS keychain service the Copilot CLI stores its
+// login under.
+const keychainService = "copilot-cli"
+
+// runTokenCommand runs a command that prints a token. It is a variable for
+// tests.
+var runTokenCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ResolveToken finds a GitHub token the way GitHub tools do, trying in
+// order:
+//
+//  1. the COPILOT_GITHUB_TOKEN, GH_TOKEN and GITHUB_TOKEN environment
+//     variables;
+//  2. the GitHub CLI's login, from "gh auth token";
+//  3. the Copilot CLI's login in the OS keychain, on macOS and Linux.
+//
+// It returns the first token found and its source, or [ErrNoToken]. A
+// missing gh or keychain tool counts as no token.
+//
+// A [Client] started without [ClientOptions.GitHubToken] or
+// [ClientOptions.TokenProvider] resolves a token this way and starts the
+// runtime with it, unless [ClientOptions.UseLoggedInUser] is false or
+// [ClientOptions.DisableTokenResolution] is set.
+//
+// Example:
+//
+//	resolved, err := copilot.ResolveToken(ctx)
+//	if errors.Is(err, copilot.ErrNoToken) {
+//	    log.Fatal("sign in with `gh auth login` or set GH_TOKEN")
+//	}
+//	log.Printf("using the GitHub token from %s", resolved.Source)
+func ResolveToken(ctx context.Context) (*ResolvedToken, error) {
+	for _, source := range []TokenSource{TokenSourceCopilotEnv, TokenSourceGHEnv, TokenSourceGitHubEnv} {
+		if token := strings.TrimSpace(os.Getenv(string(source))); token != "" {
+			return &ResolvedToken{Token: token, Source: source}, nil
+		}
+	}
+
+	type tokenCommand struct {
+		source TokenSource
+		args   []string
+	}
+	commands := []tokenCommand{{TokenSourceGHCLI, []string{"gh", "auth", "token"}}}
+	switch runtime.GOOS {
+	case "darwin":
+		commands = append(commands, tokenCommand{TokenSourceKeychain, []string{"security", "find-generic-password", "-s", keychainService, "-w"}})
+	case "linux":
+		commands = append(commands, tokenCommand{TokenSourceKeychain, []string{"secret-tool", "lookup", "service", keychainService}})
+	}
+	for _, command := range commands {
+		token, err := runTokenCommand(ctx, command.args[0], command.args[1:]...)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to resolve GitHub token: %w", ctx.Err())
+		}
+		// A tool that is missing or has no login is not an error: the
+		// next source is tried.
+		if err == nil && token != "" {
+			return &ResolvedToken{Token: token, Source: command.source}, nil
+		}
+	}
+	return nil, ErrNoToken
+}
+
+// resolveDefaultToken resolves the token to start an SDK-owned runtime with
+// when the client has no GitHubToken. Finding none is not an error: the
+// runtime then signs in on its own, if it can.
+func (c *Client) resolveDefaultToken(ctx context.Context) {
+	c.providedToken.Store(nil)
+	if c.options.GitHubToken != "" || c.isExternalServer || c.options.Offline || c.options.DisableTokenResolution ||
+		(c.options.UseLoggedInUser != nil && !*c.options.UseLoggedInUser) {
+		return
+	}
+	resolved, err := ResolveToken(ctx)
+	if err != nil {
+		c.logger().Debug("no GitHub token resolved", "error", err)
+		return
+	}
+	c.providedToken.Store(&resolved.Token)
+	c.logger().Debug("resolved GitHub token", "source", resolved.Source)
+}
@@ -0,0 +1,114 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// stubTokenCommands replaces runTokenCommand for the test with one that
+// answers from tokens, keyed by command name.
+func stubTokenCommands(t *testing.T, tokens map[string]string) *[]string {
+	var ran []string
+	original := runTokenCommand
+	runTokenCommand = func(_ context.Context, name string, _ ...string) (string, error) {
+		ran = append(ran, name)
+		if token, ok := tokens[name]; ok {
+			return token, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	t.Cleanup(func() { runTokenCommand = original })
+	return &ran
+}
+
+func TestResolveToken(t *testing.T) {
+	clearEnv := func(t *testing.T) {
+		for _, name := range []string{"COPILOT_GITHUB_TOKEN", "GH_TOKEN", "GITHUB_TOKEN"} {
+			t.Setenv(name, "")
+		}
+	}
+
+	t.Run("prefers environment variables in order", func(t *testing.T) {
+		clearEnv(t)
+		ran := stubTokenCommands(t, map[string]string{"gh": "gho_cli"})
+		t.Setenv("GH_TOKEN", "gho_gh")
+		t.Setenv("GITHUB_TOKEN", "ghs_actions")
+		resolved, err := ResolveToken(t.Context())
+		if err != nil || resolved.Token != "gho_gh" || resolved.Source != TokenSourceGHEnv {
+			t.Errorf("unexpected resolved token %+v, %v", resolved, err)
+		}
+		if len(*ran) != 0 {
+			t.Errorf("expected no commands to run, got %v", *ran)
+		}
+	})
+
+	t.Run("falls back to the gh CLI", func(t *testing.T) {
+		clearEnv(t)
+		stubTokenCommands(t, map[string]string{"gh": "gho_cli"})
+		resolved, err := ResolveToken(t.Context())
+		if err != nil || resolved.Token != "gho_cli" || resolved.Source != TokenSourceGHCLI {
+			t.Errorf("unexpected resolved token %+v, %v", resolved, err)
+		}
+	})
+
+	t.Run("falls back to the keychain", func(t *testing.T) {
+		if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+			t.Skip("no keychain lookup on " + runtime.GOOS)
+		}
+		clearEnv(t)
+		stubTokenCommands(t, map[string]string{"security": "gho_keychain", "secret-tool": "gho_keychain"})
+		resolved, err := ResolveToken(t.Context())
+		if err != nil || resolved.Token != "gho_keychain" || resolved.Source != TokenSourceKeychain {
+			t.Errorf("unexpected resolved token %+v, %v", resolved, err)
+		}
+	})
+
+	t.Run("reports no token", func(t *testing.T) {
+		clearEnv(t)
+		stubTokenCommands(t, nil)
+		if _, err := ResolveToken(t.Context()); !errors.Is(err, ErrNoToken) {
+			t.Errorf("expected ErrNoToken, got %v", err)
+		}
+	})
+
+	t.Run("starts the runtime with the resolved token", func(t *testing.T) {
+		clearEnv(t)
+		stubTokenCommands(t, map[string]string{"gh": "gho_cli"})
+		client := NewClient(&ClientOptions{Connection: InProcessConnection{}})
+		client.resolveDefaultToken(t.Context())
+		config := client.inProcessHostConfig()
+		if config.Environment["COPILOT_SDK_AUTH_TOKEN"] != "gho_cli" {
+			t.Errorf("unexpected environment %v", config.Environment)
+		}
+		// The runtime may still fall back to its own login.
+		if slices.Contains(config.Args, "--no-auto-login") {
+			t.Errorf("unexpected args %v", config.Args)
+		}
+	})
+
+	t.Run("does not resolve when UseLoggedInUser is false", func(t *testing.T) {
+		clearEnv(t)
+		ran := stubTokenCommands(t, map[string]string{"gh": "gho_cli"})
+		client := NewClient(&ClientOptions{Connection: InProcessConnection{}, UseLoggedInUser: Bool(false)})
+		client.resolveDefaultToken(t.Context())
+		if _, ok := client.inProcessHostConfig().Environment["COPILOT_SDK_AUTH_TOKEN"]; ok || len(*ran) != 0 {
+			t.Errorf("expected no token to be resolved, ran %v", *ran)
+		}
+	})
+
+	t.Run("does not resolve when DisableTokenResolution is set", func(t *testing.T) {
+		clearEnv(t)
+		ran := stubTokenCommands(t, map[string]string{"gh": "gho_cli"})
+		client := NewClient(&ClientOptions{Connection: InProcessConnection{}, DisableTokenResolution: true})
+		if _, err := client.fetchToken(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := client.inProcessHostConfig().Environment["COPILOT_SDK_AUTH_TOKEN"]; ok || len(*ran) != 0 {
+			t.Errorf("expected the runtime to use its own login, ran %v", *ran)
+		}
+	})
+}
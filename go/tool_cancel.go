@@ -0,0 +1,150 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ErrToolCallNotRunning is returned by [Session.CancelToolCall] when no
+// handler is running for the given tool call ID in this client.
+var ErrToolCallNotRunning = errors.New("tool call is not running")
+
+// ErrToolCallCancelled is the cause of a tool handler's context
+// (ToolInvocation.TraceContext) once [Session.CancelToolCall] cancels the
+// call; test for it with context.Cause.
+var ErrToolCallCancelled = errors.New("tool call cancelled")
+
+// cancelledToolResultText is what the model is told about a cancelled call.
+const cancelledToolResultText = "The tool call was cancelled by the user before it completed."
+
+// runningToolCall is a tool handler running in this client.
+type runningToolCall struct {
+	requestID string
+	cancel    context.CancelCauseFunc
+	// responded is set once the result is sent; only the first of the
+	// handler's result and a cancellation reaches the runtime.
+	responded bool
+}
+
+// runningToolCalls tracks the session's running tool handlers by tool
+// call ID. The zero value is ready to use.
+type runningToolCalls struct {
+	mu    sync.Mutex
+	calls map[string]*runningToolCall
+}
+
+// start records a running call and returns it.
+func (r *runningToolCalls) start(toolCallID, requestID string, cancel context.CancelCauseFunc) *runningToolCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = make(map[string]*runningToolCall)
+	}
+	call := &runningToolCall{requestID: requestID, cancel: cancel}
+	r.calls[toolCallID] = call
+	return call
+}
+
+// finish forgets call once its handler has returned.
+func (r *runningToolCalls) finish(toolCallID string, call *runningToolCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls[toolCallID] == call {
+		delete(r.calls, toolCallID)
+	}
+}
+
+// claim reports whether the caller may send call's result, and marks it
+// as sent.
+func (r *runningToolCalls) claim(call *runningToolCall) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if call.responded {
+		return false
+	}
+	call.responded = true
+	return true
+}
+
+// claimByID claims the result of the running call with toolCallID, as
+// claim does, and returns the call; it returns nil if there is no such call
+// or its result has been sent.
+func (r *runningToolCalls) claimByID(toolCallID string) *runningToolCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	call := r.calls[toolCallID]
+	if call == nil || call.responded {
+		return nil
+	}
+	call.responded = true
+	return call
+}
+
+// claimAll claims the results of all running calls, as claim does, and
+// returns the calls.
+func (r *runningToolCalls) claimAll() []*runningToolCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []*runningToolCall
+	for _, call := range r.calls {
+		if !call.responded {
+			call.responded = true
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// respondToolCall sends the result of call to the runtime unless it has
+// already been sent.
+func (s *Session) respondToolCall(ctx context.Context, call *runningToolCall, req *rpc.HandlePendingToolCallRequest) {
+	if s.runningTools.claim(call) {
+		s.conn.Load().rpc.Tools.HandlePendingToolCall(ctx, req)
+	}
+}
+
+// CancelToolCall aborts one running tool call without interrupting the
+// rest of the turn. The handler's context (ToolInvocation.TraceContext) is
+// cancelled with cause [ErrToolCallCancelled], and the model is immediately
+// given a failed result saying the call was cancelled; whatever the handler
+// returns afterwards is discarded. Other tool calls and the turn continue.
+//
+// toolCallID comes from the tool.execution_start event or
+// ToolInvocation.ToolCallID. Only handlers running in this client can be
+// cancelled; CancelToolCall returns [ErrToolCallNotRunning] for any other
+// call, including one that has already completed.
+//
+// Example:
+//
+//	session.On(func(event copilot.SessionEvent) {
+//	    if d, ok := event.Data.(*copilot.ToolExecutionStartData); ok && d.ToolName == "crawl_site" {
+//	        time.AfterFunc(30*time.Second, func() {
+//	            session.CancelToolCall(context.Background(), d.ToolCallID)
+//	        })
+//	    }
+//	})
+func (s *Session) CancelToolCall(ctx context.Context, toolCallID string) error {
+	call := s.runningTools.claimByID(toolCallID)
+	if call == nil {
+		return fmt.Errorf("%w: %s", ErrToolCallNotRunning, toolCallID)
+	}
+	call.cancel(ErrToolCallCancelled)
+	resultType := "failure"
+	errMsg := ErrToolCallCancelled.Error()
+	_, err := s.conn.Load().rpc.Tools.HandlePendingToolCall(ctx, &rpc.HandlePendingToolCallRequest{
+		RequestID: call.requestID,
+		Result: &rpc.ExternalToolTextResultForLlm{
+			TextResultForLlm: cancelledToolResultText,
+			ResultType:       &resultType,
+			Error:            &errMsg,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel tool call: %w", err)
+	}
+	return nil
+}
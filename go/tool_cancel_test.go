@@ -0,0 +1,75 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_CancelToolCall(t *testing.T) {
+	requests := make(chan map[string]any, 4)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.tools.handlePendingToolCall": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			requests <- req
+			return []byte(`{"success":true}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+	started := make(chan struct{}, 1)
+	causes := make(chan error, 1)
+	session.registerTools([]Tool{{
+		Name: "crawl",
+		Handler: func(inv ToolInvocation) (ToolResult, error) {
+			started <- struct{}{}
+			<-inv.TraceContext.Done()
+			causes <- context.Cause(inv.TraceContext)
+			return ToolResult{TextResultForLLM: "partial crawl"}, nil
+		},
+	}})
+	next := func() map[string]any {
+		t.Helper()
+		select {
+		case req := <-requests:
+			return req
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the tool result")
+			return nil
+		}
+	}
+
+	t.Run("cancels the handler and reports a cancelled result once", func(t *testing.T) {
+		session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "crawl"}})
+		<-started
+
+		if err := session.CancelToolCall(t.Context(), "c1"); err != nil {
+			t.Fatal(err)
+		}
+		if cause := <-causes; !errors.Is(cause, ErrToolCallCancelled) {
+			t.Errorf("expected the handler context to be cancelled with ErrToolCallCancelled, got %v", cause)
+		}
+		req := next()
+		result, _ := req["result"].(map[string]any)
+		if req["requestId"] != "r1" || result["resultType"] != "failure" || result["textResultForLlm"] != cancelledToolResultText {
+			t.Errorf("expected a cancelled result for r1, got %v", req)
+		}
+		session.WaitIdle(t.Context())
+		select {
+		case req := <-requests:
+			t.Errorf("expected the handler's late result to be discarded, got %v", req)
+		default:
+		}
+	})
+
+	t.Run("returns ErrToolCallNotRunning for unknown or finished calls", func(t *testing.T) {
+		if err := session.CancelToolCall(t.Context(), "c1"); !errors.Is(err, ErrToolCallNotRunning) {
+			t.Errorf("expected ErrToolCallNotRunning, got %v", err)
+		}
+	})
+}
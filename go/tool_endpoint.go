@@ -0,0 +1,184 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ToolAuth authenticates requests to a [Tool.Endpoint].
+type ToolAuth struct {
+	// BearerToken is sent in the Authorization header.
+	BearerToken string
+	// Headers are added to every request.
+	Headers http.Header
+	// HMACSecret, when set, signs each request body. See the
+	// X-Copilot-Signature header in the protocol of [Tool.Endpoint].
+	HMACSecret []byte
+}
+
+// ToolEndpointOptions tunes delivery to a [Tool.Endpoint].
+type ToolEndpointOptions struct {
+	// Timeout bounds each attempt. Defaults to 30s.
+	Timeout time.Duration
+	// MaxRetries is the number of retries after a failed attempt. Defaults
+	// to 2; set to -1 to disable retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each
+	// subsequent retry. Defaults to 500ms.
+	RetryBackoff time.Duration
+	// HTTPClient sends the requests. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// ToolEndpointError is returned when a [Tool.Endpoint] responds with a non-2xx
+// status.
+type ToolEndpointError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ToolEndpointError) Error() string {
+	return fmt.Sprintf("tool endpoint returned %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *ToolEndpointError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+type toolEndpointRequest struct {
+	SessionID  string `json:"sessionId"`
+	ToolCallID string `json:"toolCallId"`
+	ToolName   string `json:"toolName"`
+	Arguments  any    `json:"arguments"`
+}
+
+// maxToolEndpointErrorBody caps how much of an error response is kept.
+const maxToolEndpointErrorBody = 4096
+
+// endpointToolHandler returns a handler that forwards invocations to
+// tool.Endpoint, with the invocation's trace context injected by propagator.
+func endpointToolHandler(tool Tool, propagator TracePropagator) ToolHandler {
+	opts := ToolEndpointOptions{}
+	if tool.EndpointOptions != nil {
+		opts = *tool.EndpointOptions
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 2
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 500 * time.Millisecond
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	return func(inv ToolInvocation) (ToolResult, error) {
+		ctx := inv.TraceContext
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		body, err := json.Marshal(toolEndpointRequest{
+			SessionID:  inv.SessionID,
+			ToolCallID: inv.ToolCallID,
+			ToolName:   inv.ToolName,
+			Arguments:  inv.Arguments,
+		})
+		if err != nil {
+			return ToolResult{}, fmt.Errorf("failed to encode tool call: %w", err)
+		}
+
+		backoff := opts.RetryBackoff
+		for attempt := 0; ; attempt++ {
+			result, err := postToolCall(ctx, tool.Endpoint, tool.Auth, opts, propagator, inv.ToolCallID, body)
+			if err == nil {
+				return result, nil
+			}
+			var statusErr *ToolEndpointError
+			retryable := !errors.As(err, &statusErr) || statusErr.retryable()
+			if !retryable || attempt >= opts.MaxRetries || ctx.Err() != nil {
+				return ToolResult{}, fmt.Errorf("failed to call tool endpoint for %s: %w", tool.Name, err)
+			}
+			select {
+			case <-ctx.Done():
+				return ToolResult{}, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+func postToolCall(ctx context.Context, endpoint string, auth *ToolAuth, opts ToolEndpointOptions, propagator TracePropagator, toolCallID string, body []byte) (ToolResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return ToolResult{}, err
+	}
+	if auth != nil {
+		for name, values := range auth.Headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if auth.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+auth.BearerToken)
+		}
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Copilot-Tool-Call-Id", toolCallID)
+	req.Header.Set("X-Copilot-Timestamp", timestamp)
+	if auth != nil && len(auth.HMACSecret) > 0 {
+		req.Header.Set("X-Copilot-Signature", "sha256="+signToolCall(auth.HMACSecret, timestamp, body))
+	}
+	if traceparent, tracestate := getTraceContext(propagator, ctx); traceparent != "" {
+		req.Header.Set("traceparent", traceparent)
+		if tracestate != "" {
+			req.Header.Set("tracestate", tracestate)
+		}
+	}
+
+	resp, err := opts.HTTPClient.Do(req)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxToolEndpointErrorBody))
+		return ToolResult{}, &ToolEndpointError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	var result ToolResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ToolResult{}, fmt.Errorf("invalid tool result from endpoint: %w", err)
+	}
+	if result.ResultType == "" {
+		result.ResultType = "success"
+	}
+	return result, nil
+}
+
+// signToolCall computes the X-Copilot-Signature value (without its "sha256="
+// prefix) for a request body.
+func signToolCall(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,140 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpointToolHandler(t *testing.T) {
+	invocation := ToolInvocation{
+		SessionID:  "s1",
+		ToolCallID: "call-1",
+		ToolName:   "lookup",
+		Arguments:  map[string]any{"id": "42"},
+	}
+	fastRetries := &ToolEndpointOptions{RetryBackoff: time.Millisecond}
+
+	t.Run("posts the invocation with auth and a signature", func(t *testing.T) {
+		secret := []byte("shh")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+				t.Errorf("unexpected Authorization header %q", got)
+			}
+			if got := r.Header.Get("X-Tenant"); got != "acme" {
+				t.Errorf("unexpected X-Tenant header %q", got)
+			}
+			if got := r.Header.Get("X-Copilot-Tool-Call-Id"); got != "call-1" {
+				t.Errorf("unexpected tool call ID header %q", got)
+			}
+			want := "sha256=" + signToolCall(secret, r.Header.Get("X-Copilot-Timestamp"), body)
+			if got := r.Header.Get("X-Copilot-Signature"); got != want {
+				t.Errorf("signature mismatch: got %q, want %q", got, want)
+			}
+			var req toolEndpointRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatal(err)
+			}
+			if req.SessionID != "s1" || req.ToolName != "lookup" || req.Arguments.(map[string]any)["id"] != "42" {
+				t.Errorf("unexpected request body: %s", body)
+			}
+			w.Write([]byte(`{"textResultForLlm":"order 42 shipped"}`))
+		}))
+		defer server.Close()
+
+		handler := endpointToolHandler(Tool{
+			Name:     "lookup",
+			Endpoint: server.URL,
+			Auth: &ToolAuth{
+				BearerToken: "tok",
+				Headers:     http.Header{"X-Tenant": []string{"acme"}},
+				HMACSecret:  secret,
+			},
+		}, nil)
+		result, err := handler(invocation)
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if result.TextResultForLLM != "order 42 shipped" || result.ResultType != "success" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"textResultForLlm":"ok","resultType":"success"}`))
+		}))
+		defer server.Close()
+
+		handler := endpointToolHandler(Tool{Name: "lookup", Endpoint: server.URL, EndpointOptions: fastRetries}, nil)
+		if _, err := handler(invocation); err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d", calls.Load())
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.Error(w, "bad arguments", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		handler := endpointToolHandler(Tool{Name: "lookup", Endpoint: server.URL, EndpointOptions: fastRetries}, nil)
+		_, err := handler(invocation)
+		var endpointErr *ToolEndpointError
+		if !errors.As(err, &endpointErr) || endpointErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected ToolEndpointError with status 400, got %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected a single attempt, got %d", calls.Load())
+		}
+	})
+
+	t.Run("times out slow endpoints", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		handler := endpointToolHandler(Tool{Name: "lookup", Endpoint: server.URL, EndpointOptions: &ToolEndpointOptions{
+			Timeout:    20 * time.Millisecond,
+			MaxRetries: -1,
+		}}, nil)
+		if _, err := handler(invocation); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("registers a handler for endpoint tools", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		session.registerTools([]Tool{{Name: "remote", Endpoint: "https://tools.example/remote"}, {Name: "declared"}})
+		if _, ok := session.getToolHandler("remote"); !ok {
+			t.Error("expected a handler for the endpoint tool")
+		}
+		if _, ok := session.getToolHandler("declared"); ok {
+			t.Error("expected no handler for a declaration-only tool")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package copilot
+
+import (
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type ToolOutputDeltaData = rpc.ToolOutputDeltaData
+
+const SessionEventTypeToolOutputDelta = rpc.SessionEventTypeToolOutputDelta
+
+// shellTools are the built-in tools whose partial results are terminal output.
+var shellTools = map[string]bool{
+	"bash":       true,
+	"powershell": true,
+}
+
+// runningShellTool is a shell tool call that has started but not completed.
+type runningShellTool struct {
+	toolName string
+	offset   int
+}
+
+// toolOutputTracker pairs shell tool starts with their partial results.
+// While a shell tool runs, the runtime reports its output incrementally as
+// tool.execution_partial_result events. The tracker re-delivers those chunks
+// as synthesized tool.output_delta events that name the tool and track the
+// output offset, so UIs can render live command output without pairing
+// partial results with tool starts themselves. It is only accessed from the
+// session's event consumer goroutine and needs no locking.
+type toolOutputTracker struct {
+	running map[string]*runningShellTool
+}
+
+// observe records event and returns the tool.output_delta event it produces,
+// if any.
+func (t *toolOutputTracker) observe(event SessionEvent) *SessionEvent {
+	switch d := event.Data.(type) {
+	case *ToolExecutionStartData:
+		if d.MCPServerName != nil || (d.ShellToolInfo == nil && !shellTools[d.ToolName]) {
+			return nil
+		}
+		if t.running == nil {
+			t.running = make(map[string]*runningShellTool)
+		}
+		t.running[d.ToolCallID] = &runningShellTool{toolName: d.ToolName}
+	case *ToolExecutionPartialResultData:
+		tool, ok := t.running[d.ToolCallID]
+		if !ok || d.PartialOutput == "" {
+			return nil
+		}
+		data := &ToolOutputDeltaData{
+			ToolCallID: d.ToolCallID,
+			ToolName:   tool.toolName,
+			Delta:      d.PartialOutput,
+			Offset:     tool.offset,
+		}
+		tool.offset += len(d.PartialOutput)
+		ephemeral := true
+		parentID := event.ID
+		return &SessionEvent{
+			AgentID:   event.AgentID,
+			Data:      data,
+			Ephemeral: &ephemeral,
+			ID:        uuid.NewString(),
+			ParentID:  &parentID,
+			Timestamp: event.Timestamp,
+		}
+	case *ToolExecutionCompleteData:
+		delete(t.running, d.ToolCallID)
+	}
+	return nil
+}
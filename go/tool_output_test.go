@@ -0,0 +1,46 @@
+package copilot
+
+import "testing"
+
+func TestSession_ToolOutputDeltaEvents(t *testing.T) {
+	session, cleanup := newTestSession()
+	defer cleanup()
+	received := make(chan SessionEvent, 16)
+	session.On(func(event SessionEvent) { received <- event })
+
+	partial := func(toolCallID, output string) SessionEvent {
+		return SessionEvent{ID: "partial-" + output, Data: &ToolExecutionPartialResultData{ToolCallID: toolCallID, PartialOutput: output}}
+	}
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c1", ToolName: "bash"}})
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c2", ToolName: "view"}})
+	session.dispatchEvent(partial("c1", "\x1b[32mok\x1b[0m\n"))
+	session.dispatchEvent(partial("c2", "file contents"))
+	session.dispatchEvent(partial("c1", "done\n"))
+	session.dispatchEvent(SessionEvent{Data: &ToolExecutionCompleteData{ToolCallID: "c1", Success: true}})
+	session.dispatchEvent(partial("c1", "late"))
+	session.dispatchEvent(newTestEvent())
+
+	var deltas []SessionEvent
+	for event := range received {
+		if event.Type() == SessionEventTypeSessionIdle {
+			break
+		}
+		if event.Type() == SessionEventTypeToolOutputDelta {
+			deltas = append(deltas, event)
+		}
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("expected 2 tool.output_delta events, got %d", len(deltas))
+	}
+	first := deltas[0].Data.(*ToolOutputDeltaData)
+	if first.Delta != "\x1b[32mok\x1b[0m\n" || first.ToolName != "bash" || first.Offset != 0 {
+		t.Errorf("unexpected first delta: %+v", first)
+	}
+	if deltas[0].ParentID == nil || *deltas[0].ParentID != "partial-\x1b[32mok\x1b[0m\n" || deltas[0].Ephemeral == nil || !*deltas[0].Ephemeral {
+		t.Errorf("unexpected envelope: %+v", deltas[0])
+	}
+	second := deltas[1].Data.(*ToolOutputDeltaData)
+	if second.Delta != "done\n" || second.Offset != len("\x1b[32mok\x1b[0m\n") {
+		t.Errorf("unexpected second delta: %+v", second)
+	}
+}
@@ -0,0 +1,56 @@
+package copilot
+
+import (
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type ToolProgressData = rpc.ToolProgressData
+
+const SessionEventTypeToolProgress = rpc.SessionEventTypeToolProgress
+
+// ReportProgress tells session handlers how a long-running tool call is
+// getting on. Each call is delivered as a tool.progress event carrying
+// message and pct, the completion percentage from 0 to 100; pass a negative
+// pct when the tool cannot estimate it. Progress is for the host's UI only
+// and is not sent to the model.
+//
+// It is a no-op for invocations not made by a session, such as those built
+// in tests.
+//
+// Example:
+//
+//	copilot.DefineTool("build", "Build the project",
+//	    func(params BuildParams, inv copilot.ToolInvocation) (string, error) {
+//	        for i, pkg := range params.Packages {
+//	            inv.ReportProgress("building "+pkg, float64(i)*100/float64(len(params.Packages)))
+//	            // ...
+//	        }
+//	        return "ok", nil
+//	    })
+func (inv ToolInvocation) ReportProgress(message string, pct float64) {
+	if progress := inv.sessionScope().progress; progress != nil {
+		progress(message, pct)
+	}
+}
+
+// toolProgressReporter returns the ReportProgress implementation for a tool
+// call in s.
+func (s *Session) toolProgressReporter(toolCallID, toolName string) func(string, float64) {
+	return func(message string, pct float64) {
+		data := &ToolProgressData{ToolCallID: toolCallID, ToolName: toolName, Message: message}
+		if pct >= 0 {
+			pct = min(pct, 100)
+			data.Percent = &pct
+		}
+		ephemeral := true
+		s.dispatchEvent(SessionEvent{
+			Data:      data,
+			Ephemeral: &ephemeral,
+			ID:        uuid.NewString(),
+			Timestamp: time.Now(),
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package copilot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToolInvocation_ReportProgress(t *testing.T) {
+	t.Run("delivers progress as tool.progress events", func(t *testing.T) {
+		session, cleanup := newTestSession()
+		defer cleanup()
+		events := make(chan *ToolProgressData, 4)
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*ToolProgressData); ok {
+				if event.Ephemeral == nil || !*event.Ephemeral {
+					t.Error("expected progress events to be ephemeral")
+				}
+				events <- d
+			}
+		})
+
+		inv := ToolInvocation{ToolCallID: "c1", ToolName: "build", scope: &toolCallScope{progress: session.toolProgressReporter("c1", "build")}}
+		inv.ReportProgress("compiling", 40)
+		inv.ReportProgress("linking", -1)
+		inv.ReportProgress("done", 250)
+
+		want := []struct {
+			message string
+			percent *float64
+		}{{"compiling", ptr(40.0)}, {"linking", nil}, {"done", ptr(100.0)}}
+		for _, w := range want {
+			select {
+			case d := <-events:
+				if d.ToolCallID != "c1" || d.ToolName != "build" || d.Message != w.message {
+					t.Errorf("unexpected event: %+v", d)
+				}
+				if (d.Percent == nil) != (w.percent == nil) || (d.Percent != nil && *d.Percent != *w.percent) {
+					t.Errorf("%s: expected percent %v, got %v", w.message, w.percent, d.Percent)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %q", w.message)
+			}
+		}
+	})
+
+	t.Run("is a no-op outside a session", func(t *testing.T) {
+		ToolInvocation{ToolCallID: "c1"}.ReportProgress("working", 50)
+	})
+}
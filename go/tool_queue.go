@@ -0,0 +1,291 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ToolQueue is a minimal message-queue abstraction for executing tools on a
+// fleet of workers. Implement it on top of NATS, RabbitMQ, or similar.
+//
+// Subscribe must deliver each message on a job subject to a single
+// subscriber (NATS queue groups, RabbitMQ competing consumers), so a job is
+// handled by one worker.
+type ToolQueue interface {
+	// Publish sends data on subject.
+	Publish(ctx context.Context, subject string, data []byte) error
+	// Subscribe calls handler for each message on subject until the returned
+	// function is called.
+	Subscribe(subject string, handler func(data []byte)) (unsubscribe func() error, err error)
+}
+
+// ToolJob is the message published for a tool invocation.
+type ToolJob struct {
+	// CorrelationID identifies the job; replies must echo it.
+	CorrelationID string `json:"correlationId"`
+	// ReplyTo is the subject the worker publishes its [ToolJobReply] to.
+	ReplyTo string `json:"replyTo"`
+	// Deadline is when the SDK stops waiting for a reply. Workers should
+	// drop jobs whose deadline has passed instead of executing them.
+	Deadline   time.Time `json:"deadline"`
+	SessionID  string    `json:"sessionId"`
+	ToolCallID string    `json:"toolCallId"`
+	ToolName   string    `json:"toolName"`
+	Arguments  any       `json:"arguments"`
+}
+
+// ToolJobReply is the message a worker publishes after executing a [ToolJob].
+type ToolJobReply struct {
+	CorrelationID string      `json:"correlationId"`
+	Result        *ToolResult `json:"result,omitempty"`
+	// Error is set when the tool failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ToolQueueOptions configures a [ToolQueueDispatcher].
+type ToolQueueOptions struct {
+	// SubjectPrefix is prepended to the tool name to form the job subject
+	// ("<prefix>.<tool>"). Defaults to "copilot.tools".
+	SubjectPrefix string
+	// ReplySubject is the subject this dispatcher receives replies on. It
+	// must be unique per dispatcher. Defaults to "copilot.replies.<uuid>".
+	ReplySubject string
+	// Timeout is how long to wait for a reply. Defaults to 60s.
+	Timeout time.Duration
+}
+
+// ErrToolJobTimeout is returned when no worker replies before the job deadline.
+var ErrToolJobTimeout = errors.New("timed out waiting for tool worker")
+
+// ToolQueueDispatcher executes tools by publishing jobs to a [ToolQueue] and
+// waiting for a worker's reply.
+//
+// Each invocation is published exactly once and the first reply with a
+// matching correlation ID wins; duplicate or late replies are dropped. Jobs
+// are never republished, so a tool runs at most once even if a reply is lost.
+//
+// Example:
+//
+//	dispatcher, err := copilot.NewToolQueueDispatcher(queue, copilot.ToolQueueOptions{Timeout: 2 * time.Minute})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer dispatcher.Close()
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Tools: []copilot.Tool{dispatcher.Tool(copilot.Tool{Name: "render_video", Parameters: schema})},
+//	})
+type ToolQueueDispatcher struct {
+	queue       ToolQueue
+	opts        ToolQueueOptions
+	unsubscribe func() error
+
+	mu      sync.Mutex
+	pending map[string]chan ToolJobReply
+	closed  bool
+}
+
+// NewToolQueueDispatcher subscribes to the reply subject and returns a
+// dispatcher. Call [ToolQueueDispatcher.Close] to unsubscribe.
+func NewToolQueueDispatcher(queue ToolQueue, opts ToolQueueOptions) (*ToolQueueDispatcher, error) {
+	if opts.SubjectPrefix == "" {
+		opts.SubjectPrefix = "copilot.tools"
+	}
+	if opts.ReplySubject == "" {
+		opts.ReplySubject = "copilot.replies." + uuid.NewString()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 60 * time.Second
+	}
+	d := &ToolQueueDispatcher{queue: queue, opts: opts, pending: make(map[string]chan ToolJobReply)}
+	unsubscribe, err := queue.Subscribe(opts.ReplySubject, d.handleReply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", opts.ReplySubject, err)
+	}
+	d.unsubscribe = unsubscribe
+	return d, nil
+}
+
+// Tool returns tool with a Handler that dispatches invocations to workers.
+func (d *ToolQueueDispatcher) Tool(tool Tool) Tool {
+	subject := d.opts.SubjectPrefix + "." + tool.Name
+	tool.Handler = func(inv ToolInvocation) (ToolResult, error) {
+		ctx := inv.TraceContext
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return d.dispatch(ctx, subject, inv)
+	}
+	return tool
+}
+
+// Close unsubscribes from replies. In-flight invocations fail.
+func (d *ToolQueueDispatcher) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for id, ch := range d.pending {
+		close(ch)
+		delete(d.pending, id)
+	}
+	d.mu.Unlock()
+	return d.unsubscribe()
+}
+
+func (d *ToolQueueDispatcher) dispatch(ctx context.Context, subject string, inv ToolInvocation) (ToolResult, error) {
+	deadline := time.Now().Add(d.opts.Timeout)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	job := ToolJob{
+		CorrelationID: uuid.NewString(),
+		ReplyTo:       d.opts.ReplySubject,
+		Deadline:      deadline,
+		SessionID:     inv.SessionID,
+		ToolCallID:    inv.ToolCallID,
+		ToolName:      inv.ToolName,
+		Arguments:     inv.Arguments,
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("failed to encode tool job: %w", err)
+	}
+
+	replies := make(chan ToolJobReply, 1)
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return ToolResult{}, errors.New("tool queue dispatcher is closed")
+	}
+	d.pending[job.CorrelationID] = replies
+	d.mu.Unlock()
+	defer d.forget(job.CorrelationID)
+
+	if err := d.queue.Publish(ctx, subject, data); err != nil {
+		return ToolResult{}, fmt.Errorf("failed to publish tool job to %s: %w", subject, err)
+	}
+
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return ToolResult{}, errors.New("tool queue dispatcher is closed")
+		}
+		if reply.Error != "" {
+			return ToolResult{}, errors.New(reply.Error)
+		}
+		if reply.Result == nil {
+			return ToolResult{}, fmt.Errorf("tool worker replied without a result for %s", inv.ToolName)
+		}
+		result := *reply.Result
+		if result.ResultType == "" {
+			result.ResultType = "success"
+		}
+		return result, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ToolResult{}, fmt.Errorf("%w after %s (tool %s)", ErrToolJobTimeout, d.opts.Timeout, inv.ToolName)
+		}
+		return ToolResult{}, ctx.Err()
+	}
+}
+
+func (d *ToolQueueDispatcher) forget(correlationID string) {
+	d.mu.Lock()
+	delete(d.pending, correlationID)
+	d.mu.Unlock()
+}
+
+func (d *ToolQueueDispatcher) handleReply(data []byte) {
+	var reply ToolJobReply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return
+	}
+	d.mu.Lock()
+	ch, ok := d.pending[reply.CorrelationID]
+	if ok {
+		// First reply wins; later duplicates find no pending entry.
+		delete(d.pending, reply.CorrelationID)
+	}
+	d.mu.Unlock()
+	if ok {
+		ch <- reply
+	}
+}
+
+// ServeToolQueue runs tools as a queue worker: it executes jobs published by
+// a [ToolQueueDispatcher] with the matching SubjectPrefix and publishes the
+// replies. Jobs whose deadline has passed are dropped without running. It
+// blocks until ctx is done.
+//
+// Example:
+//
+//	err := copilot.ServeToolQueue(ctx, queue, "copilot.tools", []copilot.Tool{renderVideoTool})
+func ServeToolQueue(ctx context.Context, queue ToolQueue, subjectPrefix string, tools []Tool) error {
+	if subjectPrefix == "" {
+		subjectPrefix = "copilot.tools"
+	}
+	var unsubscribes []func() error
+	defer func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}()
+	for _, tool := range tools {
+		if tool.Handler == nil {
+			return fmt.Errorf("tool %s has no handler", tool.Name)
+		}
+		unsubscribe, err := queue.Subscribe(subjectPrefix+"."+tool.Name, func(data []byte) {
+			runToolJob(ctx, queue, tool.Handler, data)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to tool %s: %w", tool.Name, err)
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func runToolJob(ctx context.Context, queue ToolQueue, handler ToolHandler, data []byte) {
+	var job ToolJob
+	if err := json.Unmarshal(data, &job); err != nil || job.ReplyTo == "" {
+		return
+	}
+	if !job.Deadline.IsZero() && time.Now().After(job.Deadline) {
+		return
+	}
+
+	reply := ToolJobReply{CorrelationID: job.CorrelationID}
+	result, err := func() (result ToolResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("tool panicked: %v", r)
+			}
+		}()
+		return handler(ToolInvocation{
+			SessionID:    job.SessionID,
+			ToolCallID:   job.ToolCallID,
+			ToolName:     job.ToolName,
+			Arguments:    job.Arguments,
+			TraceContext: ctx,
+		})
+	}()
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Result = &result
+	}
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	queue.Publish(ctx, job.ReplyTo, payload)
+}
@@ -0,0 +1,199 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryToolQueue delivers messages to subscribers synchronously on a
+// goroutine per publish.
+type memoryToolQueue struct {
+	mu     sync.Mutex
+	subs   map[string]map[int]func([]byte)
+	nextID int
+	// drop, when set, swallows messages on matching subjects.
+	drop func(subject string) bool
+}
+
+func newMemoryToolQueue() *memoryToolQueue {
+	return &memoryToolQueue{subs: make(map[string]map[int]func([]byte))}
+}
+
+func (q *memoryToolQueue) Publish(_ context.Context, subject string, data []byte) error {
+	q.mu.Lock()
+	if q.drop != nil && q.drop(subject) {
+		q.mu.Unlock()
+		return nil
+	}
+	var handlers []func([]byte)
+	for _, h := range q.subs[subject] {
+		handlers = append(handlers, h)
+	}
+	q.mu.Unlock()
+	for _, h := range handlers {
+		go h(data)
+	}
+	return nil
+}
+
+func (q *memoryToolQueue) Subscribe(subject string, handler func([]byte)) (func() error, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.subs[subject] == nil {
+		q.subs[subject] = make(map[int]func([]byte))
+	}
+	q.nextID++
+	id := q.nextID
+	q.subs[subject][id] = handler
+	return func() error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.subs[subject], id)
+		return nil
+	}, nil
+}
+
+func TestToolQueueDispatcher(t *testing.T) {
+	var runs atomic.Int32
+	echo := Tool{Name: "echo", Handler: func(inv ToolInvocation) (ToolResult, error) {
+		runs.Add(1)
+		args := inv.Arguments.(map[string]any)
+		if args["fail"] == true {
+			return ToolResult{}, errors.New("worker failed")
+		}
+		return ToolResult{TextResultForLLM: "echo " + args["text"].(string)}, nil
+	}}
+
+	startWorker := func(t *testing.T, queue ToolQueue) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- ServeToolQueue(ctx, queue, "", []Tool{echo}) }()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+		// Wait until the worker has subscribed.
+		for {
+			q := queue.(*memoryToolQueue)
+			q.mu.Lock()
+			n := len(q.subs["copilot.tools.echo"])
+			q.mu.Unlock()
+			if n > 0 {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("round-trips invocations through a worker", func(t *testing.T) {
+		queue := newMemoryToolQueue()
+		startWorker(t, queue)
+		dispatcher, err := NewToolQueueDispatcher(queue, ToolQueueOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dispatcher.Close()
+
+		tool := dispatcher.Tool(Tool{Name: "echo"})
+		result, err := tool.Handler(ToolInvocation{ToolName: "echo", Arguments: map[string]any{"text": "hi"}})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		if result.TextResultForLLM != "echo hi" || result.ResultType != "success" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+
+		_, err = tool.Handler(ToolInvocation{ToolName: "echo", Arguments: map[string]any{"fail": true}})
+		if err == nil || err.Error() != "worker failed" {
+			t.Errorf("expected worker error, got %v", err)
+		}
+	})
+
+	t.Run("times out without republishing", func(t *testing.T) {
+		queue := newMemoryToolQueue()
+		var published atomic.Int32
+		queue.drop = func(subject string) bool {
+			if subject == "copilot.tools.echo" {
+				published.Add(1)
+				return true
+			}
+			return false
+		}
+		dispatcher, err := NewToolQueueDispatcher(queue, ToolQueueOptions{Timeout: 20 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dispatcher.Close()
+
+		_, err = dispatcher.Tool(Tool{Name: "echo"}).Handler(ToolInvocation{ToolName: "echo", Arguments: map[string]any{}})
+		if !errors.Is(err, ErrToolJobTimeout) {
+			t.Fatalf("expected ErrToolJobTimeout, got %v", err)
+		}
+		if published.Load() != 1 {
+			t.Errorf("expected the job to be published once, got %d", published.Load())
+		}
+	})
+
+	t.Run("drops duplicate replies", func(t *testing.T) {
+		queue := newMemoryToolQueue()
+		dispatcher, err := NewToolQueueDispatcher(queue, ToolQueueOptions{ReplySubject: "replies"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dispatcher.Close()
+
+		replies := make(chan ToolJobReply, 1)
+		dispatcher.mu.Lock()
+		dispatcher.pending["job-1"] = replies
+		dispatcher.mu.Unlock()
+		dispatcher.handleReply([]byte(`{"correlationId":"job-1","result":{"textResultForLlm":"first"}}`))
+		dispatcher.handleReply([]byte(`{"correlationId":"job-1","result":{"textResultForLlm":"second"}}`))
+		if reply := <-replies; reply.Result.TextResultForLLM != "first" {
+			t.Errorf("expected the first reply to win, got %+v", reply.Result)
+		}
+		if len(replies) != 0 {
+			t.Error("expected the duplicate reply to be dropped")
+		}
+	})
+
+	t.Run("workers skip expired jobs", func(t *testing.T) {
+		queue := newMemoryToolQueue()
+		before := runs.Load()
+		runToolJob(t.Context(), queue, echo.Handler, []byte(`{"correlationId":"x","replyTo":"r","deadline":"2000-01-01T00:00:00Z","arguments":{"text":"late"}}`))
+		if runs.Load() != before {
+			t.Error("expected an expired job not to run")
+		}
+	})
+
+	t.Run("fails in-flight invocations on Close", func(t *testing.T) {
+		queue := newMemoryToolQueue()
+		queue.drop = func(string) bool { return true }
+		dispatcher, err := NewToolQueueDispatcher(queue, ToolQueueOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			_, err := dispatcher.Tool(Tool{Name: "echo"}).Handler(ToolInvocation{ToolName: "echo"})
+			done <- err
+		}()
+		for {
+			dispatcher.mu.Lock()
+			n := len(dispatcher.pending)
+			dispatcher.mu.Unlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		dispatcher.Close()
+		if err := <-done; err == nil || !strings.Contains(err.Error(), "closed") {
+			t.Errorf("expected closed error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package copilot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ToolResultContentType identifies the kind of a [ToolResultContent] block.
+type ToolResultContentType string
+
+const (
+	// ToolResultContentText is plain text.
+	ToolResultContentText ToolResultContentType = "text"
+	// ToolResultContentImage is an image the model can see.
+	ToolResultContentImage ToolResultContentType = "image"
+	// ToolResultContentAudio is an audio clip.
+	ToolResultContentAudio ToolResultContentType = "audio"
+	// ToolResultContentResource is a file whose contents are embedded in the
+	// result.
+	ToolResultContentResource ToolResultContentType = "resource"
+	// ToolResultContentResourceLink references a file by URI without
+	// embedding it.
+	ToolResultContentResourceLink ToolResultContentType = "resource_link"
+)
+
+// ToolResultContent is a content block of a [ToolResult], mirroring the
+// content an MCP tool result can carry. Build blocks with [TextContent],
+// [ImageContent], [AudioContent], [FileContent], [FileReference] and
+// [JSONContent].
+type ToolResultContent struct {
+	Type ToolResultContentType `json:"type"`
+	// Text is the text of a text block, or the contents of a text resource.
+	Text string `json:"text,omitempty"`
+	// Data is the raw bytes of an image, audio clip or binary resource. It
+	// is base64-encoded on the wire.
+	Data     []byte `json:"data,omitempty"`
+	MIMEType string `json:"mimeType,omitempty"`
+	// URI identifies a resource or resource link, e.g. "file:///tmp/a.png".
+	URI string `json:"uri,omitempty"`
+	// Name is the name of a resource link.
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TextContent returns a text block.
+func TextContent(text string) ToolResultContent {
+	return ToolResultContent{Type: ToolResultContentText, Text: text}
+}
+
+// ImageContent returns an image block, so a tool such as a screenshot tool
+// can show the model an image rather than describing it.
+//
+// Example:
+//
+//	png, err := takeScreenshot()
+//	if err != nil {
+//		return copilot.ToolResult{}, err
+//	}
+//	return copilot.ToolResult{
+//		TextResultForLLM: "Screenshot of the login page",
+//		Contents:         []copilot.ToolResultContent{copilot.ImageContent(png, "image/png")},
+//	}, nil
+func ImageContent(data []byte, mimeType string) ToolResultContent {
+	return ToolResultContent{Type: ToolResultContentImage, Data: data, MIMEType: mimeType}
+}
+
+// AudioContent returns an audio block.
+func AudioContent(data []byte, mimeType string) ToolResultContent {
+	return ToolResultContent{Type: ToolResultContentAudio, Data: data, MIMEType: mimeType}
+}
+
+// FileContent returns a resource block embedding a file's contents. Text
+// MIME types (text/*, application/json and similar) are embedded as text,
+// other content as binary.
+func FileContent(uri string, data []byte, mimeType string) ToolResultContent {
+	content := ToolResultContent{Type: ToolResultContentResource, URI: uri, MIMEType: mimeType}
+	if isTextMIMEType(mimeType) {
+		content.Text = string(data)
+	} else {
+		content.Data = data
+	}
+	return content
+}
+
+// FileReference returns a resource link block pointing the model at a file
+// without embedding it.
+func FileReference(uri, name, mimeType string) ToolResultContent {
+	return ToolResultContent{Type: ToolResultContentResourceLink, URI: uri, Name: name, MIMEType: mimeType}
+}
+
+// JSONContent returns a text block holding v encoded as indented JSON, for
+// structured payloads the model should read as data.
+func JSONContent(v any) (ToolResultContent, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ToolResultContent{}, fmt.Errorf("failed to encode JSON content: %w", err)
+	}
+	return ToolResultContent{Type: ToolResultContentText, Text: string(data), MIMEType: "application/json"}, nil
+}
+
+// isTextMIMEType reports whether content of mimeType is text.
+func isTextMIMEType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.TrimSpace(mimeType)
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" ||
+		strings.HasSuffix(mimeType, "+json") || mimeType == "application/xml" ||
+		strings.HasSuffix(mimeType, "+xml") || mimeType == "application/yaml"
+}
+
+// toRPC converts the block to its wire representation.
+func (c ToolResultContent) toRPC() (rpc.ExternalToolTextResultForLlmContent, error) {
+	switch c.Type {
+	case ToolResultContentText:
+		return rpc.ExternalToolTextResultForLlmContentText{Text: c.Text}, nil
+	case ToolResultContentImage:
+		return rpc.ExternalToolTextResultForLlmContentImage{Data: base64.StdEncoding.EncodeToString(c.Data), MIMEType: c.MIMEType}, nil
+	case ToolResultContentAudio:
+		return rpc.ExternalToolTextResultForLlmContentAudio{Data: base64.StdEncoding.EncodeToString(c.Data), MIMEType: c.MIMEType}, nil
+	case ToolResultContentResource:
+		if c.URI == "" {
+			return nil, fmt.Errorf("resource content requires a URI")
+		}
+		if c.Data != nil {
+			return rpc.ExternalToolTextResultForLlmContentResource{Resource: &rpc.EmbeddedBlobResourceContents{
+				Blob: base64.StdEncoding.EncodeToString(c.Data), MIMEType: optionalString(c.MIMEType), URI: c.URI,
+			}}, nil
+		}
+		return rpc.ExternalToolTextResultForLlmContentResource{Resource: &rpc.EmbeddedTextResourceContents{
+			Text: c.Text, MIMEType: optionalString(c.MIMEType), URI: c.URI,
+		}}, nil
+	case ToolResultContentResourceLink:
+		if c.URI == "" {
+			return nil, fmt.Errorf("resource link content requires a URI")
+		}
+		name := c.Name
+		if name == "" {
+			name = c.URI
+		}
+		return rpc.ExternalToolTextResultForLlmContentResourceLink{
+			URI: c.URI, Name: name, MIMEType: optionalString(c.MIMEType), Description: optionalString(c.Description),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown tool result content type %q", c.Type)
+	}
+}
+
+// toolResultToRPC converts a handler's result to its wire representation.
+func toolResultToRPC(result ToolResult) (*rpc.ExternalToolTextResultForLlm, error) {
+	textResultForLLM := result.TextResultForLLM
+	if textResultForLLM == "" {
+		if len(result.Contents) == 0 && len(result.BinaryResultsForLLM) == 0 {
+			textResultForLLM = fmt.Sprintf("%v", result)
+		} else {
+			// Rich results still need text for models that only read it.
+			var parts []string
+			for _, content := range result.Contents {
+				if content.Type == ToolResultContentText {
+					parts = append(parts, content.Text)
+				}
+			}
+			textResultForLLM = strings.Join(parts, "\n")
+		}
+	}
+
+	// Default ResultType to "success" when unset, or "failure" when there's an error.
+	effectiveResultType := result.ResultType
+	if effectiveResultType == "" {
+		if result.Error != "" {
+			effectiveResultType = "failure"
+		} else {
+			effectiveResultType = "success"
+		}
+	}
+
+	rpcResult := &rpc.ExternalToolTextResultForLlm{
+		TextResultForLlm: textResultForLLM,
+		ToolTelemetry:    result.ToolTelemetry,
+		ResultType:       &effectiveResultType,
+		ToolReferences:   result.ToolReferences,
+	}
+	if result.Error != "" {
+		rpcResult.Error = &result.Error
+	}
+	for _, binary := range result.BinaryResultsForLLM {
+		rpcResult.BinaryResultsForLlm = append(rpcResult.BinaryResultsForLlm, rpc.ExternalToolTextResultForLlmBinaryResultsForLlm{
+			Data:        binary.Data,
+			MIMEType:    binary.MIMEType,
+			Type:        rpc.ExternalToolTextResultForLlmBinaryResultsForLlmType(binary.Type),
+			Description: optionalString(binary.Description),
+		})
+	}
+	for i, content := range result.Contents {
+		block, err := content.toRPC()
+		if err != nil {
+			return nil, fmt.Errorf("invalid content block %d: %w", i, err)
+		}
+		rpcResult.Contents = append(rpcResult.Contents, block)
+	}
+	return rpcResult, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
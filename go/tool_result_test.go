@@ -0,0 +1,103 @@
+package copilot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestToolResultToRPC(t *testing.T) {
+	t.Run("encodes content blocks for the wire", func(t *testing.T) {
+		data, err := JSONContent(map[string]int{"passed": 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := toolResultToRPC(ToolResult{
+			TextResultForLLM: "Screenshot taken",
+			Contents: []ToolResultContent{
+				ImageContent([]byte("png"), "image/png"),
+				FileContent("file:///tmp/report.md", []byte("# Report"), "text/markdown"),
+				FileContent("file:///tmp/trace.bin", []byte{1, 2}, "application/octet-stream"),
+				FileReference("file:///tmp/big.log", "big.log", "text/plain"),
+				data,
+			},
+		})
+		if err != nil {
+			t.Fatalf("toolResultToRPC failed: %v", err)
+		}
+		encoded, err := json.Marshal(result.Contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `[{"type":"image","data":"cG5n","mimeType":"image/png"},` +
+			`{"type":"resource","resource":{"mimeType":"text/markdown","text":"# Report","uri":"file:///tmp/report.md"}},` +
+			`{"type":"resource","resource":{"blob":"AQI=","mimeType":"application/octet-stream","uri":"file:///tmp/trace.bin"}},` +
+			`{"type":"resource_link","mimeType":"text/plain","name":"big.log","uri":"file:///tmp/big.log"},` +
+			`{"type":"text","text":"{\n  \"passed\": 3\n}"}]`
+		if string(encoded) != want {
+			t.Errorf("got  %s\nwant %s", encoded, want)
+		}
+		if result.TextResultForLlm != "Screenshot taken" {
+			t.Errorf("unexpected text result %q", result.TextResultForLlm)
+		}
+	})
+
+	t.Run("derives the text result from text blocks", func(t *testing.T) {
+		result, err := toolResultToRPC(ToolResult{Contents: []ToolResultContent{
+			TextContent("line 1"),
+			ImageContent([]byte("png"), "image/png"),
+			TextContent("line 2"),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.TextResultForLlm != "line 1\nline 2" {
+			t.Errorf("unexpected text result %q", result.TextResultForLlm)
+		}
+	})
+
+	t.Run("rejects resources without a URI", func(t *testing.T) {
+		if _, err := toolResultToRPC(ToolResult{Contents: []ToolResultContent{{Type: ToolResultContentResource}}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestSession_RichToolResults(t *testing.T) {
+	requests := make(chan map[string]any, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.tools.handlePendingToolCall": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req map[string]any
+			json.Unmarshal(params, &req)
+			requests <- req
+			return []byte(`{"success":true}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+	session.registerTools([]Tool{{
+		Name: "screenshot",
+		Handler: func(ToolInvocation) (ToolResult, error) {
+			return ToolResult{
+				TextResultForLLM:    "Screenshot taken",
+				BinaryResultsForLLM: []ToolBinaryResult{{Data: "cG5n", MIMEType: "image/png", Type: "image"}},
+				Contents:            []ToolResultContent{ImageContent([]byte("png"), "image/png")},
+			}, nil
+		},
+	}})
+
+	session.dispatchEvent(SessionEvent{Data: &ExternalToolRequestedData{RequestID: "r1", ToolCallID: "c1", ToolName: "screenshot"}})
+	select {
+	case req := <-requests:
+		result, _ := req["result"].(map[string]any)
+		binaries, _ := result["binaryResultsForLlm"].([]any)
+		contents, _ := result["contents"].([]any)
+		if len(binaries) != 1 || len(contents) != 1 {
+			t.Errorf("expected the binary results and contents to reach the runtime, got %v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the tool result")
+	}
+}
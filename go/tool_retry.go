@@ -0,0 +1,106 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// ToolRetryPolicy retries failed executions of a tool's handler before the
+// failure is reported to the model. Set it on [Tool.RetryPolicy] for tools
+// whose failures are often transient, such as calls to a flaky API.
+type ToolRetryPolicy struct {
+	// MaxAttempts is the number of times the handler may run, including the
+	// first. Values below 2 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled on each
+	// subsequent retry. Defaults to 500ms.
+	Backoff time.Duration
+	// RetryOn reports whether a failed attempt is worth retrying. It is
+	// called with the handler's result and error whenever the handler
+	// returns an error or a result with ResultType "failure". Defaults to
+	// retrying errors but not failure results.
+	RetryOn func(result ToolResult, err error) bool
+}
+
+// toolAttemptsTelemetryKey is the [ToolResult.ToolTelemetry] key under which
+// the SDK records how many attempts a retried tool call took, so the count
+// appears in the tool.execution_complete event and the transcript.
+const toolAttemptsTelemetryKey = "attempts"
+
+// retryingToolHandler returns handler wrapped to retry according to policy.
+func retryingToolHandler(handler ToolHandler, policy ToolRetryPolicy) ToolHandler {
+	if policy.MaxAttempts < 2 {
+		return handler
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = 500 * time.Millisecond
+	}
+	if policy.RetryOn == nil {
+		policy.RetryOn = func(_ ToolResult, err error) bool { return err != nil }
+	}
+
+	return func(inv ToolInvocation) (ToolResult, error) {
+		ctx := inv.TraceContext
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var failures []string
+		backoff := policy.Backoff
+		for attempt := 1; ; attempt++ {
+			inv.Attempt = attempt
+			result, err := handler(inv)
+			failed := err != nil || result.ResultType == "failure"
+			if !failed || attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.RetryOn(result, err) {
+				if attempt == 1 {
+					return result, err
+				}
+				// The failure is what the model sees, so it is told the call
+				// was already retried. A handler error would reach the runtime
+				// without telemetry, so it is reported as a failure result.
+				retried := fmt.Sprintf("failed after %d attempts (%s)", attempt, strings.Join(failures, "; "))
+				if err != nil {
+					message := fmt.Sprintf("%s: %v", retried, err)
+					return withAttempts(ToolResult{TextResultForLLM: message, ResultType: "failure", Error: message}, attempt), nil
+				}
+				if failed {
+					result.Error = retried + ": " + attemptFailure(result, nil)
+				}
+				return withAttempts(result, attempt), nil
+			}
+			failures = append(failures, fmt.Sprintf("attempt %d: %s", attempt, attemptFailure(result, err)))
+			select {
+			case <-ctx.Done():
+				message := fmt.Sprintf("retry interrupted after %d attempts (%s): %v", attempt, strings.Join(failures, "; "), context.Cause(ctx))
+				return withAttempts(ToolResult{TextResultForLLM: message, ResultType: "failure", Error: message}, attempt), nil
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// withAttempts returns result with the number of attempts recorded in its
+// telemetry.
+func withAttempts(result ToolResult, attempts int) ToolResult {
+	result.ToolTelemetry = maps.Clone(result.ToolTelemetry)
+	if result.ToolTelemetry == nil {
+		result.ToolTelemetry = make(map[string]any)
+	}
+	result.ToolTelemetry[toolAttemptsTelemetryKey] = attempts
+	return result
+}
+
+// attemptFailure describes why an attempt failed.
+func attemptFailure(result ToolResult, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case result.Error != "":
+		return result.Error
+	default:
+		return "failure result"
+	}
+}
@@ -0,0 +1,86 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolRetryPolicy(t *testing.T) {
+	flaky := func(failures int, attempts *[]int) ToolHandler {
+		return func(inv ToolInvocation) (ToolResult, error) {
+			*attempts = append(*attempts, inv.Attempt)
+			if len(*attempts) <= failures {
+				return ToolResult{}, errors.New("connection reset")
+			}
+			return ToolResult{TextResultForLLM: "ok"}, nil
+		}
+	}
+
+	t.Run("retries errors and records the attempts in telemetry", func(t *testing.T) {
+		var attempts []int
+		handler := retryingToolHandler(flaky(2, &attempts), ToolRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+		result, err := handler(ToolInvocation{ToolName: "api"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+			t.Errorf("expected attempts 1..3, got %v", attempts)
+		}
+		if result.ToolTelemetry[toolAttemptsTelemetryKey] != 3 {
+			t.Errorf("expected 3 attempts in telemetry, got %v", result.ToolTelemetry)
+		}
+	})
+
+	t.Run("tells the model about earlier attempts when all fail", func(t *testing.T) {
+		var attempts []int
+		handler := retryingToolHandler(flaky(5, &attempts), ToolRetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+		result, err := handler(ToolInvocation{ToolName: "api"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ResultType != "failure" || !strings.Contains(result.Error, "failed after 2 attempts (attempt 1: connection reset): connection reset") {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if result.ToolTelemetry[toolAttemptsTelemetryKey] != 2 {
+			t.Errorf("expected 2 attempts in telemetry, got %v", result.ToolTelemetry)
+		}
+		if len(attempts) != 2 {
+			t.Errorf("expected 2 attempts, got %d", len(attempts))
+		}
+	})
+
+	t.Run("retries failure results only when RetryOn allows", func(t *testing.T) {
+		calls := 0
+		failing := func(ToolInvocation) (ToolResult, error) {
+			calls++
+			return ToolResult{ResultType: "failure", Error: "rate limited"}, nil
+		}
+		result, _ := retryingToolHandler(failing, ToolRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})(ToolInvocation{})
+		if calls != 1 || result.Error != "rate limited" {
+			t.Errorf("expected failure results not to be retried by default, got %d calls and %q", calls, result.Error)
+		}
+
+		calls = 0
+		retryOn := func(result ToolResult, err error) bool { return result.Error == "rate limited" }
+		result, _ = retryingToolHandler(failing, ToolRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, RetryOn: retryOn})(ToolInvocation{})
+		if calls != 3 || !strings.HasPrefix(result.Error, "failed after 3 attempts") {
+			t.Errorf("expected 3 calls and a retry summary, got %d calls and %q", calls, result.Error)
+		}
+		if result.ToolTelemetry[toolAttemptsTelemetryKey] != 3 {
+			t.Errorf("expected 3 attempts in telemetry, got %v", result.ToolTelemetry)
+		}
+	})
+
+	t.Run("stops retrying when the call is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(t.Context())
+		cancel(ErrToolCallCancelled)
+		var attempts []int
+		_, err := retryingToolHandler(flaky(5, &attempts), ToolRetryPolicy{MaxAttempts: 5, Backoff: time.Hour})(ToolInvocation{TraceContext: ctx})
+		if len(attempts) != 1 || err == nil {
+			t.Errorf("expected a single attempt and an error, got %d attempts and %v", len(attempts), err)
+		}
+	})
+}
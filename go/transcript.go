@@ -0,0 +1,209 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Transcript is a portable record of a session: its events in order plus a
+// summary of the tool calls they contain. It marshals to JSON, and
+// copilotpb provides protobuf equivalents for non-Go consumers.
+type Transcript struct {
+	SessionID  string           `json:"sessionId"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Events     []SessionEvent   `json:"events"`
+	ToolCalls  []ToolCallRecord `json:"toolCalls,omitempty"`
+	// Annotations are the session's turn annotations, keyed by turn ID.
+	// See [Session.Annotate].
+	Annotations map[string]map[string]string `json:"annotations,omitempty"`
+	// Generation is the sampling configuration the session was created or
+	// resumed with, including its seed. Pass it as
+	// [ResumeSessionConfig.Generation] to continue the session with it.
+	Generation *GenerationConfig `json:"generation,omitempty"`
+}
+
+// ToolCallRecord summarizes one tool invocation, joined from its
+// tool.execution_start and tool.execution_complete events.
+type ToolCallRecord struct {
+	ToolCallID    string    `json:"toolCallId"`
+	ToolName      string    `json:"toolName"`
+	MCPServerName string    `json:"mcpServerName,omitempty"`
+	TurnID        string    `json:"turnId,omitempty"`
+	Arguments     any       `json:"arguments,omitempty"`
+	StartedAt     time.Time `json:"startedAt"`
+	// CompletedAt is zero when the tool call never completed.
+	CompletedAt time.Time `json:"completedAt,omitzero"`
+	Success     bool      `json:"success"`
+	// Result is the result text sent to the model.
+	Result string `json:"result,omitempty"`
+	// Error and ErrorCode are set when the tool failed.
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+	// ResourceUsage is what the tool execution consumed, when measured.
+	ResourceUsage *ToolResourceUsage `json:"resourceUsage,omitempty"`
+}
+
+// NewTranscript builds a transcript from a session's events, such as those
+// returned by [Session.GetEvents].
+//
+// Example:
+//
+//	events, err := session.GetEvents(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	transcript := copilot.NewTranscript(session.SessionID, events)
+func NewTranscript(sessionID string, events []SessionEvent) Transcript {
+	return Transcript{
+		SessionID:  sessionID,
+		ExportedAt: time.Now().UTC(),
+		Events:     events,
+		ToolCalls:  CollectToolCalls(events),
+	}
+}
+
+// CollectToolCalls pairs tool execution start and complete events into
+// records, ordered by start.
+func CollectToolCalls(events []SessionEvent) []ToolCallRecord {
+	var records []ToolCallRecord
+	index := make(map[string]int)
+	for _, event := range events {
+		switch d := event.Data.(type) {
+		case *ToolExecutionStartData:
+			record := ToolCallRecord{
+				ToolCallID: d.ToolCallID,
+				ToolName:   d.ToolName,
+				Arguments:  d.Arguments,
+				StartedAt:  event.Timestamp,
+			}
+			if d.MCPServerName != nil {
+				record.MCPServerName = *d.MCPServerName
+			}
+			if d.TurnID != nil {
+				record.TurnID = *d.TurnID
+			}
+			index[d.ToolCallID] = len(records)
+			records = append(records, record)
+		case *ToolExecutionCompleteData:
+			i, ok := index[d.ToolCallID]
+			if !ok {
+				continue
+			}
+			record := &records[i]
+			record.CompletedAt = event.Timestamp
+			record.Success = d.Success
+			record.ResourceUsage = resourceUsageFromTelemetry(d.ToolTelemetry)
+			if d.Result != nil {
+				record.Result = d.Result.Content
+			}
+			if d.Error != nil {
+				record.Error = d.Error.Message
+				if d.Error.Code != nil {
+					record.ErrorCode = *d.Error.Code
+				}
+			}
+		}
+	}
+	return records
+}
+
+// Export returns a portable transcript of the session's persisted history:
+// messages, tool calls, compaction summaries, and every other event that
+// [Session.GetEvents] returns. Store it as JSON (or convert it with
+// copilotpb) and rehydrate it elsewhere with [Client.ImportSession].
+//
+// Example:
+//
+//	transcript, err := session.Export(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	data, _ := json.Marshal(transcript)
+//	db.SaveTranscript(session.SessionID, data)
+func (s *Session) Export(ctx context.Context) (*Transcript, error) {
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export session: %w", err)
+	}
+	transcript := NewTranscript(s.SessionID, events)
+	transcript.Generation = s.generation
+	transcript.Annotations, err = s.Annotations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export session: %w", err)
+	}
+	return &transcript, nil
+}
+
+// ImportSession writes transcript into the runtime's session store so the
+// session can be resumed with [Client.ResumeSession] under its original ID,
+// for example on a different machine than the one it was exported from.
+// Ephemeral events are skipped.
+//
+// The runtime must share a filesystem with this process, which is always
+// the case for a CLI started by the client. ImportSession refuses to
+// overwrite a session that already exists.
+//
+// Example:
+//
+//	var transcript copilot.Transcript
+//	if err := json.Unmarshal(data, &transcript); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := client.ImportSession(ctx, &transcript); err != nil {
+//	    log.Fatal(err)
+//	}
+//	session, err := client.ResumeSession(ctx, transcript.SessionID, &copilot.ResumeSessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	})
+func (c *Client) ImportSession(ctx context.Context, transcript *Transcript) error {
+	if transcript == nil || transcript.SessionID == "" {
+		return errors.New("transcript must have a session ID")
+	}
+	if len(transcript.Events) == 0 {
+		return errors.New("transcript has no events")
+	}
+	existing, err := c.GetSessionMetadata(ctx, transcript.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing session: %w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("session %s already exists", transcript.SessionID)
+	}
+
+	eventFile, err := c.sessionEventFilePath(ctx, transcript.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(eventFile), 0o700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	file, err := os.OpenFile(eventFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create session event log: %w", err)
+	}
+	encoder := json.NewEncoder(file)
+	for _, event := range transcript.Events {
+		if event.Ephemeral != nil && *event.Ephemeral {
+			continue
+		}
+		if err := encoder.Encode(event); err != nil {
+			file.Close()
+			os.Remove(eventFile)
+			return fmt.Errorf("failed to write event %s: %w", event.ID, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(eventFile)
+		return fmt.Errorf("failed to write session event log: %w", err)
+	}
+	if len(transcript.Annotations) > 0 {
+		return c.saveSessionAnnotations(ctx, transcript.SessionID, transcript.Annotations)
+	}
+	return nil
+}
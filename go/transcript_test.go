@@ -0,0 +1,146 @@
+package copilot
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestCollectToolCalls(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []SessionEvent{
+		{ID: "e1", Timestamp: start, Data: &ToolExecutionStartData{
+			ToolCallID: "call-1", ToolName: "grep", Arguments: map[string]any{"pattern": "TODO"}, TurnID: ptr("turn-1"),
+		}},
+		{ID: "e2", Timestamp: start.Add(time.Second), Data: &ToolExecutionStartData{ToolCallID: "call-2", ToolName: "bash"}},
+		{ID: "e3", Timestamp: start.Add(2 * time.Second), Data: &ToolExecutionCompleteData{
+			ToolCallID: "call-1", Success: true, Result: &ToolExecutionCompleteResult{Content: "3 matches"},
+		}},
+		{ID: "e4", Timestamp: start.Add(3 * time.Second), Data: &ToolExecutionCompleteData{
+			ToolCallID: "call-2", Error: &ToolExecutionCompleteError{Message: "denied", Code: ptr("permission")},
+		}},
+		{ID: "e5", Timestamp: start.Add(4 * time.Second), Data: &ToolExecutionCompleteData{ToolCallID: "unknown"}},
+	}
+
+	records := CollectToolCalls(events)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	first := records[0]
+	if first.ToolName != "grep" || first.TurnID != "turn-1" || !first.Success || first.Result != "3 matches" {
+		t.Errorf("unexpected first record: %+v", first)
+	}
+	if !first.StartedAt.Equal(start) || !first.CompletedAt.Equal(start.Add(2*time.Second)) {
+		t.Errorf("unexpected first record timestamps: %v - %v", first.StartedAt, first.CompletedAt)
+	}
+	second := records[1]
+	if second.Success || second.Error != "denied" || second.ErrorCode != "permission" {
+		t.Errorf("unexpected second record: %+v", second)
+	}
+}
+
+// startTestRuntime serves connect plus the given handlers on a unix
+// socket and returns a started client connected to it.
+func startTestRuntime(t *testing.T, handlers map[string]jsonrpc2.RequestHandler) *Client {
+	t.Helper()
+	return startTestRuntimeWithOptions(t, &ClientOptions{}, handlers)
+}
+
+// startTestRuntimeWithOptions is startTestRuntime with client options; the
+// connection is replaced by the fake runtime's socket.
+func startTestRuntimeWithOptions(t *testing.T, options *ClientOptions, handlers map[string]jsonrpc2.RequestHandler) *Client {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "rt.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		server := jsonrpc2.NewClient(conn, conn)
+		server.SetRequestHandler("connect", func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			return []byte(`{"ok":true,"protocolVersion":3,"version":"test"}`), nil
+		})
+		for method, handler := range handlers {
+			server.SetRequestHandler(method, handler)
+		}
+		server.Start()
+	}()
+
+	options.Connection = UnixSocketConnection{Path: socketPath}
+	client := NewClient(options)
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { client.Stop() })
+	return client
+}
+
+func TestClient_ImportSession(t *testing.T) {
+	var events []SessionEvent
+	if err := json.Unmarshal([]byte(`[
+		{"id": "e1", "parentId": null, "timestamp": "2026-01-01T00:00:00Z", "type": "user.message", "data": {"content": "hi"}},
+		{"id": "e2", "parentId": "e1", "timestamp": "2026-01-01T00:00:01Z", "ephemeral": true, "type": "assistant.message_delta", "data": {"messageId": "m1", "deltaContent": "he"}},
+		{"id": "e3", "parentId": "e1", "timestamp": "2026-01-01T00:00:02Z", "type": "assistant.message", "data": {"messageId": "m1", "content": "hello"}}
+	]`), &events); err != nil {
+		t.Fatalf("failed to unmarshal events: %v", err)
+	}
+	transcript := NewTranscript("s1", events)
+
+	t.Run("writes persisted events to the session store", func(t *testing.T) {
+		eventFile := filepath.Join(t.TempDir(), "session-state", "s1", "events.jsonl")
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.getMetadata": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{}`), nil
+			},
+			"sessions.getEventFilePath": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				result, _ := json.Marshal(map[string]string{"filePath": eventFile})
+				return result, nil
+			},
+		})
+
+		if err := client.ImportSession(t.Context(), &transcript); err != nil {
+			t.Fatalf("ImportSession failed: %v", err)
+		}
+		data, err := os.ReadFile(eventFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 persisted events, got %d:\n%s", len(lines), data)
+		}
+		var restored SessionEvent
+		if err := json.Unmarshal([]byte(lines[1]), &restored); err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := restored.Data.(*AssistantMessageData); !ok || d.Content != "hello" || restored.ID != "e3" {
+			t.Errorf("unexpected persisted event: %s", lines[1])
+		}
+
+		if err := client.ImportSession(t.Context(), &transcript); err == nil {
+			t.Error("expected importing over an existing event log to fail")
+		}
+	})
+
+	t.Run("refuses to overwrite an existing session", func(t *testing.T) {
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.getMetadata": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return []byte(`{"session":{"sessionId":"s1"}}`), nil
+			},
+		})
+		if err := client.ImportSession(t.Context(), &transcript); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("expected an already-exists error, got %v", err)
+		}
+	})
+}
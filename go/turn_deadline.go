@@ -0,0 +1,128 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTurnDeadlineExceeded is returned by [Session.SendAndWait] and
+// [Session.SendAndWaitResponse] when the turn was interrupted at the deadline
+// set by [MessageOptions.Deadline] or [MessageOptions.Timeout].
+var ErrTurnDeadlineExceeded = errors.New("turn deadline exceeded")
+
+// turnDeadline returns when the turn started by options must end, or the
+// zero time if it has no deadline.
+func (o *MessageOptions) turnDeadline(sent time.Time) time.Time {
+	deadline := o.Deadline
+	if o.Timeout > 0 {
+		if timeout := sent.Add(o.Timeout); deadline.IsZero() || timeout.Before(deadline) {
+			deadline = timeout
+		}
+	}
+	return deadline
+}
+
+// turnDeadline interrupts the running turn at its deadline.
+type turnDeadline struct {
+	mu    sync.Mutex
+	timer *time.Timer
+	// generation distinguishes sends, so a stale timer does not interrupt
+	// a later turn.
+	generation uint64
+}
+
+// begin arranges for onExpire to be called if the turn is still running at
+// deadline. The returned function stops the timer if the send fails.
+func (d *turnDeadline) begin(deadline time.Time, onExpire func()) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resetLocked()
+	generation := d.generation
+	d.timer = time.AfterFunc(time.Until(deadline), func() {
+		d.mu.Lock()
+		expired := d.generation == generation
+		if expired {
+			d.resetLocked()
+		}
+		d.mu.Unlock()
+		if expired {
+			onExpire()
+		}
+	})
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.generation == generation {
+			d.resetLocked()
+		}
+	}
+}
+
+// resetLocked stops the timer of the running turn, if any.
+func (d *turnDeadline) resetLocked() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.generation++
+	d.timer = nil
+}
+
+// observe stops the timer when the turn ends. Called only from
+// processEvents.
+func (d *turnDeadline) observe(event SessionEvent) {
+	switch event.Data.(type) {
+	case *SessionIdleData, *SessionErrorData:
+		d.mu.Lock()
+		if d.timer != nil {
+			d.resetLocked()
+		}
+		d.mu.Unlock()
+	}
+}
+
+// interruptTurn aborts the running turn at its deadline.
+func (s *Session) interruptTurn() {
+	s.logger().Warn("turn deadline exceeded; aborting turn")
+	go func() {
+		if err := s.Abort(context.Background()); err != nil {
+			s.logger().Error("failed to abort turn at its deadline", "error", err)
+		}
+	}()
+}
+
+// partialContent accumulates what the assistant has said in a turn, to
+// answer with if the turn is interrupted.
+type partialContent struct {
+	// message is the content of the last complete assistant message.
+	message string
+	// streaming is the text streamed so far for the message in progress,
+	// identified by streamingID.
+	streaming   strings.Builder
+	streamingID string
+}
+
+func (p *partialContent) observe(event SessionEvent) {
+	switch d := event.Data.(type) {
+	case *AssistantMessageDeltaData:
+		if d.MessageID != p.streamingID {
+			p.streaming.Reset()
+			p.streamingID = d.MessageID
+		}
+		p.streaming.WriteString(d.DeltaContent)
+	case *AssistantMessageData:
+		p.message = d.Content
+		p.streaming.Reset()
+		p.streamingID = ""
+	}
+}
+
+// content returns the message in progress, or else the last complete one.
+func (p *partialContent) content() string {
+	if p.streaming.Len() > 0 {
+		return p.streaming.String()
+	}
+	return p.message
+}
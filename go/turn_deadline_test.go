@@ -0,0 +1,108 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestTurnDeadline(t *testing.T) {
+	start := func(t *testing.T) (*Session, chan struct{}, chan struct{}) {
+		t.Helper()
+		sent := make(chan struct{}, 4)
+		aborted := make(chan struct{}, 4)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				sent <- struct{}{}
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+			"session.abort": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				aborted <- struct{}{}
+				return []byte(`{}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		return session, sent, aborted
+	}
+	waitFor := func(t *testing.T, ch chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+
+	t.Run("aborts the turn and returns the partial answer", func(t *testing.T) {
+		session, sent, aborted := start(t)
+		type result struct {
+			response *Response
+			err      error
+		}
+		results := make(chan result, 1)
+		go func() {
+			response, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "explain", Timeout: 200 * time.Millisecond})
+			results <- result{response, err}
+		}()
+		waitFor(t, sent, "the send")
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "a1", Content: "Let me look."}})
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageDeltaData{MessageID: "a2", DeltaContent: "The answer "}})
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageDeltaData{MessageID: "a2", DeltaContent: "is"}})
+
+		r := <-results
+		if !errors.Is(r.err, ErrTurnDeadlineExceeded) {
+			t.Fatalf("expected ErrTurnDeadlineExceeded, got %v", r.err)
+		}
+		if !r.response.Partial || r.response.Content != "The answer is" {
+			t.Errorf("expected the partial content %q, got %+v", "The answer is", r.response)
+		}
+		if d, ok := r.response.Message.Data.(*AssistantMessageData); !ok || d.MessageID != "a1" {
+			t.Errorf("expected the last complete message, got %+v", r.response.Message)
+		}
+		waitFor(t, aborted, "the abort")
+	})
+
+	t.Run("falls back to the last complete message", func(t *testing.T) {
+		session, sent, _ := start(t)
+		go func() {
+			<-sent
+			session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "a1", Content: "Done so far."}})
+		}()
+		message, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "explain", Deadline: time.Now().Add(200 * time.Millisecond)})
+		if !errors.Is(err, ErrTurnDeadlineExceeded) {
+			t.Fatalf("expected ErrTurnDeadlineExceeded, got %v", err)
+		}
+		if d, ok := message.Data.(*AssistantMessageData); !ok || d.Content != "Done so far." {
+			t.Errorf("expected the last complete message, got %+v", message)
+		}
+	})
+
+	t.Run("stops the timer when the turn ends", func(t *testing.T) {
+		session, _, aborted := start(t)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "once", Timeout: 20 * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		select {
+		case <-aborted:
+			t.Error("expected no abort after the turn ended")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("applies the earlier of Deadline and Timeout", func(t *testing.T) {
+		sent := time.Now()
+		options := MessageOptions{Deadline: sent.Add(time.Minute), Timeout: time.Second}
+		if got := options.turnDeadline(sent); !got.Equal(sent.Add(time.Second)) {
+			t.Errorf("expected the Timeout to apply, got %v", got)
+		}
+		options.Timeout = time.Hour
+		if got := options.turnDeadline(sent); !got.Equal(options.Deadline) {
+			t.Errorf("expected the Deadline to apply, got %v", got)
+		}
+	})
+}
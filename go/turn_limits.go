@@ -0,0 +1,169 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type (
+	TurnLimitExceededData = rpc.TurnLimitExceededData
+	TurnLimit             = rpc.TurnLimit
+)
+
+const (
+	SessionEventTypeTurnLimitExceeded = rpc.SessionEventTypeTurnLimitExceeded
+
+	TurnLimitToolCalls    = rpc.TurnLimitToolCalls
+	TurnLimitOutputTokens = rpc.TurnLimitOutputTokens
+	TurnLimitDuration     = rpc.TurnLimitDuration
+)
+
+// ErrTurnLimitExceeded is returned by [Session.SendAndWait] and
+// [Session.SendAndWaitResponse] when the turn was aborted for exceeding one
+// of its [TurnLimits].
+var ErrTurnLimitExceeded = errors.New("turn limit exceeded")
+
+// TurnLimits bounds the work a single turn may do. Zero fields are
+// unlimited. Tool calls include those of sub-agents; output tokens are
+// summed over every model call of the turn.
+type TurnLimits struct {
+	// MaxToolCalls is the number of tool calls the turn may start.
+	MaxToolCalls int
+	// MaxOutputTokens is the number of output tokens the model may produce.
+	MaxOutputTokens int64
+	// MaxDuration is how long the turn may run, measured from the send.
+	MaxDuration time.Duration
+}
+
+// turnLimiter enforces the limits of the running turn. A message sent with
+// [MessageOptions.Limits] starts counting the tool calls, output tokens and
+// wall-clock time of its turn. When the turn exceeds a limit, the limiter
+// delivers a turn.limit_exceeded event and aborts the turn, so a runaway
+// tool loop ends without killing the session.
+type turnLimiter struct {
+	mu           sync.Mutex
+	limits       *TurnLimits // nil when the running turn has no limits
+	sent         time.Time
+	toolCalls    int
+	outputTokens int64
+	timer        *time.Timer
+	// generation distinguishes sends, so a stale timer does not end a
+	// later turn.
+	generation uint64
+}
+
+// begin starts enforcing limits from a send; onTimeout is called if the
+// turn is still running after limits.MaxDuration. The returned function
+// stops enforcement if the send fails.
+func (l *turnLimiter) begin(limits TurnLimits, onTimeout func(*TurnLimitExceededData)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.resetLocked()
+	l.limits = &limits
+	l.sent = time.Now()
+	generation := l.generation
+	if limits.MaxDuration > 0 {
+		l.timer = time.AfterFunc(limits.MaxDuration, func() {
+			if exceeded := l.expire(generation); exceeded != nil {
+				onTimeout(exceeded)
+			}
+		})
+	}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.generation == generation {
+			l.resetLocked()
+		}
+	}
+}
+
+// resetLocked stops enforcing the current limits.
+func (l *turnLimiter) resetLocked() {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.generation++
+	l.limits = nil
+	l.timer = nil
+	l.toolCalls = 0
+	l.outputTokens = 0
+}
+
+// expire reports the duration limit of send generation as exceeded, unless
+// the turn has ended or another limit was exceeded first.
+func (l *turnLimiter) expire(generation uint64) *TurnLimitExceededData {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits == nil || l.generation != generation {
+		return nil
+	}
+	exceeded := &TurnLimitExceededData{
+		Limit: TurnLimitDuration,
+		Max:   l.limits.MaxDuration.Milliseconds(),
+		Used:  time.Since(l.sent).Milliseconds(),
+	}
+	l.resetLocked()
+	return exceeded
+}
+
+// observe counts event against the limits and returns the limit it
+// exceeded, if any. Called only from processEvents.
+func (l *turnLimiter) observe(event SessionEvent) *TurnLimitExceededData {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits == nil {
+		return nil
+	}
+	var exceeded *TurnLimitExceededData
+	switch d := event.Data.(type) {
+	case *ToolExecutionStartData:
+		l.toolCalls++
+		if max := l.limits.MaxToolCalls; max > 0 && l.toolCalls > max {
+			exceeded = &TurnLimitExceededData{Limit: TurnLimitToolCalls, Max: int64(max), Used: int64(l.toolCalls)}
+		}
+	case *AssistantUsageData:
+		l.outputTokens += derefInt64(d.OutputTokens)
+		if max := l.limits.MaxOutputTokens; max > 0 && l.outputTokens > max {
+			exceeded = &TurnLimitExceededData{Limit: TurnLimitOutputTokens, Max: max, Used: l.outputTokens}
+		}
+	case *SessionIdleData, *SessionErrorData:
+		l.resetLocked()
+	}
+	if exceeded != nil {
+		l.resetLocked()
+	}
+	return exceeded
+}
+
+// exceedTurnLimit delivers a turn.limit_exceeded event with deliver and
+// aborts the turn.
+func (s *Session) exceedTurnLimit(exceeded *TurnLimitExceededData, deliver func(SessionEvent)) {
+	s.logger().Warn("turn limit exceeded; aborting turn", "limit", exceeded.Limit, "max", exceeded.Max, "used", exceeded.Used)
+	ephemeral := true
+	deliver(SessionEvent{
+		Data:      exceeded,
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	})
+	go func() {
+		if err := s.Abort(context.Background()); err != nil {
+			s.logger().Error("failed to abort turn after exceeding a limit", "error", err)
+		}
+	}()
+}
+
+// turnLimitError describes exceeded for the caller of SendAndWait.
+func turnLimitError(exceeded *TurnLimitExceededData) error {
+	if exceeded.Limit == TurnLimitDuration {
+		return fmt.Errorf("%w: %s of %s", ErrTurnLimitExceeded, exceeded.Limit, time.Duration(exceeded.Max)*time.Millisecond)
+	}
+	return fmt.Errorf("%w: %s of %d", ErrTurnLimitExceeded, exceeded.Limit, exceeded.Max)
+}
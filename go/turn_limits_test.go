@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestTurnLimits(t *testing.T) {
+	start := func(t *testing.T) (*Session, chan struct{}, chan struct{}) {
+		t.Helper()
+		sent := make(chan struct{}, 4)
+		aborted := make(chan struct{}, 4)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.send": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				sent <- struct{}{}
+				return []byte(`{"messageId":"m1"}`), nil
+			},
+			"session.abort": func(json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				aborted <- struct{}{}
+				return []byte(`{}`), nil
+			},
+		})
+		session := newSession("s1", client.client, "")
+		t.Cleanup(func() { close(session.done) })
+		return session, sent, aborted
+	}
+	waitFor := func(t *testing.T, ch chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+
+	t.Run("aborts the turn after too many tool calls and fails SendAndWait", func(t *testing.T) {
+		session, sent, aborted := start(t)
+		var exceeded []TurnLimitExceededData
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*TurnLimitExceededData); ok {
+				exceeded = append(exceeded, *d)
+			}
+		})
+		errs := make(chan error, 1)
+		go func() {
+			_, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "loop", Limits: &TurnLimits{MaxToolCalls: 2}})
+			errs <- err
+		}()
+		waitFor(t, sent, "the send")
+
+		for _, id := range []string{"c1", "c2", "c3", "c4"} {
+			session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: id, ToolName: "bash"}})
+		}
+		waitFor(t, aborted, "the abort")
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{Aborted: ptr(true)}})
+
+		if err := <-errs; !errors.Is(err, ErrTurnLimitExceeded) {
+			t.Errorf("expected ErrTurnLimitExceeded, got %v", err)
+		}
+		want := TurnLimitExceededData{Limit: TurnLimitToolCalls, Max: 2, Used: 3}
+		if len(exceeded) != 1 || exceeded[0] != want {
+			t.Errorf("expected one %+v event, got %+v", want, exceeded)
+		}
+	})
+
+	t.Run("aborts the turn after too many output tokens", func(t *testing.T) {
+		session, _, aborted := start(t)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "write", Limits: &TurnLimits{MaxOutputTokens: 100}}); err != nil {
+			t.Fatal(err)
+		}
+		session.dispatchEvent(SessionEvent{Data: &AssistantUsageData{OutputTokens: ptr(int64(60))}})
+		session.dispatchEvent(SessionEvent{Data: &AssistantUsageData{OutputTokens: ptr(int64(60))}})
+		waitFor(t, aborted, "the abort")
+	})
+
+	t.Run("aborts the turn when it runs too long", func(t *testing.T) {
+		session, _, aborted := start(t)
+		exceeded := make(chan TurnLimitExceededData, 1)
+		session.On(func(event SessionEvent) {
+			if d, ok := event.Data.(*TurnLimitExceededData); ok {
+				exceeded <- *d
+			}
+		})
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "wait", Limits: &TurnLimits{MaxDuration: 10 * time.Millisecond}}); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, aborted, "the abort")
+		if d := <-exceeded; d.Limit != TurnLimitDuration || d.Max != 10 || d.Used < 10 {
+			t.Errorf("unexpected event: %+v", d)
+		}
+	})
+
+	t.Run("stops enforcing limits when the turn ends", func(t *testing.T) {
+		session, _, aborted := start(t)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "once", Limits: &TurnLimits{MaxToolCalls: 1, MaxDuration: 20 * time.Millisecond}}); err != nil {
+			t.Fatal(err)
+		}
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c1"}})
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c2"}})
+		select {
+		case <-aborted:
+			t.Error("expected no abort after the turn ended")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,468 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TurnRecord is a completed turn as synced to a system of record by a
+// [TurnSyncer].
+type TurnRecord struct {
+	// IdempotencyKey identifies the turn across retries and repeated syncs:
+	// the session ID and turn ID joined by "/". Sinks should pass it on so
+	// the external system records each turn once.
+	IdempotencyKey string `json:"idempotencyKey"`
+	// Reference is the external item the session works on, such as a Jira
+	// issue key or a ServiceNow ticket number, as passed to
+	// [TurnSyncer.Attach].
+	Reference   string      `json:"reference,omitempty"`
+	SessionID   string      `json:"sessionId"`
+	TurnID      string      `json:"turnId"`
+	Outcome     TurnOutcome `json:"outcome"`
+	StartedAt   time.Time   `json:"startedAt"`
+	CompletedAt time.Time   `json:"completedAt"`
+	// Summary is the turn's final answer.
+	Summary string `json:"summary"`
+	// Error is the error message of a failed turn.
+	Error string `json:"error,omitempty"`
+	// Actions are the tool calls of the turn, in the order they started,
+	// followed by its file edits.
+	Actions []TurnAction `json:"actions,omitempty"`
+}
+
+// TurnActionKind is the kind of a [TurnAction].
+type TurnActionKind string
+
+const (
+	TurnActionToolCall TurnActionKind = "tool_call"
+	TurnActionFileEdit TurnActionKind = "file_edit"
+)
+
+// TurnAction is something the agent did during a turn.
+type TurnAction struct {
+	Kind TurnActionKind `json:"kind"`
+	// Tool is the name of the tool called, or of the tool that edited the
+	// file.
+	Tool string `json:"tool"`
+	// Path is the edited file, for file edits.
+	Path    string `json:"path,omitempty"`
+	Success bool   `json:"success"`
+}
+
+// Note renders the record as the text of a ticket comment or work note.
+func (r *TurnRecord) Note() string {
+	var b strings.Builder
+	switch r.Outcome {
+	case TurnOutcomeFailed:
+		fmt.Fprintf(&b, "Copilot turn failed: %s", r.Error)
+	case TurnOutcomeAborted:
+		b.WriteString("Copilot turn was aborted.")
+	default:
+		b.WriteString("Copilot turn completed.")
+	}
+	if r.Summary != "" {
+		b.WriteString("\n\n" + r.Summary)
+	}
+	if len(r.Actions) > 0 {
+		b.WriteString("\n\nActions:")
+		for _, action := range r.Actions {
+			switch {
+			case action.Kind == TurnActionFileEdit:
+				fmt.Fprintf(&b, "\n- Edited %s", action.Path)
+			case action.Success:
+				fmt.Fprintf(&b, "\n- Ran %s", action.Tool)
+			default:
+				fmt.Fprintf(&b, "\n- Ran %s (failed)", action.Tool)
+			}
+		}
+	}
+	return b.String()
+}
+
+// TurnSink is an external system that completed turns are synced to, such
+// as a ticketing system. See [TurnSyncer] and [WebhookSink].
+type TurnSink interface {
+	// SyncTurn records the turn. It may be called again for the same
+	// record after an error, so it should be idempotent, keyed by
+	// record.IdempotencyKey.
+	SyncTurn(ctx context.Context, record *TurnRecord) error
+}
+
+// ErrTurnSyncDropped is reported to [TurnSyncerOptions.OnError] when a turn
+// is discarded because the sync queue is full.
+var ErrTurnSyncDropped = errors.New("turn sync queue full; turn dropped")
+
+// TurnSyncerOptions configures a [TurnSyncer].
+type TurnSyncerOptions struct {
+	// Retry controls how failed syncs are retried. Its RetryOn defaults to
+	// retrying every error except a [WebhookError] for a request the
+	// endpoint rejected. When nil, syncs are retried with the
+	// [RetryPolicy] defaults and 5 attempts.
+	Retry *RetryPolicy
+	// Timeout bounds each attempt. Defaults to 30s.
+	Timeout time.Duration
+	// Outcomes limits syncing to turns with the listed outcomes. When
+	// empty, every turn is synced.
+	Outcomes []TurnOutcome
+	// QueueSize is the number of turns queued for syncing before new turns
+	// are dropped. Defaults to 256.
+	QueueSize int
+	// OnError is called (from the syncing goroutine, or from the session's
+	// event dispatch for [ErrTurnSyncDropped]) when a turn cannot be synced.
+	OnError func(record *TurnRecord, err error)
+}
+
+// TurnSyncer syncs a summary and the actions of each completed turn to a
+// system of record, so agent work shows up in existing workflows such as
+// Jira issues or ServiceNow incidents.
+//
+// Turns are synced in order from a single background goroutine. A failed
+// sync is retried with backoff before the next turn is synced; a turn that
+// still fails is reported to OnError. Each turn is synced once: turns
+// already queued or synced are skipped, and every attempt carries the
+// turn's [TurnRecord.IdempotencyKey] so the sink can deduplicate retries.
+// Syncing never blocks a session: when the queue is full the turn is
+// dropped and reported to OnError.
+//
+// Example:
+//
+//	syncer := copilot.NewTurnSyncer(&copilot.WebhookSink{
+//	    URL:    os.Getenv("JIRA_AUTOMATION_WEBHOOK"),
+//	    Format: copilot.WebhookFormatJira,
+//	    Header: http.Header{"X-Automation-Webhook-Token": {token}},
+//	}, copilot.TurnSyncerOptions{})
+//	defer syncer.Close(context.Background())
+//
+//	session, err := client.CreateSession(ctx, config)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	syncer.Attach(session, "PROJ-123")
+type TurnSyncer struct {
+	sink  TurnSink
+	opts  TurnSyncerOptions
+	retry RetryPolicy
+	queue chan *TurnRecord
+	done  chan struct{}
+	// ctx is cancelled when Close gives up waiting.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	// keys holds the idempotency keys of turns queued or synced, the
+	// oldest of which are forgotten beyond maxSyncedTurns.
+	keys  map[string]bool
+	order []string
+}
+
+// maxSyncedTurns bounds how many turns a TurnSyncer remembers as synced.
+const maxSyncedTurns = 4096
+
+// NewTurnSyncer starts a syncer for sink.
+func NewTurnSyncer(sink TurnSink, opts TurnSyncerOptions) *TurnSyncer {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+	retry := RetryPolicy{MaxAttempts: 5}
+	if opts.Retry != nil {
+		retry = *opts.Retry
+	}
+	if retry.RetryOn == nil {
+		retry.RetryOn = func(err error) bool {
+			var webhookErr *WebhookError
+			return !errors.As(err, &webhookErr) || webhookErr.retryable()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &TurnSyncer{
+		sink:   sink,
+		opts:   opts,
+		retry:  retry.withDefaults(),
+		queue:  make(chan *TurnRecord, opts.QueueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+		keys:   make(map[string]bool),
+	}
+	go s.run()
+	return s
+}
+
+// Attach syncs the session's turns, recording reference, such as the key of
+// the issue the session works on, in each [TurnRecord]. Returns a function
+// that stops syncing.
+func (s *TurnSyncer) Attach(session *Session, reference string) func() {
+	sessionID := session.SessionID
+	var tracker turnTracker
+	var edits []TurnAction
+	return session.On(func(event SessionEvent) {
+		start, complete := tracker.observe(sessionID, event)
+		if start != nil {
+			edits = nil
+		}
+		if d, ok := event.Data.(*FileEditedData); ok && tracker.active != nil {
+			edits = append(edits, TurnAction{Kind: TurnActionFileEdit, Tool: d.ToolName, Path: d.Path, Success: true})
+		}
+		if complete != nil {
+			s.Enqueue(turnRecord(complete, reference, edits))
+			edits = nil
+		}
+	})
+}
+
+// turnRecord returns the record of a completed turn with its file edits.
+func turnRecord(info *TurnCompleteInfo, reference string, edits []TurnAction) *TurnRecord {
+	record := &TurnRecord{
+		IdempotencyKey: info.SessionID + "/" + info.TurnID,
+		Reference:      reference,
+		SessionID:      info.SessionID,
+		TurnID:         info.TurnID,
+		Outcome:        info.Outcome,
+		StartedAt:      info.StartedAt,
+		CompletedAt:    info.CompletedAt,
+		Summary:        info.Content,
+	}
+	if info.Error != nil {
+		record.Error = info.Error.Message
+	}
+	for _, call := range info.ToolCalls {
+		record.Actions = append(record.Actions, TurnAction{Kind: TurnActionToolCall, Tool: call.ToolName, Success: call.Success})
+	}
+	record.Actions = append(record.Actions, edits...)
+	return record
+}
+
+// Enqueue queues a turn for syncing unless it was already queued or synced.
+// It is called by sessions attached with [TurnSyncer.Attach] and can also
+// be used to sync turns recorded elsewhere. An empty IdempotencyKey is set
+// from the session and turn IDs.
+func (s *TurnSyncer) Enqueue(record *TurnRecord) {
+	if len(s.opts.Outcomes) > 0 && !slices.Contains(s.opts.Outcomes, record.Outcome) {
+		return
+	}
+	if record.IdempotencyKey == "" {
+		record.IdempotencyKey = record.SessionID + "/" + record.TurnID
+	}
+	s.mu.Lock()
+	if s.closed || s.keys[record.IdempotencyKey] {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.queue <- record:
+		s.keys[record.IdempotencyKey] = true
+		s.order = append(s.order, record.IdempotencyKey)
+		if len(s.order) > maxSyncedTurns {
+			delete(s.keys, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.mu.Unlock()
+	default:
+		s.mu.Unlock()
+		s.reportError(record, ErrTurnSyncDropped)
+	}
+}
+
+// Close stops accepting turns and waits until queued turns are synced, or
+// until ctx is done, in which case the remaining turns are reported to
+// OnError and ctx's error is returned.
+func (s *TurnSyncer) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+func (s *TurnSyncer) run() {
+	defer close(s.done)
+	defer s.cancel()
+	for record := range s.queue {
+		if err := s.sync(record); err != nil {
+			// Forget the turn so that it can be queued again.
+			s.mu.Lock()
+			delete(s.keys, record.IdempotencyKey)
+			s.mu.Unlock()
+			s.reportError(record, err)
+		}
+	}
+}
+
+// sync syncs record, retrying failed attempts.
+func (s *TurnSyncer) sync(record *TurnRecord) error {
+	for attempt := 1; ; attempt++ {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, s.opts.Timeout)
+		err := s.sink.SyncTurn(ctx, record)
+		cancel()
+		if err == nil || attempt >= s.retry.MaxAttempts || !s.retry.RetryOn(err) {
+			return err
+		}
+		select {
+		case <-s.ctx.Done():
+			return err
+		case <-time.After(s.retry.backoff(attempt)):
+		}
+	}
+}
+
+func (s *TurnSyncer) reportError(record *TurnRecord, err error) {
+	if s.opts.OnError == nil {
+		return
+	}
+	defer func() { recover() }() // Ignore handler panics
+	s.opts.OnError(record, err)
+}
+
+// WebhookFormat is the body schema a [WebhookSink] posts.
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON posts the [TurnRecord] as JSON.
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatJira posts a [JiraWebhookPayload], for a Jira
+	// Automation rule with an incoming webhook trigger.
+	WebhookFormatJira WebhookFormat = "jira"
+	// WebhookFormatServiceNow posts a [ServiceNowWebhookPayload], for a
+	// ServiceNow import set or scripted REST endpoint.
+	WebhookFormatServiceNow WebhookFormat = "servicenow"
+)
+
+// JiraWebhookPayload is the body of [WebhookFormatJira]. Issues names the
+// record's Reference, so the rule acts on that issue; its actions can use
+// {{webhookData.comment}} for the turn's note, or the record's fields, such
+// as {{webhookData.outcome}}.
+type JiraWebhookPayload struct {
+	Issues []string        `json:"issues,omitempty"`
+	Data   JiraWebhookData `json:"data"`
+}
+
+// JiraWebhookData is the record of a [JiraWebhookPayload] with its note.
+type JiraWebhookData struct {
+	*TurnRecord
+	// Comment is the record's [TurnRecord.Note].
+	Comment string `json:"comment"`
+}
+
+// ServiceNowWebhookPayload is the body of [WebhookFormatServiceNow]. Its
+// fields are named after the task table's, so a transform map can copy
+// them onto the incident or request with the ticket number Number.
+type ServiceNowWebhookPayload struct {
+	// CorrelationID is the record's idempotency key.
+	CorrelationID string `json:"correlation_id"`
+	// Number is the record's Reference.
+	Number           string `json:"number,omitempty"`
+	ShortDescription string `json:"short_description"`
+	// WorkNotes is the record's [TurnRecord.Note].
+	WorkNotes string `json:"work_notes"`
+	// Record is the whole record, for fields the transform needs.
+	Record *TurnRecord `json:"u_copilot_turn"`
+}
+
+// WebhookError is returned by [WebhookSink] when the endpoint responds with
+// a non-2xx status.
+type WebhookError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook returned %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *WebhookError) retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// maxWebhookErrorBody caps how much of an error response is kept.
+const maxWebhookErrorBody = 4096
+
+// WebhookSink is a [TurnSink] that posts each turn to an HTTP endpoint, in
+// the body schema of Format, with the record's idempotency key in the
+// Idempotency-Key header.
+type WebhookSink struct {
+	URL string
+	// Format is the body schema. Defaults to [WebhookFormatJSON].
+	Format WebhookFormat
+	// Header is added to each request, for example for authentication.
+	Header http.Header
+	// HTTPClient sends the requests. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// SyncTurn posts record to the webhook.
+func (w *WebhookSink) SyncTurn(ctx context.Context, record *TurnRecord) error {
+	var payload any
+	switch w.Format {
+	case "", WebhookFormatJSON:
+		payload = record
+	case WebhookFormatJira:
+		jira := JiraWebhookPayload{Data: JiraWebhookData{TurnRecord: record, Comment: record.Note()}}
+		if record.Reference != "" {
+			jira.Issues = []string{record.Reference}
+		}
+		payload = jira
+	case WebhookFormatServiceNow:
+		payload = ServiceNowWebhookPayload{
+			CorrelationID:    record.IdempotencyKey,
+			Number:           record.Reference,
+			ShortDescription: fmt.Sprintf("Copilot turn %s in session %s", record.Outcome, record.SessionID),
+			WorkNotes:        record.Note(),
+			Record:           record,
+		}
+	default:
+		return fmt.Errorf("invalid webhook format %q", w.Format)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal turn record: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	for name, values := range w.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", record.IdempotencyKey)
+	client := w.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+		return &WebhookError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
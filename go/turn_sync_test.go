@@ -0,0 +1,117 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTurnSyncer(t *testing.T) {
+	type request struct {
+		key  string
+		body map[string]any
+	}
+	startWebhook := func(t *testing.T, statuses ...int) (string, chan request) {
+		t.Helper()
+		requests := make(chan request, 8)
+		var mu sync.Mutex
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			requests <- request{key: r.Header.Get("Idempotency-Key"), body: body}
+			mu.Lock()
+			status := http.StatusOK
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return server.URL, requests
+	}
+	retry := &RetryPolicy{InitialBackoff: time.Millisecond}
+
+	t.Run("syncs completed turns to a Jira webhook with retries", func(t *testing.T) {
+		url, requests := startWebhook(t, http.StatusServiceUnavailable)
+		syncer := NewTurnSyncer(&WebhookSink{URL: url, Format: WebhookFormatJira}, TurnSyncerOptions{Retry: retry})
+		session := newSession("s1", nil, "")
+		t.Cleanup(func() { close(session.done) })
+		syncer.Attach(session, "PROJ-7")
+		idle := make(chan struct{})
+		session.On(func(event SessionEvent) {
+			if _, ok := event.Data.(*SessionIdleData); ok {
+				close(idle)
+			}
+		})
+
+		session.dispatchEvent(SessionEvent{ID: "u1", Data: &UserMessageData{Content: "fix the build"}})
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionStartData{ToolCallID: "c1", ToolName: "edit"}})
+		session.dispatchEvent(SessionEvent{Data: &FileEditedData{ToolCallID: "c1", ToolName: "edit", Path: "main.go"}})
+		session.dispatchEvent(SessionEvent{Data: &ToolExecutionCompleteData{ToolCallID: "c1", Success: true}})
+		session.dispatchEvent(SessionEvent{Data: &AssistantMessageData{MessageID: "a1", Content: "Fixed the import."}})
+		session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+		<-idle
+		if err := syncer.Close(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(requests) != 2 {
+			t.Fatalf("expected a failed attempt and a retry, got %d requests", len(requests))
+		}
+		<-requests
+		r := <-requests
+		if r.key != "s1/u1" {
+			t.Errorf("expected the idempotency key s1/u1, got %q", r.key)
+		}
+		issues, _ := r.body["issues"].([]any)
+		data, _ := r.body["data"].(map[string]any)
+		if len(issues) != 1 || issues[0] != "PROJ-7" || data["outcome"] != "completed" {
+			t.Errorf("unexpected payload %v", r.body)
+		}
+		want := "Copilot turn completed.\n\nFixed the import.\n\nActions:\n- Ran edit\n- Edited main.go"
+		if data["comment"] != want {
+			t.Errorf("expected the comment %q, got %q", want, data["comment"])
+		}
+	})
+
+	t.Run("syncs each turn once", func(t *testing.T) {
+		url, requests := startWebhook(t)
+		syncer := NewTurnSyncer(&WebhookSink{URL: url}, TurnSyncerOptions{})
+		record := &TurnRecord{SessionID: "s1", TurnID: "u1", Outcome: TurnOutcomeCompleted}
+		syncer.Enqueue(record)
+		syncer.Enqueue(&TurnRecord{SessionID: "s1", TurnID: "u1", Outcome: TurnOutcomeCompleted})
+		syncer.Close(t.Context())
+		if len(requests) != 1 || record.IdempotencyKey != "s1/u1" {
+			t.Errorf("expected one request keyed s1/u1, got %d keyed %q", len(requests), record.IdempotencyKey)
+		}
+	})
+
+	t.Run("reports rejected turns without retrying", func(t *testing.T) {
+		url, requests := startWebhook(t, http.StatusBadRequest)
+		var reported []error
+		syncer := NewTurnSyncer(&WebhookSink{URL: url, Format: WebhookFormatServiceNow}, TurnSyncerOptions{
+			Retry:   retry,
+			OnError: func(_ *TurnRecord, err error) { reported = append(reported, err) },
+		})
+		syncer.Enqueue(&TurnRecord{SessionID: "s1", TurnID: "u1", Reference: "INC0010001", Outcome: TurnOutcomeFailed, Error: "boom"})
+		syncer.Close(t.Context())
+
+		var webhookErr *WebhookError
+		if len(reported) != 1 || !errors.As(reported[0], &webhookErr) || webhookErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected the rejection to be reported once, got %v", reported)
+		}
+		if len(requests) != 1 {
+			t.Fatalf("expected no retry, got %d requests", len(requests))
+		}
+		r := <-requests
+		if r.body["number"] != "INC0010001" || r.body["correlation_id"] != "s1/u1" || !strings.HasPrefix(r.body["work_notes"].(string), "Copilot turn failed: boom") {
+			t.Errorf("unexpected payload %v", r.body)
+		}
+	})
+}
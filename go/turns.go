@@ -0,0 +1,258 @@
+package copilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+	"github.com/google/uuid"
+)
+
+type TurnResultData = rpc.TurnResultData
+
+const SessionEventTypeTurnResult = rpc.SessionEventTypeTurnResult
+
+// TurnOutcome describes how a turn ended.
+type TurnOutcome string
+
+const (
+	// TurnOutcomeCompleted means the session went idle normally.
+	TurnOutcomeCompleted TurnOutcome = "completed"
+	// TurnOutcomeAborted means the turn was cancelled, e.g. via [Session.Abort].
+	TurnOutcomeAborted TurnOutcome = "aborted"
+	// TurnOutcomeFailed means the session reported an error.
+	TurnOutcomeFailed TurnOutcome = "failed"
+)
+
+// TurnUsage aggregates model usage across all model calls made during a turn,
+// including calls made by sub-agents.
+type TurnUsage struct {
+	ModelCalls       int
+	InputTokens      int64
+	OutputTokens     int64
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+	ReasoningTokens  int64
+	// Cost is the sum of the model multiplier costs reported for the turn,
+	// that is, the number of premium requests it consumed.
+	Cost float64
+}
+
+// TurnStartInfo is passed to [SessionHooks.OnTurnStart].
+type TurnStartInfo struct {
+	SessionID string
+	// TurnID identifies the turn. It is the ID of the user.message event
+	// that started it.
+	TurnID string
+	// PromptHash is the hex-encoded SHA-256 of the prompt text, suitable for
+	// correlating turns without logging prompt contents.
+	PromptHash string
+	StartedAt  time.Time
+}
+
+// TurnCompleteInfo is passed to [SessionHooks.OnTurnComplete].
+type TurnCompleteInfo struct {
+	SessionID   string
+	TurnID      string
+	PromptHash  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Duration    time.Duration
+	Usage       TurnUsage
+	Outcome     TurnOutcome
+	// Error is set when Outcome is [TurnOutcomeFailed].
+	Error *SessionErrorData
+	// Content is the content of the turn's last top-level assistant
+	// message.
+	Content string
+	// ToolCalls summarizes the tool calls made during the turn, including
+	// those of sub-agents, in the order they started.
+	ToolCalls []TurnToolCall
+}
+
+// TurnToolCall summarizes one tool call of a turn.
+type TurnToolCall struct {
+	ToolCallID string
+	ToolName   string
+	// Completed reports whether the call finished before the turn ended,
+	// and Success whether it finished successfully.
+	Completed bool
+	Success   bool
+}
+
+// TurnResult is returned by [SessionHooks.OnTurnResult] to post-process a
+// turn.
+type TurnResult struct {
+	// Postscript is appended to the turn's final content, such as a
+	// disclaimer or a "sources" footer.
+	Postscript string
+	// FlagForReview marks the turn for human review, with ReviewReason
+	// explaining why.
+	FlagForReview bool
+	ReviewReason  string
+}
+
+// TurnStartHandler is called when a turn starts.
+type TurnStartHandler func(info TurnStartInfo)
+
+// TurnCompleteHandler is called when a turn completes, is aborted, or fails.
+type TurnCompleteHandler func(info TurnCompleteInfo)
+
+// TurnResultHandler is called when a turn completes, is aborted, or fails,
+// with the turn's final content and tool calls. A non-nil result is
+// delivered to event handlers as a turn.result event ([TurnResultData])
+// just before the turn's session.idle, and applied to the [Response]
+// returned by [Session.SendAndWaitResponse].
+type TurnResultHandler func(info TurnCompleteInfo) *TurnResult
+
+// turnTracker pairs turn start and end events for SessionHooks.OnTurnStart
+// and SessionHooks.OnTurnComplete, so callers don't have to pair start and
+// idle events themselves. A turn spans from a top-level user.message until
+// the session goes idle or reports an error. It is only accessed from the
+// session's event consumer goroutine and needs no locking.
+type turnTracker struct {
+	active *TurnCompleteInfo
+}
+
+// observe updates turn state for event and returns the notifications to
+// deliver, if any.
+func (t *turnTracker) observe(sessionID string, event SessionEvent) (start *TurnStartInfo, complete *TurnCompleteInfo) {
+	switch d := event.Data.(type) {
+	case *UserMessageData:
+		// Messages sent while a turn is running steer the current turn, and
+		// sub-agent messages belong to their parent's turn.
+		if t.active != nil || event.AgentID != nil {
+			return nil, nil
+		}
+		sum := sha256.Sum256([]byte(d.Content))
+		t.active = &TurnCompleteInfo{
+			SessionID:  sessionID,
+			TurnID:     event.ID,
+			PromptHash: hex.EncodeToString(sum[:]),
+			StartedAt:  event.Timestamp,
+		}
+		return &TurnStartInfo{
+			SessionID:  sessionID,
+			TurnID:     t.active.TurnID,
+			PromptHash: t.active.PromptHash,
+			StartedAt:  t.active.StartedAt,
+		}, nil
+	case *AssistantUsageData:
+		if t.active == nil {
+			return nil, nil
+		}
+		t.active.Usage.add(d)
+	case *AssistantMessageData:
+		if t.active == nil || event.AgentID != nil {
+			return nil, nil
+		}
+		t.active.Content = d.Content
+	case *ToolExecutionStartData:
+		if t.active == nil {
+			return nil, nil
+		}
+		t.active.ToolCalls = append(t.active.ToolCalls, TurnToolCall{ToolCallID: d.ToolCallID, ToolName: d.ToolName})
+	case *ToolExecutionCompleteData:
+		if t.active == nil {
+			return nil, nil
+		}
+		for i := range t.active.ToolCalls {
+			if call := &t.active.ToolCalls[i]; call.ToolCallID == d.ToolCallID {
+				call.Completed = true
+				call.Success = d.Success
+			}
+		}
+	case *SessionIdleData:
+		if t.active == nil {
+			return nil, nil
+		}
+		outcome := TurnOutcomeCompleted
+		if d.Aborted != nil && *d.Aborted {
+			outcome = TurnOutcomeAborted
+		}
+		return nil, t.finish(event.Timestamp, outcome, nil)
+	case *SessionErrorData:
+		if t.active == nil {
+			return nil, nil
+		}
+		return nil, t.finish(event.Timestamp, TurnOutcomeFailed, d)
+	}
+	return nil, nil
+}
+
+func (t *turnTracker) finish(at time.Time, outcome TurnOutcome, errData *SessionErrorData) *TurnCompleteInfo {
+	info := t.active
+	t.active = nil
+	info.CompletedAt = at
+	info.Duration = at.Sub(info.StartedAt)
+	info.Outcome = outcome
+	info.Error = errData
+	return info
+}
+
+// trackTurn feeds event to the turn tracker, records metrics and invokes
+// the turn hooks, delivering the turn.result event of OnTurnResult. Called
+// from processEvents before user event handlers run.
+func (s *Session) trackTurn(event SessionEvent) {
+	start, complete := s.turns.observe(s.SessionID, event)
+	s.recordMetrics(event, start, complete)
+	if complete != nil {
+		s.owner.recordTurnTelemetry(complete)
+	}
+	if start == nil && complete == nil {
+		return
+	}
+	hooks := s.getHooks()
+	if hooks == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger().Error("panic in turn hook", "error", r)
+			s.recovered("turn hook", "", r)
+		}
+	}()
+	if start != nil && hooks.OnTurnStart != nil {
+		hooks.OnTurnStart(*start)
+	}
+	if complete != nil && hooks.OnTurnComplete != nil {
+		hooks.OnTurnComplete(*complete)
+	}
+	if complete != nil && hooks.OnTurnResult != nil {
+		if result := hooks.OnTurnResult(*complete); result != nil {
+			s.deliverEvent(turnResultEvent(complete, result))
+		}
+	}
+}
+
+// turnResultEvent returns the turn.result event for result.
+func turnResultEvent(info *TurnCompleteInfo, result *TurnResult) SessionEvent {
+	content := info.Content
+	if result.Postscript != "" {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += result.Postscript
+	}
+	ephemeral := true
+	return SessionEvent{
+		Data: &TurnResultData{
+			TurnID:           info.TurnID,
+			Content:          content,
+			Postscript:       result.Postscript,
+			FlaggedForReview: result.FlagForReview,
+			ReviewReason:     result.ReviewReason,
+		},
+		Ephemeral: &ephemeral,
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+	}
+}
+
+func derefInt64(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
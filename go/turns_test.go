@@ -0,0 +1,145 @@
+package copilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestTurnTracker(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	userMessage := func(id, content string, at time.Time) SessionEvent {
+		return SessionEvent{ID: id, Timestamp: at, Data: &UserMessageData{Content: content}}
+	}
+
+	t.Run("pairs a user message with the following idle event", func(t *testing.T) {
+		var tracker turnTracker
+		start, complete := tracker.observe("s1", userMessage("evt-1", "hello", t0))
+		if start == nil || complete != nil {
+			t.Fatalf("expected a start notification, got start=%v complete=%v", start, complete)
+		}
+		sum := sha256.Sum256([]byte("hello"))
+		if start.TurnID != "evt-1" || start.PromptHash != hex.EncodeToString(sum[:]) || start.SessionID != "s1" {
+			t.Errorf("unexpected start info: %+v", start)
+		}
+
+		tracker.observe("s1", SessionEvent{Data: &AssistantUsageData{InputTokens: ptr(int64(100)), OutputTokens: ptr(int64(20)), Cost: ptr(1.0)}})
+		tracker.observe("s1", SessionEvent{AgentID: ptr("sub"), Data: &AssistantUsageData{InputTokens: ptr(int64(50)), Cost: ptr(0.5)}})
+
+		_, complete = tracker.observe("s1", SessionEvent{Timestamp: t0.Add(3 * time.Second), Data: &SessionIdleData{}})
+		if complete == nil {
+			t.Fatal("expected a completion notification")
+		}
+		if complete.Outcome != TurnOutcomeCompleted || complete.Duration != 3*time.Second || complete.TurnID != "evt-1" {
+			t.Errorf("unexpected completion info: %+v", complete)
+		}
+		want := TurnUsage{ModelCalls: 2, InputTokens: 150, OutputTokens: 20, Cost: 1.5}
+		if complete.Usage != want {
+			t.Errorf("expected usage %+v, got %+v", want, complete.Usage)
+		}
+	})
+
+	t.Run("treats messages sent during a turn as part of that turn", func(t *testing.T) {
+		var tracker turnTracker
+		tracker.observe("s1", userMessage("evt-1", "first", t0))
+		if start, _ := tracker.observe("s1", userMessage("evt-2", "steer", t0)); start != nil {
+			t.Errorf("expected no new turn for a steering message, got %+v", start)
+		}
+		if start, _ := tracker.observe("s1", SessionEvent{AgentID: ptr("sub"), Data: &UserMessageData{}}); start != nil {
+			t.Errorf("expected no new turn for a sub-agent message, got %+v", start)
+		}
+	})
+
+	t.Run("reports aborted and failed outcomes", func(t *testing.T) {
+		var tracker turnTracker
+		tracker.observe("s1", userMessage("evt-1", "a", t0))
+		_, complete := tracker.observe("s1", SessionEvent{Timestamp: t0, Data: &SessionIdleData{Aborted: ptr(true)}})
+		if complete == nil || complete.Outcome != TurnOutcomeAborted {
+			t.Errorf("expected aborted outcome, got %+v", complete)
+		}
+
+		tracker.observe("s1", userMessage("evt-2", "b", t0))
+		errData := &SessionErrorData{ErrorType: "quota", Message: "out of quota"}
+		_, complete = tracker.observe("s1", SessionEvent{Timestamp: t0, Data: errData})
+		if complete == nil || complete.Outcome != TurnOutcomeFailed || complete.Error != errData {
+			t.Errorf("expected failed outcome, got %+v", complete)
+		}
+		if _, complete := tracker.observe("s1", SessionEvent{Data: &SessionIdleData{}}); complete != nil {
+			t.Errorf("expected idle after an error not to complete another turn, got %+v", complete)
+		}
+	})
+}
+
+func TestSession_TurnHooks(t *testing.T) {
+	session, cleanup := newTestSession()
+	defer cleanup()
+	session.SessionID = "s1"
+
+	started := make(chan TurnStartInfo, 1)
+	completed := make(chan TurnCompleteInfo, 1)
+	session.registerHooks(&SessionHooks{
+		OnTurnStart:    func(info TurnStartInfo) { started <- info },
+		OnTurnComplete: func(info TurnCompleteInfo) { completed <- info },
+	})
+
+	session.dispatchEvent(SessionEvent{ID: "evt-1", Data: &UserMessageData{Content: "hi"}})
+	session.dispatchEvent(SessionEvent{Data: &SessionIdleData{}})
+
+	select {
+	case info := <-started:
+		if info.TurnID != "evt-1" || info.SessionID != "s1" {
+			t.Errorf("unexpected start info: %+v", info)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnTurnStart")
+	}
+	select {
+	case info := <-completed:
+		if info.Outcome != TurnOutcomeCompleted {
+			t.Errorf("unexpected completion info: %+v", info)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnTurnComplete")
+	}
+}
+
+func TestSession_TurnResult(t *testing.T) {
+	session := startReplyTestSession(t,
+		&UserMessageData{Content: "deploy"},
+		&ToolExecutionStartData{ToolCallID: "t1", ToolName: "bash"},
+		&ToolExecutionCompleteData{ToolCallID: "t1", Success: false},
+		&AssistantMessageData{MessageID: "a1", Content: "Deployed."},
+	)
+	var info TurnCompleteInfo
+	session.registerHooks(&SessionHooks{OnTurnResult: func(i TurnCompleteInfo) *TurnResult {
+		info = i
+		return &TurnResult{Postscript: "Generated by AI.", FlagForReview: true, ReviewReason: "a tool call failed"}
+	}})
+	results := make(chan TurnResultData, 1)
+	session.On(func(event SessionEvent) {
+		if d, ok := event.Data.(*TurnResultData); ok {
+			results <- *d
+		}
+	})
+
+	response, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "deploy"})
+	if err != nil {
+		t.Fatalf("SendAndWaitResponse failed: %v", err)
+	}
+	wantCall := TurnToolCall{ToolCallID: "t1", ToolName: "bash", Completed: true}
+	if info.Content != "Deployed." || len(info.ToolCalls) != 1 || info.ToolCalls[0] != wantCall {
+		t.Errorf("unexpected turn info %+v", info)
+	}
+	if response.Content != "Deployed.\n\nGenerated by AI." || response.Result == nil || !response.Result.FlaggedForReview {
+		t.Errorf("unexpected response content %q with result %+v", response.Content, response.Result)
+	}
+	select {
+	case got := <-results:
+		if got.Postscript != "Generated by AI." || got.ReviewReason != "a tool call failed" {
+			t.Errorf("unexpected turn.result event %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the turn.result event")
+	}
+}
@@ -2,7 +2,11 @@ package copilot
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/github/copilot-sdk/go/rpc"
@@ -20,8 +24,8 @@ const (
 
 // RuntimeConnection describes how a [Client] connects to the Copilot runtime.
 //
-// Construct one with a [StdioConnection], [TCPConnection], [URIConnection], or
-// [InProcessConnection] literal and pass it via [ClientOptions.Connection]. When
+// Construct one with a [StdioConnection], [TCPConnection], [URIConnection],
+// [UnixSocketConnection], or [InProcessConnection] literal and pass it via [ClientOptions.Connection]. When
 // [ClientOptions.Connection] is nil, COPILOT_SDK_DEFAULT_CONNECTION may select
 // "inprocess" or "stdio"; when unset, the default is an empty [StdioConnection].
 type RuntimeConnection interface {
@@ -44,8 +48,8 @@ type StdioConnection struct {
 	Path string
 	// Args are extra command-line arguments inserted before SDK-managed args.
 	Args []string
-	// Env are the environment variables for the runtime process, each of the
-	// form "KEY=VALUE". When set, these take precedence over
+	// Env are the environment variables for the runtime process, as in
+	// [ClientOptions.Env]. When set, these take precedence over
 	// [ClientOptions.Env]; setting both is rejected. When nil, the client-level
 	// env (or the current process environment) is used.
 	Env []string
@@ -70,8 +74,8 @@ type TCPConnection struct {
 	Path string
 	// Args are extra command-line arguments inserted before SDK-managed args.
 	Args []string
-	// Env are the environment variables for the runtime process, each of the
-	// form "KEY=VALUE". When set, these take precedence over
+	// Env are the environment variables for the runtime process, as in
+	// [ClientOptions.Env]. When set, these take precedence over
 	// [ClientOptions.Env]; setting both is rejected. When nil, the client-level
 	// env (or the current process environment) is used.
 	Env []string
@@ -85,15 +89,43 @@ func (c TCPConnection) connEnv() []string { return c.Env }
 // The SDK does not spawn a process in this mode.
 type URIConnection struct {
 	// URL of the runtime. Accepts "port", "host:port", or a full URL such
-	// as "http://host:port".
+	// as "http://host:port". An "https://" URL enables TLS with default
+	// settings when TLS is nil.
 	URL string
 	// ConnectionToken authenticates the connection; must match what the
 	// remote runtime expects.
 	ConnectionToken string
+	// TLS, when non-nil, wraps the connection in TLS using this configuration.
+	// Use it to trust a custom CA (RootCAs), present a client certificate
+	// (Certificates), or, for development only, skip verification
+	// (InsecureSkipVerify). ServerName defaults to the URL's host.
+	TLS *tls.Config
+	// Headers are sent in an HTTP/1.1 upgrade request before JSON-RPC traffic
+	// begins, for runtimes deployed behind a gateway that authenticates or
+	// routes connections by header. When Headers and BearerToken are both
+	// empty, no upgrade request is sent.
+	Headers http.Header
+	// BearerToken, when set, is sent as "Authorization: Bearer <token>" in the
+	// upgrade request. It takes precedence over an Authorization entry in Headers.
+	BearerToken string
 }
 
 func (URIConnection) runtimeConnection() {}
 
+// UnixSocketConnection connects to an already-running runtime listening on a
+// unix domain socket. The SDK does not spawn a process in this mode. Use it on
+// hosts where TCP ports are restricted and the application cannot own the
+// runtime's stdio.
+type UnixSocketConnection struct {
+	// Path is the filesystem path of the runtime's socket.
+	Path string
+	// ConnectionToken authenticates the connection; must match what the
+	// remote runtime expects.
+	ConnectionToken string
+}
+
+func (UnixSocketConnection) runtimeConnection() {}
+
 // InProcessConnection hosts the Copilot runtime in-process by loading its native
 // runtime library (a Rust cdylib) and driving JSON-RPC over the library's C ABI,
 // instead of spawning a runtime child process.
@@ -132,10 +164,36 @@ type ClientOptions struct {
 	// LogLevel for the runtime. When empty (the default), the runtime
 	// uses its own default level; the SDK does not pass --log-level.
 	// Recognized values: "none", "error", "warning", "info", "debug", "all".
+	// LogLevel only affects the runtime's own logs; the SDK's logging is
+	// controlled by Logger.
 	LogLevel string
+	// Logger receives the SDK's logs: client and session lifecycle events
+	// and recovered errors, and at debug level each line the spawned
+	// runtime writes to stderr and all JSON-RPC traffic with the runtime.
+	// When nil, [slog.Default] is used.
+	//
+	// Debug logs include message payloads, which contain prompts, tool
+	// results and any provider API keys in [SessionConfig.Provider].
+	Logger *slog.Logger
+	// MetricsSink receives counters and histograms for turns, tool calls,
+	// permission denials and reconnects of sessions created or resumed by
+	// this client. See [MetricsSink] for the metric names. When nil, no
+	// metrics are recorded.
+	MetricsSink MetricsSink
+	// TelemetrySink, when set, receives anonymized usage events about the
+	// SDK: which options are used, how long starts, session creation and
+	// turns take, and classes of errors. See [TelemetrySink]. When nil, no
+	// usage events are recorded.
+	TelemetrySink TelemetrySink
 	// Env are the environment variables for the runtime process (default:
-	// inherits from current process). Each entry is of the form "KEY=VALUE".
-	// If Env contains duplicate keys, only the last value for each key is used.
+	// inherits from current process). Each entry is of the form "KEY=VALUE",
+	// or a bare "KEY" to pass that variable through from the current
+	// process, if it is set. If Env contains duplicate keys, only the last
+	// value for each key is used. When set, Env is the runtime's whole
+	// environment unless InheritEnv is set, so that secrets in the host
+	// environment do not reach the runtime:
+	//
+	//	Env: []string{"PATH", "HOME", "HTTPS_PROXY", "COPILOT_LOG_LEVEL=debug"},
 	//
 	// For child-process transports ([StdioConnection] / [TCPConnection]) the
 	// per-connection Env, when set, takes precedence over this field; setting
@@ -143,16 +201,33 @@ type ClientOptions struct {
 	// runtime shares this process's single environment block) and is rejected
 	// by [NewClient].
 	Env []string
+	// InheritEnv makes Env (or the connection's Env) override the current
+	// process environment rather than replace it. It has no effect when no
+	// Env is set, as the runtime then inherits the whole environment.
+	InheritEnv bool
 	// GitHubToken is the GitHub token to use for authentication.
 	// When provided, the token is passed to the runtime via environment
 	// variable. This takes priority over other authentication methods.
+	// For tokens that expire, use TokenProvider instead.
 	GitHubToken string
+	// TokenProvider supplies the GitHub token to use for authentication,
+	// for tokens that expire, such as GitHub App installation tokens. The
+	// client gets a token when it starts and refreshes it before it
+	// expires, re-authenticating the runtime. See [TokenProvider].
+	// Cannot be combined with GitHubToken.
+	TokenProvider TokenProvider
 	// UseLoggedInUser controls whether to use the logged-in user for
 	// authentication. When true, the runtime attempts to use stored OAuth
 	// tokens or gh CLI auth. When false, only explicit tokens (GitHubToken
 	// or environment variables) are used.
-	// Default: true (but defaults to false when GitHubToken is provided).
+	// Default: true (but defaults to false when GitHubToken or
+	// TokenProvider is provided).
 	UseLoggedInUser *bool
+	// DisableTokenResolution stops a client without GitHubToken or
+	// TokenProvider from resolving a token with [ResolveToken] and starting
+	// the runtime with it. The runtime then finds a token itself, preferring
+	// its own stored login over the gh CLI's.
+	DisableTokenResolution bool
 	// OnListModels is a custom handler for listing available models.
 	// When provided, [Client.ListModels] calls this handler instead of
 	// querying the runtime. Useful in BYOK mode to return models available
@@ -167,7 +242,8 @@ type ClientOptions struct {
 	// non-nil, the client registers as the inference provider on connect, and
 	// the runtime routes its model-layer HTTP and WebSocket traffic through
 	// this handler instead of issuing the calls itself. Works for both CAPI
-	// and BYOK sessions.
+	// and BYOK sessions. WebSocket traffic needs
+	// [CopilotRequestHandler.OpenWebSocket].
 	RequestHandler *CopilotRequestHandler
 	// OnGitHubTelemetry registers a connection-level callback (experimental)
 	// that receives GitHub telemetry events the runtime forwards for sessions
@@ -178,6 +254,11 @@ type ClientOptions struct {
 	// When non-nil, COPILOT_OTEL_ENABLED=true is set and any populated
 	// fields are mapped to the corresponding environment variables.
 	Telemetry *TelemetryConfig
+	// TracePropagator propagates W3C Trace Context between the SDK and the
+	// runtime on CreateSession, ResumeSession and Send, and into tool
+	// handlers' [ToolInvocation.TraceContext]. When nil, no trace context
+	// is propagated.
+	TracePropagator TracePropagator
 	// SessionIdleTimeoutSeconds configures the server-wide session idle
 	// timeout in seconds. Sessions without activity for this duration are
 	// automatically cleaned up. Set to 0 or leave unset to disable.
@@ -188,6 +269,17 @@ type ClientOptions struct {
 	// directory are accessible from GitHub web and mobile.
 	// Ignored when connecting to an existing runtime via [URIConnection].
 	EnableRemoteSessions bool
+	// Offline runs without network access to GitHub, for setups whose models
+	// are served by a local [ProviderConfig] such as Ollama. The runtime does
+	// not log in to GitHub on start, and features that need GitHub return an
+	// [OfflineError] (matching [ErrOffline]): sessions without a Provider or
+	// Providers, cloud and remote sessions, [Client.ListModels] without
+	// OnListModels, and CLI update checks.
+	Offline bool
+	// Network routes the runtime's and the SDK's outbound traffic through a
+	// proxy and trusts extra certificate authorities. See [NetworkConfig]
+	// and [Client.TestConnectivity].
+	Network *NetworkConfig
 	// Mode controls the default tool surface and feature flags presented to
 	// sessions created by this client. The zero value ([ModeCopilotCli])
 	// matches legacy CLI defaults. Set to [ModeEmpty] to opt in to
@@ -197,6 +289,60 @@ type ClientOptions struct {
 	// SessionFS, or a [URIConnection] so the runtime has persistent storage
 	// for session state.
 	Mode ClientMode
+	// Reconnect enables automatic reconnection when the connection to an
+	// existing runtime ([URIConnection] or [UnixSocketConnection]) drops.
+	// Sessions open on the client are re-attached by ID once the connection
+	// is restored. Progress is reported to [Client.OnStateChange] and
+	// [Client.OnConnectionStateChange] handlers.
+	// When nil, a dropped connection is not retried. Ignored for runtimes
+	// spawned by the SDK.
+	Reconnect *ReconnectPolicy
+	// Restart relaunches a CLI spawned by the SDK (stdio or TCP) when its
+	// process exits unexpectedly, then re-attaches open sessions by ID like
+	// [Client.Restart]; the outcome is reported to [Client.OnStateChange]
+	// and [Client.OnCLIRestart] handlers. Requests in flight when the process exits fail with an error
+	// that [IsTransient] accepts, so Retry can send them again once the CLI
+	// is back. When nil, calls fail after a crash until [Client.Restart] is
+	// called.
+	Restart *RestartPolicy
+	// Retry retries [Session.Send], [Session.SendAndWait] and
+	// [Client.CreateSession] when they fail with a transient error (see
+	// [IsTransient]), with jittered exponential backoff. SendAndWait sends
+	// the prompt again when the turn fails with a rate limit or server error
+	// from the model endpoint, so the conversation records it once per
+	// attempt. After a dropped connection to an existing runtime, retries
+	// succeed once Reconnect has restored it. When nil, nothing is retried.
+	Retry *RetryPolicy
+	// Heartbeat enables periodic pings of the runtime so a runtime that stops
+	// responding is detected before the next request hangs. Failures move
+	// the client to [ClientStateDegraded], reported to [Client.OnStateChange]
+	// and [Client.OnUnhealthy] handlers and reflected by [Client.Healthy].
+	// When Reconnect is also set, an unresponsive connection to an existing
+	// runtime is closed and re-established. When nil, no heartbeat is sent.
+	Heartbeat *HeartbeatConfig
+	// SessionRetention, when set, prunes persisted session state once the
+	// client starts and periodically afterwards, deleting sessions that are
+	// too old or beyond the configured count. See [Client.PruneSessions].
+	// When nil, sessions are kept until deleted with [Client.DeleteSession].
+	SessionRetention *SessionRetention
+	// SessionReaper, when set, closes orphaned sessions once the client
+	// starts and periodically afterwards: sessions the runtime still holds in
+	// memory whose owning client has stopped heartbeating, such as one that
+	// crashed. See
+	// [Client.ReapOrphanedSessions]. When nil, such sessions stay loaded until
+	// the runtime exits.
+	SessionReaper *SessionReaper
+	// CLIUpdate configures where [Client.CheckForCLIUpdate] and
+	// [Client.UpdateCLI] look for new CLI releases. When nil, the public npm
+	// registry and the default [EnsureCLI] cache directory are used.
+	CLIUpdate *CLIUpdateOptions
+	// OnInternalError is called with the stack trace when the SDK recovers
+	// from a panic in application code: tool, permission, event, command and
+	// hook handlers. The panic does not crash the process; a panicking tool
+	// call fails with an error result the model can see, and a panicking
+	// permission handler denies the request. Called from the goroutine that
+	// recovered, so it must not block.
+	OnInternalError func(err *InternalError)
 }
 
 // CloudSessionRepository is GitHub repository metadata associated with a cloud session.
@@ -265,6 +411,12 @@ func Int(v int) *int {
 	return &v
 }
 
+// Int64 returns a pointer to the given int64 value.
+// Use for setting a sampling seed: Seed: Int64(42)
+func Int64(v int64) *int64 {
+	return &v
+}
+
 // Known system message section identifiers for the "customize" mode.
 const (
 	// SectionPreamble is the agent identity preamble and mode statement.
@@ -371,11 +523,24 @@ type SystemMessageConfig struct {
 //	&rpc.PermissionDecisionReject{Feedback: &feedback}
 //	&rpc.PermissionDecisionUserNotAvailable{}
 //	&rpc.PermissionDecisionNoResult{}  // decline to respond; another client may answer
+//	&rpc.PermissionDecisionRemember{Decision: &rpc.PermissionDecisionApproveOnce{}, Scope: rpc.PermissionRememberScopeCommand}
 type PermissionHandlerFunc func(request PermissionRequest, invocation PermissionInvocation) (rpc.PermissionDecision, error)
 
 // PermissionInvocation provides context about a permission request
 type PermissionInvocation struct {
 	SessionID string
+	// Diff is the parsed diff of a write request, one entry per file, or
+	// of the new file's contents when the request carries no diff.
+	Diff []FileEditedData
+	// Command is the parsed command line of a shell request. It is nil if
+	// [ParseShellCommand] fails on the request's FullCommandText.
+	Command *ShellCommandLine
+	// URL is the parsed target of a URL request.
+	URL *url.URL
+	// WorkspaceFolder is the folder of the session's workspace (see
+	// [SessionConfig.WorkspaceFolders]) containing every path a read, write
+	// or shell request names, or nil if they are not all in one folder.
+	WorkspaceFolder *WorkspaceFolder
 }
 
 // MCPAuthWwwAuthenticateParams contains parsed parameters from an MCP server's WWW-Authenticate response.
@@ -537,9 +702,14 @@ func (h *PreToolUseHookInput) UnmarshalJSON(data []byte) error {
 type PreToolUseHookOutput struct {
 	PermissionDecision       string `json:"permissionDecision,omitempty"` // "allow", "deny", "ask"
 	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
-	ModifiedArgs             any    `json:"modifiedArgs,omitempty"`
-	AdditionalContext        string `json:"additionalContext,omitempty"`
-	SuppressOutput           bool   `json:"suppressOutput,omitempty"`
+	// ModifiedArgs, when non-nil, replaces the tool's arguments before it runs.
+	// Use it to rewrite a dangerous command, force a dry-run flag, or redirect
+	// file paths into a sandbox. For tools registered on the session, the new
+	// arguments are re-validated against the tool's Parameters schema and the
+	// hook fails if they do not match.
+	ModifiedArgs      any    `json:"modifiedArgs,omitempty"`
+	AdditionalContext string `json:"additionalContext,omitempty"`
+	SuppressOutput    bool   `json:"suppressOutput,omitempty"`
 }
 
 // PreToolUseHandler handles pre-tool-use hook invocations
@@ -553,6 +723,9 @@ type PostToolUseHookInput struct {
 	ToolName         string    `json:"toolName"`
 	ToolArgs         any       `json:"toolArgs"`
 	ToolResult       any       `json:"toolResult"`
+	// ResourceUsage is what the tool execution consumed, when measured. See
+	// [ToolResourceUsage].
+	ResourceUsage *ToolResourceUsage `json:"-"`
 }
 
 // MarshalJSON implements json.Marshaler, emitting Timestamp as Unix milliseconds.
@@ -580,6 +753,10 @@ func (h *PostToolUseHookInput) UnmarshalJSON(data []byte) error {
 
 // PostToolUseHookOutput is the output for a post-tool-use hook
 type PostToolUseHookOutput struct {
+	// ModifiedResult, when non-nil, replaces the tool result before it reaches
+	// the model. Use it to redact secrets, truncate large outputs, or annotate
+	// results. The original result is reported to
+	// [SessionHooks.OnToolResultModified] for auditing.
 	ModifiedResult    any    `json:"modifiedResult,omitempty"`
 	AdditionalContext string `json:"additionalContext,omitempty"`
 	SuppressOutput    bool   `json:"suppressOutput,omitempty"`
@@ -588,6 +765,20 @@ type PostToolUseHookOutput struct {
 // PostToolUseHandler handles post-tool-use hook invocations
 type PostToolUseHandler func(input PostToolUseHookInput, invocation HookInvocation) (*PostToolUseHookOutput, error)
 
+// ToolResultModification records a tool result rewritten by an OnPostToolUse hook.
+type ToolResultModification struct {
+	SessionID      string
+	Timestamp      time.Time
+	ToolName       string
+	ToolArgs       any
+	OriginalResult any
+	ModifiedResult any
+}
+
+// ToolResultModifiedHandler receives an audit record each time an
+// OnPostToolUse hook replaces a tool result.
+type ToolResultModifiedHandler func(modification ToolResultModification)
+
 // PostToolUseFailureHookInput is the input for a post-tool-use-failure hook.
 //
 // Fires after a tool execution whose result was "failure". The CLI extracts
@@ -806,6 +997,52 @@ type ErrorOccurredHookOutput struct {
 // ErrorOccurredHandler handles error-occurred hook invocations
 type ErrorOccurredHandler func(input ErrorOccurredHookInput, invocation HookInvocation) (*ErrorOccurredHookOutput, error)
 
+// PreCompactHookInput is the input for a pre-compact hook, called before
+// the runtime compacts the conversation history
+type PreCompactHookInput struct {
+	SessionID          string    `json:"sessionId"`
+	Timestamp          time.Time `json:"-"`
+	WorkingDirectory   string    `json:"cwd"`
+	Trigger            string    `json:"trigger"` // "auto", "manual"
+	CustomInstructions string    `json:"customInstructions,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, emitting Timestamp as Unix milliseconds.
+func (h PreCompactHookInput) MarshalJSON() ([]byte, error) {
+	type alias PreCompactHookInput
+	return json.Marshal(&struct {
+		Timestamp int64 `json:"timestamp"`
+		alias
+	}{Timestamp: h.Timestamp.UnixMilli(), alias: alias(h)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, parsing Timestamp from Unix milliseconds.
+func (h *PreCompactHookInput) UnmarshalJSON(data []byte) error {
+	type alias PreCompactHookInput
+	aux := &struct {
+		Timestamp int64 `json:"timestamp"`
+		*alias
+	}{alias: (*alias)(h)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	h.Timestamp = time.UnixMilli(aux.Timestamp)
+	return nil
+}
+
+// PreCompactHookOutput is the output for a pre-compact hook
+type PreCompactHookOutput struct {
+	Decision string `json:"decision,omitempty"` // "allow", "block"
+	Reason   string `json:"reason,omitempty"`
+	// CustomInstructions, when non-empty, replaces the instructions that
+	// focus the compaction summary, so the application can steer what the
+	// summary keeps.
+	CustomInstructions string `json:"customInstructions,omitempty"`
+}
+
+// PreCompactHandler handles pre-compact hook invocations
+type PreCompactHandler func(input PreCompactHookInput, invocation HookInvocation) (*PreCompactHookOutput, error)
+
 // PreMCPToolCallHookInput is the input for a pre-mcp-tool-call hook
 type PreMCPToolCallHookInput struct {
 	SessionID        string    `json:"sessionId"`
@@ -864,10 +1101,42 @@ type SessionHooks struct {
 	OnSessionEnd          SessionEndHandler
 	OnErrorOccurred       ErrorOccurredHandler
 	OnPreMCPToolCall      PreMCPToolCallHandler
+	OnPreCompact          PreCompactHandler
+
+	// OnCompaction is called by the SDK after the runtime compacts the
+	// conversation history, with the summary that replaced it and the
+	// events it summarized, so they can be archived for audit.
+	OnCompaction CompactionHandler
+
+	// OnToolResultModified is called by the SDK, not the runtime, whenever
+	// OnPostToolUse returns a ModifiedResult or OnSuspiciousContent strips or
+	// quarantines a result. It receives both the original and the
+	// replacement so the unfiltered output can be kept for audit.
+	OnToolResultModified ToolResultModifiedHandler
+
+	// OnSuspiciousContent is called by the SDK when [DetectSuspiciousContent]
+	// flags a tool result, after OnPostToolUse, or a text attachment of a
+	// message being sent. Its action decides whether the model sees the
+	// content as is, stripped of the findings, or not at all.
+	OnSuspiciousContent SuspiciousContentHandler
+
+	// OnTurnStart and OnTurnComplete are called by the SDK as turns start and
+	// end, derived from the session event stream. They run on the session's
+	// event goroutine before any [Session.On] handlers see the same event.
+	OnTurnStart    TurnStartHandler
+	OnTurnComplete TurnCompleteHandler
+
+	// OnTurnResult is called by the SDK right after OnTurnComplete and may
+	// append a postscript to the turn's final content or flag the turn for
+	// review, for consumers that process turns from events rather than
+	// after SendAndWait returns.
+	OnTurnResult TurnResultHandler
 }
 
 // MCPServerConfig is implemented by MCP server configuration types.
-// Only MCPStdioServerConfig and MCPHTTPServerConfig implement this interface.
+// Only [MCPStdioServerConfig], [MCPHTTPServerConfig] and [MCPSSEServerConfig]
+// implement this interface. Configurations are validated when a session is
+// created or resumed.
 type MCPServerConfig interface {
 	mcpServerConfig()
 }
@@ -902,7 +1171,8 @@ func (c MCPStdioServerConfig) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// MCPHTTPServerConfig configures a remote MCP server (HTTP or SSE).
+// MCPHTTPServerConfig configures a remote MCP server using the streamable
+// HTTP transport.
 //
 // See [MCPStdioServerConfig] for the semantics of the Tools field.
 type MCPHTTPServerConfig struct {
@@ -926,6 +1196,35 @@ func (c MCPHTTPServerConfig) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// MCPSSEServerConfig configures a remote MCP server using the legacy
+// HTTP+SSE transport.
+//
+// See [MCPStdioServerConfig] for the semantics of the Tools field.
+//
+// Deprecated: the MCP specification replaced HTTP+SSE with Streamable HTTP.
+// Use [MCPHTTPServerConfig]; servers that only speak HTTP+SSE keep working
+// until the next major version.
+type MCPSSEServerConfig struct {
+	Tools   []string          `json:"tools,omitzero"`
+	Timeout int               `json:"timeout,omitempty"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitzero"`
+}
+
+func (MCPSSEServerConfig) mcpServerConfig() {}
+
+// MarshalJSON implements json.Marshaler, injecting the "type" discriminator.
+func (c MCPSSEServerConfig) MarshalJSON() ([]byte, error) {
+	type alias MCPSSEServerConfig
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{
+		Type:  "sse",
+		alias: alias(c),
+	})
+}
+
 // CustomAgentConfig configures a custom agent.
 type CustomAgentConfig struct {
 	// Name is the unique name of the custom agent
@@ -1101,6 +1400,12 @@ func (e ExpConfigEntry) MarshalJSON() ([]byte, error) {
 type SessionConfig struct {
 	// SessionID is an optional custom session ID
 	SessionID string
+	// Metadata is caller-defined key/value data saved with the session, such
+	// as tenant IDs or ticket numbers. It is returned in [SessionMetadata] and
+	// can be filtered on with [SessionListFilter.Metadata]. Stored by the SDK
+	// next to the session's event log, so it requires a runtime whose session
+	// store is on this machine's filesystem; not supported with Cloud.
+	Metadata map[string]string
 	// ClientName identifies the application using the SDK.
 	// Included in the User-Agent header for API requests.
 	ClientName string
@@ -1116,6 +1421,9 @@ type SessionConfig struct {
 	// ContextTier pins the session to a context window tier for models that support it.
 	// Use ContextTierDefault or ContextTierLongContext for the currently known tiers.
 	ContextTier ContextTier
+	// Generation configures how the model samples its responses, such as a
+	// seed for reproducible output. See [GenerationConfig].
+	Generation *GenerationConfig
 	// ConfigDirectory overrides the default configuration directory location.
 	// When specified, the session will use this directory for storing config and state.
 	ConfigDirectory string
@@ -1162,6 +1470,57 @@ type SessionConfig struct {
 	// Tools exposes caller-implemented tools to the CLI. A Tool with a nil Handler
 	// is declaration-only; the consumer must resolve its calls via pending tool RPCs.
 	Tools []Tool
+	// PTYShell, when set, replaces the built-in bash tool with one that runs
+	// commands in the SDK's process, in a pseudo-terminal when
+	// [PTYShellConfig.Start] is set, so the host can answer interactive
+	// prompts with [Session.SendToolInput].
+	PTYShell *PTYShellConfig
+	// ShellEnvPolicy controls which host environment variables are visible to
+	// shell commands the SDK-hosted shell runs, for example to keep CI
+	// secrets out of agent shells. Without a policy, or with a nil Deny, the
+	// shell removes [SensitiveEnvPatterns]. The built-in bash tool inherits
+	// the runtime process's whole environment and cannot be filtered per
+	// session, so a policy requires PTYShell; sessions that set one without
+	// it are rejected.
+	ShellEnvPolicy *ShellEnvPolicy
+	// EgressPolicy restricts the network destinations the agent can reach.
+	// URL fetches and shell commands whose permission requests name a blocked
+	// URL are rejected before OnPermissionRequest is called, and each blocked
+	// destination is reported as an egress.denied event. Enforcement relies on
+	// the runtime asking permission, so avoid pre-approving URLs in sessions
+	// that use a policy.
+	EgressPolicy *EgressPolicy
+	// AllowedPaths, when non-empty, limits the files the agent can read and
+	// write, and that shell commands may touch, to these files and
+	// directories. DeniedPaths blocks files and directories, even inside
+	// allowed ones. Relative paths resolve against WorkingDirectory. The SDK
+	// resolves "..", "." and symbolic links in requested paths, rejects
+	// requests for blocked paths before OnPermissionRequest is called, and
+	// reports each as a path.denied event ([PathDeniedData]). Like
+	// EgressPolicy, enforcement relies on the runtime asking permission:
+	// shell commands are checked by the paths the runtime finds in them, and
+	// reads it does not ask about, such as reads in WorkingDirectory, are not
+	// checked. Sessions that also set PTYShell or
+	// EgressPolicy.ProxyShellCommands are rejected, as the SDK-hosted shell's
+	// commands cannot be checked.
+	AllowedPaths []string
+	DeniedPaths  []string
+	// CircuitBreaker, when set, stops calling SDK-hosted tools and MCP
+	// servers that keep failing: after FailureThreshold consecutive failures
+	// their calls are answered with an explanatory failure until a probe
+	// call succeeds. MCP calls are short-circuited by rejecting their
+	// permission requests, before OnPermissionRequest is called.
+	CircuitBreaker *CircuitBreakerConfig
+	// URLAttachments bounds the size and content types of pages attached
+	// with [URLAttachment]. Defaults apply when nil.
+	URLAttachments *URLAttachmentConfig
+	// TimeContext gives the model the user's time zone and, optionally, the
+	// current time with every prompt.
+	TimeContext *TimeContext
+	// Locale gives the model the user's locale, measurement units and
+	// currency with every prompt. Set it, even to an empty LocaleContext, to
+	// use [MessageOptions.Locale].
+	Locale *LocaleContext
 	// SystemMessage configures system message customization
 	SystemMessage *SystemMessageConfig
 	// AvailableTools is a list of tool names to allow. When specified, only these tools will be available.
@@ -1179,6 +1538,21 @@ type SessionConfig struct {
 	// When nil, permission requests are surfaced as events and left pending for the
 	// consumer to resolve via pending permission RPCs.
 	OnPermissionRequest PermissionHandlerFunc
+	// PermissionStore persists the decisions OnPermissionRequest remembers
+	// with [PermissionDecisionRemember] and Persist set, so they apply to
+	// later sessions using the same store. Decisions in the store are
+	// applied to this session's requests without calling
+	// OnPermissionRequest. See [NewFilePermissionStore].
+	PermissionStore PermissionStore
+	// PermissionTimeout, if positive, is how long OnPermissionRequest may
+	// take, for example waiting for a human who never answers. After it,
+	// the SDK resolves the request with PermissionTimeoutDecision and
+	// delivers a permission.timed_out event ([PermissionTimedOutData]); the
+	// handler's eventual result is discarded.
+	PermissionTimeout time.Duration
+	// PermissionTimeoutDecision resolves requests whose handler timed out.
+	// Defaults to a rejection telling the model the user did not respond.
+	PermissionTimeoutDecision rpc.PermissionDecision
 	// OnMCPAuthRequest is an optional handler for MCP OAuth requests from MCP servers.
 	// When provided, the SDK can satisfy MCP server OAuth requests with host-provided
 	// token data or cancellation.
@@ -1188,13 +1562,58 @@ type SessionConfig struct {
 	// Hooks configures hook handlers for session lifecycle events
 	Hooks *SessionHooks
 	// WorkingDirectory is the working directory for the session.
-	// Tool operations will be relative to this directory.
+	// Tool operations will be relative to this directory. The SDK resolves
+	// relative [Image] and [FilePart] paths against it too, and runs
+	// SDK-hosted shell commands ([PTYShellConfig]) in it, so that the
+	// sessions of one client can each work in their own directory.
 	WorkingDirectory string
+	// WorkspaceFolders makes the session's workspace span several folders,
+	// such as the repositories an editor has open side by side. When
+	// WorkingDirectory is empty, the first folder is the working directory.
+	// The runtime's tools may use every folder without asking, permission
+	// handlers are told which folder a request touches
+	// ([PermissionInvocation.WorkspaceFolder]), the model is given the
+	// folders and their git branches with every prompt, and SDK-hosted
+	// tools can resolve the paths the model writes with
+	// [ToolInvocation.ResolvePath]. Relative folders resolve against
+	// WorkingDirectory. Folders must have distinct base names.
+	WorkspaceFolders []string
+	// ScratchDir gives the session a directory of its own for intermediate
+	// files, outside the workspace. It is created on first use, the model
+	// is told to use it, the runtime's reads and writes in it are approved
+	// without asking the permission handler up to the size cap, tools get
+	// it from [ToolInvocation.ScratchDir], and it is deleted with
+	// everything in it when the session is disconnected or deleted.
+	ScratchDir *ScratchDirConfig
 	// Streaming enables streaming of assistant message and reasoning chunks.
 	// When non-nil and true, assistant.message_delta and assistant.reasoning_delta
 	// events with deltaContent are sent as the response is generated.
 	// When nil, the runtime decides (currently defaults to non-streaming).
 	Streaming *bool
+	// DeltaSegmentation, when set, makes the SDK deliver streamed assistant
+	// messages as one assistant.message_delta event per complete word or
+	// sentence instead of the fragments the model produces. The rest of a
+	// message is delivered just before its assistant.message event. Requires
+	// Streaming, except for DeltaSegmentationSpeech, which also adds
+	// assistant.flush_point events for text-to-speech.
+	DeltaSegmentation DeltaSegmentation
+	// BusyPolicy selects what Send and SendAndWait do with a message sent
+	// while a turn is running: wait for the turn and the messages queued
+	// before it ([BusyPolicyQueue]), fail with [ErrSessionBusy]
+	// ([BusyPolicyReject]), or stop the turn and send the message in its
+	// place ([BusyPolicyInterrupt]). When empty, the message is sent at
+	// once and the runtime runs it after the running turn, as it also does
+	// for a message sent while one of the session's handlers is running.
+	// Messages sent with Mode "immediate" go to the running turn
+	// regardless.
+	BusyPolicy BusyPolicy
+	// OutputProfile fits answers to the channel they are shown in: the SDK
+	// tells the model the channel's formatting with every prompt and
+	// converts the content of assistant messages, and of deltas when
+	// DeltaSegmentation is set, that do not fit. Unsegmented deltas are
+	// delivered as the model writes them. See [OutputProfilePlainText] and
+	// [OutputProfileSlack].
+	OutputProfile OutputProfile
 	// IncludeSubAgentStreamingEvents includes sub-agent streaming events in the
 	// event stream. When true, streaming delta events from sub-agents (e.g.,
 	// assistant.message_delta, assistant.reasoning_delta, assistant.streaming_delta
@@ -1420,6 +1839,47 @@ type Tool struct {
 	// Handler is optional. When nil, the SDK exposes the tool declaration but does
 	// not automatically invoke it.
 	Handler ToolHandler `json:"-"`
+	// Endpoint, when set on a tool without a Handler, is the URL of a remote
+	// HTTP service that executes the tool, so tool implementations can live
+	// in separate services. The SDK POSTs each invocation as JSON and relays
+	// the [ToolResult] the service responds with, retrying transient
+	// failures.
+	//
+	// The request body is
+	//
+	//	{"sessionId": "...", "toolCallId": "...", "toolName": "...", "arguments": {...}}
+	//
+	// and the request has these headers:
+	//
+	//   - Content-Type: application/json
+	//   - X-Copilot-Tool-Call-Id: the tool call ID. It is stable across
+	//     retries, so services can use it to deduplicate.
+	//   - X-Copilot-Timestamp: Unix seconds at which the attempt was sent.
+	//   - X-Copilot-Signature: "sha256=" followed by the hex HMAC-SHA256 of
+	//     timestamp + "." + body, when [ToolAuth.HMACSecret] is set.
+	//   - Authorization: "Bearer <token>", when [ToolAuth.BearerToken] is set.
+	//   - traceparent/tracestate, when the invocation carries a trace context.
+	//
+	// A 2xx response must carry a JSON [ToolResult]; an empty resultType is
+	// treated as "success". Network errors, 429 and 5xx responses are retried
+	// with exponential backoff; other responses fail the tool call.
+	//
+	// Example:
+	//
+	//	copilot.Tool{
+	//	    Name:       "lookup_order",
+	//	    Parameters: schema,
+	//	    Endpoint:   "https://orders.internal/tools/lookup",
+	//	    Auth:       &copilot.ToolAuth{HMACSecret: secret},
+	//	}
+	Endpoint string `json:"-"`
+	// Auth authenticates requests to Endpoint.
+	Auth *ToolAuth `json:"-"`
+	// EndpointOptions tunes timeouts and retries for Endpoint.
+	EndpointOptions *ToolEndpointOptions `json:"-"`
+	// RetryPolicy, when set, retries failed executions of the handler before
+	// the failure is reported to the model. See [ToolRetryPolicy].
+	RetryPolicy *ToolRetryPolicy `json:"-"`
 }
 
 // ToolInvocation describes a tool call initiated by Copilot
@@ -1429,6 +1889,10 @@ type ToolInvocation struct {
 	ToolName   string
 	Arguments  any
 
+	// Attempt is the 1-based number of this execution of the call when the
+	// tool has a [Tool.RetryPolicy], and 0 otherwise.
+	Attempt int
+
 	// AvailableTools is a snapshot of the session's currently initialized
 	// tools. The SDK populates it only when this invocation targets the
 	// built-in tool-search tool ("tool_search_tool"), so a tool-search
@@ -1440,8 +1904,35 @@ type ToolInvocation struct {
 	// TraceContext carries the W3C Trace Context propagated from the CLI's
 	// execute_tool span.  Pass this to OpenTelemetry-aware code so that
 	// child spans created inside the handler are parented to the CLI span.
-	// When no trace context is available this will be context.Background().
+	//
+	// It carries the values (auth principals, request IDs, baggage) of the
+	// context passed to the [Session.Send] (or [Session.SendAndWait]) that
+	// started the turn, so downstream HTTP calls carry the original
+	// request's trace. The send's deadline and cancellation do not carry
+	// over: Send returns before the turn's tools run. When a turn was not
+	// started by a send from this client, it derives from
+	// context.Background().
 	TraceContext context.Context
+
+	// scope implements ReportProgress, ResolvePath and ScratchDir; nil
+	// outside a session.
+	scope *toolCallScope
+}
+
+// toolCallScope is what a session provides to the invocation of a tool call.
+type toolCallScope struct {
+	progress         func(message string, pct float64)
+	folders          *workspaceFolders
+	workingDirectory string
+	scratch          *scratchDir
+}
+
+// sessionScope returns inv's scope, or an empty one outside a session.
+func (inv ToolInvocation) sessionScope() *toolCallScope {
+	if inv.scope == nil {
+		return &toolCallScope{}
+	}
+	return inv.scope
 }
 
 // ToolHandler executes a tool invocation.
@@ -1458,6 +1949,11 @@ type ToolResult struct {
 	ToolTelemetry       map[string]any     `json:"toolTelemetry,omitempty"`
 	// ToolReferences lists names of tools returned by a tool-search tool.
 	ToolReferences []string `json:"toolReferences,omitempty"`
+	// Contents are content blocks passed to the model alongside
+	// TextResultForLLM: images, audio, embedded files, file references and
+	// structured JSON. When TextResultForLLM is empty, the text blocks are
+	// used for it. See [ToolResultContent].
+	Contents []ToolResultContent `json:"contents,omitempty"`
 }
 
 // CommandContext provides context about a slash-command invocation.
@@ -1591,6 +2087,57 @@ type ResumeSessionConfig struct {
 	// Tools exposes caller-implemented tools to the CLI. A Tool with a nil Handler
 	// is declaration-only; the consumer must resolve its calls via pending tool RPCs.
 	Tools []Tool
+	// PTYShell, when set, replaces the built-in bash tool with one that runs
+	// commands in the SDK's process, in a pseudo-terminal when
+	// [PTYShellConfig.Start] is set, so the host can answer interactive
+	// prompts with [Session.SendToolInput].
+	PTYShell *PTYShellConfig
+	// ShellEnvPolicy controls which host environment variables are visible to
+	// shell commands the SDK-hosted shell runs, for example to keep CI
+	// secrets out of agent shells. Without a policy, or with a nil Deny, the
+	// shell removes [SensitiveEnvPatterns]. The built-in bash tool inherits
+	// the runtime process's whole environment and cannot be filtered per
+	// session, so a policy requires PTYShell; sessions that set one without
+	// it are rejected.
+	ShellEnvPolicy *ShellEnvPolicy
+	// EgressPolicy restricts the network destinations the agent can reach.
+	// URL fetches and shell commands whose permission requests name a blocked
+	// URL are rejected before OnPermissionRequest is called, and each blocked
+	// destination is reported as an egress.denied event. Enforcement relies on
+	// the runtime asking permission, so avoid pre-approving URLs in sessions
+	// that use a policy.
+	EgressPolicy *EgressPolicy
+	// AllowedPaths, when non-empty, limits the files the agent can read and
+	// write, and that shell commands may touch, to these files and
+	// directories. DeniedPaths blocks files and directories, even inside
+	// allowed ones. Relative paths resolve against WorkingDirectory. The SDK
+	// resolves "..", "." and symbolic links in requested paths, rejects
+	// requests for blocked paths before OnPermissionRequest is called, and
+	// reports each as a path.denied event ([PathDeniedData]). Like
+	// EgressPolicy, enforcement relies on the runtime asking permission:
+	// shell commands are checked by the paths the runtime finds in them, and
+	// reads it does not ask about, such as reads in WorkingDirectory, are not
+	// checked. Sessions that also set PTYShell or
+	// EgressPolicy.ProxyShellCommands are rejected, as the SDK-hosted shell's
+	// commands cannot be checked.
+	AllowedPaths []string
+	DeniedPaths  []string
+	// CircuitBreaker, when set, stops calling SDK-hosted tools and MCP
+	// servers that keep failing: after FailureThreshold consecutive failures
+	// their calls are answered with an explanatory failure until a probe
+	// call succeeds. MCP calls are short-circuited by rejecting their
+	// permission requests, before OnPermissionRequest is called.
+	CircuitBreaker *CircuitBreakerConfig
+	// URLAttachments bounds the size and content types of pages attached
+	// with [URLAttachment]. Defaults apply when nil.
+	URLAttachments *URLAttachmentConfig
+	// TimeContext gives the model the user's time zone and, optionally, the
+	// current time with every prompt.
+	TimeContext *TimeContext
+	// Locale gives the model the user's locale, measurement units and
+	// currency with every prompt. Set it, even to an empty LocaleContext, to
+	// use [MessageOptions.Locale].
+	Locale *LocaleContext
 	// SystemMessage configures system message customization
 	SystemMessage *SystemMessageConfig
 	// AvailableTools is a list of tool names to allow. When specified, only these tools will be available.
@@ -1662,10 +2209,28 @@ type ResumeSessionConfig struct {
 	// ContextTier pins the session to a context window tier for models that support it.
 	// Use ContextTierDefault or ContextTierLongContext for the currently known tiers.
 	ContextTier ContextTier
+	// Generation configures how the model samples its responses, such as a
+	// seed for reproducible output. See [GenerationConfig].
+	Generation *GenerationConfig
 	// OnPermissionRequest is an optional handler for permission requests from the server.
 	// When nil, permission requests are surfaced as events and left pending for the
 	// consumer to resolve via pending permission RPCs.
 	OnPermissionRequest PermissionHandlerFunc
+	// PermissionStore persists the decisions OnPermissionRequest remembers
+	// with [PermissionDecisionRemember] and Persist set, so they apply to
+	// later sessions using the same store. Decisions in the store are
+	// applied to this session's requests without calling
+	// OnPermissionRequest. See [NewFilePermissionStore].
+	PermissionStore PermissionStore
+	// PermissionTimeout, if positive, is how long OnPermissionRequest may
+	// take, for example waiting for a human who never answers. After it,
+	// the SDK resolves the request with PermissionTimeoutDecision and
+	// delivers a permission.timed_out event ([PermissionTimedOutData]); the
+	// handler's eventual result is discarded.
+	PermissionTimeout time.Duration
+	// PermissionTimeoutDecision resolves requests whose handler timed out.
+	// Defaults to a rejection telling the model the user did not respond.
+	PermissionTimeoutDecision rpc.PermissionDecision
 	// OnMCPAuthRequest is an optional handler for MCP OAuth requests from MCP servers.
 	// See SessionConfig.OnMCPAuthRequest.
 	OnMCPAuthRequest MCPAuthHandler
@@ -1674,8 +2239,29 @@ type ResumeSessionConfig struct {
 	// Hooks configures hook handlers for session lifecycle events
 	Hooks *SessionHooks
 	// WorkingDirectory is the working directory for the session.
-	// Tool operations will be relative to this directory.
+	// Tool operations will be relative to this directory. The SDK resolves
+	// relative [Image] and [FilePart] paths against it too, and runs
+	// SDK-hosted shell commands ([PTYShellConfig]) in it, so that the
+	// sessions of one client can each work in their own directory.
 	WorkingDirectory string
+	// WorkspaceFolders makes the session's workspace span several folders,
+	// such as the repositories an editor has open side by side. When
+	// WorkingDirectory is empty, the first folder is the working directory.
+	// The runtime's tools may use every folder without asking, permission
+	// handlers are told which folder a request touches
+	// ([PermissionInvocation.WorkspaceFolder]), the model is given the
+	// folders and their git branches with every prompt, and SDK-hosted
+	// tools can resolve the paths the model writes with
+	// [ToolInvocation.ResolvePath]. Relative folders resolve against
+	// WorkingDirectory. Folders must have distinct base names.
+	WorkspaceFolders []string
+	// ScratchDir gives the session a directory of its own for intermediate
+	// files, outside the workspace. It is created on first use, the model
+	// is told to use it, the runtime's reads and writes in it are approved
+	// without asking the permission handler up to the size cap, tools get
+	// it from [ToolInvocation.ScratchDir], and it is deleted with
+	// everything in it when the session is disconnected or deleted.
+	ScratchDir *ScratchDirConfig
 	// ConfigDirectory overrides the default configuration directory location.
 	ConfigDirectory string
 	// EnableConfigDiscovery, when non-nil, controls automatic discovery of MCP server configurations
@@ -1717,6 +2303,30 @@ type ResumeSessionConfig struct {
 	// events with deltaContent are sent as the response is generated.
 	// When nil, the runtime decides (currently defaults to non-streaming).
 	Streaming *bool
+	// DeltaSegmentation, when set, makes the SDK deliver streamed assistant
+	// messages as one assistant.message_delta event per complete word or
+	// sentence instead of the fragments the model produces. The rest of a
+	// message is delivered just before its assistant.message event. Requires
+	// Streaming, except for DeltaSegmentationSpeech, which also adds
+	// assistant.flush_point events for text-to-speech.
+	DeltaSegmentation DeltaSegmentation
+	// BusyPolicy selects what Send and SendAndWait do with a message sent
+	// while a turn is running: wait for the turn and the messages queued
+	// before it ([BusyPolicyQueue]), fail with [ErrSessionBusy]
+	// ([BusyPolicyReject]), or stop the turn and send the message in its
+	// place ([BusyPolicyInterrupt]). When empty, the message is sent at
+	// once and the runtime runs it after the running turn, as it also does
+	// for a message sent while one of the session's handlers is running.
+	// Messages sent with Mode "immediate" go to the running turn
+	// regardless.
+	BusyPolicy BusyPolicy
+	// OutputProfile fits answers to the channel they are shown in: the SDK
+	// tells the model the channel's formatting with every prompt and
+	// converts the content of assistant messages, and of deltas when
+	// DeltaSegmentation is set, that do not fit. Unsegmented deltas are
+	// delivered as the model writes them. See [OutputProfilePlainText] and
+	// [OutputProfileSlack].
+	OutputProfile OutputProfile
 	// IncludeSubAgentStreamingEvents includes sub-agent streaming events in the
 	// event stream. When true, streaming delta events from sub-agents (e.g.,
 	// assistant.message_delta, assistant.reasoning_delta, assistant.streaming_delta
@@ -1876,7 +2486,7 @@ type ProviderTokenArgs struct {
 type BearerTokenProvider func(args ProviderTokenArgs) (string, error)
 
 type ProviderConfig struct {
-	// Type is the provider type: "openai", "azure", or "anthropic". Defaults to "openai".
+	// Type is the provider type: "openai", "azure", "anthropic", "bedrock", or "vertex". Defaults to "openai".
 	Type string `json:"type,omitempty"`
 	// WireAPI is the API format (openai/azure only): "completions" or "responses". Defaults to "completions".
 	WireAPI string `json:"wireApi,omitempty"`
@@ -1894,6 +2504,14 @@ type ProviderConfig struct {
 	BearerToken string `json:"bearerToken,omitempty"`
 	// Azure contains Azure-specific options
 	Azure *AzureProviderOptions `json:"azure,omitempty"`
+	// Bedrock contains Amazon Bedrock options for Type "bedrock". Bedrock
+	// providers are served through an SDK-side proxy and never sent to the
+	// runtime as is. See [BedrockProvider].
+	Bedrock *BedrockProviderOptions `json:"-"`
+	// Vertex contains Google Vertex AI options for Type "vertex". Vertex
+	// providers are sent to the runtime as OpenAI providers for the Vertex
+	// AI endpoint. See [VertexProvider].
+	Vertex *VertexProviderOptions `json:"-"`
 	// Headers are custom HTTP headers included in outbound provider requests.
 	Headers map[string]string `json:"headers,omitempty"`
 	// ModelID is the well-known model name used by the runtime to look up
@@ -1966,7 +2584,8 @@ type CapiSessionOptions struct {
 
 // AzureProviderOptions contains Azure-specific provider configuration
 type AzureProviderOptions struct {
-	// APIVersion is the Azure API version. Defaults to "2024-10-21".
+	// APIVersion is the Azure API version. Required; when
+	// [ProviderConfig.Azure] is nil the runtime uses "2024-10-21".
 	APIVersion string `json:"apiVersion,omitempty"`
 }
 
@@ -2072,8 +2691,18 @@ type ToolBinaryResult struct {
 type MessageOptions struct {
 	// Prompt is the message to send
 	Prompt string
+	// Parts composes the message from ordered parts instead of Prompt:
+	// [TextPart], [CodePart], [FilePart] and [ImagePart]. Text and code
+	// parts become the prompt, separated by blank lines; files and images
+	// are attached, in order, after Attachments. Cannot be combined with
+	// Prompt.
+	Parts []MessagePart
 	// Attachments are file or directory attachments
 	Attachments []Attachment
+	// Images are shown to vision-capable models as image content. They are
+	// loaded when the message is sent and added after Attachments. See
+	// [Image].
+	Images []Image
 	// Mode is the message delivery mode (default: "enqueue")
 	Mode string
 	// AgentMode is the UI mode the agent was in when this message was sent
@@ -2084,6 +2713,23 @@ type MessageOptions struct {
 	RequestHeaders map[string]string
 	// DisplayPrompt, if provided, is shown in the timeline instead of Prompt.
 	DisplayPrompt string
+	// Locale overrides fields of [SessionConfig.Locale] for this message
+	// only. The session must have been created with a Locale.
+	Locale *LocaleContext
+	// Limits bounds the tool calls, output tokens and duration of the turn
+	// this message starts. When a limit is exceeded the SDK delivers a
+	// turn.limit_exceeded event ([TurnLimitExceededData]) and aborts the
+	// turn. A later message with its own Limits replaces them.
+	Limits *TurnLimits
+	// Deadline is when the turn this message starts must end. At the
+	// deadline the SDK aborts the turn, and [Session.SendAndWaitResponse]
+	// returns what the assistant had said with [ErrTurnDeadlineExceeded].
+	// Unlike the context passed to Send, which bounds the call, the deadline
+	// bounds the turn and salvages its partial answer.
+	Deadline time.Time
+	// Timeout sets Deadline relative to the send. If both are set, the
+	// earlier applies.
+	Timeout time.Duration
 }
 
 // AgentMode is the UI mode the agent is in for a given turn. See
@@ -2181,6 +2827,9 @@ type SessionListFilter struct {
 	Repository string `json:"repository,omitempty"`
 	// Branch filters by branch
 	Branch string `json:"branch,omitempty"`
+	// Metadata keeps only sessions whose [SessionConfig.Metadata] contains
+	// every given key/value pair. Applied by the SDK after listing.
+	Metadata map[string]string `json:"-"`
 }
 
 // SessionMetadata contains metadata about a session
@@ -2191,6 +2840,11 @@ type SessionMetadata struct {
 	Summary      *string         `json:"summary,omitempty"`
 	IsRemote     bool            `json:"isRemote"`
 	Context      *SessionContext `json:"context,omitempty"`
+	// Model is the model the session last selected, read from its event
+	// log. It is empty for remote sessions and when the log names none.
+	Model string `json:"-"`
+	// Metadata is the session's [SessionConfig.Metadata], if any.
+	Metadata map[string]string `json:"-"`
 }
 
 // SessionLifecycleEventType represents the type of session lifecycle event
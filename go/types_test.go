@@ -446,3 +446,18 @@ func TestElicitationResult_JSONOmitsNilContent(t *testing.T) {
 		t.Errorf("expected content to be omitted for nil map, got %v", decoded["content"])
 	}
 }
+
+func TestMCPSSEServerConfig_JSONIncludesTypeDiscriminator(t *testing.T) {
+	data, err := json.Marshal(MCPSSEServerConfig{URL: "https://mcp.example.com/sse"})
+	if err != nil {
+		t.Fatalf("failed to marshal MCPSSEServerConfig: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal MCPSSEServerConfig: %v", err)
+	}
+	if decoded["type"] != "sse" || decoded["url"] != "https://mcp.example.com/sse" {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
@@ -0,0 +1,215 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// AttachmentTypeURL is the type of attachments created by [URLAttachment].
+const AttachmentTypeURL AttachmentType = "url"
+
+// URLAttachmentConfig bounds what a [URLAttachment] may inject into a
+// message. See [SessionConfig.URLAttachments].
+type URLAttachmentConfig struct {
+	// MaxBytes is the largest page that may be attached. Larger pages fail
+	// the send. Defaults to 1 MiB.
+	MaxBytes int64
+	// AllowedContentTypes are the media types that may be attached, such as
+	// "text/html". An entry ending in "/*" matches a whole type. Defaults to
+	// text/*, application/json, application/xml and application/yaml.
+	AllowedContentTypes []string
+}
+
+const defaultURLAttachmentMaxBytes = 1 << 20
+
+var defaultURLAttachmentContentTypes = []string{"text/*", "application/json", "application/xml", "application/yaml"}
+
+// URLAttachment returns an attachment whose content is fetched from rawURL
+// when the message is sent, and attached as a text blob named after the URL.
+// The fetch is bounded by the session's [URLAttachmentConfig], uses the HTTP
+// client of [ClientOptions.Network] when set, and honors the session's
+// [EgressPolicy]. The content is screened by
+// [SessionHooks.OnSuspiciousContent] like any other text attachment. A page
+// that cannot be fetched or is not allowed fails the send.
+//
+// The runtime has no URL attachment type, so the SDK fetches the page, not
+// the runtime.
+//
+// Example:
+//
+//	_, err := session.Send(ctx, copilot.MessageOptions{
+//		Prompt:      "Summarize the release notes",
+//		Attachments: []copilot.Attachment{copilot.URLAttachment("https://go.dev/doc/go1.24")},
+//	})
+func URLAttachment(rawURL string) Attachment {
+	raw, _ := json.Marshal(struct {
+		Type AttachmentType `json:"type"`
+		URL  string         `json:"url"`
+	}{AttachmentTypeURL, rawURL})
+	return &rpc.RawAttachmentData{Discriminator: AttachmentTypeURL, Raw: raw}
+}
+
+// attachmentURL returns the URL of an attachment created by [URLAttachment].
+func attachmentURL(attachment Attachment) (string, bool) {
+	var raw *rpc.RawAttachmentData
+	switch a := attachment.(type) {
+	case *rpc.RawAttachmentData:
+		raw = a
+	case rpc.RawAttachmentData:
+		raw = &a
+	default:
+		return "", false
+	}
+	if raw.Discriminator != AttachmentTypeURL {
+		return "", false
+	}
+	var data struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(raw.Raw, &data); err != nil {
+		return "", false
+	}
+	return data.URL, true
+}
+
+// resolveURLAttachments replaces URL attachments with the fetched content.
+// The caller's attachments are not modified.
+func (s *Session) resolveURLAttachments(ctx context.Context, attachments []Attachment) ([]Attachment, error) {
+	var resolved []Attachment
+	for i, attachment := range attachments {
+		rawURL, ok := attachmentURL(attachment)
+		if !ok {
+			continue
+		}
+		blob, err := s.fetchURLAttachment(ctx, rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach %s: %w", rawURL, err)
+		}
+		if resolved == nil {
+			resolved = append([]Attachment{}, attachments...)
+		}
+		resolved[i] = blob
+	}
+	if resolved == nil {
+		return attachments, nil
+	}
+	return resolved, nil
+}
+
+// fetchURLAttachment fetches a page within the session's limits.
+func (s *Session) fetchURLAttachment(ctx context.Context, rawURL string) (*AttachmentBlob, error) {
+	config := s.urlAttachments
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultURLAttachmentMaxBytes
+	}
+	if len(config.AllowedContentTypes) == 0 {
+		config.AllowedContentTypes = defaultURLAttachmentContentTypes
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("only http and https URLs can be attached")
+	}
+	if err := s.checkURLEgress(u); err != nil {
+		return nil, err
+	}
+	httpClient, err := s.fetchClient()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type: %w", err)
+	}
+	if !contentTypeAllowed(mediaType, config.AllowedContentTypes) {
+		return nil, fmt.Errorf("content type %s is not allowed", mediaType)
+	}
+	if resp.ContentLength > config.MaxBytes {
+		return nil, fmt.Errorf("content is larger than %d bytes", config.MaxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, config.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > config.MaxBytes {
+		return nil, fmt.Errorf("content is larger than %d bytes", config.MaxBytes)
+	}
+	return BytesAttachment(rawURL, data, mediaType), nil
+}
+
+// checkURLEgress applies the session's egress policy to u.
+func (s *Session) checkURLEgress(u *url.URL) error {
+	if s.egress == nil {
+		return nil
+	}
+	if reason := s.egress.check(u.Hostname()); reason != "" {
+		return fmt.Errorf("%w: %s", errEgressDenied, reason)
+	}
+	return nil
+}
+
+// fetchClient returns the client for content the SDK fetches on the
+// session's behalf. With an egress policy, it checks each redirect too, so
+// an allowed host cannot bounce the fetch to a blocked one; callers check
+// the first URL with [Session.checkURLEgress].
+func (s *Session) fetchClient() (*http.Client, error) {
+	httpClient := http.DefaultClient
+	if s.owner != nil {
+		var err error
+		if httpClient, err = s.owner.httpClient(nil); err != nil {
+			return nil, fmt.Errorf("failed to configure network: %w", err)
+		}
+	}
+	if s.egress == nil {
+		return httpClient, nil
+	}
+	client := *httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return s.checkURLEgress(req.URL)
+	}
+	return &client, nil
+}
+
+// contentTypeAllowed reports whether mediaType matches one of the allowed
+// media types or "type/*" patterns.
+func contentTypeAllowed(mediaType string, allowed []string) bool {
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
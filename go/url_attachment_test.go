@@ -0,0 +1,132 @@
+package copilot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSession_FetchURLAttachment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notes":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Write([]byte("# Release notes"))
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0, 1, 2})
+		case "/redirect":
+			http.Redirect(w, r, "http://blocked.example/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("attaches the page as a text blob", func(t *testing.T) {
+		session := &Session{}
+		blob, err := session.fetchURLAttachment(t.Context(), server.URL+"/notes")
+		if err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+		data, _ := base64.StdEncoding.DecodeString(*blob.Data)
+		if string(data) != "# Release notes" || blob.MIMEType != "text/markdown" || *blob.DisplayName != server.URL+"/notes" {
+			t.Errorf("unexpected blob %+v with data %q", blob, data)
+		}
+	})
+
+	t.Run("enforces the size cap", func(t *testing.T) {
+		session := &Session{urlAttachments: URLAttachmentConfig{MaxBytes: 10}}
+		if _, err := session.fetchURLAttachment(t.Context(), server.URL+"/large"); err == nil {
+			t.Error("expected a page over MaxBytes to be rejected")
+		}
+	})
+
+	t.Run("enforces the content-type allowlist", func(t *testing.T) {
+		session := &Session{}
+		if _, err := session.fetchURLAttachment(t.Context(), server.URL+"/binary"); err == nil {
+			t.Error("expected binary content to be rejected by default")
+		}
+		session.urlAttachments.AllowedContentTypes = []string{"application/*"}
+		if _, err := session.fetchURLAttachment(t.Context(), server.URL+"/binary"); err != nil {
+			t.Errorf("expected an allowed content type to be attached: %v", err)
+		}
+		if _, err := session.fetchURLAttachment(t.Context(), server.URL+"/notes"); err == nil {
+			t.Error("expected text to be rejected when only application/* is allowed")
+		}
+	})
+
+	t.Run("rejects failed requests and other schemes", func(t *testing.T) {
+		session := &Session{}
+		for _, rawURL := range []string{server.URL + "/missing", "file:///etc/passwd"} {
+			if _, err := session.fetchURLAttachment(t.Context(), rawURL); err == nil {
+				t.Errorf("expected %s to be rejected", rawURL)
+			}
+		}
+	})
+
+	t.Run("honors the egress policy across redirects", func(t *testing.T) {
+		guard, err := newEgressGuard(&EgressPolicy{DenyDomains: []string{"blocked.example"}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session := &Session{egress: guard}
+		if _, err := session.fetchURLAttachment(t.Context(), "http://blocked.example/"); !errors.Is(err, errEgressDenied) {
+			t.Errorf("expected a blocked host to be denied, got %v", err)
+		}
+		if _, err := session.fetchURLAttachment(t.Context(), server.URL+"/redirect"); !errors.Is(err, errEgressDenied) {
+			t.Errorf("expected a redirect to a blocked host to be denied, got %v", err)
+		}
+	})
+}
+
+func TestSession_SendURLAttachment(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("page content"))
+	}))
+	defer page.Close()
+
+	type sendRequest struct {
+		Attachments []map[string]any `json:"attachments"`
+	}
+	requests := make(chan sendRequest, 1)
+	client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+		"session.send": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+			var req sendRequest
+			json.Unmarshal(params, &req)
+			requests <- req
+			return []byte(`{"messageId":"m1"}`), nil
+		},
+	})
+	session := newSession("s1", client.client, "")
+	t.Cleanup(func() { close(session.done) })
+
+	attachments := []Attachment{URLAttachment(page.URL)}
+	if _, err := session.Send(t.Context(), MessageOptions{Prompt: "summarize", Attachments: attachments}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	req := <-requests
+	if len(req.Attachments) != 1 || req.Attachments[0]["type"] != "blob" {
+		t.Fatalf("expected the page as a blob, got %+v", req.Attachments)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(req.Attachments[0]["data"].(string)); string(data) != "page content" {
+		t.Errorf("unexpected attachment data %q", data)
+	}
+	if _, ok := attachmentURL(attachments[0]); !ok {
+		t.Error("expected the caller's attachment to be left unchanged")
+	}
+
+	if _, err := session.Send(t.Context(), MessageOptions{Prompt: "hi", Attachments: []Attachment{URLAttachment(page.URL + "\x00")}}); err == nil {
+		t.Error("expected an invalid URL to fail the send")
+	}
+}
@@ -0,0 +1,71 @@
+package copilot
+
+import "sync"
+
+// Response is the result of a turn, as returned by
+// [Session.SendAndWaitResponse].
+type Response struct {
+	// Message is the final assistant message event, or nil if none was
+	// received.
+	Message *SessionEvent
+	// Content is the content of Message, followed by the postscript of
+	// Result, if any.
+	Content string
+	// Usage aggregates the model calls made while waiting for the turn,
+	// including calls made by sub-agents.
+	Usage TurnUsage
+	// Provenance lists the skills, agents, MCP servers and attachments
+	// involved in the turn.
+	Provenance Provenance
+	// Result is what [SessionHooks.OnTurnResult] returned for the turn, or
+	// nil if it is not set or returned nil.
+	Result *TurnResultData
+	// Partial reports that the turn was interrupted at its deadline, with
+	// [ErrTurnDeadlineExceeded], or aborted, with a [*TurnAbortedError].
+	// Content is then the text streamed for the
+	// message in progress or, if none, the last complete message.
+	Partial bool
+}
+
+// add records the usage of one model call.
+func (u *TurnUsage) add(d *AssistantUsageData) {
+	u.ModelCalls++
+	u.InputTokens += derefInt64(d.InputTokens)
+	u.OutputTokens += derefInt64(d.OutputTokens)
+	u.CacheReadTokens += derefInt64(d.CacheReadTokens)
+	u.CacheWriteTokens += derefInt64(d.CacheWriteTokens)
+	u.ReasoningTokens += derefInt64(d.ReasoningTokens)
+	if d.Cost != nil {
+		u.Cost += *d.Cost
+	}
+}
+
+// usageTracker accumulates the usage of every model call of a session.
+type usageTracker struct {
+	mu    sync.Mutex
+	total TurnUsage
+}
+
+func (t *usageTracker) observe(event SessionEvent) {
+	if d, ok := event.Data.(*AssistantUsageData); ok {
+		t.mu.Lock()
+		t.total.add(d)
+		t.mu.Unlock()
+	}
+}
+
+// Usage returns the model usage accumulated by the session: every model
+// call reported since the session was created or resumed by this client,
+// including calls made by sub-agents and between turns, such as for
+// compaction. Usage from before a resume is not included.
+//
+// Example:
+//
+//	usage := session.Usage()
+//	log.Printf("tenant %s: %d input, %d output tokens, %.1f premium requests",
+//		tenant, usage.InputTokens, usage.OutputTokens, usage.Cost)
+func (s *Session) Usage() TurnUsage {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	return s.usage.total
+}
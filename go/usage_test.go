@@ -0,0 +1,35 @@
+package copilot
+
+import "testing"
+
+func TestSession_Usage(t *testing.T) {
+	session := startReplyTestSession(t,
+		&AssistantUsageData{Model: "gpt-5", InputTokens: ptr[int64](1000), OutputTokens: ptr[int64](50), CacheReadTokens: ptr[int64](800), Cost: ptr(1.0)},
+		&AssistantUsageData{Model: "gpt-5", InputTokens: ptr[int64](1200), OutputTokens: ptr[int64](20), Initiator: ptr("sub-agent")},
+		&AssistantMessageData{MessageID: "a1", Content: "It is 4."},
+	)
+
+	t.Run("reports the usage of the turn on the response", func(t *testing.T) {
+		response, err := session.SendAndWaitResponse(t.Context(), MessageOptions{Prompt: "2+2?"})
+		if err != nil {
+			t.Fatalf("SendAndWaitResponse failed: %v", err)
+		}
+		if response.Content != "It is 4." || response.Message == nil {
+			t.Errorf("unexpected response %+v", response)
+		}
+		want := TurnUsage{ModelCalls: 2, InputTokens: 2200, OutputTokens: 70, CacheReadTokens: 800, Cost: 1}
+		if response.Usage != want {
+			t.Errorf("expected usage %+v, got %+v", want, response.Usage)
+		}
+	})
+
+	t.Run("accumulates usage across turns", func(t *testing.T) {
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "again"}); err != nil {
+			t.Fatalf("SendAndWait failed: %v", err)
+		}
+		usage := session.Usage()
+		if usage.ModelCalls != 4 || usage.InputTokens != 4400 || usage.Cost != 2 {
+			t.Errorf("unexpected session usage %+v", usage)
+		}
+	})
+}
@@ -0,0 +1,275 @@
+package copilot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// VertexProviderOptions configures a provider of Type "vertex". See
+// [VertexProvider].
+type VertexProviderOptions struct {
+	// Project is the Google Cloud project ID. Defaults to the
+	// GOOGLE_CLOUD_PROJECT environment variable, then the project of the
+	// service account key in CredentialsJSON.
+	Project string
+	// Location is the Vertex AI region, such as "us-central1", or "global".
+	// Defaults to the GOOGLE_CLOUD_LOCATION environment variable, then
+	// "global".
+	Location string
+	// CredentialsJSON is a service account key or gcloud user credentials
+	// file. Defaults to Application Default Credentials: the
+	// GOOGLE_APPLICATION_CREDENTIALS file, the gcloud application-default
+	// credentials, then the metadata server of the Google Cloud machine
+	// the SDK runs on. It is not used when the provider has a BearerToken
+	// or BearerTokenProvider.
+	CredentialsJSON []byte
+}
+
+// VertexProvider configures Google Vertex AI as a BYOK provider, for Gemini
+// models. Use [VertexProvider.Config] to get the [ProviderConfig] for
+// [SessionConfig.Provider].
+//
+// Sessions use Vertex AI's OpenAI-compatible Chat Completions endpoint,
+// through a proxy the SDK runs for the session, with OAuth access tokens the
+// SDK fetches and refreshes through the provider's BearerTokenProvider.
+// Models are named as on that endpoint, such as "google/gemini-2.5-pro"; a
+// name without a publisher is given the "google/" prefix on each request, so
+// models switched to with [Session.SetModel] are too. As the runtime must
+// reach the proxy, Vertex AI is not supported with a [URIConnection].
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Model: "gemini-2.5-pro",
+//	    Provider: copilot.VertexProvider{
+//	        Project:  "my-project",
+//	        Location: "us-central1",
+//	    }.Config(),
+//	})
+type VertexProvider struct {
+	// Project, Location and CredentialsJSON are as in
+	// [VertexProviderOptions].
+	Project         string
+	Location        string
+	CredentialsJSON []byte
+	// BearerToken is an OAuth access token, used instead of fetching one.
+	BearerToken string
+	// BearerTokenProvider resolves an OAuth access token on demand, such as
+	// from a golang.org/x/oauth2/google token source. See
+	// [ProviderConfig.BearerTokenProvider].
+	BearerTokenProvider BearerTokenProvider
+	// Headers are custom HTTP headers included in provider requests.
+	Headers map[string]string
+	// ModelID, WireModel, MaxPromptTokens and MaxOutputTokens are as in
+	// [ProviderConfig].
+	ModelID         string
+	WireModel       string
+	MaxPromptTokens int
+	MaxOutputTokens int
+}
+
+// Config returns the provider as a [ProviderConfig].
+func (p VertexProvider) Config() *ProviderConfig {
+	return &ProviderConfig{
+		Type:        "vertex",
+		BearerToken: p.BearerToken,
+		Vertex: &VertexProviderOptions{
+			Project:         p.Project,
+			Location:        p.Location,
+			CredentialsJSON: p.CredentialsJSON,
+		},
+		Headers:             p.Headers,
+		ModelID:             p.ModelID,
+		WireModel:           p.WireModel,
+		MaxPromptTokens:     p.MaxPromptTokens,
+		MaxOutputTokens:     p.MaxOutputTokens,
+		BearerTokenProvider: p.BearerTokenProvider,
+	}
+}
+
+// Validate reports the first problem with the provider's options.
+func (p VertexProvider) Validate() error {
+	return p.Config().Validate()
+}
+
+// validateVertexProvider validates a provider of Type "vertex".
+func validateVertexProvider(p *ProviderConfig) error {
+	if p.BaseURL != "" {
+		return errors.New(`BaseURL does not apply to Type "vertex": it is derived from Vertex.Project and Vertex.Location`)
+	}
+	if p.Azure != nil || p.Bedrock != nil || p.Transport != "" {
+		return errors.New(`Azure, Bedrock and Transport do not apply to Type "vertex"`)
+	}
+	if p.WireAPI != "" && p.WireAPI != "completions" {
+		return errors.New(`Vertex AI supports only WireAPI "completions"`)
+	}
+	if p.APIKey != "" {
+		return errors.New(`APIKey is not supported for Type "vertex": set BearerToken, BearerTokenProvider or Vertex.CredentialsJSON`)
+	}
+	_, _, err := vertexEndpoint(p.Vertex)
+	return err
+}
+
+// vertexEndpoint returns the project and location of a Vertex provider,
+// with their defaults applied.
+func vertexEndpoint(options *VertexProviderOptions) (project, location string, err error) {
+	if options == nil {
+		options = &VertexProviderOptions{}
+	}
+	var credentials *googleCredentialsFile
+	if options.CredentialsJSON != nil {
+		if credentials, err = parseGoogleCredentials(options.CredentialsJSON); err != nil {
+			return "", "", fmt.Errorf("invalid Vertex.CredentialsJSON: %w", err)
+		}
+	}
+	project = cmp.Or(options.Project, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	if project == "" && credentials != nil {
+		project = credentials.ProjectID
+	}
+	if project == "" {
+		return "", "", errors.New("Vertex.Project is required when GOOGLE_CLOUD_PROJECT is not set")
+	}
+	location = cmp.Or(options.Location, os.Getenv("GOOGLE_CLOUD_LOCATION"), "global")
+	for _, c := range location {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return "", "", fmt.Errorf("invalid Vertex.Location %q", location)
+		}
+	}
+	return project, location, nil
+}
+
+// vertexWireProvider returns the provider config to send to the runtime in
+// place of provider if it is of Type "vertex": an OpenAI provider for a
+// proxy to the Vertex AI endpoint, with a BearerTokenProvider fetching
+// access tokens, and the proxy. Other providers are returned unchanged,
+// with a nil proxy.
+func (c *Client) vertexWireProvider(provider *ProviderConfig) (*vertexProxy, *ProviderConfig, error) {
+	if provider == nil || provider.Type != "vertex" {
+		return nil, provider, nil
+	}
+	if c.isExternalServer && c.socketPath == "" {
+		return nil, nil, errors.New(`Type "vertex" is not supported with a URIConnection: the runtime cannot reach the SDK's Vertex AI proxy`)
+	}
+	endpoint, err := vertexBaseURL(provider.Vertex)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := c.httpClient(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	proxy, err := startVertexProxy(client, endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wire := *provider
+	wire.Type = "openai"
+	wire.WireAPI = "completions"
+	wire.BaseURL = proxy.url
+	wire.Vertex = nil
+	if wire.BearerToken == "" && wire.BearerTokenProvider == nil {
+		var credentials *googleCredentialsFile
+		if provider.Vertex != nil && provider.Vertex.CredentialsJSON != nil {
+			credentials, _ = parseGoogleCredentials(provider.Vertex.CredentialsJSON)
+		}
+		source := newGoogleTokenSource(client, credentials)
+		wire.BearerTokenProvider = func(ProviderTokenArgs) (string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return source.Token(ctx)
+		}
+	}
+	return proxy, &wire, nil
+}
+
+// vertexBaseURL returns the OpenAI-compatible endpoint of a Vertex
+// provider's project and location.
+func vertexBaseURL(options *VertexProviderOptions) (string, error) {
+	project, location, err := vertexEndpoint(options)
+	if err != nil {
+		return "", err
+	}
+	host := location + "-aiplatform.googleapis.com"
+	if location == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	return "https://" + host + "/v1/projects/" + project + "/locations/" + location + "/endpoints/openapi", nil
+}
+
+// vertexModel names model as Vertex AI's OpenAI-compatible endpoint expects:
+// a name without a publisher is given the "google/" prefix.
+func vertexModel(model string) string {
+	if model != "" && !strings.Contains(model, "/") {
+		return "google/" + model
+	}
+	return model
+}
+
+// vertexProxy forwards the runtime's requests to the Vertex AI endpoint,
+// naming the model of each request with [vertexModel], so that a model
+// switched to with [Session.SetModel] is named correctly too. The runtime's
+// access token is forwarded as is, so the proxy grants nothing a caller
+// does not already have.
+type vertexProxy struct {
+	server *http.Server
+	url    string
+}
+
+func startVertexProxy(client *http.Client, endpoint string) (*vertexProxy, error) {
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Vertex AI proxy: %w", err)
+	}
+	handler := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			if r.Out.Body == nil || r.Out.Body == http.NoBody {
+				return
+			}
+			body, err := io.ReadAll(r.Out.Body)
+			r.Out.Body.Close()
+			var fields map[string]json.RawMessage
+			if err == nil && json.Unmarshal(body, &fields) == nil {
+				var model string
+				if json.Unmarshal(fields["model"], &model) == nil && model != "" {
+					fields["model"], _ = json.Marshal(vertexModel(model))
+					body, _ = json.Marshal(fields)
+				}
+			}
+			r.Out.Body = io.NopCloser(bytes.NewReader(body))
+			r.Out.ContentLength = int64(len(body))
+			r.Out.Header.Del("Content-Length")
+		},
+		Transport: client.Transport,
+	}
+	p := &vertexProxy{
+		server: &http.Server{Handler: handler, ReadHeaderTimeout: 30 * time.Second},
+		url:    "http://" + listener.Addr().String(),
+	}
+	go p.server.Serve(listener)
+	return p, nil
+}
+
+// close stops the proxy.
+func (p *vertexProxy) close() {
+	if p != nil {
+		p.server.Close()
+	}
+}
@@ -0,0 +1,210 @@
+package copilot
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVertexProvider(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GOOGLE_CLOUD_LOCATION", "")
+
+	t.Run("validates", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			provider *ProviderConfig
+			want     string
+		}{
+			{name: "project", provider: VertexProvider{Project: "p"}.Config()},
+			{name: "missing project", provider: VertexProvider{}.Config(), want: "Vertex.Project is required"},
+			{name: "APIKey", provider: &ProviderConfig{Type: "vertex", APIKey: "k", Vertex: &VertexProviderOptions{Project: "p"}}, want: "APIKey is not supported"},
+			{name: "responses", provider: &ProviderConfig{Type: "vertex", WireAPI: "responses", Vertex: &VertexProviderOptions{Project: "p"}}, want: `only WireAPI "completions"`},
+			{name: "invalid location", provider: VertexProvider{Project: "p", Location: "us central"}.Config(), want: "invalid Vertex.Location"},
+			{name: "invalid credentials", provider: VertexProvider{Project: "p", CredentialsJSON: []byte(`{"type":"external_account"}`)}.Config(), want: "unsupported Google credentials type"},
+			{name: "options without type", provider: &ProviderConfig{BaseURL: "https://x", Vertex: &VertexProviderOptions{}}, want: `require Type "vertex"`},
+		}
+		for _, tc := range cases {
+			err := tc.provider.Validate()
+			if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+				t.Errorf("%s: expected error %q, got %v", tc.name, tc.want, err)
+			}
+		}
+	})
+
+	t.Run("sends an OpenAI provider for a proxy to the Vertex AI endpoint", func(t *testing.T) {
+		for _, tc := range []struct {
+			options VertexProviderOptions
+			want    string
+		}{
+			{VertexProviderOptions{Project: "my-project", Location: "us-central1"}, "https://us-central1-aiplatform.googleapis.com/v1/projects/my-project/locations/us-central1/endpoints/openapi"},
+			{VertexProviderOptions{Project: "p"}, "https://aiplatform.googleapis.com/v1/projects/p/locations/global/endpoints/openapi"},
+		} {
+			if got, err := vertexBaseURL(&tc.options); err != nil || got != tc.want {
+				t.Errorf("expected endpoint %s, got %s, %v", tc.want, got, err)
+			}
+		}
+
+		client := NewClient(&ClientOptions{})
+		proxy, wire, err := client.vertexWireProvider(VertexProvider{Project: "my-project", Location: "us-central1"}.Config())
+		if err != nil {
+			t.Fatalf("vertexWireProvider failed: %v", err)
+		}
+		defer proxy.close()
+		if wire.Type != "openai" || wire.WireAPI != "completions" || wire.Vertex != nil || wire.BearerTokenProvider == nil || wire.BaseURL != proxy.url {
+			t.Errorf("unexpected wire provider %+v", wire)
+		}
+		proxy, wire, _ = client.vertexWireProvider(VertexProvider{Project: "p", BearerToken: "token"}.Config())
+		defer proxy.close()
+		if wire.BearerTokenProvider != nil || wire.BearerToken != "token" {
+			t.Errorf("unexpected wire provider %+v", wire)
+		}
+
+		remote := NewClient(&ClientOptions{Connection: URIConnection{URL: "runtime.internal:3000"}})
+		if _, _, err := remote.vertexWireProvider(VertexProvider{Project: "p"}.Config()); err == nil {
+			t.Error("expected Vertex AI to be rejected for a runtime reached over the network")
+		}
+	})
+
+	t.Run("names the model of each request", func(t *testing.T) {
+		requests := make(chan *http.Request, 4)
+		models := make(chan string, 4)
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Model string `json:"model"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests <- r
+			models <- body.Model
+			w.Write([]byte(`{}`))
+		}))
+		defer upstream.Close()
+		proxy, err := startVertexProxy(upstream.Client(), upstream.URL+"/v1/endpoints/openapi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer proxy.close()
+
+		for model, want := range map[string]string{
+			"gemini-2.5-pro":     "google/gemini-2.5-pro",
+			"gemini-2.5-flash":   "google/gemini-2.5-flash",
+			"meta/llama-3.3-70b": "meta/llama-3.3-70b",
+		} {
+			req, _ := http.NewRequest(http.MethodPost, proxy.url+"/chat/completions", strings.NewReader(`{"model":"`+model+`","stream":true}`))
+			req.Header.Set("Authorization", "Bearer access-token")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			r := <-requests
+			if got := <-models; got != want {
+				t.Errorf("expected %s to be sent as %s, got %s", model, want, got)
+			}
+			if r.URL.Path != "/v1/endpoints/openapi/chat/completions" || r.Header.Get("Authorization") != "Bearer access-token" {
+				t.Errorf("unexpected upstream request %s %v", r.URL.Path, r.Header)
+			}
+		}
+	})
+}
+
+func TestGoogleTokenSource(t *testing.T) {
+	t.Run("exchanges a service account assertion and caches the token", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+				t.Errorf("unexpected grant_type %q", r.Form.Get("grant_type"))
+			}
+			parts := strings.Split(r.Form.Get("assertion"), ".")
+			if len(parts) != 3 {
+				t.Fatalf("malformed assertion %q", r.Form.Get("assertion"))
+			}
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+				t.Errorf("invalid assertion signature: %v", err)
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			var c map[string]any
+			json.Unmarshal(claims, &c)
+			if c["iss"] != "sa@p.iam.gserviceaccount.com" || c["scope"] != googleCloudPlatformScope || !strings.HasPrefix(c["aud"].(string), "http://127.0.0.1") {
+				t.Errorf("unexpected claims %v", c)
+			}
+			w.Write([]byte(`{"access_token":"sa-token","expires_in":3600}`))
+		}))
+		defer server.Close()
+
+		credentialsJSON, _ := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "sa@p.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+			"token_uri":    server.URL,
+		})
+		credentials, err := parseGoogleCredentials(credentialsJSON)
+		if err != nil {
+			t.Fatalf("parseGoogleCredentials failed: %v", err)
+		}
+		source := newGoogleTokenSource(server.Client(), credentials)
+		for range 2 {
+			if token, err := source.Token(t.Context()); err != nil || token != "sa-token" {
+				t.Fatalf("unexpected token %q, %v", token, err)
+			}
+		}
+		if requests.Load() != 1 {
+			t.Errorf("expected the token to be cached, got %d requests", requests.Load())
+		}
+	})
+
+	t.Run("refreshes user credentials", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "rt" {
+				t.Errorf("unexpected form %v", r.Form)
+			}
+			w.Write([]byte(`{"access_token":"user-token","expires_in":3600}`))
+		}))
+		defer server.Close()
+
+		credentials, _ := parseGoogleCredentials([]byte(`{"type":"authorized_user","client_id":"id","client_secret":"secret","refresh_token":"rt"}`))
+		source := newGoogleTokenSource(server.Client(), credentials)
+		source.tokenURL = server.URL
+		if token, err := source.Token(t.Context()); err != nil || token != "user-token" {
+			t.Errorf("unexpected token %q, %v", token, err)
+		}
+	})
+
+	t.Run("falls back to the metadata server", func(t *testing.T) {
+		t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+		t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				t.Errorf("missing Metadata-Flavor header")
+			}
+			w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600}`))
+		}))
+		defer server.Close()
+
+		source := newGoogleTokenSource(server.Client(), nil)
+		source.metadataURL = server.URL
+		if token, err := source.Token(t.Context()); err != nil || token != "metadata-token" {
+			t.Errorf("unexpected token %q, %v", token, err)
+		}
+	})
+}
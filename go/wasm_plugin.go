@@ -0,0 +1,233 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// WASMPluginABIVersion is the plugin ABI version implemented by this SDK.
+const WASMPluginABIVersion = 1
+
+// WASMEngine instantiates WebAssembly modules for [LoadWASMPlugin]. The SDK
+// does not bundle a WebAssembly runtime; callers supply one, such as the
+// wazero-backed engine in the wasmwazero module, which keeps the sandboxing
+// policy in the host's hands. How bytes cross the module boundary, typically
+// through an exported allocator and (ptr, len) pairs, is the engine's
+// concern.
+type WASMEngine interface {
+	// Instantiate compiles and instantiates module in a fresh sandbox.
+	Instantiate(ctx context.Context, module []byte) (WASMInstance, error)
+}
+
+// WASMInstance is an instantiated plugin module. The SDK serializes calls to
+// an instance, so implementations need not be safe for concurrent use.
+type WASMInstance interface {
+	// Call invokes the named export with a JSON request and returns its JSON response.
+	Call(ctx context.Context, export string, request []byte) ([]byte, error)
+	// Close releases the instance.
+	Close(ctx context.Context) error
+}
+
+// WASMPlugin is a loaded plugin. Register its tools and hooks on a session via
+// [WASMPlugin.Tools] and [WASMPlugin.Hooks].
+type WASMPlugin struct {
+	instance WASMInstance
+	manifest wasmPluginManifest
+	mu       sync.Mutex
+}
+
+type wasmPluginManifest struct {
+	ABIVersion int      `json:"abiVersion"`
+	Name       string   `json:"name"`
+	Tools      []Tool   `json:"tools"`
+	Hooks      []string `json:"hooks"`
+}
+
+type wasmToolRequest struct {
+	SessionID  string `json:"sessionId"`
+	ToolCallID string `json:"toolCallId"`
+	ToolName   string `json:"toolName"`
+	Arguments  any    `json:"arguments"`
+}
+
+type wasmHookRequest struct {
+	HookType  string `json:"hookType"`
+	SessionID string `json:"sessionId"`
+	Input     any    `json:"input"`
+}
+
+// LoadWASMPlugin instantiates module with engine and reads its manifest.
+//
+// A plugin is a WASM module that provides tools and hooks through a small
+// JSON-over-exports ABI, so it can be written in any language that compiles
+// to WebAssembly and distributed separately from the host binary. The module
+// exports three functions, each taking a JSON request and returning a JSON
+// response:
+//
+//   - copilot_manifest: request is empty. Returns
+//     {"abiVersion": 1, "name": "...", "tools": [Tool...], "hooks": ["preToolUse", ...]}
+//     where each tool has the JSON shape of [Tool].
+//   - copilot_invoke_tool: request is
+//     {"sessionId", "toolCallId", "toolName", "arguments"}. Returns a [ToolResult].
+//   - copilot_invoke_hook: request is {"hookType", "sessionId", "input"}, where
+//     input has the JSON shape of the matching *HookInput type. Returns the
+//     matching *HookOutput, or null for no output.
+//
+// Example:
+//
+//	plugin, err := copilot.LoadWASMPlugin(ctx, engine, moduleBytes)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer plugin.Close(ctx)
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    Tools: plugin.Tools(),
+//	    Hooks: plugin.Hooks(),
+//	})
+func LoadWASMPlugin(ctx context.Context, engine WASMEngine, module []byte) (*WASMPlugin, error) {
+	instance, err := engine.Instantiate(ctx, module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate WASM plugin: %w", err)
+	}
+	p := &WASMPlugin{instance: instance}
+	raw, err := p.call(ctx, "copilot_manifest", nil)
+	if err != nil {
+		instance.Close(ctx)
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &p.manifest); err != nil {
+		instance.Close(ctx)
+		return nil, fmt.Errorf("failed to parse WASM plugin manifest: %w", err)
+	}
+	if p.manifest.ABIVersion != WASMPluginABIVersion {
+		instance.Close(ctx)
+		return nil, fmt.Errorf("WASM plugin %q uses ABI version %d, but this SDK supports version %d",
+			p.manifest.Name, p.manifest.ABIVersion, WASMPluginABIVersion)
+	}
+	for _, hookType := range p.manifest.Hooks {
+		if !isWASMHookType(hookType) {
+			instance.Close(ctx)
+			return nil, fmt.Errorf("WASM plugin %q declares unknown hook %q", p.manifest.Name, hookType)
+		}
+	}
+	return p, nil
+}
+
+// Name returns the plugin name from its manifest.
+func (p *WASMPlugin) Name() string {
+	return p.manifest.Name
+}
+
+// Tools returns the plugin's tools with handlers that invoke the module. The
+// module is called with the invocation's [ToolInvocation.TraceContext], so
+// cancelling the tool call reaches the engine.
+func (p *WASMPlugin) Tools() []Tool {
+	tools := make([]Tool, len(p.manifest.Tools))
+	for i, tool := range p.manifest.Tools {
+		tool.Handler = func(inv ToolInvocation) (ToolResult, error) {
+			ctx := inv.TraceContext
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			raw, err := p.call(ctx, "copilot_invoke_tool", wasmToolRequest{
+				SessionID:  inv.SessionID,
+				ToolCallID: inv.ToolCallID,
+				ToolName:   inv.ToolName,
+				Arguments:  inv.Arguments,
+			})
+			if err != nil {
+				return ToolResult{}, err
+			}
+			var result ToolResult
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return ToolResult{}, fmt.Errorf("invalid tool result from WASM plugin %q: %w", p.manifest.Name, err)
+			}
+			return result, nil
+		}
+		tools[i] = tool
+	}
+	return tools
+}
+
+// Hooks returns session hooks for the hook types declared in the plugin's
+// manifest. Hooks the plugin does not declare are left nil.
+func (p *WASMPlugin) Hooks() *SessionHooks {
+	hooks := &SessionHooks{}
+	for _, hookType := range p.manifest.Hooks {
+		switch hookType {
+		case "preToolUse":
+			hooks.OnPreToolUse = wasmHook[PreToolUseHookInput, PreToolUseHookOutput](p, hookType)
+		case "postToolUse":
+			hooks.OnPostToolUse = wasmHook[PostToolUseHookInput, PostToolUseHookOutput](p, hookType)
+		case "postToolUseFailure":
+			hooks.OnPostToolUseFailure = wasmHook[PostToolUseFailureHookInput, PostToolUseFailureHookOutput](p, hookType)
+		case "userPromptSubmitted":
+			hooks.OnUserPromptSubmitted = wasmHook[UserPromptSubmittedHookInput, UserPromptSubmittedHookOutput](p, hookType)
+		case "sessionStart":
+			hooks.OnSessionStart = wasmHook[SessionStartHookInput, SessionStartHookOutput](p, hookType)
+		case "sessionEnd":
+			hooks.OnSessionEnd = wasmHook[SessionEndHookInput, SessionEndHookOutput](p, hookType)
+		case "errorOccurred":
+			hooks.OnErrorOccurred = wasmHook[ErrorOccurredHookInput, ErrorOccurredHookOutput](p, hookType)
+		case "preMcpToolCall":
+			hooks.OnPreMCPToolCall = wasmHook[PreMCPToolCallHookInput, PreMCPToolCallHookOutput](p, hookType)
+		}
+	}
+	return hooks
+}
+
+// Close releases the plugin instance.
+func (p *WASMPlugin) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.instance.Close(ctx)
+}
+
+func (p *WASMPlugin) call(ctx context.Context, export string, request any) ([]byte, error) {
+	var payload []byte
+	if request != nil {
+		var err error
+		if payload, err = json.Marshal(request); err != nil {
+			return nil, err
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	raw, err := p.instance.Call(ctx, export, payload)
+	if err != nil {
+		return nil, fmt.Errorf("WASM plugin %q %s failed: %w", p.manifest.Name, export, err)
+	}
+	return raw, nil
+}
+
+func isWASMHookType(hookType string) bool {
+	switch hookType {
+	case "preToolUse", "postToolUse", "postToolUseFailure", "userPromptSubmitted",
+		"sessionStart", "sessionEnd", "errorOccurred", "preMcpToolCall":
+		return true
+	}
+	return false
+}
+
+func wasmHook[In, Out any](p *WASMPlugin, hookType string) func(In, HookInvocation) (*Out, error) {
+	return func(input In, invocation HookInvocation) (*Out, error) {
+		raw, err := p.call(context.Background(), "copilot_invoke_hook", wasmHookRequest{
+			HookType:  hookType,
+			SessionID: invocation.SessionID,
+			Input:     input,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			return nil, nil
+		}
+		var out Out
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, fmt.Errorf("invalid %s output from WASM plugin %q: %w", hookType, p.manifest.Name, err)
+		}
+		return &out, nil
+	}
+}
@@ -0,0 +1,135 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeWASMEngine stands in for a real WebAssembly runtime by dispatching
+// exports to Go functions.
+type fakeWASMEngine struct {
+	exports map[string]func(request []byte) ([]byte, error)
+	closed  bool
+	// ctx is the context of the last call.
+	ctx context.Context
+}
+
+func (e *fakeWASMEngine) Instantiate(context.Context, []byte) (WASMInstance, error) {
+	return e, nil
+}
+
+func (e *fakeWASMEngine) Call(ctx context.Context, export string, request []byte) ([]byte, error) {
+	e.ctx = ctx
+	fn, ok := e.exports[export]
+	if !ok {
+		return nil, errors.New("missing export " + export)
+	}
+	return fn(request)
+}
+
+func (e *fakeWASMEngine) Close(context.Context) error {
+	e.closed = true
+	return nil
+}
+
+func newFakePluginEngine(manifest string) *fakeWASMEngine {
+	return &fakeWASMEngine{exports: map[string]func([]byte) ([]byte, error){
+		"copilot_manifest": func([]byte) ([]byte, error) { return []byte(manifest), nil },
+		"copilot_invoke_tool": func(request []byte) ([]byte, error) {
+			var req wasmToolRequest
+			if err := json.Unmarshal(request, &req); err != nil {
+				return nil, err
+			}
+			args := req.Arguments.(map[string]any)
+			return json.Marshal(ToolResult{TextResultForLLM: "hello " + args["name"].(string), ResultType: "success"})
+		},
+		"copilot_invoke_hook": func(request []byte) ([]byte, error) {
+			var req struct {
+				HookType string              `json:"hookType"`
+				Input    PreToolUseHookInput `json:"input"`
+			}
+			if err := json.Unmarshal(request, &req); err != nil {
+				return nil, err
+			}
+			if req.Input.ToolName == "bash" {
+				return []byte(`{"permissionDecision":"deny"}`), nil
+			}
+			return []byte("null"), nil
+		},
+	}}
+}
+
+func TestLoadWASMPlugin(t *testing.T) {
+	const manifest = `{
+		"abiVersion": 1,
+		"name": "greeter",
+		"tools": [{"name": "greet", "description": "Greets", "parameters": {"type": "object"}}],
+		"hooks": ["preToolUse"]
+	}`
+
+	t.Run("exposes tools and hooks backed by the module", func(t *testing.T) {
+		engine := newFakePluginEngine(manifest)
+		plugin, err := LoadWASMPlugin(t.Context(), engine, nil)
+		if err != nil {
+			t.Fatalf("LoadWASMPlugin failed: %v", err)
+		}
+		if plugin.Name() != "greeter" {
+			t.Errorf("expected name greeter, got %q", plugin.Name())
+		}
+
+		tools := plugin.Tools()
+		if len(tools) != 1 || tools[0].Name != "greet" || tools[0].Handler == nil {
+			t.Fatalf("unexpected tools: %+v", tools)
+		}
+		type key struct{}
+		ctx := context.WithValue(t.Context(), key{}, "call-1")
+		result, err := tools[0].Handler(ToolInvocation{ToolName: "greet", Arguments: map[string]any{"name": "Ada"}, TraceContext: ctx})
+		if err != nil {
+			t.Fatalf("tool handler failed: %v", err)
+		}
+		if result.TextResultForLLM != "hello Ada" {
+			t.Errorf("unexpected tool result: %+v", result)
+		}
+		if engine.ctx.Value(key{}) != "call-1" {
+			t.Error("expected the module to be called with the invocation's context")
+		}
+
+		hooks := plugin.Hooks()
+		if hooks.OnPostToolUse != nil {
+			t.Error("expected undeclared hooks to be nil")
+		}
+		out, err := hooks.OnPreToolUse(PreToolUseHookInput{ToolName: "bash"}, HookInvocation{SessionID: "s1"})
+		if err != nil || out == nil || out.PermissionDecision != "deny" {
+			t.Errorf("expected deny from plugin hook, got %+v (err %v)", out, err)
+		}
+		out, err = hooks.OnPreToolUse(PreToolUseHookInput{ToolName: "view"}, HookInvocation{})
+		if err != nil || out != nil {
+			t.Errorf("expected nil output for null response, got %+v (err %v)", out, err)
+		}
+
+		if err := plugin.Close(t.Context()); err != nil || !engine.closed {
+			t.Errorf("expected Close to close the instance (err %v)", err)
+		}
+	})
+
+	t.Run("rejects an unsupported ABI version", func(t *testing.T) {
+		engine := newFakePluginEngine(`{"abiVersion": 99, "name": "future"}`)
+		_, err := LoadWASMPlugin(t.Context(), engine, nil)
+		if err == nil || !strings.Contains(err.Error(), "ABI version 99") {
+			t.Fatalf("expected ABI version error, got %v", err)
+		}
+		if !engine.closed {
+			t.Error("expected the instance to be closed after a failed load")
+		}
+	})
+
+	t.Run("rejects unknown hook types", func(t *testing.T) {
+		engine := newFakePluginEngine(`{"abiVersion": 1, "name": "x", "hooks": ["onEverything"]}`)
+		if _, err := LoadWASMPlugin(t.Context(), engine, nil); err == nil {
+			t.Fatal("expected error for unknown hook type")
+		}
+	})
+}
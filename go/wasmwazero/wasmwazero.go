@@ -0,0 +1,161 @@
+// Package wasmwazero runs WebAssembly plugins for copilot.LoadWASMPlugin
+// with wazero, a WebAssembly runtime written in Go without cgo.
+//
+// Besides the plugin ABI exports, a module exports its memory as "memory"
+// and an allocator, copilot_alloc(size i32) -> ptr i32, which the engine
+// calls to place each request in the module's memory. Each ABI export takes
+// the request as (ptr i32, len i32) and returns its response as an i64
+// holding the pointer in the high 32 bits and the length in the low 32
+// bits. If the module also exports copilot_free(ptr i32, len i32), the
+// engine calls it to release the request and the response once it has read
+// the response.
+//
+// Example:
+//
+//	engine, err := wasmwazero.New(ctx, nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer engine.Close(ctx)
+//	plugin, err := copilot.LoadWASMPlugin(ctx, engine, moduleBytes)
+package wasmwazero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Options configures an [Engine].
+type Options struct {
+	// ModuleConfig configures the sandbox of each plugin instance: its
+	// filesystem, environment, clocks and standard streams. When nil, a
+	// plugin gets none of them, and its "_initialize" function, if any, is
+	// run when it is instantiated, as for a WASI reactor.
+	ModuleConfig wazero.ModuleConfig
+	// WASI provides the WASI preview 1 host functions, for plugins built by
+	// toolchains that import them, such as Rust's wasm32-wasip1 target or
+	// TinyGo. They reach only what ModuleConfig grants.
+	WASI bool
+	// MemoryLimitPages caps the memory of each instance, in 64 KiB pages.
+	// When zero, wazero's limit of 65536 pages (4 GiB) applies.
+	MemoryLimitPages uint32
+}
+
+// Engine is a [copilot.WASMEngine] backed by a wazero runtime. Plugins
+// instantiated by the same engine share compiled code but not memory.
+type Engine struct {
+	runtime wazero.Runtime
+	config  wazero.ModuleConfig
+}
+
+// New creates an engine. Calls into a plugin stop when their context is
+// done, so cancelling a tool call stops the plugin. Call [Engine.Close] to
+// release the engine and every plugin it instantiated.
+func New(ctx context.Context, options *Options) (*Engine, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if options.MemoryLimitPages > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(options.MemoryLimitPages)
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	if options.WASI {
+		if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+			runtime.Close(ctx)
+			return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+		}
+	}
+	config := options.ModuleConfig
+	if config == nil {
+		config = wazero.NewModuleConfig().WithStartFunctions("_initialize")
+	}
+	return &Engine{runtime: runtime, config: config}, nil
+}
+
+// Instantiate implements [copilot.WASMEngine].
+func (e *Engine) Instantiate(ctx context.Context, module []byte) (copilot.WASMInstance, error) {
+	compiled, err := e.runtime.CompileModule(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	// Instances are anonymous, so a plugin can be loaded more than once.
+	instance, err := e.runtime.InstantiateModule(ctx, compiled, e.config.WithName(""))
+	if err != nil {
+		compiled.Close(ctx)
+		return nil, err
+	}
+	i := &pluginInstance{module: instance, compiled: compiled, alloc: instance.ExportedFunction("copilot_alloc"), free: instance.ExportedFunction("copilot_free")}
+	if instance.Memory() == nil || i.alloc == nil {
+		i.Close(ctx)
+		return nil, errors.New("WASM plugin must export its memory and copilot_alloc")
+	}
+	return i, nil
+}
+
+// Close releases the engine and every plugin it instantiated.
+func (e *Engine) Close(ctx context.Context) error {
+	return e.runtime.Close(ctx)
+}
+
+// pluginInstance is an instantiated plugin module.
+type pluginInstance struct {
+	module   api.Module
+	compiled wazero.CompiledModule
+	alloc    api.Function
+	free     api.Function
+}
+
+// Call implements [copilot.WASMInstance].
+func (i *pluginInstance) Call(ctx context.Context, export string, request []byte) ([]byte, error) {
+	fn := i.module.ExportedFunction(export)
+	if fn == nil {
+		return nil, fmt.Errorf("module does not export %s", export)
+	}
+	var ptr uint32
+	if len(request) > 0 {
+		results, err := i.alloc.Call(ctx, uint64(len(request)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate the request: %w", err)
+		}
+		ptr = uint32(results[0])
+		if !i.module.Memory().Write(ptr, request) {
+			return nil, fmt.Errorf("copilot_alloc returned %d, outside the module's memory", ptr)
+		}
+		defer i.release(ctx, ptr, uint32(len(request)))
+	}
+	results, err := fn.Call(ctx, uint64(ptr), uint64(len(request)))
+	if err != nil {
+		return nil, err
+	}
+	responsePtr, responseLen := uint32(results[0]>>32), uint32(results[0])
+	if responseLen == 0 {
+		return nil, nil
+	}
+	response, ok := i.module.Memory().Read(responsePtr, responseLen)
+	if !ok {
+		return nil, fmt.Errorf("%s returned %d bytes at %d, outside the module's memory", export, responseLen, responsePtr)
+	}
+	// The view is only valid until the module's memory changes.
+	response = append([]byte(nil), response...)
+	i.release(ctx, responsePtr, responseLen)
+	return response, nil
+}
+
+// release passes memory back to the module's copilot_free, if it has one.
+func (i *pluginInstance) release(ctx context.Context, ptr, size uint32) {
+	if i.free != nil {
+		i.free.Call(ctx, uint64(ptr), uint64(size))
+	}
+}
+
+// Close implements [copilot.WASMInstance].
+func (i *pluginInstance) Close(ctx context.Context) error {
+	return errors.Join(i.module.Close(ctx), i.compiled.Close(ctx))
+}
@@ -0,0 +1,186 @@
+package wasmwazero
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+const (
+	testManifest   = `{"abiVersion":1,"name":"greeter","tools":[{"name":"greet","description":"Says hello"}],"hooks":[]}`
+	testToolResult = `{"textResultForLlm":"hello from wasm","resultType":"success"}`
+	// testToolResultAt is where the tool result is stored in the module's
+	// memory; requests are allocated from 1024 up.
+	testToolResultAt = 256
+)
+
+// testModule assembles a plugin module by hand, so the tests need no
+// WebAssembly toolchain. It exports memory, a bump allocator, a
+// copilot_manifest and copilot_invoke_tool returning constants, an echo
+// function returning its request, and a spin function that never returns.
+func testModule() []byte {
+	packed := func(ptr, size int) int64 { return int64(ptr)<<32 | int64(size) }
+	constant := func(value int64) []byte {
+		return append(append([]byte{0x00, 0x42}, sleb(value)...), 0x0b)
+	}
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, vec(
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},       // (i32) -> i32
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e}, // (i32, i32) -> i64
+		[]byte{0x60, 0x00, 0x00},                   // () -> ()
+	))...)
+	module = append(module, section(3, vec([]byte{0}, []byte{1}, []byte{1}, []byte{1}, []byte{2}))...)
+	module = append(module, section(5, vec([]byte{0x00, 0x01}))...)
+	module = append(module, section(6, vec(append(append([]byte{0x7f, 0x01, 0x41}, sleb(1024)...), 0x0b)))...)
+	module = append(module, section(7, vec(
+		export("memory", 2, 0),
+		export("copilot_alloc", 0, 0),
+		export("copilot_manifest", 0, 1),
+		export("copilot_invoke_tool", 0, 2),
+		export("echo", 0, 3),
+		export("spin", 0, 4),
+	))...)
+	module = append(module, section(10, vec(
+		// global.get 0; global.get 0; local.get 0; i32.add; global.set 0
+		body([]byte{0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}),
+		body(constant(packed(0, len(testManifest)))),
+		body(constant(packed(testToolResultAt, len(testToolResult)))),
+		// (i64(ptr) << 32) | i64(len)
+		body([]byte{0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b}),
+		// loop; br 0; end
+		body([]byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}),
+	))...)
+	module = append(module, section(11, vec(
+		data(0, testManifest),
+		data(testToolResultAt, testToolResult),
+	))...)
+	return module
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+func export(name string, kind, index byte) []byte {
+	return append(append(uleb(uint64(len(name))), name...), kind, index)
+}
+
+func body(code []byte) []byte {
+	return append(uleb(uint64(len(code))), code...)
+}
+
+func data(offset int, content string) []byte {
+	out := append(append([]byte{0x00, 0x41}, sleb(int64(offset))...), 0x0b)
+	return append(append(out, uleb(uint64(len(content)))...), content...)
+}
+
+func TestEngine(t *testing.T) {
+	engine, err := New(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(context.Background()) })
+
+	t.Run("loads a plugin and calls its tools", func(t *testing.T) {
+		plugin, err := copilot.LoadWASMPlugin(t.Context(), engine, testModule())
+		if err != nil {
+			t.Fatalf("LoadWASMPlugin failed: %v", err)
+		}
+		defer plugin.Close(t.Context())
+		tools := plugin.Tools()
+		if plugin.Name() != "greeter" || len(tools) != 1 || tools[0].Name != "greet" {
+			t.Fatalf("unexpected plugin %q with tools %+v", plugin.Name(), tools)
+		}
+		result, err := tools[0].Handler(copilot.ToolInvocation{ToolName: "greet", Arguments: map[string]any{"name": "Mona"}})
+		if err != nil {
+			t.Fatalf("tool call failed: %v", err)
+		}
+		if result.TextResultForLLM != "hello from wasm" || result.ResultType != "success" {
+			t.Errorf("unexpected result %+v", result)
+		}
+	})
+
+	t.Run("passes requests through the module's memory", func(t *testing.T) {
+		instance, err := engine.Instantiate(t.Context(), testModule())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer instance.Close(t.Context())
+		for _, request := range []string{`{"first":1}`, `{"second":2}`} {
+			response, err := instance.Call(t.Context(), "echo", []byte(request))
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			if string(response) != request {
+				t.Errorf("expected %s echoed, got %s", request, response)
+			}
+		}
+		if _, err := instance.Call(t.Context(), "copilot_missing", nil); err == nil {
+			t.Error("expected an error for a missing export")
+		}
+	})
+
+	t.Run("stops a call when its context is done", func(t *testing.T) {
+		instance, err := engine.Instantiate(t.Context(), testModule())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer instance.Close(t.Context())
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			_, err := instance.Call(ctx, "spin", nil)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Error("expected the call to fail")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the call did not stop")
+		}
+	})
+
+	t.Run("rejects modules without an allocator", func(t *testing.T) {
+		empty := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+		if _, err := engine.Instantiate(t.Context(), empty); err == nil {
+			t.Error("expected an error for a module without copilot_alloc")
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// WorkspaceFolder is one root of a multi-root workspace, as editors open
+// several repositories side by side as the folders of one workspace. See
+// [SessionConfig.WorkspaceFolders].
+type WorkspaceFolder struct {
+	// Name is the base name of the folder, which the model uses to refer to
+	// it.
+	Name string
+	// Path is the absolute path of the folder, with symbolic links
+	// resolved.
+	Path string
+}
+
+// workspaceFolders holds the folders of a session's workspace.
+type workspaceFolders struct {
+	// dir is the session's working directory: the configured one, or else
+	// the first folder.
+	dir     string
+	folders []WorkspaceFolder
+}
+
+// newWorkspaceFolders resolves folders, relative to workingDirectory. It returns
+// nil when there are none.
+func newWorkspaceFolders(folders []string, workingDirectory string) (*workspaceFolders, error) {
+	if len(folders) == 0 {
+		return nil, nil
+	}
+	resolver := &pathGuard{dir: workingDirectory}
+	w := &workspaceFolders{}
+	if workingDirectory != "" {
+		dir, err := resolver.resolve(workingDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WorkingDirectory %q: %w", workingDirectory, err)
+		}
+		w.dir = dir
+	}
+	names := make(map[string]string, len(folders))
+	for _, folder := range folders {
+		if folder == "" {
+			return nil, errors.New("invalid WorkspaceFolders: empty path")
+		}
+		path, err := resolver.resolve(folder)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WorkspaceFolders path %q: %w", folder, err)
+		}
+		name := filepath.Base(path)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("invalid WorkspaceFolders: %q and %q have the same name %q", other, folder, name)
+		}
+		names[name] = folder
+		w.folders = append(w.folders, WorkspaceFolder{Name: name, Path: path})
+	}
+	if w.dir == "" {
+		w.dir = w.folders[0].Path
+	}
+	return w, nil
+}
+
+// workingDirectory returns the session's working directory given the
+// configured one.
+func (w *workspaceFolders) workingDirectory(configured string) string {
+	if w == nil || configured != "" {
+		return configured
+	}
+	return w.dir
+}
+
+// folderOf returns the folder containing path, or nil.
+func (w *workspaceFolders) folderOf(path string) *WorkspaceFolder {
+	if w == nil || path == "" {
+		return nil
+	}
+	resolved, err := (&pathGuard{dir: w.dir}).resolve(path)
+	if err != nil {
+		return nil
+	}
+	for i := range w.folders {
+		if withinPath(w.folders[i].Path, resolved) {
+			folder := w.folders[i]
+			return &folder
+		}
+	}
+	return nil
+}
+
+// requestFolder returns the folder containing every path a permission
+// request names, or nil if they are not all in one folder.
+func (w *workspaceFolders) requestFolder(request PermissionRequest) *WorkspaceFolder {
+	var paths []string
+	switch r := request.(type) {
+	case *PermissionRequestRead:
+		paths = []string{r.Path}
+	case *PermissionRequestWrite:
+		paths = []string{r.FileName}
+	case *PermissionRequestShell:
+		paths = r.PossiblePaths
+	}
+	var folder *WorkspaceFolder
+	for _, path := range paths {
+		f := w.folderOf(path)
+		if f == nil || (folder != nil && *f != *folder) {
+			return nil
+		}
+		folder = f
+	}
+	return folder
+}
+
+// resolvePath resolves a path the model wrote: absolute paths are kept,
+// "name/..." is taken to be in the folder with that name, and other relative
+// paths are in the working directory, dir when there are no folders.
+func (w *workspaceFolders) resolvePath(path, dir string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if w == nil {
+		if dir == "" {
+			if abs, err := filepath.Abs(path); err == nil {
+				return abs
+			}
+		}
+		return filepath.Join(dir, path)
+	}
+	first, rest, _ := strings.Cut(path, string(filepath.Separator))
+	for _, folder := range w.folders {
+		if folder.Name == first {
+			return filepath.Join(folder.Path, rest)
+		}
+	}
+	return filepath.Join(w.dir, path)
+}
+
+// allow adds the folders other than the working directory to the runtime's
+// allow-list, so its tools can use them without asking. With a path policy
+// the folders are left off it: the runtime does not ask about allowed paths,
+// so the policy could not check requests inside them.
+func (w *workspaceFolders) allow(ctx context.Context, session *Session) error {
+	if w == nil || session.paths != nil {
+		return nil
+	}
+	for _, folder := range w.folders {
+		if withinPath(folder.Path, w.dir) {
+			continue
+		}
+		if _, err := session.conn.Load().rpc.Permissions.Paths().Add(ctx, &rpc.PermissionPathsAddParams{Path: folder.Path}); err != nil {
+			return fmt.Errorf("failed to add workspace folder %s: %w", folder.Path, err)
+		}
+	}
+	return nil
+}
+
+// context returns the workspace summary added to prompts.
+func (w *workspaceFolders) context() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The workspace has %d folders. Relative paths resolve against %s; write paths in other folders as absolute paths or starting with the folder's name.", len(w.folders), w.dir)
+	for _, folder := range w.folders {
+		fmt.Fprintf(&b, "\n- %s: %s", folder.Name, folder.Path)
+		if head := gitHead(folder.Path); head != "" {
+			fmt.Fprintf(&b, " (git %s)", head)
+		}
+	}
+	return b.String()
+}
+
+// gitHead describes the checked-out branch or commit of the git repository
+// at root, or returns "" if root is not one.
+func gitHead(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	if data, err := os.ReadFile(gitDir); err == nil {
+		// A worktree or submodule: .git is a file pointing to the git dir.
+		dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return ""
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		gitDir = dir
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	if branch, ok := strings.CutPrefix(head, "ref: refs/heads/"); ok {
+		return "branch " + branch
+	}
+	if len(head) >= 12 && !strings.HasPrefix(head, "ref:") {
+		return "detached at " + head[:12]
+	}
+	return ""
+}
+
+// WorkspaceFolders returns the folders of the session's workspace (see
+// [SessionConfig.WorkspaceFolders]), or nil.
+func (s *Session) WorkspaceFolders() []WorkspaceFolder {
+	if s.folders == nil {
+		return nil
+	}
+	return append([]WorkspaceFolder(nil), s.folders.folders...)
+}
+
+// ResolvePath resolves a path from the tool's arguments to an absolute path.
+// Relative paths resolve against the session's working directory, except
+// that in a session with [SessionConfig.WorkspaceFolders] a path starting
+// with a folder's name ("api/main.go") resolves in that folder.
+//
+// Example:
+//
+//	copilot.DefineTool("lint", "Lint a file",
+//	    func(params LintParams, inv copilot.ToolInvocation) (string, error) {
+//	        return lint(inv.ResolvePath(params.File))
+//	    })
+func (inv ToolInvocation) ResolvePath(path string) string {
+	scope := inv.sessionScope()
+	return scope.folders.resolvePath(path, scope.workingDirectory)
+}
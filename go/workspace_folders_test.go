@@ -0,0 +1,149 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestWorkspaceFolders(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, web := filepath.Join(root, "api"), filepath.Join(root, "web")
+	for _, dir := range []string{filepath.Join(api, ".git"), web} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(api, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("resolves folders and paths the model writes", func(t *testing.T) {
+		folders, err := newWorkspaceFolders([]string{"api", web}, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []WorkspaceFolder{{"api", api}, {"web", web}}; !slices.Equal(folders.folders, want) {
+			t.Errorf("expected folders %v, got %v", want, folders.folders)
+		}
+		if got := folders.workingDirectory(root); got != root {
+			t.Errorf("expected the configured working directory, got %q", got)
+		}
+		for path, want := range map[string]string{
+			"web/src/app.ts": filepath.Join(web, "src", "app.ts"),
+			"README.md":      filepath.Join(root, "README.md"),
+			"/etc/hosts":     "/etc/hosts",
+		} {
+			if got := folders.resolvePath(path, ""); got != want {
+				t.Errorf("resolvePath(%q) = %q, want %q", path, got, want)
+			}
+		}
+
+		if _, err := newWorkspaceFolders([]string{api, filepath.Join(root, "other", "api")}, ""); err == nil {
+			t.Error("expected folders with the same name to be rejected")
+		}
+	})
+
+	t.Run("scopes permission requests to a folder", func(t *testing.T) {
+		folders, err := newWorkspaceFolders([]string{api, web}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if folders.dir != api {
+			t.Errorf("expected the first folder to be the working directory, got %q", folders.dir)
+		}
+		if f := folders.requestFolder(&PermissionRequestRead{Path: filepath.Join(web, "index.html")}); f == nil || f.Name != "web" {
+			t.Errorf("expected a read in web to be scoped to it, got %v", f)
+		}
+		if f := folders.requestFolder(&PermissionRequestWrite{FileName: "main.go"}); f == nil || f.Name != "api" {
+			t.Errorf("expected a relative write to be scoped to the working directory, got %v", f)
+		}
+		shell := &PermissionRequestShell{PossiblePaths: []string{filepath.Join(api, "go.mod"), filepath.Join(web, "package.json")}}
+		if f := folders.requestFolder(shell); f != nil {
+			t.Errorf("expected a command spanning folders to be unscoped, got %v", f)
+		}
+	})
+
+	t.Run("creates the session in the first folder and allows the others", func(t *testing.T) {
+		var mu sync.Mutex
+		var cwd string
+		var added []string
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct {
+					WorkingDirectory string `json:"workingDirectory"`
+				}
+				json.Unmarshal(params, &req)
+				mu.Lock()
+				cwd = req.WorkingDirectory
+				mu.Unlock()
+				return sessionIDResult(params), nil
+			},
+			"session.permissions.paths.add": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				var req struct {
+					Path string `json:"path"`
+				}
+				json.Unmarshal(params, &req)
+				mu.Lock()
+				added = append(added, req.Path)
+				mu.Unlock()
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			WorkspaceFolders:    []string{api, web},
+		})
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if cwd != api || !slices.Equal(added, []string{web}) {
+			t.Errorf("expected cwd %q and %q allowed, got cwd %q and %v", api, web, cwd, added)
+		}
+		if len(session.WorkspaceFolders()) != 2 {
+			t.Errorf("expected 2 folders, got %v", session.WorkspaceFolders())
+		}
+		hook := session.addPromptContext(nil, "hello")
+		want := "- api: " + api + " (git branch main)\n- web: " + web
+		if hook == nil || !strings.Contains(hook.AdditionalContext, want) {
+			t.Errorf("expected the folder summary %q in the prompt context, got %+v", want, hook)
+		}
+	})
+
+	t.Run("leaves the folders off the runtime's allow-list under a path policy", func(t *testing.T) {
+		added := make(chan string, 2)
+		client := startTestRuntime(t, map[string]jsonrpc2.RequestHandler{
+			"session.create": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				return sessionIDResult(params), nil
+			},
+			"session.permissions.paths.add": func(params json.RawMessage) (json.RawMessage, *jsonrpc2.Error) {
+				added <- string(params)
+				return []byte(`{"success":true}`), nil
+			},
+		})
+		if _, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			WorkspaceFolders:    []string{api, web},
+			DeniedPaths:         []string{filepath.Join(web, ".env")},
+		}); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		select {
+		case params := <-added:
+			t.Errorf("expected no folders to be allowed, got %s", params)
+		default:
+		}
+	})
+}